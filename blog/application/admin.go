@@ -0,0 +1,75 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ErrResyncInProgress is returned when a resync is requested while one is still running
+var ErrResyncInProgress = errors.New("resync already in progress")
+
+// PublishPost publishes a post immediately, regardless of its branch or schedule
+func (s *PostService) PublishPost(ctx context.Context, id string) error {
+	if _, err := s.repo.GetPost(ctx, id); err != nil {
+		return err
+	}
+	return s.repo.Publish(ctx, id)
+}
+
+// UnpublishPost takes a post offline without deleting it
+func (s *PostService) UnpublishPost(ctx context.Context, id string) error {
+	if _, err := s.repo.GetPost(ctx, id); err != nil {
+		return err
+	}
+	return s.repo.Unpublish(ctx, id)
+}
+
+// DeletePost removes a post and its rendered HTML
+// The post comes back if its markdown is changed again or the repository is resynced.
+func (s *PostService) DeletePost(ctx context.Context, id string) error {
+	return s.repo.DeletePost(ctx, id)
+}
+
+// StartResync re-processes the full history of every branch in the background
+// Only one resync runs at a time; ErrResyncInProgress is returned while one is running.
+func (s *PostService) StartResync() error {
+	if !s.resyncing.CompareAndSwap(false, true) {
+		return ErrResyncInProgress
+	}
+
+	s.wg.Go(func() {
+		defer s.resyncing.Store(false)
+
+		start := time.Now()
+		if err := s.resync(); err != nil {
+			log.Error().Err(err).Msg("Resync failed")
+			return
+		}
+		log.Info().Dur("duration", time.Since(start)).Msg("Resync completed")
+	})
+
+	return nil
+}
+
+// resync processes every commit on every branch, rather than only those since the last update
+// Like a sync, it runs as a single job on the dispatch queue.
+func (s *PostService) resync() error {
+	return s.dispatchWait(s.resyncBranches)
+}
+
+func (s *PostService) resyncBranches() error {
+	branches, err := s.sourceRepo.ListBranches(s.ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve branches: %w", err)
+	}
+
+	if err := s.processBranches(time.Time{}, branches); err != nil {
+		return fmt.Errorf("failed to process branches: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/google/go-github/v75/github"
+)
+
+func TestPostService_PublishAndUnpublishPost(t *testing.T) {
+	repo := newFakePostRepository(&domain.Post{ID: "001"})
+	service := NewPostService(repo, newFakeImageRepository(), nil, NewMarkdownRenderer(), "main")
+	defer service.Close()
+	ctx := context.Background()
+
+	if err := service.PublishPost(ctx, "001"); err != nil {
+		t.Fatalf("PublishPost() error = %v", err)
+	}
+	if repo.posts["001"].PublishedAt.IsZero() {
+		t.Error("post was not published")
+	}
+
+	if err := service.UnpublishPost(ctx, "001"); err != nil {
+		t.Fatalf("UnpublishPost() error = %v", err)
+	}
+	if !repo.posts["001"].PublishedAt.IsZero() {
+		t.Error("post was not unpublished")
+	}
+
+	if err := service.PublishPost(ctx, "missing"); !errors.Is(err, domain.ErrPostNotFound) {
+		t.Errorf("PublishPost() of a missing post error = %v, want ErrPostNotFound", err)
+	}
+	if err := service.UnpublishPost(ctx, "missing"); !errors.Is(err, domain.ErrPostNotFound) {
+		t.Errorf("UnpublishPost() of a missing post error = %v, want ErrPostNotFound", err)
+	}
+}
+
+func TestPostService_DeletePost(t *testing.T) {
+	repo := newFakePostRepository(&domain.Post{ID: "001"})
+	service := NewPostService(repo, newFakeImageRepository(), nil, NewMarkdownRenderer(), "main")
+	defer service.Close()
+	ctx := context.Background()
+
+	if err := service.DeletePost(ctx, "001"); err != nil {
+		t.Fatalf("DeletePost() error = %v", err)
+	}
+	if _, ok := repo.posts["001"]; ok {
+		t.Error("post was not deleted")
+	}
+	if err := service.DeletePost(ctx, "001"); !errors.Is(err, domain.ErrPostNotFound) {
+		t.Errorf("DeletePost() of a missing post error = %v, want ErrPostNotFound", err)
+	}
+}
+
+func TestPostService_StartResync(t *testing.T) {
+	source := newFakeSourceRepository()
+	repo := newFakePostRepository()
+	service := NewPostService(repo, newFakeImageRepository(), source, NewMarkdownRenderer(), "main")
+
+	source.branches = []*github.Branch{{Name: github.Ptr("main")}}
+	source.commits["a"] = testCommit("a", "posts/001-resync.md")
+	source.files["posts/001-resync.md"] = []byte("# Resync\n\nBody")
+
+	// Hold the resync flag so a second request is rejected deterministically
+	service.resyncing.Store(true)
+	if err := service.StartResync(); !errors.Is(err, ErrResyncInProgress) {
+		t.Errorf("StartResync() while running error = %v, want ErrResyncInProgress", err)
+	}
+	service.resyncing.Store(false)
+
+	if err := service.StartResync(); err != nil {
+		t.Fatalf("StartResync() error = %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for service.resyncing.Load() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	service.Close()
+
+	if _, ok := repo.posts["001"]; !ok {
+		t.Error("resync did not process the post")
+	}
+}
@@ -0,0 +1,54 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+)
+
+// WithArchiveTimezone sets the time zone posts are sorted into archive months in, defaulting to UTC
+// It should match the zone the theme shows dates in, or posts published near midnight land in the wrong month.
+func WithArchiveTimezone(loc *time.Location) PostServiceOption {
+	return func(s *PostService) {
+		s.archiveLocation = loc
+	}
+}
+
+// ArchiveMonths counts the published posts in each month that has any, newest first
+func (s *PostService) ArchiveMonths(ctx context.Context) ([]domain.ArchiveMonth, error) {
+	times, err := s.repo.ListPublishTimes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Times are newest first, so each month's posts are next to each other
+	var months []domain.ArchiveMonth
+	for _, publishedAt := range times {
+		local := publishedAt.In(s.archiveTimezone())
+		if n := len(months); n > 0 && months[n-1].Year == local.Year() && months[n-1].Month == local.Month() {
+			months[n-1].Posts++
+			continue
+		}
+		months = append(months, domain.ArchiveMonth{Year: local.Year(), Month: local.Month(), Posts: 1})
+	}
+	return months, nil
+}
+
+// ArchivePosts returns the posts published in a month, newest first
+func (s *PostService) ArchivePosts(ctx context.Context, year int, month time.Month) ([]*domain.Post, error) {
+	if month < time.January || month > time.December {
+		return nil, fmt.Errorf("invalid month %d", month)
+	}
+
+	start := time.Date(year, month, 1, 0, 0, 0, 0, s.archiveTimezone())
+	return s.repo.ListPublishedPostsBetween(ctx, start, start.AddDate(0, 1, 0))
+}
+
+func (s *PostService) archiveTimezone() *time.Location {
+	if s.archiveLocation == nil {
+		return time.UTC
+	}
+	return s.archiveLocation
+}
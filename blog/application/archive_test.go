@@ -0,0 +1,48 @@
+package application
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+)
+
+func TestPostService_Archive(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+
+	repo := newFakePostRepository(
+		&domain.Post{ID: "001", PublishedAt: time.Date(2024, time.May, 10, 0, 0, 0, 0, time.UTC)},
+		// Still May in UTC, but already June in Tokyo
+		&domain.Post{ID: "002", PublishedAt: time.Date(2024, time.May, 31, 20, 0, 0, 0, time.UTC)},
+		&domain.Post{ID: "003", PublishedAt: time.Date(2024, time.June, 15, 0, 0, 0, 0, time.UTC)},
+		&domain.Post{ID: "004"},
+	)
+	service := NewPostService(repo, newFakeImageRepository(), nil, NewMarkdownRenderer(), "main", WithArchiveTimezone(tokyo))
+	defer service.Close()
+	ctx := context.Background()
+
+	months, err := service.ArchiveMonths(ctx)
+	if err != nil {
+		t.Fatalf("ArchiveMonths() error = %v", err)
+	}
+	want := []domain.ArchiveMonth{{Year: 2024, Month: time.June, Posts: 2}, {Year: 2024, Month: time.May, Posts: 1}}
+	if len(months) != len(want) || months[0] != want[0] || months[1] != want[1] {
+		t.Errorf("ArchiveMonths() = %v, want %v", months, want)
+	}
+
+	posts, err := service.ArchivePosts(ctx, 2024, time.June)
+	if err != nil {
+		t.Fatalf("ArchivePosts() error = %v", err)
+	}
+	if len(posts) != 2 || posts[0].ID != "003" || posts[1].ID != "002" {
+		t.Errorf("ArchivePosts() = %v, want 003 then 002", posts)
+	}
+
+	if _, err := service.ArchivePosts(ctx, 2024, 13); err == nil {
+		t.Error("ArchivePosts() should reject month 13")
+	}
+}
@@ -0,0 +1,123 @@
+package application
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/rs/zerolog/log"
+)
+
+// apiTokenPrefix marks goblog tokens so they are easy to recognise, e.g. by secret scanners
+const apiTokenPrefix = "gbt_"
+
+// ErrInvalidScope is returned when a token is requested with a scope that does not exist
+var ErrInvalidScope = errors.New("invalid scope")
+
+type AuthConfig struct {
+	// BootstrapToken is accepted as an admin token without being stored, so the first API token can be created
+	BootstrapToken string
+}
+
+func NewAuthConfig() *AuthConfig {
+	return &AuthConfig{
+		BootstrapToken: os.Getenv("ADMIN_TOKEN"),
+	}
+}
+
+// AuthService issues and checks API tokens
+type AuthService struct {
+	tokens domain.APITokenRepository
+	// bootstrapHash is the hash of the bootstrap token, or nil when none is configured
+	bootstrapHash []byte
+}
+
+func NewAuthService(tokens domain.APITokenRepository, cfg *AuthConfig) *AuthService {
+	s := &AuthService{
+		tokens: tokens,
+	}
+
+	if cfg.BootstrapToken != "" {
+		sum := sha256.Sum256([]byte(cfg.BootstrapToken))
+		s.bootstrapHash = sum[:]
+	}
+
+	return s
+}
+
+// CreateToken issues a new token with the given scopes
+// The returned secret is the only copy of the token; just its hash is stored.
+func (s *AuthService) CreateToken(ctx context.Context, name string, scopes []domain.Scope) (string, *domain.APIToken, error) {
+	if name == "" {
+		return "", nil, fmt.Errorf("token name cannot be empty")
+	}
+	if len(scopes) == 0 {
+		return "", nil, fmt.Errorf("%w: at least one scope is required", ErrInvalidScope)
+	}
+	for _, scope := range scopes {
+		if scope != domain.ScopeRead && scope != domain.ScopeAdmin {
+			return "", nil, fmt.Errorf("%w: %q", ErrInvalidScope, scope)
+		}
+	}
+
+	secret := apiTokenPrefix + rand.Text()
+	token := &domain.APIToken{
+		Name:   name,
+		Scopes: scopes,
+	}
+	if err := s.tokens.CreateToken(ctx, token, hashToken(secret)); err != nil {
+		return "", nil, err
+	}
+
+	return secret, token, nil
+}
+
+// Authenticate returns the active token matching secret, or domain.ErrAPITokenNotFound
+func (s *AuthService) Authenticate(ctx context.Context, secret string) (*domain.APIToken, error) {
+	if secret == "" {
+		return nil, domain.ErrAPITokenNotFound
+	}
+
+	if s.bootstrapHash != nil && !strings.HasPrefix(secret, apiTokenPrefix) {
+		sum := sha256.Sum256([]byte(secret))
+		if subtle.ConstantTimeCompare(sum[:], s.bootstrapHash) == 1 {
+			return &domain.APIToken{Name: "ADMIN_TOKEN", Scopes: []domain.Scope{domain.ScopeAdmin}}, nil
+		}
+	}
+
+	// Lookups are by hash, so comparing the stored value does not leak timing information about the secret
+	token, err := s.tokens.GetTokenByHash(ctx, hashToken(secret))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.tokens.TouchToken(ctx, token.ID); err != nil {
+		log.Warn().Err(err).Int64("tokenID", token.ID).Msg("Failed to record api token use")
+	}
+
+	return token, nil
+}
+
+// ListTokens returns every token, including revoked ones, newest first
+func (s *AuthService) ListTokens(ctx context.Context) ([]*domain.APIToken, error) {
+	return s.tokens.ListTokens(ctx)
+}
+
+// RevokeToken stops a token from authenticating
+func (s *AuthService) RevokeToken(ctx context.Context, id int64) error {
+	return s.tokens.RevokeToken(ctx, id)
+}
+
+// hashToken returns the hex SHA-256 of a token secret
+// Tokens are long and random, so a fast unsalted hash is enough to protect them at rest.
+func hashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
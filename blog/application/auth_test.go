@@ -0,0 +1,84 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+)
+
+func TestAuthService_CreateAuthenticateRevoke(t *testing.T) {
+	repo := newFakeAPITokenRepository()
+	service := NewAuthService(repo, &AuthConfig{})
+	ctx := context.Background()
+
+	secret, token, err := service.CreateToken(ctx, "reader", []domain.Scope{domain.ScopeRead})
+	if err != nil {
+		t.Fatalf("CreateToken failed: %v", err)
+	}
+	if !strings.HasPrefix(secret, apiTokenPrefix) {
+		t.Errorf("Expected secret to start with %q, got %q", apiTokenPrefix, secret)
+	}
+	if _, ok := repo.hashes[secret]; ok {
+		t.Error("Expected the secret not to be stored in plain text")
+	}
+
+	found, err := service.Authenticate(ctx, secret)
+	if err != nil {
+		t.Fatalf("Authenticate failed: %v", err)
+	}
+	if found.ID != token.ID {
+		t.Errorf("Expected token %d, got %d", token.ID, found.ID)
+	}
+	if len(repo.touched) != 1 || repo.touched[0] != token.ID {
+		t.Errorf("Expected token use to be recorded, got %v", repo.touched)
+	}
+	if !found.HasScope(domain.ScopeRead) || found.HasScope(domain.ScopeAdmin) {
+		t.Errorf("Unexpected scopes: %v", found.Scopes)
+	}
+
+	if err := service.RevokeToken(ctx, token.ID); err != nil {
+		t.Fatalf("RevokeToken failed: %v", err)
+	}
+	if _, err := service.Authenticate(ctx, secret); !errors.Is(err, domain.ErrAPITokenNotFound) {
+		t.Errorf("Expected revoked token to be rejected, got %v", err)
+	}
+}
+
+func TestAuthService_Authenticate_BootstrapToken(t *testing.T) {
+	service := NewAuthService(newFakeAPITokenRepository(), &AuthConfig{BootstrapToken: "bootstrap"})
+	ctx := context.Background()
+
+	token, err := service.Authenticate(ctx, "bootstrap")
+	if err != nil {
+		t.Fatalf("Authenticate failed: %v", err)
+	}
+	if !token.HasScope(domain.ScopeAdmin) || !token.HasScope(domain.ScopeRead) {
+		t.Errorf("Expected bootstrap token to have every scope, got %v", token.Scopes)
+	}
+
+	for _, secret := range []string{"", "wrong", "bootstrap2"} {
+		if _, err := service.Authenticate(ctx, secret); !errors.Is(err, domain.ErrAPITokenNotFound) {
+			t.Errorf("Authenticate(%q): expected ErrAPITokenNotFound, got %v", secret, err)
+		}
+	}
+}
+
+func TestAuthService_CreateToken_InvalidScope(t *testing.T) {
+	service := NewAuthService(newFakeAPITokenRepository(), &AuthConfig{})
+	ctx := context.Background()
+
+	tests := map[string][]domain.Scope{
+		"no scopes":     nil,
+		"unknown scope": {domain.ScopeRead, "write"},
+	}
+	for name, scopes := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := service.CreateToken(ctx, "token", scopes); !errors.Is(err, ErrInvalidScope) {
+				t.Errorf("Expected ErrInvalidScope, got %v", err)
+			}
+		})
+	}
+}
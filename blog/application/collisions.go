@@ -0,0 +1,62 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+)
+
+// postIDCollisions returns an error for each post file in a batch that shares its ID with another file in the batch
+// The file that already owns the post keeps it; when none does, every file sharing the ID is refused.
+func (s *PostService) postIDCollisions(ctx context.Context, paths []string) map[string]error {
+	byID := make(map[string][]string)
+	for _, path := range paths {
+		if postID := s.postID(path); postID != "" {
+			byID[postID] = append(byID[postID], path)
+		}
+	}
+
+	collisions := make(map[string]error)
+	for postID, shared := range byID {
+		if len(shared) < 2 {
+			continue
+		}
+		slices.Sort(shared)
+
+		owner := ""
+		if existing, err := s.repo.GetPost(ctx, postID); err == nil && slices.Contains(shared, existing.SourcePath) {
+			owner = existing.SourcePath
+		}
+
+		for _, path := range shared {
+			if path == owner {
+				continue
+			}
+			collisions[path] = fmt.Errorf("%w: %s derive ID %s", domain.ErrPostIDCollision, strings.Join(shared, ", "), postID)
+		}
+	}
+
+	return collisions
+}
+
+// checkPostOwner refuses to overwrite a post that belongs to another file still present at ref
+// A post whose file is gone was renamed or deleted, so a new file may take over its ID.
+func (s *PostService) checkPostOwner(ctx context.Context, existing *domain.Post, path string, ref string) error {
+	if existing.SourcePath == "" || existing.SourcePath == path {
+		return nil
+	}
+
+	_, err := s.sourceRepo.GetFileContents(ctx, existing.SourcePath, ref)
+	if errors.Is(err, domain.ErrSourceFileNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check owner of post %s: %w", existing.ID, err)
+	}
+
+	return fmt.Errorf("%w: %s and %s derive ID %s", domain.ErrPostIDCollision, existing.SourcePath, path, existing.ID)
+}
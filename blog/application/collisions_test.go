@@ -0,0 +1,72 @@
+package application
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/google/go-github/v75/github"
+)
+
+func TestPostService_SyncRepositoryChanges_RefusesCollidingFiles(t *testing.T) {
+	repo := newFakePostRepository()
+	source := newFakeSourceRepository()
+	source.branches = []*github.Branch{{Name: github.Ptr("main")}}
+	source.commits["a"] = testCommit("a", "posts/001-foo.md", "posts/001-bar.md", "posts/002-other.md")
+	source.files["posts/001-foo.md"] = []byte("# Foo\n\nBody")
+	source.files["posts/001-bar.md"] = []byte("# Bar\n\nBody")
+	source.files["posts/002-other.md"] = []byte("# Other\n\nBody")
+
+	service := NewPostService(repo, newFakeImageRepository(), source, NewMarkdownRenderer(), "main")
+	defer service.Close()
+
+	if err := service.SyncRepositoryChanges(); err != nil {
+		t.Fatalf("SyncRepositoryChanges() error = %v", err)
+	}
+
+	if _, err := repo.GetPost(t.Context(), "001"); err == nil {
+		t.Error("colliding files were saved")
+	}
+	if _, err := repo.GetPost(t.Context(), "002"); err != nil {
+		t.Errorf("unrelated post was not synced: %v", err)
+	}
+}
+
+func TestPostService_ProcessPostFile_PostOwner(t *testing.T) {
+	newService := func() (*PostService, *fakePostRepository, *fakeSourceRepository) {
+		repo := newFakePostRepository(&domain.Post{ID: "001", Title: "Foo", SourcePath: "posts/001-foo.md"})
+		source := newFakeSourceRepository()
+		source.files["posts/001-bar.md"] = []byte("# Bar\n\nBody")
+		service := NewPostService(repo, newFakeImageRepository(), source, NewMarkdownRenderer(), "main")
+		t.Cleanup(func() { service.Close() })
+		return service, repo, source
+	}
+	fileInfo := commitFileInfo{path: "posts/001-bar.md", createdAt: time.Now(), modifiedAt: time.Now()}
+
+	t.Run("owner still exists", func(t *testing.T) {
+		service, repo, source := newService()
+		source.files["posts/001-foo.md"] = []byte("# Foo\n\nBody")
+
+		err := service.processPostFile(t.Context(), "001", fileInfo, "head", "main", nil)
+		if !errors.Is(err, domain.ErrPostIDCollision) {
+			t.Fatalf("Expected ErrPostIDCollision, got %v", err)
+		}
+		post, _ := repo.GetPost(t.Context(), "001")
+		if post.SourcePath != "posts/001-foo.md" {
+			t.Errorf("post was overwritten by %s", post.SourcePath)
+		}
+	})
+
+	t.Run("owner was renamed", func(t *testing.T) {
+		service, repo, _ := newService()
+
+		if err := service.processPostFile(t.Context(), "001", fileInfo, "head", "main", nil); err != nil {
+			t.Fatalf("processPostFile() error = %v", err)
+		}
+		post, _ := repo.GetPost(t.Context(), "001")
+		if post.SourcePath != "posts/001-bar.md" || post.Title != "Bar" {
+			t.Errorf("post was not taken over by the renamed file: %+v", post)
+		}
+	})
+}
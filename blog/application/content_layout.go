@@ -0,0 +1,159 @@
+package application
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+)
+
+const (
+	defaultPostsDir  = "posts"
+	defaultImagesDir = "images"
+	defaultPostIDs   = "number"
+)
+
+// postNumberID is the number a post file name starts with, which is its ID by default
+var postNumberID = regexp.MustCompile(`^(\d+)-`)
+
+// PostIDStrategy derives a post's ID from its file name without the .md extension, returning "" if the name has none
+type PostIDStrategy func(name string) string
+
+// PostIDStrategies are the strategies ContentLayoutConfig.PostIDs can name
+// Programs embedding the blog can add their own before building a layout.
+var PostIDStrategies = map[string]PostIDStrategy{
+	// "001-my-post" -> "001"; names without a number are not posts
+	"number": func(name string) string {
+		if matches := postNumberID.FindStringSubmatch(name); matches != nil {
+			return matches[1]
+		}
+		return ""
+	},
+	// "my-post" -> "my-post", so renaming a post's file makes it a new post
+	"slug": postSlug,
+}
+
+// ContentLayoutConfig describes where posts and images live in the content repository
+// The defaults match the original layout of posts/NNN-title.md and images/.
+type ContentLayoutConfig struct {
+	// PostsDir is the directory holding post files
+	PostsDir string
+	// ImagesDir is the directory holding images, which may have folders of their own
+	ImagesDir string
+	// YearDirs expects posts in a directory per year below PostsDir, such as posts/2025/001-post.md
+	YearDirs bool
+	// PostIDs names the PostIDStrategies entry deriving post IDs from file names
+	PostIDs string
+}
+
+func NewContentLayoutConfig() *ContentLayoutConfig {
+	return &ContentLayoutConfig{
+		PostsDir:  os.Getenv("CONTENT_POSTS_DIR"),
+		ImagesDir: os.Getenv("CONTENT_IMAGES_DIR"),
+		YearDirs:  os.Getenv("CONTENT_YEAR_DIRS") == "true",
+		PostIDs:   os.Getenv("CONTENT_POST_IDS"),
+	}
+}
+
+// ContentLayout recognises post and image files in the content repository and derives post IDs from their paths
+type ContentLayout struct {
+	postsDir  string
+	imagesDir string
+	postPath  *regexp.Regexp
+	imagePath *regexp.Regexp
+	postID    PostIDStrategy
+}
+
+// NewContentLayout builds the layout cfg describes, refusing an unknown post ID strategy
+func NewContentLayout(cfg *ContentLayoutConfig) (*ContentLayout, error) {
+	postIDs := cmp.Or(cfg.PostIDs, defaultPostIDs)
+	postID, ok := PostIDStrategies[postIDs]
+	if !ok {
+		return nil, fmt.Errorf("unknown post ID strategy %q", postIDs)
+	}
+
+	postsDir := cmp.Or(strings.Trim(cfg.PostsDir, "/"), defaultPostsDir)
+	imagesDir := cmp.Or(strings.Trim(cfg.ImagesDir, "/"), defaultImagesDir)
+	yearDir := ""
+	if cfg.YearDirs {
+		yearDir = `\d{4}/`
+	}
+
+	return &ContentLayout{
+		postsDir:  postsDir,
+		imagesDir: imagesDir,
+		postPath:  regexp.MustCompile(`^` + regexp.QuoteMeta(postsDir) + `/` + yearDir + `([^/]+)\.md$`),
+		imagePath: regexp.MustCompile(`^` + regexp.QuoteMeta(imagesDir) + `/.*\.(jpg|jpeg|png|gif|svg|webp|avif)$`),
+		postID:    postID,
+	}, nil
+}
+
+// DefaultContentLayout returns the original layout of posts/NNN-title.md and images/
+func DefaultContentLayout() *ContentLayout {
+	layout, _ := NewContentLayout(&ContentLayoutConfig{})
+	return layout
+}
+
+// PostsDir returns the directory holding post files
+func (l *ContentLayout) PostsDir() string {
+	return l.postsDir
+}
+
+// ImagesDir returns the directory holding images
+func (l *ContentLayout) ImagesDir() string {
+	return l.imagesDir
+}
+
+// PostID derives the ID of the post at path, returning "" if path is not a post file
+// Example: "posts/001-my-post.md" -> "001"
+func (l *ContentLayout) PostID(path string) string {
+	matches := l.postPath.FindStringSubmatch(path)
+	if matches == nil {
+		return ""
+	}
+	return l.postID(matches[1])
+}
+
+// isPostPath checks if path is a markdown file where the layout keeps posts, whether or not it has an ID
+func (l *ContentLayout) isPostPath(path string) bool {
+	return l.postPath.MatchString(path)
+}
+
+// isImageFile checks if path is an image file in the images directory
+func (l *ContentLayout) isImageFile(path string) bool {
+	return l.imagePath.MatchString(path)
+}
+
+// imageRepoPath returns the repository path of an image given its path below the images directory
+func (l *ContentLayout) imageRepoPath(file string) string {
+	return l.imagesDir + "/" + file
+}
+
+// imageFile returns the path of an image below the images directory, which is its URL path below /images/
+func (l *ContentLayout) imageFile(imagePath string) string {
+	return strings.TrimPrefix(imagePath, l.imagesDir+"/")
+}
+
+// imageDestPath maps an image destination in a post to its path in the repository, keeping any folders below
+// the images directory. Destinations that do not point into it keep the old behaviour of naming a file directly in it.
+func (l *ContentLayout) imageDestPath(dest string) string {
+	// Rooting the path first lets Clean resolve ./ and ../ without ever climbing above the repository
+	cleaned := path.Clean("/" + dest)
+	if rest, ok := strings.CutPrefix(cleaned, "/"+l.imagesDir+"/"); ok {
+		return l.imageRepoPath(rest)
+	}
+	return l.imageRepoPath(path.Base(cleaned))
+}
+
+// postNamePath returns a path the layout would keep a post file with the given name at
+// Only the name decides a post's ID, so any year directory will do.
+func (l *ContentLayout) postNamePath(name string) string {
+	for _, candidate := range []string{l.postsDir + "/" + name + ".md", l.postsDir + "/0000/" + name + ".md"} {
+		if l.isPostPath(candidate) {
+			return candidate
+		}
+	}
+	return ""
+}
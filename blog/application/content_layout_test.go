@@ -0,0 +1,79 @@
+package application
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v75/github"
+)
+
+func TestContentLayout(t *testing.T) {
+	layout, err := NewContentLayout(&ContentLayoutConfig{PostsDir: "content/blog/", ImagesDir: "static/img", YearDirs: true, PostIDs: "slug"})
+	if err != nil {
+		t.Fatalf("NewContentLayout() error = %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "content/blog/2025/My Post.md", want: "my-post"},
+		{path: "content/blog/2025/001-numbered.md", want: "numbered"},
+		{path: "content/blog/my-post.md", want: ""},
+		{path: "content/blog/2025/drafts/my-post.md", want: ""},
+		{path: "posts/2025/my-post.md", want: ""},
+	}
+	for _, tt := range tests {
+		if got := layout.PostID(tt.path); got != tt.want {
+			t.Errorf("PostID(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+
+	if !layout.isImageFile("static/img/2024/cat.png") || layout.isImageFile("images/cat.png") {
+		t.Error("Expected images to be recognised in the configured directory only")
+	}
+	if got := layout.imageDestPath("../../static/img/2024/cat.png"); got != "static/img/2024/cat.png" {
+		t.Errorf("imageDestPath() = %q, want the path below the images directory kept", got)
+	}
+	if got := layout.postNamePath("my-post"); layout.PostID(got) != "my-post" {
+		t.Errorf("postNamePath() = %q, want a path with the post's ID", got)
+	}
+
+	if _, err := NewContentLayout(&ContentLayoutConfig{PostIDs: "uuid"}); err == nil {
+		t.Error("Expected an unknown post ID strategy to be refused")
+	}
+}
+
+func TestPostService_ContentLayout(t *testing.T) {
+	layout, err := NewContentLayout(&ContentLayoutConfig{PostsDir: "content", ImagesDir: "static/img", PostIDs: "slug"})
+	if err != nil {
+		t.Fatalf("NewContentLayout() error = %v", err)
+	}
+
+	source := newFakeSourceRepository()
+	repo := newFakePostRepository()
+	service := NewPostService(repo, newFakeImageRepository(), source, NewMarkdownRenderer(WithMarkdownLayout(layout)), "main",
+		WithContentLayout(layout),
+	)
+	defer service.Close()
+
+	source.commits["a"] = testCommit("a", "content/hello-world.md", "posts/001-ignored.md")
+	source.files["content/hello-world.md"] = []byte("# Hello\n\n![Cat](/static/img/cat.png)")
+
+	branches := []*github.Branch{{Name: github.Ptr("main")}}
+	if err := service.processBranches(time.Time{}, branches); err != nil {
+		t.Fatalf("processBranches() error = %v", err)
+	}
+
+	post, ok := repo.posts["hello-world"]
+	if !ok || len(repo.posts) != 1 {
+		t.Fatalf("Expected only the post in the configured directory to be saved, got %v", repo.posts)
+	}
+	if !strings.Contains(string(post.HTMLContent), `src="https://blog.werewolves.fyi/images/cat.png"`) {
+		t.Errorf("HTMLContent = %s, want the image linked below /images/", post.HTMLContent)
+	}
+	if got := service.ImagePath("cat.png"); got != "static/img/cat.png" {
+		t.Errorf("ImagePath() = %q, want the image in the configured directory", got)
+	}
+}
@@ -0,0 +1,85 @@
+package application
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+)
+
+// postVersionLength is how many hex digits of the hash identify a version of a post
+const postVersionLength = 16
+
+// ContentVersion identifies the current state of everything the blog serves
+// It changes whenever a post is saved, published, unpublished or deleted, and on every restart
+// so theme changes are picked up too. Pages can use it as a site-wide ETag.
+func (s *PostService) ContentVersion() int64 {
+	return s.contentVersion.Load()
+}
+
+// ContentModifiedAt is when the content version last changed, for use as a site-wide Last-Modified time
+func (s *PostService) ContentModifiedAt() time.Time {
+	return time.Unix(0, s.contentModifiedAt.Load()).UTC()
+}
+
+// PostVersion identifies the state of a single post as this process serves it
+// Unlike ContentVersion it ignores changes to other posts, so clients polling one post aren't sent it again
+// whenever another changes. It still changes on restart, for the same reason ContentVersion does.
+func (s *PostService) PostVersion(post *domain.Post) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d\x00%s\x00%s\x00%s\x00%d\x00%d\x00",
+		s.startedAt.UnixNano(), post.ID, post.Title, post.Language, post.UpdatedAt.UnixNano(), post.PublishedAt.UnixNano())
+	h.Write(post.HTMLContent)
+	return hex.EncodeToString(h.Sum(nil))[:postVersionLength]
+}
+
+// PostModifiedAt is when a post last changed as this process serves it: when it was updated or published,
+// or when the process started if that is later
+func (s *PostService) PostModifiedAt(post *domain.Post) time.Time {
+	modified := s.startedAt
+	for _, t := range []time.Time{post.UpdatedAt, post.PublishedAt} {
+		if t.After(modified) {
+			modified = t
+		}
+	}
+	return modified.UTC()
+}
+
+// contentChanged moves the content version on after a write to the live posts
+func (s *PostService) contentChanged() {
+	s.contentVersion.Add(1)
+	s.contentModifiedAt.Store(s.clock.Now().UnixNano())
+}
+
+// versionedPostRepository bumps the content version after every successful write
+// Wrapping the repository keeps the version correct however a write is reached: sync, scheduler or admin.
+type versionedPostRepository struct {
+	domain.PostRepository
+	changed func()
+}
+
+func (r *versionedPostRepository) SavePost(ctx context.Context, p *domain.Post) error {
+	return r.bump(r.PostRepository.SavePost(ctx, p))
+}
+
+func (r *versionedPostRepository) Publish(ctx context.Context, postID string) error {
+	return r.bump(r.PostRepository.Publish(ctx, postID))
+}
+
+func (r *versionedPostRepository) Unpublish(ctx context.Context, postID string) error {
+	return r.bump(r.PostRepository.Unpublish(ctx, postID))
+}
+
+func (r *versionedPostRepository) DeletePost(ctx context.Context, postID string) error {
+	return r.bump(r.PostRepository.DeletePost(ctx, postID))
+}
+
+func (r *versionedPostRepository) bump(err error) error {
+	if err == nil {
+		r.changed()
+	}
+	return err
+}
@@ -0,0 +1,97 @@
+package application
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/clock"
+)
+
+func TestPostService_ContentVersion(t *testing.T) {
+	repo := newFakePostRepository(&domain.Post{ID: "001"})
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	service := NewPostService(repo, newFakeImageRepository(), nil, NewMarkdownRenderer(), "main",
+		WithClock(clock.Fixed(start)),
+	)
+	defer service.Close()
+	ctx := context.Background()
+
+	version := service.ContentVersion()
+	if version != start.UnixNano() {
+		t.Errorf("initial ContentVersion() = %d, want the startup time %d", version, start.UnixNano())
+	}
+
+	if _, err := service.ListPublishedPosts(ctx, 10, 0); err != nil {
+		t.Fatalf("ListPublishedPosts() error = %v", err)
+	}
+	if service.ContentVersion() != version {
+		t.Error("ContentVersion() changed on a read")
+	}
+
+	if err := service.PublishPost(ctx, "001"); err != nil {
+		t.Fatalf("PublishPost() error = %v", err)
+	}
+	if service.ContentVersion() == version {
+		t.Error("ContentVersion() did not change on publish")
+	}
+
+	version = service.ContentVersion()
+	if err := service.PublishPost(ctx, "missing"); err == nil {
+		t.Fatal("PublishPost() of a missing post should fail")
+	}
+	if service.ContentVersion() != version {
+		t.Error("ContentVersion() changed on a failed write")
+	}
+}
+
+func TestPostService_ContentModifiedAt(t *testing.T) {
+	repo := newFakePostRepository(&domain.Post{ID: "001"})
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	service := NewPostService(repo, newFakeImageRepository(), nil, NewMarkdownRenderer(), "main",
+		WithClock(clock.Func(func() time.Time { return now })),
+	)
+	defer service.Close()
+
+	if got := service.ContentModifiedAt(); !got.Equal(now) {
+		t.Errorf("initial ContentModifiedAt() = %v, want the startup time %v", got, now)
+	}
+
+	now = now.Add(time.Hour)
+	if err := service.PublishPost(context.Background(), "001"); err != nil {
+		t.Fatalf("PublishPost() error = %v", err)
+	}
+	if got := service.ContentModifiedAt(); !got.Equal(now) {
+		t.Errorf("ContentModifiedAt() after publishing = %v, want %v", got, now)
+	}
+}
+
+func TestPostService_PostVersion(t *testing.T) {
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	service := NewPostService(newFakePostRepository(), newFakeImageRepository(), nil, NewMarkdownRenderer(), "main",
+		WithClock(clock.Fixed(start)),
+	)
+	defer service.Close()
+
+	updated := start.Add(time.Hour)
+	post := &domain.Post{ID: "001", HTMLContent: []byte("<p>One</p>"), UpdatedAt: updated, PublishedAt: start.Add(-time.Hour)}
+	version := service.PostVersion(post)
+
+	if again := service.PostVersion(&domain.Post{ID: "001", HTMLContent: []byte("<p>One</p>"), UpdatedAt: updated, PublishedAt: start.Add(-time.Hour)}); again != version {
+		t.Errorf("PostVersion() of the same post = %s, want %s", again, version)
+	}
+	edited := *post
+	edited.HTMLContent = []byte("<p>Two</p>")
+	if service.PostVersion(&edited) == version {
+		t.Error("PostVersion() did not change with the content")
+	}
+
+	if got := service.PostModifiedAt(post); !got.Equal(updated) {
+		t.Errorf("PostModifiedAt() = %v, want the update time %v", got, updated)
+	}
+	old := &domain.Post{ID: "002", UpdatedAt: start.Add(-48 * time.Hour)}
+	if got := service.PostModifiedAt(old); !got.Equal(start) {
+		t.Errorf("PostModifiedAt() of a post older than the process = %v, want the startup time %v", got, start)
+	}
+}
@@ -0,0 +1,61 @@
+package application
+
+import (
+	"context"
+	"errors"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog/log"
+)
+
+var syncFileErrors = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "goblog_sync_file_errors_total",
+	Help: "Post and image files that failed to process after retries.",
+})
+
+// ListDeadLetters returns the files that keep failing to process
+func (s *PostService) ListDeadLetters(ctx context.Context) ([]*domain.DeadLetter, error) {
+	if s.deadLetters == nil {
+		return []*domain.DeadLetter{}, nil
+	}
+	return s.deadLetters.ListDeadLetters(ctx)
+}
+
+// recordFileResult dead-letters a file that failed after retries and notifies operators, and clears it once it succeeds
+// The service context may already be cancelled during shutdown, so the update runs without it
+// Skipping a quarantined file is not another failure of it.
+func (s *PostService) recordFileResult(path string, ref string, fileErr error) {
+	if errors.Is(fileErr, ErrPoisoned) {
+		return
+	}
+	if fileErr != nil {
+		syncFileErrors.Inc()
+	}
+
+	ctx := context.WithoutCancel(s.ctx)
+	if fileErr == nil {
+		if s.deadLetters == nil {
+			return
+		}
+		if err := s.deadLetters.Resolve(ctx, path); err != nil {
+			log.Error().Err(err).Str("path", path).Msg("Failed to resolve dead letter")
+		}
+		return
+	}
+
+	// Files that failed only because we are shutting down will be picked up by the next sync
+	if s.ctx.Err() != nil {
+		return
+	}
+
+	if s.deadLetters != nil {
+		if err := s.deadLetters.RecordFailure(ctx, path, ref, fileErr); err != nil {
+			log.Error().Err(err).Str("path", path).Msg("Failed to record dead letter")
+			return
+		}
+		s.poisonIfRepeated(ctx, path)
+	}
+	s.notifyFailure(ctx, path, ref, fileErr)
+}
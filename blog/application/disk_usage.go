@@ -0,0 +1,183 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog/log"
+)
+
+const defaultDiskUsageInterval = 5 * time.Minute
+
+// ErrQuotaExceeded is returned when storing more content would exceed the hard disk quota
+var ErrQuotaExceeded = errors.New("disk quota exceeded")
+
+var (
+	storageBytes = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "goblog_storage_bytes",
+		Help: "Bytes of storage used, by kind of content.",
+	}, []string{"kind"})
+
+	storageQuotaBytes = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "goblog_storage_quota_bytes",
+		Help: "Configured storage quota in bytes; zero means unlimited.",
+	}, []string{"limit"})
+
+	imagesRejected = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "goblog_images_rejected_total",
+		Help: "Images that were not ingested because the hard disk quota was exceeded.",
+	})
+)
+
+// UsageSource reports the bytes consumed by one kind of stored content
+type UsageSource interface {
+	UsageBytes(ctx context.Context) (int64, error)
+}
+
+// ImageQuota decides whether a new image may be stored
+type ImageQuota interface {
+	CheckImageQuota(size int64) error
+}
+
+type DiskQuotaConfig struct {
+	// Interval is how often disk usage is measured
+	Interval time.Duration
+	// SoftLimit logs a warning when total usage exceeds it; zero disables the check
+	SoftLimit int64
+	// HardLimit stops new images from being ingested once exceeded; zero disables the check
+	HardLimit int64
+}
+
+func NewDiskQuotaConfig() *DiskQuotaConfig {
+	interval := defaultDiskUsageInterval
+	if d, err := time.ParseDuration(os.Getenv("DISK_USAGE_INTERVAL")); err == nil && d > 0 {
+		interval = d
+	}
+
+	return &DiskQuotaConfig{
+		Interval:  interval,
+		SoftLimit: parseByteSize("DISK_QUOTA_SOFT"),
+		HardLimit: parseByteSize("DISK_QUOTA_HARD"),
+	}
+}
+
+// parseByteSize reads a human-readable size such as "500MB" from an environment variable
+func parseByteSize(env string) int64 {
+	value := os.Getenv(env)
+	if value == "" {
+		return 0
+	}
+
+	size, err := humanize.ParseBytes(value)
+	if err != nil {
+		log.Warn().Err(err).Str("env", env).Str("value", value).Msg("Ignoring invalid byte size")
+		return 0
+	}
+
+	return int64(size)
+}
+
+// DiskUsage is a point-in-time measurement of storage used by the blog
+type DiskUsage struct {
+	Posts      int64
+	Images     int64
+	Database   int64
+	MeasuredAt time.Time
+}
+
+// Total returns the combined bytes used by all content
+func (u DiskUsage) Total() int64 {
+	return u.Posts + u.Images + u.Database
+}
+
+// DiskUsageMonitor periodically measures storage usage and enforces the configured quotas
+type DiskUsageMonitor struct {
+	posts    UsageSource
+	images   UsageSource
+	database UsageSource
+	cfg      *DiskQuotaConfig
+
+	mu   sync.RWMutex
+	last DiskUsage
+}
+
+func NewDiskUsageMonitor(posts UsageSource, images UsageSource, database UsageSource, cfg *DiskQuotaConfig) *DiskUsageMonitor {
+	storageQuotaBytes.WithLabelValues("soft").Set(float64(cfg.SoftLimit))
+	storageQuotaBytes.WithLabelValues("hard").Set(float64(cfg.HardLimit))
+
+	return &DiskUsageMonitor{
+		posts:    posts,
+		images:   images,
+		database: database,
+		cfg:      cfg,
+	}
+}
+
+// Refresh measures current disk usage and warns when a quota is exceeded
+func (m *DiskUsageMonitor) Refresh(ctx context.Context) error {
+	var usage DiskUsage
+	var err error
+
+	if usage.Posts, err = m.posts.UsageBytes(ctx); err != nil {
+		return fmt.Errorf("failed to measure post storage: %w", err)
+	}
+	if usage.Images, err = m.images.UsageBytes(ctx); err != nil {
+		return fmt.Errorf("failed to measure image storage: %w", err)
+	}
+	if usage.Database, err = m.database.UsageBytes(ctx); err != nil {
+		return fmt.Errorf("failed to measure database storage: %w", err)
+	}
+	usage.MeasuredAt = time.Now().UTC()
+
+	m.mu.Lock()
+	m.last = usage
+	m.mu.Unlock()
+
+	storageBytes.WithLabelValues("posts").Set(float64(usage.Posts))
+	storageBytes.WithLabelValues("images").Set(float64(usage.Images))
+	storageBytes.WithLabelValues("database").Set(float64(usage.Database))
+
+	total := usage.Total()
+	switch {
+	case m.cfg.HardLimit > 0 && total > m.cfg.HardLimit:
+		log.Error().Int64("totalBytes", total).Int64("hardLimitBytes", m.cfg.HardLimit).Msg("Disk usage exceeds hard quota, new images will be rejected")
+	case m.cfg.SoftLimit > 0 && total > m.cfg.SoftLimit:
+		log.Warn().Int64("totalBytes", total).Int64("softLimitBytes", m.cfg.SoftLimit).Msg("Disk usage exceeds soft quota")
+	}
+
+	return nil
+}
+
+// Usage returns the most recent measurement
+func (m *DiskUsageMonitor) Usage() DiskUsage {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.last
+}
+
+// Limits returns the configured soft and hard quotas
+func (m *DiskUsageMonitor) Limits() (soft int64, hard int64) {
+	return m.cfg.SoftLimit, m.cfg.HardLimit
+}
+
+// CheckImageQuota rejects an image of the given size if storing it would exceed the hard quota
+func (m *DiskUsageMonitor) CheckImageQuota(size int64) error {
+	if m.cfg.HardLimit <= 0 {
+		return nil
+	}
+
+	total := m.Usage().Total()
+	if total+size > m.cfg.HardLimit {
+		imagesRejected.Inc()
+		return fmt.Errorf("%w: %d bytes used of %d", ErrQuotaExceeded, total, m.cfg.HardLimit)
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// staticUsage is a UsageSource that always reports the same size
+type staticUsage int64
+
+func (s staticUsage) UsageBytes(ctx context.Context) (int64, error) {
+	return int64(s), nil
+}
+
+func TestDiskUsageMonitor_Refresh(t *testing.T) {
+	m := NewDiskUsageMonitor(staticUsage(100), staticUsage(200), staticUsage(300), &DiskQuotaConfig{})
+
+	if err := m.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+
+	usage := m.Usage()
+	if usage.Posts != 100 || usage.Images != 200 || usage.Database != 300 {
+		t.Errorf("usage = %+v, want posts=100 images=200 database=300", usage)
+	}
+	if usage.Total() != 600 {
+		t.Errorf("Total() = %d, want 600", usage.Total())
+	}
+	if usage.MeasuredAt.IsZero() {
+		t.Error("MeasuredAt not set")
+	}
+}
+
+func TestDiskUsageMonitor_CheckImageQuota(t *testing.T) {
+	tests := []struct {
+		name      string
+		hardLimit int64
+		imageSize int64
+		wantErr   bool
+	}{
+		{name: "No hard limit", hardLimit: 0, imageSize: 1 << 30},
+		{name: "Within limit", hardLimit: 1000, imageSize: 100},
+		{name: "Exactly at limit", hardLimit: 700, imageSize: 100},
+		{name: "Exceeds limit", hardLimit: 650, imageSize: 100, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewDiskUsageMonitor(staticUsage(100), staticUsage(200), staticUsage(300), &DiskQuotaConfig{
+				SoftLimit: 500,
+				HardLimit: tt.hardLimit,
+			})
+			if err := m.Refresh(context.Background()); err != nil {
+				t.Fatalf("Refresh() error = %v", err)
+			}
+
+			err := m.CheckImageQuota(tt.imageSize)
+			if tt.wantErr {
+				if !errors.Is(err, ErrQuotaExceeded) {
+					t.Errorf("CheckImageQuota() error = %v, want ErrQuotaExceeded", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("CheckImageQuota() unexpected error = %v", err)
+			}
+		})
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int64
+	}{
+		{value: "", want: 0},
+		{value: "1024", want: 1024},
+		{value: "2KB", want: 2000},
+		{value: "1GiB", want: 1 << 30},
+		{value: "lots", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv("TEST_BYTE_SIZE", tt.value)
+			if got := parseByteSize("TEST_BYTE_SIZE"); got != tt.want {
+				t.Errorf("parseByteSize(%q) = %d, want %d", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package application
+
+import "sync"
+
+// dispatchQueue runs jobs one at a time in the order they were queued
+// Everything that applies changes from the source repository goes through the service's queue: startup syncs,
+// resyncs, pushes, tags, releases, pull request previews and recovered renders. Planning which files changed
+// happens before a job is queued and only reads, and its result is never modified afterwards, so it can be handed
+// to other goroutines without locking. A job fans its files out on the worker pool and waits for them before the
+// next job starts, so a push can't interleave with the startup sync and overwrite a post with an older version.
+// State shared between jobs keeps its own lock: renderCache and postCache hold a mutex, postVersions is a sync.Map
+// and counters are atomic. Repositories must be safe for concurrent use.
+// Queueing never blocks, since each job only waits for the one queued before it. A job must not queue another job
+// and wait for it, as it would be waiting for itself.
+type dispatchQueue struct {
+	mu sync.Mutex
+	// tail is closed once the last job queued has finished
+	tail chan struct{}
+}
+
+func newDispatchQueue() *dispatchQueue {
+	tail := make(chan struct{})
+	close(tail)
+	return &dispatchQueue{tail: tail}
+}
+
+// enqueue reserves the next place in the queue
+// The job may start once previous is closed, and must call done when it finishes.
+func (q *dispatchQueue) enqueue() (previous <-chan struct{}, done func()) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	finished := make(chan struct{})
+	previous, q.tail = q.tail, finished
+	return previous, func() { close(finished) }
+}
+
+// dispatch runs fn in the background once every job queued before it has finished, tracked by the service wait group
+// Jobs queued during shutdown still run, so they can record their files as failed; cancelled work fails fast.
+func (s *PostService) dispatch(fn func()) {
+	previous, done := s.dispatcher.enqueue()
+	s.wg.Go(func() {
+		defer done()
+		<-previous
+		fn()
+	})
+}
+
+// dispatchWait runs fn on the calling goroutine once every job queued before it has finished
+func (s *PostService) dispatchWait(fn func() error) error {
+	previous, done := s.dispatcher.enqueue()
+	defer done()
+	<-previous
+	return fn()
+}
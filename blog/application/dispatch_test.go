@@ -0,0 +1,174 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v75/github"
+)
+
+func TestPostService_DispatchRunsJobsInOrder(t *testing.T) {
+	service := NewPostService(newFakePostRepository(), newFakeImageRepository(), nil, NewMarkdownRenderer(), "main")
+
+	var running atomic.Int32
+	var mu sync.Mutex
+	var order []int
+	for i := range 10 {
+		service.dispatch(func() {
+			if running.Add(1) > 1 {
+				t.Error("Expected jobs to run one at a time")
+			}
+			time.Sleep(time.Millisecond)
+			mu.Lock()
+			order = append(order, i)
+			mu.Unlock()
+			running.Add(-1)
+		})
+	}
+
+	err := service.dispatchWait(func() error {
+		mu.Lock()
+		defer mu.Unlock()
+		if len(order) != 10 {
+			return fmt.Errorf("ran after %d jobs, want all 10", len(order))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	service.Close()
+
+	for i, n := range order {
+		if n != i {
+			t.Fatalf("jobs ran in order %v, want the order they were queued", order)
+		}
+	}
+}
+
+// gatedSourceRepository holds back fetching files at one commit until released
+type gatedSourceRepository struct {
+	*fakeSourceRepository
+	sha     string
+	started chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func (g *gatedSourceRepository) GetFileContents(ctx context.Context, path string, ref string) ([]byte, error) {
+	if ref == g.sha {
+		g.once.Do(func() { close(g.started) })
+		<-g.release
+	}
+	return g.fakeSourceRepository.GetFileContents(ctx, path, ref)
+}
+
+func TestPostService_PushDuringSyncAppliesAfterIt(t *testing.T) {
+	source := &gatedSourceRepository{
+		fakeSourceRepository: newFakeSourceRepository(),
+		sha:                  "a",
+		started:              make(chan struct{}),
+		release:              make(chan struct{}),
+	}
+	source.branches = []*github.Branch{{Name: github.Ptr("main")}}
+	source.commits["a"] = testCommit("a", "posts/001-post.md")
+	source.files["a:posts/001-post.md"] = []byte("# Old\n\nBody")
+	source.files["b:posts/001-post.md"] = []byte("# New\n\nBody")
+
+	repo := newFakePostRepository()
+	service := NewPostService(repo, newFakeImageRepository(), source, NewMarkdownRenderer(), "main")
+
+	syncErr := make(chan error, 1)
+	go func() { syncErr <- service.SyncRepositoryChanges() }()
+	<-source.started
+
+	// The push lands while the sync is still rendering the commit before it
+	source.mu.Lock()
+	source.commits["b"] = testCommit("b", "posts/001-post.md")
+	source.comparisons["a...b"] = &github.CommitsComparison{Commits: []*github.RepositoryCommit{testCommit("b")}}
+	source.mu.Unlock()
+
+	_, err := service.HandlePushEvent(&github.PushEvent{
+		Ref:    github.Ptr("refs/heads/main"),
+		Before: github.Ptr("a"),
+		After:  github.Ptr("b"),
+	})
+	if err != nil {
+		t.Fatalf("HandlePushEvent() error = %v", err)
+	}
+
+	// Give the push's files time to be processed, were they not held back until the sync finishes
+	time.Sleep(20 * time.Millisecond)
+	close(source.release)
+	if err := <-syncErr; err != nil {
+		t.Fatalf("SyncRepositoryChanges() error = %v", err)
+	}
+	service.Close()
+
+	post, err := repo.GetPost(t.Context(), "001")
+	if err != nil {
+		t.Fatalf("GetPost() error = %v", err)
+	}
+	if post.CommitSHA != "b" || post.Title != "New" {
+		t.Errorf("Expected the push to be applied after the sync, got the post from %s titled %q", post.CommitSHA, post.Title)
+	}
+}
+
+// Run with -race: pushes to several branches and overlapping syncs share the worker pool and repositories
+func TestPostService_ConcurrentPushesAndSyncs(t *testing.T) {
+	const pushes = 8
+
+	source := newFakeSourceRepository()
+	source.branches = []*github.Branch{{Name: github.Ptr("main")}}
+	source.commits["m"] = testCommit("m", "posts/100-main.md", "images/shared.png")
+	source.files["posts/100-main.md"] = []byte("# Main\n\n![shared](../images/shared.png)")
+	source.files["images/shared.png"] = encodePNG(t, testImage(8, 8, func(x, y float64) float64 { return x }))
+	for i := range pushes {
+		sha, path := fmt.Sprintf("c%d", i), fmt.Sprintf("posts/%03d-draft.md", i)
+		source.commits[sha] = testCommit(sha, path, "images/shared.png")
+		source.files[path] = []byte(fmt.Sprintf("# Draft %d\n\n![shared](../images/shared.png)", i))
+	}
+
+	repo := newFakePostRepository()
+	processed := newFakeProcessedCommitRepository()
+	service := NewPostService(repo, newFakeImageRepository(), source, NewMarkdownRenderer(), "main",
+		WithProcessedCommits(processed),
+		WithSyncWorkers(&SyncConfig{Workers: 3}),
+	)
+
+	var wg sync.WaitGroup
+	for i := range pushes {
+		wg.Go(func() {
+			_, err := service.HandlePushEvent(&github.PushEvent{
+				Ref:    github.Ptr(fmt.Sprintf("refs/heads/feature-%d", i)),
+				Before: github.Ptr(zeroSHA),
+				After:  github.Ptr(fmt.Sprintf("c%d", i)),
+			})
+			if err != nil {
+				t.Errorf("HandlePushEvent() error = %v", err)
+			}
+		})
+		if i%2 == 0 {
+			wg.Go(func() {
+				if err := service.SyncRepositoryChanges(); err != nil {
+					t.Errorf("SyncRepositoryChanges() error = %v", err)
+				}
+			})
+		}
+	}
+	wg.Wait()
+	service.Close()
+
+	for i := range pushes {
+		if _, err := repo.GetPost(t.Context(), fmt.Sprintf("%03d", i)); err != nil {
+			t.Errorf("Expected the post of push %d to be saved: %v", i, err)
+		}
+	}
+	if _, err := repo.GetPost(t.Context(), "100"); err != nil {
+		t.Errorf("Expected the post on main to be synced: %v", err)
+	}
+}
@@ -0,0 +1,195 @@
+package application
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark/ast"
+	extast "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// ErrNoDocument is returned for a post rendered while documents were not enabled
+var ErrNoDocument = errors.New("post has no document")
+
+// GetPublishedPostDocument returns a published post along with its structured document
+// Posts rendered before documents were enabled have none until they change or the repository is resynced.
+func (s *PostService) GetPublishedPostDocument(ctx context.Context, id string) (*domain.Post, error) {
+	post, err := s.GetPublishedPost(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	document, err := s.repo.GetPostDocument(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if document == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoDocument, id)
+	}
+	post.Document = document
+
+	return post, nil
+}
+
+// documentKey stores the structured document of the post being converted
+var documentKey = parser.NewContextKey()
+
+// documentBuilder records the structure of a document once every other transformer has run,
+// so links, images and diagrams appear as they do in the rendered HTML
+type documentBuilder struct{}
+
+func (documentBuilder) Transform(node *ast.Document, reader text.Reader, pc parser.Context) {
+	pc.Set(documentKey, &domain.Document{Blocks: documentNodes(node, reader.Source())})
+}
+
+// documentNodes converts the children of n
+func documentNodes(n ast.Node, source []byte) []*domain.DocumentNode {
+	var nodes []*domain.DocumentNode
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		nodes = append(nodes, documentNode(c, source))
+	}
+	return nodes
+}
+
+// documentNode converts n and its children; leaf nodes carry their content as Text instead of children
+func documentNode(n ast.Node, source []byte) *domain.DocumentNode {
+	node := &domain.DocumentNode{Type: snakeCase(n.Kind().String())}
+
+	switch t := n.(type) {
+	case *ast.Heading:
+		node.Level = t.Level
+		if id, ok := t.AttributeString("id"); ok {
+			idBytes, _ := id.([]byte)
+			node.ID = string(idBytes)
+		}
+	case *ast.FencedCodeBlock:
+		node.Language = string(t.Language(source))
+		node.Text = string(blockText(t, source))
+		return node
+	case *ast.CodeBlock, *mermaidBlock, *mathBlock:
+		node.Text = string(blockText(n, source))
+		return node
+	case *ast.HTMLBlock:
+		content := blockText(t, source)
+		if t.HasClosure() {
+			content = append(content, t.ClosureLine.Value(source)...)
+		}
+		node.Text = string(content)
+		return node
+	case *ast.Text:
+		node.Text = string(t.Segment.Value(source))
+		node.LineBreak = t.SoftLineBreak() || t.HardLineBreak()
+		return node
+	case *ast.String:
+		node.Text = string(t.Value)
+		return node
+	case *ast.CodeSpan:
+		node.Text = string(nodeText(t, source))
+		return node
+	case *ast.RawHTML:
+		var content bytes.Buffer
+		for i := 0; i < t.Segments.Len(); i++ {
+			segment := t.Segments.At(i)
+			content.Write(segment.Value(source))
+		}
+		node.Text = content.String()
+		return node
+	case *mathNode:
+		node.Text = string(t.value.Value(source))
+		node.Display = t.display
+		return node
+	case *ast.Emphasis:
+		node.Level = t.Level
+	case *ast.Link:
+		node.URL = string(t.Destination)
+		node.Title = string(t.Title)
+	case *ast.AutoLink:
+		node.URL = string(t.URL(source))
+		node.Text = string(t.Label(source))
+		return node
+	case *ast.Image:
+		node.URL = string(t.Destination)
+		node.Title = string(t.Title)
+		node.Text = string(nodeText(t, source))
+		return node
+	case *ast.List:
+		node.Ordered = t.IsOrdered()
+		if t.IsOrdered() {
+			node.Start = t.Start
+		}
+	case *extast.TaskCheckBox:
+		node.Checked = t.IsChecked
+	case *extast.TableCell:
+		if t.Alignment != extast.AlignNone {
+			node.Align = t.Alignment.String()
+		}
+	}
+
+	node.Children = documentNodes(n, source)
+	return node
+}
+
+// blockText returns the lines of a raw block, such as a code block
+func blockText(n ast.Node, source []byte) []byte {
+	var buf bytes.Buffer
+	lines := n.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		segment := lines.At(i)
+		buf.Write(segment.Value(source))
+	}
+	return buf.Bytes()
+}
+
+// snakeCase turns a node kind such as FencedCodeBlock or HTMLBlock into fenced_code_block or html_block
+func snakeCase(kind string) string {
+	runes := []rune(kind)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			// A word starts after a lower case letter, or at the last capital of an acronym
+			if !unicode.IsUpper(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// sanitizeDocument applies the same policy to a document as to the HTML rendered with it:
+// raw HTML is cleaned, and links and images that don't use a web or mail URL are dropped
+func sanitizeDocument(policy *bluemonday.Policy, nodes []*domain.DocumentNode) {
+	for _, node := range nodes {
+		switch node.Type {
+		case "html_block", "raw_html":
+			node.Text = policy.Sanitize(node.Text)
+		}
+		if node.URL != "" && !safeDocumentURL(node.URL) {
+			node.URL = ""
+		}
+		sanitizeDocument(policy, node.Children)
+	}
+}
+
+// safeDocumentURL reports whether a URL is relative or uses a scheme the sanitizer keeps in links
+func safeDocumentURL(ref string) bool {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "", "http", "https", "mailto":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,125 @@
+package application
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+)
+
+func TestMarkdownRenderer_Document(t *testing.T) {
+	renderer := NewMarkdownRenderer(WithMarkdownExtensions(&MarkdownConfig{Document: true}))
+
+	result, err := renderer.Render([]byte("# Title\n\n## Setup\n\nRead *this* and [the docs](other.md).\n\n```go\nfmt.Println()\n```\n\n- [x] done\n\n<div>raw</div>\n"))
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if result.Document == nil {
+		t.Fatal("Render() returned no document")
+	}
+
+	blocks := result.Document.Blocks
+	var types []string
+	for _, b := range blocks {
+		types = append(types, b.Type)
+	}
+	want := []string{"heading", "heading", "paragraph", "fenced_code_block", "list", "html_block"}
+	if len(types) != len(want) {
+		t.Fatalf("block types = %v, want %v", types, want)
+	}
+	for i := range want {
+		if types[i] != want[i] {
+			t.Errorf("block %d type = %s, want %s", i, types[i], want[i])
+		}
+	}
+
+	if h := blocks[1]; h.Level != 2 || h.ID != "setup" {
+		t.Errorf("heading = %+v, want level 2 with ID setup", h)
+	}
+	paragraph := blocks[2].Children
+	if len(paragraph) < 4 || paragraph[1].Type != "emphasis" || paragraph[1].Level != 1 || paragraph[1].Children[0].Text != "this" {
+		t.Errorf("paragraph children = %+v, want text then emphasis", paragraph)
+	} else if link := paragraph[3]; link.Type != "link" || link.URL != blogURL+"/other" {
+		t.Errorf("link = %+v, want its rendered URL %s/other", link, blogURL)
+	}
+	if code := blocks[3]; code.Language != "go" || code.Text != "fmt.Println()\n" || len(code.Children) != 0 {
+		t.Errorf("code block = %+v, want go code as text", code)
+	}
+	item := blocks[4].Children[0]
+	if len(item.Children) == 0 || len(item.Children[0].Children) == 0 || item.Children[0].Children[0].Type != "task_check_box" || !item.Children[0].Children[0].Checked {
+		t.Errorf("list item = %+v, want a checked task box", item)
+	}
+	if html := blocks[5]; html.Text != "<div>raw</div>\n" {
+		t.Errorf("html block text = %q", html.Text)
+	}
+}
+
+func TestMarkdownRenderer_DocumentSanitized(t *testing.T) {
+	renderer := NewMarkdownRenderer(WithMarkdownExtensions(&MarkdownConfig{Document: true, Sanitize: SanitizeUGC}))
+
+	result, err := renderer.Render([]byte("# Title\n\n<script>alert(1)</script>\n\n[click](javascript:alert(1))\n"))
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	blocks := result.Document.Blocks
+	if html := blocks[1]; html.Type != "html_block" || strings.TrimSpace(html.Text) != "" {
+		t.Errorf("html block = %+v, want the script removed", html)
+	}
+	if link := blocks[2].Children[0]; link.Type != "link" || link.URL != "" {
+		t.Errorf("link = %+v, want the javascript URL dropped", link)
+	}
+}
+
+func TestMarkdownRenderer_DocumentDisabled(t *testing.T) {
+	result, err := NewMarkdownRenderer().Render([]byte("# Title\n\nBody"))
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if result.Document != nil {
+		t.Error("Render() built a document without the option")
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	for kind, want := range map[string]string{
+		"Paragraph":       "paragraph",
+		"FencedCodeBlock": "fenced_code_block",
+		"HTMLBlock":       "html_block",
+		"RawHTML":         "raw_html",
+		"TaskCheckBox":    "task_check_box",
+	} {
+		if got := snakeCase(kind); got != want {
+			t.Errorf("snakeCase(%q) = %q, want %q", kind, got, want)
+		}
+	}
+}
+
+func TestPostService_GetPublishedPostDocument(t *testing.T) {
+	published := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	document := &domain.Document{Blocks: []*domain.DocumentNode{{Type: "paragraph"}}}
+	repo := newFakePostRepository(
+		&domain.Post{ID: "001", PublishedAt: published, Document: document},
+		&domain.Post{ID: "002", PublishedAt: published},
+		&domain.Post{ID: "003", Document: document},
+	)
+	service := NewPostService(repo, newFakeImageRepository(), nil, NewMarkdownRenderer(), "main")
+	defer service.Close()
+
+	post, err := service.GetPublishedPostDocument(t.Context(), "001")
+	if err != nil {
+		t.Fatalf("GetPublishedPostDocument() error = %v", err)
+	}
+	if post.Document != document {
+		t.Errorf("Document = %+v, want the stored document", post.Document)
+	}
+
+	if _, err := service.GetPublishedPostDocument(t.Context(), "002"); !errors.Is(err, ErrNoDocument) {
+		t.Errorf("GetPublishedPostDocument() of a post without a document error = %v, want ErrNoDocument", err)
+	}
+	if _, err := service.GetPublishedPostDocument(t.Context(), "003"); !errors.Is(err, domain.ErrPostNotFound) {
+		t.Errorf("GetPublishedPostDocument() of a draft error = %v, want ErrPostNotFound", err)
+	}
+}
@@ -0,0 +1,1184 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/clock"
+	"github.com/google/go-github/v75/github"
+)
+
+// fakeImageRepository is an in-memory domain.ImageRepository for tests
+type fakeImageRepository struct {
+	images  map[string]*domain.Image
+	deleted []string
+	// postsWithoutImageRefs is what CountPostsWithoutImageRefs reports
+	postsWithoutImageRefs int
+}
+
+func newFakeImageRepository(images ...*domain.Image) *fakeImageRepository {
+	repo := &fakeImageRepository{images: make(map[string]*domain.Image)}
+	for _, img := range images {
+		repo.images[img.Path] = img
+	}
+	return repo
+}
+
+func (f *fakeImageRepository) SaveImage(ctx context.Context, img *domain.Image) error {
+	f.images[img.Path] = img
+	return nil
+}
+
+func (f *fakeImageRepository) GetImage(ctx context.Context, path string) (*domain.Image, error) {
+	img, ok := f.images[path]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", domain.ErrImageNotFound, path)
+	}
+	return img, nil
+}
+
+func (f *fakeImageRepository) GetImageByHash(ctx context.Context, hash string) (*domain.Image, error) {
+	for _, img := range f.images {
+		if img.Hash == hash {
+			return img, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", domain.ErrImageNotFound, hash)
+}
+
+func (f *fakeImageRepository) GetImageContent(ctx context.Context, path string) ([]byte, error) {
+	img, err := f.GetImage(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return img.Content, nil
+}
+
+func (f *fakeImageRepository) DeleteImage(ctx context.Context, path string) error {
+	delete(f.images, path)
+	f.deleted = append(f.deleted, path)
+	return nil
+}
+
+func (f *fakeImageRepository) FlagOrphanedImages(ctx context.Context, at time.Time) error {
+	return nil
+}
+
+func (f *fakeImageRepository) CountPostsWithoutImageRefs(ctx context.Context) (int, error) {
+	return f.postsWithoutImageRefs, nil
+}
+
+func (f *fakeImageRepository) ListOrphanedImages(ctx context.Context) ([]*domain.Image, error) {
+	var orphans []*domain.Image
+	for _, img := range f.images {
+		if !img.OrphanedAt.IsZero() {
+			orphans = append(orphans, img)
+		}
+	}
+	// Oldest first, matching the repository contract
+	sort.Slice(orphans, func(i, j int) bool {
+		return orphans[i].OrphanedAt.Before(orphans[j].OrphanedAt)
+	})
+	return orphans, nil
+}
+
+func (f *fakeImageRepository) ListImages(ctx context.Context) ([]*domain.Image, error) {
+	images := make([]*domain.Image, 0, len(f.images))
+	for _, img := range f.images {
+		images = append(images, img)
+	}
+	sort.Slice(images, func(i, j int) bool {
+		return images[i].Path < images[j].Path
+	})
+	return images, nil
+}
+
+// fakePostRepository is an in-memory domain.PostRepository for tests
+// It is safe for concurrent use, since sync processes files on a worker pool.
+type fakePostRepository struct {
+	mu    sync.Mutex
+	posts map[string]*domain.Post
+}
+
+func newFakePostRepository(posts ...*domain.Post) *fakePostRepository {
+	repo := &fakePostRepository{posts: make(map[string]*domain.Post)}
+	for _, p := range posts {
+		repo.posts[p.ID] = p
+	}
+	return repo
+}
+
+func (f *fakePostRepository) SavePost(ctx context.Context, p *domain.Post) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.posts[p.ID] = p
+	return nil
+}
+
+func (f *fakePostRepository) GetPost(ctx context.Context, id string) (*domain.Post, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.get(id)
+}
+
+func (f *fakePostRepository) get(id string) (*domain.Post, error) {
+	p, ok := f.posts[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", domain.ErrPostNotFound, id)
+	}
+	return p, nil
+}
+
+func (f *fakePostRepository) GetPostHTML(ctx context.Context, id string) ([]byte, error) {
+	p, err := f.GetPost(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return p.HTMLContent, nil
+}
+
+func (f *fakePostRepository) GetPostDocument(ctx context.Context, id string) (*domain.Document, error) {
+	p, err := f.GetPost(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return p.Document, nil
+}
+
+func (f *fakePostRepository) GetLatestUpdatedTime(ctx context.Context) (time.Time, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var latest time.Time
+	for _, p := range f.posts {
+		if p.UpdatedAt.After(latest) {
+			latest = p.UpdatedAt
+		}
+	}
+	return latest, nil
+}
+
+func (f *fakePostRepository) ListPublishedPosts(ctx context.Context, limit int, offset int) ([]*domain.Post, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var published []*domain.Post
+	for _, p := range f.posts {
+		if !p.PublishedAt.IsZero() {
+			published = append(published, p)
+		}
+	}
+	sort.Slice(published, func(i, j int) bool {
+		return published[i].PublishedAt.After(published[j].PublishedAt)
+	})
+	if offset >= len(published) {
+		return []*domain.Post{}, nil
+	}
+	published = published[offset:]
+	if limit < len(published) {
+		published = published[:limit]
+	}
+	return published, nil
+}
+
+func (f *fakePostRepository) GetPostBySlug(ctx context.Context, slug string) (*domain.Post, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, p := range f.posts {
+		if slug != "" && p.Slug == slug {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", domain.ErrPostNotFound, slug)
+}
+
+func (f *fakePostRepository) ListPublishedPostsPage(ctx context.Context, limit int, after *domain.PostCursor) (*domain.PublishedPostPage, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var published []*domain.Post
+	for _, p := range f.posts {
+		if !p.PublishedAt.IsZero() {
+			published = append(published, p)
+		}
+	}
+	sort.Slice(published, func(i, j int) bool {
+		if !published[i].PublishedAt.Equal(published[j].PublishedAt) {
+			return published[i].PublishedAt.After(published[j].PublishedAt)
+		}
+		return published[i].ID > published[j].ID
+	})
+
+	page := &domain.PublishedPostPage{Total: len(published), Posts: []*domain.Post{}}
+	for _, p := range published {
+		if after != nil && (p.PublishedAt.After(after.PublishedAt) || p.PublishedAt.Equal(after.PublishedAt) && p.ID >= after.ID) {
+			continue
+		}
+		if len(page.Posts) == limit {
+			last := page.Posts[limit-1]
+			page.Next = &domain.PostCursor{PublishedAt: last.PublishedAt, ID: last.ID}
+			break
+		}
+		page.Posts = append(page.Posts, p)
+	}
+	return page, nil
+}
+
+func (f *fakePostRepository) ListPublishedPostsBetween(ctx context.Context, start time.Time, end time.Time) ([]*domain.Post, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var published []*domain.Post
+	for _, p := range f.posts {
+		if !p.PublishedAt.IsZero() && !p.PublishedAt.Before(start) && p.PublishedAt.Before(end) {
+			published = append(published, p)
+		}
+	}
+	sort.Slice(published, func(i, j int) bool {
+		return published[i].PublishedAt.After(published[j].PublishedAt)
+	})
+	return published, nil
+}
+
+func (f *fakePostRepository) ListPublishTimes(ctx context.Context) ([]time.Time, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var times []time.Time
+	for _, p := range f.posts {
+		if !p.PublishedAt.IsZero() {
+			times = append(times, p.PublishedAt)
+		}
+	}
+	sort.Slice(times, func(i, j int) bool {
+		return times[i].After(times[j])
+	})
+	return times, nil
+}
+
+func (f *fakePostRepository) ListDuePosts(ctx context.Context, now time.Time) ([]*domain.Post, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var due []*domain.Post
+	for _, p := range f.posts {
+		if !p.PublishAt.IsZero() && !p.PublishAt.After(now) && p.PublishedAt.IsZero() {
+			due = append(due, p)
+		}
+	}
+	return due, nil
+}
+
+func (f *fakePostRepository) ListPostsByImage(ctx context.Context, imagePath string) ([]*domain.Post, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var posts []*domain.Post
+	for _, p := range f.posts {
+		for _, img := range p.Images {
+			if img == imagePath {
+				posts = append(posts, p)
+				break
+			}
+		}
+	}
+	return posts, nil
+}
+
+func (f *fakePostRepository) ListScheduledPosts(ctx context.Context) ([]*domain.Post, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var scheduled []*domain.Post
+	for _, p := range f.posts {
+		if p.PublishedAt.IsZero() && !p.PublishAt.IsZero() {
+			scheduled = append(scheduled, p)
+		}
+	}
+	sort.Slice(scheduled, func(i, j int) bool {
+		return scheduled[i].PublishAt.Before(scheduled[j].PublishAt)
+	})
+	return scheduled, nil
+}
+
+func (f *fakePostRepository) ListPosts(ctx context.Context) ([]*domain.Post, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	posts := make([]*domain.Post, 0, len(f.posts))
+	for _, p := range f.posts {
+		posts = append(posts, p)
+	}
+	sort.Slice(posts, func(i, j int) bool {
+		return posts[i].ID < posts[j].ID
+	})
+	return posts, nil
+}
+
+func (f *fakePostRepository) ListUnpublishedPosts(ctx context.Context) ([]*domain.Post, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var unpublished []*domain.Post
+	for _, p := range f.posts {
+		if p.PublishedAt.IsZero() && p.PublishAt.IsZero() {
+			unpublished = append(unpublished, p)
+		}
+	}
+	sort.Slice(unpublished, func(i, j int) bool {
+		return unpublished[i].UpdatedAt.Before(unpublished[j].UpdatedAt)
+	})
+	return unpublished, nil
+}
+
+func (f *fakePostRepository) Publish(ctx context.Context, postID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p, err := f.get(postID)
+	if err != nil {
+		return err
+	}
+	p.PublishedAt = time.Now().UTC()
+	return nil
+}
+
+func (f *fakePostRepository) Unpublish(ctx context.Context, postID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p, err := f.get(postID)
+	if err != nil {
+		return err
+	}
+	p.PublishedAt = time.Time{}
+	return nil
+}
+
+func (f *fakePostRepository) DeletePost(ctx context.Context, postID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, err := f.get(postID); err != nil {
+		return err
+	}
+	delete(f.posts, postID)
+	return nil
+}
+
+// fakeSourceRepository is an in-memory domain.SourceRepository for tests
+type fakeSourceRepository struct {
+	mu          sync.Mutex
+	commits     map[string]*github.RepositoryCommit
+	comparisons map[string]*github.CommitsComparison
+	// files holds contents by path, or by "ref:path" for a version at a specific ref
+	files    map[string][]byte
+	branches []*github.Branch
+	// trees holds the file tree of each commit by SHA
+	trees map[string]*github.Tree
+
+	getCommitCalls int
+	getFileCalls   int
+	// since is the start of the most recent GetCommitsSince window
+	since time.Time
+}
+
+func newFakeSourceRepository() *fakeSourceRepository {
+	return &fakeSourceRepository{
+		commits:     make(map[string]*github.RepositoryCommit),
+		comparisons: make(map[string]*github.CommitsComparison),
+		files:       make(map[string][]byte),
+		trees:       make(map[string]*github.Tree),
+	}
+}
+
+func (f *fakeSourceRepository) GetCommitsSince(ctx context.Context, branchName string, since time.Time) ([]*github.RepositoryCommit, error) {
+	f.mu.Lock()
+	f.since = since
+	f.mu.Unlock()
+	var commits []*github.RepositoryCommit
+	for _, c := range f.commits {
+		commits = append(commits, c)
+	}
+	return commits, nil
+}
+
+func (f *fakeSourceRepository) GetCommitsInRange(ctx context.Context, baseCommit string, headCommit string) ([]*github.RepositoryCommit, error) {
+	comparison, err := f.CompareCommits(ctx, baseCommit, headCommit)
+	if err != nil {
+		return nil, err
+	}
+	return comparison.Commits, nil
+}
+
+func (f *fakeSourceRepository) CompareCommits(ctx context.Context, baseCommit string, headCommit string) (*github.CommitsComparison, error) {
+	comparison, ok := f.comparisons[baseCommit+"..."+headCommit]
+	if !ok {
+		return nil, fmt.Errorf("no comparison %s...%s", baseCommit, headCommit)
+	}
+	return comparison, nil
+}
+
+func (f *fakeSourceRepository) GetCommit(ctx context.Context, sha string) (*github.RepositoryCommit, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.getCommitCalls++
+	commit, ok := f.commits[sha]
+	if !ok {
+		return nil, fmt.Errorf("commit not found: %s", sha)
+	}
+	return commit, nil
+}
+
+func (f *fakeSourceRepository) GetTree(ctx context.Context, sha string) (*github.Tree, error) {
+	tree, ok := f.trees[sha]
+	if !ok {
+		return nil, fmt.Errorf("tree not found: %s", sha)
+	}
+	return tree, nil
+}
+
+func (f *fakeSourceRepository) GetFileContents(ctx context.Context, path string, ref string) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.getFileCalls++
+	if content, ok := f.files[ref+":"+path]; ok {
+		return content, nil
+	}
+	content, ok := f.files[path]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", domain.ErrSourceFileNotFound, path)
+	}
+	return content, nil
+}
+
+func (f *fakeSourceRepository) ListBranches(ctx context.Context) ([]*github.Branch, error) {
+	return f.branches, nil
+}
+
+func (f *fakeSourceRepository) GetDefaultBranchName(ctx context.Context) (string, error) {
+	return "main", nil
+}
+
+func (f *fakeSourceRepository) GetRepoFullName() string {
+	return "owner/repo"
+}
+
+// fakeSourceCache is an in-memory domain.SourceCache for tests
+type fakeSourceCache struct {
+	commits     map[string]*github.RepositoryCommit
+	files       map[string][]byte
+	blobs       map[string][]byte
+	trees       map[string]*github.Tree
+	comparisons map[string]*github.CommitsComparison
+}
+
+func newFakeSourceCache() *fakeSourceCache {
+	return &fakeSourceCache{
+		commits:     make(map[string]*github.RepositoryCommit),
+		files:       make(map[string][]byte),
+		blobs:       make(map[string][]byte),
+		trees:       make(map[string]*github.Tree),
+		comparisons: make(map[string]*github.CommitsComparison),
+	}
+}
+
+func (f *fakeSourceCache) GetCommit(ctx context.Context, sha string) (*github.RepositoryCommit, error) {
+	commit, ok := f.commits[sha]
+	if !ok {
+		return nil, domain.ErrSourceCacheMiss
+	}
+	return commit, nil
+}
+
+func (f *fakeSourceCache) SaveCommit(ctx context.Context, commit *github.RepositoryCommit) error {
+	f.commits[commit.GetSHA()] = commit
+	return nil
+}
+
+func (f *fakeSourceCache) GetFile(ctx context.Context, path string, ref string) ([]byte, error) {
+	content, ok := f.files[ref+":"+path]
+	if !ok {
+		return nil, domain.ErrSourceCacheMiss
+	}
+	return content, nil
+}
+
+func (f *fakeSourceCache) SaveFile(ctx context.Context, path string, ref string, content []byte) error {
+	f.files[ref+":"+path] = content
+	f.blobs[domain.GitBlobSHA(content)] = content
+	return nil
+}
+
+func (f *fakeSourceCache) GetBlob(ctx context.Context, blobSHA string) ([]byte, error) {
+	content, ok := f.blobs[blobSHA]
+	if !ok {
+		return nil, domain.ErrSourceCacheMiss
+	}
+	return content, nil
+}
+
+func (f *fakeSourceCache) GetTree(ctx context.Context, sha string) (*github.Tree, error) {
+	tree, ok := f.trees[sha]
+	if !ok {
+		return nil, domain.ErrSourceCacheMiss
+	}
+	return tree, nil
+}
+
+func (f *fakeSourceCache) SaveTree(ctx context.Context, sha string, tree *github.Tree) error {
+	f.trees[sha] = tree
+	return nil
+}
+
+func (f *fakeSourceCache) GetComparison(ctx context.Context, base string, head string) (*github.CommitsComparison, error) {
+	comparison, ok := f.comparisons[base+"..."+head]
+	if !ok {
+		return nil, domain.ErrSourceCacheMiss
+	}
+	return comparison, nil
+}
+
+func (f *fakeSourceCache) SaveComparison(ctx context.Context, base string, head string, comparison *github.CommitsComparison) error {
+	f.comparisons[base+"..."+head] = comparison
+	return nil
+}
+
+// fakeAPITokenRepository is an in-memory domain.APITokenRepository for tests
+type fakeAPITokenRepository struct {
+	tokens  map[int64]*domain.APIToken
+	hashes  map[string]int64
+	touched []int64
+	nextID  int64
+}
+
+func newFakeAPITokenRepository() *fakeAPITokenRepository {
+	return &fakeAPITokenRepository{
+		tokens: make(map[int64]*domain.APIToken),
+		hashes: make(map[string]int64),
+	}
+}
+
+func (f *fakeAPITokenRepository) CreateToken(ctx context.Context, token *domain.APIToken, hash string) error {
+	f.nextID++
+	token.ID = f.nextID
+	f.tokens[token.ID] = token
+	f.hashes[hash] = token.ID
+	return nil
+}
+
+func (f *fakeAPITokenRepository) GetTokenByHash(ctx context.Context, hash string) (*domain.APIToken, error) {
+	id, ok := f.hashes[hash]
+	if !ok || !f.tokens[id].RevokedAt.IsZero() {
+		return nil, domain.ErrAPITokenNotFound
+	}
+	return f.tokens[id], nil
+}
+
+func (f *fakeAPITokenRepository) ListTokens(ctx context.Context) ([]*domain.APIToken, error) {
+	tokens := make([]*domain.APIToken, 0, len(f.tokens))
+	for _, token := range f.tokens {
+		tokens = append(tokens, token)
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].ID > tokens[j].ID
+	})
+	return tokens, nil
+}
+
+func (f *fakeAPITokenRepository) RevokeToken(ctx context.Context, id int64) error {
+	token, ok := f.tokens[id]
+	if !ok || !token.RevokedAt.IsZero() {
+		return fmt.Errorf("%w: %d", domain.ErrAPITokenNotFound, id)
+	}
+	token.RevokedAt = time.Now().UTC()
+	return nil
+}
+
+func (f *fakeAPITokenRepository) TouchToken(ctx context.Context, id int64) error {
+	f.touched = append(f.touched, id)
+	return nil
+}
+
+// fakeReaderPreferencesRepository is an in-memory domain.ReaderPreferencesRepository for tests
+type fakeReaderPreferencesRepository struct {
+	prefs map[string]domain.ReaderPreferences
+	clock clock.Clock
+}
+
+func newFakeReaderPreferencesRepository(c clock.Clock) *fakeReaderPreferencesRepository {
+	return &fakeReaderPreferencesRepository{
+		prefs: make(map[string]domain.ReaderPreferences),
+		clock: c,
+	}
+}
+
+func (f *fakeReaderPreferencesRepository) GetPreferences(ctx context.Context, hash string, cutoff time.Time) (*domain.ReaderPreferences, error) {
+	prefs, ok := f.prefs[hash]
+	if !ok || prefs.LastSeenAt.Before(cutoff) {
+		return nil, domain.ErrReaderPreferencesNotFound
+	}
+	prefs.LastSeenAt = f.clock.Now()
+	f.prefs[hash] = prefs
+	return &prefs, nil
+}
+
+func (f *fakeReaderPreferencesRepository) SavePreferences(ctx context.Context, hash string, prefs *domain.ReaderPreferences) error {
+	prefs.UpdatedAt = f.clock.Now()
+	prefs.LastSeenAt = prefs.UpdatedAt
+	f.prefs[hash] = *prefs
+	return nil
+}
+
+func (f *fakeReaderPreferencesRepository) DeletePreferences(ctx context.Context, hash string) error {
+	if _, ok := f.prefs[hash]; !ok {
+		return domain.ErrReaderPreferencesNotFound
+	}
+	delete(f.prefs, hash)
+	return nil
+}
+
+func (f *fakeReaderPreferencesRepository) DeleteUnseenPreferences(ctx context.Context, cutoff time.Time) (int, error) {
+	deleted := 0
+	for hash, prefs := range f.prefs {
+		if prefs.LastSeenAt.Before(cutoff) {
+			delete(f.prefs, hash)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// fakeProcessedCommitRepository is an in-memory domain.ProcessedCommitRepository for tests
+type fakeProcessedCommitRepository struct {
+	mu        sync.Mutex
+	processed map[string]bool
+}
+
+func newFakeProcessedCommitRepository() *fakeProcessedCommitRepository {
+	return &fakeProcessedCommitRepository{processed: make(map[string]bool)}
+}
+
+func (f *fakeProcessedCommitRepository) ListProcessed(ctx context.Context, branch string, shas []string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	processed := []string{}
+	for _, sha := range shas {
+		if f.processed[branch+":"+sha] {
+			processed = append(processed, sha)
+		}
+	}
+	return processed, nil
+}
+
+func (f *fakeProcessedCommitRepository) MarkProcessed(ctx context.Context, branch string, shas []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, sha := range shas {
+		f.processed[branch+":"+sha] = true
+	}
+	return nil
+}
+
+func (f *fakeProcessedCommitRepository) PruneProcessed(ctx context.Context, before time.Time) error {
+	return nil
+}
+
+// fakeWebhookDeliveryRepository is an in-memory domain.WebhookDeliveryRepository for tests
+type fakeWebhookDeliveryRepository struct {
+	mu         sync.Mutex
+	deliveries map[string]bool
+}
+
+func newFakeWebhookDeliveryRepository() *fakeWebhookDeliveryRepository {
+	return &fakeWebhookDeliveryRepository{deliveries: make(map[string]bool)}
+}
+
+func (f *fakeWebhookDeliveryRepository) ClaimDelivery(ctx context.Context, id string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.deliveries[id] {
+		return false, nil
+	}
+	f.deliveries[id] = true
+	return true, nil
+}
+
+func (f *fakeWebhookDeliveryRepository) ReleaseDelivery(ctx context.Context, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.deliveries, id)
+	return nil
+}
+
+func (f *fakeWebhookDeliveryRepository) PruneDeliveries(ctx context.Context, before time.Time) error {
+	return nil
+}
+
+type fakePostViewRepository struct {
+	mu    sync.Mutex
+	views map[time.Time]map[string]int
+	// added is sent each batch of views as it is added
+	added chan map[string]int
+}
+
+func newFakePostViewRepository() *fakePostViewRepository {
+	return &fakePostViewRepository{views: make(map[time.Time]map[string]int), added: make(chan map[string]int, 10)}
+}
+
+func (f *fakePostViewRepository) AddPostViews(ctx context.Context, day time.Time, views map[string]int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.views[day] == nil {
+		f.views[day] = make(map[string]int)
+	}
+	added := make(map[string]int)
+	for postID, n := range views {
+		f.views[day][postID] += n
+		added[postID] = n
+	}
+	f.added <- added
+	return nil
+}
+
+func (f *fakePostViewRepository) ListPopularPosts(ctx context.Context, since time.Time, limit int) ([]*domain.PostViews, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	totals := make(map[string]int)
+	for day, views := range f.views {
+		if day.Before(since) {
+			continue
+		}
+		for postID, n := range views {
+			totals[postID] += n
+		}
+	}
+
+	popular := make([]*domain.PostViews, 0, len(totals))
+	for postID, n := range totals {
+		popular = append(popular, &domain.PostViews{PostID: postID, Views: n})
+	}
+	sort.Slice(popular, func(i, j int) bool {
+		if popular[i].Views != popular[j].Views {
+			return popular[i].Views > popular[j].Views
+		}
+		return popular[i].PostID < popular[j].PostID
+	})
+	return popular[:min(limit, len(popular))], nil
+}
+
+type fakeRedirectRepository struct {
+	mu        sync.Mutex
+	redirects map[string]*domain.Redirect
+}
+
+func newFakeRedirectRepository(redirects ...*domain.Redirect) *fakeRedirectRepository {
+	f := &fakeRedirectRepository{redirects: make(map[string]*domain.Redirect)}
+	for _, redirect := range redirects {
+		f.redirects[redirect.FromSlug] = redirect
+	}
+	return f
+}
+
+func (f *fakeRedirectRepository) SaveRedirect(ctx context.Context, redirect *domain.Redirect) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	stored := *redirect
+	f.redirects[redirect.FromSlug] = &stored
+	return nil
+}
+
+func (f *fakeRedirectRepository) GetRedirect(ctx context.Context, fromSlug string) (*domain.Redirect, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	redirect, ok := f.redirects[fromSlug]
+	if !ok {
+		return nil, domain.ErrRedirectNotFound
+	}
+	return redirect, nil
+}
+
+func (f *fakeRedirectRepository) ListRedirects(ctx context.Context) ([]*domain.Redirect, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	redirects := make([]*domain.Redirect, 0, len(f.redirects))
+	for _, redirect := range f.redirects {
+		redirects = append(redirects, redirect)
+	}
+	return redirects, nil
+}
+
+func (f *fakeRedirectRepository) DeleteRedirect(ctx context.Context, fromSlug string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.redirects[fromSlug]; !ok {
+		return domain.ErrRedirectNotFound
+	}
+	delete(f.redirects, fromSlug)
+	return nil
+}
+
+// fakeWebmentionRepository is an in-memory domain.WebmentionRepository for tests
+type fakeWebmentionRepository struct {
+	mu       sync.Mutex
+	nextID   int64
+	mentions map[string]*domain.Webmention
+	// changed receives the source of each mention saved or deleted
+	changed chan string
+}
+
+func newFakeWebmentionRepository() *fakeWebmentionRepository {
+	return &fakeWebmentionRepository{mentions: make(map[string]*domain.Webmention), changed: make(chan string, 10)}
+}
+
+func (f *fakeWebmentionRepository) SaveWebmention(ctx context.Context, mention *domain.Webmention) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := mention.Source + " " + mention.PostID
+	if existing, ok := f.mentions[key]; ok {
+		mention.ID, mention.Status, mention.ReceivedAt = existing.ID, existing.Status, existing.ReceivedAt
+	} else {
+		f.nextID++
+		mention.ID = f.nextID
+	}
+	stored := *mention
+	f.mentions[key] = &stored
+	f.changed <- mention.Source
+	return nil
+}
+
+func (f *fakeWebmentionRepository) DeleteWebmention(ctx context.Context, source string, postID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.mentions, source+" "+postID)
+	f.changed <- source
+	return nil
+}
+
+func (f *fakeWebmentionRepository) ListWebmentions(ctx context.Context, status domain.WebmentionStatus) ([]*domain.Webmention, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var mentions []*domain.Webmention
+	for _, mention := range f.mentions {
+		if status == "" || mention.Status == status {
+			mentions = append(mentions, mention)
+		}
+	}
+	sort.Slice(mentions, func(i, j int) bool { return mentions[i].ID > mentions[j].ID })
+	return mentions, nil
+}
+
+func (f *fakeWebmentionRepository) ListPostWebmentions(ctx context.Context, postID string, status domain.WebmentionStatus) ([]*domain.Webmention, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var mentions []*domain.Webmention
+	for _, mention := range f.mentions {
+		if mention.PostID == postID && mention.Status == status {
+			mentions = append(mentions, mention)
+		}
+	}
+	sort.Slice(mentions, func(i, j int) bool { return mentions[i].ID < mentions[j].ID })
+	return mentions, nil
+}
+
+func (f *fakeWebmentionRepository) SetWebmentionStatus(ctx context.Context, id int64, status domain.WebmentionStatus) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, mention := range f.mentions {
+		if mention.ID == id {
+			mention.Status = status
+			return nil
+		}
+	}
+	return domain.ErrWebmentionNotFound
+}
+
+func (f *fakeWebmentionRepository) get(source string, postID string) *domain.Webmention {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.mentions[source+" "+postID]
+}
+
+// fakeFederationRepository is an in-memory domain.FederationRepository for tests
+type fakeFederationRepository struct {
+	mu        sync.Mutex
+	followers []*domain.Follower
+	announced map[string]time.Time
+}
+
+func newFakeFederationRepository(followers ...*domain.Follower) *fakeFederationRepository {
+	return &fakeFederationRepository{followers: followers, announced: make(map[string]time.Time)}
+}
+
+func (f *fakeFederationRepository) SaveFollower(ctx context.Context, follower *domain.Follower) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, existing := range f.followers {
+		if existing.ActorID == follower.ActorID {
+			existing.Inbox, existing.SharedInbox = follower.Inbox, follower.SharedInbox
+			return nil
+		}
+	}
+	stored := *follower
+	f.followers = append(f.followers, &stored)
+	return nil
+}
+
+func (f *fakeFederationRepository) DeleteFollower(ctx context.Context, actorID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, existing := range f.followers {
+		if existing.ActorID == actorID {
+			f.followers = append(f.followers[:i], f.followers[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (f *fakeFederationRepository) ListFollowers(ctx context.Context) ([]*domain.Follower, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*domain.Follower(nil), f.followers...), nil
+}
+
+func (f *fakeFederationRepository) MarkAnnounced(ctx context.Context, postID string, at time.Time) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.announced[postID]; ok {
+		return false, nil
+	}
+	f.announced[postID] = at
+	return true, nil
+}
+
+func (f *fakeFederationRepository) ForgetAnnounced(ctx context.Context, postID string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.announced[postID]
+	delete(f.announced, postID)
+	return ok, nil
+}
+
+// fakeNewsletterRepository is an in-memory domain.NewsletterRepository for tests
+type fakeNewsletterRepository struct {
+	mu          sync.Mutex
+	subscribers map[string]*domain.Subscriber
+	mailed      map[string]time.Time
+}
+
+func newFakeNewsletterRepository(subscribers ...*domain.Subscriber) *fakeNewsletterRepository {
+	f := &fakeNewsletterRepository{subscribers: make(map[string]*domain.Subscriber), mailed: make(map[string]time.Time)}
+	for _, s := range subscribers {
+		f.subscribers[s.Email] = s
+	}
+	return f
+}
+
+func (f *fakeNewsletterRepository) SaveSubscriber(ctx context.Context, subscriber *domain.Subscriber) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if existing, ok := f.subscribers[subscriber.Email]; ok && !existing.ConfirmedAt.IsZero() {
+		return nil
+	}
+	stored := *subscriber
+	stored.ConfirmedAt = time.Time{}
+	f.subscribers[subscriber.Email] = &stored
+	return nil
+}
+
+func (f *fakeNewsletterRepository) GetSubscriber(ctx context.Context, email string) (*domain.Subscriber, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s, ok := f.subscribers[email]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", domain.ErrSubscriberNotFound, email)
+	}
+	copied := *s
+	return &copied, nil
+}
+
+func (f *fakeNewsletterRepository) GetSubscriberByToken(ctx context.Context, token string) (*domain.Subscriber, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, s := range f.subscribers {
+		if s.Token == token {
+			copied := *s
+			return &copied, nil
+		}
+	}
+	return nil, domain.ErrSubscriberNotFound
+}
+
+func (f *fakeNewsletterRepository) ConfirmSubscriber(ctx context.Context, email string, at time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s, ok := f.subscribers[email]
+	if !ok {
+		return fmt.Errorf("%w: %s", domain.ErrSubscriberNotFound, email)
+	}
+	if s.ConfirmedAt.IsZero() {
+		s.ConfirmedAt = at
+	}
+	return nil
+}
+
+func (f *fakeNewsletterRepository) DeleteSubscriber(ctx context.Context, email string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.subscribers, email)
+	return nil
+}
+
+func (f *fakeNewsletterRepository) ListConfirmedSubscribers(ctx context.Context) ([]*domain.Subscriber, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var confirmed []*domain.Subscriber
+	for _, s := range f.subscribers {
+		if !s.ConfirmedAt.IsZero() {
+			copied := *s
+			confirmed = append(confirmed, &copied)
+		}
+	}
+	sort.Slice(confirmed, func(i, j int) bool { return confirmed[i].Email < confirmed[j].Email })
+	return confirmed, nil
+}
+
+func (f *fakeNewsletterRepository) MarkMailed(ctx context.Context, postID string, at time.Time) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.mailed[postID]; ok {
+		return false, nil
+	}
+	f.mailed[postID] = at
+	return true, nil
+}
+
+type fakeDeadLetterRepository struct {
+	mu      sync.Mutex
+	letters map[string]*domain.DeadLetter
+}
+
+func newFakeDeadLetterRepository(letters ...*domain.DeadLetter) *fakeDeadLetterRepository {
+	f := &fakeDeadLetterRepository{letters: make(map[string]*domain.DeadLetter)}
+	for _, dl := range letters {
+		f.letters[dl.Path] = dl
+	}
+	return f
+}
+
+func (f *fakeDeadLetterRepository) RecordFailure(ctx context.Context, path string, ref string, fileErr error) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	dl, ok := f.letters[path]
+	if !ok {
+		dl = &domain.DeadLetter{Path: path}
+		f.letters[path] = dl
+	}
+	if dl.Ref != ref {
+		dl.RefAttempts = 0
+		dl.PoisonedAt = time.Time{}
+	}
+	dl.Ref = ref
+	dl.Error = fileErr.Error()
+	dl.Attempts++
+	dl.RefAttempts++
+	return nil
+}
+
+func (f *fakeDeadLetterRepository) GetDeadLetter(ctx context.Context, path string) (*domain.DeadLetter, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	dl, ok := f.letters[path]
+	if !ok {
+		return nil, domain.ErrDeadLetterNotFound
+	}
+	c := *dl
+	return &c, nil
+}
+
+func (f *fakeDeadLetterRepository) Poison(ctx context.Context, path string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if dl, ok := f.letters[path]; ok && dl.PoisonedAt.IsZero() {
+		dl.PoisonedAt = time.Now()
+	}
+	return nil
+}
+
+func (f *fakeDeadLetterRepository) Release(ctx context.Context, path string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	dl, ok := f.letters[path]
+	if !ok {
+		return domain.ErrDeadLetterNotFound
+	}
+	dl.RefAttempts = 0
+	dl.PoisonedAt = time.Time{}
+	return nil
+}
+
+func (f *fakeDeadLetterRepository) Resolve(ctx context.Context, path string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.letters, path)
+	return nil
+}
+
+func (f *fakeDeadLetterRepository) ListDeadLetters(ctx context.Context) ([]*domain.DeadLetter, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	letters := make([]*domain.DeadLetter, 0, len(f.letters))
+	for _, dl := range f.letters {
+		letters = append(letters, dl)
+	}
+	sort.Slice(letters, func(i, j int) bool {
+		return letters[i].Path < letters[j].Path
+	})
+	return letters, nil
+}
+
+type fakePendingRenderRepository struct {
+	mu      sync.Mutex
+	renders map[string]*domain.PendingRender
+}
+
+func newFakePendingRenderRepository(renders ...*domain.PendingRender) *fakePendingRenderRepository {
+	f := &fakePendingRenderRepository{renders: make(map[string]*domain.PendingRender)}
+	for _, r := range renders {
+		f.renders[r.Branch+":"+r.Path] = r
+	}
+	return f
+}
+
+func (f *fakePendingRenderRepository) SavePendingRenders(ctx context.Context, renders []*domain.PendingRender) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, r := range renders {
+		f.renders[r.Branch+":"+r.Path] = r
+	}
+	return nil
+}
+
+func (f *fakePendingRenderRepository) ClearPendingRender(ctx context.Context, branch string, path string, commitSHA string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r, ok := f.renders[branch+":"+path]; ok && r.CommitSHA == commitSHA {
+		delete(f.renders, branch+":"+path)
+	}
+	return nil
+}
+
+func (f *fakePendingRenderRepository) ListPendingRenders(ctx context.Context) ([]*domain.PendingRender, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	renders := make([]*domain.PendingRender, 0, len(f.renders))
+	for _, r := range f.renders {
+		renders = append(renders, r)
+	}
+	sort.Slice(renders, func(i, j int) bool {
+		return renders[i].Path < renders[j].Path
+	})
+	return renders, nil
+}
+
+// pending returns the paths of the pending renders
+func (f *fakePendingRenderRepository) pending() []string {
+	renders, _ := f.ListPendingRenders(context.Background())
+	paths := make([]string, 0, len(renders))
+	for _, r := range renders {
+		paths = append(paths, r.Path)
+	}
+	return paths
+}
@@ -0,0 +1,514 @@
+package application
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/activitypub"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// Paths of the blog's ActivityPub actor and the collections and objects it serves, below the site's base URL
+	FederationActorPath     = "/federation/actor"
+	FederationInboxPath     = "/federation/inbox"
+	FederationOutboxPath    = "/federation/outbox"
+	FederationFollowersPath = "/federation/followers"
+	FederationPostsPath     = "/federation/posts/"
+
+	defaultFederationUsername = "blog"
+	// federationQueue is how many deliveries may wait to be sent before more are dropped
+	federationQueue = 1000
+	// federationOutboxSize is how many of the latest posts the outbox lists
+	federationOutboxSize   = 20
+	federationFetchTimeout = 10 * time.Second
+	// maxDeliveryAttempts is how many times an activity is sent to an inbox that fails, each retry waiting twice as
+	// long as the last
+	maxDeliveryAttempts = 4
+	deliveryRetryDelay  = time.Minute
+)
+
+var (
+	// ErrFederationDisabled is returned for federation requests when the blog is not federated
+	ErrFederationDisabled = errors.New("federation is not enabled")
+	// ErrUnknownResource is returned for a WebFinger lookup of anything other than the blog's actor
+	ErrUnknownResource = errors.New("unknown webfinger resource")
+	// ErrInvalidActivity is returned for an activity sent to the inbox that can't be understood or acted on
+	ErrInvalidActivity = errors.New("invalid activity")
+)
+
+type FederationConfig struct {
+	// Enabled publishes the blog as an ActivityPub actor, which other servers' users can follow
+	Enabled bool
+	// Username is the name the blog is followed by, as in @blog@example.com
+	Username string
+	// Name is the display name of the actor
+	Name string
+	// BaseURL is where the blog is served, and the host of its address
+	BaseURL string
+	// KeyFile is a PEM RSA private key that signs the blog's requests to other servers
+	KeyFile string
+}
+
+func NewFederationConfig() *FederationConfig {
+	baseURL := strings.TrimSuffix(os.Getenv("SITE_BASE_URL"), "/")
+	if baseURL == "" {
+		baseURL = blogURL
+	}
+	username := os.Getenv("FEDERATION_USERNAME")
+	if username == "" {
+		username = defaultFederationUsername
+	}
+	name := os.Getenv("SITE_TITLE")
+	if name == "" {
+		name = username
+	}
+
+	return &FederationConfig{
+		Enabled:  os.Getenv("FEDERATION") == "true",
+		Username: username,
+		Name:     name,
+		BaseURL:  baseURL,
+		KeyFile:  os.Getenv("FEDERATION_KEY_FILE"),
+	}
+}
+
+// PrivateKey reads the key the actor signs with, returning nil when federation is disabled
+func (c *FederationConfig) PrivateKey() (*rsa.PrivateKey, error) {
+	if !c.Enabled {
+		return nil, nil
+	}
+	if c.KeyFile == "" {
+		return nil, errors.New("FEDERATION_KEY_FILE is required when federation is enabled")
+	}
+
+	data, err := os.ReadFile(c.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read federation key: %w", err)
+	}
+	return activitypub.ParsePrivateKey(data)
+}
+
+// actorID is the URL of the blog's actor
+func (c *FederationConfig) actorID() string {
+	return c.BaseURL + FederationActorPath
+}
+
+// WithFederation publishes the blog as an ActivityPub actor when cfg enables it, announcing posts to its
+// followers as they are published, edited and taken down
+func WithFederation(federation domain.FederationRepository, cfg *FederationConfig, key *rsa.PrivateKey) PostServiceOption {
+	return func(s *PostService) {
+		if !cfg.Enabled || key == nil {
+			return
+		}
+		s.federation = federation
+		s.federationConfig = cfg
+		s.federationKey = key
+		s.federationClient = activitypub.NewClient(newPublicClient(federationFetchTimeout), cfg.actorID()+"#main-key", key,
+			"goblog (+"+cfg.BaseURL+")")
+	}
+}
+
+// federationDelivery is an activity waiting to be sent to an inbox
+type federationDelivery struct {
+	inbox    string
+	activity *activitypub.Activity
+	// attempt counts the times sending it has failed
+	attempt int
+}
+
+// startFederationDelivery sends queued activities one at a time until the service is closed
+// Deliveries still waiting when it closes are dropped.
+func (s *PostService) startFederationDelivery() {
+	s.federationCh = make(chan federationDelivery, federationQueue)
+	s.wg.Go(func() {
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case delivery := <-s.federationCh:
+				s.deliverActivity(delivery)
+			}
+		}
+	})
+}
+
+// deliverActivity sends an activity to its inbox, scheduling a retry if the inbox may accept it later
+func (s *PostService) deliverActivity(delivery federationDelivery) {
+	err := s.federationClient.Deliver(s.ctx, delivery.inbox, delivery.activity)
+	if err == nil {
+		log.Debug().Str("inbox", delivery.inbox).Str("activity", delivery.activity.ID).Msg("Delivered activity")
+		return
+	}
+
+	delivery.attempt++
+	if errors.Is(err, activitypub.ErrRejected) || delivery.attempt >= maxDeliveryAttempts || s.ctx.Err() != nil {
+		log.Warn().Err(err).Str("inbox", delivery.inbox).Str("activity", delivery.activity.ID).Msg("Failed to deliver activity")
+		return
+	}
+
+	delay := deliveryRetryDelay << (delivery.attempt - 1)
+	log.Info().Err(err).Str("inbox", delivery.inbox).Dur("retryIn", delay).Msg("Activity delivery failed, will retry")
+	time.AfterFunc(delay, func() {
+		if s.ctx.Err() == nil {
+			s.enqueueDelivery(delivery)
+		}
+	})
+}
+
+// enqueueDelivery queues an activity for delivery, dropping it rather than blocking when the queue is full
+func (s *PostService) enqueueDelivery(delivery federationDelivery) {
+	select {
+	case s.federationCh <- delivery:
+	default:
+		log.Warn().Str("inbox", delivery.inbox).Str("activity", delivery.activity.ID).Msg("Federation queue is full, dropping activity")
+	}
+}
+
+// deliverToFollowers queues an activity for every follower, once per server that has a shared inbox
+func (s *PostService) deliverToFollowers(ctx context.Context, activity *activitypub.Activity) error {
+	followers, err := s.federation.ListFollowers(ctx)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	for _, follower := range followers {
+		inbox := follower.SharedInbox
+		if inbox == "" {
+			inbox = follower.Inbox
+		}
+		if seen[inbox] {
+			continue
+		}
+		seen[inbox] = true
+		s.enqueueDelivery(federationDelivery{inbox: inbox, activity: activity})
+	}
+	return nil
+}
+
+// announcePost sends followers a Create of a newly published post, or an Update of one announced before
+// Failures are logged, since the post is published either way.
+func (s *PostService) announcePost(ctx context.Context, postID string) {
+	post, err := s.repo.GetPost(ctx, postID)
+	if err != nil {
+		log.Error().Err(err).Str("postID", postID).Msg("Failed to load published post for federation")
+		return
+	}
+
+	now := s.clock.Now().UTC()
+	first, err := s.federation.MarkAnnounced(ctx, postID, now)
+	if err != nil {
+		log.Error().Err(err).Str("postID", postID).Msg("Failed to record announced post")
+		return
+	}
+
+	note := s.postNote(post)
+	activity := &activitypub.Activity{
+		Context:   activitypub.Context,
+		ID:        note.ID + "#create",
+		Type:      "Create",
+		Actor:     note.AttributedTo,
+		Published: note.Published,
+		To:        note.To,
+		CC:        note.CC,
+		Object:    note,
+	}
+	if !first {
+		note.Updated = now.Format(time.RFC3339)
+		activity.ID = note.ID + "#update-" + strconv.FormatInt(now.Unix(), 10)
+		activity.Type = "Update"
+	}
+
+	if err := s.deliverToFollowers(ctx, activity); err != nil {
+		log.Error().Err(err).Str("postID", postID).Msg("Failed to announce post")
+		return
+	}
+	log.Info().Str("postID", postID).Str("activity", activity.Type).Msg("Announced post to followers")
+}
+
+// withdrawPost sends followers a Delete of an announced post that was unpublished or deleted
+func (s *PostService) withdrawPost(ctx context.Context, postID string) {
+	announced, err := s.federation.ForgetAnnounced(ctx, postID)
+	if err != nil {
+		log.Error().Err(err).Str("postID", postID).Msg("Failed to forget announced post")
+		return
+	}
+	if !announced {
+		return
+	}
+
+	noteID := s.federationConfig.BaseURL + FederationPostsPath + postID
+	activity := &activitypub.Activity{
+		Context: activitypub.Context,
+		ID:      noteID + "#delete-" + strconv.FormatInt(s.clock.Now().Unix(), 10),
+		Type:    "Delete",
+		Actor:   s.federationConfig.actorID(),
+		To:      []string{activitypub.Public},
+		Object:  &activitypub.Tombstone{ID: noteID, Type: "Tombstone"},
+	}
+	if err := s.deliverToFollowers(ctx, activity); err != nil {
+		log.Error().Err(err).Str("postID", postID).Msg("Failed to withdraw post")
+		return
+	}
+	log.Info().Str("postID", postID).Msg("Withdrew post from followers")
+}
+
+// postNote is a post as followers see it: its title, snippet and a link to read it on the blog
+// The note's ID names the post by ID, so it stays the same if the post gains or changes a slug.
+func (s *PostService) postNote(post *domain.Post) *activitypub.Note {
+	cfg := s.federationConfig
+	postURL := cfg.BaseURL + post.URLPath()
+
+	var content strings.Builder
+	content.WriteString("<p><strong>" + html.EscapeString(post.Title) + "</strong></p>")
+	if post.Snippet != "" {
+		content.WriteString("<p>" + html.EscapeString(post.Snippet) + "</p>")
+	}
+	escapedURL := html.EscapeString(postURL)
+	content.WriteString(`<p><a href="` + escapedURL + `">` + escapedURL + `</a></p>`)
+
+	return &activitypub.Note{
+		ID:           cfg.BaseURL + FederationPostsPath + post.ID,
+		Type:         "Note",
+		AttributedTo: cfg.actorID(),
+		Content:      content.String(),
+		URL:          postURL,
+		Published:    post.PublishedAt.UTC().Format(time.RFC3339),
+		To:           []string{activitypub.Public},
+		CC:           []string{cfg.BaseURL + FederationFollowersPath},
+	}
+}
+
+// FederationActor returns the document of the blog's actor
+func (s *PostService) FederationActor() (*activitypub.Actor, error) {
+	if s.federation == nil {
+		return nil, ErrFederationDisabled
+	}
+
+	keyPEM, err := activitypub.PublicKeyPEM(&s.federationKey.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	cfg := s.federationConfig
+	return &activitypub.Actor{
+		Context:           activitypub.ActorContext,
+		ID:                cfg.actorID(),
+		Type:              "Person",
+		PreferredUsername: cfg.Username,
+		Name:              cfg.Name,
+		URL:               cfg.BaseURL + "/",
+		Inbox:             cfg.BaseURL + FederationInboxPath,
+		Outbox:            cfg.BaseURL + FederationOutboxPath,
+		Followers:         cfg.BaseURL + FederationFollowersPath,
+		PublicKey: &activitypub.PublicKey{
+			ID:           cfg.actorID() + "#main-key",
+			Owner:        cfg.actorID(),
+			PublicKeyPEM: keyPEM,
+		},
+	}, nil
+}
+
+// FederationWebFinger resolves the blog's acct: address, or its actor's URL, to the actor
+func (s *PostService) FederationWebFinger(resource string) (*activitypub.WebFinger, error) {
+	if s.federation == nil {
+		return nil, ErrFederationDisabled
+	}
+
+	cfg := s.federationConfig
+	base, err := url.Parse(cfg.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+	subject := "acct:" + cfg.Username + "@" + base.Host
+	if !strings.EqualFold(resource, subject) && resource != cfg.actorID() {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownResource, resource)
+	}
+
+	return &activitypub.WebFinger{
+		Subject: subject,
+		Aliases: []string{cfg.actorID()},
+		Links: []activitypub.WebFingerLink{
+			{Rel: "self", Type: activitypub.ContentType, Href: cfg.actorID()},
+			{Rel: "http://webfinger.net/rel/profile-page", Type: "text/html", Href: cfg.BaseURL + "/"},
+		},
+	}, nil
+}
+
+// FederationOutbox lists the latest published posts as the activities that created them, newest first
+func (s *PostService) FederationOutbox(ctx context.Context) (*activitypub.OrderedCollection, error) {
+	if s.federation == nil {
+		return nil, ErrFederationDisabled
+	}
+
+	page, err := s.repo.ListPublishedPostsPage(ctx, federationOutboxSize, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	outbox := &activitypub.OrderedCollection{
+		Context:    activitypub.Context,
+		ID:         s.federationConfig.BaseURL + FederationOutboxPath,
+		Type:       "OrderedCollection",
+		TotalItems: page.Total,
+	}
+	for _, post := range page.Posts {
+		note := s.postNote(post)
+		outbox.OrderedItems = append(outbox.OrderedItems, &activitypub.Activity{
+			ID:        note.ID + "#create",
+			Type:      "Create",
+			Actor:     note.AttributedTo,
+			Published: note.Published,
+			To:        note.To,
+			CC:        note.CC,
+			Object:    note,
+		})
+	}
+	return outbox, nil
+}
+
+// FederationFollowers counts the blog's followers, without listing who they are
+func (s *PostService) FederationFollowers(ctx context.Context) (*activitypub.OrderedCollection, error) {
+	if s.federation == nil {
+		return nil, ErrFederationDisabled
+	}
+
+	followers, err := s.federation.ListFollowers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &activitypub.OrderedCollection{
+		Context:    activitypub.Context,
+		ID:         s.federationConfig.BaseURL + FederationFollowersPath,
+		Type:       "OrderedCollection",
+		TotalItems: len(followers),
+	}, nil
+}
+
+// FederationNote returns a published post as the note followers were sent
+func (s *PostService) FederationNote(ctx context.Context, postID string) (*activitypub.Note, error) {
+	if s.federation == nil {
+		return nil, ErrFederationDisabled
+	}
+
+	post, err := s.GetPublishedPost(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	note := s.postNote(post)
+	note.Context = activitypub.Context
+	return note, nil
+}
+
+// ListFollowers returns the actors following the blog, in the order they followed
+func (s *PostService) ListFollowers(ctx context.Context) ([]*domain.Follower, error) {
+	if s.federation == nil {
+		return []*domain.Follower{}, nil
+	}
+	return s.federation.ListFollowers(ctx)
+}
+
+// ReceiveActivity acts on an activity delivered to the blog's inbox
+// Follows are accepted and undone follows forgotten, once the request's signature is checked against the key of the
+// actor that sent it. Other activities, such as replies or deleted accounts, are ignored without fetching anything.
+// It returns activitypub.ErrInvalidSignature for requests that can't be shown to come from their actor.
+func (s *PostService) ReceiveActivity(ctx context.Context, req *http.Request, body []byte) error {
+	if s.federation == nil {
+		return ErrFederationDisabled
+	}
+
+	var activity activitypub.ReceivedActivity
+	if err := json.Unmarshal(body, &activity); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidActivity, err)
+	}
+	if activity.Type != "Follow" && activity.Type != "Undo" {
+		return nil
+	}
+
+	actor, err := s.verifyActivity(ctx, req, body, &activity)
+	if err != nil {
+		return err
+	}
+
+	switch activity.Type {
+	case "Follow":
+		if activity.ObjectID() != s.federationConfig.actorID() {
+			return fmt.Errorf("%w: only %s can be followed", ErrInvalidActivity, s.federationConfig.actorID())
+		}
+		return s.acceptFollow(ctx, actor, &activity)
+	default:
+		var undone activitypub.ReceivedActivity
+		if err := json.Unmarshal(activity.Object, &undone); err == nil && undone.Type != "Follow" {
+			return nil
+		}
+		if err := s.federation.DeleteFollower(ctx, actor.ID); err != nil {
+			return err
+		}
+		log.Info().Str("actor", actor.ID).Msg("Lost a follower")
+		return nil
+	}
+}
+
+// verifyActivity checks that a request was signed by the actor of the activity it carries, returning the actor
+func (s *PostService) verifyActivity(ctx context.Context, req *http.Request, body []byte, activity *activitypub.ReceivedActivity) (*activitypub.Actor, error) {
+	sig, err := activitypub.ParseSignature(req)
+	if err != nil {
+		return nil, err
+	}
+	actor, key, err := s.federationClient.FetchKey(ctx, sig.KeyID)
+	if err != nil {
+		if errors.Is(err, activitypub.ErrInvalidSignature) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", activitypub.ErrInvalidSignature, err)
+	}
+	if err := sig.Verify(req, body, key, s.clock.Now()); err != nil {
+		return nil, err
+	}
+	if actor.ID != activity.Actor {
+		return nil, fmt.Errorf("%w: %s signed an activity of %s", activitypub.ErrInvalidSignature, actor.ID, activity.Actor)
+	}
+	return actor, nil
+}
+
+// acceptFollow records a follower and tells its server the follow was accepted
+func (s *PostService) acceptFollow(ctx context.Context, actor *activitypub.Actor, follow *activitypub.ReceivedActivity) error {
+	follower := &domain.Follower{
+		ActorID:    actor.ID,
+		Inbox:      actor.Inbox,
+		FollowedAt: s.clock.Now().UTC(),
+	}
+	if actor.Endpoints != nil {
+		follower.SharedInbox = actor.Endpoints.SharedInbox
+	}
+	if err := s.federation.SaveFollower(ctx, follower); err != nil {
+		return err
+	}
+
+	followID := sha256.Sum256([]byte(follow.ID))
+	s.enqueueDelivery(federationDelivery{
+		inbox: actor.Inbox,
+		activity: &activitypub.Activity{
+			Context: activitypub.Context,
+			ID:      s.federationConfig.actorID() + "#accept-" + hex.EncodeToString(followID[:8]),
+			Type:    "Accept",
+			Actor:   s.federationConfig.actorID(),
+			Object:  follow,
+		},
+	})
+	log.Info().Str("actor", actor.ID).Msg("Gained a follower")
+	return nil
+}
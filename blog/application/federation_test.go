@@ -0,0 +1,275 @@
+package application
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/activitypub"
+)
+
+var (
+	federationKeyOnce sync.Once
+	federationKey     *rsa.PrivateKey
+)
+
+// newFederationKey returns a key shared by the federation tests, since generating one takes a while
+func newFederationKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	federationKeyOnce.Do(func() {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatalf("Failed to generate key: %v", err)
+		}
+		federationKey = key
+	})
+	return federationKey
+}
+
+// remoteServer is another server's actor, which follows the blog and records what is delivered to it
+type remoteServer struct {
+	*httptest.Server
+	key       *rsa.PrivateKey
+	delivered chan *activitypub.ReceivedActivity
+}
+
+func newRemoteServer(t *testing.T) *remoteServer {
+	t.Helper()
+	remote := &remoteServer{key: newFederationKey(t), delivered: make(chan *activitypub.ReceivedActivity, 10)}
+	keyPEM, err := activitypub.PublicKeyPEM(&remote.key.PublicKey)
+	if err != nil {
+		t.Fatalf("PublicKeyPEM() error = %v", err)
+	}
+
+	remote.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			var activity activitypub.ReceivedActivity
+			body, _ := io.ReadAll(r.Body)
+			json.Unmarshal(body, &activity)
+			remote.delivered <- &activity
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		json.NewEncoder(w).Encode(&activitypub.Actor{
+			ID:        remote.actorID(),
+			Type:      "Person",
+			Inbox:     remote.actorID() + "/inbox",
+			Endpoints: &activitypub.Endpoints{SharedInbox: remote.URL + "/inbox"},
+			PublicKey: &activitypub.PublicKey{ID: remote.actorID() + "#main-key", Owner: remote.actorID(), PublicKeyPEM: keyPEM},
+		})
+	}))
+	t.Cleanup(remote.Close)
+	return remote
+}
+
+func (r *remoteServer) actorID() string {
+	return r.URL + "/users/alex"
+}
+
+// send delivers an activity of the remote actor to the blog's inbox, signed with the remote actor's key
+func (r *remoteServer) send(t *testing.T, service *PostService, activity map[string]any) error {
+	t.Helper()
+	body, err := json.Marshal(activity)
+	if err != nil {
+		t.Fatalf("Failed to encode activity: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "https://blog.example"+FederationInboxPath, strings.NewReader(string(body)))
+	if err := activitypub.SignRequest(req, r.actorID()+"#main-key", r.key, body, time.Now()); err != nil {
+		t.Fatalf("SignRequest() error = %v", err)
+	}
+	return service.ReceiveActivity(context.Background(), req, body)
+}
+
+// next returns the next activity delivered to the remote server
+func (r *remoteServer) next(t *testing.T) *activitypub.ReceivedActivity {
+	t.Helper()
+	select {
+	case activity := <-r.delivered:
+		return activity
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for a delivery")
+		return nil
+	}
+}
+
+func newFederationTestService(t *testing.T, posts domain.PostRepository, federation *fakeFederationRepository) *PostService {
+	t.Helper()
+	cfg := &FederationConfig{Enabled: true, Username: "blog", Name: "The Blog", BaseURL: "https://blog.example"}
+	service := NewPostService(posts, newFakeImageRepository(), nil, NewMarkdownRenderer(), "main",
+		WithFederation(federation, cfg, newFederationKey(t)),
+	)
+	// The test servers listen on loopback, which the real client refuses to fetch
+	service.federationClient = activitypub.NewClient(http.DefaultClient, cfg.actorID()+"#main-key", newFederationKey(t), "")
+	t.Cleanup(func() { service.Close() })
+	return service
+}
+
+func TestPostService_ReceiveActivity_Follow(t *testing.T) {
+	remote := newRemoteServer(t)
+	federation := newFakeFederationRepository()
+	service := newFederationTestService(t, newFakePostRepository(), federation)
+
+	err := remote.send(t, service, map[string]any{
+		"id": remote.actorID() + "/follows/1", "type": "Follow", "actor": remote.actorID(), "object": "https://blog.example/federation/actor",
+	})
+	if err != nil {
+		t.Fatalf("ReceiveActivity(Follow) error = %v", err)
+	}
+
+	followers, _ := federation.ListFollowers(context.Background())
+	if len(followers) != 1 || followers[0].ActorID != remote.actorID() || followers[0].SharedInbox != remote.URL+"/inbox" {
+		t.Errorf("Expected the remote actor to follow, got %+v", followers)
+	}
+	if accept := remote.next(t); accept.Type != "Accept" || accept.ObjectID() != remote.actorID()+"/follows/1" {
+		t.Errorf("Expected the follow to be accepted, got %+v", accept)
+	}
+
+	err = remote.send(t, service, map[string]any{
+		"id": remote.actorID() + "/follows/1/undo", "type": "Undo", "actor": remote.actorID(),
+		"object": map[string]any{"id": remote.actorID() + "/follows/1", "type": "Follow", "actor": remote.actorID()},
+	})
+	if err != nil {
+		t.Fatalf("ReceiveActivity(Undo) error = %v", err)
+	}
+	if followers, _ := federation.ListFollowers(context.Background()); len(followers) != 0 {
+		t.Errorf("Expected undoing the follow to remove the follower, got %+v", followers)
+	}
+}
+
+func TestPostService_ReceiveActivity_Rejects(t *testing.T) {
+	remote := newRemoteServer(t)
+	federation := newFakeFederationRepository()
+	service := newFederationTestService(t, newFakePostRepository(), federation)
+
+	err := remote.send(t, service, map[string]any{
+		"id": remote.actorID() + "/follows/1", "type": "Follow", "actor": remote.actorID(), "object": "https://other.example/users/sam",
+	})
+	if !errors.Is(err, ErrInvalidActivity) {
+		t.Errorf("ReceiveActivity() following another actor error = %v, want ErrInvalidActivity", err)
+	}
+
+	err = remote.send(t, service, map[string]any{
+		"id": "https://other.example/follows/1", "type": "Follow", "actor": "https://other.example/users/sam", "object": "https://blog.example/federation/actor",
+	})
+	if !errors.Is(err, activitypub.ErrInvalidSignature) {
+		t.Errorf("ReceiveActivity() signed by another actor error = %v, want ErrInvalidSignature", err)
+	}
+
+	body := []byte(`{"type":"Follow","actor":"` + remote.actorID() + `","object":"https://blog.example/federation/actor"}`)
+	req := httptest.NewRequest(http.MethodPost, "https://blog.example"+FederationInboxPath, strings.NewReader(string(body)))
+	if err := service.ReceiveActivity(context.Background(), req, body); !errors.Is(err, activitypub.ErrInvalidSignature) {
+		t.Errorf("ReceiveActivity() without a signature error = %v, want ErrInvalidSignature", err)
+	}
+
+	// Activities the blog doesn't act on are accepted without checking who sent them
+	if err := service.ReceiveActivity(context.Background(), req, []byte(`{"type":"Like"}`)); err != nil {
+		t.Errorf("ReceiveActivity(Like) error = %v, want it ignored", err)
+	}
+
+	if followers, _ := federation.ListFollowers(context.Background()); len(followers) != 0 {
+		t.Errorf("Expected no followers, got %+v", followers)
+	}
+}
+
+func TestPostService_FederationAnnouncesPosts(t *testing.T) {
+	remote := newRemoteServer(t)
+	federation := newFakeFederationRepository(
+		&domain.Follower{ActorID: remote.actorID(), Inbox: remote.actorID() + "/inbox", SharedInbox: remote.URL + "/inbox"},
+		&domain.Follower{ActorID: remote.URL + "/users/sam", Inbox: remote.URL + "/users/sam/inbox", SharedInbox: remote.URL + "/inbox"},
+	)
+	posts := newFakePostRepository(&domain.Post{ID: "001", Slug: "first", Title: "First <post>", Snippet: "It begins"})
+	service := newFederationTestService(t, posts, federation)
+	ctx := context.Background()
+
+	if err := service.PublishPost(ctx, "001"); err != nil {
+		t.Fatalf("PublishPost() error = %v", err)
+	}
+	create := remote.next(t)
+	var note activitypub.Note
+	json.Unmarshal(create.Object, &note)
+	if create.Type != "Create" || note.ID != "https://blog.example/federation/posts/001" || note.URL != "https://blog.example/posts/first" {
+		t.Errorf("Expected a Create of the post's note, got %+v with %+v", create, note)
+	}
+	if !strings.Contains(note.Content, "First &lt;post&gt;") || !strings.Contains(note.Content, "It begins") {
+		t.Errorf("Expected the note to carry the escaped title and snippet, got %q", note.Content)
+	}
+
+	// Publishing a new version of an announced post edits it rather than creating it again
+	if err := service.PublishPost(ctx, "001"); err != nil {
+		t.Fatalf("PublishPost() error = %v", err)
+	}
+	if update := remote.next(t); update.Type != "Update" || update.ObjectID() != note.ID {
+		t.Errorf("Expected an Update of the note, got %+v", update)
+	}
+
+	if err := service.UnpublishPost(ctx, "001"); err != nil {
+		t.Fatalf("UnpublishPost() error = %v", err)
+	}
+	if deleted := remote.next(t); deleted.Type != "Delete" || deleted.ObjectID() != note.ID {
+		t.Errorf("Expected a Delete of the note, got %+v", deleted)
+	}
+
+	// Both followers share an inbox, so each activity was delivered once
+	select {
+	case extra := <-remote.delivered:
+		t.Errorf("Unexpected delivery %+v", extra)
+	default:
+	}
+}
+
+func TestPostService_FederationWebFinger(t *testing.T) {
+	service := newFederationTestService(t, newFakePostRepository(), newFakeFederationRepository())
+
+	for _, resource := range []string{"acct:blog@blog.example", "acct:Blog@Blog.Example", "https://blog.example/federation/actor"} {
+		finger, err := service.FederationWebFinger(resource)
+		if err != nil {
+			t.Errorf("FederationWebFinger(%s) error = %v", resource, err)
+			continue
+		}
+		if finger.Subject != "acct:blog@blog.example" || finger.Links[0].Href != "https://blog.example/federation/actor" {
+			t.Errorf("FederationWebFinger(%s) = %+v, want the blog's actor", resource, finger)
+		}
+	}
+	if _, err := service.FederationWebFinger("acct:someone@blog.example"); !errors.Is(err, ErrUnknownResource) {
+		t.Errorf("FederationWebFinger() of another account error = %v, want ErrUnknownResource", err)
+	}
+
+	disabled := NewPostService(newFakePostRepository(), newFakeImageRepository(), nil, NewMarkdownRenderer(), "main",
+		WithFederation(newFakeFederationRepository(), &FederationConfig{}, nil),
+	)
+	defer disabled.Close()
+	if _, err := disabled.FederationWebFinger("acct:blog@blog.example"); !errors.Is(err, ErrFederationDisabled) {
+		t.Errorf("FederationWebFinger() when disabled error = %v, want ErrFederationDisabled", err)
+	}
+}
+
+func TestPostService_FederationOutbox(t *testing.T) {
+	published := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	posts := newFakePostRepository(
+		&domain.Post{ID: "001", Slug: "first", PublishedAt: published},
+		&domain.Post{ID: "002", PublishedAt: published.Add(time.Hour)},
+		&domain.Post{ID: "003"},
+	)
+	service := newFederationTestService(t, posts, newFakeFederationRepository())
+
+	outbox, err := service.FederationOutbox(context.Background())
+	if err != nil {
+		t.Fatalf("FederationOutbox() error = %v", err)
+	}
+	if outbox.TotalItems != 2 || len(outbox.OrderedItems) != 2 {
+		t.Fatalf("Expected the 2 published posts, got %+v", outbox)
+	}
+	if latest := outbox.OrderedItems[0].(*activitypub.Activity); latest.Type != "Create" || latest.Object.(*activitypub.Note).URL != "https://blog.example/posts/002" {
+		t.Errorf("Expected the newest post first, got %+v", latest)
+	}
+}
@@ -0,0 +1,119 @@
+package application
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+)
+
+const defaultFeedItems = 20
+
+// FeedContent is how much of each post a feed item carries
+type FeedContent string
+
+const (
+	// FeedContentFull puts the whole rendered post in each item
+	FeedContentFull FeedContent = "full"
+	// FeedContentSummary puts only the post's snippet in each item, so readers follow the link to read on
+	FeedContentSummary FeedContent = "summary"
+)
+
+// FeedConfig controls what the site's feed contains
+type FeedConfig struct {
+	Content FeedContent
+	// Items is how many posts the feed lists
+	Items int
+	// BumpUpdated orders items by their last update rather than their publication, so an edited post
+	// moves back to the top and readers see it again. Aggregators that re-post updated items may prefer it off.
+	BumpUpdated bool
+}
+
+func NewFeedConfig() *FeedConfig {
+	cfg := &FeedConfig{
+		Content: FeedContentFull,
+		Items:   defaultFeedItems,
+	}
+
+	if content := FeedContent(os.Getenv("FEED_CONTENT")); content == FeedContentSummary {
+		cfg.Content = content
+	}
+	if n, err := strconv.Atoi(os.Getenv("FEED_ITEMS")); err == nil && n > 0 {
+		cfg.Items = n
+	}
+	if bump, err := strconv.ParseBool(os.Getenv("FEED_BUMP_UPDATED")); err == nil {
+		cfg.BumpUpdated = bump
+	}
+
+	return cfg
+}
+
+// WithFeed sets what the site's feed contains
+// Without it the feed lists the latest posts in full, ordered by publication.
+func WithFeed(cfg *FeedConfig) PostServiceOption {
+	return func(s *PostService) {
+		s.feed = cfg
+	}
+}
+
+// FeedEntry is a published post as it appears in the feed
+type FeedEntry struct {
+	// Post holds the post's HTML only when the feed carries full posts
+	Post *domain.Post
+	// UpdatedAt is when the entry last changed, as far as the feed is concerned
+	UpdatedAt time.Time
+}
+
+// FeedEntries returns the published posts the feed lists, most recent first
+// Posts are only bumped by later updates when the feed is configured to; otherwise an entry is fixed at publication.
+func (s *PostService) FeedEntries(ctx context.Context) ([]*FeedEntry, error) {
+	cfg := s.feed
+	if cfg == nil {
+		cfg = &FeedConfig{Content: FeedContentFull, Items: defaultFeedItems}
+	}
+
+	posts, err := s.repo.ListPosts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list posts: %w", err)
+	}
+
+	var entries []*FeedEntry
+	for _, post := range posts {
+		if post.PublishedAt.IsZero() {
+			continue
+		}
+		updatedAt := post.PublishedAt
+		if cfg.BumpUpdated && post.UpdatedAt.After(updatedAt) {
+			updatedAt = post.UpdatedAt
+		}
+		entries = append(entries, &FeedEntry{Post: post, UpdatedAt: updatedAt})
+	}
+
+	slices.SortFunc(entries, func(a, b *FeedEntry) int {
+		return cmp.Or(b.UpdatedAt.Compare(a.UpdatedAt), cmp.Compare(b.Post.ID, a.Post.ID))
+	})
+	if len(entries) > cfg.Items {
+		entries = entries[:cfg.Items]
+	}
+
+	for _, entry := range entries {
+		// Posts may be shared with the post cache, so the entry gets its own copy
+		post := *entry.Post
+		post.HTMLContent = nil
+		if cfg.Content == FeedContentFull {
+			content, err := s.repo.GetPostHTML(ctx, post.ID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load post %s: %w", post.ID, err)
+			}
+			post.HTMLContent = content
+		}
+		entry.Post = &post
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,89 @@
+package application
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+)
+
+func TestPostService_FeedEntries(t *testing.T) {
+	published := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	newPosts := func() *fakePostRepository {
+		return newFakePostRepository(
+			&domain.Post{ID: "001", Snippet: "First", HTMLContent: []byte("<p>First</p>"), PublishedAt: published, UpdatedAt: published.Add(72 * time.Hour)},
+			&domain.Post{ID: "002", Snippet: "Second", HTMLContent: []byte("<p>Second</p>"), PublishedAt: published.Add(24 * time.Hour), UpdatedAt: published.Add(24 * time.Hour)},
+			&domain.Post{ID: "003", Snippet: "Third", HTMLContent: []byte("<p>Third</p>"), PublishedAt: published.Add(48 * time.Hour), UpdatedAt: published.Add(48 * time.Hour)},
+			&domain.Post{ID: "004", Snippet: "Draft", HTMLContent: []byte("<p>Draft</p>"), UpdatedAt: published.Add(96 * time.Hour)},
+		)
+	}
+	ids := func(entries []*FeedEntry) []string {
+		var ids []string
+		for _, entry := range entries {
+			ids = append(ids, entry.Post.ID)
+		}
+		return ids
+	}
+
+	tests := []struct {
+		name        string
+		cfg         *FeedConfig
+		wantIDs     []string
+		wantContent bool
+	}{
+		{"defaults", nil, []string{"003", "002", "001"}, true},
+		{"summaries", &FeedConfig{Content: FeedContentSummary, Items: 10}, []string{"003", "002", "001"}, false},
+		{"limited", &FeedConfig{Content: FeedContentFull, Items: 2}, []string{"003", "002"}, true},
+		{"bumped by updates", &FeedConfig{Content: FeedContentFull, Items: 2, BumpUpdated: true}, []string{"001", "003"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newPosts()
+			var opts []PostServiceOption
+			if tt.cfg != nil {
+				opts = append(opts, WithFeed(tt.cfg))
+			}
+			service := NewPostService(repo, newFakeImageRepository(), nil, NewMarkdownRenderer(), "main", opts...)
+			defer service.Close()
+
+			entries, err := service.FeedEntries(context.Background())
+			if err != nil {
+				t.Fatalf("FeedEntries() error = %v", err)
+			}
+			if got := ids(entries); len(got) != len(tt.wantIDs) || got[0] != tt.wantIDs[0] || got[len(got)-1] != tt.wantIDs[len(tt.wantIDs)-1] {
+				t.Fatalf("FeedEntries() = %v, want %v", got, tt.wantIDs)
+			}
+			for _, entry := range entries {
+				if hasContent := entry.Post.HTMLContent != nil; hasContent != tt.wantContent {
+					t.Errorf("entry %s has content = %v, want %v", entry.Post.ID, hasContent, tt.wantContent)
+				}
+			}
+			if stored, _ := repo.GetPost(context.Background(), "003"); stored.HTMLContent == nil {
+				t.Error("Expected the stored post to keep its HTML")
+			}
+		})
+	}
+}
+
+func TestPostService_FeedEntriesUpdatedAt(t *testing.T) {
+	published := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	edited := published.Add(time.Hour)
+	repo := newFakePostRepository(&domain.Post{ID: "001", PublishedAt: published, UpdatedAt: edited})
+
+	fixed := NewPostService(repo, newFakeImageRepository(), nil, NewMarkdownRenderer(), "main")
+	defer fixed.Close()
+	entries, err := fixed.FeedEntries(context.Background())
+	if err != nil || len(entries) != 1 || !entries[0].UpdatedAt.Equal(published) {
+		t.Errorf("FeedEntries() = %v, %v, want the entry fixed at publication", entries, err)
+	}
+
+	bumped := NewPostService(repo, newFakeImageRepository(), nil, NewMarkdownRenderer(), "main",
+		WithFeed(&FeedConfig{Content: FeedContentFull, Items: 10, BumpUpdated: true}))
+	defer bumped.Close()
+	entries, err = bumped.FeedEntries(context.Background())
+	if err != nil || len(entries) != 1 || !entries[0].UpdatedAt.Equal(edited) {
+		t.Errorf("FeedEntries() = %v, %v, want the entry updated by the edit", entries, err)
+	}
+}
@@ -0,0 +1,249 @@
+package application
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const frontMatterDelimiter = "---"
+
+// yamlErrorLine finds the line a YAML syntax error is on, counted from the start of the block
+var yamlErrorLine = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+// languageTagPattern accepts the shape of a BCP 47 language tag, such as de, pt-BR or zh-Hant-TW
+var languageTagPattern = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{1,8})*$`)
+
+// frontMatterField is what a front matter key's value must be
+type frontMatterField struct {
+	// check returns why a value is invalid, or nil
+	check func(value string, loc *time.Location) error
+	// lenient values are still used when front matter validation isn't strict, with a warning
+	lenient bool
+}
+
+// frontMatterSchema lists the keys front matter may have
+var frontMatterSchema = map[string]frontMatterField{
+	"publish_at": {check: func(value string, loc *time.Location) error {
+		_, err := parsePublishAt(value, loc)
+		return err
+	}},
+	"lang": {check: checkLanguageTag, lenient: true},
+}
+
+// FrontMatterConfig sets how strictly front matter is held to its schema
+type FrontMatterConfig struct {
+	// Strict refuses to render posts with unknown keys or invalid values, rather than warning about them
+	Strict bool
+}
+
+func NewFrontMatterConfig() *FrontMatterConfig {
+	return &FrontMatterConfig{
+		Strict: os.Getenv("FRONT_MATTER_VALIDATION") == "strict",
+	}
+}
+
+// WithFrontMatterValidation sets how strictly front matter is validated
+// Without it, unknown keys and invalid lang values are warnings.
+func WithFrontMatterValidation(cfg *FrontMatterConfig) MarkdownOption {
+	return func(o *markdownOptions) {
+		o.frontMatter = cfg
+	}
+}
+
+// FrontMatterIssue is a problem with a post's front matter
+type FrontMatterIssue struct {
+	// Line is the line of the post file the problem is on, where the opening delimiter is line 1
+	Line int
+	// Key is the front matter key the problem is with, or empty for the block as a whole
+	Key     string
+	Message string
+}
+
+func (i FrontMatterIssue) String() string {
+	return fmt.Sprintf("line %d: %s", i.Line, i.Message)
+}
+
+// FrontMatterError is returned for front matter that breaks its schema
+type FrontMatterError struct {
+	Issues []FrontMatterIssue
+}
+
+func (e *FrontMatterError) Error() string {
+	messages := make([]string, 0, len(e.Issues))
+	for _, issue := range e.Issues {
+		messages = append(messages, issue.String())
+	}
+	return "invalid front matter: " + strings.Join(messages, "; ")
+}
+
+// checkLanguageTag checks that value is shaped like a language tag
+func checkLanguageTag(value string, loc *time.Location) error {
+	if !languageTagPattern.MatchString(value) {
+		return fmt.Errorf("invalid lang %q: expected a language tag such as de or pt-BR", value)
+	}
+	return nil
+}
+
+// publishAtLayouts are the accepted forms of publish_at without a UTC offset, read in the site's time zone
+var publishAtLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// FrontMatter holds the metadata declared in a YAML block at the top of a post
+//
+//	---
+//	publish_at: 2024-06-01T09:00:00Z
+//	lang: de
+//	---
+//	# Post title
+type FrontMatter struct {
+	// PublishAt delays publication of a merged post until the given time
+	PublishAt time.Time
+	// Language is the language tag the post is written in, overriding the site language
+	Language string
+	// Issues are the problems that don't stop the post rendering when validation isn't strict
+	Issues []FrontMatterIssue
+}
+
+// parsePublishAt reads a publish_at value, using its UTC offset when it has one and loc otherwise
+func parsePublishAt(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	}
+
+	for _, layout := range publishAtLayouts {
+		t, err := time.Parse(layout, value)
+		if err != nil {
+			continue
+		}
+		return wallClockIn(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), loc), nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid publish_at %q: expected a date, a local time or an RFC 3339 timestamp", value)
+}
+
+// splitFrontMatter separates a leading front matter block from the markdown body
+// Markdown without front matter is returned unchanged with an empty FrontMatter.
+// Times without a UTC offset are in loc. Front matter that can't be read, or whose publish_at is invalid, is a
+// *FrontMatterError; other problems are returned in FrontMatter.Issues for the caller to warn about or refuse.
+func splitFrontMatter(markdown []byte, loc *time.Location) (*FrontMatter, []byte, error) {
+	fm := &FrontMatter{}
+
+	firstLine, rest, found := bytes.Cut(markdown, []byte("\n"))
+	if !found || string(bytes.TrimSpace(firstLine)) != frontMatterDelimiter {
+		return fm, markdown, nil
+	}
+
+	var block []byte
+	for len(rest) > 0 {
+		var line []byte
+		line, rest, _ = bytes.Cut(rest, []byte("\n"))
+		if string(bytes.TrimSpace(line)) == frontMatterDelimiter {
+			if err := fm.decode(block, loc); err != nil {
+				return nil, nil, err
+			}
+			return fm, rest, nil
+		}
+		block = append(block, line...)
+		block = append(block, '\n')
+	}
+
+	return nil, nil, &FrontMatterError{Issues: []FrontMatterIssue{{
+		Line:    1,
+		Message: fmt.Sprintf("front matter is not terminated by %q", frontMatterDelimiter),
+	}}}
+}
+
+// decode reads a front matter block into fm, checking it against frontMatterSchema
+// Lines are numbered from the opening delimiter, so the block's first line is line 2.
+func (fm *FrontMatter) decode(block []byte, loc *time.Location) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(block, &doc); err != nil {
+		issue := FrontMatterIssue{Line: 1, Message: err.Error()}
+		if m := yamlErrorLine.FindStringSubmatch(err.Error()); m != nil {
+			line, _ := strconv.Atoi(m[1])
+			issue = FrontMatterIssue{Line: line + 1, Message: m[2]}
+		}
+		return &FrontMatterError{Issues: []FrontMatterIssue{issue}}
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return &FrontMatterError{Issues: []FrontMatterIssue{{Line: root.Line + 1, Message: "front matter must be a mapping of keys to values"}}}
+	}
+
+	var invalid []FrontMatterIssue
+	seen := make(map[string]bool)
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		line := key.Line + 1
+
+		field, known := frontMatterSchema[key.Value]
+		switch {
+		case seen[key.Value]:
+			invalid = append(invalid, FrontMatterIssue{Line: line, Key: key.Value, Message: fmt.Sprintf("duplicate key %q", key.Value)})
+			continue
+		case !known:
+			fm.Issues = append(fm.Issues, FrontMatterIssue{
+				Line:    line,
+				Key:     key.Value,
+				Message: fmt.Sprintf("unknown key %q: expected one of %s", key.Value, strings.Join(frontMatterKeys(), ", ")),
+			})
+			continue
+		case value.Kind != yaml.ScalarNode:
+			invalid = append(invalid, FrontMatterIssue{Line: value.Line + 1, Key: key.Value, Message: fmt.Sprintf("%s must be a single value", key.Value)})
+			continue
+		}
+		seen[key.Value] = true
+
+		text := strings.TrimSpace(value.Value)
+		if value.Tag == "!!null" || text == "" {
+			continue
+		}
+		if err := field.check(text, loc); err != nil {
+			issue := FrontMatterIssue{Line: value.Line + 1, Key: key.Value, Message: err.Error()}
+			if !field.lenient {
+				invalid = append(invalid, issue)
+				continue
+			}
+			fm.Issues = append(fm.Issues, issue)
+		}
+
+		switch key.Value {
+		case "publish_at":
+			fm.PublishAt, _ = parsePublishAt(text, loc)
+		case "lang":
+			fm.Language = text
+		}
+	}
+
+	if len(invalid) > 0 {
+		return &FrontMatterError{Issues: invalid}
+	}
+	return nil
+}
+
+// frontMatterKeys lists the keys of frontMatterSchema in order
+func frontMatterKeys() []string {
+	keys := make([]string, 0, len(frontMatterSchema))
+	for key := range frontMatterSchema {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,183 @@
+package application
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSplitFrontMatter(t *testing.T) {
+	tests := []struct {
+		name          string
+		markdown      string
+		wantBody      string
+		wantPublishAt time.Time
+		wantErr       bool
+	}{
+		{
+			name:     "No front matter",
+			markdown: "# Title\nBody",
+			wantBody: "# Title\nBody",
+		},
+		{
+			name:          "RFC3339 publish_at",
+			markdown:      "---\npublish_at: 2024-06-01T09:30:00Z\n---\n# Title\nBody",
+			wantBody:      "# Title\nBody",
+			wantPublishAt: time.Date(2024, 6, 1, 9, 30, 0, 0, time.UTC),
+		},
+		{
+			name:          "Date-only publish_at",
+			markdown:      "---\npublish_at: 2024-06-01\n---\n# Title",
+			wantBody:      "# Title",
+			wantPublishAt: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Empty front matter",
+			markdown: "---\n---\n# Title",
+			wantBody: "# Title",
+		},
+		{
+			name:     "Horizontal rule later in the document is not front matter",
+			markdown: "# Title\n\n---\n\nMore",
+			wantBody: "# Title\n\n---\n\nMore",
+		},
+		{
+			name:     "Unterminated front matter",
+			markdown: "---\npublish_at: 2024-06-01\n# Title",
+			wantErr:  true,
+		},
+		{
+			name:     "Invalid publish_at",
+			markdown: "---\npublish_at: next tuesday\n---\n# Title",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fm, body, err := splitFrontMatter([]byte(tt.markdown), time.UTC)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("splitFrontMatter() error = %v", err)
+			}
+
+			if string(body) != tt.wantBody {
+				t.Errorf("body = %q, want %q", body, tt.wantBody)
+			}
+			if !fm.PublishAt.Equal(tt.wantPublishAt) {
+				t.Errorf("PublishAt = %v, want %v", fm.PublishAt, tt.wantPublishAt)
+			}
+		})
+	}
+}
+
+func TestMarkdownRendererImpl_Render_FrontMatter(t *testing.T) {
+	renderer := NewMarkdownRenderer()
+
+	result, err := renderer.Render([]byte("---\npublish_at: 2030-01-01T00:00:00Z\nlang: de\n---\n# Scheduled\nComing soon"))
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	if result.Title != "Scheduled" {
+		t.Errorf("Title = %q, want %q", result.Title, "Scheduled")
+	}
+	if result.Snippet != "Coming soon" {
+		t.Errorf("Snippet = %q, want %q", result.Snippet, "Coming soon")
+	}
+	if want := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC); !result.PublishAt.Equal(want) {
+		t.Errorf("PublishAt = %v, want %v", result.PublishAt, want)
+	}
+	if result.Language != "de" {
+		t.Errorf("Language = %q, want %q", result.Language, "de")
+	}
+}
+
+func TestSplitFrontMatter_Issues(t *testing.T) {
+	tests := []struct {
+		name       string
+		markdown   string
+		wantIssues []FrontMatterIssue
+		wantErr    []FrontMatterIssue
+	}{
+		{
+			name:     "Valid",
+			markdown: "---\npublish_at: 2024-06-01\nlang: pt-BR\n---\n# Title",
+		},
+		{
+			name:       "Unknown key",
+			markdown:   "---\npublish_at: 2024-06-01\ntags: [go]\n---\n# Title",
+			wantIssues: []FrontMatterIssue{{Line: 3, Key: "tags", Message: `unknown key "tags": expected one of lang, publish_at`}},
+		},
+		{
+			name:       "Invalid lang",
+			markdown:   "---\nlang: German\n---\n# Title",
+			wantIssues: []FrontMatterIssue{{Line: 2, Key: "lang", Message: `invalid lang "German": expected a language tag such as de or pt-BR`}},
+		},
+		{
+			name:     "Invalid publish_at",
+			markdown: "---\nlang: de\npublish_at: next tuesday\n---\n# Title",
+			wantErr:  []FrontMatterIssue{{Line: 3, Key: "publish_at", Message: `invalid publish_at "next tuesday": expected a date, a local time or an RFC 3339 timestamp`}},
+		},
+		{
+			name:     "Duplicate key",
+			markdown: "---\nlang: de\nlang: fr\n---\n# Title",
+			wantErr:  []FrontMatterIssue{{Line: 3, Key: "lang", Message: `duplicate key "lang"`}},
+		},
+		{
+			name:     "List value",
+			markdown: "---\nlang:\n  - de\n---\n# Title",
+			wantErr:  []FrontMatterIssue{{Line: 3, Key: "lang", Message: "lang must be a single value"}},
+		},
+		{
+			name:     "Not a mapping",
+			markdown: "---\n- de\n---\n# Title",
+			wantErr:  []FrontMatterIssue{{Line: 2, Message: "front matter must be a mapping of keys to values"}},
+		},
+		{
+			name:     "YAML syntax",
+			markdown: "---\nlang: de\n\tpublish_at: x\n---\n# Title",
+			wantErr:  []FrontMatterIssue{{Line: 3, Message: "found a tab character that violates indentation"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fm, _, err := splitFrontMatter([]byte(tt.markdown), time.UTC)
+			if tt.wantErr != nil {
+				var fmErr *FrontMatterError
+				if !errors.As(err, &fmErr) || !slices.Equal(fmErr.Issues, tt.wantErr) {
+					t.Errorf("splitFrontMatter() error = %v, want issues %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("splitFrontMatter() error = %v", err)
+			}
+			if !slices.Equal(fm.Issues, tt.wantIssues) {
+				t.Errorf("Issues = %v, want %v", fm.Issues, tt.wantIssues)
+			}
+		})
+	}
+}
+
+func TestMarkdownRendererImpl_Render_StrictFrontMatter(t *testing.T) {
+	source := []byte("---\nlang: de\ntags: go\n---\n# Tagged")
+
+	result, err := NewMarkdownRenderer().Render(source)
+	if err != nil || len(result.FrontMatter) != 1 || result.Language != "de" {
+		t.Errorf("lenient Render() = %+v, %v, want the post rendered with one warning", result, err)
+	}
+
+	_, err = NewMarkdownRenderer(WithFrontMatterValidation(&FrontMatterConfig{Strict: true})).Render(source)
+	var fmErr *FrontMatterError
+	if !errors.As(err, &fmErr) || len(fmErr.Issues) != 1 || fmErr.Issues[0].Line != 3 {
+		t.Errorf("strict Render() error = %v, want the unknown key on line 3", err)
+	}
+}
@@ -0,0 +1,104 @@
+package application
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+)
+
+// imageFormats maps the extensions of image files to the format their content must be in
+var imageFormats = map[string]string{
+	".jpg":  "jpeg",
+	".jpeg": "jpeg",
+	".png":  "png",
+	".gif":  "gif",
+	".webp": "webp",
+	".avif": "avif",
+	".svg":  "svg",
+}
+
+// checkImageContent returns domain.ErrImageContentMismatch unless content is the format the extension of
+// imagePath names
+// A file that looks like an image but isn't, such as HTML named .png, could otherwise be served as one.
+func checkImageContent(imagePath string, content []byte) error {
+	want, ok := imageFormats[strings.ToLower(path.Ext(imagePath))]
+	if !ok {
+		return fmt.Errorf("%w: %s has no image extension", domain.ErrImageContentMismatch, imagePath)
+	}
+
+	got := sniffImageFormat(content)
+	if got != want {
+		if got == "" {
+			got = "not an image"
+		}
+		return fmt.Errorf("%w: %s is %s, not %s", domain.ErrImageContentMismatch, imagePath, got, want)
+	}
+	return nil
+}
+
+// sniffImageFormat returns the image format content starts with, or "" when it isn't one of imageFormats
+func sniffImageFormat(content []byte) string {
+	switch {
+	case bytes.HasPrefix(content, []byte("\xFF\xD8\xFF")):
+		return "jpeg"
+	case bytes.HasPrefix(content, []byte("\x89PNG\r\n\x1A\n")):
+		return "png"
+	case bytes.HasPrefix(content, []byte("GIF87a")), bytes.HasPrefix(content, []byte("GIF89a")):
+		return "gif"
+	case len(content) >= 12 && string(content[:4]) == "RIFF" && string(content[8:12]) == "WEBP":
+		return "webp"
+	case isAVIF(content):
+		return "avif"
+	case isSVG(content):
+		return "svg"
+	}
+	return ""
+}
+
+// isAVIF reports whether content starts with an ISO media file type box naming an AVIF brand
+func isAVIF(content []byte) bool {
+	if len(content) < 16 || string(content[4:8]) != "ftyp" {
+		return false
+	}
+	size := int(content[0])<<24 | int(content[1])<<16 | int(content[2])<<8 | int(content[3])
+	if size < 16 || size > len(content) {
+		return false
+	}
+
+	// The major brand, then the compatible brands after the minor version
+	brands := [][]byte{content[8:12]}
+	for i := 16; i+4 <= size; i += 4 {
+		brands = append(brands, content[i:i+4])
+	}
+	for _, brand := range brands {
+		if string(brand) == "avif" || string(brand) == "avis" {
+			return true
+		}
+	}
+	return false
+}
+
+// isSVG reports whether content is XML whose root element is an svg element
+// Only the prolog and the root's start tag are read; a broken document further on still renders as an image.
+func isSVG(content []byte) bool {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	decoder.Strict = false
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return false
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			return t.Name.Local == "svg"
+		case xml.CharData:
+			if len(bytes.TrimSpace(t)) > 0 {
+				return false
+			}
+		}
+	}
+}
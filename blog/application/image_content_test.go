@@ -0,0 +1,63 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"image"
+	"testing"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+)
+
+func TestCheckImageContent(t *testing.T) {
+	png := encodePNG(t, image.NewGray(image.Rect(0, 0, 1, 1)))
+	avif := []byte("\x00\x00\x00\x1Cftypmif1\x00\x00\x00\x00mif1avifmiaf")
+
+	tests := []struct {
+		path    string
+		content []byte
+		wantErr bool
+	}{
+		{"images/photo.png", png, false},
+		{"images/PHOTO.PNG", png, false},
+		{"images/photo.jpg", []byte("\xFF\xD8\xFF\xE0\x00\x10JFIF"), false},
+		{"images/photo.jpeg", []byte("\xFF\xD8\xFF\xDB"), false},
+		{"images/anim.gif", []byte("GIF89a\x01\x00\x01\x00"), false},
+		{"images/photo.webp", []byte("RIFF\x24\x00\x00\x00WEBPVP8 "), false},
+		{"images/photo.avif", avif, false},
+		{"images/logo.svg", []byte(`<?xml version="1.0"?><!DOCTYPE svg><!-- logo --><svg xmlns="http://www.w3.org/2000/svg"></svg>`), false},
+		{"images/logo.svg", []byte("\n<svg viewBox=\"0 0 1 1\"><path d=\"M0 0\"/></svg>"), false},
+		{"images/photo.png", []byte("<!DOCTYPE html><html><script>alert(1)</script></html>"), true},
+		{"images/photo.jpg", png, true},
+		{"images/photo.avif", []byte("\x00\x00\x00\x18ftypmp42\x00\x00\x00\x00mp42isom"), true},
+		{"images/logo.svg", []byte("<html><svg></svg></html>"), true},
+		{"images/logo.svg", []byte("hello <svg></svg>"), true},
+		{"images/empty.png", nil, true},
+	}
+
+	for _, tt := range tests {
+		err := checkImageContent(tt.path, tt.content)
+		if tt.wantErr != (err != nil) {
+			t.Errorf("checkImageContent(%s, %.20q) error = %v, wantErr %v", tt.path, tt.content, err, tt.wantErr)
+		}
+		if err != nil && !errors.Is(err, domain.ErrImageContentMismatch) {
+			t.Errorf("checkImageContent(%s) error = %v, want ErrImageContentMismatch", tt.path, err)
+		}
+	}
+}
+
+func TestPostService_ProcessImageFile_RejectsMismatchedContent(t *testing.T) {
+	source := newFakeSourceRepository()
+	source.files["images/photo.png"] = []byte("<html><body>Not a photo</body></html>")
+	images := newFakeImageRepository()
+	service := NewPostService(newFakePostRepository(), images, source, NewMarkdownRenderer(), "main")
+	defer service.Close()
+
+	err := service.processImageFile(context.Background(), "images/photo.png", "sha")
+	if !errors.Is(err, domain.ErrImageContentMismatch) {
+		t.Errorf("processImageFile() error = %v, want ErrImageContentMismatch", err)
+	}
+	if _, ok := images.images["images/photo.png"]; ok {
+		t.Error("Expected the mismatched image not to be stored")
+	}
+}
@@ -0,0 +1,147 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/clock"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultImageGCInterval    = time.Hour
+	defaultImageGCGracePeriod = 7 * 24 * time.Hour
+)
+
+type ImageGCConfig struct {
+	// Interval is how often the collector looks for orphaned images
+	Interval time.Duration
+	// GracePeriod is how long an image must stay unreferenced before it can be deleted
+	GracePeriod time.Duration
+	// Delete removes images once their grace period has passed
+	// When false, orphaned images are only flagged for review
+	Delete bool
+}
+
+func NewImageGCConfig() *ImageGCConfig {
+	interval := defaultImageGCInterval
+	if d, err := time.ParseDuration(os.Getenv("IMAGE_GC_INTERVAL")); err == nil && d > 0 {
+		interval = d
+	}
+
+	gracePeriod := defaultImageGCGracePeriod
+	if d, err := time.ParseDuration(os.Getenv("IMAGE_GC_GRACE_PERIOD")); err == nil && d >= 0 {
+		gracePeriod = d
+	}
+
+	return &ImageGCConfig{
+		Interval:    interval,
+		GracePeriod: gracePeriod,
+		Delete:      os.Getenv("IMAGE_GC_DELETE") == "true",
+	}
+}
+
+// OrphanedImage is an image no post references, along with when it becomes eligible for deletion
+type OrphanedImage struct {
+	Path        string
+	Hash        string
+	OrphanedAt  time.Time
+	DeleteAfter time.Time
+}
+
+// ImageGarbageCollector finds images that are no longer referenced by any post
+// and removes them once they have been orphaned for longer than the grace period
+type ImageGarbageCollector struct {
+	imageRepo domain.ImageRepository
+	cfg       *ImageGCConfig
+	clock     clock.Clock
+}
+
+// ImageGCOption configures optional ImageGarbageCollector behaviour
+type ImageGCOption func(*ImageGarbageCollector)
+
+// WithImageGCClock sets the clock used to flag orphans and decide when their grace period ends
+func WithImageGCClock(c clock.Clock) ImageGCOption {
+	return func(gc *ImageGarbageCollector) {
+		gc.clock = c
+	}
+}
+
+func NewImageGarbageCollector(imageRepo domain.ImageRepository, cfg *ImageGCConfig, opts ...ImageGCOption) *ImageGarbageCollector {
+	gc := &ImageGarbageCollector{
+		imageRepo: imageRepo,
+		cfg:       cfg,
+		clock:     clock.System,
+	}
+
+	for _, opt := range opts {
+		opt(gc)
+	}
+
+	return gc
+}
+
+// Run flags newly orphaned images and deletes those past their grace period
+func (c *ImageGarbageCollector) Run(ctx context.Context) error {
+	// A post whose image references were never recorded may use any image, so nothing can be collected yet
+	pending, err := c.imageRepo.CountPostsWithoutImageRefs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to count posts without image references: %w", err)
+	}
+	if pending > 0 {
+		log.Warn().Int("posts", pending).Msg("Skipping image garbage collection until every post is rendered again")
+		return nil
+	}
+
+	now := c.clock.Now().UTC()
+	if err := c.imageRepo.FlagOrphanedImages(ctx, now); err != nil {
+		return fmt.Errorf("failed to flag orphaned images: %w", err)
+	}
+
+	if !c.cfg.Delete {
+		return nil
+	}
+
+	orphans, err := c.imageRepo.ListOrphanedImages(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list orphaned images: %w", err)
+	}
+
+	cutoff := now.Add(-c.cfg.GracePeriod)
+	for _, img := range orphans {
+		if img.OrphanedAt.After(cutoff) {
+			// Orphans are ordered oldest first, so none of the rest are due either
+			break
+		}
+
+		if err := c.imageRepo.DeleteImage(ctx, img.Path); err != nil {
+			return fmt.Errorf("failed to delete orphaned image %s: %w", img.Path, err)
+		}
+		log.Info().Str("path", img.Path).Time("orphanedAt", img.OrphanedAt).Msg("Deleted orphaned image")
+	}
+
+	return nil
+}
+
+// ListOrphans returns the images currently flagged as orphaned for review
+func (c *ImageGarbageCollector) ListOrphans(ctx context.Context) ([]*OrphanedImage, error) {
+	images, err := c.imageRepo.ListOrphanedImages(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list orphaned images: %w", err)
+	}
+
+	orphans := make([]*OrphanedImage, 0, len(images))
+	for _, img := range images {
+		orphans = append(orphans, &OrphanedImage{
+			Path:        img.Path,
+			Hash:        img.Hash,
+			OrphanedAt:  img.OrphanedAt,
+			DeleteAfter: img.OrphanedAt.Add(c.cfg.GracePeriod),
+		})
+	}
+
+	return orphans, nil
+}
@@ -0,0 +1,91 @@
+package application
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/clock"
+)
+
+func TestImageGarbageCollector_Run_DeletesExpiredOrphans(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	repo := newFakeImageRepository(
+		&domain.Image{Path: "images/old.png", OrphanedAt: now.Add(-48 * time.Hour)},
+		&domain.Image{Path: "images/recent.png", OrphanedAt: now.Add(-time.Hour)},
+		&domain.Image{Path: "images/used.png"},
+	)
+
+	gc := NewImageGarbageCollector(repo, &ImageGCConfig{GracePeriod: 24 * time.Hour, Delete: true}, WithImageGCClock(clock.Fixed(now)))
+	if err := gc.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if len(repo.deleted) != 1 || repo.deleted[0] != "images/old.png" {
+		t.Errorf("deleted = %v, want [images/old.png]", repo.deleted)
+	}
+	if _, ok := repo.images["images/recent.png"]; !ok {
+		t.Error("image within grace period was deleted")
+	}
+	if _, ok := repo.images["images/used.png"]; !ok {
+		t.Error("referenced image was deleted")
+	}
+}
+
+func TestImageGarbageCollector_Run_FlagOnly(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	repo := newFakeImageRepository(
+		&domain.Image{Path: "images/old.png", OrphanedAt: now.Add(-48 * time.Hour)},
+	)
+
+	gc := NewImageGarbageCollector(repo, &ImageGCConfig{GracePeriod: 24 * time.Hour, Delete: false}, WithImageGCClock(clock.Fixed(now)))
+	if err := gc.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if len(repo.deleted) != 0 {
+		t.Errorf("deleted = %v, want none when deletion is disabled", repo.deleted)
+	}
+}
+
+func TestImageGarbageCollector_Run_WaitsForImageReferences(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	repo := newFakeImageRepository(
+		&domain.Image{Path: "images/old.png", OrphanedAt: now.Add(-48 * time.Hour)},
+	)
+	repo.postsWithoutImageRefs = 1
+
+	gc := NewImageGarbageCollector(repo, &ImageGCConfig{GracePeriod: 24 * time.Hour, Delete: true}, WithImageGCClock(clock.Fixed(now)))
+	if err := gc.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if len(repo.deleted) != 0 {
+		t.Errorf("deleted = %v, want none while a post's image references are unknown", repo.deleted)
+	}
+}
+
+func TestImageGarbageCollector_ListOrphans(t *testing.T) {
+	orphanedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := newFakeImageRepository(
+		&domain.Image{Path: "images/old.png", Hash: "abc", OrphanedAt: orphanedAt},
+		&domain.Image{Path: "images/used.png"},
+	)
+
+	gc := NewImageGarbageCollector(repo, &ImageGCConfig{GracePeriod: 24 * time.Hour})
+	orphans, err := gc.ListOrphans(context.Background())
+	if err != nil {
+		t.Fatalf("ListOrphans() error = %v", err)
+	}
+
+	if len(orphans) != 1 {
+		t.Fatalf("len(orphans) = %d, want 1", len(orphans))
+	}
+	if orphans[0].Path != "images/old.png" {
+		t.Errorf("Path = %q, want %q", orphans[0].Path, "images/old.png")
+	}
+	if want := orphanedAt.Add(24 * time.Hour); !orphans[0].DeleteAfter.Equal(want) {
+		t.Errorf("DeleteAfter = %v, want %v", orphans[0].DeleteAfter, want)
+	}
+}
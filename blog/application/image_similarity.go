@@ -0,0 +1,170 @@
+package application
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"math/bits"
+	"strconv"
+
+	// Register the formats perceptual hashes are computed for
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// DefaultSimilarImageDistance is how many of the 64 bits of two perceptual hashes may differ
+	// for the images to count as near-duplicates
+	DefaultSimilarImageDistance = 6
+	// maxHashedPixels bounds the images decoded for hashing, so a huge image can't exhaust memory
+	maxHashedPixels = 50_000_000
+	// hashWidth and hashHeight are the size images are reduced to; each row yields hashWidth-1 bits
+	hashWidth  = 9
+	hashHeight = 8
+)
+
+// SimilarImage is an image that looks like one stored before it
+type SimilarImage struct {
+	// Path is the newer of the two images, and the likelier one to be redundant
+	Path string
+	// SimilarTo is the older image
+	SimilarTo string
+	// Distance is how many bits of the perceptual hashes differ; 0 for identical content
+	Distance int
+	// Identical is set when the two files have the same content
+	Identical bool
+}
+
+// FindSimilarImages returns every pair of stored images whose perceptual hashes differ by at most maxDistance bits,
+// along with any images stored more than once under different paths
+func (s *PostService) FindSimilarImages(ctx context.Context, maxDistance int) ([]*SimilarImage, error) {
+	images, err := s.imageRepo.ListImages(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list images: %w", err)
+	}
+
+	similar := make([]*SimilarImage, 0)
+	for i, img := range images {
+		similar = append(similar, similarImages(img, images[i+1:], maxDistance)...)
+	}
+	return similar, nil
+}
+
+// warnSimilarImages logs the stored images that a newly saved image duplicates
+// Near-duplicates are only reported: the repository decides what it keeps.
+func (s *PostService) warnSimilarImages(ctx context.Context, img *domain.Image) {
+	images, err := s.imageRepo.ListImages(ctx)
+	if err != nil {
+		log.Warn().Err(err).Str("path", img.Path).Msg("Failed to check image for near-duplicates")
+		return
+	}
+
+	for _, match := range similarImages(img, images, DefaultSimilarImageDistance) {
+		log.Warn().
+			Str("path", match.Path).
+			Str("similarTo", match.SimilarTo).
+			Int("distance", match.Distance).
+			Bool("identical", match.Identical).
+			Msg("Image is a near-duplicate of an existing image")
+	}
+}
+
+// similarImages compares img with each of others, skipping img itself
+func similarImages(img *domain.Image, others []*domain.Image, maxDistance int) []*SimilarImage {
+	var similar []*SimilarImage
+	for _, other := range others {
+		if other.Path == img.Path {
+			continue
+		}
+
+		identical := img.Hash != "" && img.Hash == other.Hash
+		distance, ok := hashDistance(img.PerceptualHash, other.PerceptualHash)
+		switch {
+		case identical:
+			distance = 0
+		case !ok || distance > maxDistance:
+			continue
+		}
+
+		newer, older := img, other
+		if older.CreatedAt.After(newer.CreatedAt) || (older.CreatedAt.Equal(newer.CreatedAt) && older.Path > newer.Path) {
+			newer, older = older, newer
+		}
+		similar = append(similar, &SimilarImage{Path: newer.Path, SimilarTo: older.Path, Distance: distance, Identical: identical})
+	}
+	return similar
+}
+
+// perceptualHash computes a difference hash of an image: it is shrunk to 9x8 grey pixels, and each bit records
+// whether a pixel is darker than its right-hand neighbour. Scaling, re-encoding and small edits change few bits.
+// It returns an empty string when the content can't be decoded or is too large to decode.
+func perceptualHash(content []byte) string {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil || cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxHashedPixels {
+		return ""
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return ""
+	}
+
+	var grey [hashHeight][hashWidth]float64
+	bounds := img.Bounds()
+	for y := range hashHeight {
+		y0, y1 := scaledSpan(bounds.Min.Y, bounds.Dy(), y, hashHeight)
+		for x := range hashWidth {
+			x0, x1 := scaledSpan(bounds.Min.X, bounds.Dx(), x, hashWidth)
+
+			var sum float64
+			for py := y0; py < y1; py++ {
+				for px := x0; px < x1; px++ {
+					r, g, b, _ := img.At(px, py).RGBA()
+					sum += 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+				}
+			}
+			grey[y][x] = sum / float64((x1-x0)*(y1-y0))
+		}
+	}
+
+	var hash uint64
+	for y := range hashHeight {
+		for x := range hashWidth - 1 {
+			hash <<= 1
+			if grey[y][x] < grey[y][x+1] {
+				hash |= 1
+			}
+		}
+	}
+	return fmt.Sprintf("%016x", hash)
+}
+
+// scaledSpan returns the source pixels [start, end) that cell i of n covers along a dimension of size pixels,
+// always covering at least one pixel
+func scaledSpan(origin int, size int, i int, n int) (int, int) {
+	start := origin + i*size/n
+	end := origin + (i+1)*size/n
+	if end <= start {
+		end = start + 1
+	}
+	return start, end
+}
+
+// hashDistance counts the bits that differ between two perceptual hashes
+// It reports false when either image has no perceptual hash.
+func hashDistance(a string, b string) (int, bool) {
+	x, err := strconv.ParseUint(a, 16, 64)
+	if err != nil {
+		return 0, false
+	}
+	y, err := strconv.ParseUint(b, 16, 64)
+	if err != nil {
+		return 0, false
+	}
+	return bits.OnesCount64(x ^ y), true
+}
@@ -0,0 +1,127 @@
+package application
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+)
+
+// testImage draws a w by h image whose brightness follows shade
+func testImage(w, h int, shade func(x, y float64) float64) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			img.SetGray(x, y, color.Gray{Y: uint8(255 * shade(float64(x)/float64(w), float64(y)/float64(h)))})
+		}
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("png.Encode() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 60}); err != nil {
+		t.Fatalf("jpeg.Encode() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestPerceptualHash(t *testing.T) {
+	// A bright spot left of centre, on a background that darkens downwards
+	scene := func(x, y float64) float64 {
+		d := (x-0.3)*(x-0.3) + (y-0.5)*(y-0.5)
+		return max(0, min(1, 0.8-0.5*y+0.6*(1-min(1, d*8))*0.5))
+	}
+	// The same scene mirrored
+	mirrored := func(x, y float64) float64 { return scene(1-x, y) }
+
+	original := perceptualHash(encodePNG(t, testImage(400, 300, scene)))
+	resized := perceptualHash(encodeJPEG(t, testImage(133, 100, scene)))
+	different := perceptualHash(encodePNG(t, testImage(400, 300, mirrored)))
+	if original == "" || resized == "" || different == "" {
+		t.Fatalf("perceptualHash() = %q, %q, %q, want hashes of every image", original, resized, different)
+	}
+
+	if d, ok := hashDistance(original, resized); !ok || d > DefaultSimilarImageDistance {
+		t.Errorf("distance to a resized JPEG copy = %d, want at most %d", d, DefaultSimilarImageDistance)
+	}
+	if d, ok := hashDistance(original, different); !ok || d <= DefaultSimilarImageDistance {
+		t.Errorf("distance to a mirrored image = %d, want more than %d", d, DefaultSimilarImageDistance)
+	}
+
+	if got := perceptualHash([]byte("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>")); got != "" {
+		t.Errorf("perceptualHash() of an SVG = %q, want empty", got)
+	}
+	if got := perceptualHash(encodePNG(t, testImage(1, 1, scene))); got == "" {
+		t.Error("perceptualHash() of a 1x1 image is empty, want a hash")
+	}
+}
+
+func TestPostService_FindSimilarImages(t *testing.T) {
+	older := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+	images := newFakeImageRepository(
+		&domain.Image{Path: "images/a.png", Hash: "h1", PerceptualHash: "00000000000000ff", CreatedAt: newer},
+		&domain.Image{Path: "images/b.jpg", Hash: "h2", PerceptualHash: "00000000000000fc", CreatedAt: older},
+		&domain.Image{Path: "images/c.png", Hash: "h3", PerceptualHash: "ffffffffffffff00", CreatedAt: older},
+		&domain.Image{Path: "images/copy.svg", Hash: "h4", CreatedAt: newer},
+		&domain.Image{Path: "images/logo.svg", Hash: "h4", CreatedAt: older},
+	)
+	service := NewPostService(newFakePostRepository(), images, nil, NewMarkdownRenderer(), "main")
+	defer service.Close()
+
+	got, err := service.FindSimilarImages(context.Background(), DefaultSimilarImageDistance)
+	if err != nil {
+		t.Fatalf("FindSimilarImages() error = %v", err)
+	}
+
+	want := []*SimilarImage{
+		{Path: "images/a.png", SimilarTo: "images/b.jpg", Distance: 2},
+		{Path: "images/copy.svg", SimilarTo: "images/logo.svg", Distance: 0, Identical: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindSimilarImages() = %+v, want %+v", got, want)
+	}
+}
+
+func TestPostService_ProcessImageFile_RecordsPerceptualHash(t *testing.T) {
+	content := encodePNG(t, testImage(64, 64, func(x, y float64) float64 { return x * y }))
+	source := newFakeSourceRepository()
+	source.files["images/photo.png"] = content
+	images := newFakeImageRepository(&domain.Image{
+		Path:      "images/photo.png",
+		Hash:      calculateHash(content),
+		CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+	service := NewPostService(newFakePostRepository(), images, source, NewMarkdownRenderer(), "main")
+	defer service.Close()
+
+	if err := service.processImageFile(context.Background(), "images/photo.png", "sha"); err != nil {
+		t.Fatalf("processImageFile() error = %v", err)
+	}
+
+	img := images.images["images/photo.png"]
+	if img.PerceptualHash != perceptualHash(content) || img.PerceptualHash == "" {
+		t.Errorf("PerceptualHash = %q, want %q", img.PerceptualHash, perceptualHash(content))
+	}
+	if !img.CreatedAt.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("CreatedAt = %v, want it kept", img.CreatedAt)
+	}
+}
@@ -0,0 +1,65 @@
+package application
+
+import (
+	"os"
+	"strings"
+)
+
+// ImageURLStyle selects how image URLs are built on an external image host
+type ImageURLStyle string
+
+const (
+	// ImageURLByHash links <base>/images/<sha256>.<ext>, for a CDN that pulls images from the blog
+	ImageURLByHash ImageURLStyle = "hash"
+	// ImageURLByPath links <base>/images/<path>?v=<hash>, for the public URL of the bucket images are stored in,
+	// which keeps them under their repository path; the version changes with the content so caches refresh
+	ImageURLByPath ImageURLStyle = "path"
+)
+
+// imageVersionLength is how many hex digits of the content hash versions a path URL
+const imageVersionLength = 12
+
+// ImageURLConfig points image links at a host other than the blog
+// The blog still ingests, stores and serves every image; only the links in rendered posts change.
+// Changing it only affects posts rendered afterwards; a resync re-renders the rest.
+type ImageURLConfig struct {
+	// BaseURL is the image host, such as https://cdn.example.com; images are linked on the blog when it is empty
+	BaseURL string
+	Style   ImageURLStyle
+}
+
+func NewImageURLConfig() *ImageURLConfig {
+	return &ImageURLConfig{
+		BaseURL: strings.TrimSuffix(os.Getenv("IMAGE_BASE_URL"), "/"),
+		Style:   parseImageURLStyle(os.Getenv("IMAGE_URL_STYLE")),
+	}
+}
+
+// parseImageURLStyle returns the style named by value, or ImageURLByHash when it names none
+func parseImageURLStyle(value string) ImageURLStyle {
+	switch style := ImageURLStyle(value); style {
+	case ImageURLByPath:
+		return style
+	default:
+		return ImageURLByHash
+	}
+}
+
+// WithImageURLs links stored images on the configured image host
+func WithImageURLs(cfg *ImageURLConfig) MarkdownOption {
+	return func(o *markdownOptions) {
+		o.imageURLs = cfg
+	}
+}
+
+// url returns the link to a stored image on the image host, or false when images are linked on the blog
+func (c *ImageURLConfig) url(hash string, imagePath string) (string, bool) {
+	if c == nil || c.BaseURL == "" {
+		return "", false
+	}
+
+	if c.Style == ImageURLByPath {
+		return c.BaseURL + "/" + imagePath + "?v=" + hash[:min(len(hash), imageVersionLength)], true
+	}
+	return c.BaseURL + ImageURLPath(hash, imagePath), true
+}
@@ -0,0 +1,139 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/rs/zerolog/log"
+)
+
+// ImageRepositoryResolver resolves image hashes from the images already stored in the repository
+func ImageRepositoryResolver(repo domain.ImageRepository) ImageResolver {
+	return func(imagePath string) (string, bool) {
+		img, err := repo.GetImage(context.Background(), imagePath)
+		if err != nil {
+			if !errors.Is(err, domain.ErrImageNotFound) {
+				log.Warn().Err(err).Str("path", imagePath).Msg("Failed to resolve image hash")
+			}
+			return "", false
+		}
+		return img.Hash, true
+	}
+}
+
+// GetImage returns the stored image at a repository path, without its content
+func (s *PostService) GetImage(ctx context.Context, imagePath string) (*domain.Image, error) {
+	return s.imageRepo.GetImage(ctx, imagePath)
+}
+
+// ImagePath returns the repository path of an image from its path below /images/, where it is served by path
+func (s *PostService) ImagePath(file string) string {
+	return s.layout.imageRepoPath(file)
+}
+
+// GetImageByHash returns the image with the given content hash, including its content
+func (s *PostService) GetImageByHash(ctx context.Context, hash string) (*domain.Image, error) {
+	img, err := s.imageRepo.GetImageByHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := s.imageRepo.GetImageContent(ctx, img.Path)
+	if err != nil {
+		return nil, err
+	}
+	img.Content = content
+
+	return img, nil
+}
+
+// PublishedImages returns the stored images referenced by at least one published post, without their content
+func (s *PostService) PublishedImages(ctx context.Context) ([]*domain.Image, error) {
+	images, err := s.imageRepo.ListImages(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list images: %w", err)
+	}
+
+	var published []*domain.Image
+	for _, img := range images {
+		posts, err := s.repo.ListPostsByImage(ctx, img.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list posts using %s: %w", img.Path, err)
+		}
+		if slices.ContainsFunc(posts, func(p *domain.Post) bool { return !p.PublishedAt.IsZero() }) {
+			published = append(published, img)
+		}
+	}
+	return published, nil
+}
+
+// refreshPostsForImage re-renders the merged posts that reference an image so their links carry its current hash
+func (s *PostService) refreshPostsForImage(ctx context.Context, imagePath string) error {
+	posts, err := s.repo.ListPostsByImage(ctx, imagePath)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, post := range posts {
+		// Only merged posts are rendered from the main branch; drafts refresh on their next push
+		if post.SourcePath == "" || (post.PublishedAt.IsZero() && post.PublishAt.IsZero()) {
+			continue
+		}
+
+		if err := s.rerenderPost(ctx, post); err != nil {
+			errs = append(errs, fmt.Errorf("post %s: %w", post.ID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// rerenderPost renders a post again from the main branch, keeping its publication state
+// When tags or releases publish, it renders the commit the post was published from, so unreleased edits stay hidden.
+func (s *PostService) rerenderPost(ctx context.Context, post *domain.Post) error {
+	// The post may have been rendered again since it was listed, so it is read again under its lock
+	defer s.postLocks.lock(post.ID)()
+	post, err := s.repo.GetPost(ctx, post.ID)
+	if errors.Is(err, domain.ErrPostNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	ref := s.mainBranchName
+	if !s.publishesOnMerge() && post.CommitSHA != "" {
+		ref = post.CommitSHA
+	}
+
+	markdownContent, err := s.sourceRepo.GetFileContents(ctx, post.SourcePath, ref)
+	if err != nil {
+		return fmt.Errorf("failed to get file contents: %w", err)
+	}
+
+	result, err := s.markdown.Render(markdownContent)
+	if err != nil {
+		return fmt.Errorf("failed to render markdown: %w", err)
+	}
+
+	post.Title = result.Title
+	post.Snippet = result.Snippet
+	post.HTMLContent = result.HTMLContent
+	post.Images = result.Images
+	post.WordCount = result.WordCount
+	post.ReadingMinutes = result.ReadingMinutes
+	post.TOC = result.TOC
+	post.Language = result.Language
+	s.signPost(post)
+
+	if err := s.repo.SavePost(ctx, post); err != nil {
+		return fmt.Errorf("failed to save post: %w", err)
+	}
+
+	log.Info().Str("postID", post.ID).Msg("Post re-rendered for updated image")
+	return nil
+}
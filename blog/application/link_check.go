@@ -0,0 +1,235 @@
+package application
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const defaultLinkCheckInterval = time.Hour
+
+// imageHashRegex matches the name of a content-addressed image URL, see ImageURLPath
+var imageHashRegex = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+type LinkCheckConfig struct {
+	// Interval is how often rendered posts are checked for broken links
+	Interval time.Duration
+}
+
+func NewLinkCheckConfig() *LinkCheckConfig {
+	interval := defaultLinkCheckInterval
+	if d, err := time.ParseDuration(os.Getenv("LINK_CHECK_INTERVAL")); err == nil && d > 0 {
+		interval = d
+	}
+
+	return &LinkCheckConfig{Interval: interval}
+}
+
+// WithPostDiagnostics records the broken links and images CheckLinks finds in each post
+func WithPostDiagnostics(diagnostics domain.PostDiagnosticRepository) PostServiceOption {
+	return func(s *PostService) {
+		s.diagnostics = diagnostics
+	}
+}
+
+// ListDiagnostics returns the broken links and images found by the last link check, ordered by post
+func (s *PostService) ListDiagnostics(ctx context.Context) ([]*domain.PostDiagnostic, error) {
+	if s.diagnostics == nil {
+		return []*domain.PostDiagnostic{}, nil
+	}
+	return s.diagnostics.ListDiagnostics(ctx)
+}
+
+// CheckLinks scans the rendered HTML of every post for links and images on the blog that lead nowhere,
+// replacing the diagnostics of each post with what it finds
+// Links to other sites are not followed.
+func (s *PostService) CheckLinks(ctx context.Context) error {
+	if s.diagnostics == nil {
+		return nil
+	}
+
+	posts, err := s.repo.ListPosts(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list posts: %w", err)
+	}
+
+	// A /posts/ URL may name a post by ID or by slug
+	byKey := make(map[string]*domain.Post, 2*len(posts))
+	for _, post := range posts {
+		byKey[post.ID] = post
+		if post.Slug != "" {
+			byKey[post.Slug] = post
+		}
+	}
+
+	now := s.clock.Now().UTC()
+	var errs []error
+	for _, post := range posts {
+		diagnostics, err := s.checkPostLinks(ctx, post, byKey, now)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := s.diagnostics.ReplaceDiagnostics(ctx, post.ID, diagnostics); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// checkPostLinks returns the broken links and images in the rendered HTML of post
+func (s *PostService) checkPostLinks(ctx context.Context, post *domain.Post, byKey map[string]*domain.Post, now time.Time) ([]*domain.PostDiagnostic, error) {
+	content, err := s.repo.GetPostHTML(ctx, post.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read post %s: %w", post.ID, err)
+	}
+
+	refs, err := postReferences(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse post %s: %w", post.ID, err)
+	}
+
+	var diagnostics []*domain.PostDiagnostic
+	seen := make(map[string]bool)
+	for _, ref := range refs {
+		if seen[ref] {
+			continue
+		}
+		seen[ref] = true
+
+		p, ok := blogPath(ref)
+		if !ok {
+			continue
+		}
+
+		kind, message, err := s.checkBlogPath(ctx, post, p, byKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check %s in post %s: %w", ref, post.ID, err)
+		}
+		if message != "" {
+			diagnostics = append(diagnostics, &domain.PostDiagnostic{
+				PostID:    post.ID,
+				Kind:      kind,
+				Target:    ref,
+				Message:   message,
+				CheckedAt: now,
+			})
+		}
+	}
+
+	return diagnostics, nil
+}
+
+// checkBlogPath describes what is wrong with a path on the blog that post refers to, or returns no message when nothing is
+func (s *PostService) checkBlogPath(ctx context.Context, post *domain.Post, p string, byKey map[string]*domain.Post) (domain.DiagnosticKind, string, error) {
+	if file, ok := strings.CutPrefix(p, "/images/"); ok {
+		message, err := s.checkImage(ctx, file)
+		return domain.DiagnosticMissingImage, message, err
+	}
+
+	// Links to other posts are rendered as /<file name without .md>; /posts/<slug> and /posts/<id> are their URLs
+	id, ok := strings.CutPrefix(p, "/posts/")
+	missing := "no post has ID or slug %s"
+	if !ok {
+		missing = "no post has ID %s"
+		name := strings.TrimPrefix(p, "/")
+		if name == "" || strings.Contains(name, "/") {
+			return "", "", nil
+		}
+		if id = s.postID(s.layout.postNamePath(name)); id == "" {
+			return "", "", nil
+		}
+	}
+
+	target, ok := byKey[id]
+	switch {
+	case !ok:
+		return domain.DiagnosticBrokenLink, fmt.Sprintf(missing, id), nil
+	case !post.PublishedAt.IsZero() && target.PublishedAt.IsZero():
+		return domain.DiagnosticBrokenLink, fmt.Sprintf("post %s is not published", id), nil
+	default:
+		return "", "", nil
+	}
+}
+
+// checkImage describes why an image URL on the blog can't be served, or returns no message when it can
+func (s *PostService) checkImage(ctx context.Context, file string) (string, error) {
+	var err error
+	if name := strings.TrimSuffix(file, path.Ext(file)); imageHashRegex.MatchString(name) {
+		_, err = s.imageRepo.GetImageByHash(ctx, name)
+	} else {
+		_, err = s.imageRepo.GetImage(ctx, s.layout.imageRepoPath(file))
+	}
+
+	if errors.Is(err, domain.ErrImageNotFound) {
+		return "image is not stored", nil
+	}
+	return "", err
+}
+
+// blogPath returns the path of a URL on the blog, or false when it points elsewhere or within the page
+func blogPath(ref string) (string, bool) {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return "", false
+	}
+
+	if u.Scheme != "" || u.Host != "" {
+		if !strings.EqualFold(u.Scheme+"://"+u.Host, blogURL) {
+			return "", false
+		}
+	} else if !strings.HasPrefix(u.Path, "/") {
+		return "", false
+	}
+
+	return u.Path, true
+}
+
+// postReferences returns the link targets and image sources in rendered post HTML, in document order
+func postReferences(content []byte) ([]string, error) {
+	body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(bytes.NewReader(content), body)
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []string
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			key := ""
+			switch n.DataAtom {
+			case atom.A:
+				key = "href"
+			case atom.Img:
+				key = "src"
+			}
+			for _, attr := range n.Attr {
+				if key != "" && attr.Key == key && attr.Val != "" {
+					refs = append(refs, attr.Val)
+				}
+			}
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	for _, n := range nodes {
+		walk(n)
+	}
+
+	return refs, nil
+}
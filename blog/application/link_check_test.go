@@ -0,0 +1,78 @@
+package application
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+)
+
+// fakeDiagnosticRepository keeps the diagnostics of each post in memory
+type fakeDiagnosticRepository struct {
+	posts map[string][]*domain.PostDiagnostic
+}
+
+func (f *fakeDiagnosticRepository) ReplaceDiagnostics(ctx context.Context, postID string, diagnostics []*domain.PostDiagnostic) error {
+	f.posts[postID] = diagnostics
+	return nil
+}
+
+func (f *fakeDiagnosticRepository) ListDiagnostics(ctx context.Context) ([]*domain.PostDiagnostic, error) {
+	var all []*domain.PostDiagnostic
+	for _, diagnostics := range f.posts {
+		all = append(all, diagnostics...)
+	}
+	return all, nil
+}
+
+func TestPostService_CheckLinks(t *testing.T) {
+	hash := strings.Repeat("a", 64)
+	published := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := newFakePostRepository(
+		&domain.Post{ID: "001", PublishedAt: published, HTMLContent: []byte(`
+			<p><a href="https://blog.werewolves.fyi/002-draft">draft</a>
+			<a href="/posts/003">published</a>
+			<a href="https://blog.werewolves.fyi/009-gone#intro">gone</a>
+			<a href="https://example.com/009-elsewhere">elsewhere</a>
+			<a href="#intro">anchor</a></p>
+			<img src="https://blog.werewolves.fyi/images/` + hash + `.png">
+			<img src="https://blog.werewolves.fyi/images/missing.png">
+			<img src="https://blog.werewolves.fyi/images/missing.png">`)},
+		&domain.Post{ID: "002", HTMLContent: []byte(`<a href="/posts/001">first</a><a href="/posts/003">third</a>`)},
+		&domain.Post{ID: "003", PublishedAt: published, HTMLContent: []byte(`<p>No links</p>`)},
+	)
+	images := newFakeImageRepository(&domain.Image{Path: "images/photo.png", Hash: hash})
+	diagnostics := &fakeDiagnosticRepository{posts: map[string][]*domain.PostDiagnostic{
+		"003": {{PostID: "003", Kind: domain.DiagnosticBrokenLink, Target: "/posts/004"}},
+	}}
+	service := NewPostService(repo, images, newFakeSourceRepository(), NewMarkdownRenderer(), "main",
+		WithPostDiagnostics(diagnostics),
+	)
+	defer service.Close()
+
+	if err := service.CheckLinks(t.Context()); err != nil {
+		t.Fatalf("CheckLinks() error = %v", err)
+	}
+
+	var got []string
+	for _, d := range diagnostics.posts["001"] {
+		got = append(got, string(d.Kind)+" "+d.Target+": "+d.Message)
+	}
+	want := []string{
+		"broken_link https://blog.werewolves.fyi/002-draft: post 002 is not published",
+		"broken_link https://blog.werewolves.fyi/009-gone#intro: no post has ID 009",
+		"missing_image https://blog.werewolves.fyi/images/missing.png: image is not stored",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("diagnostics of post 001 =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if len(diagnostics.posts["002"]) != 0 {
+		t.Errorf("Expected a draft to link to any existing post, got %+v", diagnostics.posts["002"])
+	}
+	if len(diagnostics.posts["003"]) != 0 {
+		t.Errorf("Expected fixed posts to have their diagnostics cleared, got %+v", diagnostics.posts["003"])
+	}
+}
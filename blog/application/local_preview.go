@@ -0,0 +1,186 @@
+package application
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+)
+
+// LocalSite renders the posts of a local checkout of the content repository, so authors can preview them without pushing
+// Nothing is stored: every read renders the files as they are on disk, with the same renderer the server uses.
+type LocalSite struct {
+	dir      string
+	layout   *ContentLayout
+	markdown MarkdownRenderer
+}
+
+func NewLocalSite(dir string, layout *ContentLayout, markdown MarkdownRenderer) *LocalSite {
+	return &LocalSite{dir: dir, layout: layout, markdown: markdown}
+}
+
+// localPostFile is a post file found in the checkout
+type localPostFile struct {
+	id   string
+	path string
+	info fs.FileInfo
+}
+
+// Posts renders every post in the checkout, newest first
+// Posts that fail to render are left out, and their errors returned together with the rest.
+func (s *LocalSite) Posts() ([]*domain.Post, error) {
+	files, err := s.postFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	var posts []*domain.Post
+	var errs []error
+	for _, file := range files {
+		post, err := s.render(file)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		posts = append(posts, post)
+	}
+
+	slices.SortFunc(posts, func(a, b *domain.Post) int {
+		return cmp.Or(b.PublishedAt.Compare(a.PublishedAt), cmp.Compare(b.ID, a.ID))
+	})
+	return posts, errors.Join(errs...)
+}
+
+// Post renders the post with the given ID, or returns domain.ErrPostNotFound if the checkout has none
+func (s *LocalSite) Post(id string) (*domain.Post, error) {
+	files, err := s.postFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		if file.id == id {
+			return s.render(file)
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", domain.ErrPostNotFound, id)
+}
+
+// Images returns the images directory of the checkout
+func (s *LocalSite) Images() fs.FS {
+	return os.DirFS(filepath.Join(s.dir, s.layout.ImagesDir()))
+}
+
+// Version fingerprints the names, sizes and modification times of the posts and images in the checkout,
+// and of the files in any extra directories such as a theme. It changes whenever one of them does.
+func (s *LocalSite) Version(extraDirs ...string) (string, error) {
+	h := sha256.New()
+	dirs := append([]string{filepath.Join(s.dir, s.layout.PostsDir()), filepath.Join(s.dir, s.layout.ImagesDir())}, extraDirs...)
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(h, "%s\x00%d\x00%d\n", path, info.Size(), info.ModTime().UnixNano())
+			return nil
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to scan %s: %w", dir, err)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// postFiles lists the post files in the checkout, keeping the first file found for each ID
+func (s *LocalSite) postFiles() ([]localPostFile, error) {
+	var files []localPostFile
+	seen := make(map[string]bool)
+
+	err := filepath.WalkDir(filepath.Join(s.dir, s.layout.PostsDir()), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.dir, path)
+		if err != nil {
+			return err
+		}
+		repoPath := filepath.ToSlash(rel)
+		id := s.layout.PostID(repoPath)
+		if id == "" || seen[id] {
+			return nil
+		}
+		seen[id] = true
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, localPostFile{id: id, path: repoPath, info: info})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list posts: %w", err)
+	}
+
+	return files, nil
+}
+
+// render renders a post file as the server would once it is published
+// Posts are dated by their scheduled publication time, or by when the file was last changed.
+func (s *LocalSite) render(file localPostFile) (*domain.Post, error) {
+	source, err := os.ReadFile(filepath.Join(s.dir, filepath.FromSlash(file.path)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", file.path, err)
+	}
+
+	result, err := s.markdown.Render(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render %s: %w", file.path, err)
+	}
+
+	publishedAt := result.PublishAt
+	if publishedAt.IsZero() {
+		publishedAt = file.info.ModTime()
+	}
+
+	return &domain.Post{
+		ID:          file.id,
+		Title:       result.Title,
+		Snippet:     result.Snippet,
+		HTMLPath:    file.id + ".html",
+		HTMLContent: result.HTMLContent,
+		SourcePath:  file.path,
+		Images:      result.Images,
+		UpdatedAt:   file.info.ModTime(),
+		CreatedAt:   publishedAt,
+		PublishAt:   result.PublishAt,
+		PublishedAt: publishedAt,
+
+		WordCount:      result.WordCount,
+		ReadingMinutes: result.ReadingMinutes,
+		TOC:            result.TOC,
+		Language:       result.Language,
+		Document:       result.Document,
+	}, nil
+}
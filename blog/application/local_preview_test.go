@@ -0,0 +1,93 @@
+package application
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+)
+
+func writeLocalFile(t *testing.T, dir string, path string, content string, modTime time.Time) {
+	t.Helper()
+	full := filepath.Join(dir, filepath.FromSlash(path))
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(full, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLocalSite_Posts(t *testing.T) {
+	dir := t.TempDir()
+	older := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(24 * time.Hour)
+	writeLocalFile(t, dir, "posts/001-first.md", "# First\n\n![cat](../images/cat.png) and [second](002-second.md)", older)
+	writeLocalFile(t, dir, "posts/002-second.md", "# Second\n\nText", newer)
+	writeLocalFile(t, dir, "posts/notes.md", "# Not a post", newer)
+
+	site := NewLocalSite(dir, DefaultContentLayout(), NewMarkdownRenderer(WithLinkBaseURL("")))
+	posts, err := site.Posts()
+	if err != nil {
+		t.Fatalf("Posts() error = %v", err)
+	}
+	if len(posts) != 2 || posts[0].ID != "002" || posts[1].ID != "001" {
+		t.Fatalf("Posts() = %v, want 002 then 001", posts)
+	}
+	if !posts[1].PublishedAt.Equal(older) || posts[1].Title != "First" {
+		t.Errorf("post 001 = %+v, want it titled and dated by its file", posts[1])
+	}
+
+	post, err := site.Post("001")
+	if err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+	html := string(post.HTMLContent)
+	if !strings.Contains(html, `src="/images/cat.png"`) || !strings.Contains(html, `href="/002-second"`) {
+		t.Errorf("Post() HTML = %s, want links rooted at the preview host", html)
+	}
+
+	if _, err := site.Post("003"); !errors.Is(err, domain.ErrPostNotFound) {
+		t.Errorf("Post() error = %v, want ErrPostNotFound", err)
+	}
+}
+
+func TestLocalSite_Version(t *testing.T) {
+	dir := t.TempDir()
+	modTime := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	writeLocalFile(t, dir, "posts/001-first.md", "# First", modTime)
+
+	site := NewLocalSite(dir, DefaultContentLayout(), NewMarkdownRenderer())
+	version, err := site.Version()
+	if err != nil {
+		t.Fatalf("Version() error = %v", err)
+	}
+	if again, _ := site.Version(); again != version {
+		t.Error("Expected the version not to change while the checkout doesn't")
+	}
+
+	writeLocalFile(t, dir, "posts/001-first.md", "# First, edited", modTime.Add(time.Second))
+	edited, err := site.Version()
+	if err != nil || edited == version {
+		t.Errorf("Version() = %q, %v, want it changed by the edit", edited, err)
+	}
+
+	writeLocalFile(t, dir, "images/cat.png", "png", modTime)
+	if added, _ := site.Version(); added == edited {
+		t.Error("Expected a new image to change the version")
+	}
+
+	themeDir := filepath.Join(dir, "theme")
+	before, _ := site.Version(themeDir)
+	writeLocalFile(t, dir, "theme/post.html", "{{.Content}}", modTime)
+	if after, _ := site.Version(themeDir); after == before {
+		t.Error("Expected a theme change to change the version")
+	}
+}
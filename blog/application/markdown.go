@@ -4,8 +4,14 @@ import (
 	"bytes"
 	"fmt"
 	"path"
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/yuin/goldmark"
 	"github.com/yuin/goldmark/ast"
 	"github.com/yuin/goldmark/extension"
@@ -15,20 +21,94 @@ import (
 	"github.com/yuin/goldmark/util"
 )
 
-const (
-	maxLength = 200
-	blogURL   = "https://blog.werewolves.fyi"
-)
+const blogURL = "https://blog.werewolves.fyi"
+
+// imageRefsKey stores the repository paths of images referenced by the document being converted
+var imageRefsKey = parser.NewContextKey()
+
+// linkRefsKey stores the relative link destinations of the document being converted, as written
+var linkRefsKey = parser.NewContextKey()
+
+var markdownRenders = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "goblog_markdown_renders_total",
+	Help: "Markdown documents rendered to HTML, by result.",
+}, []string{"result"})
 
 // MarkdownProcessingResult contains the results of processing a markdown file
 type MarkdownProcessingResult struct {
 	Title       string
 	Snippet     string
 	HTMLContent []byte
+	// Images holds the repository paths of the local images the post references
+	Images []string
+	// Links holds the destinations of the post's relative links, as written
+	Links []string
+	// PublishAt is the scheduled publication time from the front matter, if any
+	PublishAt time.Time
+	// WordCount is the number of words of prose, leaving out code blocks
+	WordCount int
+	// ReadingMinutes is the estimated reading time in whole minutes
+	ReadingMinutes int
+	// TOC is the nested table of contents, or nil when the post has too few headings
+	TOC []*domain.Heading
+	// Document is the structure of the post, or nil when documents are not enabled
+	Document *domain.Document
+	// Language is the language tag from the front matter, if any
+	Language string
+	// FrontMatter lists the front matter problems that were only warned about
+	FrontMatter []FrontMatterIssue
+}
+
+// ImageResolver returns the content hash of the image stored at a repository path
+type ImageResolver func(imagePath string) (hash string, ok bool)
+
+// MarkdownOption configures optional MarkdownRenderer behaviour
+type MarkdownOption func(*markdownOptions)
+
+type markdownOptions struct {
+	resolveImage ImageResolver
+	extensions   *MarkdownConfig
+	location     *time.Location
+	imageURLs    *ImageURLConfig
+	linkBaseURL  string
+	frontMatter  *FrontMatterConfig
+	layout       *ContentLayout
+}
+
+// WithImageResolver makes rendered posts link images by content hash
+// Images the resolver does not know yet are linked by path, which redirects once they are stored
+func WithImageResolver(resolve ImageResolver) MarkdownOption {
+	return func(o *markdownOptions) {
+		o.resolveImage = resolve
+	}
+}
+
+// WithLinkBaseURL sets the base URL relative links and images in posts are made absolute against
+// An empty base URL leaves them rooted at the current host, as a local preview needs.
+func WithLinkBaseURL(baseURL string) MarkdownOption {
+	return func(o *markdownOptions) {
+		o.linkBaseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// WithMarkdownLayout maps image destinations in posts to the images directory of layout
+func WithMarkdownLayout(layout *ContentLayout) MarkdownOption {
+	return func(o *markdownOptions) {
+		o.layout = layout
+	}
+}
+
+// ImageURLPath returns the content-addressed URL path for an image
+func ImageURLPath(hash string, imagePath string) string {
+	return "/images/" + hash + strings.ToLower(path.Ext(imagePath))
 }
 
 type relativeLinkTransformer struct {
-	domain string
+	domain       string
+	resolveImage ImageResolver
+	// imageURLs links stored images on an external host, or is nil to link them on the blog
+	imageURLs *ImageURLConfig
+	layout    *ContentLayout
 }
 
 func (t *relativeLinkTransformer) Transform(node *ast.Document, reader text.Reader, pc parser.Context) {
@@ -53,8 +133,11 @@ func (t *relativeLinkTransformer) Transform(node *ast.Document, reader text.Read
 		if isRelativeLink(dest) {
 			destFile := path.Base(dest)
 			if imgOk {
-				img.Destination = []byte(t.domain + "/images/" + destFile)
+				imagePath := t.layout.imageDestPath(dest)
+				img.Destination = []byte(t.imageURL(imagePath))
+				addRef(pc, imageRefsKey, imagePath)
 			} else if linkOk {
+				addRef(pc, linkRefsKey, dest)
 				// Strip .md and .html extensions from links
 				destFile = strings.TrimSuffix(destFile, ".md")
 				destFile = strings.TrimSuffix(destFile, ".html")
@@ -66,6 +149,29 @@ func (t *relativeLinkTransformer) Transform(node *ast.Document, reader text.Read
 	})
 }
 
+// imageURL links an image by content hash when it is known, and by path otherwise
+// Only stored images are linked on an external image host; others are linked on the blog, which redirects once they are stored.
+func (t *relativeLinkTransformer) imageURL(imagePath string) string {
+	if t.resolveImage != nil {
+		if hash, ok := t.resolveImage(imagePath); ok {
+			if url, ok := t.imageURLs.url(hash, imagePath); ok {
+				return url
+			}
+			return t.domain + ImageURLPath(hash, imagePath)
+		}
+	}
+	return t.domain + "/images/" + t.layout.imageFile(imagePath)
+}
+
+// addRef records a reference under key in the parser context, ignoring duplicates
+func addRef(pc parser.Context, key parser.ContextKey, ref string) {
+	refs, _ := pc.Get(key).([]string)
+	if slices.Contains(refs, ref) {
+		return
+	}
+	pc.Set(key, append(refs, ref))
+}
+
 func isRelativeLink(dest string) bool {
 	// Absolute path check
 	if strings.HasPrefix(dest, "/") {
@@ -93,22 +199,44 @@ type MarkdownRenderer interface {
 
 type MarkdownRendererImpl struct {
 	renderer goldmark.Markdown
+	// sanitizer cleans rendered HTML, or is nil when raw HTML in posts is trusted
+	sanitizer *bluemonday.Policy
+	// location is the time zone of front matter times written without a UTC offset
+	location *time.Location
+	// strict refuses posts whose front matter has any issue, rather than rendering them with warnings
+	strict bool
 }
 
-func NewMarkdownRenderer() MarkdownRenderer {
-	// TODO: Implement custom domains for relative links
+func NewMarkdownRenderer(opts ...MarkdownOption) MarkdownRenderer {
+	options := &markdownOptions{extensions: &MarkdownConfig{}, location: time.UTC, linkBaseURL: blogURL, frontMatter: &FrontMatterConfig{}, layout: DefaultContentLayout()}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	linkTransformer := &relativeLinkTransformer{domain: options.linkBaseURL, resolveImage: options.resolveImage, imageURLs: options.imageURLs, layout: options.layout}
+
+	transformers := []util.PrioritizedValue{
+		util.Prioritized(linkTransformer, 100),
+		util.Prioritized(wordCounter{}, 200),
+		util.Prioritized(tocExtractor{}, 300),
+		util.Prioritized(snippetExtractor{maxLength: options.extensions.snippetLength()}, 400),
+	}
+	if options.extensions.Document {
+		// After every other transformer, including the footnote list at 999
+		transformers = append(transformers, util.Prioritized(documentBuilder{}, 1000))
+	}
+
 	renderer := goldmark.New(
-		goldmark.WithExtensions(
+		goldmark.WithExtensions(append([]goldmark.Extender{
 			extension.GFM,
 			extension.Table,
 			extension.Strikethrough,
 			extension.TaskList,
-		),
+			mermaidExtension{},
+		}, options.extensions.extenders()...)...),
 		goldmark.WithParserOptions(
 			parser.WithAutoHeadingID(),
-			parser.WithASTTransformers(
-				util.Prioritized(&relativeLinkTransformer{domain: blogURL}, 100),
-			),
+			parser.WithASTTransformers(transformers...),
 		),
 		goldmark.WithRendererOptions(
 			html.WithHardWraps(),
@@ -118,24 +246,62 @@ func NewMarkdownRenderer() MarkdownRenderer {
 	)
 
 	return &MarkdownRendererImpl{
-		renderer: renderer,
+		renderer:  renderer,
+		sanitizer: sanitizePolicy(options.extensions.Sanitize),
+		location:  options.location,
+		strict:    options.frontMatter.Strict,
 	}
 }
 
-func (r *MarkdownRendererImpl) Render(markdown []byte) (*MarkdownProcessingResult, error) {
+func (r *MarkdownRendererImpl) Render(source []byte) (*MarkdownProcessingResult, error) {
+	frontMatter, markdown, err := splitFrontMatter(source, r.location)
+	if err == nil && r.strict && len(frontMatter.Issues) > 0 {
+		err = &FrontMatterError{Issues: frontMatter.Issues}
+	}
+	if err != nil {
+		markdownRenders.WithLabelValues("error").Inc()
+		return nil, err
+	}
+
 	title := extractPostTitle(markdown)
-	snippet := extractSnippet(markdown)
-	
+
 	var buf bytes.Buffer
-	err := r.renderer.Convert(markdown, &buf)
+	pc := parser.NewContext()
+	err = r.renderer.Convert(markdown, &buf, parser.WithContext(pc))
 	if err != nil {
+		markdownRenders.WithLabelValues("error").Inc()
 		return nil, fmt.Errorf("failed to convert markdown to HTML: %w", err)
 	}
+	markdownRenders.WithLabelValues("ok").Inc()
+
+	content := buf.Bytes()
+	if r.sanitizer != nil {
+		content = r.sanitizer.SanitizeBytes(content)
+	}
+
+	images, _ := pc.Get(imageRefsKey).([]string)
+	links, _ := pc.Get(linkRefsKey).([]string)
+	snippet, _ := pc.Get(snippetKey).(string)
+	words, _ := pc.Get(wordCountKey).(int)
+	toc, _ := pc.Get(tocKey).([]*domain.Heading)
+	document, _ := pc.Get(documentKey).(*domain.Document)
+	if document != nil && r.sanitizer != nil {
+		sanitizeDocument(r.sanitizer, document.Blocks)
+	}
 
 	return &MarkdownProcessingResult{
-		Title:       title,
-		Snippet:     snippet,
-		HTMLContent: buf.Bytes(),
+		Title:          title,
+		Snippet:        snippet,
+		HTMLContent:    content,
+		Images:         images,
+		Links:          links,
+		PublishAt:      frontMatter.PublishAt,
+		WordCount:      words,
+		ReadingMinutes: readingMinutes(words),
+		TOC:            toc,
+		Document:       document,
+		Language:       frontMatter.Language,
+		FrontMatter:    frontMatter.Issues,
 	}, nil
 }
 
@@ -153,62 +319,3 @@ func extractPostTitle(markdown []byte) string {
 
 	return strings.TrimSpace(title)
 }
-
-func extractSnippet(markdown []byte) string {
-	lines := strings.Split(string(markdown), "\n")
-	var paragraphLines []string
-
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-
-		// Skip headings before we find content
-		if strings.HasPrefix(trimmed, "#") {
-			if len(paragraphLines) > 0 {
-				break
-			}
-			continue
-		}
-
-		// Empty line handling
-		if trimmed == "" {
-			if len(paragraphLines) > 0 {
-				break // End of first paragraph
-			}
-			continue
-		}
-
-		// Stop at code blocks, horizontal rules, lists, tables
-		if strings.HasPrefix(trimmed, "```") ||
-			strings.HasPrefix(trimmed, "---") ||
-			strings.HasPrefix(trimmed, "***") ||
-			strings.HasPrefix(trimmed, "- ") ||
-			strings.HasPrefix(trimmed, "* ") ||
-			strings.HasPrefix(trimmed, "+ ") ||
-			strings.HasPrefix(trimmed, "|") {
-			if len(paragraphLines) > 0 {
-				break
-			}
-			continue
-		}
-
-		// Collect paragraph content
-		paragraphLines = append(paragraphLines, trimmed)
-	}
-
-	if len(paragraphLines) == 0 {
-		return ""
-	}
-
-	snippet := strings.Join(paragraphLines, " ")
-
-	// Truncate if too long
-	if len(snippet) > maxLength {
-		snippet = snippet[:maxLength]
-		if lastSpace := strings.LastIndexAny(snippet, " \t"); lastSpace > 0 {
-			snippet = snippet[:lastSpace]
-		}
-		snippet += "..."
-	}
-
-	return snippet
-}
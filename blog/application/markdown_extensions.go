@@ -0,0 +1,78 @@
+package application
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// MarkdownConfig enables markdown extensions beyond GFM
+// Changing it only affects posts rendered afterwards; a resync re-renders the rest.
+type MarkdownConfig struct {
+	// Footnotes renders [^label] references with their definitions collected at the end of the post
+	Footnotes bool
+	// DefinitionLists renders a term followed by ": definition" lines as a <dl>
+	DefinitionLists bool
+	// Typographer replaces straight quotes, "--", "---" and "..." with their typographic forms
+	Typographer bool
+	// Math passes $inline$ and $$display$$ TeX through to the page for KaTeX or MathJax to typeset
+	Math bool
+	// Sanitize cleans the rendered HTML, for blogs that accept posts from authors who are not fully trusted
+	Sanitize SanitizeMode
+	// Document stores the structure of each rendered post, served as JSON to frontends that don't use its HTML
+	Document bool
+	// SnippetLength is how many characters of a post's first paragraph its snippet keeps, or 0 for the default
+	SnippetLength int
+}
+
+func NewMarkdownConfig() *MarkdownConfig {
+	snippetLength := defaultSnippetLength
+	if n, err := strconv.Atoi(os.Getenv("MARKDOWN_SNIPPET_LENGTH")); err == nil && n > 0 {
+		snippetLength = n
+	}
+
+	return &MarkdownConfig{
+		Footnotes:       os.Getenv("MARKDOWN_FOOTNOTES") == "true",
+		DefinitionLists: os.Getenv("MARKDOWN_DEFINITION_LISTS") == "true",
+		Typographer:     os.Getenv("MARKDOWN_TYPOGRAPHER") == "true",
+		Math:            os.Getenv("MARKDOWN_MATH") == "true",
+		Sanitize:        parseSanitizeMode(os.Getenv("MARKDOWN_SANITIZE")),
+		Document:        os.Getenv("MARKDOWN_DOCUMENT") == "true",
+		SnippetLength:   snippetLength,
+	}
+}
+
+// WithMarkdownExtensions enables the extensions selected in cfg
+func WithMarkdownExtensions(cfg *MarkdownConfig) MarkdownOption {
+	return func(o *markdownOptions) {
+		o.extensions = cfg
+	}
+}
+
+// extenders returns the goldmark extensions the config enables
+func (c *MarkdownConfig) extenders() []goldmark.Extender {
+	var extenders []goldmark.Extender
+	if c.Footnotes {
+		extenders = append(extenders, extension.Footnote)
+	}
+	if c.DefinitionLists {
+		extenders = append(extenders, extension.DefinitionList)
+	}
+	if c.Typographer {
+		extenders = append(extenders, extension.Typographer)
+	}
+	if c.Math {
+		extenders = append(extenders, mathExtension{})
+	}
+	return extenders
+}
+
+// snippetLength returns the configured snippet length, falling back to the default when it is unset
+func (c *MarkdownConfig) snippetLength() int {
+	if c.SnippetLength > 0 {
+		return c.SnippetLength
+	}
+	return defaultSnippetLength
+}
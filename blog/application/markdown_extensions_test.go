@@ -0,0 +1,131 @@
+package application
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMarkdownRendererImpl_Render_Extensions(t *testing.T) {
+	source := "# Title\n\nA claim[^1] -- \"quoted\"\n\nTerm\n: Definition\n\n[^1]: The source."
+
+	tests := []struct {
+		name    string
+		cfg     *MarkdownConfig
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "disabled by default",
+			cfg:     &MarkdownConfig{},
+			want:    []string{"[^1]", "&quot;quoted&quot;"},
+			notWant: []string{`class="footnotes"`, "<dl>", "&ndash;"},
+		},
+		{
+			name: "footnotes",
+			cfg:  &MarkdownConfig{Footnotes: true},
+			want: []string{`<sup id="fnref:1">`, `class="footnotes"`, "The source."},
+		},
+		{
+			name: "definition lists",
+			cfg:  &MarkdownConfig{DefinitionLists: true},
+			want: []string{"<dl>", "<dt>Term</dt>", "<dd>Definition</dd>"},
+		},
+		{
+			name: "typographer",
+			cfg:  &MarkdownConfig{Typographer: true},
+			want: []string{"&ndash;", "&ldquo;quoted&rdquo;"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewMarkdownRenderer(WithMarkdownExtensions(tt.cfg)).Render([]byte(source))
+			if err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+
+			html := string(result.HTMLContent)
+			for _, want := range tt.want {
+				if !strings.Contains(html, want) {
+					t.Errorf("HTML missing %q: %s", want, html)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(html, notWant) {
+					t.Errorf("HTML should not contain %q: %s", notWant, html)
+				}
+			}
+		})
+	}
+}
+
+func TestNewMarkdownConfig(t *testing.T) {
+	t.Setenv("MARKDOWN_FOOTNOTES", "true")
+	t.Setenv("MARKDOWN_DEFINITION_LISTS", "")
+	t.Setenv("MARKDOWN_TYPOGRAPHER", "true")
+
+	cfg := NewMarkdownConfig()
+	if !cfg.Footnotes || cfg.DefinitionLists || !cfg.Typographer {
+		t.Errorf("NewMarkdownConfig() = %+v", cfg)
+	}
+	if got := len(cfg.extenders()); got != 2 {
+		t.Errorf("extenders() returned %d extensions, want 2", got)
+	}
+}
+
+func TestMarkdownRendererImpl_Render_Math(t *testing.T) {
+	renderer := NewMarkdownRenderer(WithMarkdownExtensions(&MarkdownConfig{Math: true}))
+
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{
+			name:   "inline",
+			source: "Euler: $e^{i\\pi} + 1 < 2$ done",
+			want:   `<p>Euler: <span class="math inline">\(e^{i\pi} + 1 &lt; 2\)</span> done</p>`,
+		},
+		{
+			name:   "inline display",
+			source: "See $$\\sum_i x_i$$ here",
+			want:   `<span class="math display">\[\sum_i x_i\]</span>`,
+		},
+		{
+			name:   "prices stay text",
+			source: "It costs $5 and $10",
+			want:   "<p>It costs $5 and $10</p>",
+		},
+		{
+			name:   "block",
+			source: "Before\n\n$$\na_1 *b* c\n\\\\ d\n$$\n\nAfter",
+			want:   "<div class=\"math display\">\\[a_1 *b* c\n\\\\ d\n\\]</div>",
+		},
+		{
+			name:   "single line block",
+			source: "$$ x^2 $$",
+			want:   `<div class="math display">\[ x^2 \]</div>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := renderer.Render([]byte(tt.source))
+			if err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+			if html := string(result.HTMLContent); !strings.Contains(html, tt.want) {
+				t.Errorf("HTML missing %q: %s", tt.want, html)
+			}
+		})
+	}
+}
+
+func TestHasMath(t *testing.T) {
+	if !HasMath([]byte(`<p><span class="math inline">\(x\)</span></p>`)) {
+		t.Error("HasMath() = false for rendered math")
+	}
+	if HasMath([]byte("<p>$x$</p>")) {
+		t.Error("HasMath() = true for plain text")
+	}
+}
@@ -1,6 +1,7 @@
 package application
 
 import (
+	"slices"
 	"strings"
 	"testing"
 )
@@ -58,7 +59,7 @@ func TestExtractPostTitle(t *testing.T) {
 	}
 }
 
-func TestExtractSnippet(t *testing.T) {
+func TestSnippet(t *testing.T) {
 	tests := []struct {
 		name     string
 		markdown []byte
@@ -94,14 +95,19 @@ func TestExtractSnippet(t *testing.T) {
 			markdown: []byte("# Title\nIntro text\n- List item"),
 			expected: "Intro text",
 		},
+		{
+			name:     "Skip leading list and code",
+			markdown: []byte("# Title\n- List item\n\n```\ncode\n```\n\nFirst paragraph"),
+			expected: "First paragraph",
+		},
 		{
 			name:     "Stop at horizontal rule",
-			markdown: []byte("# Title\nContent before rule\n---\nAfter"),
+			markdown: []byte("# Title\nContent before rule\n\n---\nAfter"),
 			expected: "Content before rule",
 		},
 		{
 			name:     "Stop at table",
-			markdown: []byte("# Title\nIntro\n| Col1 | Col2 |"),
+			markdown: []byte("# Title\nIntro\n\n| Col1 | Col2 |\n| --- | --- |\n| a | b |"),
 			expected: "Intro",
 		},
 		{
@@ -126,21 +132,61 @@ func TestExtractSnippet(t *testing.T) {
 		},
 		{
 			name:     "Paragraph with inline formatting",
-			markdown: []byte("# Title\nThis has **bold** and *italic* text."),
-			expected: "This has **bold** and *italic* text.",
+			markdown: []byte("# Title\nThis has **bold**, *italic*, `code`, ~~struck~~ and [linked](https://example.com) text."),
+			expected: "This has bold, italic, code, struck and linked text.",
+		},
+		{
+			name:     "Escapes, entities and raw HTML",
+			markdown: []byte("# Title\nFish \\*&amp;\\* <span>chips</span> at <https://example.com>"),
+			expected: "Fish *&* chips at https://example.com",
+		},
+		{
+			name:     "Image-only paragraph is skipped",
+			markdown: []byte("# Title\n![A cat](images/cat.png)\n\nThe cat sat."),
+			expected: "The cat sat.",
+		},
+		{
+			name:     "More marker",
+			markdown: []byte("# Title\nFirst **paragraph**.\n\n- A list\n\nSecond paragraph.\n<!--more-->\nThe rest"),
+			expected: "First paragraph. A list Second paragraph.",
+		},
+		{
+			name:     "More marker with spaces is not truncated",
+			markdown: []byte("# Title\n" + strings.Repeat("word ", 60) + "\n\n<!-- more -->\n\nThe rest"),
+			expected: strings.TrimSpace(strings.Repeat("word ", 60)),
+		},
+		{
+			name:     "More marker with nothing before it",
+			markdown: []byte("# Title\n<!--more-->\n\nFirst paragraph"),
+			expected: "First paragraph",
 		},
 	}
 
+	renderer := NewMarkdownRenderer()
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := extractSnippet(tt.markdown)
-			if result != tt.expected {
-				t.Errorf("extractSnippet() = %q, want %q", result, tt.expected)
+			result, err := renderer.Render(tt.markdown)
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if result.Snippet != tt.expected {
+				t.Errorf("Snippet = %q, want %q", result.Snippet, tt.expected)
 			}
 		})
 	}
 }
 
+func TestSnippet_Length(t *testing.T) {
+	renderer := NewMarkdownRenderer(WithMarkdownExtensions(&MarkdownConfig{SnippetLength: 12}))
+	result, err := renderer.Render([]byte("# Title\nCafé crème brûlée is lovely"))
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if want := "Café crème..."; result.Snippet != want {
+		t.Errorf("Snippet = %q, want %q", result.Snippet, want)
+	}
+}
+
 func TestMarkdownRendererImpl_Render(t *testing.T) {
 	renderer := NewMarkdownRenderer()
 
@@ -553,3 +599,188 @@ Snippet
 		})
 	}
 }
+
+func TestMarkdownRendererImpl_Render_ImageReferences(t *testing.T) {
+	renderer := NewMarkdownRenderer()
+
+	markdown := []byte(`# Test
+Intro
+
+![One](../images/one.png)
+![Two](two.jpg)
+![One again](./one.png)
+![Remote](https://example.com/remote.jpg)`)
+
+	result, err := renderer.Render(markdown)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	expected := []string{"images/one.png", "images/two.jpg"}
+	if len(result.Images) != len(expected) {
+		t.Fatalf("Images = %v, want %v", result.Images, expected)
+	}
+	for i, img := range expected {
+		if result.Images[i] != img {
+			t.Errorf("Images[%d] = %q, want %q", i, result.Images[i], img)
+		}
+	}
+
+	// References must not leak between renders
+	result, err = renderer.Render([]byte("# No images\nJust text"))
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if len(result.Images) != 0 {
+		t.Errorf("Images = %v, want none", result.Images)
+	}
+}
+
+func TestMarkdownRendererImpl_Render_ImageHashURLs(t *testing.T) {
+	hashes := map[string]string{"images/known.PNG": "abc123"}
+	renderer := NewMarkdownRenderer(WithImageResolver(func(imagePath string) (string, bool) {
+		hash, ok := hashes[imagePath]
+		return hash, ok
+	}))
+
+	result, err := renderer.Render([]byte("# Test\n\n![Known](../images/known.PNG)\n![Unknown](unknown.jpg)"))
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	html := string(result.HTMLContent)
+	for _, expected := range []string{
+		`src="https://blog.werewolves.fyi/images/abc123.png"`,
+		`src="https://blog.werewolves.fyi/images/unknown.jpg"`,
+	} {
+		if !strings.Contains(html, expected) {
+			t.Errorf("HTML does not contain expected string %q", expected)
+		}
+	}
+}
+
+func TestMarkdownRendererImpl_Render_ImageBaseURL(t *testing.T) {
+	hashes := map[string]string{"images/2024/known.png": "abc123def4567890"}
+	resolver := WithImageResolver(func(imagePath string) (string, bool) {
+		hash, ok := hashes[imagePath]
+		return hash, ok
+	})
+	source := []byte("# Test\n\n![Known](../images/2024/known.png)\n![Unknown](unknown.jpg)")
+
+	tests := []struct {
+		name string
+		cfg  *ImageURLConfig
+		want string
+	}{
+		{
+			name: "hash",
+			cfg:  &ImageURLConfig{BaseURL: "https://cdn.example.com", Style: ImageURLByHash},
+			want: `src="https://cdn.example.com/images/abc123def4567890.png"`,
+		},
+		{
+			name: "path",
+			cfg:  &ImageURLConfig{BaseURL: "https://bucket.example.com", Style: ImageURLByPath},
+			want: `src="https://bucket.example.com/images/2024/known.png?v=abc123def456"`,
+		},
+		{
+			name: "unset",
+			cfg:  &ImageURLConfig{},
+			want: `src="https://blog.werewolves.fyi/images/abc123def4567890.png"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewMarkdownRenderer(resolver, WithImageURLs(tt.cfg)).Render(source)
+			if err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+
+			html := string(result.HTMLContent)
+			// Images that are not stored yet are linked on the blog, which redirects once they are
+			for _, expected := range []string{tt.want, `src="https://blog.werewolves.fyi/images/unknown.jpg"`} {
+				if !strings.Contains(html, expected) {
+					t.Errorf("HTML does not contain expected string %q: %s", expected, html)
+				}
+			}
+		})
+	}
+}
+
+func TestMarkdownRendererImpl_Render_ImageSubdirectories(t *testing.T) {
+	renderer := NewMarkdownRenderer()
+
+	result, err := renderer.Render([]byte("# Test\n\n![A](../images/2024/photo.jpg)\n![B](../images/2025/photo.jpg)\n![C](../../images/x.png)"))
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	expected := []string{"images/2024/photo.jpg", "images/2025/photo.jpg", "images/x.png"}
+	if !slices.Equal(result.Images, expected) {
+		t.Errorf("Images = %v, want %v", result.Images, expected)
+	}
+	if html := string(result.HTMLContent); !strings.Contains(html, `src="https://blog.werewolves.fyi/images/2025/photo.jpg"`) {
+		t.Errorf("HTML does not keep the image folder: %s", html)
+	}
+}
+
+func TestMarkdownRendererImpl_Render_ReadingTime(t *testing.T) {
+	renderer := NewMarkdownRenderer()
+
+	body := strings.Repeat("word ", 250)
+	source := "# Two Words\n\n" + body + "\n\n```go\nfunc skipped() { return }\n```\n"
+	result, err := renderer.Render([]byte(source))
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	if result.WordCount != 252 {
+		t.Errorf("WordCount = %d, want 252", result.WordCount)
+	}
+	if result.ReadingMinutes != 2 {
+		t.Errorf("ReadingMinutes = %d, want 2", result.ReadingMinutes)
+	}
+
+	result, err = renderer.Render([]byte(""))
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if result.WordCount != 0 || result.ReadingMinutes != 0 {
+		t.Errorf("Expected an empty post to take no time, got %d words in %d minutes", result.WordCount, result.ReadingMinutes)
+	}
+}
+
+func TestMarkdownRendererImpl_Render_TOC(t *testing.T) {
+	renderer := NewMarkdownRenderer()
+
+	source := "# Title\n\n## Setup\n\n### Install *it*\n\n### Configure\n\n## Usage\n\nBody"
+	result, err := renderer.Render([]byte(source))
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	if len(result.TOC) != 2 {
+		t.Fatalf("Expected 2 top-level headings, got %d", len(result.TOC))
+	}
+	setup := result.TOC[0]
+	if setup.ID != "setup" || setup.Text != "Setup" || setup.Level != 2 {
+		t.Errorf("Unexpected first heading: %+v", setup)
+	}
+	if len(setup.Children) != 2 {
+		t.Fatalf("Expected 2 headings under Setup, got %d", len(setup.Children))
+	}
+	if install := setup.Children[0]; install.ID != "install-it" || install.Text != "Install it" {
+		t.Errorf("Unexpected nested heading: %+v", install)
+	}
+	if !strings.Contains(string(result.HTMLContent), `id="install-it"`) {
+		t.Errorf("TOC ID does not match the rendered heading: %s", result.HTMLContent)
+	}
+
+	result, err = renderer.Render([]byte("# Title\n\n## One\n\n## Two\n\nBody"))
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if result.TOC != nil {
+		t.Errorf("Expected no TOC for a short post, got %+v", result.TOC)
+	}
+}
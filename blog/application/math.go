@@ -0,0 +1,219 @@
+package application
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+var (
+	kindMath      = ast.NewNodeKind("Math")
+	kindMathBlock = ast.NewNodeKind("MathBlock")
+)
+
+// mathDelimiter opens and closes display math, on its own lines or inline
+var mathDelimiter = []byte("$$")
+
+// mathMarker is present in rendered HTML that contains math
+var mathMarker = []byte(`class="math `)
+
+// mathNode is $inline$ or $$display$$ math inside a paragraph
+type mathNode struct {
+	ast.BaseInline
+	display bool
+	value   text.Segment
+}
+
+func (n *mathNode) Kind() ast.NodeKind { return kindMath }
+
+func (n *mathNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Value": string(n.value.Value(source))}, nil)
+}
+
+// mathBlock is display math written between lines starting and ending with $$
+type mathBlock struct {
+	ast.BaseBlock
+	// closed is set once the closing $$ has been read
+	closed bool
+}
+
+func (n *mathBlock) Kind() ast.NodeKind { return kindMathBlock }
+
+func (n *mathBlock) IsRaw() bool { return true }
+
+func (n *mathBlock) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, nil, nil)
+}
+
+// mathExtension passes TeX through untouched, wrapped in the \(...\) and \[...\] delimiters
+// that KaTeX's auto-render and MathJax look for, so no TeX is interpreted on the server.
+type mathExtension struct{}
+
+func (mathExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithBlockParsers(util.Prioritized(mathBlockParser{}, 750)),
+		parser.WithInlineParsers(util.Prioritized(mathInlineParser{}, 500)),
+	)
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(util.Prioritized(mathRenderer{}, 500)))
+}
+
+// HasMath reports whether rendered post HTML contains math, so pages only load a math library when needed
+func HasMath(html []byte) bool {
+	return bytes.Contains(html, mathMarker)
+}
+
+type mathInlineParser struct{}
+
+func (mathInlineParser) Trigger() []byte {
+	return []byte{'$'}
+}
+
+// Parse reads math on a single line, following pandoc's rules for $ so that prices like "$5 and $10" stay text:
+// the opening $ must not be followed by a space, and the closing $ must not follow a space or precede a digit
+func (mathInlineParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	line, segment := block.PeekLine()
+
+	opener := 0
+	for opener < len(line) && line[opener] == '$' {
+		opener++
+	}
+	if opener > 2 || opener >= len(line) || util.IsSpace(line[opener]) {
+		return nil
+	}
+
+	for i := opener; i < len(line); i++ {
+		if line[i] == '\\' {
+			i++
+			continue
+		}
+		if line[i] != '$' {
+			continue
+		}
+
+		closer := i
+		for closer < len(line) && line[closer] == '$' {
+			closer++
+		}
+		if closer-i != opener {
+			i = closer - 1
+			continue
+		}
+		if util.IsSpace(line[i-1]) || (closer < len(line) && line[closer] >= '0' && line[closer] <= '9') {
+			i = closer - 1
+			continue
+		}
+
+		block.Advance(closer)
+		return &mathNode{
+			display: opener == 2,
+			value:   text.NewSegment(segment.Start+opener, segment.Start+i),
+		}
+	}
+
+	return nil
+}
+
+type mathBlockParser struct{}
+
+func (mathBlockParser) Trigger() []byte {
+	return []byte{'$'}
+}
+
+func (mathBlockParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, segment := reader.PeekLine()
+	pos := pc.BlockOffset()
+	if pos < 0 || !bytes.HasPrefix(line[pos:], mathDelimiter) {
+		return nil, parser.NoChildren
+	}
+
+	node := &mathBlock{}
+	start := segment.Start + pos + len(mathDelimiter)
+	rest := util.TrimRightSpace(line[pos+len(mathDelimiter):])
+	// $$...$$ on one line is a complete block
+	if len(rest) >= len(mathDelimiter) && bytes.HasSuffix(rest, mathDelimiter) {
+		node.Lines().Append(text.NewSegment(start, start+len(rest)-len(mathDelimiter)))
+		node.closed = true
+	} else if !util.IsBlank(rest) {
+		node.Lines().Append(text.NewSegment(start, segment.Stop))
+	}
+
+	reader.AdvanceToEOL()
+	return node, parser.NoChildren
+}
+
+func (mathBlockParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	block := node.(*mathBlock)
+	if block.closed {
+		return parser.Close
+	}
+
+	line, segment := reader.PeekLine()
+	trimmed := util.TrimRightSpace(line)
+	if bytes.HasSuffix(trimmed, mathDelimiter) {
+		if content := trimmed[:len(trimmed)-len(mathDelimiter)]; !util.IsBlank(content) {
+			node.Lines().Append(text.NewSegment(segment.Start, segment.Start+len(content)))
+		}
+		block.closed = true
+		reader.AdvanceToEOL()
+		return parser.Close
+	}
+
+	node.Lines().Append(segment)
+	reader.AdvanceToEOL()
+	return parser.Continue | parser.NoChildren
+}
+
+func (mathBlockParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {}
+
+func (mathBlockParser) CanInterruptParagraph() bool {
+	return true
+}
+
+func (mathBlockParser) CanAcceptIndentedLine() bool {
+	return false
+}
+
+type mathRenderer struct{}
+
+func (mathRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindMath, renderMath)
+	reg.Register(kindMathBlock, renderMathBlock)
+}
+
+func renderMath(w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	node := n.(*mathNode)
+	if node.display {
+		_, _ = w.WriteString(`<span class="math display">\[`)
+		_, _ = w.Write(util.EscapeHTML(node.value.Value(source)))
+		_, _ = w.WriteString(`\]</span>`)
+	} else {
+		_, _ = w.WriteString(`<span class="math inline">\(`)
+		_, _ = w.Write(util.EscapeHTML(node.value.Value(source)))
+		_, _ = w.WriteString(`\)</span>`)
+	}
+	return ast.WalkSkipChildren, nil
+}
+
+func renderMathBlock(w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	_, _ = w.WriteString(`<div class="math display">\[`)
+	lines := n.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		line := lines.At(i)
+		_, _ = w.Write(util.EscapeHTML(line.Value(source)))
+	}
+	_, _ = w.WriteString("\\]</div>\n")
+	return ast.WalkSkipChildren, nil
+}
@@ -0,0 +1,89 @@
+package application
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+var kindMermaid = ast.NewNodeKind("Mermaid")
+
+// mermaidMarker opens every rendered diagram
+var mermaidMarker = []byte(`<pre class="mermaid">`)
+
+// mermaidBlock is the source of a diagram from a ```mermaid fenced block
+type mermaidBlock struct {
+	ast.BaseBlock
+}
+
+func (n *mermaidBlock) Kind() ast.NodeKind { return kindMermaid }
+
+func (n *mermaidBlock) IsRaw() bool { return true }
+
+func (n *mermaidBlock) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, nil, nil)
+}
+
+// HasMermaid reports whether rendered post HTML contains diagrams, so pages only load Mermaid when needed
+func HasMermaid(html []byte) bool {
+	return bytes.Contains(html, mermaidMarker)
+}
+
+// mermaidExtension renders ```mermaid fenced blocks as <pre class="mermaid">, which Mermaid turns into
+// diagrams in the browser; without it the diagram source is still readable as preformatted text
+type mermaidExtension struct{}
+
+func (mermaidExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithASTTransformers(util.Prioritized(mermaidTransformer{}, 400)))
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(util.Prioritized(mermaidRenderer{}, 500)))
+}
+
+type mermaidTransformer struct{}
+
+func (mermaidTransformer) Transform(node *ast.Document, reader text.Reader, pc parser.Context) {
+	source := reader.Source()
+
+	var blocks []*ast.FencedCodeBlock
+	_ = ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		if code, ok := n.(*ast.FencedCodeBlock); ok && string(code.Language(source)) == "mermaid" {
+			blocks = append(blocks, code)
+		}
+		return ast.WalkContinue, nil
+	})
+
+	// Replace after walking, so the walk never visits a detached node
+	for _, code := range blocks {
+		diagram := &mermaidBlock{}
+		diagram.SetLines(code.Lines())
+		code.Parent().ReplaceChild(code.Parent(), code, diagram)
+	}
+}
+
+type mermaidRenderer struct{}
+
+func (mermaidRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindMermaid, renderMermaid)
+}
+
+func renderMermaid(w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	_, _ = w.Write(mermaidMarker)
+	lines := n.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		line := lines.At(i)
+		_, _ = w.Write(util.EscapeHTML(line.Value(source)))
+	}
+	_, _ = w.WriteString("</pre>\n")
+	return ast.WalkSkipChildren, nil
+}
@@ -0,0 +1,30 @@
+package application
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMarkdownRendererImpl_Render_Mermaid(t *testing.T) {
+	renderer := NewMarkdownRenderer()
+
+	source := "# Diagram\n\n```mermaid\ngraph TD\n  A --> B & C\n```\n\n```go\nfunc main() {}\n```\n"
+	result, err := renderer.Render([]byte(source))
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	html := string(result.HTMLContent)
+	if !strings.Contains(html, "<pre class=\"mermaid\">graph TD\n  A --&gt; B &amp; C\n</pre>") {
+		t.Errorf("mermaid block not rendered for Mermaid: %s", html)
+	}
+	if !strings.Contains(html, `<pre><code class="language-go">func main() {}`) {
+		t.Errorf("other code blocks should render as code: %s", html)
+	}
+	if !HasMermaid(result.HTMLContent) {
+		t.Error("HasMermaid() = false for a post with a diagram")
+	}
+	if HasMermaid([]byte(`<pre><code class="language-go">x</code></pre>`)) {
+		t.Error("HasMermaid() = true for a post without diagrams")
+	}
+}
@@ -0,0 +1,58 @@
+package application
+
+import (
+	"html"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+)
+
+// imgSrcRegex finds the source of an image in rendered post HTML
+var imgSrcRegex = regexp.MustCompile(`<img[^>]*\ssrc="([^"]+)"`)
+
+type MetadataConfig struct {
+	// DefaultImage is shared for posts without images; a path is resolved against the site URL
+	DefaultImage string
+}
+
+func NewMetadataConfig() *MetadataConfig {
+	return &MetadataConfig{
+		DefaultImage: os.Getenv("OG_DEFAULT_IMAGE"),
+	}
+}
+
+// WithMetadata sets how link preview metadata is built for posts
+func WithMetadata(cfg *MetadataConfig) PostServiceOption {
+	return func(s *PostService) {
+		s.metadata = cfg
+	}
+}
+
+// PostMetadata returns the link preview metadata of a post served under baseURL
+// The image is the first one in the post, since the rendered HTML already links it by its current hash.
+func (s *PostService) PostMetadata(post *domain.Post, baseURL string) domain.PostMetadata {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	meta := domain.PostMetadata{
+		Title:       post.Title,
+		Description: post.Snippet,
+		URL:         baseURL + post.URLPath(),
+		TwitterCard: "summary",
+	}
+
+	if match := imgSrcRegex.FindSubmatch(post.HTMLContent); match != nil {
+		meta.Image = html.UnescapeString(string(match[1]))
+	} else if s.metadata != nil {
+		meta.Image = s.metadata.DefaultImage
+	}
+
+	if meta.Image != "" {
+		if strings.HasPrefix(meta.Image, "/") && !strings.HasPrefix(meta.Image, "//") {
+			meta.Image = baseURL + meta.Image
+		}
+		meta.TwitterCard = "summary_large_image"
+	}
+
+	return meta
+}
@@ -0,0 +1,52 @@
+package application
+
+import (
+	"testing"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+)
+
+func TestPostService_PostMetadata(t *testing.T) {
+	service := NewPostService(newFakePostRepository(), newFakeImageRepository(), newFakeSourceRepository(), NewMarkdownRenderer(), "main",
+		WithMetadata(&MetadataConfig{DefaultImage: "/static/card.png"}),
+	)
+	defer service.Close()
+
+	tests := []struct {
+		name      string
+		html      string
+		wantImage string
+		wantCard  string
+	}{
+		{"first image", `<p><img src="https://blog.example.com/images/a.png?x=1&amp;y=2" alt="a"><img src="/images/b.png"></p>`, "https://blog.example.com/images/a.png?x=1&y=2", "summary_large_image"},
+		{"relative image", `<p><img alt="b" src="/images/b.png"></p>`, "https://blog.example.com/images/b.png", "summary_large_image"},
+		{"default image", `<p>No images</p>`, "https://blog.example.com/static/card.png", "summary_large_image"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			post := &domain.Post{ID: "001", Title: "Title", Snippet: "Snippet", HTMLContent: []byte(tt.html)}
+			meta := service.PostMetadata(post, "https://blog.example.com/")
+
+			if meta.Title != "Title" || meta.Description != "Snippet" || meta.URL != "https://blog.example.com/posts/001" {
+				t.Errorf("Unexpected metadata: %+v", meta)
+			}
+			if meta.Image != tt.wantImage {
+				t.Errorf("Image = %q, want %q", meta.Image, tt.wantImage)
+			}
+			if meta.TwitterCard != tt.wantCard {
+				t.Errorf("TwitterCard = %q, want %q", meta.TwitterCard, tt.wantCard)
+			}
+		})
+	}
+
+	t.Run("no image", func(t *testing.T) {
+		bare := NewPostService(newFakePostRepository(), newFakeImageRepository(), newFakeSourceRepository(), NewMarkdownRenderer(), "main")
+		defer bare.Close()
+
+		meta := bare.PostMetadata(&domain.Post{ID: "002", HTMLContent: []byte("<p>Text</p>")}, "https://blog.example.com")
+		if meta.Image != "" || meta.TwitterCard != "summary" {
+			t.Errorf("Unexpected metadata: %+v", meta)
+		}
+	})
+}
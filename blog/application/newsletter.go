@@ -0,0 +1,277 @@
+package application
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	netmail "net/mail"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/mail"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// Paths of the links in newsletter emails, below the site's base URL
+	NewsletterConfirmPath     = "/api/subscribe/confirm"
+	NewsletterUnsubscribePath = "/api/unsubscribe"
+
+	// newsletterQueue is how many published posts may wait to be emailed before more are dropped
+	newsletterQueue = 100
+	// subscriptionExpiry is how long a confirmation link works
+	subscriptionExpiry = 7 * 24 * time.Hour
+	// confirmationResendInterval is how long asking to subscribe again doesn't send another confirmation email,
+	// so the endpoint can't be used to flood someone's inbox
+	confirmationResendInterval = 10 * time.Minute
+	newsletterSendTimeout      = 30 * time.Second
+)
+
+var (
+	// ErrNewsletterDisabled is returned for subscription requests when posts are not emailed
+	ErrNewsletterDisabled = errors.New("newsletter is not enabled")
+	// ErrInvalidEmail is returned for a subscription to something that isn't a single email address
+	ErrInvalidEmail = errors.New("invalid email address")
+	// ErrInvalidSubscriptionToken is returned for a confirmation or unsubscribe link that is unknown or has expired
+	ErrInvalidSubscriptionToken = errors.New("invalid or expired subscription link")
+)
+
+type NewsletterConfig struct {
+	// Enabled lets readers subscribe to be emailed new posts
+	Enabled bool
+	// BaseURL is where the blog is served, which links in the emails point to
+	BaseURL string
+	// Title names the blog in the emails
+	Title string
+}
+
+func NewNewsletterConfig() *NewsletterConfig {
+	baseURL := strings.TrimSuffix(os.Getenv("SITE_BASE_URL"), "/")
+	if baseURL == "" {
+		baseURL = blogURL
+	}
+	title := os.Getenv("SITE_TITLE")
+	if title == "" {
+		title = strings.TrimPrefix(strings.TrimPrefix(baseURL, "https://"), "http://")
+	}
+
+	return &NewsletterConfig{
+		Enabled: os.Getenv("NEWSLETTER") == "true",
+		BaseURL: baseURL,
+		Title:   title,
+	}
+}
+
+// WithNewsletter lets readers subscribe to new posts when cfg enables it, emailing each post through mailer
+// to the confirmed subscribers the first time it is published
+func WithNewsletter(newsletter domain.NewsletterRepository, mailer mail.Sender, cfg *NewsletterConfig) PostServiceOption {
+	return func(s *PostService) {
+		if !cfg.Enabled || mailer == nil {
+			return
+		}
+		s.newsletter = newsletter
+		s.newsletterMailer = mailer
+		s.newsletterConfig = cfg
+	}
+}
+
+// Subscribe emails a confirmation link to an address asking for new posts
+// To avoid revealing who is subscribed, an address that is already confirmed succeeds without an email,
+// as does asking again shortly after the last confirmation email was sent.
+func (s *PostService) Subscribe(ctx context.Context, email string) error {
+	if s.newsletter == nil {
+		return ErrNewsletterDisabled
+	}
+
+	addr, err := netmail.ParseAddress(email)
+	if err != nil || addr.Address != strings.TrimSpace(email) {
+		return fmt.Errorf("%w: %q", ErrInvalidEmail, email)
+	}
+	email = strings.ToLower(addr.Address)
+
+	now := s.clock.Now().UTC()
+	existing, err := s.newsletter.GetSubscriber(ctx, email)
+	switch {
+	case errors.Is(err, domain.ErrSubscriberNotFound):
+	case err != nil:
+		return err
+	case !existing.ConfirmedAt.IsZero():
+		log.Debug().Str("email", email).Msg("Already subscribed, not sending a confirmation")
+		return nil
+	case now.Sub(existing.SubscribedAt) < confirmationResendInterval:
+		log.Debug().Str("email", email).Msg("Confirmation sent recently, not sending another")
+		return nil
+	}
+
+	subscriber := &domain.Subscriber{Email: email, Token: rand.Text(), SubscribedAt: now}
+	if err := s.newsletter.SaveSubscriber(ctx, subscriber); err != nil {
+		return err
+	}
+
+	cfg := s.newsletterConfig
+	confirmURL := cfg.BaseURL + NewsletterConfirmPath + "?token=" + url.QueryEscape(subscriber.Token)
+	msg := &mail.Message{
+		To:      []string{email},
+		Subject: "Confirm your subscription to " + cfg.Title,
+		Body: fmt.Sprintf("Someone, hopefully you, asked to be emailed new posts from %s (%s).\n\n"+
+			"Confirm your subscription within %d days:\n%s\n\n"+
+			"If it wasn't you, ignore this email and you won't hear from us again.\n",
+			cfg.Title, cfg.BaseURL, int(subscriptionExpiry.Hours()/24), confirmURL),
+	}
+	if err := s.newsletterMailer.Send(ctx, msg); err != nil {
+		return fmt.Errorf("failed to send confirmation: %w", err)
+	}
+
+	log.Info().Str("email", email).Msg("Sent subscription confirmation")
+	return nil
+}
+
+// ConfirmSubscription confirms the subscription of the address a confirmation link was sent to
+// Following the link again succeeds; ErrInvalidSubscriptionToken is returned once it has expired.
+func (s *PostService) ConfirmSubscription(ctx context.Context, token string) error {
+	if s.newsletter == nil {
+		return ErrNewsletterDisabled
+	}
+
+	subscriber, err := s.subscriberByToken(ctx, token)
+	if err != nil {
+		return err
+	}
+	if !subscriber.ConfirmedAt.IsZero() {
+		return nil
+	}
+
+	now := s.clock.Now().UTC()
+	if now.Sub(subscriber.SubscribedAt) > subscriptionExpiry {
+		return fmt.Errorf("%w: confirmation link has expired", ErrInvalidSubscriptionToken)
+	}
+	if err := s.newsletter.ConfirmSubscriber(ctx, subscriber.Email, now); err != nil {
+		return err
+	}
+
+	log.Info().Str("email", subscriber.Email).Msg("Subscription confirmed")
+	return nil
+}
+
+// Unsubscribe removes the subscriber an unsubscribe link was sent to
+func (s *PostService) Unsubscribe(ctx context.Context, token string) error {
+	if s.newsletter == nil {
+		return ErrNewsletterDisabled
+	}
+
+	subscriber, err := s.subscriberByToken(ctx, token)
+	if err != nil {
+		return err
+	}
+	if err := s.newsletter.DeleteSubscriber(ctx, subscriber.Email); err != nil {
+		return err
+	}
+
+	log.Info().Str("email", subscriber.Email).Msg("Unsubscribed")
+	return nil
+}
+
+func (s *PostService) subscriberByToken(ctx context.Context, token string) (*domain.Subscriber, error) {
+	if token == "" {
+		return nil, ErrInvalidSubscriptionToken
+	}
+	subscriber, err := s.newsletter.GetSubscriberByToken(ctx, token)
+	if errors.Is(err, domain.ErrSubscriberNotFound) {
+		return nil, ErrInvalidSubscriptionToken
+	}
+	return subscriber, err
+}
+
+// startNewsletterSender emails queued posts one at a time until the service is closed
+// Posts still waiting when it closes are not emailed.
+func (s *PostService) startNewsletterSender() {
+	s.newsletterCh = make(chan *domain.Post, newsletterQueue)
+	s.wg.Go(func() {
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case post := <-s.newsletterCh:
+				s.mailPost(post)
+			}
+		}
+	})
+}
+
+// queuePostMail queues a post to be emailed to subscribers, unless it has been emailed before
+// Republishing a post, such as when it is edited, doesn't email it again. Failures are logged, since the post
+// is published either way.
+func (s *PostService) queuePostMail(ctx context.Context, postID string) {
+	post, err := s.repo.GetPost(ctx, postID)
+	if err != nil {
+		log.Error().Err(err).Str("postID", postID).Msg("Failed to load published post for the newsletter")
+		return
+	}
+
+	first, err := s.newsletter.MarkMailed(ctx, postID, s.clock.Now().UTC())
+	if err != nil {
+		log.Error().Err(err).Str("postID", postID).Msg("Failed to record mailed post")
+		return
+	}
+	if !first {
+		return
+	}
+
+	select {
+	case s.newsletterCh <- post:
+	default:
+		log.Warn().Str("postID", postID).Msg("Newsletter queue is full, not emailing post")
+	}
+}
+
+// mailPost emails a post to every confirmed subscriber, each with their own unsubscribe link
+func (s *PostService) mailPost(post *domain.Post) {
+	subscribers, err := s.newsletter.ListConfirmedSubscribers(s.ctx)
+	if err != nil {
+		log.Error().Err(err).Str("postID", post.ID).Msg("Failed to list subscribers")
+		return
+	}
+
+	sent := 0
+	for _, subscriber := range subscribers {
+		ctx, cancel := context.WithTimeout(s.ctx, newsletterSendTimeout)
+		err := s.newsletterMailer.Send(ctx, s.postMail(post, subscriber))
+		cancel()
+		if err != nil {
+			log.Warn().Err(err).Str("postID", post.ID).Str("email", subscriber.Email).Msg("Failed to email post")
+			continue
+		}
+		sent++
+	}
+
+	log.Info().Str("postID", post.ID).Int("sent", sent).Int("subscribers", len(subscribers)).Msg("Emailed post to subscribers")
+}
+
+// postMail is the email announcing a post to a subscriber, with the post's snippet and a link to it
+func (s *PostService) postMail(post *domain.Post, subscriber *domain.Subscriber) *mail.Message {
+	cfg := s.newsletterConfig
+	unsubscribeURL := cfg.BaseURL + NewsletterUnsubscribePath + "?token=" + url.QueryEscape(subscriber.Token)
+
+	var body strings.Builder
+	body.WriteString(post.Title + "\n\n")
+	if post.Snippet != "" {
+		body.WriteString(post.Snippet + "\n\n")
+	}
+	fmt.Fprintf(&body, "Read it at %s\n\n", cfg.BaseURL+post.URLPath())
+	fmt.Fprintf(&body, "-- \nYou are receiving this because you subscribed to %s.\nUnsubscribe: %s\n", cfg.Title, unsubscribeURL)
+
+	return &mail.Message{
+		To:      []string{subscriber.Email},
+		Subject: cfg.Title + ": " + post.Title,
+		Body:    body.String(),
+		// One-click unsubscribe (RFC 8058) lets mail clients unsubscribe without opening the link
+		Headers: map[string]string{
+			"List-Unsubscribe":      "<" + unsubscribeURL + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		},
+	}
+}
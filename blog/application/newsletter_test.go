@@ -0,0 +1,159 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/clock"
+	"github.com/dfryer1193/goblog/shared/mail"
+)
+
+var linkTokenRegex = regexp.MustCompile(`token=(\S+)`)
+
+func newNewsletterTestService(t *testing.T, posts domain.PostRepository, newsletter *fakeNewsletterRepository, mailer *fakeMailer, c clock.Clock) *PostService {
+	t.Helper()
+	cfg := &NewsletterConfig{Enabled: true, BaseURL: "https://blog.example", Title: "The Blog"}
+	service := NewPostService(posts, newFakeImageRepository(), nil, NewMarkdownRenderer(), "main",
+		WithNewsletter(newsletter, mailer, cfg),
+		WithClock(c),
+	)
+	t.Cleanup(func() { service.Close() })
+	return service
+}
+
+// waitForMail waits until mailer has sent n messages and returns them
+func waitForMail(t *testing.T, mailer *fakeMailer, n int) []*mail.Message {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if sent := mailer.messages(); len(sent) >= n {
+			return sent
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("sent %d emails, want %d", len(mailer.messages()), n)
+	return nil
+}
+
+func TestPostService_SubscribeAndConfirm(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	newsletter := newFakeNewsletterRepository()
+	mailer := &fakeMailer{}
+	service := newNewsletterTestService(t, newFakePostRepository(), newsletter, mailer, clock.Func(func() time.Time { return now }))
+	ctx := context.Background()
+
+	if err := service.Subscribe(ctx, " Alex@Example.com"); err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	sent := mailer.messages()
+	if len(sent) != 1 || sent[0].To[0] != "alex@example.com" || !strings.Contains(sent[0].Body, "https://blog.example/api/subscribe/confirm?token=") {
+		t.Fatalf("Expected a confirmation link emailed to alex, got %+v", sent)
+	}
+	token := linkTokenRegex.FindStringSubmatch(sent[0].Body)[1]
+
+	// Asking again straight away doesn't send another email
+	if err := service.Subscribe(ctx, "alex@example.com"); err != nil {
+		t.Fatalf("Subscribe() again error = %v", err)
+	}
+	if len(mailer.messages()) != 1 {
+		t.Errorf("Expected no second confirmation, got %d emails", len(mailer.messages()))
+	}
+
+	if err := service.ConfirmSubscription(ctx, token); err != nil {
+		t.Fatalf("ConfirmSubscription() error = %v", err)
+	}
+	if err := service.ConfirmSubscription(ctx, token); err != nil {
+		t.Errorf("ConfirmSubscription() again error = %v, want it to succeed", err)
+	}
+	if alex, _ := newsletter.GetSubscriber(ctx, "alex@example.com"); alex.ConfirmedAt.IsZero() {
+		t.Error("Expected alex to be confirmed")
+	}
+
+	// A confirmed address isn't emailed, even much later
+	now = now.Add(24 * time.Hour)
+	if err := service.Subscribe(ctx, "alex@example.com"); err != nil || len(mailer.messages()) != 1 {
+		t.Errorf("Subscribe() when confirmed = %v with %d emails, want no new email", err, len(mailer.messages()))
+	}
+
+	for _, email := range []string{"Alex <alex@example.com>", "alex@example.com, sam@example.com", "not an address"} {
+		if err := service.Subscribe(ctx, email); !errors.Is(err, ErrInvalidEmail) {
+			t.Errorf("Subscribe(%q) error = %v, want ErrInvalidEmail", email, err)
+		}
+	}
+	if err := service.ConfirmSubscription(ctx, "unknown"); !errors.Is(err, ErrInvalidSubscriptionToken) {
+		t.Errorf("ConfirmSubscription() of an unknown token error = %v, want ErrInvalidSubscriptionToken", err)
+	}
+}
+
+func TestPostService_ConfirmSubscriptionExpires(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	newsletter := newFakeNewsletterRepository(&domain.Subscriber{Email: "sam@example.com", Token: "t1", SubscribedAt: now.Add(-8 * 24 * time.Hour)})
+	service := newNewsletterTestService(t, newFakePostRepository(), newsletter, &fakeMailer{}, clock.Fixed(now))
+
+	if err := service.ConfirmSubscription(context.Background(), "t1"); !errors.Is(err, ErrInvalidSubscriptionToken) {
+		t.Errorf("ConfirmSubscription() after a week error = %v, want ErrInvalidSubscriptionToken", err)
+	}
+}
+
+func TestPostService_NewsletterMailsPublishedPosts(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	newsletter := newFakeNewsletterRepository(
+		&domain.Subscriber{Email: "alex@example.com", Token: "t1", SubscribedAt: now, ConfirmedAt: now},
+		&domain.Subscriber{Email: "sam@example.com", Token: "t2", SubscribedAt: now},
+	)
+	posts := newFakePostRepository(&domain.Post{ID: "001", Slug: "first", Title: "First post", Snippet: "It begins"})
+	mailer := &fakeMailer{}
+	service := newNewsletterTestService(t, posts, newsletter, mailer, clock.Fixed(now))
+	ctx := context.Background()
+
+	if err := service.PublishPost(ctx, "001"); err != nil {
+		t.Fatalf("PublishPost() error = %v", err)
+	}
+	msg := waitForMail(t, mailer, 1)[0]
+	if msg.To[0] != "alex@example.com" || msg.Subject != "The Blog: First post" {
+		t.Errorf("Expected the post emailed to the confirmed subscriber, got %+v", msg)
+	}
+	for _, want := range []string{"It begins", "https://blog.example/posts/first", "https://blog.example/api/unsubscribe?token=t1"} {
+		if !strings.Contains(msg.Body, want) {
+			t.Errorf("Expected the email to contain %q, got:\n%s", want, msg.Body)
+		}
+	}
+	if msg.Headers["List-Unsubscribe"] != "<https://blog.example/api/unsubscribe?token=t1>" {
+		t.Errorf("List-Unsubscribe = %q, want the subscriber's unsubscribe link", msg.Headers["List-Unsubscribe"])
+	}
+
+	// Publishing an edit doesn't email the post again
+	if err := service.PublishPost(ctx, "001"); err != nil {
+		t.Fatalf("PublishPost() again error = %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if sent := mailer.messages(); len(sent) != 1 {
+		t.Errorf("Expected the post to be emailed once, got %d emails", len(sent))
+	}
+
+	if err := service.Unsubscribe(ctx, "t1"); err != nil {
+		t.Fatalf("Unsubscribe() error = %v", err)
+	}
+	if _, err := newsletter.GetSubscriber(ctx, "alex@example.com"); !errors.Is(err, domain.ErrSubscriberNotFound) {
+		t.Errorf("Expected alex to be unsubscribed, got %v", err)
+	}
+	if err := service.Unsubscribe(ctx, "t1"); !errors.Is(err, ErrInvalidSubscriptionToken) {
+		t.Errorf("Unsubscribe() again error = %v, want ErrInvalidSubscriptionToken", err)
+	}
+}
+
+func TestPostService_NewsletterDisabled(t *testing.T) {
+	service := NewPostService(newFakePostRepository(), newFakeImageRepository(), nil, NewMarkdownRenderer(), "main",
+		WithNewsletter(newFakeNewsletterRepository(), &fakeMailer{}, &NewsletterConfig{}),
+	)
+	defer service.Close()
+
+	if err := service.Subscribe(context.Background(), "alex@example.com"); !errors.Is(err, ErrNewsletterDisabled) {
+		t.Errorf("Subscribe() when disabled error = %v, want ErrNewsletterDisabled", err)
+	}
+}
@@ -0,0 +1,116 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dfryer1193/goblog/shared/notify"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// notificationQueue is how many notifications may wait to be sent before more are dropped
+	notificationQueue       = 100
+	notificationSendTimeout = 30 * time.Second
+)
+
+type NotificationConfig struct {
+	// BaseURL is where the blog is served, which publish notifications link to
+	BaseURL string
+}
+
+func NewNotificationConfig() *NotificationConfig {
+	baseURL := strings.TrimSuffix(os.Getenv("SITE_BASE_URL"), "/")
+	if baseURL == "" {
+		baseURL = blogURL
+	}
+
+	return &NotificationConfig{BaseURL: baseURL}
+}
+
+// notification is an event waiting to be sent
+type notification struct {
+	event notify.Event
+	text  string
+}
+
+// WithNotifications sends operational events through notifier: files that fail to process, and posts as they go live
+// Events notifier doesn't want are never queued; a nil notifier sends nothing.
+func WithNotifications(notifier notify.Notifier, cfg *NotificationConfig) PostServiceOption {
+	return func(s *PostService) {
+		if notifier == nil {
+			return
+		}
+		s.notifier = notifier
+		s.notificationConfig = cfg
+	}
+}
+
+// startNotificationSender sends queued notifications one at a time until the service is closed
+// Failures to send are logged and not retried; notifications still waiting when it closes are not sent.
+func (s *PostService) startNotificationSender() {
+	s.notificationCh = make(chan notification, notificationQueue)
+	s.wg.Go(func() {
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case n := <-s.notificationCh:
+				ctx, cancel := context.WithTimeout(s.ctx, notificationSendTimeout)
+				if err := s.notifier.Notify(ctx, n.event, n.text); err != nil {
+					log.Warn().Err(err).Str("event", string(n.event)).Msg("Failed to send notification")
+				}
+				cancel()
+			}
+		}
+	})
+}
+
+// notify queues a notification of event, unless nothing is configured to receive it
+// It never blocks, so a slow channel can't hold up a sync.
+func (s *PostService) notify(event notify.Event, text string) {
+	if s.notifier == nil || !s.notifier.Wants(event) {
+		return
+	}
+
+	select {
+	case s.notificationCh <- notification{event: event, text: text}:
+	default:
+		log.Warn().Str("event", string(event)).Msg("Notification queue is full, dropping notification")
+	}
+}
+
+// notifyPublished notifies operators of a post going live
+// A post is published again each time an edit to it is merged, so edits are notified too.
+func (s *PostService) notifyPublished(ctx context.Context, postID string) {
+	if !s.notifier.Wants(notify.EventPublish) {
+		return
+	}
+
+	post, err := s.repo.GetPost(ctx, postID)
+	if err != nil {
+		log.Error().Err(err).Str("postID", postID).Msg("Failed to load published post for notification")
+		return
+	}
+	s.notify(notify.EventPublish, fmt.Sprintf("Published %q: %s", post.Title, s.notificationConfig.BaseURL+post.URLPath()))
+}
+
+// notifyFailure notifies operators of a file that failed to process after retries
+// Only the first failure of each version of a file is notified when failures are dead-lettered, so a file
+// failing on every sync doesn't repeat the alert.
+func (s *PostService) notifyFailure(ctx context.Context, path string, ref string, fileErr error) {
+	if s.notifier == nil || !s.notifier.Wants(notify.EventFailure) {
+		return
+	}
+
+	if s.deadLetters != nil {
+		dl, err := s.deadLetters.GetDeadLetter(ctx, path)
+		if err == nil && dl.RefAttempts > 1 {
+			return
+		}
+	}
+	s.notify(notify.EventFailure, fmt.Sprintf("Failed to process %s at %s: %v", path, ref, fileErr))
+}
@@ -0,0 +1,100 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/notify"
+)
+
+// fakeNotifier records notifications for the events it wants
+type fakeNotifier struct {
+	mu     sync.Mutex
+	wants  map[notify.Event]bool
+	events []notification
+}
+
+func (n *fakeNotifier) Notify(ctx context.Context, event notify.Event, text string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.events = append(n.events, notification{event: event, text: text})
+	return nil
+}
+
+func (n *fakeNotifier) Wants(event notify.Event) bool {
+	return n.wants[event]
+}
+
+func (n *fakeNotifier) sent() []notification {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]notification(nil), n.events...)
+}
+
+// waitForNotifications waits until notifier has been sent n notifications and returns them
+func waitForNotifications(t *testing.T, notifier *fakeNotifier, n int) []notification {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if sent := notifier.sent(); len(sent) >= n {
+			return sent
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("sent %d notifications, want %d", len(notifier.sent()), n)
+	return nil
+}
+
+func TestPostService_NotifiesPublishes(t *testing.T) {
+	notifier := &fakeNotifier{wants: map[notify.Event]bool{notify.EventPublish: true}}
+	posts := newFakePostRepository(&domain.Post{ID: "001", Slug: "first", Title: "First post"})
+	service := NewPostService(posts, newFakeImageRepository(), nil, NewMarkdownRenderer(), "main",
+		WithNotifications(notifier, &NotificationConfig{BaseURL: "https://blog.example"}),
+	)
+	defer service.Close()
+
+	if err := service.PublishPost(t.Context(), "001"); err != nil {
+		t.Fatalf("PublishPost() error = %v", err)
+	}
+
+	sent := waitForNotifications(t, notifier, 1)
+	if sent[0].event != notify.EventPublish || sent[0].text != `Published "First post": https://blog.example/posts/first` {
+		t.Errorf("notification = %+v, want the post's title and link", sent[0])
+	}
+
+	// Failures aren't wanted, so they are never queued
+	service.recordFileResult("posts/002-broken.md", "a", errors.New("boom"))
+	time.Sleep(20 * time.Millisecond)
+	if sent := notifier.sent(); len(sent) != 1 {
+		t.Errorf("Expected only the publish to be notified, got %+v", sent)
+	}
+}
+
+func TestPostService_NotifiesFirstFailureOfEachVersion(t *testing.T) {
+	notifier := &fakeNotifier{wants: map[notify.Event]bool{notify.EventFailure: true}}
+	service := NewPostService(newFakePostRepository(), newFakeImageRepository(), nil, NewMarkdownRenderer(), "main",
+		WithDeadLetters(newFakeDeadLetterRepository()),
+		WithNotifications(notifier, &NotificationConfig{BaseURL: "https://blog.example"}),
+	)
+	defer service.Close()
+
+	service.recordFileResult("posts/002-broken.md", "a", errors.New("boom"))
+	service.recordFileResult("posts/002-broken.md", "a", errors.New("boom"))
+	sent := waitForNotifications(t, notifier, 1)
+	if sent[0].event != notify.EventFailure || !strings.Contains(sent[0].text, "posts/002-broken.md at a: boom") {
+		t.Errorf("notification = %+v, want the failed file and its error", sent[0])
+	}
+
+	// Another version failing is notified again
+	service.recordFileResult("posts/002-broken.md", "b", errors.New("still broken"))
+	waitForNotifications(t, notifier, 2)
+	time.Sleep(20 * time.Millisecond)
+	if sent := notifier.sent(); len(sent) != 2 || !strings.Contains(sent[1].text, "at b: still broken") {
+		t.Errorf("Expected one notification per failing version, got %+v", sent)
+	}
+}
@@ -0,0 +1,154 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/clock"
+	"github.com/dfryer1193/goblog/shared/mail"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+type OwnerDigestConfig struct {
+	// To is the owner's address; no digest is sent when it is empty
+	To string
+	// Frequency is DigestDaily or DigestWeekly
+	Frequency string
+}
+
+func NewOwnerDigestConfig() *OwnerDigestConfig {
+	frequency := DigestDaily
+	if os.Getenv("DIGEST_FREQUENCY") == DigestWeekly {
+		frequency = DigestWeekly
+	}
+
+	return &OwnerDigestConfig{
+		To:        os.Getenv("DIGEST_EMAIL"),
+		Frequency: frequency,
+	}
+}
+
+// Period is how often the digest is sent, and how far back each one looks
+func (c *OwnerDigestConfig) Period() time.Duration {
+	if c.Frequency == DigestWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// OwnerDigest emails the blog owner a summary of what needs their attention
+// For now that is the files that failed to process; a digest with nothing to report is not sent.
+type OwnerDigest struct {
+	deadLetters domain.DeadLetterRepository
+	mailer      mail.Sender
+	cfg         *OwnerDigestConfig
+	clock       clock.Clock
+}
+
+// OwnerDigestOption configures optional OwnerDigest behaviour
+type OwnerDigestOption func(*OwnerDigest)
+
+// WithOwnerDigestClock sets the clock used to decide which failures are new
+func WithOwnerDigestClock(c clock.Clock) OwnerDigestOption {
+	return func(d *OwnerDigest) {
+		d.clock = c
+	}
+}
+
+func NewOwnerDigest(deadLetters domain.DeadLetterRepository, mailer mail.Sender, cfg *OwnerDigestConfig, opts ...OwnerDigestOption) *OwnerDigest {
+	d := &OwnerDigest{
+		deadLetters: deadLetters,
+		mailer:      mailer,
+		cfg:         cfg,
+		clock:       clock.System,
+	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return d
+}
+
+// Run sends the digest for the period that just ended
+func (d *OwnerDigest) Run(ctx context.Context) error {
+	now := d.clock.Now()
+	msg, err := d.compose(ctx, now.Add(-d.cfg.Period()))
+	if err != nil {
+		return err
+	}
+	if msg == nil {
+		log.Debug().Msg("Nothing to report in owner digest")
+		return nil
+	}
+
+	if err := d.mailer.Send(ctx, msg); err != nil {
+		return fmt.Errorf("failed to send owner digest: %w", err)
+	}
+
+	log.Info().Str("to", d.cfg.To).Msg("Sent owner digest")
+	return nil
+}
+
+// compose builds the digest of failures since the given time, or returns nil when there are none
+func (d *OwnerDigest) compose(ctx context.Context, since time.Time) (*mail.Message, error) {
+	letters, err := d.deadLetters.ListDeadLetters(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+
+	var failed []*domain.DeadLetter
+	for _, letter := range letters {
+		if !letter.LastFailedAt.Before(since) {
+			failed = append(failed, letter)
+		}
+	}
+	if len(failed) == 0 {
+		return nil, nil
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "Files that failed to process since %s:\n", since.UTC().Format(time.RFC1123))
+	for _, letter := range failed {
+		fmt.Fprintf(&body, "\n%s at %s\n", letter.Path, shortRef(letter.Ref))
+		fmt.Fprintf(&body, "  %d attempts, last at %s", letter.Attempts, letter.LastFailedAt.UTC().Format(time.RFC1123))
+		if !letter.PoisonedAt.IsZero() {
+			body.WriteString(", quarantined")
+		}
+		fmt.Fprintf(&body, "\n  %s\n", letter.Error)
+	}
+	if older := len(letters) - len(failed); older > 0 {
+		fmt.Fprintf(&body, "\n%d more files have been failing for longer.\n", older)
+	}
+	body.WriteString("\nThe full list is at /admin/dead-letters.\n")
+
+	return &mail.Message{
+		To:      []string{d.cfg.To},
+		Subject: fmt.Sprintf("Blog %s digest: %s", d.cfg.Frequency, plural(len(failed), "processing failure")),
+		Body:    body.String(),
+	}, nil
+}
+
+// shortRef abbreviates a commit SHA the way git does, and leaves branch names alone
+func shortRef(ref string) string {
+	if len(ref) == 40 && strings.Trim(ref, "0123456789abcdef") == "" {
+		return ref[:7]
+	}
+	return ref
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
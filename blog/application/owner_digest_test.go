@@ -0,0 +1,81 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/clock"
+	"github.com/dfryer1193/goblog/shared/mail"
+)
+
+// fakeMailer records the messages it is asked to send
+type fakeMailer struct {
+	mu   sync.Mutex
+	sent []*mail.Message
+	err  error
+}
+
+func (m *fakeMailer) Send(ctx context.Context, msg *mail.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+// messages returns the messages sent so far
+func (m *fakeMailer) messages() []*mail.Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*mail.Message(nil), m.sent...)
+}
+
+func TestOwnerDigest_Run(t *testing.T) {
+	now := time.Date(2026, 1, 8, 0, 0, 0, 0, time.UTC)
+	letters := newFakeDeadLetterRepository(
+		&domain.DeadLetter{Path: "posts/001-new.md", Ref: "0123456789abcdef0123456789abcdef01234567", Error: "bad front matter", Attempts: 3, LastFailedAt: now.Add(-time.Hour), PoisonedAt: now.Add(-time.Hour)},
+		&domain.DeadLetter{Path: "images/old.png", Ref: "main", Error: "too large", Attempts: 1, LastFailedAt: now.Add(-10 * 24 * time.Hour)},
+	)
+	mailer := &fakeMailer{}
+	digest := NewOwnerDigest(letters, mailer, &OwnerDigestConfig{To: "owner@example.com", Frequency: DigestWeekly}, WithOwnerDigestClock(clock.Fixed(now)))
+
+	if err := digest.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(mailer.sent) != 1 {
+		t.Fatalf("Expected 1 digest to be sent, got %d", len(mailer.sent))
+	}
+
+	msg := mailer.sent[0]
+	if msg.To[0] != "owner@example.com" || msg.Subject != "Blog weekly digest: 1 processing failure" {
+		t.Errorf("Unexpected digest: to %v, subject %q", msg.To, msg.Subject)
+	}
+	for _, want := range []string{"posts/001-new.md at 0123456", "3 attempts", "quarantined", "bad front matter", "1 more files"} {
+		if !strings.Contains(msg.Body, want) {
+			t.Errorf("Expected the digest to contain %q, got:\n%s", want, msg.Body)
+		}
+	}
+	if strings.Contains(msg.Body, "images/old.png") {
+		t.Errorf("Expected failures from before the period to be left out, got:\n%s", msg.Body)
+	}
+}
+
+func TestOwnerDigest_NothingToReport(t *testing.T) {
+	now := time.Date(2026, 1, 8, 0, 0, 0, 0, time.UTC)
+	letters := newFakeDeadLetterRepository(
+		&domain.DeadLetter{Path: "images/old.png", LastFailedAt: now.Add(-2 * 24 * time.Hour)},
+	)
+	mailer := &fakeMailer{err: errors.New("should not send")}
+	digest := NewOwnerDigest(letters, mailer, &OwnerDigestConfig{To: "owner@example.com", Frequency: DigestDaily}, WithOwnerDigestClock(clock.Fixed(now)))
+
+	if err := digest.Run(context.Background()); err != nil {
+		t.Errorf("Run() error = %v, want nothing sent", err)
+	}
+}
@@ -0,0 +1,157 @@
+package application
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/rs/zerolog/log"
+)
+
+// WithPendingRenders records each render and removal before it starts and forgets it once it finishes,
+// so RecoverPendingRenders can run again the work a crash interrupted
+func WithPendingRenders(pendingRenders domain.PendingRenderRepository) PostServiceOption {
+	return func(s *PostService) {
+		s.pendingRenders = pendingRenders
+	}
+}
+
+// pendingRender describes the render of a post file version, so it can be run again
+func pendingRender(fileInfo commitFileInfo, commitSHA string, branch string) *domain.PendingRender {
+	return &domain.PendingRender{
+		Path:       fileInfo.path,
+		CommitSHA:  commitSHA,
+		Branch:     branch,
+		BlobSHA:    fileInfo.blobSHA,
+		CreatedAt:  fileInfo.createdAt,
+		ModifiedAt: fileInfo.modifiedAt,
+		Released:   fileInfo.released,
+	}
+}
+
+// pendingImageRender describes the ingestion of an image file version, so it can be run again
+func pendingImageRender(imagePath string, commitSHA string) *domain.PendingRender {
+	return &domain.PendingRender{Path: imagePath, CommitSHA: commitSHA}
+}
+
+// pendingRemoval describes the removal of a file, so it can be run again
+// Removing a post unpublishes it from the main branch and deletes the preview of any other branch;
+// images are removed with an empty branch.
+func pendingRemoval(path string, commitSHA string, branch string) *domain.PendingRender {
+	return &domain.PendingRender{Path: path, CommitSHA: commitSHA, Branch: branch, Action: domain.SyncActionRemove}
+}
+
+// savePendingRenders records the renders among tasks before any of them is queued
+// Tracking failures are logged rather than failing the push
+func (s *PostService) savePendingRenders(tasks []syncTask) {
+	if s.pendingRenders == nil {
+		return
+	}
+
+	var renders []*domain.PendingRender
+	for _, task := range tasks {
+		if task.render != nil {
+			renders = append(renders, task.render)
+		}
+	}
+	if len(renders) == 0 {
+		return
+	}
+
+	if err := s.pendingRenders.SavePendingRenders(s.ctx, renders); err != nil {
+		log.Error().Err(err).Int("renders", len(renders)).Msg("Failed to record pending renders")
+	}
+}
+
+// clearPendingRender forgets the render of a task once it has run
+// Renders cut short by shutdown stay pending, so they are recovered when the server starts again. Other
+// failures are dead-lettered instead. The service context may already be cancelled, so the update runs without it.
+func (s *PostService) clearPendingRender(task syncTask, taskErr error) {
+	if s.pendingRenders == nil || task.render == nil {
+		return
+	}
+	if taskErr != nil && s.ctx.Err() != nil {
+		return
+	}
+
+	render := task.render
+	if err := s.pendingRenders.ClearPendingRender(context.WithoutCancel(s.ctx), render.Branch, render.Path, render.CommitSHA); err != nil {
+		log.Error().Err(err).Str("path", render.Path).Msg("Failed to clear pending render")
+	}
+}
+
+// RecoverPendingRenders queues the renders that were still pending when the server last stopped,
+// returning the ID of the sync job they run under, or zero when there are none
+// It should be called at startup, before webhooks are accepted.
+func (s *PostService) RecoverPendingRenders() (int64, error) {
+	if s.pendingRenders == nil {
+		return 0, nil
+	}
+
+	renders, err := s.pendingRenders.ListPendingRenders(s.ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list pending renders: %w", err)
+	}
+	if len(renders) == 0 {
+		return 0, nil
+	}
+
+	jobID, err := s.startSyncJob("recovery", "", "")
+	if err != nil {
+		return 0, err
+	}
+
+	tasks := make([]syncTask, 0, len(renders))
+	for _, render := range renders {
+		tasks = append(tasks, s.renderTask(render))
+	}
+
+	s.addSyncJobFiles(jobID, tasks)
+	log.Warn().Int("renders", len(tasks)).Msg("Recovering renders interrupted by the last shutdown")
+	s.runSyncTasks(jobID, tasks, nil)
+
+	return jobID, nil
+}
+
+// renderTask returns a task running a pending render or removal again
+func (s *PostService) renderTask(render *domain.PendingRender) syncTask {
+	task := syncTask{
+		file:   domain.SyncJobFile{Path: render.Path, CommitSHA: render.CommitSHA, Action: domain.SyncActionUpsert},
+		render: render,
+	}
+	postID := s.postID(render.Path)
+
+	if render.Action == domain.SyncActionRemove {
+		task.file.Action = domain.SyncActionRemove
+		task.run = func(ctx context.Context) error {
+			switch {
+			case s.isImageFile(render.Path):
+				return s.removeImage(ctx, render.Path)
+			case render.Branch == s.mainBranchName:
+				return s.repo.Unpublish(ctx, postID)
+			default:
+				return s.repo.DeletePost(ctx, postID)
+			}
+		}
+		return task
+	}
+
+	if s.isImageFile(render.Path) {
+		task.run = func(ctx context.Context) error {
+			return s.processImageFile(ctx, render.Path, render.CommitSHA)
+		}
+		return task
+	}
+
+	fileInfo := commitFileInfo{
+		path:       render.Path,
+		blobSHA:    render.BlobSHA,
+		createdAt:  render.CreatedAt,
+		modifiedAt: render.ModifiedAt,
+		released:   render.Released,
+	}
+	task.run = func(ctx context.Context) error {
+		return s.processPostFile(ctx, postID, fileInfo, render.CommitSHA, render.Branch, nil)
+	}
+	return task
+}
@@ -0,0 +1,125 @@
+package application
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+)
+
+// waitForPendingRenders waits until the paths of the pending renders are want
+func waitForPendingRenders(t *testing.T, pending *fakePendingRenderRepository, want ...string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if slices.Equal(pending.pending(), want) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("pending renders = %v, want %v", pending.pending(), want)
+}
+
+func TestPostService_RecoverPendingRenders(t *testing.T) {
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	modified := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	pending := newFakePendingRenderRepository(&domain.PendingRender{
+		Path:       "posts/001-foo.md",
+		CommitSHA:  "head",
+		Branch:     "main",
+		CreatedAt:  created,
+		ModifiedAt: modified,
+	})
+	source := newFakeSourceRepository()
+	source.files["head:posts/001-foo.md"] = []byte("# Foo\n\nBody")
+	repo := newFakePostRepository()
+	service := NewPostService(repo, newFakeImageRepository(), source, NewMarkdownRenderer(), "main",
+		WithPendingRenders(pending),
+	)
+	defer service.Close()
+
+	if _, err := service.RecoverPendingRenders(); err != nil {
+		t.Fatalf("RecoverPendingRenders() error = %v", err)
+	}
+	waitForPendingRenders(t, pending)
+
+	post, err := repo.GetPost(t.Context(), "001")
+	if err != nil {
+		t.Fatalf("GetPost() error = %v", err)
+	}
+	if post.Title != "Foo" || post.Branch != "main" || !post.CreatedAt.Equal(created) || !post.UpdatedAt.Equal(modified) {
+		t.Errorf("recovered post = %+v, want it rendered with the pending render's times", post)
+	}
+}
+
+func TestPostService_RecoverPendingRemovals(t *testing.T) {
+	now := time.Now().UTC()
+	pending := newFakePendingRenderRepository(
+		&domain.PendingRender{Path: "posts/001-removed.md", CommitSHA: "head", Branch: "main", Action: domain.SyncActionRemove},
+		&domain.PendingRender{Path: "posts/002-closed.md", CommitSHA: "head", Branch: "feature", Action: domain.SyncActionRemove},
+		&domain.PendingRender{Path: "images/cat.png", CommitSHA: "head", Action: domain.SyncActionRemove},
+	)
+	repo := newFakePostRepository(
+		&domain.Post{ID: "001", SourcePath: "posts/001-removed.md", Branch: "main", PublishedAt: now},
+		&domain.Post{ID: "002", SourcePath: "posts/002-closed.md", Branch: "feature"},
+	)
+	images := newFakeImageRepository(&domain.Image{Path: "images/cat.png", Hash: "h"})
+	service := NewPostService(repo, images, newFakeSourceRepository(), NewMarkdownRenderer(), "main",
+		WithPendingRenders(pending),
+	)
+	defer service.Close()
+
+	if _, err := service.RecoverPendingRenders(); err != nil {
+		t.Fatalf("RecoverPendingRenders() error = %v", err)
+	}
+	waitForPendingRenders(t, pending)
+
+	if post := repo.posts["001"]; post == nil || !post.PublishedAt.IsZero() {
+		t.Errorf("Expected the post removed from main to be unpublished, got %+v", post)
+	}
+	if _, ok := repo.posts["002"]; ok {
+		t.Error("Expected the preview of the deleted branch to be deleted")
+	}
+	if _, err := images.GetImage(t.Context(), "images/cat.png"); err == nil {
+		t.Error("Expected the removed image to be deleted")
+	}
+}
+
+func TestPostService_PendingRendersKeptOnShutdown(t *testing.T) {
+	pending := newFakePendingRenderRepository()
+	service := NewPostService(newFakePostRepository(), newFakeImageRepository(), nil, NewMarkdownRenderer(), "main",
+		WithPendingRenders(pending),
+	)
+
+	started := make(chan struct{})
+	service.runSyncTasks(0, []syncTask{
+		{
+			file:   domain.SyncJobFile{Path: "posts/001-done.md"},
+			run:    func(ctx context.Context) error { return nil },
+			render: &domain.PendingRender{Path: "posts/001-done.md", CommitSHA: "a", Branch: "main"},
+		},
+		{
+			file: domain.SyncJobFile{Path: "posts/002-interrupted.md"},
+			run: func(ctx context.Context) error {
+				close(started)
+				<-ctx.Done()
+				return ctx.Err()
+			},
+			render: &domain.PendingRender{Path: "posts/002-interrupted.md", CommitSHA: "a", Branch: "main"},
+		},
+		{
+			file: domain.SyncJobFile{Path: "posts/003-collision.md"},
+			run:  func(ctx context.Context) error { return nil },
+		},
+	}, nil)
+
+	<-started
+	waitForPendingRenders(t, pending, "posts/002-interrupted.md")
+
+	service.Close()
+	if got := pending.pending(); !slices.Equal(got, []string{"posts/002-interrupted.md"}) {
+		t.Errorf("pending renders after shutdown = %v, want the interrupted render", got)
+	}
+}
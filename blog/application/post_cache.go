@@ -0,0 +1,282 @@
+package application
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/clock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	defaultPostCacheSize = 1000
+	defaultPostCacheTTL  = 5 * time.Minute
+)
+
+var (
+	postCacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "goblog_post_cache_lookups_total",
+		Help: "Post cache lookups, by kind of content and result.",
+	}, []string{"kind", "result"})
+
+	postCacheEntries = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "goblog_post_cache_entries",
+		Help: "Entries held by the post cache.",
+	})
+)
+
+type PostCacheConfig struct {
+	// Size is how many posts, post bodies and list pages the cache holds; zero disables it
+	Size int
+	// TTL is how long an entry is served before it is read again, even if nothing invalidated it
+	TTL time.Duration
+}
+
+func NewPostCacheConfig() *PostCacheConfig {
+	size := defaultPostCacheSize
+	if n, err := strconv.Atoi(os.Getenv("POST_CACHE_SIZE")); err == nil && n >= 0 {
+		size = n
+	}
+
+	ttl := defaultPostCacheTTL
+	if d, err := time.ParseDuration(os.Getenv("POST_CACHE_TTL")); err == nil && d > 0 {
+		ttl = d
+	}
+
+	return &PostCacheConfig{Size: size, TTL: ttl}
+}
+
+// WithPostCache keeps recently read posts, post HTML and list pages in memory
+// Every write to the live posts empties the cache, so it never serves a post older than the database's.
+func WithPostCache(config *PostCacheConfig) PostServiceOption {
+	return func(s *PostService) {
+		s.postCacheConfig = config
+	}
+}
+
+// postCacheEntry is a cached value and the key it is stored under
+type postCacheEntry struct {
+	key     string
+	value   any
+	expires time.Time
+}
+
+// postCache is a least recently used cache of repository reads
+type postCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	clock   clock.Clock
+	entries map[string]*list.Element
+	// order holds the entries, most recently used first
+	order *list.List
+	// generation counts invalidations, so a read that began before one is not cached after it
+	generation uint64
+}
+
+func newPostCache(config *PostCacheConfig, c clock.Clock) *postCache {
+	return &postCache{
+		size:    config.Size,
+		ttl:     config.TTL,
+		clock:   c,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// get returns the value cached under key, if it has not expired, and the generation to store a fresh read under
+func (c *postCache) get(key string) (any, uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, c.generation, false
+	}
+
+	entry := elem.Value.(*postCacheEntry)
+	if c.ttl > 0 && !c.clock.Now().Before(entry.expires) {
+		c.remove(elem)
+		return nil, c.generation, false
+	}
+
+	c.order.MoveToFront(elem)
+	return entry.value, c.generation, true
+}
+
+// put caches value under key, unless the cache was invalidated since the read began
+func (c *postCache) put(generation uint64, key string, value any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+
+	expires := c.clock.Now().Add(c.ttl)
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*postCacheEntry)
+		entry.value = value
+		entry.expires = expires
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&postCacheEntry{key: key, value: value, expires: expires})
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+	postCacheEntries.Set(float64(c.order.Len()))
+}
+
+func (c *postCache) remove(elem *list.Element) {
+	delete(c.entries, elem.Value.(*postCacheEntry).key)
+	c.order.Remove(elem)
+	postCacheEntries.Set(float64(c.order.Len()))
+}
+
+// invalidate forgets every entry; it does nothing on a nil cache
+// List pages depend on every post, so a write to any post empties the whole cache.
+func (c *postCache) invalidate() {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	clear(c.entries)
+	c.order.Init()
+	postCacheEntries.Set(0)
+}
+
+// cachedRead returns the value cached under key, or loads and caches it
+// Errors, including missing posts, are not cached.
+func cachedRead[T any](c *postCache, kind string, key string, load func() (T, error)) (T, error) {
+	key = kind + "\x00" + key
+	cached, generation, ok := c.get(key)
+	if ok {
+		postCacheLookups.WithLabelValues(kind, "hit").Inc()
+		return cached.(T), nil
+	}
+	postCacheLookups.WithLabelValues(kind, "miss").Inc()
+
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+	c.put(generation, key, value)
+	return value, nil
+}
+
+// cachedPostRepository serves the reads behind post pages and list pages from a postCache
+// Cached posts are copied on the way out, since callers fill in fields such as HTMLContent.
+type cachedPostRepository struct {
+	domain.PostRepository
+	cache *postCache
+}
+
+func (r *cachedPostRepository) GetPost(ctx context.Context, id string) (*domain.Post, error) {
+	post, err := cachedRead(r.cache, "post", id, func() (*domain.Post, error) {
+		return r.PostRepository.GetPost(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return copyPost(post), nil
+}
+
+func (r *cachedPostRepository) GetPostBySlug(ctx context.Context, slug string) (*domain.Post, error) {
+	post, err := cachedRead(r.cache, "slug", slug, func() (*domain.Post, error) {
+		return r.PostRepository.GetPostBySlug(ctx, slug)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return copyPost(post), nil
+}
+
+func (r *cachedPostRepository) GetPostHTML(ctx context.Context, id string) ([]byte, error) {
+	return cachedRead(r.cache, "html", id, func() ([]byte, error) {
+		return r.PostRepository.GetPostHTML(ctx, id)
+	})
+}
+
+func (r *cachedPostRepository) GetPostDocument(ctx context.Context, id string) (*domain.Document, error) {
+	return cachedRead(r.cache, "document", id, func() (*domain.Document, error) {
+		return r.PostRepository.GetPostDocument(ctx, id)
+	})
+}
+
+func (r *cachedPostRepository) ListPublishedPosts(ctx context.Context, limit int, offset int) ([]*domain.Post, error) {
+	posts, err := cachedRead(r.cache, "list", fmt.Sprintf("%d:%d", limit, offset), func() ([]*domain.Post, error) {
+		return r.PostRepository.ListPublishedPosts(ctx, limit, offset)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	copies := make([]*domain.Post, len(posts))
+	for i, post := range posts {
+		copies[i] = copyPost(post)
+	}
+	return copies, nil
+}
+
+func (r *cachedPostRepository) ListPublishedPostsPage(ctx context.Context, limit int, after *domain.PostCursor) (*domain.PublishedPostPage, error) {
+	key := strconv.Itoa(limit)
+	if after != nil {
+		key += ":" + strconv.FormatInt(after.PublishedAt.UnixNano(), 10) + ":" + after.ID
+	}
+	page, err := cachedRead(r.cache, "page", key, func() (*domain.PublishedPostPage, error) {
+		return r.PostRepository.ListPublishedPostsPage(ctx, limit, after)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	copied := *page
+	copied.Posts = make([]*domain.Post, len(page.Posts))
+	for i, post := range page.Posts {
+		copied.Posts[i] = copyPost(post)
+	}
+	return &copied, nil
+}
+
+// The writes below empty the cache whether or not they succeed, since a failed write may have changed something
+
+func (r *cachedPostRepository) SavePost(ctx context.Context, p *domain.Post) error {
+	defer r.cache.invalidate()
+	return r.PostRepository.SavePost(ctx, p)
+}
+
+func (r *cachedPostRepository) Publish(ctx context.Context, postID string) error {
+	defer r.cache.invalidate()
+	return r.PostRepository.Publish(ctx, postID)
+}
+
+func (r *cachedPostRepository) Unpublish(ctx context.Context, postID string) error {
+	defer r.cache.invalidate()
+	return r.PostRepository.Unpublish(ctx, postID)
+}
+
+func (r *cachedPostRepository) DeletePost(ctx context.Context, postID string) error {
+	defer r.cache.invalidate()
+	return r.PostRepository.DeletePost(ctx, postID)
+}
+
+// copyPost returns a copy of a post that can be changed without affecting the original
+func copyPost(post *domain.Post) *domain.Post {
+	if post == nil {
+		return nil
+	}
+	c := *post
+	return &c
+}
@@ -0,0 +1,128 @@
+package application
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/clock"
+)
+
+// replacePost swaps a post in the fake repository behind the cache's back
+func replacePost(repo *fakePostRepository, post *domain.Post) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	repo.posts[post.ID] = post
+}
+
+func TestPostService_PostCache(t *testing.T) {
+	published := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := newFakePostRepository(&domain.Post{ID: "001", Title: "Old", PublishedAt: published, HTMLContent: []byte("<p>old</p>")})
+	service := NewPostService(repo, newFakeImageRepository(), nil, NewMarkdownRenderer(), "main",
+		WithPostCache(&PostCacheConfig{Size: 10, TTL: time.Hour}),
+	)
+	defer service.Close()
+	ctx := context.Background()
+
+	post, err := service.GetPublishedPost(ctx, "001")
+	if err != nil {
+		t.Fatalf("GetPublishedPost() error = %v", err)
+	}
+	if _, err := service.ListPublishedPosts(ctx, 10, 0); err != nil {
+		t.Fatalf("ListPublishedPosts() error = %v", err)
+	}
+	post.Title = "Changed by the caller"
+
+	replacePost(repo, &domain.Post{ID: "001", Title: "New", PublishedAt: published, HTMLContent: []byte("<p>new</p>")})
+
+	post, err = service.GetPublishedPost(ctx, "001")
+	if err != nil {
+		t.Fatalf("GetPublishedPost() error = %v", err)
+	}
+	if post.Title != "Old" || string(post.HTMLContent) != "<p>old</p>" {
+		t.Errorf("GetPublishedPost() = %q, %q, want the cached post unchanged by its caller", post.Title, post.HTMLContent)
+	}
+	posts, err := service.ListPublishedPosts(ctx, 10, 0)
+	if err != nil || len(posts) != 1 || posts[0].Title != "Old" {
+		t.Errorf("ListPublishedPosts() = %v, %v, want the cached page", posts, err)
+	}
+
+	if err := service.UnpublishPost(ctx, "001"); err != nil {
+		t.Fatalf("UnpublishPost() error = %v", err)
+	}
+	if posts, err := service.ListPublishedPosts(ctx, 10, 0); err != nil || len(posts) != 0 {
+		t.Errorf("ListPublishedPosts() after unpublishing = %v, %v, want it empty", posts, err)
+	}
+	if err := service.PublishPost(ctx, "001"); err != nil {
+		t.Fatalf("PublishPost() error = %v", err)
+	}
+	post, err = service.GetPublishedPost(ctx, "001")
+	if err != nil {
+		t.Fatalf("GetPublishedPost() after publishing error = %v", err)
+	}
+	if post.Title != "New" || string(post.HTMLContent) != "<p>new</p>" {
+		t.Errorf("GetPublishedPost() after publishing = %q, %q, want the new post", post.Title, post.HTMLContent)
+	}
+}
+
+func TestPostCache_Expiry(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := newPostCache(&PostCacheConfig{Size: 10, TTL: time.Minute}, clock.Func(func() time.Time { return now }))
+
+	loads := 0
+	load := func() (string, error) {
+		loads++
+		return "value", nil
+	}
+
+	for range 2 {
+		if _, err := cachedRead(cache, "post", "001", load); err != nil {
+			t.Fatalf("cachedRead() error = %v", err)
+		}
+	}
+	if loads != 1 {
+		t.Errorf("loads before expiry = %d, want 1", loads)
+	}
+
+	now = now.Add(time.Minute)
+	if _, err := cachedRead(cache, "post", "001", load); err != nil {
+		t.Fatalf("cachedRead() error = %v", err)
+	}
+	if loads != 2 {
+		t.Errorf("loads after expiry = %d, want 2", loads)
+	}
+}
+
+func TestPostCache_Eviction(t *testing.T) {
+	cache := newPostCache(&PostCacheConfig{Size: 2, TTL: time.Minute}, clock.System)
+
+	_, generation, _ := cache.get("a")
+	cache.put(generation, "a", 1)
+	cache.put(generation, "b", 2)
+	if _, _, ok := cache.get("a"); !ok {
+		t.Fatal("Expected a to be cached")
+	}
+	cache.put(generation, "c", 3)
+
+	if _, _, ok := cache.get("b"); ok {
+		t.Error("Expected the least recently used entry to be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, _, ok := cache.get(key); !ok {
+			t.Errorf("Expected %s to be cached", key)
+		}
+	}
+}
+
+func TestPostCache_StaleReadNotCached(t *testing.T) {
+	cache := newPostCache(&PostCacheConfig{Size: 10, TTL: time.Minute}, clock.System)
+
+	_, generation, _ := cache.get("a")
+	cache.invalidate()
+	cache.put(generation, "a", "read before the write")
+
+	if _, _, ok := cache.get("a"); ok {
+		t.Error("Expected a read that began before an invalidation not to be cached")
+	}
+}
@@ -0,0 +1,331 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+)
+
+// diffContextLines is how many unchanged lines surround each hunk of a unified diff
+const diffContextLines = 3
+
+// maxDiffCells bounds the size of the table used to compare two revisions
+// Revisions too large to compare line by line are shown as a full replacement.
+const maxDiffCells = 4 << 20
+
+// ErrInvalidDiff is returned when a diff is requested without the revisions to compare
+var ErrInvalidDiff = errors.New("invalid diff")
+
+// DiffOp says whether a line is shared by both revisions or only appears in one of them
+type DiffOp int
+
+const (
+	DiffEqual DiffOp = iota
+	DiffDelete
+	DiffInsert
+)
+
+// DiffLine is one line of rendered HTML in a diff
+type DiffLine struct {
+	Op   DiffOp
+	Text string
+}
+
+// PostDiff compares the rendered HTML of a post at two revisions of the content repository
+type PostDiff struct {
+	PostID     string
+	SourcePath string
+	From       string
+	To         string
+	Lines      []DiffLine
+}
+
+// DiffPost renders a post's source file at two commits and compares the HTML
+// Revisions are commits of the content repository; to defaults to the commit the post was last rendered from.
+// A revision at which the file does not exist compares as empty, so the first revision of a post shows as all added.
+func (s *PostService) DiffPost(ctx context.Context, id string, from string, to string) (*PostDiff, error) {
+	post, err := s.repo.GetPost(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if post.SourcePath == "" {
+		return nil, fmt.Errorf("%w: post %s has no source file", ErrInvalidDiff, id)
+	}
+	if to == "" {
+		to = post.CommitSHA
+	}
+	if from == "" || to == "" {
+		return nil, fmt.Errorf("%w: both revisions are required", ErrInvalidDiff)
+	}
+
+	before, err := s.renderRevision(ctx, post.SourcePath, from)
+	if err != nil {
+		return nil, err
+	}
+	after, err := s.renderRevision(ctx, post.SourcePath, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PostDiff{
+		PostID:     id,
+		SourcePath: post.SourcePath,
+		From:       from,
+		To:         to,
+		Lines:      diffLines(before, after),
+	}, nil
+}
+
+// renderRevision returns the rendered HTML of a source file at ref, split into lines
+func (s *PostService) renderRevision(ctx context.Context, path string, ref string) ([]string, error) {
+	source, err := s.sourceRepo.GetFileContents(ctx, path, ref)
+	if errors.Is(err, domain.ErrSourceFileNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file contents at %s: %w", ref, err)
+	}
+
+	result, err := s.markdown.Render(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render markdown at %s: %w", ref, err)
+	}
+
+	content := strings.TrimSuffix(string(result.HTMLContent), "\n")
+	if content == "" {
+		return nil, nil
+	}
+	return strings.Split(content, "\n"), nil
+}
+
+// diffLines returns the edit script turning a into b, built from their longest common subsequence
+func diffLines(a, b []string) []DiffLine {
+	// Shared leading and trailing lines are matched directly to keep the table small
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	lines := make([]DiffLine, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		lines = append(lines, DiffLine{Op: DiffEqual, Text: line})
+	}
+
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if (len(midA)+1)*(len(midB)+1) > maxDiffCells {
+		for _, line := range midA {
+			lines = append(lines, DiffLine{Op: DiffDelete, Text: line})
+		}
+		for _, line := range midB {
+			lines = append(lines, DiffLine{Op: DiffInsert, Text: line})
+		}
+	} else {
+		lines = append(lines, lcsDiff(midA, midB)...)
+	}
+
+	for _, line := range a[len(a)-suffix:] {
+		lines = append(lines, DiffLine{Op: DiffEqual, Text: line})
+	}
+	return lines
+}
+
+// lcsDiff diffs two slices with a dynamic programming table of common subsequence lengths
+func lcsDiff(a, b []string) []DiffLine {
+	width := len(b) + 1
+	// lengths[i*width+j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lengths := make([]int, (len(a)+1)*width)
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lengths[i*width+j] = lengths[(i+1)*width+j+1] + 1
+			} else {
+				lengths[i*width+j] = max(lengths[(i+1)*width+j], lengths[i*width+j+1])
+			}
+		}
+	}
+
+	var lines []DiffLine
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, DiffLine{Op: DiffEqual, Text: a[i]})
+			i++
+			j++
+		case lengths[(i+1)*width+j] >= lengths[i*width+j+1]:
+			lines = append(lines, DiffLine{Op: DiffDelete, Text: a[i]})
+			i++
+		default:
+			lines = append(lines, DiffLine{Op: DiffInsert, Text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, DiffLine{Op: DiffDelete, Text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, DiffLine{Op: DiffInsert, Text: b[j]})
+	}
+	return lines
+}
+
+// Changed reports whether the two revisions render differently
+func (d *PostDiff) Changed() bool {
+	for _, line := range d.Lines {
+		if line.Op != DiffEqual {
+			return true
+		}
+	}
+	return false
+}
+
+// Unified formats the diff in unified diff format, with a few lines of context around each change
+func (d *PostDiff) Unified() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- a/%s\t%s\n", d.SourcePath, d.From)
+	fmt.Fprintf(&b, "+++ b/%s\t%s\n", d.SourcePath, d.To)
+
+	for start := 0; start < len(d.Lines); {
+		// Find the next change, then extend the hunk until a run of unchanged lines is long enough to split on
+		first := start
+		for first < len(d.Lines) && d.Lines[first].Op == DiffEqual {
+			first++
+		}
+		if first == len(d.Lines) {
+			break
+		}
+
+		end := first
+		for end < len(d.Lines) {
+			if d.Lines[end].Op != DiffEqual {
+				end++
+				continue
+			}
+			run := end
+			for run < len(d.Lines) && d.Lines[run].Op == DiffEqual {
+				run++
+			}
+			if run == len(d.Lines) || run-end > 2*diffContextLines {
+				break
+			}
+			end = run
+		}
+
+		hunkStart := max(first-diffContextLines, start)
+		hunkEnd := min(end+diffContextLines, len(d.Lines))
+		d.writeHunk(&b, hunkStart, hunkEnd)
+		start = hunkEnd
+	}
+
+	return b.String()
+}
+
+// writeHunk writes the lines in [from, to) as a single unified diff hunk
+func (d *PostDiff) writeHunk(b *strings.Builder, from, to int) {
+	// Line numbers in the header count the lines of each revision before the hunk
+	oldLine, newLine := 1, 1
+	for _, line := range d.Lines[:from] {
+		if line.Op != DiffInsert {
+			oldLine++
+		}
+		if line.Op != DiffDelete {
+			newLine++
+		}
+	}
+
+	oldCount, newCount := 0, 0
+	var body strings.Builder
+	for _, line := range d.Lines[from:to] {
+		switch line.Op {
+		case DiffEqual:
+			oldCount++
+			newCount++
+			body.WriteString(" ")
+		case DiffDelete:
+			oldCount++
+			body.WriteString("-")
+		case DiffInsert:
+			newCount++
+			body.WriteString("+")
+		}
+		body.WriteString(line.Text)
+		body.WriteString("\n")
+	}
+
+	// An empty side of a hunk starts at the line before it, as in diff(1)
+	if oldCount == 0 {
+		oldLine--
+	}
+	if newCount == 0 {
+		newLine--
+	}
+	fmt.Fprintf(b, "@@ -%d,%d +%d,%d @@\n", oldLine, oldCount, newLine, newCount)
+	b.WriteString(body.String())
+}
+
+// SideBySide formats the diff as an HTML table with the old revision on the left and the new one on the right
+// Deleted and inserted lines in the same block of changes are paired up row by row.
+func (d *PostDiff) SideBySide() string {
+	var b strings.Builder
+	b.WriteString(`<table class="diff">` + "\n")
+	fmt.Fprintf(&b, "<thead><tr><th>%s</th><th>%s</th></tr></thead>\n", html.EscapeString(d.From), html.EscapeString(d.To))
+	b.WriteString("<tbody>\n")
+
+	for i := 0; i < len(d.Lines); {
+		if d.Lines[i].Op == DiffEqual {
+			writeDiffRow(&b, &d.Lines[i], &d.Lines[i])
+			i++
+			continue
+		}
+
+		var deleted, inserted []*DiffLine
+		for ; i < len(d.Lines) && d.Lines[i].Op != DiffEqual; i++ {
+			if d.Lines[i].Op == DiffDelete {
+				deleted = append(deleted, &d.Lines[i])
+			} else {
+				inserted = append(inserted, &d.Lines[i])
+			}
+		}
+		for row := 0; row < max(len(deleted), len(inserted)); row++ {
+			var left, right *DiffLine
+			if row < len(deleted) {
+				left = deleted[row]
+			}
+			if row < len(inserted) {
+				right = inserted[row]
+			}
+			writeDiffRow(&b, left, right)
+		}
+	}
+
+	b.WriteString("</tbody>\n</table>\n")
+	return b.String()
+}
+
+// writeDiffRow writes one row of a side-by-side diff, leaving a side empty when its line is nil
+func writeDiffRow(b *strings.Builder, left, right *DiffLine) {
+	b.WriteString("<tr>")
+	for _, line := range []*DiffLine{left, right} {
+		if line == nil {
+			b.WriteString(`<td class="diff-empty"></td>`)
+			continue
+		}
+		class := "diff-equal"
+		switch line.Op {
+		case DiffDelete:
+			class = "diff-delete"
+		case DiffInsert:
+			class = "diff-insert"
+		}
+		fmt.Fprintf(b, `<td class="%s"><code>%s</code></td>`, class, html.EscapeString(line.Text))
+	}
+	b.WriteString("</tr>\n")
+}
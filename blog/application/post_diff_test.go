@@ -0,0 +1,101 @@
+package application
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+)
+
+func TestDiffLines(t *testing.T) {
+	a := []string{"a", "b", "c", "d"}
+	b := []string{"a", "c", "x", "d"}
+
+	var got []string
+	for _, line := range diffLines(a, b) {
+		got = append(got, string(" -+"[line.Op])+line.Text)
+	}
+
+	want := []string{" a", "-b", " c", "+x", " d"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("diffLines() = %v, want %v", got, want)
+	}
+}
+
+func TestPostDiff_Unified(t *testing.T) {
+	var lines []DiffLine
+	for _, text := range []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11", "12"} {
+		lines = append(lines, DiffLine{Op: DiffEqual, Text: text})
+	}
+	lines[1] = DiffLine{Op: DiffDelete, Text: "2"}
+	lines = append(lines[:11], DiffLine{Op: DiffInsert, Text: "11b"}, lines[11])
+
+	d := &PostDiff{SourcePath: "posts/001-foo.md", From: "a", To: "b", Lines: lines}
+	want := "--- a/posts/001-foo.md\ta\n" +
+		"+++ b/posts/001-foo.md\tb\n" +
+		"@@ -1,5 +1,4 @@\n 1\n-2\n 3\n 4\n 5\n" +
+		"@@ -9,4 +8,5 @@\n 9\n 10\n 11\n+11b\n 12\n"
+	if got := d.Unified(); got != want {
+		t.Errorf("Unified() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestPostDiff_SideBySide(t *testing.T) {
+	d := &PostDiff{From: "a", To: "b", Lines: []DiffLine{
+		{Op: DiffEqual, Text: "<h1>Title</h1>"},
+		{Op: DiffDelete, Text: "<p>Old</p>"},
+		{Op: DiffInsert, Text: "<p>New</p>"},
+		{Op: DiffInsert, Text: "<p>More</p>"},
+	}}
+
+	out := d.SideBySide()
+	for _, want := range []string{
+		`<td class="diff-equal"><code>&lt;h1&gt;Title&lt;/h1&gt;</code></td><td class="diff-equal">`,
+		`<td class="diff-delete"><code>&lt;p&gt;Old&lt;/p&gt;</code></td><td class="diff-insert"><code>&lt;p&gt;New&lt;/p&gt;</code></td>`,
+		`<td class="diff-empty"></td><td class="diff-insert"><code>&lt;p&gt;More&lt;/p&gt;</code></td>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("SideBySide() missing %q\n%s", want, out)
+		}
+	}
+}
+
+func TestPostService_DiffPost(t *testing.T) {
+	repo := newFakePostRepository(&domain.Post{ID: "001", SourcePath: "posts/001-foo.md", CommitSHA: "new"})
+	source := newFakeSourceRepository()
+	source.files["old:posts/001-foo.md"] = []byte("# Foo\n\nFirst draft\n\nKept")
+	source.files["new:posts/001-foo.md"] = []byte("# Foo\n\nSecond draft\n\nKept")
+
+	service := NewPostService(repo, newFakeImageRepository(), source, NewMarkdownRenderer(), "main")
+	defer service.Close()
+
+	d, err := service.DiffPost(t.Context(), "001", "old", "")
+	if err != nil {
+		t.Fatalf("DiffPost() error = %v", err)
+	}
+	if d.To != "new" {
+		t.Errorf("To = %q, want the post's current commit", d.To)
+	}
+	unified := d.Unified()
+	if !strings.Contains(unified, "-<p>First draft</p>\n+<p>Second draft</p>\n") {
+		t.Errorf("Unexpected diff:\n%s", unified)
+	}
+
+	d, err = service.DiffPost(t.Context(), "001", "missing", "new")
+	if err != nil {
+		t.Fatalf("DiffPost() error = %v", err)
+	}
+	for _, line := range d.Lines {
+		if line.Op != DiffInsert {
+			t.Errorf("Expected every line to be added when the file is missing, got %+v", line)
+		}
+	}
+
+	if _, err := service.DiffPost(t.Context(), "001", "", "new"); !errors.Is(err, ErrInvalidDiff) {
+		t.Errorf("Expected ErrInvalidDiff without a from revision, got %v", err)
+	}
+	if _, err := service.DiffPost(t.Context(), "002", "old", "new"); !errors.Is(err, domain.ErrPostNotFound) {
+		t.Errorf("Expected ErrPostNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,203 @@
+package application
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const (
+	// maxFindQueryLength bounds the text searched for within a post
+	maxFindQueryLength = 200
+	// maxFindMatches bounds the blocks returned by a search; Total still counts every match
+	maxFindMatches = 100
+)
+
+// ErrInvalidQuery is returned when a search has no text to look for, or too much
+var ErrInvalidQuery = errors.New("invalid query")
+
+// findBlocks are the elements whose text is searched as a unit
+// Text inside a nested block, such as a paragraph in a list item, belongs to the innermost block.
+var findBlocks = map[atom.Atom]bool{
+	atom.P: true, atom.Li: true, atom.Pre: true, atom.Td: true, atom.Th: true, atom.Dt: true, atom.Dd: true,
+	atom.Figcaption: true, atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+}
+
+// PostMatch is a block of a post that contains the search text
+type PostMatch struct {
+	// Block is the position of the block among the post's blocks of text, counting from zero
+	Block int
+	// Anchor is the ID of the heading the block is in, or the heading itself, or empty before the first heading
+	Anchor string
+	// Text is the block's text, with whitespace collapsed
+	Text string
+	// Ranges are the byte offsets in Text of each match, as [start, end) pairs
+	Ranges [][2]int
+}
+
+// PostFindResult lists the blocks of a post that contain the search text
+type PostFindResult struct {
+	PostID string
+	Query  string
+	// Matches holds up to maxFindMatches blocks, in document order
+	Matches []PostMatch
+	// Total counts every matching block, including any left out of Matches
+	Total int
+}
+
+// FindInPost searches the text of a published post, block by block, ignoring case
+// Long posts can be searched without sending the client the text to search.
+func (s *PostService) FindInPost(ctx context.Context, id string, query string) (*PostFindResult, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, fmt.Errorf("%w: no search text", ErrInvalidQuery)
+	}
+	if len(query) > maxFindQueryLength {
+		return nil, fmt.Errorf("%w: search text is longer than %d bytes", ErrInvalidQuery, maxFindQueryLength)
+	}
+
+	post, err := s.GetPublishedPost(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	blocks, err := textBlocks(post.HTMLContent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse post %s: %w", id, err)
+	}
+
+	result := &PostFindResult{PostID: id, Query: query, Matches: []PostMatch{}}
+	for i, block := range blocks {
+		ranges := findFold(block.text, query)
+		if len(ranges) == 0 {
+			continue
+		}
+
+		result.Total++
+		if len(result.Matches) < maxFindMatches {
+			result.Matches = append(result.Matches, PostMatch{Block: i, Anchor: block.anchor, Text: block.text, Ranges: ranges})
+		}
+	}
+
+	return result, nil
+}
+
+// textBlock is the text of one block of rendered HTML
+type textBlock struct {
+	anchor string
+	text   string
+}
+
+// textBlocks splits rendered post HTML into its blocks of text, in document order, leaving out empty blocks
+func textBlocks(content []byte) ([]textBlock, error) {
+	body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(bytes.NewReader(content), body)
+	if err != nil {
+		return nil, err
+	}
+
+	type openBlock struct {
+		anchor string
+		text   strings.Builder
+	}
+	var (
+		blocks []*openBlock
+		stack  []*openBlock
+		anchor string
+	)
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.WriteString(n.Data)
+			}
+			return
+		case html.ElementNode:
+		default:
+			return
+		}
+
+		isBlock := findBlocks[n.DataAtom]
+		if isBlock {
+			if id := elementID(n); id != "" && isHeading(n.DataAtom) {
+				anchor = id
+			}
+			block := &openBlock{anchor: anchor}
+			blocks = append(blocks, block)
+			stack = append(stack, block)
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+
+		if isBlock {
+			stack = stack[:len(stack)-1]
+		}
+	}
+	for _, n := range nodes {
+		walk(n)
+	}
+
+	var result []textBlock
+	for _, b := range blocks {
+		if text := strings.Join(strings.Fields(b.text.String()), " "); text != "" {
+			result = append(result, textBlock{anchor: b.anchor, text: text})
+		}
+	}
+	return result, nil
+}
+
+func elementID(n *html.Node) string {
+	for _, attr := range n.Attr {
+		if attr.Key == "id" {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+func isHeading(a atom.Atom) bool {
+	switch a {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		return true
+	}
+	return false
+}
+
+// findFold returns the byte ranges of every non-overlapping occurrence of query in text, ignoring case
+func findFold(text string, query string) [][2]int {
+	var ranges [][2]int
+	queryRunes := utf8.RuneCountInString(query)
+
+	for start := 0; start < len(text); {
+		// Case folding can change a rune's encoded length, so compare rune for rune rather than byte for byte
+		end, n := start, 0
+		for end < len(text) && n < queryRunes {
+			_, size := utf8.DecodeRuneInString(text[end:])
+			end += size
+			n++
+		}
+		if n < queryRunes {
+			break
+		}
+
+		if strings.EqualFold(text[start:end], query) {
+			ranges = append(ranges, [2]int{start, end})
+			start = end
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(text[start:])
+		start += size
+	}
+
+	return ranges
+}
@@ -0,0 +1,96 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+)
+
+func TestPostService_FindInPost(t *testing.T) {
+	content := `<p>Intro about Go.</p>
+<h2 id="setup">Setup</h2>
+<p>Install <code>go</code> and run
+  <em>go build</em>.</p>
+<ul><li><p>Go modules</p></li><li>Nothing here</li></ul>
+<h2 id="notes">Notes on GO</h2>
+<pre><code>go test ./...</code></pre>`
+	repo := newFakePostRepository(
+		&domain.Post{ID: "001", PublishedAt: time.Now(), HTMLContent: []byte(content)},
+		&domain.Post{ID: "002", HTMLContent: []byte(content)},
+	)
+	service := NewPostService(repo, newFakeImageRepository(), nil, NewMarkdownRenderer(), "main")
+	defer service.Close()
+	ctx := context.Background()
+
+	result, err := service.FindInPost(ctx, "001", "  go ")
+	if err != nil {
+		t.Fatalf("FindInPost() error = %v", err)
+	}
+
+	want := []PostMatch{
+		{Block: 0, Anchor: "", Text: "Intro about Go.", Ranges: [][2]int{{12, 14}}},
+		{Block: 2, Anchor: "setup", Text: "Install go and run go build.", Ranges: [][2]int{{8, 10}, {19, 21}}},
+		{Block: 3, Anchor: "setup", Text: "Go modules", Ranges: [][2]int{{0, 2}}},
+		{Block: 5, Anchor: "notes", Text: "Notes on GO", Ranges: [][2]int{{9, 11}}},
+		{Block: 6, Anchor: "notes", Text: "go test ./...", Ranges: [][2]int{{0, 2}}},
+	}
+	if !reflect.DeepEqual(result.Matches, want) {
+		t.Errorf("Matches = %+v, want %+v", result.Matches, want)
+	}
+	if result.Total != len(want) || result.Query != "go" {
+		t.Errorf("Total = %d, Query = %q, want %d, %q", result.Total, result.Query, len(want), "go")
+	}
+
+	if _, err := service.FindInPost(ctx, "002", "go"); !errors.Is(err, domain.ErrPostNotFound) {
+		t.Errorf("FindInPost() of an unpublished post error = %v, want ErrPostNotFound", err)
+	}
+	if _, err := service.FindInPost(ctx, "001", " "); !errors.Is(err, ErrInvalidQuery) {
+		t.Errorf("FindInPost() with no query error = %v, want ErrInvalidQuery", err)
+	}
+	if _, err := service.FindInPost(ctx, "001", strings.Repeat("a", maxFindQueryLength+1)); !errors.Is(err, ErrInvalidQuery) {
+		t.Errorf("FindInPost() with a long query error = %v, want ErrInvalidQuery", err)
+	}
+}
+
+func TestPostService_FindInPost_LimitsMatches(t *testing.T) {
+	var content strings.Builder
+	for i := range maxFindMatches + 5 {
+		fmt.Fprintf(&content, "<p>match %d</p>", i)
+	}
+	repo := newFakePostRepository(&domain.Post{ID: "001", PublishedAt: time.Now(), HTMLContent: []byte(content.String())})
+	service := NewPostService(repo, newFakeImageRepository(), nil, NewMarkdownRenderer(), "main")
+	defer service.Close()
+
+	result, err := service.FindInPost(context.Background(), "001", "MATCH")
+	if err != nil {
+		t.Fatalf("FindInPost() error = %v", err)
+	}
+	if len(result.Matches) != maxFindMatches || result.Total != maxFindMatches+5 {
+		t.Errorf("got %d matches of %d, want %d of %d", len(result.Matches), result.Total, maxFindMatches, maxFindMatches+5)
+	}
+}
+
+func TestFindFold(t *testing.T) {
+	tests := []struct {
+		text  string
+		query string
+		want  [][2]int
+	}{
+		{"aaaa", "aa", [][2]int{{0, 2}, {2, 4}}},
+		{"Straße STRASSE", "straße", [][2]int{{0, 7}}},
+		{"ÉCOLE école", "école", [][2]int{{0, 6}, {7, 13}}},
+		{"short", "longer query", nil},
+	}
+
+	for _, tt := range tests {
+		if got := findFold(tt.text, tt.query); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("findFold(%q, %q) = %v, want %v", tt.text, tt.query, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package application
+
+import (
+	"context"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// postLocks serializes the writes of each post, so work on one post can't interleave with other work on it
+// Jobs are already applied one at a time by the dispatch queue, but within a job a post's file and an image
+// it references are processed concurrently, and both render and save the post.
+// A lock is created on first use and dropped once nothing holds or waits for it.
+type postLocks struct {
+	mu    sync.Mutex
+	locks map[string]*postLock
+}
+
+type postLock struct {
+	sync.Mutex
+	// refs counts the holder and waiters of the lock
+	refs int
+}
+
+// lock blocks until the post with the given ID is free, returning the function that frees it
+func (l *postLocks) lock(postID string) (unlock func()) {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[string]*postLock)
+	}
+	lock, ok := l.locks[postID]
+	if !ok {
+		lock = &postLock{}
+		l.locks[postID] = lock
+	}
+	lock.refs++
+	l.mu.Unlock()
+
+	lock.Lock()
+	return func() {
+		lock.Unlock()
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		lock.refs--
+		if lock.refs == 0 {
+			delete(l.locks, postID)
+		}
+	}
+}
+
+// supersededBy reports whether commitSHA is an ancestor of newerSHA, so rendering it would overwrite a newer version
+// Pushes are applied in the order their webhooks arrive, and a redelivered or delayed push can arrive after a
+// later one. A failed comparison, such as for a commit a force push discarded, is logged and not treated as older.
+func (s *PostService) supersededBy(ctx context.Context, commitSHA string, newerSHA string) bool {
+	comparison, err := s.sourceRepo.CompareCommits(ctx, commitSHA, newerSHA)
+	if err != nil {
+		log.Warn().Err(err).Str("commit", commitSHA).Str("newer", newerSHA).Msg("Failed to compare commits, applying the commit anyway")
+		return false
+	}
+	// The newer commit being ahead means it contains commitSHA
+	return comparison.GetStatus() == "ahead"
+}
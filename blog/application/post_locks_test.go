@@ -0,0 +1,73 @@
+package application
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/google/go-github/v75/github"
+)
+
+func TestPostLocks(t *testing.T) {
+	var locks postLocks
+	var holding atomic.Int32
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Go(func() {
+			defer locks.lock("001")()
+			if holding.Add(1) > 1 {
+				t.Error("Expected one holder of a post's lock at a time")
+			}
+			time.Sleep(time.Millisecond)
+			holding.Add(-1)
+		})
+	}
+	// Another post is never held up
+	unlock := locks.lock("002")
+	unlock()
+	wg.Wait()
+
+	if len(locks.locks) != 0 {
+		t.Errorf("Expected unused locks to be dropped, %d left", len(locks.locks))
+	}
+}
+
+func TestPostService_ProcessPostFile_SkipsOlderCommit(t *testing.T) {
+	older := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+
+	tests := []struct {
+		name string
+		// status is how the current commit compares to the one being processed
+		status    string
+		wantTitle string
+	}{
+		{name: "ancestor of the current commit", status: "ahead", wantTitle: "Current"},
+		{name: "rebased, with an older commit time", status: "diverged", wantTitle: "Rebased"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakePostRepository(&domain.Post{
+				ID: "001", Title: "Current", SourcePath: "posts/001-post.md", Branch: "feature", CommitSHA: "b", UpdatedAt: newer,
+			})
+			source := newFakeSourceRepository()
+			source.comparisons["a...b"] = &github.CommitsComparison{Status: github.Ptr(tt.status)}
+			source.files["a:posts/001-post.md"] = []byte("# Rebased\n\nBody")
+			service := NewPostService(repo, newFakeImageRepository(), source, NewMarkdownRenderer(), "main")
+			defer service.Close()
+
+			fileInfo := commitFileInfo{path: "posts/001-post.md", createdAt: older, modifiedAt: older}
+			if err := service.processPostFile(t.Context(), "001", fileInfo, "a", "feature", nil); err != nil {
+				t.Fatalf("processPostFile() error = %v", err)
+			}
+
+			if post := repo.posts["001"]; post.Title != tt.wantTitle {
+				t.Errorf("post title = %q, want %q", post.Title, tt.wantTitle)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package application
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+)
+
+const (
+	// defaultPostPageSize is the page size when none is asked for
+	defaultPostPageSize = 10
+	// maxPostPageSize bounds the posts returned by a single page
+	maxPostPageSize = 100
+)
+
+// ErrInvalidCursor is returned when a cursor was not produced by EncodePostCursor
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// PostPage is a page of published posts with an opaque cursor for the next one
+type PostPage struct {
+	Posts []*domain.Post
+	// Total counts every published post
+	Total int
+	// NextCursor continues the listing, or is empty on the last page
+	NextCursor string
+}
+
+// ListPublishedPostsPage returns up to limit published posts, newest first, continuing after cursor when it is set
+// A limit of zero or less asks for the default page size, and larger limits are capped.
+func (s *PostService) ListPublishedPostsPage(ctx context.Context, limit int, cursor string) (*PostPage, error) {
+	if limit <= 0 {
+		limit = defaultPostPageSize
+	}
+	limit = min(limit, maxPostPageSize)
+
+	var after *domain.PostCursor
+	if cursor != "" {
+		parsed, err := ParsePostCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		after = parsed
+	}
+
+	page, err := s.repo.ListPublishedPostsPage(ctx, limit, after)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list published posts: %w", err)
+	}
+
+	result := &PostPage{Posts: page.Posts, Total: page.Total}
+	if page.Next != nil {
+		result.NextCursor = EncodePostCursor(page.Next)
+	}
+	return result, nil
+}
+
+// EncodePostCursor turns a cursor into an opaque, URL-safe token
+func EncodePostCursor(cursor *domain.PostCursor) string {
+	raw := strconv.FormatInt(cursor.PublishedAt.UnixNano(), 10) + ":" + cursor.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParsePostCursor reverses EncodePostCursor
+func ParsePostCursor(token string) (*domain.PostCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	nanos, id, ok := strings.Cut(string(raw), ":")
+	if !ok || id == "" {
+		return nil, ErrInvalidCursor
+	}
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &domain.PostCursor{PublishedAt: time.Unix(0, n).UTC(), ID: id}, nil
+}
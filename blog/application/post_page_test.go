@@ -0,0 +1,63 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+)
+
+func TestPostService_ListPublishedPostsPage(t *testing.T) {
+	published := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := newFakePostRepository(
+		&domain.Post{ID: "001", PublishedAt: published},
+		&domain.Post{ID: "002", PublishedAt: published.Add(time.Hour)},
+		&domain.Post{ID: "003", PublishedAt: published.Add(2 * time.Hour)},
+		&domain.Post{ID: "004"},
+	)
+	service := NewPostService(repo, newFakeImageRepository(), nil, NewMarkdownRenderer(), "main")
+	defer service.Close()
+	ctx := context.Background()
+
+	first, err := service.ListPublishedPostsPage(ctx, 2, "")
+	if err != nil {
+		t.Fatalf("ListPublishedPostsPage() error = %v", err)
+	}
+	if len(first.Posts) != 2 || first.Posts[0].ID != "003" || first.Total != 3 || first.NextCursor == "" {
+		t.Fatalf("first page = %+v, want 003 and 002 of 3 with a cursor", first)
+	}
+
+	// A post published between pages doesn't shift the next one
+	repo.SavePost(ctx, &domain.Post{ID: "005", PublishedAt: published.Add(3 * time.Hour)})
+
+	second, err := service.ListPublishedPostsPage(ctx, 2, first.NextCursor)
+	if err != nil {
+		t.Fatalf("ListPublishedPostsPage() error = %v", err)
+	}
+	if len(second.Posts) != 1 || second.Posts[0].ID != "001" || second.NextCursor != "" {
+		t.Errorf("second page = %+v, want only 001 and no cursor", second)
+	}
+
+	if _, err := service.ListPublishedPostsPage(ctx, 2, "not a cursor"); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("ListPublishedPostsPage() with a bad cursor error = %v, want ErrInvalidCursor", err)
+	}
+}
+
+func TestParsePostCursor(t *testing.T) {
+	cursor := &domain.PostCursor{PublishedAt: time.Date(2026, 1, 1, 12, 30, 0, 5, time.UTC), ID: "post:with:colons"}
+	parsed, err := ParsePostCursor(EncodePostCursor(cursor))
+	if err != nil {
+		t.Fatalf("ParsePostCursor() error = %v", err)
+	}
+	if !parsed.PublishedAt.Equal(cursor.PublishedAt) || parsed.ID != cursor.ID {
+		t.Errorf("ParsePostCursor() = %+v, want %+v", parsed, cursor)
+	}
+
+	for _, token := range []string{"", "!!!", "bm90LWEtbnVtYmVyOmlk", "MTIz"} {
+		if _, err := ParsePostCursor(token); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("ParsePostCursor(%q) error = %v, want ErrInvalidCursor", token, err)
+		}
+	}
+}
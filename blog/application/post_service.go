@@ -1,23 +1,39 @@
 package application
 
 import (
+	"cmp"
 	"context"
+	"crypto/ed25519"
+	"crypto/rsa"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
-	"regexp"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/activitypub"
+	"github.com/dfryer1193/goblog/shared/clock"
+	"github.com/dfryer1193/goblog/shared/mail"
+	"github.com/dfryer1193/goblog/shared/notify"
 	"github.com/dfryer1193/mjolnir/utils/set"
 	"github.com/google/go-github/v75/github"
 	"github.com/rs/zerolog/log"
 )
 
-var (
-	postPathRegex  = regexp.MustCompile(`^posts/(\d+)-.*\.md$`)
-	imagePathRegex = regexp.MustCompile(`^images/.*\.(jpg|jpeg|png|gif|svg|webp|avif)$`)
+const (
+	// maxIndividualCommits is the most commits analyzed by fetching each one
+	// Larger ranges use a single compare call to conserve the API rate limit
+	maxIndividualCommits = 10
+
+	// compareFileLimit is the most files the compare API returns before truncating a diff
+	compareFileLimit = 300
 )
 
 type PostService struct {
@@ -32,12 +48,181 @@ type PostService struct {
 
 	repo      domain.PostRepository
 	imageRepo domain.ImageRepository
+
+	imageQuota       ImageQuota
+	syncJobs         domain.SyncJobRepository
+	deadLetters      domain.DeadLetterRepository
+	processedCommits domain.ProcessedCommitRepository
+	deliveries       domain.WebhookDeliveryRepository
+	webhookMu        sync.Mutex
+	webhookStatus    WebhookStatus
+	redirects        domain.RedirectRepository
+
+	// syncOverlap is subtracted from the last update time when listing commits to sync
+	syncOverlap time.Duration
+	timestamps  TimestampSource
+
+	// workers is a semaphore bounding concurrent file processing
+	workers chan struct{}
+	// dispatcher serializes the jobs applying source changes, see dispatchQueue
+	dispatcher *dispatchQueue
+
+	// taskRetries retries and quarantines failing files, or is nil to process each file once per sync
+	taskRetries *TaskRetryConfig
+
+	clock clock.Clock
+	// layout recognises post and image files in the source repository
+	layout *ContentLayout
+	// postID derives post IDs from source paths, by default using layout
+	postID PostIDFunc
+
+	// resyncing is set while a full resync started by StartResync is running
+	resyncing atomic.Bool
+
+	// shuttingDown is set once the server begins a graceful shutdown, see BeginShutdown
+	shuttingDown atomic.Bool
+
+	// startedAt is when the service was created; content served before then may have looked different
+	startedAt         time.Time
+	contentVersion    atomic.Int64
+	contentModifiedAt atomic.Int64
+
+	previewRetention *PreviewRetentionConfig
+	previewMu        sync.Mutex
+	previewStats     PreviewStats
+
+	metadata *MetadataConfig
+	feed     *FeedConfig
+
+	// archiveLocation is the time zone archive months are counted in, or nil for UTC
+	archiveLocation *time.Location
+
+	// publishing decides what publishes merged posts; nil publishes them on merge
+	publishing *PublishingConfig
+
+	// prCommenter comments previews on pull requests, or is nil when pull requests are not previewed
+	prCommenter domain.PullRequestCommenter
+	// prChecks reports check runs on pull requests, or is nil when they are not checked
+	prChecks   domain.CheckReporter
+	prPreviews *PullRequestPreviewConfig
+
+	// shadowRepo holds full rebuilds until they are promoted, or is nil when shadow builds are unavailable
+	shadowRepo   domain.ShadowPostRepository
+	shadowMu     sync.Mutex
+	shadowStatus ShadowStatus
+
+	// diagnostics records the broken links found by CheckLinks, or is nil when links are not checked
+	diagnostics domain.PostDiagnosticRepository
+
+	// signingKey signs rendered post HTML, or is nil when content signing is disabled
+	signingKey ed25519.PrivateKey
+
+	// pendingRenders records renders until they finish, or is nil when interrupted renders are not recovered
+	pendingRenders domain.PendingRenderRepository
+
+	// postVersions holds the keys of the post file versions being processed, see postVersionKey
+	postVersions sync.Map
+	// postLocks serializes the writes of each post
+	postLocks postLocks
+	// previewRenders keeps the renders of posts on preview branches, so merging them unchanged promotes
+	// the preview instead of fetching and rendering each file again
+	previewRenders *renderCache
+
+	// postCache serves repeated reads of the live posts, or is nil when caching is disabled
+	postCacheConfig *PostCacheConfig
+	postCache       *postCache
+
+	// postViews stores view counts, or is nil when views are not counted
+	postViews      domain.PostViewRepository
+	postViewConfig *PostViewConfig
+	// postViewCh buffers views until the writer counts them
+	postViewCh chan string
+
+	// webmentions stores received mentions, or is nil when they are not received
+	webmentions      domain.WebmentionRepository
+	webmentionConfig *WebmentionConfig
+	webmentionClient *http.Client
+	// webmentionCh holds received mentions until they are verified
+	webmentionCh chan webmentionJob
+
+	// federation stores the blog's followers, or is nil when the blog is not federated
+	federation       domain.FederationRepository
+	federationConfig *FederationConfig
+	federationKey    *rsa.PrivateKey
+	federationClient *activitypub.Client
+	// federationCh holds activities until they are delivered
+	federationCh chan federationDelivery
+
+	// newsletter stores subscribers to new posts, or is nil when posts are not emailed
+	newsletter       domain.NewsletterRepository
+	newsletterMailer mail.Sender
+	newsletterConfig *NewsletterConfig
+	// newsletterCh holds published posts until they are emailed
+	newsletterCh chan *domain.Post
+
+	// notifier alerts operators to failures and publishes, or is nil when no notifications are sent
+	notifier           notify.Notifier
+	notificationConfig *NotificationConfig
+	// notificationCh holds notifications until they are sent
+	notificationCh chan notification
+}
+
+// PostServiceOption configures optional PostService collaborators
+type PostServiceOption func(*PostService)
+
+// WithImageQuota makes the service consult quota before ingesting new images
+func WithImageQuota(quota ImageQuota) PostServiceOption {
+	return func(s *PostService) {
+		s.imageQuota = quota
+	}
 }
 
-func NewPostService(repo domain.PostRepository, imageRepo domain.ImageRepository, sourceRepo domain.SourceRepository, markdown MarkdownRenderer, mainBranchName string) *PostService {
+// WithSyncJobs records each push event as a sync job with per-file statuses
+func WithSyncJobs(syncJobs domain.SyncJobRepository) PostServiceOption {
+	return func(s *PostService) {
+		s.syncJobs = syncJobs
+	}
+}
+
+// WithClock sets the clock used to decide when scheduled posts are due
+func WithClock(c clock.Clock) PostServiceOption {
+	return func(s *PostService) {
+		s.clock = c
+	}
+}
+
+// WithPostIDFunc overrides how post IDs are derived from source paths
+func WithPostIDFunc(postID PostIDFunc) PostServiceOption {
+	return func(s *PostService) {
+		s.postID = postID
+	}
+}
+
+// WithContentLayout recognises posts and images where layout says the source repository keeps them
+func WithContentLayout(layout *ContentLayout) PostServiceOption {
+	return func(s *PostService) {
+		s.layout = layout
+	}
+}
+
+// WithProcessedCommits remembers synced commits so overlapping sync windows skip them
+func WithProcessedCommits(processedCommits domain.ProcessedCommitRepository) PostServiceOption {
+	return func(s *PostService) {
+		s.processedCommits = processedCommits
+	}
+}
+
+// WithDeadLetters records files that fail to process so they can be reviewed
+func WithDeadLetters(deadLetters domain.DeadLetterRepository) PostServiceOption {
+	return func(s *PostService) {
+		s.deadLetters = deadLetters
+	}
+}
+
+func NewPostService(repo domain.PostRepository, imageRepo domain.ImageRepository, sourceRepo domain.SourceRepository, markdown MarkdownRenderer, mainBranchName string, opts ...PostServiceOption) *PostService {
 	ctx, cancel := context.WithCancel(context.Background())
 	wg := sync.WaitGroup{}
-	return &PostService{
+	s := &PostService{
 		sourceRepo:     sourceRepo,
 		markdown:       markdown,
 		mainBranchName: mainBranchName,
@@ -46,7 +231,74 @@ func NewPostService(repo domain.PostRepository, imageRepo domain.ImageRepository
 		wg:             &wg,
 		repo:           repo,
 		imageRepo:      imageRepo,
+		workers:        make(chan struct{}, defaultSyncWorkers),
+		dispatcher:     newDispatchQueue(),
+		clock:          clock.System,
+		layout:         DefaultContentLayout(),
+		syncOverlap:    defaultSyncOverlap,
+		previewRenders: newBoundedRenderCache(previewRenderLimit),
+		// Previews are only counted until a retention policy is configured
+		previewRetention: &PreviewRetentionConfig{},
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.postID == nil {
+		s.postID = s.layout.PostID
+	}
+
+	s.startedAt = s.clock.Now()
+	s.contentVersion.Store(s.startedAt.UnixNano())
+	s.contentModifiedAt.Store(s.startedAt.UnixNano())
+	if s.postCacheConfig != nil && s.postCacheConfig.Size > 0 {
+		s.postCache = newPostCache(s.postCacheConfig, s.clock)
+		repo = &cachedPostRepository{PostRepository: repo, cache: s.postCache}
+	}
+	hooks := &publishHookRepository{PostRepository: repo}
+	if s.federation != nil {
+		hooks.published = append(hooks.published, s.announcePost)
+		hooks.withdrawn = append(hooks.withdrawn, s.withdrawPost)
+	}
+	if s.newsletter != nil {
+		hooks.published = append(hooks.published, s.queuePostMail)
+	}
+	if s.notifier != nil {
+		hooks.published = append(hooks.published, s.notifyPublished)
+	}
+	if len(hooks.published) > 0 || len(hooks.withdrawn) > 0 {
+		repo = hooks
+	}
+	s.repo = &versionedPostRepository{PostRepository: repo, changed: s.contentChanged}
+	if s.postViews != nil {
+		s.startPostViewWriter()
+	}
+	if s.webmentions != nil {
+		s.startWebmentionVerifier()
+	}
+	if s.federation != nil {
+		s.startFederationDelivery()
+	}
+	if s.newsletter != nil {
+		s.startNewsletterSender()
 	}
+	if s.notifier != nil {
+		s.startNotificationSender()
+	}
+
+	return s
+}
+
+// BeginShutdown marks the service as shutting down, before Close cancels its workers
+// Webhook deliveries arriving after this should be refused, so they are redelivered to the next process
+// rather than accepted and then cut short.
+func (s *PostService) BeginShutdown() {
+	s.shuttingDown.Store(true)
+}
+
+// ShuttingDown reports whether BeginShutdown has been called
+func (s *PostService) ShuttingDown() bool {
+	return s.shuttingDown.Load()
 }
 
 // Close gracefully shuts down the PostService by cancelling all background workers
@@ -57,37 +309,180 @@ func (s *PostService) Close() error {
 	return nil
 }
 
+// StartPublishScheduler periodically publishes merged posts whose publish_at time has arrived
+// The scheduler stops when the service is closed
+func (s *PostService) StartPublishScheduler(interval time.Duration) {
+	s.wg.Go(func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := s.publishDuePosts(s.ctx); err != nil {
+				log.Error().Err(err).Msg("Failed to publish scheduled posts")
+			}
+
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+// publishDuePosts publishes every scheduled post whose publish time has passed
+func (s *PostService) publishDuePosts(ctx context.Context) error {
+	posts, err := s.repo.ListDuePosts(ctx, s.clock.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to list due posts: %w", err)
+	}
+
+	var errs []error
+	for _, post := range posts {
+		if err := s.repo.Publish(ctx, post.ID); err != nil {
+			errs = append(errs, fmt.Errorf("failed to publish post %s: %w", post.ID, err))
+			continue
+		}
+		log.Info().Str("postID", post.ID).Time("publishAt", post.PublishAt).Msg("Published scheduled post")
+	}
+
+	return errors.Join(errs...)
+}
+
+// ListPublishedPosts returns a page of published posts, newest first
+func (s *PostService) ListPublishedPosts(ctx context.Context, limit int, offset int) ([]*domain.Post, error) {
+	return s.repo.ListPublishedPosts(ctx, limit, offset)
+}
+
+// PublishedPosts returns every published post without its HTML, newest first
+func (s *PostService) PublishedPosts(ctx context.Context) ([]*domain.Post, error) {
+	posts, err := s.repo.ListPosts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list posts: %w", err)
+	}
+
+	var published []*domain.Post
+	for _, post := range posts {
+		if !post.PublishedAt.IsZero() {
+			published = append(published, post)
+		}
+	}
+	slices.SortFunc(published, func(a, b *domain.Post) int {
+		return cmp.Or(b.PublishedAt.Compare(a.PublishedAt), cmp.Compare(b.ID, a.ID))
+	})
+	return published, nil
+}
+
+// GetPublishedPost returns a published post along with its rendered HTML
+// Posts that exist but have not been published are reported as not found
+func (s *PostService) GetPublishedPost(ctx context.Context, id string) (*domain.Post, error) {
+	post, err := s.repo.GetPost(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if post.PublishedAt.IsZero() {
+		return nil, fmt.Errorf("%w: %s", domain.ErrPostNotFound, id)
+	}
+
+	content, err := s.repo.GetPostHTML(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	post.HTMLContent = content
+
+	return post, nil
+}
+
 // SyncRepositoryChanges syncs posts from recent commits across all branches
 // This catches any changes that happened while the server was offline
+// The sync is a single job on the dispatch queue, so pushes arriving meanwhile are applied after it.
 func (s *PostService) SyncRepositoryChanges() error {
+	return s.dispatchWait(s.syncRepositoryChanges)
+}
+
+func (s *PostService) syncRepositoryChanges() error {
 	lastUpdatedAt, err := s.repo.GetLatestUpdatedTime(s.ctx)
 	if err != nil {
 		return fmt.Errorf("could not get the time of the last update: %w", err)
 	}
 
+	since := lastUpdatedAt
+	if !since.IsZero() {
+		since = since.Add(-s.syncOverlap)
+	}
+
 	branches, err := s.sourceRepo.ListBranches(s.ctx)
 	if err != nil {
 		return fmt.Errorf("failed to retrieve branches: %w", err)
 	}
 
-	err = s.processBranches(lastUpdatedAt, branches)
+	err = s.processBranches(since, branches)
 	if err != nil {
 		return fmt.Errorf("failed to process branches: %w", err)
 	}
 
+	s.pruneProcessedCommits()
+	s.pruneWebhookDeliveries()
+
 	return nil
 }
 
+// StartInitialSync syncs changes made while the server was offline in the background
+// ready is called once the sync finishes or timeout passes, whichever comes first,
+// so a slow sync delays traffic without blocking it indefinitely.
+func (s *PostService) StartInitialSync(timeout time.Duration, ready func()) {
+	var once sync.Once
+	markReady := func() { once.Do(ready) }
+
+	timer := time.AfterFunc(timeout, func() {
+		log.Warn().Dur("timeout", timeout).Msg("Initial sync still running, reporting ready anyway")
+		markReady()
+	})
+
+	s.wg.Go(func() {
+		start := time.Now()
+		if err := s.SyncRepositoryChanges(); err != nil {
+			log.Error().Err(err).Msg("Initial sync failed")
+		} else {
+			log.Info().Dur("duration", time.Since(start)).Msg("Initial sync completed")
+		}
+
+		timer.Stop()
+		markReady()
+	})
+}
+
+// processBranches syncs branches one at a time, finishing with the main branch
+// Branches write to the same posts, so processing main last guarantees merged content wins.
 func (s *PostService) processBranches(lastUpdatedAt time.Time, branches []*github.Branch) error {
+	ordered := slices.Clone(branches)
+	slices.SortStableFunc(ordered, func(a, b *github.Branch) int {
+		aMain, bMain := a.GetName() == s.mainBranchName, b.GetName() == s.mainBranchName
+		switch {
+		case aMain == bMain:
+			return 0
+		case aMain:
+			return 1
+		default:
+			return -1
+		}
+	})
+
+	renders := newRenderCache()
 	var errs []error
-	for _, b := range branches {
-		err := s.processBranch(lastUpdatedAt, b)
+	for _, b := range ordered {
+		err := s.processBranch(lastUpdatedAt, b, renders)
 		if err != nil {
 			log.Error().Err(err).Str("branch", *b.Name).Msg("Failed to process branch")
 			errs = append(errs, err)
 		}
 	}
 
+	if skipped := renders.skipped(); skipped > 0 {
+		log.Info().Int("skipped", skipped).Msg("Reused renders of files unchanged across branches")
+	}
+
 	if len(errs) > 0 {
 		return fmt.Errorf("encountered %d errors processing branches", len(errs))
 	}
@@ -95,131 +490,201 @@ func (s *PostService) processBranches(lastUpdatedAt time.Time, branches []*githu
 	return nil
 }
 
-func (s *PostService) processBranch(lastUpdatedAt time.Time, branch *github.Branch) error {
+func (s *PostService) processBranch(lastUpdatedAt time.Time, branch *github.Branch, renders *renderCache) error {
 	commits, err := s.sourceRepo.GetCommitsSince(s.ctx, *branch.Name, lastUpdatedAt)
 	if err != nil {
 		return fmt.Errorf("failed to get commits for branch %s: %w", *branch.Name, err)
 	}
 
+	// A windowed sync overlaps the previous one; a full sync from the zero time reprocesses everything
+	if !lastUpdatedAt.IsZero() {
+		commits = s.skipProcessedCommits(branch.GetName(), commits)
+	}
+
 	if len(commits) == 0 {
 		return nil
 	}
 
-	analysisResult, err := s.analyzeCommitFiles(commits)
+	analysisResult, err := s.analyzeBranchCommits(commits)
 	if err != nil {
 		return fmt.Errorf("failed to analyze commits for branch %s: %w", *branch.Name, err)
 	}
 
-	for _, f := range analysisResult.postsToRemove.Items() {
-		err := s.repo.Unpublish(s.ctx, f)
-		if err != nil {
-			return err
+	// When tags or releases publish, files removed from main stay live until the next one
+	if branch.GetName() != s.mainBranchName || s.publishesOnMerge() {
+		for _, f := range analysisResult.postsToRemove {
+			err := s.repo.Unpublish(s.ctx, s.postID(f))
+			if err != nil {
+				return err
+			}
 		}
-	}
 
-	for _, imagePath := range analysisResult.imagesToRemove.Items() {
-		if err := s.removeImage(imagePath); err != nil {
-			return err
+		for _, imagePath := range analysisResult.imagesToRemove {
+			if err := s.removeImage(s.ctx, imagePath); err != nil {
+				return err
+			}
 		}
 	}
 
-	s.upsertPosts(analysisResult.posts, branch)
+	if len(analysisResult.images) > 0 || len(analysisResult.imagesToRemove) > 0 {
+		renders.clear()
+	}
+
+	// Images go first so posts can link them by hash as soon as they are rendered
 	s.processImages(analysisResult.images, branch)
+	s.upsertPosts(analysisResult.posts, branch, renders)
+
+	shas := make([]string, 0, len(commits))
+	for _, c := range commits {
+		shas = append(shas, c.GetSHA())
+	}
+	s.markCommitsProcessed(branch.GetName(), shas)
 
 	return nil
 }
 
-func handleCommitFile(
-	path string,
-	status string,
-	previousPath string,
-	fullCommit *github.RepositoryCommit,
-	filesToProcess map[string]*github.RepositoryCommit,
-	imagesToProcess map[string]*github.RepositoryCommit,
-	filesToRemove set.Set[string],
-	imagesToRemove set.Set[string],
-) (map[string]*github.RepositoryCommit, map[string]*github.RepositoryCommit, set.Set[string], set.Set[string]) {
-	currentIsPost := isPostFile(path)
-	previousIsPost := isPostFile(previousPath)
-	currentIsImage := isImageFile(path)
-	previousIsImage := isImageFile(previousPath)
+// fileChange is a file to process and the commit to process it at
+type fileChange struct {
+	path   string
+	commit *github.RepositoryCommit
+}
+
+// commitAnalysisResult holds the results of analyzing commits, with every list sorted by path
+// It is never modified once built, so it can be shared between goroutines without locking.
+type commitAnalysisResult struct {
+	posts          []fileChange
+	images         []fileChange
+	postsToRemove  []string
+	imagesToRemove []string
+}
+
+// commitAnalyzer collects the file changes of commits into a commitAnalysisResult
+// A file is attributed to the first commit added that changes it.
+// An analyzer belongs to the goroutine analyzing the commits and is discarded once its result is built.
+type commitAnalyzer struct {
+	// isPost and isImage classify paths the way the service's content layout does
+	isPost  func(path string) bool
+	isImage func(path string) bool
+
+	posts          map[string]*github.RepositoryCommit
+	images         map[string]*github.RepositoryCommit
+	postsToRemove  set.Set[string]
+	imagesToRemove set.Set[string]
+}
+
+func (s *PostService) newCommitAnalyzer() *commitAnalyzer {
+	return &commitAnalyzer{
+		isPost:         s.isPostFile,
+		isImage:        s.isImageFile,
+		posts:          make(map[string]*github.RepositoryCommit),
+		images:         make(map[string]*github.RepositoryCommit),
+		postsToRemove:  set.New[string](),
+		imagesToRemove: set.New[string](),
+	}
+}
+
+// add records a file changed by commit with the given status, and the path it was renamed from if any
+func (a *commitAnalyzer) add(path string, status string, previousPath string, commit *github.RepositoryCommit) {
+	currentIsPost := a.isPost(path)
+	previousIsPost := a.isPost(previousPath)
+	currentIsImage := a.isImage(path)
+	previousIsImage := a.isImage(previousPath)
 
 	if !currentIsPost && !previousIsPost && !currentIsImage && !previousIsImage {
-		return filesToProcess, imagesToProcess, filesToRemove, imagesToRemove
+		return
 	}
 
 	switch status {
 	case "added", "modified":
 		if currentIsPost {
-			if _, exists := filesToProcess[path]; !exists {
-				filesToProcess[path] = fullCommit
+			if _, exists := a.posts[path]; !exists {
+				a.posts[path] = commit
 			}
-			filesToRemove.Remove(path)
+			a.postsToRemove.Remove(path)
 		}
 		if currentIsImage {
-			if _, exists := imagesToProcess[path]; !exists {
-				imagesToProcess[path] = fullCommit
+			if _, exists := a.images[path]; !exists {
+				a.images[path] = commit
 			}
-			imagesToRemove.Remove(path)
+			a.imagesToRemove.Remove(path)
 		}
 	case "removed":
 		if currentIsPost {
-			if _, exists := filesToProcess[path]; !exists {
-				filesToRemove.Add(path)
+			if _, exists := a.posts[path]; !exists {
+				a.postsToRemove.Add(path)
 			}
-			delete(filesToProcess, path)
+			delete(a.posts, path)
 		}
 		if currentIsImage {
-			if _, exists := imagesToProcess[path]; !exists {
-				imagesToRemove.Add(path)
+			if _, exists := a.images[path]; !exists {
+				a.imagesToRemove.Add(path)
 			}
-			delete(imagesToProcess, path)
+			delete(a.images, path)
 		}
 	case "renamed":
 		if previousIsPost {
-			if _, exists := filesToProcess[previousPath]; !exists {
-				filesToRemove.Add(previousPath)
+			if _, exists := a.posts[previousPath]; !exists {
+				a.postsToRemove.Add(previousPath)
 			}
-			delete(filesToProcess, previousPath)
+			delete(a.posts, previousPath)
 		}
 		if currentIsPost {
-			if _, exists := filesToProcess[path]; !exists {
-				filesToProcess[path] = fullCommit
+			if _, exists := a.posts[path]; !exists {
+				a.posts[path] = commit
 			}
-			filesToRemove.Remove(previousPath)
+			a.postsToRemove.Remove(previousPath)
 		}
 		if previousIsImage {
-			if _, exists := imagesToProcess[previousPath]; !exists {
-				imagesToRemove.Add(previousPath)
+			if _, exists := a.images[previousPath]; !exists {
+				a.imagesToRemove.Add(previousPath)
 			}
-			delete(imagesToProcess, previousPath)
+			delete(a.images, previousPath)
 		}
 		if currentIsImage {
-			if _, exists := imagesToProcess[path]; !exists {
-				imagesToProcess[path] = fullCommit
+			if _, exists := a.images[path]; !exists {
+				a.images[path] = commit
 			}
-			imagesToRemove.Remove(path)
+			a.imagesToRemove.Remove(path)
 		}
 	}
+}
 
-	return filesToProcess, imagesToProcess, filesToRemove, imagesToRemove
+// result returns the changes collected so far as sorted lists
+func (a *commitAnalyzer) result() *commitAnalysisResult {
+	return &commitAnalysisResult{
+		posts:          sortedFileChanges(a.posts),
+		images:         sortedFileChanges(a.images),
+		postsToRemove:  sortedItems(a.postsToRemove),
+		imagesToRemove: sortedItems(a.imagesToRemove),
+	}
 }
 
-// commitAnalysisResult holds the results of analyzing commits
-type commitAnalysisResult struct {
-	posts          map[string]*github.RepositoryCommit
-	images         map[string]*github.RepositoryCommit
-	postsToRemove  set.Set[string]
-	imagesToRemove set.Set[string]
+func sortedItems(items set.Set[string]) []string {
+	sorted := items.Items()
+	slices.Sort(sorted)
+	return sorted
+}
+
+// changedPaths returns the path of every change
+func changedPaths(changes []fileChange) []string {
+	paths := make([]string, 0, len(changes))
+	for _, change := range changes {
+		paths = append(paths, change.path)
+	}
+	return paths
+}
+
+func sortedFileChanges(files map[string]*github.RepositoryCommit) []fileChange {
+	changes := make([]fileChange, 0, len(files))
+	for _, path := range slices.Sorted(maps.Keys(files)) {
+		changes = append(changes, fileChange{path: path, commit: files[path]})
+	}
+	return changes
 }
 
 // analyzeCommitFiles iterates through commits to determine which files were changed and which were removed.
 func (s *PostService) analyzeCommitFiles(commits []*github.RepositoryCommit) (*commitAnalysisResult, error) {
-	posts := make(map[string]*github.RepositoryCommit)
-	images := make(map[string]*github.RepositoryCommit)
-	postsToRemove := set.New[string]()
-	imagesToRemove := set.New[string]()
-
+	analyzer := s.newCommitAnalyzer()
 	for _, commitSummary := range commits {
 		fullCommit, err := s.sourceRepo.GetCommit(s.ctx, *commitSummary.SHA)
 		if err != nil {
@@ -227,39 +692,89 @@ func (s *PostService) analyzeCommitFiles(commits []*github.RepositoryCommit) (*c
 		}
 
 		for _, file := range fullCommit.Files {
-			posts, images, postsToRemove, imagesToRemove = handleCommitFile(
-				file.GetFilename(),
-				file.GetStatus(),
-				file.GetPreviousFilename(),
-				fullCommit,
-				posts,
-				images,
-				postsToRemove,
-				imagesToRemove,
-			)
+			analyzer.add(file.GetFilename(), file.GetStatus(), file.GetPreviousFilename(), fullCommit)
 		}
 	}
 
-	return &commitAnalysisResult{
-		posts:          posts,
-		images:         images,
-		postsToRemove:  postsToRemove,
-		imagesToRemove: imagesToRemove,
-	}, nil
+	return analyzer.result(), nil
+}
+
+// analyzeBranchCommits analyzes commits listed newest first, as returned for a branch
+// A large backlog is analyzed with a single compare call from the parent of the oldest commit
+func (s *PostService) analyzeBranchCommits(commits []*github.RepositoryCommit) (*commitAnalysisResult, error) {
+	oldest := commits[len(commits)-1]
+	if len(commits) <= maxIndividualCommits || len(oldest.Parents) == 0 {
+		return s.analyzeCommitFiles(commits)
+	}
+
+	headSHA := commits[0].GetSHA()
+	comparison, err := s.sourceRepo.CompareCommits(s.ctx, oldest.Parents[0].GetSHA(), headSHA)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare commits: %w", err)
+	}
+
+	return s.analyzeComparison(comparison, oldest.Parents[0].GetSHA(), headSHA)
+}
+
+// analyzeComparison determines changed files from a compare result
+// Small ranges are still analyzed commit by commit so each file is attributed to the commit that last changed it.
+// Larger ranges use the net file changes of the comparison, attributing every file to the head commit.
+// When the comparison lists too many files to be complete, the trees of base and head are diffed instead,
+// falling back to fetching each commit only if the trees can't stand in for them.
+func (s *PostService) analyzeComparison(comparison *github.CommitsComparison, baseSHA string, headSHA string) (*commitAnalysisResult, error) {
+	commits := comparison.Commits
+	if len(comparison.Files) >= compareFileLimit {
+		result, ok, err := s.analyzeRangeTrees(baseSHA, headSHA)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return result, nil
+		}
+		log.Debug().Str("base", baseSHA).Str("head", headSHA).Msg("Trees can't stand in for the commits, analyzing each commit")
+		return s.analyzeCommitFiles(commits)
+	}
+	if len(commits) <= maxIndividualCommits {
+		return s.analyzeCommitFiles(commits)
+	}
+
+	// The compare API lists at most 250 commits, so the head may be missing from a long range
+	headCommit := commits[len(commits)-1]
+	if headCommit.GetSHA() != headSHA {
+		var err error
+		headCommit, err = s.sourceRepo.GetCommit(s.ctx, headSHA)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get head commit %s: %w", headSHA, err)
+		}
+	}
+
+	analyzer := s.newCommitAnalyzer()
+	for _, file := range comparison.Files {
+		analyzer.add(file.GetFilename(), file.GetStatus(), file.GetPreviousFilename(), headCommit)
+	}
+
+	return analyzer.result(), nil
 }
 
-// upsertPosts processes and upserts posts from the given filesToProcess map
-func (s *PostService) upsertPosts(filesToProcess map[string]*github.RepositoryCommit, branch *github.Branch) error {
-	ref := "refs/heads/" + *branch.Name
-	isMainBranch := ref == "refs/heads/"+s.mainBranchName
+// upsertPosts processes and upserts the given post files
+// Renders are reused from renders when another branch already rendered the same file version.
+func (s *PostService) upsertPosts(files []fileChange, branch *github.Branch, renders *renderCache) error {
+	collisions := s.postIDCollisions(s.ctx, changedPaths(files))
 
-	for path, commit := range filesToProcess {
-		postID := extractPostID(path)
+	forEachBounded(s, files, func(file fileChange) {
+		path, commit := file.path, file.commit
+		postID := s.postID(path)
 		if postID == "" {
-			continue
+			return
 		}
 
-		modifiedAt := commit.GetCommit().GetAuthor().GetDate().Time
+		if err, ok := collisions[path]; ok {
+			log.Error().Err(err).Str("path", path).Str("branch", branch.GetName()).Msg("Refusing to process post")
+			s.recordFileResult(path, commit.GetSHA(), err)
+			return
+		}
+
+		modifiedAt := s.commitTime(commit, time.Time{})
 
 		existingPost, err := s.repo.GetPost(s.ctx, postID)
 		createdAt := modifiedAt
@@ -269,85 +784,208 @@ func (s *PostService) upsertPosts(filesToProcess map[string]*github.RepositoryCo
 
 		fileInfo := commitFileInfo{
 			path:       path,
+			blobSHA:    fileBlobSHA(commit, path),
 			createdAt:  createdAt,
 			modifiedAt: modifiedAt,
 		}
 
-		capturedPostID := postID
-		capturedFileInfo := fileInfo
 		// Use the commit SHA instead of ref to get the exact file version
-		capturedCommitSHA := commit.GetSHA()
-
-		s.processPostFile(
-			s.ctx,
-			capturedPostID,
-			capturedFileInfo,
-			capturedCommitSHA,
-			isMainBranch,
-		)
-	}
+		commitSHA := commit.GetSHA()
+
+		err = s.processFile(s.ctx, path, commitSHA, func(ctx context.Context) error {
+			return s.processPostFile(
+				ctx,
+				postID,
+				fileInfo,
+				commitSHA,
+				branch.GetName(),
+				renders,
+			)
+		})
+		if err != nil {
+			log.Error().Err(err).Str("path", path).Str("branch", branch.GetName()).Msg("Failed to process post")
+		}
+		s.recordFileResult(path, commitSHA, err)
+	})
 
 	return nil
 }
 
 // HandlePushEvent processes a GitHub push event and updates posts accordingly
-// This method returns immediately after validating the event and spawning async workers
+// This method returns once the push is planned, queueing its files on the dispatch queue
 // Workers use the service's lifecycle context, not the request context
-func (s *PostService) HandlePushEvent(evt *github.PushEvent) error {
-	// Get all commits in the push range
-	var commits []*github.RepositoryCommit
-	var err error
-
-	if evt.GetBefore() != "" && evt.GetBefore() != "0000000000000000000000000000000000000000" {
-		// Normal push with a base commit - get the range
-		commits, err = s.sourceRepo.GetCommitsInRange(s.ctx, evt.GetBefore(), evt.GetAfter())
+// The returned job ID can be used to follow processing; it is zero when job tracking is disabled
+func (s *PostService) HandlePushEvent(evt *github.PushEvent) (int64, error) {
+	jobID, err := s.startSyncJob(evt.GetRef(), evt.GetBefore(), evt.GetAfter())
+	if err != nil {
+		return 0, err
+	}
+
+	tasks, err := s.planPushEvent(evt)
+	if err != nil {
+		s.failSyncJob(jobID, err)
+		return jobID, err
+	}
+
+	s.addSyncJobFiles(jobID, tasks)
+
+	// The push's commits count as processed once every file has been handled
+	// Failed files are retried from the dead letter queue rather than by the next sync.
+	branch, isBranch := strings.CutPrefix(evt.GetRef(), "refs/heads/")
+	markProcessed := func() {
+		if isBranch {
+			s.markCommitsProcessed(branch, pushCommitSHAs(evt))
+		}
+	}
+	s.runSyncTasks(jobID, tasks, markProcessed)
+
+	return jobID, nil
+}
+
+// runSyncTasks queues a job's tasks on the dispatch queue without waiting for them
+// Once the jobs queued before it finish, the tasks run on the worker pool. done, if set, is called once every
+// task has run, before the next job starts.
+func (s *PostService) runSyncTasks(jobID int64, tasks []syncTask, done func()) {
+	s.savePendingRenders(tasks)
+
+	s.dispatch(func() {
+		forEachBounded(s, tasks, func(task syncTask) {
+			err := s.processFile(s.ctx, task.file.Path, task.file.CommitSHA, task.run)
+			if err != nil {
+				log.Error().Err(err).Str("path", task.file.Path).Str("action", string(task.file.Action)).Msg("Failed to process file")
+			}
+			s.clearPendingRender(task, err)
+			s.completeSyncJobFile(jobID, task.file.Path, err)
+			s.recordFileResult(task.file.Path, task.file.CommitSHA, err)
+		})
+		if done != nil {
+			done()
+		}
+	})
+}
+
+// pushCommitSHAs returns the SHAs of the commits a push event delivered
+func pushCommitSHAs(evt *github.PushEvent) []string {
+	shas := make([]string, 0, len(evt.Commits)+1)
+	for _, c := range evt.Commits {
+		shas = append(shas, c.GetID())
+	}
+	if after := evt.GetAfter(); after != "" && after != zeroSHA && !slices.Contains(shas, after) {
+		shas = append(shas, after)
+	}
+	return shas
+}
+
+// syncTask is a unit of work for a single file in a push
+type syncTask struct {
+	file domain.SyncJobFile
+	run  func(ctx context.Context) error
+	// render describes the task so it can be run again if interrupted, or is nil for tasks that are not recovered
+	render *domain.PendingRender
+}
+
+// planPushEvent analyzes the commits in a push and returns the work needed to apply it
+func (s *PostService) planPushEvent(evt *github.PushEvent) ([]syncTask, error) {
+	if evt.GetDeleted() || evt.GetAfter() == zeroSHA {
+		return s.planBranchDeletion(evt)
+	}
+
+	branch, isBranch := strings.CutPrefix(evt.GetRef(), "refs/heads/")
+	if !isBranch {
+		// Tags are pushed as refs too; they only matter when they publish, through their create event
+		log.Debug().Str("ref", evt.GetRef()).Msg("Ignoring push to a ref that is not a branch")
+		return nil, nil
+	}
+
+	// Analyze all commits in the push range to determine which files to process
+	var analysisResult *commitAnalysisResult
+
+	if evt.GetBefore() != "" && evt.GetBefore() != zeroSHA && evt.GetForced() {
+		// The old head was replaced rather than extended, so compare what the two heads contain
+		var err error
+		analysisResult, err = s.analyzeTreeDiff(branch, evt.GetBefore(), evt.GetAfter())
+		if err != nil {
+			return nil, fmt.Errorf("failed to analyze force push: %w", err)
+		}
+	} else if evt.GetBefore() != "" && evt.GetBefore() != zeroSHA {
+		// Normal push with a base commit - compare the range
+		comparison, err := s.sourceRepo.CompareCommits(s.ctx, evt.GetBefore(), evt.GetAfter())
+		if err != nil {
+			return nil, fmt.Errorf("failed to get commits in range %s...%s: %w", evt.GetBefore(), evt.GetAfter(), err)
+		}
+
+		analysisResult, err = s.analyzeComparison(comparison, evt.GetBefore(), evt.GetAfter())
 		if err != nil {
-			return fmt.Errorf("failed to get commits in range %s...%s: %w", evt.GetBefore(), evt.GetAfter(), err)
+			return nil, fmt.Errorf("failed to analyze commits: %w", err)
 		}
 	} else {
 		// New branch or first commit - just get the head commit
 		headCommit, err := s.sourceRepo.GetCommit(s.ctx, evt.GetAfter())
 		if err != nil {
-			return fmt.Errorf("failed to get commit %s: %w", evt.GetAfter(), err)
+			return nil, fmt.Errorf("failed to get commit %s: %w", evt.GetAfter(), err)
 		}
-		commits = []*github.RepositoryCommit{headCommit}
-	}
 
-	// Analyze all commits to determine which files to process
-	analysisResult, err := s.analyzeCommitFiles(commits)
-	if err != nil {
-		return fmt.Errorf("failed to analyze commits: %w", err)
+		analysisResult, err = s.analyzeCommitFiles([]*github.RepositoryCommit{headCommit})
+		if err != nil {
+			return nil, fmt.Errorf("failed to analyze commits: %w", err)
+		}
 	}
 
-	ref := evt.GetRef()
-	isMainBranch := ref == "refs/heads/"+s.mainBranchName
+	isMainBranch := branch == s.mainBranchName
 
-	if isMainBranch {
-		for _, filePath := range analysisResult.postsToRemove.Items() {
-			capturedPath := filePath
-			s.wg.Go(func() {
-				if err := s.repo.Unpublish(s.ctx, capturedPath); err != nil {
-					log.Error().Err(err).Str("path", capturedPath).Msg("Failed to unpublish post")
-				}
+	// GitHub sends the push time with the event; fall back to when it arrived
+	pushedAt := evt.GetRepo().GetPushedAt().Time
+	if pushedAt.IsZero() {
+		pushedAt = s.clock.Now()
+	}
+
+	var tasks []syncTask
+
+	// When tags or releases publish, files removed from main stay live until the next one
+	if isMainBranch && s.publishesOnMerge() {
+		for _, filePath := range analysisResult.postsToRemove {
+			postID := s.postID(filePath)
+			tasks = append(tasks, syncTask{
+				file: domain.SyncJobFile{Path: filePath, CommitSHA: evt.GetAfter(), Action: domain.SyncActionRemove},
+				run: func(ctx context.Context) error {
+					return s.repo.Unpublish(ctx, postID)
+				},
+				render: pendingRemoval(filePath, evt.GetAfter(), branch),
 			})
 		}
 
-		for _, imagePath := range analysisResult.imagesToRemove.Items() {
-			capturedPath := imagePath
-			s.wg.Go(func() {
-				s.removeImage(capturedPath)
+		for _, imagePath := range analysisResult.imagesToRemove {
+			tasks = append(tasks, syncTask{
+				file: domain.SyncJobFile{Path: imagePath, CommitSHA: evt.GetAfter(), Action: domain.SyncActionRemove},
+				run: func(ctx context.Context) error {
+					return s.removeImage(ctx, imagePath)
+				},
+				render: pendingRemoval(imagePath, evt.GetAfter(), ""),
 			})
 		}
 	}
 
 	// Process post additions/modifications
-	for filePath, commit := range analysisResult.posts {
-		postID := extractPostID(filePath)
+	collisions := s.postIDCollisions(s.ctx, changedPaths(analysisResult.posts))
+	for _, change := range analysisResult.posts {
+		filePath, commit := change.path, change.commit
+		postID := s.postID(filePath)
 		if postID == "" {
 			continue
 		}
 
-		modifiedAt := commit.GetCommit().GetAuthor().GetDate().Time
+		// Colliding files still become tasks, so the conflict shows up in the sync job and dead letters
+		if collisionErr, ok := collisions[filePath]; ok {
+			tasks = append(tasks, syncTask{
+				file: domain.SyncJobFile{Path: filePath, CommitSHA: commit.GetSHA(), Action: domain.SyncActionUpsert},
+				run: func(ctx context.Context) error {
+					return collisionErr
+				},
+			})
+			continue
+		}
+
+		modifiedAt := s.commitTime(commit, pushedAt)
 
 		existingPost, err := s.repo.GetPost(s.ctx, postID)
 		createdAt := modifiedAt
@@ -357,38 +995,37 @@ func (s *PostService) HandlePushEvent(evt *github.PushEvent) error {
 
 		fileInfo := commitFileInfo{
 			path:       filePath,
+			blobSHA:    fileBlobSHA(commit, filePath),
 			createdAt:  createdAt,
 			modifiedAt: modifiedAt,
 		}
 
-		// Capture variables for goroutine
-		capturedPostID := postID
-		capturedFileInfo := fileInfo
 		// Use the commit SHA instead of ref to get the exact file version
-		capturedCommitSHA := commit.GetSHA()
-
-		s.wg.Go(func() {
-			s.processPostFile(
-				s.ctx,
-				capturedPostID,
-				capturedFileInfo,
-				capturedCommitSHA,
-				isMainBranch,
-			)
+		commitSHA := commit.GetSHA()
+
+		tasks = append(tasks, syncTask{
+			file: domain.SyncJobFile{Path: filePath, CommitSHA: commitSHA, Action: domain.SyncActionUpsert},
+			run: func(ctx context.Context) error {
+				return s.processPostFile(ctx, postID, fileInfo, commitSHA, branch, nil)
+			},
+			render: pendingRender(fileInfo, commitSHA, branch),
 		})
 	}
 
 	// Process image additions/modifications
-	for imagePath, commit := range analysisResult.images {
-		capturedPath := imagePath
-		capturedCommitSHA := commit.GetSHA()
-
-		s.wg.Go(func() {
-			s.processImageFile(s.ctx, capturedPath, capturedCommitSHA)
+	for _, change := range analysisResult.images {
+		imagePath, commitSHA := change.path, change.commit.GetSHA()
+
+		tasks = append(tasks, syncTask{
+			file: domain.SyncJobFile{Path: imagePath, CommitSHA: commitSHA, Action: domain.SyncActionUpsert},
+			run: func(ctx context.Context) error {
+				return s.processImageFile(ctx, imagePath, commitSHA)
+			},
+			render: pendingImageRender(imagePath, commitSHA),
 		})
 	}
 
-	return nil
+	return tasks, nil
 }
 
 // processPostFile processes a single post file
@@ -398,18 +1035,57 @@ func (s *PostService) processPostFile(
 	postID string,
 	fileInfo commitFileInfo,
 	commitSHA string,
-	isMainBranch bool,
-) {
-	markdownContent, err := s.sourceRepo.GetFileContents(ctx, fileInfo.path, commitSHA)
-	if err != nil {
-		log.Error().Err(err).Str("path", fileInfo.path).Str("commitSHA", commitSHA).Msg("Failed to get file contents")
-		return
+	branch string,
+	renders *renderCache,
+) error {
+	isMainBranch := branch == s.mainBranchName
+	if isMainBranch && !fileInfo.released && !s.publishesOnMerge() {
+		log.Debug().Str("postID", postID).Str("commit", commitSHA).Msg("Merged post waits for a tag or release")
+		return nil
 	}
 
-	result, err := s.markdown.Render(markdownContent)
+	// Redelivered pushes and overlapping syncs can process the same version concurrently; one run is enough.
+	// A release still has to publish the version, so it waits for the other run instead.
+	key := postVersionKey(branch, commitSHA, fileInfo.path)
+	if fileInfo.released {
+		if err := s.awaitPostVersion(ctx, key); err != nil {
+			return err
+		}
+	} else if _, busy := s.postVersions.LoadOrStore(key, struct{}{}); busy {
+		log.Debug().Str("postID", postID).Str("commit", commitSHA).Msg("Post version is already being processed")
+		return nil
+	}
+	defer s.postVersions.Delete(key)
+	defer s.postLocks.lock(postID)()
+
+	// publishedAt is kept when this exact version was already applied, so processing it again never republishes
+	var publishedAt time.Time
+	var previous *domain.Post
+	if existing, err := s.repo.GetPost(ctx, postID); err == nil {
+		if err := s.checkPostOwner(ctx, existing, fileInfo.path, commitSHA); err != nil {
+			return err
+		}
+		// Only a post that looks newer by its commit time can be a newer version, which the history confirms
+		if existing.Branch == branch && existing.CommitSHA != "" && existing.CommitSHA != commitSHA &&
+			existing.UpdatedAt.After(fileInfo.modifiedAt) && s.supersededBy(ctx, commitSHA, existing.CommitSHA) {
+			log.Info().Str("postID", postID).Str("commit", commitSHA).Str("current", existing.CommitSHA).Msg("Skipping older version of post")
+			return nil
+		}
+		if postVersionKey(existing.Branch, existing.CommitSHA, existing.SourcePath) == key {
+			publishedAt = existing.PublishedAt
+		}
+		previous = existing
+	}
+
+	result, err := s.renderPostFile(ctx, fileInfo, commitSHA, renders)
 	if err != nil {
-		log.Error().Err(err).Str("path", fileInfo.path).Msg("Failed to render markdown")
-		return
+		return err
+	}
+	for _, issue := range result.FrontMatter {
+		log.Warn().Str("postID", postID).Str("path", fileInfo.path).Int("line", issue.Line).Msg("Front matter: " + issue.Message)
+	}
+	if !isMainBranch {
+		s.previewRenders.put(fileInfo.path, fileInfo.blobSHA, result)
 	}
 
 	// Derive HTML filename from post ID
@@ -421,103 +1097,203 @@ func (s *PostService) processPostFile(
 		Snippet:     result.Snippet,
 		HTMLPath:    htmlFilename,
 		HTMLContent: result.HTMLContent,
+		SourcePath:  fileInfo.path,
+		Branch:      branch,
+		CommitSHA:   commitSHA,
+		Images:      result.Images,
 		UpdatedAt:   fileInfo.modifiedAt,
 		CreatedAt:   fileInfo.createdAt,
+
+		WordCount:      result.WordCount,
+		ReadingMinutes: result.ReadingMinutes,
+		TOC:            result.TOC,
+		Language:       result.Language,
+		Document:       result.Document,
+	}
+	if err := s.assignSlug(ctx, post); err != nil {
+		return err
 	}
 
+	// Only merged posts can be scheduled; drafts on other branches are never published
+	scheduled := isMainBranch && result.PublishAt.After(s.clock.Now())
+	if isMainBranch {
+		post.PublishAt = result.PublishAt
+	}
+	if !scheduled {
+		post.PublishedAt = publishedAt
+	}
+	s.signPost(post)
+
 	err = s.repo.SavePost(ctx, post)
 	if err != nil {
-		log.Error().Err(err).Str("postID", postID).Msg("Failed to save post")
-		return
+		return fmt.Errorf("failed to save post %s: %w", postID, err)
 	}
+	s.recordSlugChange(ctx, previous, post)
 
-	if isMainBranch {
+	if scheduled {
+		log.Info().Str("postID", postID).Time("publishAt", result.PublishAt).Msg("Post scheduled for publication")
+		return nil
+	}
+
+	if isMainBranch && post.PublishedAt.IsZero() {
 		err = s.repo.Publish(ctx, postID)
 		if err != nil {
-			log.Error().Err(err).Str("postID", postID).Msg("Failed to publish post")
-			return
+			return fmt.Errorf("failed to publish post %s: %w", postID, err)
 		}
 	}
+
+	return nil
+}
+
+// postVersionKey identifies a version of a post file by the branch and commit it was rendered from and its path
+func postVersionKey(branch string, commitSHA string, path string) string {
+	return branch + "@" + commitSHA + ":" + path
+}
+
+// renderPostFile fetches and renders a post file, reusing a render of the same file version if renders has one
+// A version already rendered for a preview branch is promoted as it is, so a branch merged without changes to
+// its posts publishes without fetching them again.
+func (s *PostService) renderPostFile(ctx context.Context, fileInfo commitFileInfo, commitSHA string, renders *renderCache) (*MarkdownProcessingResult, error) {
+	if result, ok := renders.get(fileInfo.path, fileInfo.blobSHA); ok {
+		return result, nil
+	}
+	if result, ok := s.previewRenders.get(fileInfo.path, fileInfo.blobSHA); ok {
+		log.Debug().Str("path", fileInfo.path).Str("commit", commitSHA).Msg("Promoting preview render")
+		renders.put(fileInfo.path, fileInfo.blobSHA, result)
+		return result, nil
+	}
+
+	markdownContent, err := s.sourceRepo.GetFileContents(ctx, fileInfo.path, commitSHA)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file contents at %s: %w", commitSHA, err)
+	}
+
+	result, err := s.markdown.Render(markdownContent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render markdown: %w", err)
+	}
+
+	renders.put(fileInfo.path, fileInfo.blobSHA, result)
+	return result, nil
 }
 
 // commitFileInfo tracks when a file was first created and last modified in a push
 type commitFileInfo struct {
-	path       string
+	path string
+	// blobSHA is the git blob SHA of the file version, or "" when it is not known
+	blobSHA    string
 	createdAt  time.Time
 	modifiedAt time.Time
+	// released is set when the file is processed for a tag or release, which publishes it in those publish modes
+	released bool
 }
 
-// isPostFile checks if a file path is a valid post file in the posts/ directory
-// Valid format: posts/NNN-title-of-post.md where NNN is one or more digits
-func isPostFile(path string) bool {
-	return postPathRegex.MatchString(path)
+// isPostFile checks if a file path is a post file with an ID where the layout keeps posts
+// Valid format by default: posts/NNN-title-of-post.md where NNN is one or more digits
+func (s *PostService) isPostFile(path string) bool {
+	return s.layout.isPostPath(path) && s.postID(path) != ""
 }
 
-// extractPostID extracts the numeric ID from a post filename
-// Example: "posts/001-my-post.md" -> "001"
-func extractPostID(path string) string {
-	matches := postPathRegex.FindStringSubmatch(path)
-	if len(matches) < 2 {
-		return ""
-	}
-	return matches[1]
-}
+// PostIDFunc derives a post's ID from its source path, returning "" if the path has no ID
+type PostIDFunc func(path string) string
 
-// isImageFile checks if a file path is a valid image file in the images/ directory
-func isImageFile(path string) bool {
-	return imagePathRegex.MatchString(path)
+// isImageFile checks if a file path is an image file in the layout's images directory
+func (s *PostService) isImageFile(path string) bool {
+	return s.layout.isImageFile(path)
 }
 
-// processImages processes multiple image files synchronously
-func (s *PostService) processImages(imagesToProcess map[string]*github.RepositoryCommit, branch *github.Branch) {
-	for imagePath, commit := range imagesToProcess {
-		s.processImageFile(s.ctx, imagePath, commit.GetSHA())
-	}
+// processImages processes multiple image files on the worker pool, returning once all are done
+func (s *PostService) processImages(images []fileChange, branch *github.Branch) {
+	forEachBounded(s, images, func(image fileChange) {
+		imagePath, commitSHA := image.path, image.commit.GetSHA()
+		err := s.processFile(s.ctx, imagePath, commitSHA, func(ctx context.Context) error {
+			return s.processImageFile(ctx, imagePath, commitSHA)
+		})
+		if err != nil {
+			log.Error().Err(err).Str("path", imagePath).Str("branch", branch.GetName()).Msg("Failed to process image")
+		}
+		s.recordFileResult(imagePath, commitSHA, err)
+	})
 }
 
 // processImageFile downloads and saves an image file from the repository
 // The repository handles both database and filesystem persistence transactionally
-func (s *PostService) processImageFile(ctx context.Context, imagePath string, commitSHA string) {
+func (s *PostService) processImageFile(ctx context.Context, imagePath string, commitSHA string) error {
 	imageContent, err := s.sourceRepo.GetFileContents(ctx, imagePath, commitSHA)
 	if err != nil {
-		log.Error().Err(err).Str("path", imagePath).Str("commitSHA", commitSHA).Msg("Failed to get image contents")
-		return
+		return fmt.Errorf("failed to get image contents at %s: %w", commitSHA, err)
+	}
+
+	// Images are served with the type their extension names, so content of another type is never stored
+	if err := checkImageContent(imagePath, imageContent); err != nil {
+		return err
 	}
 
 	// Calculate hash of the image content
 	hash := calculateHash(imageContent)
 
 	// Check if image exists and has the same hash
+	// An unchanged image stored before perceptual hashes were recorded is saved again to add its own
 	existingImage, err := s.imageRepo.GetImage(ctx, imagePath)
-	if err == nil && existingImage.Hash == hash {
+	unchanged := err == nil && existingImage.Hash == hash
+	if unchanged && existingImage.PerceptualHash != "" {
 		log.Debug().Str("path", imagePath).Str("hash", hash).Msg("Image unchanged, skipping")
-		return
+		return nil
+	}
+
+	perceptual := perceptualHash(imageContent)
+	if unchanged && perceptual == "" {
+		log.Debug().Str("path", imagePath).Str("hash", hash).Msg("Image unchanged, skipping")
+		return nil
+	}
+
+	if s.imageQuota != nil && !unchanged {
+		if err := s.imageQuota.CheckImageQuota(int64(len(imageContent))); err != nil {
+			return fmt.Errorf("refusing to ingest image: %w", err)
+		}
 	}
 
 	// Save image (repository handles transaction)
-	now := time.Now().UTC()
+	now := s.clock.Now().UTC()
 	img := &domain.Image{
-		Path:      imagePath,
-		Hash:      hash,
-		Content:   imageContent,
-		UpdatedAt: now,
-		CreatedAt: now,
+		Path:           imagePath,
+		Hash:           hash,
+		PerceptualHash: perceptual,
+		Content:        imageContent,
+		UpdatedAt:      now,
+		CreatedAt:      now,
+	}
+	if unchanged {
+		img.CreatedAt, img.UpdatedAt = existingImage.CreatedAt, existingImage.UpdatedAt
 	}
 
 	if err := s.imageRepo.SaveImage(ctx, img); err != nil {
-		log.Error().Err(err).Str("path", imagePath).Msg("Failed to save image")
-		return
+		return fmt.Errorf("failed to save image: %w", err)
+	}
+	s.warnSimilarImages(ctx, img)
+
+	if unchanged {
+		log.Info().Str("path", imagePath).Str("perceptualHash", perceptual).Msg("Recorded perceptual hash of image")
+		return nil
+	}
+
+	// Posts link images by hash, so they must be re-rendered to pick up the new content
+	s.previewRenders.clear()
+	if err := s.refreshPostsForImage(ctx, imagePath); err != nil {
+		return fmt.Errorf("failed to refresh posts referencing image: %w", err)
 	}
 
 	log.Info().Str("path", imagePath).Str("hash", hash).Msg("Image processed successfully")
+	return nil
 }
 
 // removeImage deletes an image file from both filesystem and database
 // The repository handles both operations transactionally
-func (s *PostService) removeImage(imagePath string) error {
-	if err := s.imageRepo.DeleteImage(s.ctx, imagePath); err != nil {
+func (s *PostService) removeImage(ctx context.Context, imagePath string) error {
+	if err := s.imageRepo.DeleteImage(ctx, imagePath); err != nil {
 		return err
 	}
+	s.previewRenders.clear()
 
 	log.Info().Str("path", imagePath).Msg("Image removed successfully")
 	return nil
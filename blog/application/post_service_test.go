@@ -1,7 +1,16 @@
 package application
 
 import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
 	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/clock"
+	"github.com/google/go-github/v75/github"
 )
 
 func TestIsPostFile(t *testing.T) {
@@ -44,9 +53,9 @@ func TestIsPostFile(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := isPostFile(tt.path)
+			result := DefaultContentLayout().PostID(tt.path) != ""
 			if result != tt.expected {
-				t.Errorf("isPostFile(%q) = %v, want %v", tt.path, result, tt.expected)
+				t.Errorf("is post file %q = %v, want %v", tt.path, result, tt.expected)
 			}
 		})
 	}
@@ -122,7 +131,7 @@ func TestIsImageFile(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := isImageFile(tt.path)
+			result := DefaultContentLayout().isImageFile(tt.path)
 			if result != tt.expected {
 				t.Errorf("isImageFile(%q) = %v, want %v", tt.path, result, tt.expected)
 			}
@@ -165,9 +174,9 @@ func TestExtractPostID(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := extractPostID(tt.path)
+			result := DefaultContentLayout().PostID(tt.path)
 			if result != tt.expected {
-				t.Errorf("extractPostID(%q) = %q, want %q", tt.path, result, tt.expected)
+				t.Errorf("PostID(%q) = %q, want %q", tt.path, result, tt.expected)
 			}
 		})
 	}
@@ -210,3 +219,255 @@ func TestCalculateHash(t *testing.T) {
 		})
 	}
 }
+
+func TestPostService_PublishDuePosts(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	repo := newFakePostRepository(
+		&domain.Post{ID: "001", PublishAt: now.Add(-time.Minute)},
+		&domain.Post{ID: "002", PublishAt: now.Add(time.Hour)},
+		&domain.Post{ID: "003"},
+	)
+	service := NewPostService(repo, newFakeImageRepository(), nil, NewMarkdownRenderer(), "main",
+		WithClock(clock.Fixed(now)),
+	)
+	defer service.Close()
+
+	if err := service.publishDuePosts(context.Background()); err != nil {
+		t.Fatalf("publishDuePosts() error = %v", err)
+	}
+
+	if repo.posts["001"].PublishedAt.IsZero() {
+		t.Error("post past its publish_at was not published")
+	}
+	if !repo.posts["002"].PublishedAt.IsZero() {
+		t.Error("post scheduled in the future was published")
+	}
+	if !repo.posts["003"].PublishedAt.IsZero() {
+		t.Error("unscheduled draft was published")
+	}
+}
+
+func TestPostService_ProcessBranches_SchedulesByClock(t *testing.T) {
+	source := newFakeSourceRepository()
+	repo := newFakePostRepository()
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	service := NewPostService(repo, newFakeImageRepository(), source, NewMarkdownRenderer(), "main",
+		WithClock(clock.Fixed(now)),
+	)
+	defer service.Close()
+
+	source.commits["a"] = testCommit("a", "posts/001-past.md", "posts/002-future.md")
+	source.files["posts/001-past.md"] = []byte("---\npublish_at: 2025-05-31T12:00:00Z\n---\n# Past\n\nBody")
+	source.files["posts/002-future.md"] = []byte("---\npublish_at: 2025-06-02T12:00:00Z\n---\n# Future\n\nBody")
+
+	branches := []*github.Branch{{Name: github.Ptr("main")}}
+	if err := service.processBranches(time.Time{}, branches); err != nil {
+		t.Fatalf("processBranches() error = %v", err)
+	}
+
+	if repo.posts["001"].PublishedAt.IsZero() {
+		t.Error("post with publish_at before the clock was not published")
+	}
+	if !repo.posts["002"].PublishedAt.IsZero() {
+		t.Error("post with publish_at after the clock was published")
+	}
+}
+
+func TestPostService_WithPostIDFunc(t *testing.T) {
+	source := newFakeSourceRepository()
+	repo := newFakePostRepository()
+	service := NewPostService(repo, newFakeImageRepository(), source, NewMarkdownRenderer(), "main",
+		WithPostIDFunc(func(path string) string { return "custom-" + DefaultContentLayout().PostID(path) }),
+	)
+	defer service.Close()
+
+	source.commits["a"] = testCommit("a", "posts/001-test.md")
+	source.files["posts/001-test.md"] = []byte("# Test\n\nBody")
+
+	branches := []*github.Branch{{Name: github.Ptr("main")}}
+	if err := service.processBranches(time.Time{}, branches); err != nil {
+		t.Fatalf("processBranches() error = %v", err)
+	}
+
+	if _, ok := repo.posts["custom-001"]; !ok {
+		t.Errorf("post was not saved under the generated ID, got %v", slices.Collect(maps.Keys(repo.posts)))
+	}
+}
+
+func testCommit(sha string, files ...string) *github.RepositoryCommit {
+	commit := &github.RepositoryCommit{
+		SHA: github.Ptr(sha),
+		Commit: &github.Commit{
+			Author: &github.CommitAuthor{Date: &github.Timestamp{Time: time.Now()}},
+		},
+	}
+	for _, f := range files {
+		commit.Files = append(commit.Files, &github.CommitFile{
+			Filename: github.Ptr(f),
+			Status:   github.Ptr("modified"),
+		})
+	}
+	return commit
+}
+
+// changedSHAs maps the path of each change to the SHA of its commit
+func changedSHAs(changes []fileChange) map[string]string {
+	shas := make(map[string]string, len(changes))
+	for _, change := range changes {
+		shas[change.path] = change.commit.GetSHA()
+	}
+	return shas
+}
+
+func TestPostService_AnalyzeComparison(t *testing.T) {
+	source := newFakeSourceRepository()
+	service := NewPostService(newFakePostRepository(), newFakeImageRepository(), source, NewMarkdownRenderer(), "main")
+	defer service.Close()
+
+	t.Run("small range fetches each commit", func(t *testing.T) {
+		source.getCommitCalls = 0
+		source.commits["a"] = testCommit("a", "posts/001-first.md")
+		source.commits["b"] = testCommit("b", "images/cat.png")
+
+		result, err := service.analyzeComparison(&github.CommitsComparison{
+			Commits: []*github.RepositoryCommit{testCommit("a"), testCommit("b")},
+		}, "base", "b")
+		if err != nil {
+			t.Fatalf("analyzeComparison() error = %v", err)
+		}
+
+		if source.getCommitCalls != 2 {
+			t.Errorf("GetCommit calls = %d, want 2", source.getCommitCalls)
+		}
+		if changedSHAs(result.posts)["posts/001-first.md"] != "a" {
+			t.Error("post not attributed to the commit that changed it")
+		}
+		if changedSHAs(result.images)["images/cat.png"] != "b" {
+			t.Error("image not attributed to the commit that changed it")
+		}
+	})
+
+	t.Run("large range uses compared files", func(t *testing.T) {
+		source.getCommitCalls = 0
+		comparison := &github.CommitsComparison{
+			Files: []*github.CommitFile{
+				{Filename: github.Ptr("posts/002-second.md"), Status: github.Ptr("added")},
+				{Filename: github.Ptr("posts/003-gone.md"), Status: github.Ptr("removed")},
+			},
+		}
+		for i := 0; i <= maxIndividualCommits; i++ {
+			comparison.Commits = append(comparison.Commits, testCommit(fmt.Sprintf("c%d", i)))
+		}
+		headSHA := fmt.Sprintf("c%d", maxIndividualCommits)
+
+		result, err := service.analyzeComparison(comparison, "base", headSHA)
+		if err != nil {
+			t.Fatalf("analyzeComparison() error = %v", err)
+		}
+
+		if source.getCommitCalls != 0 {
+			t.Errorf("GetCommit calls = %d, want 0", source.getCommitCalls)
+		}
+		if changedSHAs(result.posts)["posts/002-second.md"] != headSHA {
+			t.Error("post not attributed to the head commit")
+		}
+		if !slices.Contains(result.postsToRemove, "posts/003-gone.md") {
+			t.Error("removed post not detected")
+		}
+	})
+
+	t.Run("truncated comparison diffs trees", func(t *testing.T) {
+		source.getCommitCalls = 0
+		source.commits["head"] = testCommit("head")
+		source.trees["base"] = testTree(map[string]string{
+			"posts/001-first.md": "1",
+			"posts/002-old.md":   "2",
+			"posts/003-gone.md":  "3",
+			"images/cat.png":     "4",
+		})
+		source.trees["head"] = testTree(map[string]string{
+			"posts/001-first.md":   "1",
+			"posts/002-renamed.md": "2",
+			"images/cat.png":       "5",
+			"posts/004-new.md":     "6",
+		})
+		comparison := &github.CommitsComparison{Commits: []*github.RepositoryCommit{testCommit("head")}}
+		for i := 0; i < compareFileLimit; i++ {
+			comparison.Files = append(comparison.Files, &github.CommitFile{Filename: github.Ptr(fmt.Sprintf("other/%d.txt", i))})
+		}
+
+		result, err := service.analyzeComparison(comparison, "base", "head")
+		if err != nil {
+			t.Fatalf("analyzeComparison() error = %v", err)
+		}
+
+		if source.getCommitCalls != 1 {
+			t.Errorf("GetCommit calls = %d, want only the head commit", source.getCommitCalls)
+		}
+		if got := slices.Sorted(maps.Keys(changedSHAs(result.posts))); !slices.Equal(got, []string{"posts/002-renamed.md", "posts/004-new.md"}) {
+			t.Errorf("posts = %v, want the renamed and new posts", got)
+		}
+		if !slices.Equal(result.postsToRemove, []string{"posts/003-gone.md"}) {
+			t.Errorf("postsToRemove = %v, want only the removed post, not the renamed one", result.postsToRemove)
+		}
+		if changedSHAs(result.images)["images/cat.png"] != "head" {
+			t.Error("modified image not detected")
+		}
+	})
+
+	t.Run("ambiguous rename analyzes each commit", func(t *testing.T) {
+		source.getCommitCalls = 0
+		source.commits["c1"] = testCommit("c1", "posts/002-copy.md")
+		source.trees["c1"] = testTree(map[string]string{"posts/002-renamed.md": "2", "posts/002-copy.md": "2"})
+		comparison := &github.CommitsComparison{Commits: []*github.RepositoryCommit{testCommit("c1")}}
+		for i := 0; i < compareFileLimit; i++ {
+			comparison.Files = append(comparison.Files, &github.CommitFile{Filename: github.Ptr(fmt.Sprintf("other/%d.txt", i))})
+		}
+		source.trees["base2"] = testTree(map[string]string{"posts/002-old.md": "2"})
+
+		result, err := service.analyzeComparison(comparison, "base2", "c1")
+		if err != nil {
+			t.Fatalf("analyzeComparison() error = %v", err)
+		}
+
+		if source.getCommitCalls != 2 {
+			t.Errorf("GetCommit calls = %d, want the head commit and then each commit", source.getCommitCalls)
+		}
+		if len(result.postsToRemove) != 0 || len(result.posts) != 1 {
+			t.Errorf("result = %+v, want the commit's own changes", result)
+		}
+	})
+}
+
+func TestPostService_PublishedPostsAndImages(t *testing.T) {
+	published := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := newFakePostRepository(
+		&domain.Post{ID: "001", PublishedAt: published, Images: []string{"images/a.png"}},
+		&domain.Post{ID: "002", PublishedAt: published.Add(time.Hour)},
+		&domain.Post{ID: "003", Images: []string{"images/b.png"}},
+	)
+	imageRepo := newFakeImageRepository(
+		&domain.Image{Path: "images/a.png", Hash: "a"},
+		&domain.Image{Path: "images/b.png", Hash: "b"},
+		&domain.Image{Path: "images/c.png", Hash: "c"},
+	)
+	service := NewPostService(repo, imageRepo, nil, NewMarkdownRenderer(), "main")
+	defer service.Close()
+	ctx := context.Background()
+
+	posts, err := service.PublishedPosts(ctx)
+	if err != nil {
+		t.Fatalf("PublishedPosts() error = %v", err)
+	}
+	if len(posts) != 2 || posts[0].ID != "002" || posts[1].ID != "001" {
+		t.Errorf("PublishedPosts() = %v, want 002 then 001", posts)
+	}
+
+	images, err := service.PublishedImages(ctx)
+	if err != nil {
+		t.Fatalf("PublishedImages() error = %v", err)
+	}
+	if len(images) != 1 || images[0].Path != "images/a.png" {
+		t.Errorf("PublishedImages() = %v, want only the image of the published post", images)
+	}
+}
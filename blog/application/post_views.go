@@ -0,0 +1,166 @@
+package application
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultPostViewFlushInterval = 10 * time.Second
+	defaultPostViewBuffer        = 1024
+	// postViewFlushTimeout bounds the last flush on shutdown, so a stuck database can't hold the process open
+	postViewFlushTimeout = 5 * time.Second
+)
+
+var (
+	postViewsRecorded = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "goblog_post_views_total",
+		Help: "Post page views counted.",
+	})
+	postViewsDropped = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "goblog_post_views_dropped_total",
+		Help: "Post page views not counted because the view buffer was full.",
+	})
+)
+
+type PostViewConfig struct {
+	// FlushInterval is how often counted views are written to the database
+	FlushInterval time.Duration
+	// Buffer is how many views may wait to be counted before more are dropped
+	Buffer int
+}
+
+func NewPostViewConfig() *PostViewConfig {
+	cfg := &PostViewConfig{
+		FlushInterval: defaultPostViewFlushInterval,
+		Buffer:        defaultPostViewBuffer,
+	}
+
+	if d, err := time.ParseDuration(os.Getenv("POST_VIEWS_FLUSH_INTERVAL")); err == nil && d > 0 {
+		cfg.FlushInterval = d
+	}
+	if n, err := strconv.Atoi(os.Getenv("POST_VIEWS_BUFFER")); err == nil && n > 0 {
+		cfg.Buffer = n
+	}
+
+	return cfg
+}
+
+// WithPostViews counts views of posts, writing them to views in batches
+// Without it, views are not counted and no posts are popular.
+func WithPostViews(views domain.PostViewRepository, cfg *PostViewConfig) PostServiceOption {
+	return func(s *PostService) {
+		s.postViews = views
+		s.postViewConfig = cfg
+	}
+}
+
+// PopularPost is a published post and how often it was viewed
+type PopularPost struct {
+	Post  *domain.Post
+	Views int
+}
+
+// RecordPostView counts a view of a post without waiting for it to be written
+// When the buffer is full the view is dropped, since a slow database must never slow down serving pages.
+func (s *PostService) RecordPostView(postID string) {
+	if s.postViewCh == nil {
+		return
+	}
+
+	select {
+	case s.postViewCh <- postID:
+	default:
+		postViewsDropped.Inc()
+	}
+}
+
+// startPostViewWriter counts the views sent to postViewCh by day, writing the counts every flush interval
+// and once more when the service is closed
+func (s *PostService) startPostViewWriter() {
+	s.postViewCh = make(chan string, s.postViewConfig.Buffer)
+	s.wg.Go(func() {
+		ticker := time.NewTicker(s.postViewConfig.FlushInterval)
+		defer ticker.Stop()
+
+		pending := make(map[time.Time]map[string]int)
+		count := func(postID string) {
+			day := s.viewDay(s.clock.Now())
+			if pending[day] == nil {
+				pending[day] = make(map[string]int)
+			}
+			pending[day][postID]++
+			postViewsRecorded.Inc()
+		}
+
+		for {
+			select {
+			case postID := <-s.postViewCh:
+				count(postID)
+			case <-ticker.C:
+				s.flushPostViews(s.ctx, pending)
+			case <-s.ctx.Done():
+				// Views still in the channel are counted before the last flush
+				for len(s.postViewCh) > 0 {
+					count(<-s.postViewCh)
+				}
+				ctx, cancel := context.WithTimeout(context.Background(), postViewFlushTimeout)
+				s.flushPostViews(ctx, pending)
+				cancel()
+				return
+			}
+		}
+	})
+}
+
+// flushPostViews writes the pending counts of each day, keeping those that fail for the next flush
+func (s *PostService) flushPostViews(ctx context.Context, pending map[time.Time]map[string]int) {
+	for day, views := range pending {
+		if err := s.postViews.AddPostViews(ctx, day, views); err != nil {
+			log.Error().Err(err).Time("day", day).Int("posts", len(views)).Msg("Failed to write post views")
+			continue
+		}
+		delete(pending, day)
+	}
+}
+
+// viewDay is the day a view at t is counted on, in the site's time zone
+func (s *PostService) viewDay(t time.Time) time.Time {
+	t = t.In(s.archiveTimezone())
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// PopularPosts returns up to limit published posts most viewed over the last days days, today included
+// A limit of zero or less asks for the default page size, and larger limits are capped.
+func (s *PostService) PopularPosts(ctx context.Context, days int, limit int) ([]*PopularPost, error) {
+	if s.postViews == nil {
+		return []*PopularPost{}, nil
+	}
+	if limit <= 0 {
+		limit = defaultPostPageSize
+	}
+	limit = min(limit, maxPostPageSize)
+
+	since := s.viewDay(s.clock.Now()).AddDate(0, 0, 1-days)
+	counts, err := s.postViews.ListPopularPosts(ctx, since, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	popular := make([]*PopularPost, 0, len(counts))
+	for _, count := range counts {
+		post, err := s.repo.GetPost(ctx, count.PostID)
+		if err != nil {
+			return nil, err
+		}
+		popular = append(popular, &PopularPost{Post: post, Views: count.Views})
+	}
+	return popular, nil
+}
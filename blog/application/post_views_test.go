@@ -0,0 +1,93 @@
+package application
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/clock"
+)
+
+func TestPostService_RecordPostView(t *testing.T) {
+	now := time.Date(2026, 3, 1, 23, 30, 0, 0, time.UTC)
+	views := newFakePostViewRepository()
+	service := NewPostService(newFakePostRepository(), newFakeImageRepository(), nil, NewMarkdownRenderer(), "main",
+		WithClock(clock.Func(func() time.Time { return now })),
+		WithArchiveTimezone(time.FixedZone("UTC+1", 3600)),
+		WithPostViews(views, &PostViewConfig{FlushInterval: time.Hour, Buffer: 10}),
+	)
+
+	service.RecordPostView("001")
+	service.RecordPostView("001")
+	service.RecordPostView("002")
+	service.Close()
+
+	// Views are counted on the day in the site's time zone, which is already March 2nd
+	day := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	if got := views.views[day]; got["001"] != 2 || got["002"] != 1 {
+		t.Errorf("views on %s = %v, want 2 of 001 and 1 of 002 written on close", day.Format(time.DateOnly), views.views)
+	}
+}
+
+func TestPostService_RecordPostView_FlushesInBatches(t *testing.T) {
+	views := newFakePostViewRepository()
+	service := NewPostService(newFakePostRepository(), newFakeImageRepository(), nil, NewMarkdownRenderer(), "main",
+		WithPostViews(views, &PostViewConfig{FlushInterval: 10 * time.Millisecond, Buffer: 10}),
+	)
+	defer service.Close()
+
+	service.RecordPostView("001")
+	select {
+	case added := <-views.added:
+		if added["001"] != 1 {
+			t.Errorf("flushed %v, want one view of 001", added)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for views to be flushed")
+	}
+}
+
+func TestPostService_RecordPostView_Disabled(t *testing.T) {
+	service := NewPostService(newFakePostRepository(), newFakeImageRepository(), nil, NewMarkdownRenderer(), "main")
+	defer service.Close()
+
+	// Without a repository views are ignored rather than blocking
+	service.RecordPostView("001")
+	popular, err := service.PopularPosts(context.Background(), 30, 10)
+	if err != nil || len(popular) != 0 {
+		t.Errorf("PopularPosts() = %v, %v, want none", popular, err)
+	}
+}
+
+func TestPostService_PopularPosts(t *testing.T) {
+	now := time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)
+	published := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := newFakePostRepository(
+		&domain.Post{ID: "001", Slug: "first", PublishedAt: published},
+		&domain.Post{ID: "002", Slug: "second", PublishedAt: published},
+	)
+	views := newFakePostViewRepository()
+	views.AddPostViews(context.Background(), time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC), map[string]int{"001": 3, "002": 5})
+	views.AddPostViews(context.Background(), time.Date(2026, 3, 25, 0, 0, 0, 0, time.UTC), map[string]int{"001": 4})
+	views.AddPostViews(context.Background(), time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), map[string]int{"002": 100})
+	service := NewPostService(repo, newFakeImageRepository(), nil, NewMarkdownRenderer(), "main",
+		WithClock(clock.Func(func() time.Time { return now })),
+		WithPostViews(views, &PostViewConfig{FlushInterval: time.Hour, Buffer: 10}),
+	)
+	defer service.Close()
+	ctx := context.Background()
+
+	popular, err := service.PopularPosts(ctx, 7, 0)
+	if err != nil {
+		t.Fatalf("PopularPosts() error = %v", err)
+	}
+	if len(popular) != 2 || popular[0].Post.ID != "001" || popular[0].Views != 7 || popular[1].Views != 5 {
+		t.Errorf("PopularPosts(7 days) = %+v, want 001 with 7 views then 002 with 5", popular)
+	}
+
+	popular, err = service.PopularPosts(ctx, 31, 1)
+	if err != nil || len(popular) != 1 || popular[0].Post.ID != "002" || popular[0].Views != 105 {
+		t.Errorf("PopularPosts(31 days, 1) = %+v, %v, want 002 with 105 views", popular, err)
+	}
+}
@@ -0,0 +1,135 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultPreviewCleanupInterval = time.Hour
+	defaultPreviewRetentionDays   = 30
+)
+
+type PreviewRetentionConfig struct {
+	// Interval is how often previews are checked for cleanup
+	Interval time.Duration
+	// MaxAge is how long a preview is kept after its last update; zero keeps previews regardless of age
+	MaxAge time.Duration
+	// DeleteClosed removes previews whose branch no longer exists, because it was merged or closed
+	DeleteClosed bool
+}
+
+func NewPreviewRetentionConfig() *PreviewRetentionConfig {
+	interval := defaultPreviewCleanupInterval
+	if d, err := time.ParseDuration(os.Getenv("PREVIEW_CLEANUP_INTERVAL")); err == nil && d > 0 {
+		interval = d
+	}
+
+	days := defaultPreviewRetentionDays
+	if n, err := strconv.Atoi(os.Getenv("PREVIEW_RETENTION_DAYS")); err == nil && n >= 0 {
+		days = n
+	}
+
+	return &PreviewRetentionConfig{
+		Interval:     interval,
+		MaxAge:       time.Duration(days) * 24 * time.Hour,
+		DeleteClosed: os.Getenv("PREVIEW_DELETE_CLOSED") != "false",
+	}
+}
+
+// WithPreviewRetention sets when CleanupPreviews deletes previews
+// Without it, previews are counted but never deleted.
+func WithPreviewRetention(cfg *PreviewRetentionConfig) PostServiceOption {
+	return func(s *PostService) {
+		s.previewRetention = cfg
+	}
+}
+
+// PreviewStats reports on branch previews as of the last cleanup
+type PreviewStats struct {
+	// Active is how many previews were kept by the last cleanup
+	Active int
+	// DeletedExpired counts previews deleted for exceeding the maximum age since startup
+	DeletedExpired int
+	// DeletedClosed counts previews deleted because their branch was gone since startup
+	DeletedClosed int
+	LastRunAt     time.Time
+}
+
+// PreviewStats returns the results of preview cleanups so far
+func (s *PostService) PreviewStats() PreviewStats {
+	s.previewMu.Lock()
+	defer s.previewMu.Unlock()
+	return s.previewStats
+}
+
+// CleanupPreviews deletes previews that have expired or whose branch has been merged or closed
+// A preview is a post rendered from a branch other than main that was never published or scheduled.
+func (s *PostService) CleanupPreviews(ctx context.Context) error {
+	posts, err := s.repo.ListUnpublishedPosts(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list unpublished posts: %w", err)
+	}
+
+	var openBranches map[string]bool
+	if s.previewRetention.DeleteClosed {
+		branches, err := s.sourceRepo.ListBranches(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve branches: %w", err)
+		}
+
+		openBranches = make(map[string]bool, len(branches))
+		for _, b := range branches {
+			openBranches[b.GetName()] = true
+		}
+	}
+
+	now := s.clock.Now().UTC()
+	var active, expired, closed int
+	var errs []error
+	for _, post := range posts {
+		// Posts rendered before branches were recorded can't be told apart from unpublished main posts
+		if post.Branch == "" || post.Branch == s.mainBranchName {
+			continue
+		}
+
+		reason := ""
+		switch {
+		case openBranches != nil && !openBranches[post.Branch]:
+			reason = "branch closed"
+		case s.previewRetention.MaxAge > 0 && now.Sub(post.UpdatedAt) > s.previewRetention.MaxAge:
+			reason = "expired"
+		default:
+			active++
+			continue
+		}
+
+		if err := s.repo.DeletePost(ctx, post.ID); err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete preview %s: %w", post.ID, err))
+			active++
+			continue
+		}
+
+		if reason == "expired" {
+			expired++
+		} else {
+			closed++
+		}
+		log.Info().Str("postID", post.ID).Str("branch", post.Branch).Str("reason", reason).Msg("Deleted preview")
+	}
+
+	s.previewMu.Lock()
+	s.previewStats.Active = active
+	s.previewStats.DeletedExpired += expired
+	s.previewStats.DeletedClosed += closed
+	s.previewStats.LastRunAt = now
+	s.previewMu.Unlock()
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,69 @@
+package application
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/clock"
+	"github.com/google/go-github/v75/github"
+)
+
+func TestPostService_CleanupPreviews(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	repo := newFakePostRepository(
+		&domain.Post{ID: "001", Branch: "open", UpdatedAt: now.Add(-time.Hour)},
+		&domain.Post{ID: "002", Branch: "open", UpdatedAt: now.Add(-48 * time.Hour)},
+		&domain.Post{ID: "003", Branch: "closed", UpdatedAt: now},
+		&domain.Post{ID: "004", Branch: "main", UpdatedAt: now.Add(-48 * time.Hour)},
+		&domain.Post{ID: "005", Branch: "open", UpdatedAt: now.Add(-48 * time.Hour), PublishedAt: now},
+		&domain.Post{ID: "006", UpdatedAt: now.Add(-48 * time.Hour)},
+	)
+	source := newFakeSourceRepository()
+	source.branches = []*github.Branch{{Name: github.Ptr("main")}, {Name: github.Ptr("open")}}
+
+	service := NewPostService(repo, newFakeImageRepository(), source, NewMarkdownRenderer(), "main",
+		WithClock(clock.Fixed(now)),
+		WithPreviewRetention(&PreviewRetentionConfig{MaxAge: 24 * time.Hour, DeleteClosed: true}),
+	)
+	defer service.Close()
+
+	if err := service.CleanupPreviews(context.Background()); err != nil {
+		t.Fatalf("CleanupPreviews() error = %v", err)
+	}
+
+	for _, id := range []string{"002", "003"} {
+		if _, ok := repo.posts[id]; ok {
+			t.Errorf("preview %s was not deleted", id)
+		}
+	}
+	for _, id := range []string{"001", "004", "005", "006"} {
+		if _, ok := repo.posts[id]; !ok {
+			t.Errorf("post %s was deleted", id)
+		}
+	}
+
+	want := PreviewStats{Active: 1, DeletedExpired: 1, DeletedClosed: 1, LastRunAt: now}
+	if got := service.PreviewStats(); got != want {
+		t.Errorf("PreviewStats() = %+v, want %+v", got, want)
+	}
+}
+
+func TestPostService_CleanupPreviews_NoPolicy(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	repo := newFakePostRepository(&domain.Post{ID: "001", Branch: "gone", UpdatedAt: now.AddDate(-1, 0, 0)})
+	service := NewPostService(repo, newFakeImageRepository(), nil, NewMarkdownRenderer(), "main", WithClock(clock.Fixed(now)))
+	defer service.Close()
+
+	if err := service.CleanupPreviews(context.Background()); err != nil {
+		t.Fatalf("CleanupPreviews() error = %v", err)
+	}
+
+	if _, ok := repo.posts["001"]; !ok {
+		t.Error("preview was deleted without a retention policy")
+	}
+	if got := service.PreviewStats().Active; got != 1 {
+		t.Errorf("Active = %d, want 1", got)
+	}
+}
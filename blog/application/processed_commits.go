@@ -0,0 +1,67 @@
+package application
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/dfryer1193/mjolnir/utils/set"
+	"github.com/google/go-github/v75/github"
+	"github.com/rs/zerolog/log"
+)
+
+// processedCommitRetention is how long synced commits are remembered
+// It only needs to outlast the sync overlap, plus any clock skew it is meant to absorb.
+const processedCommitRetention = 30 * 24 * time.Hour
+
+// skipProcessedCommits drops commits already synced on branch, keeping the rest in order
+// If processed commits can't be looked up, every commit is kept; reprocessing is safe, just slower.
+func (s *PostService) skipProcessedCommits(branch string, commits []*github.RepositoryCommit) []*github.RepositoryCommit {
+	if s.processedCommits == nil {
+		return commits
+	}
+
+	shas := make([]string, 0, len(commits))
+	for _, c := range commits {
+		shas = append(shas, c.GetSHA())
+	}
+
+	processed, err := s.processedCommits.ListProcessed(s.ctx, branch, shas)
+	if err != nil {
+		log.Warn().Err(err).Str("branch", branch).Msg("Failed to look up processed commits")
+		return commits
+	}
+	if len(processed) == 0 {
+		return commits
+	}
+
+	skip := set.New(processed...)
+
+	log.Debug().Str("branch", branch).Int("skipped", len(processed)).Msg("Skipping commits synced previously")
+	return slices.DeleteFunc(slices.Clone(commits), func(c *github.RepositoryCommit) bool {
+		return skip.Contains(c.GetSHA())
+	})
+}
+
+// markCommitsProcessed records commits as synced on branch
+// The service context may already be cancelled during shutdown, so the update runs without it
+func (s *PostService) markCommitsProcessed(branch string, shas []string) {
+	if s.processedCommits == nil || len(shas) == 0 {
+		return
+	}
+
+	if err := s.processedCommits.MarkProcessed(context.WithoutCancel(s.ctx), branch, shas); err != nil {
+		log.Warn().Err(err).Str("branch", branch).Msg("Failed to record processed commits")
+	}
+}
+
+// pruneProcessedCommits forgets commits synced too long ago to reappear in a sync window
+func (s *PostService) pruneProcessedCommits() {
+	if s.processedCommits == nil {
+		return
+	}
+
+	if err := s.processedCommits.PruneProcessed(s.ctx, s.clock.Now().UTC().Add(-processedCommitRetention)); err != nil {
+		log.Warn().Err(err).Msg("Failed to prune processed commits")
+	}
+}
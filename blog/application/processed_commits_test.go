@@ -0,0 +1,46 @@
+package application
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/google/go-github/v75/github"
+)
+
+func TestPostService_SyncRepositoryChanges_OverlapsAndSkipsProcessedCommits(t *testing.T) {
+	lastUpdate := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	repo := newFakePostRepository(&domain.Post{ID: "099", UpdatedAt: lastUpdate})
+	source := newFakeSourceRepository()
+	source.branches = []*github.Branch{{Name: github.Ptr("main")}}
+	source.commits["a"] = testCommit("a", "posts/001-seen.md")
+	source.commits["b"] = testCommit("b", "posts/002-new.md")
+	source.files["posts/001-seen.md"] = []byte("# Seen\n\nBody")
+	source.files["posts/002-new.md"] = []byte("# New\n\nBody")
+
+	processed := newFakeProcessedCommitRepository()
+	processed.processed["main:a"] = true
+
+	service := NewPostService(repo, newFakeImageRepository(), source, NewMarkdownRenderer(), "main",
+		WithSyncOverlap(&SyncConfig{Overlap: 5 * time.Minute}),
+		WithProcessedCommits(processed),
+	)
+	defer service.Close()
+
+	if err := service.SyncRepositoryChanges(); err != nil {
+		t.Fatalf("SyncRepositoryChanges() error = %v", err)
+	}
+
+	if want := lastUpdate.Add(-5 * time.Minute); !source.since.Equal(want) {
+		t.Errorf("commits listed since %v, want %v", source.since, want)
+	}
+	if _, err := repo.GetPost(t.Context(), "001"); err == nil {
+		t.Error("post from an already processed commit was synced again")
+	}
+	if _, err := repo.GetPost(t.Context(), "002"); err != nil {
+		t.Errorf("post from a new commit was not synced: %v", err)
+	}
+	if !processed.processed["main:b"] {
+		t.Error("new commit was not recorded as processed")
+	}
+}
@@ -0,0 +1,48 @@
+package application
+
+import (
+	"context"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+)
+
+// publishHook is called with the ID of a post after it is published or withdrawn
+type publishHook func(ctx context.Context, postID string)
+
+// publishHookRepository runs hooks after posts are published, and after they are unpublished or deleted
+// Wrapping the repository catches every way a post is published: sync, scheduler or admin.
+type publishHookRepository struct {
+	domain.PostRepository
+	published []publishHook
+	withdrawn []publishHook
+}
+
+func (r *publishHookRepository) Publish(ctx context.Context, postID string) error {
+	if err := r.PostRepository.Publish(ctx, postID); err != nil {
+		return err
+	}
+	runPublishHooks(ctx, r.published, postID)
+	return nil
+}
+
+func (r *publishHookRepository) Unpublish(ctx context.Context, postID string) error {
+	if err := r.PostRepository.Unpublish(ctx, postID); err != nil {
+		return err
+	}
+	runPublishHooks(ctx, r.withdrawn, postID)
+	return nil
+}
+
+func (r *publishHookRepository) DeletePost(ctx context.Context, postID string) error {
+	if err := r.PostRepository.DeletePost(ctx, postID); err != nil {
+		return err
+	}
+	runPublishHooks(ctx, r.withdrawn, postID)
+	return nil
+}
+
+func runPublishHooks(ctx context.Context, hooks []publishHook, postID string) {
+	for _, hook := range hooks {
+		hook(ctx, postID)
+	}
+}
@@ -0,0 +1,43 @@
+package application
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+)
+
+func TestPublishHookRepository(t *testing.T) {
+	var calls []string
+	hook := func(name string) publishHook {
+		return func(ctx context.Context, postID string) {
+			calls = append(calls, name+" "+postID)
+		}
+	}
+
+	repo := &publishHookRepository{
+		PostRepository: newFakePostRepository(&domain.Post{ID: "001"}, &domain.Post{ID: "002"}),
+		published:      []publishHook{hook("announce"), hook("mail")},
+		withdrawn:      []publishHook{hook("withdraw")},
+	}
+	ctx := context.Background()
+
+	if err := repo.Publish(ctx, "001"); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	if err := repo.Unpublish(ctx, "001"); err != nil {
+		t.Fatalf("Unpublish failed: %v", err)
+	}
+	if err := repo.DeletePost(ctx, "002"); err != nil {
+		t.Fatalf("DeletePost failed: %v", err)
+	}
+	if err := repo.Publish(ctx, "missing"); err == nil {
+		t.Fatal("Publish of a missing post succeeded")
+	}
+
+	want := []string{"announce 001", "mail 001", "withdraw 001", "withdraw 002"}
+	if !slices.Equal(calls, want) {
+		t.Errorf("hooks ran as %v, want %v", calls, want)
+	}
+}
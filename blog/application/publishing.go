@@ -0,0 +1,276 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/google/go-github/v75/github"
+	"github.com/rs/zerolog/log"
+)
+
+// PublishMode decides what publishes posts merged to the main branch
+type PublishMode string
+
+const (
+	// PublishOnMerge publishes posts as soon as they reach the main branch
+	PublishOnMerge PublishMode = "merge"
+	// PublishOnTag publishes the posts on the main branch when a tag is created
+	PublishOnTag PublishMode = "tag"
+	// PublishOnRelease publishes the posts on the main branch when a GitHub Release is published
+	PublishOnRelease PublishMode = "release"
+
+	// postVersionPollInterval is how often a release checks whether a post version it waits for is still being processed
+	postVersionPollInterval = 100 * time.Millisecond
+)
+
+type PublishingConfig struct {
+	Mode PublishMode
+	// TagPattern is a path.Match pattern, such as v*, that a tag must match to publish; empty matches every tag
+	TagPattern string
+}
+
+func NewPublishingConfig() *PublishingConfig {
+	mode := PublishMode(os.Getenv("PUBLISH_MODE"))
+	if mode == "" {
+		mode = PublishOnMerge
+	}
+
+	return &PublishingConfig{
+		Mode:       mode,
+		TagPattern: os.Getenv("PUBLISH_TAG_PATTERN"),
+	}
+}
+
+// Validate reports an unknown mode or a malformed tag pattern
+func (c *PublishingConfig) Validate() error {
+	switch c.Mode {
+	case PublishOnMerge, PublishOnTag, PublishOnRelease:
+	default:
+		return fmt.Errorf("unknown publish mode %q, want %s, %s or %s", c.Mode, PublishOnMerge, PublishOnTag, PublishOnRelease)
+	}
+
+	if _, err := path.Match(c.TagPattern, ""); err != nil {
+		return fmt.Errorf("invalid tag pattern %q: %w", c.TagPattern, err)
+	}
+
+	return nil
+}
+
+// matchesTag reports whether a tag publishes under the configured pattern
+func (c *PublishingConfig) matchesTag(tag string) bool {
+	if c.TagPattern == "" {
+		return true
+	}
+	matched, _ := path.Match(c.TagPattern, tag)
+	return matched
+}
+
+// WithPublishing sets what publishes merged posts
+// Without it, posts are published when they are merged.
+func WithPublishing(cfg *PublishingConfig) PostServiceOption {
+	return func(s *PostService) {
+		s.publishing = cfg
+	}
+}
+
+// publishesOnMerge reports whether pushes to the main branch publish posts, rather than tags or releases
+func (s *PostService) publishesOnMerge() bool {
+	return s.publishing == nil || s.publishing.Mode == PublishOnMerge
+}
+
+// HandleCreateEvent publishes the main branch as of a new tag when posts are published on tags
+// It returns a zero job ID when the event publishes nothing.
+func (s *PostService) HandleCreateEvent(evt *github.CreateEvent) (int64, error) {
+	if s.publishing == nil || s.publishing.Mode != PublishOnTag || evt.GetRefType() != "tag" {
+		return 0, nil
+	}
+	return s.publishTag(evt.GetRef())
+}
+
+// HandleReleaseEvent publishes the main branch as of a release's tag when posts are published on releases
+// Drafts and pre-releases publish nothing. It returns a zero job ID when the event publishes nothing.
+func (s *PostService) HandleReleaseEvent(evt *github.ReleaseEvent) (int64, error) {
+	release := evt.GetRelease()
+	if s.publishing == nil || s.publishing.Mode != PublishOnRelease || evt.GetAction() != "published" || release.GetDraft() || release.GetPrerelease() {
+		return 0, nil
+	}
+	return s.publishTag(release.GetTagName())
+}
+
+// publishTag renders and publishes the posts at a tag, and unpublishes merged posts that the tag no longer has
+// The tag must point at a commit on the main branch, so a tag on a feature branch can't publish drafts.
+func (s *PostService) publishTag(tag string) (int64, error) {
+	if !s.publishing.matchesTag(tag) {
+		log.Info().Str("tag", tag).Str("pattern", s.publishing.TagPattern).Msg("Tag does not match the publish pattern, ignoring")
+		return 0, nil
+	}
+
+	commit, err := s.sourceRepo.GetCommit(s.ctx, "tags/"+tag)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get commit of tag %s: %w", tag, err)
+	}
+	sha := commit.GetSHA()
+
+	comparison, err := s.sourceRepo.CompareCommits(s.ctx, sha, s.mainBranchName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to compare tag %s with %s: %w", tag, s.mainBranchName, err)
+	}
+	if status := comparison.GetStatus(); status != "identical" && status != "ahead" {
+		log.Warn().Str("tag", tag).Str("commit", sha).Msgf("Tag is not on %s, ignoring", s.mainBranchName)
+		return 0, nil
+	}
+
+	jobID, err := s.startSyncJob("refs/tags/"+tag, "", sha)
+	if err != nil {
+		return 0, err
+	}
+
+	tasks, err := s.planTagPublish(commit)
+	if err != nil {
+		s.failSyncJob(jobID, err)
+		return jobID, err
+	}
+
+	s.addSyncJobFiles(jobID, tasks)
+	log.Info().Str("tag", tag).Str("commit", sha).Int("files", len(tasks)).Msg("Publishing tag")
+	s.runSyncTasks(jobID, tasks, nil)
+
+	return jobID, nil
+}
+
+// planTagPublish returns the work needed to bring the published posts in line with the tree of a tagged commit
+// Posts published from the same file content are left alone, so a release doesn't move their publication date.
+func (s *PostService) planTagPublish(commit *github.RepositoryCommit) ([]syncTask, error) {
+	sha := commit.GetSHA()
+	tree, err := s.sourceRepo.GetTree(s.ctx, sha)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tree of %s: %w", sha, err)
+	}
+	files := treeFiles(tree)
+
+	posts, err := s.repo.ListPosts(s.ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list posts: %w", err)
+	}
+	stored := make(map[string]*domain.Post, len(posts))
+	for _, post := range posts {
+		stored[post.ID] = post
+	}
+
+	// publishedFiles caches the trees of the commits posts were last published from
+	publishedFiles := make(map[string]map[string]string)
+	var changed []string
+	tagged := make(map[string]bool)
+	for filePath, blobSHA := range files {
+		if !s.isPostFile(filePath) {
+			continue
+		}
+		tagged[s.postID(filePath)] = true
+		if post, ok := stored[s.postID(filePath)]; ok && s.isLive(post) && post.SourcePath == filePath {
+			if s.publishedBlob(post.CommitSHA, filePath, publishedFiles) == blobSHA {
+				continue
+			}
+		}
+		changed = append(changed, filePath)
+	}
+	slices.Sort(changed)
+
+	var tasks []syncTask
+	for _, post := range posts {
+		// A post whose file was renamed is taken over by the new file rather than unpublished
+		if tagged[post.ID] || !s.isLive(post) {
+			continue
+		}
+		postID := post.ID
+		tasks = append(tasks, syncTask{
+			file: domain.SyncJobFile{Path: post.SourcePath, CommitSHA: sha, Action: domain.SyncActionRemove},
+			run: func(ctx context.Context) error {
+				return s.repo.Unpublish(ctx, postID)
+			},
+			render: pendingRemoval(post.SourcePath, sha, s.mainBranchName),
+		})
+	}
+
+	modifiedAt := s.commitTime(commit, s.clock.Now())
+	collisions := s.postIDCollisions(s.ctx, changed)
+	for _, filePath := range changed {
+		postID := s.postID(filePath)
+		if collisionErr, ok := collisions[filePath]; ok {
+			tasks = append(tasks, syncTask{
+				file: domain.SyncJobFile{Path: filePath, CommitSHA: sha, Action: domain.SyncActionUpsert},
+				run: func(ctx context.Context) error {
+					return collisionErr
+				},
+			})
+			continue
+		}
+
+		createdAt := modifiedAt
+		if post, ok := stored[postID]; ok {
+			createdAt = post.CreatedAt
+		}
+		fileInfo := commitFileInfo{
+			path:       filePath,
+			blobSHA:    files[filePath],
+			createdAt:  createdAt,
+			modifiedAt: modifiedAt,
+			released:   true,
+		}
+
+		tasks = append(tasks, syncTask{
+			file: domain.SyncJobFile{Path: filePath, CommitSHA: sha, Action: domain.SyncActionUpsert},
+			run: func(ctx context.Context) error {
+				return s.processPostFile(ctx, postID, fileInfo, sha, s.mainBranchName, nil)
+			},
+			render: pendingRender(fileInfo, sha, s.mainBranchName),
+		})
+	}
+
+	return tasks, nil
+}
+
+// isLive reports whether a post was published or scheduled from the main branch
+func (s *PostService) isLive(post *domain.Post) bool {
+	return post.Branch == s.mainBranchName && post.SourcePath != "" && (!post.PublishedAt.IsZero() || !post.PublishAt.IsZero())
+}
+
+// publishedBlob returns the blob SHA of a file at the commit a post was published from, or "" if it is unknown
+func (s *PostService) publishedBlob(commitSHA string, filePath string, trees map[string]map[string]string) string {
+	if commitSHA == "" {
+		return ""
+	}
+
+	files, ok := trees[commitSHA]
+	if !ok {
+		tree, err := s.sourceRepo.GetTree(s.ctx, commitSHA)
+		if err != nil {
+			log.Warn().Err(err).Str("commit", commitSHA).Msg("Failed to get tree of published commit, rendering its posts again")
+		} else {
+			files = treeFiles(tree)
+		}
+		trees[commitSHA] = files
+	}
+
+	return files[filePath]
+}
+
+// awaitPostVersion claims a post file version for processing, waiting for another run of it to finish
+// A release can arrive while the push that brought the same version to the main branch is still being processed.
+func (s *PostService) awaitPostVersion(ctx context.Context, key string) error {
+	for {
+		if _, busy := s.postVersions.LoadOrStore(key, struct{}{}); !busy {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(postVersionPollInterval):
+		}
+	}
+}
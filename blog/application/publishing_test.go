@@ -0,0 +1,160 @@
+package application
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/google/go-github/v75/github"
+)
+
+func TestPublishingConfig_Validate(t *testing.T) {
+	tests := []struct {
+		cfg     PublishingConfig
+		wantErr bool
+	}{
+		{cfg: PublishingConfig{Mode: PublishOnMerge}},
+		{cfg: PublishingConfig{Mode: PublishOnRelease, TagPattern: "v*"}},
+		{cfg: PublishingConfig{Mode: "releases"}, wantErr: true},
+		{cfg: PublishingConfig{Mode: PublishOnTag, TagPattern: "v["}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("Validate() of %+v error = %v, want error %v", tt.cfg, err, tt.wantErr)
+		}
+	}
+}
+
+func TestPostService_ProcessPostFile_WaitsForRelease(t *testing.T) {
+	repo := newFakePostRepository()
+	source := newFakeSourceRepository()
+	source.files["posts/001-post.md"] = []byte("# Post\n\nBody")
+	service := NewPostService(repo, newFakeImageRepository(), source, NewMarkdownRenderer(), "main",
+		WithPublishing(&PublishingConfig{Mode: PublishOnRelease}),
+	)
+	defer service.Close()
+
+	now := time.Now()
+	fileInfo := commitFileInfo{path: "posts/001-post.md", createdAt: now, modifiedAt: now}
+	if err := service.processPostFile(t.Context(), "001", fileInfo, "a", "main", nil); err != nil {
+		t.Fatalf("processPostFile() error = %v", err)
+	}
+	if _, ok := repo.posts["001"]; ok {
+		t.Fatal("Expected a merged post to wait for a release")
+	}
+
+	if err := service.processPostFile(t.Context(), "001", fileInfo, "a", "feature", nil); err != nil {
+		t.Fatalf("processPostFile() error = %v", err)
+	}
+	if post := repo.posts["001"]; post == nil || !post.PublishedAt.IsZero() {
+		t.Errorf("Expected an unpublished preview, got %+v", post)
+	}
+
+	fileInfo.released = true
+	if err := service.processPostFile(t.Context(), "001", fileInfo, "a", "main", nil); err != nil {
+		t.Fatalf("processPostFile() error = %v", err)
+	}
+	if post := repo.posts["001"]; post.Branch != "main" || post.PublishedAt.IsZero() {
+		t.Errorf("Expected a released post to be published, got %+v", post)
+	}
+}
+
+func TestPostService_PlanTagPublish(t *testing.T) {
+	published := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := newFakePostRepository(
+		&domain.Post{ID: "001", SourcePath: "posts/001-same.md", Branch: "main", CommitSHA: "r1", PublishedAt: published},
+		&domain.Post{ID: "002", SourcePath: "posts/002-edited.md", Branch: "main", CommitSHA: "r1", PublishedAt: published},
+		&domain.Post{ID: "003", SourcePath: "posts/003-removed.md", Branch: "main", CommitSHA: "r1", PublishedAt: published},
+		&domain.Post{ID: "005", SourcePath: "posts/005-draft.md", Branch: "feature"},
+	)
+	source := newFakeSourceRepository()
+	source.trees["r1"] = testTree(map[string]string{
+		"posts/001-same.md":    "s1",
+		"posts/002-edited.md":  "e1",
+		"posts/003-removed.md": "r1",
+	})
+	source.trees["r2"] = testTree(map[string]string{
+		"posts/001-same.md":   "s1",
+		"posts/002-edited.md": "e2",
+		"posts/004-new.md":    "n1",
+		"images/photo.png":    "p1",
+	})
+	source.files["r2:posts/002-edited.md"] = []byte("# Edited\n\nBody")
+	source.files["r2:posts/004-new.md"] = []byte("# New\n\nBody")
+
+	service := NewPostService(repo, newFakeImageRepository(), source, NewMarkdownRenderer(), "main",
+		WithPublishing(&PublishingConfig{Mode: PublishOnRelease}),
+	)
+	defer service.Close()
+
+	tasks, err := service.planTagPublish(testCommit("r2"))
+	if err != nil {
+		t.Fatalf("planTagPublish() error = %v", err)
+	}
+
+	want := []string{"remove posts/003-removed.md", "upsert posts/002-edited.md", "upsert posts/004-new.md"}
+	if got := taskFiles(tasks); !slices.Equal(got, want) {
+		t.Fatalf("tasks = %v, want %v", got, want)
+	}
+	for _, task := range tasks {
+		if err := task.run(t.Context()); err != nil {
+			t.Fatalf("task %s failed: %v", task.file.Path, err)
+		}
+	}
+
+	for _, id := range []string{"002", "004"} {
+		if post := repo.posts[id]; post.Branch != "main" || post.CommitSHA != "r2" || post.PublishedAt.IsZero() {
+			t.Errorf("Expected post %s to be published from the tag, got %+v", id, post)
+		}
+	}
+	if post := repo.posts["001"]; post.CommitSHA != "r1" || !post.PublishedAt.Equal(published) {
+		t.Errorf("Expected an unchanged post to be left alone, got %+v", post)
+	}
+	if !repo.posts["003"].PublishedAt.IsZero() {
+		t.Error("Expected a post missing from the tag to be unpublished")
+	}
+	if !repo.posts["005"].PublishedAt.IsZero() {
+		t.Error("Expected a draft to stay unpublished")
+	}
+}
+
+func TestPostService_HandleReleaseEvent_Ignored(t *testing.T) {
+	source := newFakeSourceRepository()
+	source.commits["tags/v1"] = testCommit("off-main")
+	source.comparisons["off-main...main"] = &github.CommitsComparison{Status: github.Ptr("diverged")}
+
+	release := func(tag string, prerelease bool) *github.ReleaseEvent {
+		return &github.ReleaseEvent{
+			Action:  github.Ptr("published"),
+			Release: &github.RepositoryRelease{TagName: github.Ptr(tag), Prerelease: github.Ptr(prerelease)},
+		}
+	}
+
+	tests := []struct {
+		name string
+		cfg  *PublishingConfig
+		evt  *github.ReleaseEvent
+	}{
+		{name: "merge mode", cfg: &PublishingConfig{Mode: PublishOnMerge}, evt: release("v1", false)},
+		{name: "tag mode", cfg: &PublishingConfig{Mode: PublishOnTag}, evt: release("v1", false)},
+		{name: "pre-release", cfg: &PublishingConfig{Mode: PublishOnRelease}, evt: release("v1", true)},
+		{name: "pattern", cfg: &PublishingConfig{Mode: PublishOnRelease, TagPattern: "release-*"}, evt: release("v1", false)},
+		{name: "not on main", cfg: &PublishingConfig{Mode: PublishOnRelease}, evt: release("v1", false)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewPostService(newFakePostRepository(), newFakeImageRepository(), source, NewMarkdownRenderer(), "main",
+				WithPublishing(tt.cfg),
+			)
+			defer service.Close()
+
+			jobID, err := service.HandleReleaseEvent(tt.evt)
+			if err != nil || jobID != 0 {
+				t.Errorf("HandleReleaseEvent() = %d, %v, want it ignored", jobID, err)
+			}
+		})
+	}
+}
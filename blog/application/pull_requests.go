@@ -0,0 +1,416 @@
+package application
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/google/go-github/v75/github"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// previewCommentMarker identifies the preview comment, so later pushes to a pull request edit it
+	previewCommentMarker = "<!-- goblog:previews -->"
+	// frontMatterCheckName names the check run reporting the front matter of a pull request's posts
+	frontMatterCheckName = "goblog/front-matter"
+	// previewTokenLength is how many hex digits of the link signature a preview link carries
+	previewTokenLength = 32
+)
+
+type PullRequestPreviewConfig struct {
+	// Comments turns on previewing pull requests; the GitHub token then needs write access to pull requests
+	Comments bool
+	// BaseURL is where the blog is served, used to build preview links
+	BaseURL string
+	// LinkSecret signs preview links; comments carry render warnings but no links when it is empty
+	LinkSecret string
+	// Checks reports the front matter of the pull request's posts as a check run annotating each problem
+	Checks bool
+}
+
+func NewPullRequestPreviewConfig() *PullRequestPreviewConfig {
+	baseURL := strings.TrimSuffix(os.Getenv("SITE_BASE_URL"), "/")
+	if baseURL == "" {
+		baseURL = blogURL
+	}
+
+	return &PullRequestPreviewConfig{
+		Comments:   os.Getenv("PR_PREVIEW_COMMENTS") == "true",
+		BaseURL:    baseURL,
+		LinkSecret: os.Getenv("PREVIEW_LINK_SECRET"),
+		Checks:     os.Getenv("PR_CHECKS") == "true",
+	}
+}
+
+// WithPullRequestPreviews renders the posts of pull requests as drafts and comments on the pull request with
+// preview links and render warnings, when cfg turns comments on
+// Without it, pull request events are ignored and preview links are disabled.
+func WithPullRequestPreviews(commenter domain.PullRequestCommenter, cfg *PullRequestPreviewConfig) PostServiceOption {
+	return func(s *PostService) {
+		s.prCommenter = commenter
+		s.prPreviews = cfg
+	}
+}
+
+// WithPullRequestChecks reports front matter problems in pull requests as check runs, when the preview
+// config turns checks on
+func WithPullRequestChecks(checks domain.CheckReporter) PostServiceOption {
+	return func(s *PostService) {
+		s.prChecks = checks
+	}
+}
+
+// PostPreview is a post rendered from a pull request, with what is wrong with it
+type PostPreview struct {
+	PostID string
+	Path   string
+	Title  string
+	// URL is the signed preview link, or empty when preview links are disabled or the post failed to render
+	URL string
+	// Err is why the post could not be rendered
+	Err error
+	// MissingImages lists the images the post references that are not in the pull request
+	MissingImages []string
+	// BrokenLinks lists the post's links to other post files that are not in the pull request
+	BrokenLinks []string
+	// FrontMatter lists the front matter problems the post was rendered in spite of
+	FrontMatter []FrontMatterIssue
+}
+
+// HandlePullRequestEvent renders the posts a pull request adds or changes as drafts on its branch,
+// then comments on the pull request with their preview links and render warnings, and reports their front
+// matter as a check run on its head commit
+// Only pull requests into the main branch from the repository itself are previewed: a fork's branch can't be
+// fetched by name, and its content is untrusted. It returns a zero job ID when the event previews nothing.
+func (s *PostService) HandlePullRequestEvent(evt *github.PullRequestEvent) (int64, error) {
+	commenting := s.prCommenter != nil && s.prPreviews.Comments
+	checking := s.prChecks != nil && s.prPreviews != nil && s.prPreviews.Checks
+	if !commenting && !checking {
+		return 0, nil
+	}
+	switch evt.GetAction() {
+	case "opened", "reopened", "synchronize":
+	default:
+		return 0, nil
+	}
+
+	pr := evt.GetPullRequest()
+	number := evt.GetNumber()
+	if pr.GetBase().GetRef() != s.mainBranchName {
+		return 0, nil
+	}
+	if repo := pr.GetHead().GetRepo(); repo.GetFullName() != evt.GetRepo().GetFullName() {
+		log.Info().Int("pullRequest", number).Str("repo", repo.GetFullName()).Msg("Not previewing pull request from a fork")
+		return 0, nil
+	}
+
+	branch := pr.GetHead().GetRef()
+	headSHA := pr.GetHead().GetSHA()
+	comparison, err := s.sourceRepo.CompareCommits(s.ctx, pr.GetBase().GetSHA(), headSHA)
+	if err != nil {
+		return 0, fmt.Errorf("failed to compare pull request #%d: %w", number, err)
+	}
+
+	var postFiles, imageFiles []*github.CommitFile
+	for _, file := range comparison.Files {
+		switch {
+		case file.GetStatus() == "removed":
+		case s.isPostFile(file.GetFilename()):
+			postFiles = append(postFiles, file)
+		case s.isImageFile(file.GetFilename()):
+			imageFiles = append(imageFiles, file)
+		}
+	}
+	if len(postFiles) == 0 {
+		return 0, nil
+	}
+
+	jobID, err := s.startSyncJob("refs/heads/"+branch, pr.GetBase().GetSHA(), headSHA)
+	if err != nil {
+		return 0, err
+	}
+
+	tasks, previews := s.planPullRequestPreview(postFiles, imageFiles, branch, headSHA)
+	s.addSyncJobFiles(jobID, tasks)
+	log.Info().Int("pullRequest", number).Str("branch", branch).Int("posts", len(postFiles)).Msg("Previewing pull request")
+
+	s.runSyncTasks(jobID, tasks, func() {
+		previews := previews()
+		if commenting {
+			s.commentPreviews(number, branch, headSHA, previews)
+		}
+		if checking {
+			run := frontMatterCheck(headSHA, previews)
+			if err := s.prChecks.ReportCheck(s.ctx, run); err != nil {
+				log.Error().Err(err).Int("pullRequest", number).Str("commit", headSHA).Msg("Failed to report front matter check")
+			}
+		}
+	})
+
+	return jobID, nil
+}
+
+// planPullRequestPreview returns the work of rendering a pull request's posts and storing its images,
+// along with a function that reports on the posts once the work is done
+func (s *PostService) planPullRequestPreview(postFiles []*github.CommitFile, imageFiles []*github.CommitFile, branch string, headSHA string) ([]syncTask, func() []*PostPreview) {
+	renders := newRenderCache()
+	var mu sync.Mutex
+	failures := make(map[string]error)
+
+	paths := make([]string, 0, len(postFiles))
+	for _, file := range postFiles {
+		paths = append(paths, file.GetFilename())
+	}
+	collisions := s.postIDCollisions(s.ctx, paths)
+
+	var tasks []syncTask
+	fileInfos := make([]commitFileInfo, 0, len(postFiles))
+	modifiedAt := s.clock.Now()
+	for _, file := range postFiles {
+		filePath := file.GetFilename()
+		postID := s.postID(filePath)
+		createdAt := modifiedAt
+		if existing, err := s.repo.GetPost(s.ctx, postID); err == nil {
+			createdAt = existing.CreatedAt
+		}
+		fileInfo := commitFileInfo{path: filePath, blobSHA: file.GetSHA(), createdAt: createdAt, modifiedAt: modifiedAt}
+		fileInfos = append(fileInfos, fileInfo)
+
+		tasks = append(tasks, syncTask{
+			file: domain.SyncJobFile{Path: filePath, CommitSHA: headSHA, Action: domain.SyncActionUpsert},
+			run: func(ctx context.Context) error {
+				err := collisions[filePath]
+				if err == nil {
+					err = s.processPostFile(ctx, postID, fileInfo, headSHA, branch, renders)
+				}
+				if err != nil {
+					mu.Lock()
+					failures[filePath] = err
+					mu.Unlock()
+				}
+				return err
+			},
+			render: pendingRender(fileInfo, headSHA, branch),
+		})
+	}
+
+	for _, file := range imageFiles {
+		imagePath := file.GetFilename()
+		tasks = append(tasks, syncTask{
+			file: domain.SyncJobFile{Path: imagePath, CommitSHA: headSHA, Action: domain.SyncActionUpsert},
+			run: func(ctx context.Context) error {
+				return s.processImageFile(ctx, imagePath, headSHA)
+			},
+			render: pendingImageRender(imagePath, headSHA),
+		})
+	}
+
+	previews := func() []*PostPreview {
+		mu.Lock()
+		defer mu.Unlock()
+		return s.previewPosts(fileInfos, headSHA, branch, renders, failures)
+	}
+	return tasks, previews
+}
+
+// previewPosts reports on the posts of a pull request, checking their images and links against its head
+// A post processed by an overlapping push is missing from renders and is rendered again.
+func (s *PostService) previewPosts(fileInfos []commitFileInfo, headSHA string, branch string, renders *renderCache, failures map[string]error) []*PostPreview {
+	var files map[string]string
+	if tree, err := s.sourceRepo.GetTree(s.ctx, headSHA); err != nil {
+		log.Warn().Err(err).Str("commit", headSHA).Msg("Failed to get pull request tree, skipping image and link checks")
+	} else {
+		files = treeFiles(tree)
+	}
+
+	postNames := make(map[string]bool)
+	for filePath := range files {
+		if s.isPostFile(filePath) {
+			postNames[path.Base(filePath)] = true
+		}
+	}
+
+	previews := make([]*PostPreview, 0, len(fileInfos))
+	for _, fileInfo := range fileInfos {
+		preview := &PostPreview{PostID: s.postID(fileInfo.path), Path: fileInfo.path, Err: failures[fileInfo.path]}
+		previews = append(previews, preview)
+		if preview.Err != nil {
+			continue
+		}
+
+		result, err := s.renderPostFile(s.ctx, fileInfo, headSHA, renders)
+		if err != nil {
+			preview.Err = err
+			continue
+		}
+		preview.Title = result.Title
+		preview.URL = s.prPreviews.previewURL(preview.PostID, branch)
+		preview.FrontMatter = result.FrontMatter
+
+		if files == nil {
+			continue
+		}
+		for _, imagePath := range result.Images {
+			if _, ok := files[imagePath]; !ok {
+				preview.MissingImages = append(preview.MissingImages, imagePath)
+			}
+		}
+		for _, dest := range result.Links {
+			if name, ok := linkedPostFile(dest); ok && !postNames[name] {
+				preview.BrokenLinks = append(preview.BrokenLinks, dest)
+			}
+		}
+	}
+
+	slices.SortFunc(previews, func(a, b *PostPreview) int { return strings.Compare(a.Path, b.Path) })
+	return previews
+}
+
+// linkedPostFile returns the name of the post file a relative link points at, or false if it doesn't name a .md file
+// Links are resolved by file name alone, the same way the renderer rewrites them.
+func linkedPostFile(dest string) (string, bool) {
+	dest, _, _ = strings.Cut(dest, "#")
+	dest, _, _ = strings.Cut(dest, "?")
+	name := path.Base(dest)
+	return name, strings.HasSuffix(name, ".md")
+}
+
+// commentPreviews writes the preview comment of a pull request, logging any failure
+func (s *PostService) commentPreviews(number int, branch string, headSHA string, previews []*PostPreview) {
+	body := previewComment(headSHA, previews)
+	if err := s.prCommenter.UpsertComment(s.ctx, number, previewCommentMarker, body); err != nil {
+		log.Error().Err(err).Int("pullRequest", number).Str("branch", branch).Msg("Failed to comment on pull request")
+	}
+}
+
+// previewComment formats the preview comment of a pull request as GitHub markdown
+func previewComment(headSHA string, previews []*PostPreview) string {
+	var b strings.Builder
+	b.WriteString(previewCommentMarker + "\n")
+	b.WriteString("### Post previews\n\n")
+	fmt.Fprintf(&b, "Rendered from %s.\n", headSHA[:min(len(headSHA), 7)])
+
+	for _, preview := range previews {
+		b.WriteString("\n")
+		switch {
+		case preview.URL != "":
+			fmt.Fprintf(&b, "**[%s](%s)** `%s`\n", preview.Title, preview.URL, preview.Path)
+		case preview.Title != "":
+			fmt.Fprintf(&b, "**%s** `%s`\n", preview.Title, preview.Path)
+		default:
+			fmt.Fprintf(&b, "`%s`\n", preview.Path)
+		}
+
+		if preview.Err != nil {
+			fmt.Fprintf(&b, "- :x: Failed to render: %v\n", preview.Err)
+			continue
+		}
+		for _, imagePath := range preview.MissingImages {
+			fmt.Fprintf(&b, "- :warning: Missing image `%s`\n", imagePath)
+		}
+		for _, dest := range preview.BrokenLinks {
+			fmt.Fprintf(&b, "- :warning: Broken link `%s`\n", dest)
+		}
+		for _, issue := range preview.FrontMatter {
+			fmt.Fprintf(&b, "- :warning: Front matter %s\n", issue)
+		}
+		if len(preview.MissingImages) == 0 && len(preview.BrokenLinks) == 0 && len(preview.FrontMatter) == 0 {
+			b.WriteString("- No warnings\n")
+		}
+	}
+
+	return b.String()
+}
+
+// frontMatterCheck is the check run of a pull request's front matter, annotating each problem on its line
+// Invalid front matter fails the check, since the post won't publish; problems it was rendered in spite of
+// only warn. Posts that failed to render for other reasons are left to the preview comment.
+func frontMatterCheck(headSHA string, previews []*PostPreview) *domain.CheckRun {
+	run := &domain.CheckRun{Name: frontMatterCheckName, HeadSHA: headSHA, Conclusion: domain.CheckSuccess}
+	invalid, warned := 0, 0
+	for _, preview := range previews {
+		level := domain.CheckAnnotationWarning
+		issues := preview.FrontMatter
+		var fmErr *FrontMatterError
+		if errors.As(preview.Err, &fmErr) {
+			level = domain.CheckAnnotationFailure
+			issues = fmErr.Issues
+			invalid++
+		} else if len(issues) > 0 {
+			warned++
+		}
+
+		for _, issue := range issues {
+			run.Annotations = append(run.Annotations, domain.CheckAnnotation{
+				Path:    preview.Path,
+				Line:    issue.Line,
+				Level:   level,
+				Message: issue.Message,
+			})
+		}
+	}
+
+	switch {
+	case invalid > 0:
+		run.Conclusion = domain.CheckFailure
+		run.Title = "Invalid front matter in " + plural(invalid, "post")
+		run.Summary = "Posts with invalid front matter are not published until it is fixed."
+	case warned > 0:
+		run.Conclusion = domain.CheckNeutral
+		run.Title = "Front matter warnings in " + plural(warned, "post")
+		run.Summary = "These problems are ignored now, but would stop the posts publishing with strict front matter validation."
+	default:
+		run.Title = "Front matter is valid"
+		run.Summary = "Checked " + plural(len(previews), "post") + "."
+	}
+	return run
+}
+
+// previewURL returns the signed preview link of a post rendered on branch, or "" when preview links are disabled
+func (c *PullRequestPreviewConfig) previewURL(postID string, branch string) string {
+	if c == nil || c.LinkSecret == "" {
+		return ""
+	}
+	return c.BaseURL + "/previews/" + postID + "?token=" + c.previewToken(postID, branch)
+}
+
+// previewToken signs a post ID and branch, so a link only shows the draft of the branch it was made for
+func (c *PullRequestPreviewConfig) previewToken(postID string, branch string) string {
+	mac := hmac.New(sha256.New, []byte(c.LinkSecret))
+	mac.Write([]byte(postID + "\x00" + branch))
+	return hex.EncodeToString(mac.Sum(nil))[:previewTokenLength]
+}
+
+// GetPreviewPost returns a post with its content when token is its signed preview link, whether or not it is published
+// Any other token, or preview links being disabled, reports the post as not found.
+func (s *PostService) GetPreviewPost(ctx context.Context, id string, token string) (*domain.Post, error) {
+	if s.prPreviews == nil || s.prPreviews.LinkSecret == "" {
+		return nil, fmt.Errorf("%w: %s", domain.ErrPostNotFound, id)
+	}
+
+	post, err := s.repo.GetPost(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal([]byte(token), []byte(s.prPreviews.previewToken(id, post.Branch))) {
+		return nil, fmt.Errorf("%w: %s", domain.ErrPostNotFound, id)
+	}
+
+	content, err := s.repo.GetPostHTML(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	post.HTMLContent = content
+
+	return post, nil
+}
@@ -0,0 +1,190 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/google/go-github/v75/github"
+)
+
+// fakeCommenter hands each comment it is asked to write to comments
+type fakeCommenter struct {
+	comments chan string
+}
+
+func (f *fakeCommenter) UpsertComment(ctx context.Context, number int, marker string, body string) error {
+	if !strings.Contains(body, marker) {
+		return errors.New("comment body is missing its marker")
+	}
+	f.comments <- body
+	return nil
+}
+
+// fakeCheckReporter hands each check run it is asked to report to runs
+type fakeCheckReporter struct {
+	runs chan *domain.CheckRun
+}
+
+func (f *fakeCheckReporter) ReportCheck(ctx context.Context, run *domain.CheckRun) error {
+	f.runs <- run
+	return nil
+}
+
+func testPullRequestEvent(action string, headRepo string) *github.PullRequestEvent {
+	return &github.PullRequestEvent{
+		Action: github.Ptr(action),
+		Number: github.Ptr(7),
+		Repo:   &github.Repository{FullName: github.Ptr("owner/blog")},
+		PullRequest: &github.PullRequest{
+			Base: &github.PullRequestBranch{Ref: github.Ptr("main"), SHA: github.Ptr("base")},
+			Head: &github.PullRequestBranch{
+				Ref:  github.Ptr("feature"),
+				SHA:  github.Ptr("head1234567"),
+				Repo: &github.Repository{FullName: github.Ptr(headRepo)},
+			},
+		},
+	}
+}
+
+func TestPostService_HandlePullRequestEvent(t *testing.T) {
+	source := newFakeSourceRepository()
+	source.comparisons["base...head1234567"] = &github.CommitsComparison{
+		Files: []*github.CommitFile{
+			{Filename: github.Ptr("posts/001-good.md"), Status: github.Ptr("added"), SHA: github.Ptr("g1")},
+			{Filename: github.Ptr("posts/002-broken.md"), Status: github.Ptr("modified"), SHA: github.Ptr("b1")},
+			{Filename: github.Ptr("posts/003-gone.md"), Status: github.Ptr("removed")},
+		},
+	}
+	source.trees["head1234567"] = testTree(map[string]string{
+		"posts/001-good.md":   "g1",
+		"posts/002-broken.md": "b1",
+		"images/photo.png":    "p1",
+	})
+	source.files["head1234567:posts/001-good.md"] = []byte("# Good\n\n![Photo](images/photo.png) and [the other post](002-broken.md#intro)")
+	source.files["head1234567:posts/002-broken.md"] = []byte("# Broken\n\n![Missing](images/missing.png) and [a typo](001-god.md)")
+
+	repo := newFakePostRepository()
+	commenter := &fakeCommenter{comments: make(chan string, 1)}
+	service := NewPostService(repo, newFakeImageRepository(), source, NewMarkdownRenderer(), "main",
+		WithPullRequestPreviews(commenter, &PullRequestPreviewConfig{
+			Comments:   true,
+			BaseURL:    "https://blog.example.com",
+			LinkSecret: "secret",
+		}),
+	)
+	defer service.Close()
+
+	if _, err := service.HandlePullRequestEvent(testPullRequestEvent("opened", "owner/blog")); err != nil {
+		t.Fatalf("HandlePullRequestEvent() error = %v", err)
+	}
+
+	var body string
+	select {
+	case body = <-commenter.comments:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the preview comment")
+	}
+
+	token := service.prPreviews.previewToken("001", "feature")
+	for _, want := range []string{
+		previewCommentMarker,
+		"Rendered from head123.",
+		"**[Good](https://blog.example.com/previews/001?token=" + token + ")** `posts/001-good.md`\n- No warnings",
+		"- :warning: Missing image `images/missing.png`",
+		"- :warning: Broken link `001-god.md`",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("comment is missing %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "003-gone") {
+		t.Errorf("comment previews a removed post:\n%s", body)
+	}
+
+	for _, id := range []string{"001", "002"} {
+		if post := repo.posts[id]; post == nil || post.Branch != "feature" || !post.PublishedAt.IsZero() {
+			t.Errorf("Expected post %s to be an unpublished draft on the branch, got %+v", id, post)
+		}
+	}
+
+	if _, err := service.GetPreviewPost(t.Context(), "001", token); err != nil {
+		t.Errorf("GetPreviewPost() with the signed token error = %v", err)
+	}
+	if _, err := service.GetPreviewPost(t.Context(), "002", token); !errors.Is(err, domain.ErrPostNotFound) {
+		t.Errorf("GetPreviewPost() with another post's token error = %v, want ErrPostNotFound", err)
+	}
+}
+
+func TestPostService_HandlePullRequestEvent_FrontMatterCheck(t *testing.T) {
+	source := newFakeSourceRepository()
+	source.comparisons["base...head1234567"] = &github.CommitsComparison{
+		Files: []*github.CommitFile{
+			{Filename: github.Ptr("posts/001-good.md"), Status: github.Ptr("added"), SHA: github.Ptr("g1")},
+			{Filename: github.Ptr("posts/002-tagged.md"), Status: github.Ptr("added"), SHA: github.Ptr("t1")},
+			{Filename: github.Ptr("posts/003-unscheduled.md"), Status: github.Ptr("added"), SHA: github.Ptr("u1")},
+		},
+	}
+	source.files["head1234567:posts/001-good.md"] = []byte("---\nlang: de\n---\n# Good")
+	source.files["head1234567:posts/002-tagged.md"] = []byte("---\nlang: de\ntags: go\n---\n# Tagged")
+	source.files["head1234567:posts/003-unscheduled.md"] = []byte("---\npublish_at: soon\n---\n# Unscheduled")
+
+	checks := &fakeCheckReporter{runs: make(chan *domain.CheckRun, 1)}
+	service := NewPostService(newFakePostRepository(), newFakeImageRepository(), source, NewMarkdownRenderer(), "main",
+		WithPullRequestPreviews(nil, &PullRequestPreviewConfig{Checks: true}),
+		WithPullRequestChecks(checks),
+	)
+	defer service.Close()
+
+	if _, err := service.HandlePullRequestEvent(testPullRequestEvent("opened", "owner/blog")); err != nil {
+		t.Fatalf("HandlePullRequestEvent() error = %v", err)
+	}
+
+	var run *domain.CheckRun
+	select {
+	case run = <-checks.runs:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the check run")
+	}
+
+	if run.Name != frontMatterCheckName || run.HeadSHA != "head1234567" || run.Conclusion != domain.CheckFailure {
+		t.Errorf("check run = %+v, want a failure on the head commit", run)
+	}
+	want := []domain.CheckAnnotation{
+		{Path: "posts/002-tagged.md", Line: 3, Level: domain.CheckAnnotationWarning, Message: `unknown key "tags": expected one of lang, publish_at`},
+		{Path: "posts/003-unscheduled.md", Line: 2, Level: domain.CheckAnnotationFailure, Message: `invalid publish_at "soon": expected a date, a local time or an RFC 3339 timestamp`},
+	}
+	if !slices.Equal(run.Annotations, want) {
+		t.Errorf("annotations = %+v, want %+v", run.Annotations, want)
+	}
+}
+
+func TestPostService_HandlePullRequestEvent_Ignored(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *PullRequestPreviewConfig
+		evt  *github.PullRequestEvent
+	}{
+		{name: "comments off", cfg: &PullRequestPreviewConfig{}, evt: testPullRequestEvent("opened", "owner/blog")},
+		{name: "closed", cfg: &PullRequestPreviewConfig{Comments: true}, evt: testPullRequestEvent("closed", "owner/blog")},
+		{name: "fork", cfg: &PullRequestPreviewConfig{Comments: true}, evt: testPullRequestEvent("opened", "someone/blog")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewPostService(newFakePostRepository(), newFakeImageRepository(), newFakeSourceRepository(), NewMarkdownRenderer(), "main",
+				WithPullRequestPreviews(&fakeCommenter{}, tt.cfg),
+			)
+			defer service.Close()
+
+			jobID, err := service.HandlePullRequestEvent(tt.evt)
+			if err != nil || jobID != 0 {
+				t.Errorf("HandlePullRequestEvent() = %d, %v, want it ignored", jobID, err)
+			}
+		})
+	}
+}
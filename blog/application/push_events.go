@@ -0,0 +1,253 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/google/go-github/v75/github"
+	"github.com/rs/zerolog/log"
+)
+
+// zeroSHA is reported as the before of a push that creates a branch and the after of one that deletes it
+const zeroSHA = "0000000000000000000000000000000000000000"
+
+// planBranchDeletion cleans up the previews rendered from a deleted branch
+// A preview whose file is also on main is rendered from main again, so the draft doesn't hide the merged post.
+// Other previews are deleted when closed previews are, and otherwise left for CleanupPreviews to expire.
+// Posts published from the branch by hand are left alone.
+func (s *PostService) planBranchDeletion(evt *github.PushEvent) ([]syncTask, error) {
+	branch, isBranch := strings.CutPrefix(evt.GetRef(), "refs/heads/")
+	if !isBranch {
+		return nil, nil
+	}
+	if branch == s.mainBranchName {
+		log.Warn().Str("branch", branch).Msg("Main branch deleted, leaving its posts in place")
+		return nil, nil
+	}
+
+	posts, err := s.repo.ListPosts(s.ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list posts: %w", err)
+	}
+
+	var previews []*domain.Post
+	for _, post := range posts {
+		if post.Branch == branch && post.SourcePath != "" && post.PublishedAt.IsZero() && post.PublishAt.IsZero() {
+			previews = append(previews, post)
+		}
+	}
+	if len(previews) == 0 {
+		return nil, nil
+	}
+
+	mainHead, err := s.branchHead(s.mainBranchName)
+	if err != nil {
+		return nil, err
+	}
+	mainSHA := mainHead.GetSHA()
+	deleteClosed := s.previewRetention != nil && s.previewRetention.DeleteClosed
+
+	var tasks []syncTask
+	for _, post := range previews {
+		postID := post.ID
+		_, err := s.sourceRepo.GetFileContents(s.ctx, post.SourcePath, mainSHA)
+		switch {
+		case err == nil:
+			fileInfo := commitFileInfo{
+				path:       post.SourcePath,
+				createdAt:  post.CreatedAt,
+				modifiedAt: s.commitTime(mainHead, time.Time{}),
+			}
+			tasks = append(tasks, syncTask{
+				file: domain.SyncJobFile{Path: post.SourcePath, CommitSHA: mainSHA, Action: domain.SyncActionUpsert},
+				run: func(ctx context.Context) error {
+					return s.processPostFile(ctx, postID, fileInfo, mainSHA, s.mainBranchName, nil)
+				},
+				render: pendingRender(fileInfo, mainSHA, s.mainBranchName),
+			})
+		case errors.Is(err, domain.ErrSourceFileNotFound):
+			if !deleteClosed {
+				continue
+			}
+			tasks = append(tasks, syncTask{
+				file: domain.SyncJobFile{Path: post.SourcePath, CommitSHA: evt.GetBefore(), Action: domain.SyncActionRemove},
+				run: func(ctx context.Context) error {
+					return s.repo.DeletePost(ctx, postID)
+				},
+				render: pendingRemoval(post.SourcePath, evt.GetBefore(), branch),
+			})
+		default:
+			return nil, fmt.Errorf("failed to look up %s on %s: %w", post.SourcePath, s.mainBranchName, err)
+		}
+	}
+
+	log.Info().Str("branch", branch).Int("previews", len(tasks)).Msg("Branch deleted, cleaning up its previews")
+	return tasks, nil
+}
+
+// branchHead returns the commit at the head of a branch
+func (s *PostService) branchHead(name string) (*github.RepositoryCommit, error) {
+	branches, err := s.sourceRepo.ListBranches(s.ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve branches: %w", err)
+	}
+
+	for _, b := range branches {
+		if b.GetName() == name {
+			commit, err := s.sourceRepo.GetCommit(s.ctx, b.GetCommit().GetSHA())
+			if err != nil {
+				return nil, fmt.Errorf("failed to get head commit of %s: %w", name, err)
+			}
+			return commit, nil
+		}
+	}
+
+	return nil, fmt.Errorf("branch %s not found", name)
+}
+
+// analyzeTreeDiff determines changed files from the trees of the old and new heads of a force push
+// Comparing commits doesn't work after a force push: the old head may be unreachable, and if it isn't,
+// the comparison starts at the merge base and misses whatever the replaced commits changed.
+// Every changed file is attributed to the new head. If the old tree can't be fetched, the branch's stored posts
+// stand in for it, so every file in the new tree is processed and posts missing from it are removed.
+func (s *PostService) analyzeTreeDiff(branch string, before string, after string) (*commitAnalysisResult, error) {
+	headCommit, err := s.sourceRepo.GetCommit(s.ctx, after)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit %s: %w", after, err)
+	}
+
+	newTree, err := s.sourceRepo.GetTree(s.ctx, after)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tree of %s: %w", after, err)
+	}
+	newFiles := treeFiles(newTree)
+
+	var oldFiles map[string]string
+	oldTree, err := s.sourceRepo.GetTree(s.ctx, before)
+	if err == nil {
+		oldFiles = treeFiles(oldTree)
+	} else {
+		log.Warn().Err(err).Str("branch", branch).Str("before", before).Msg("Old head of force push unavailable, processing the whole tree")
+		if oldFiles, err = s.storedBranchFiles(branch); err != nil {
+			return nil, err
+		}
+	}
+
+	analyzer := s.newCommitAnalyzer()
+	if !s.addTreeDiff(analyzer, oldFiles, newFiles, headCommit) {
+		// There are no commits to fall back to, and posts moved to several paths are kept rather than removed
+		log.Warn().Str("branch", branch).Str("after", after).Msg("Posts of force push moved to more than one path")
+	}
+
+	return analyzer.result(), nil
+}
+
+// analyzeRangeTrees determines changed files from the trees of base and head, attributing every file to head
+// It reports false when the trees can't stand in for the commits between them: a tree was truncated, or a post moved
+// to more than one path and only the commits' rename detection can tell which one it became.
+func (s *PostService) analyzeRangeTrees(baseSHA string, headSHA string) (*commitAnalysisResult, bool, error) {
+	headCommit, err := s.sourceRepo.GetCommit(s.ctx, headSHA)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get head commit %s: %w", headSHA, err)
+	}
+
+	baseTree, err := s.sourceRepo.GetTree(s.ctx, baseSHA)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get tree of %s: %w", baseSHA, err)
+	}
+	headTree, err := s.sourceRepo.GetTree(s.ctx, headSHA)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get tree of %s: %w", headSHA, err)
+	}
+	if baseTree.GetTruncated() || headTree.GetTruncated() {
+		return nil, false, nil
+	}
+
+	analyzer := s.newCommitAnalyzer()
+	if !s.addTreeDiff(analyzer, treeFiles(baseTree), treeFiles(headTree), headCommit) {
+		return nil, false, nil
+	}
+	return analyzer.result(), true, nil
+}
+
+// addTreeDiff records the differences between two maps of paths to blob SHAs, attributing them to commit
+// Trees carry no renames, so a removed post whose ID is added at another path is recorded as renamed to it, as the
+// commit would report it; recording it as removed would delete the post after it is updated. If the ID is added at
+// more than one path the removal is skipped and false is returned.
+func (s *PostService) addTreeDiff(analyzer *commitAnalyzer, oldFiles map[string]string, newFiles map[string]string, commit *github.RepositoryCommit) bool {
+	addedPosts := make(map[string][]string)
+	for path, sha := range newFiles {
+		oldSHA, ok := oldFiles[path]
+		if !ok {
+			if postID := s.postID(path); postID != "" {
+				addedPosts[postID] = append(addedPosts[postID], path)
+			}
+			continue
+		}
+		if oldSHA != sha {
+			analyzer.add(path, "modified", "", commit)
+		}
+	}
+
+	unambiguous := true
+	renamedTo := make(map[string]string)
+	for path := range oldFiles {
+		if _, ok := newFiles[path]; ok {
+			continue
+		}
+		postID := s.postID(path)
+		targets := addedPosts[postID]
+		switch {
+		case postID == "" || len(targets) == 0:
+			analyzer.add(path, "removed", "", commit)
+		case len(targets) == 1:
+			renamedTo[targets[0]] = path
+		default:
+			unambiguous = false
+		}
+	}
+
+	for path := range newFiles {
+		if _, ok := oldFiles[path]; ok {
+			continue
+		}
+		if previousPath, ok := renamedTo[path]; ok {
+			analyzer.add(path, "renamed", previousPath, commit)
+		} else {
+			analyzer.add(path, "added", "", commit)
+		}
+	}
+	return unambiguous
+}
+
+// treeFiles maps the path of every file in a tree to its blob SHA
+func treeFiles(tree *github.Tree) map[string]string {
+	files := make(map[string]string, len(tree.Entries))
+	for _, entry := range tree.Entries {
+		if entry.GetType() == "blob" {
+			files[entry.GetPath()] = entry.GetSHA()
+		}
+	}
+	return files
+}
+
+// storedBranchFiles maps the source path of every post last rendered from branch to an empty blob SHA,
+// which no file in a tree matches
+func (s *PostService) storedBranchFiles(branch string) (map[string]string, error) {
+	posts, err := s.repo.ListPosts(s.ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list posts: %w", err)
+	}
+
+	files := make(map[string]string)
+	for _, post := range posts {
+		if post.Branch == branch && post.SourcePath != "" {
+			files[post.SourcePath] = ""
+		}
+	}
+	return files, nil
+}
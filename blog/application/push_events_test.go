@@ -0,0 +1,167 @@
+package application
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/google/go-github/v75/github"
+)
+
+// taskFiles lists the action and path of each task, sorted
+func taskFiles(tasks []syncTask) []string {
+	files := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		files = append(files, string(task.file.Action)+" "+task.file.Path)
+	}
+	slices.Sort(files)
+	return files
+}
+
+func testTree(files map[string]string) *github.Tree {
+	tree := &github.Tree{}
+	for path, sha := range files {
+		tree.Entries = append(tree.Entries, &github.TreeEntry{Path: github.Ptr(path), SHA: github.Ptr(sha), Type: github.Ptr("blob")})
+	}
+	return tree
+}
+
+func TestPostService_PlanPushEvent_BranchDeleted(t *testing.T) {
+	now := time.Now().UTC()
+	repo := newFakePostRepository(
+		&domain.Post{ID: "001", SourcePath: "posts/001-merged.md", Branch: "feature", CreatedAt: now},
+		&domain.Post{ID: "002", SourcePath: "posts/002-draft.md", Branch: "feature"},
+		&domain.Post{ID: "003", SourcePath: "posts/003-other.md", Branch: "other"},
+		&domain.Post{ID: "004", SourcePath: "posts/004-published.md", Branch: "feature", PublishedAt: now},
+	)
+	source := newFakeSourceRepository()
+	source.branches = []*github.Branch{{Name: github.Ptr("main"), Commit: &github.RepositoryCommit{SHA: github.Ptr("m")}}}
+	source.commits["m"] = testCommit("m")
+	source.files["m:posts/001-merged.md"] = []byte("# Merged\n\nBody")
+
+	service := NewPostService(repo, newFakeImageRepository(), source, NewMarkdownRenderer(), "main",
+		WithPreviewRetention(&PreviewRetentionConfig{DeleteClosed: true}),
+	)
+	defer service.Close()
+
+	tasks, err := service.planPushEvent(&github.PushEvent{
+		Ref:     github.Ptr("refs/heads/feature"),
+		Before:  github.Ptr("f"),
+		After:   github.Ptr(zeroSHA),
+		Deleted: github.Ptr(true),
+	})
+	if err != nil {
+		t.Fatalf("planPushEvent() error = %v", err)
+	}
+
+	want := []string{"remove posts/002-draft.md", "upsert posts/001-merged.md"}
+	if got := taskFiles(tasks); !slices.Equal(got, want) {
+		t.Fatalf("tasks = %v, want %v", got, want)
+	}
+	for _, task := range tasks {
+		if err := task.run(t.Context()); err != nil {
+			t.Fatalf("task %s failed: %v", task.file.Path, err)
+		}
+	}
+
+	if post := repo.posts["001"]; post.Branch != "main" || post.CommitSHA != "m" || post.PublishedAt.IsZero() {
+		t.Errorf("Expected the merged post to be restored from main, got %+v", post)
+	}
+	if _, ok := repo.posts["002"]; ok {
+		t.Error("Expected the draft of the deleted branch to be deleted")
+	}
+	if _, ok := repo.posts["003"]; !ok {
+		t.Error("Expected the preview of another branch to be kept")
+	}
+	if _, ok := repo.posts["004"]; !ok {
+		t.Error("Expected a published post to be kept")
+	}
+}
+
+func TestPostService_PlanPushEvent_BranchDeletedKeepsClosedPreviews(t *testing.T) {
+	repo := newFakePostRepository(&domain.Post{ID: "002", SourcePath: "posts/002-draft.md", Branch: "feature"})
+	source := newFakeSourceRepository()
+	source.branches = []*github.Branch{{Name: github.Ptr("main"), Commit: &github.RepositoryCommit{SHA: github.Ptr("m")}}}
+	source.commits["m"] = testCommit("m")
+
+	service := NewPostService(repo, newFakeImageRepository(), source, NewMarkdownRenderer(), "main",
+		WithPreviewRetention(&PreviewRetentionConfig{DeleteClosed: false}),
+	)
+	defer service.Close()
+
+	tasks, err := service.planPushEvent(&github.PushEvent{
+		Ref:     github.Ptr("refs/heads/feature"),
+		Before:  github.Ptr("f"),
+		After:   github.Ptr(zeroSHA),
+		Deleted: github.Ptr(true),
+	})
+	if err != nil {
+		t.Fatalf("planPushEvent() error = %v", err)
+	}
+	if len(tasks) != 0 {
+		t.Errorf("Expected closed previews to be kept, got tasks %v", taskFiles(tasks))
+	}
+}
+
+func TestPostService_PlanPushEvent_ForcePush(t *testing.T) {
+	newTree := map[string]string{
+		"posts/001-same.md":  "s1",
+		"posts/003-added.md": "s4",
+		"images/cat.png":     "s5",
+		"README.md":          "s6",
+	}
+
+	tests := []struct {
+		name    string
+		oldTree map[string]string
+		stored  []*domain.Post
+		want    []string
+	}{
+		{
+			name: "Old head available",
+			oldTree: map[string]string{
+				"posts/001-same.md":    "s1",
+				"posts/002-dropped.md": "s2",
+				"images/cat.png":       "s3",
+			},
+			want: []string{"remove posts/002-dropped.md", "upsert images/cat.png", "upsert posts/003-added.md"},
+		},
+		{
+			name: "Old head unreachable",
+			stored: []*domain.Post{
+				{ID: "001", SourcePath: "posts/001-same.md", Branch: "main"},
+				{ID: "005", SourcePath: "posts/005-gone.md", Branch: "main"},
+				{ID: "006", SourcePath: "posts/006-elsewhere.md", Branch: "feature"},
+			},
+			want: []string{"remove posts/005-gone.md", "upsert images/cat.png", "upsert posts/001-same.md", "upsert posts/003-added.md"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := newFakeSourceRepository()
+			source.commits["new"] = testCommit("new")
+			source.trees["new"] = testTree(newTree)
+			if tt.oldTree != nil {
+				source.trees["old"] = testTree(tt.oldTree)
+			}
+
+			service := NewPostService(newFakePostRepository(tt.stored...), newFakeImageRepository(), source, NewMarkdownRenderer(), "main")
+			defer service.Close()
+
+			tasks, err := service.planPushEvent(&github.PushEvent{
+				Ref:    github.Ptr("refs/heads/main"),
+				Before: github.Ptr("old"),
+				After:  github.Ptr("new"),
+				Forced: github.Ptr(true),
+			})
+			if err != nil {
+				t.Fatalf("planPushEvent() error = %v", err)
+			}
+			if got := taskFiles(tasks); !slices.Equal(got, tt.want) {
+				t.Errorf("tasks = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package application
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/clock"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultReaderPreferencesCleanupInterval = time.Hour
+	defaultReaderPreferencesRetentionDays   = 180
+
+	// readerTokenPrefix marks reader preference tokens, which grant nothing but reading and changing the preferences
+	readerTokenPrefix = "gbr_"
+)
+
+// ErrInvalidPreferences is returned when a preference has a value the site does not offer
+var ErrInvalidPreferences = errors.New("invalid preferences")
+
+var (
+	// ReaderThemes are the values a reader can choose for Theme; empty leaves it to the site
+	ReaderThemes = []string{"", "system", "light", "dark"}
+	// ReaderFontSizes are the values a reader can choose for FontSize; empty leaves it to the site
+	ReaderFontSizes = []string{"", "small", "medium", "large", "x-large"}
+)
+
+type ReaderPreferencesConfig struct {
+	// Interval is how often expired preferences are deleted
+	Interval time.Duration
+	// TTL is how long preferences are kept after they were last read or saved
+	TTL time.Duration
+}
+
+func NewReaderPreferencesConfig() *ReaderPreferencesConfig {
+	interval := defaultReaderPreferencesCleanupInterval
+	if d, err := time.ParseDuration(os.Getenv("READER_PREFERENCES_CLEANUP_INTERVAL")); err == nil && d > 0 {
+		interval = d
+	}
+
+	days := defaultReaderPreferencesRetentionDays
+	if n, err := strconv.Atoi(os.Getenv("READER_PREFERENCES_RETENTION_DAYS")); err == nil && n > 0 {
+		days = n
+	}
+
+	return &ReaderPreferencesConfig{
+		Interval: interval,
+		TTL:      time.Duration(days) * 24 * time.Hour,
+	}
+}
+
+// ReaderPreferencesService stores display settings for anonymous readers under tokens it issues
+type ReaderPreferencesService struct {
+	repo  domain.ReaderPreferencesRepository
+	cfg   *ReaderPreferencesConfig
+	clock clock.Clock
+}
+
+// ReaderPreferencesOption configures optional ReaderPreferencesService behaviour
+type ReaderPreferencesOption func(*ReaderPreferencesService)
+
+// WithReaderPreferencesClock sets the clock used to decide when preferences expire
+func WithReaderPreferencesClock(c clock.Clock) ReaderPreferencesOption {
+	return func(s *ReaderPreferencesService) {
+		s.clock = c
+	}
+}
+
+func NewReaderPreferencesService(repo domain.ReaderPreferencesRepository, cfg *ReaderPreferencesConfig, opts ...ReaderPreferencesOption) *ReaderPreferencesService {
+	s := &ReaderPreferencesService{
+		repo:  repo,
+		cfg:   cfg,
+		clock: clock.System,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// TTL is how long preferences last without being read or saved
+func (s *ReaderPreferencesService) TTL() time.Duration {
+	return s.cfg.TTL
+}
+
+// Get returns the preferences saved under token, or domain.ErrReaderPreferencesNotFound
+// Reading the preferences keeps them from expiring.
+func (s *ReaderPreferencesService) Get(ctx context.Context, token string) (*domain.ReaderPreferences, error) {
+	if token == "" {
+		return nil, domain.ErrReaderPreferencesNotFound
+	}
+
+	// Expired preferences are kept until the next cleanup, but are not served or revived
+	return s.repo.GetPreferences(ctx, hashToken(token), s.clock.Now().Add(-s.cfg.TTL))
+}
+
+// Save stores prefs under token, issuing a new token when token is empty
+// It returns the token the preferences are stored under, which the reader must keep to find them again.
+func (s *ReaderPreferencesService) Save(ctx context.Context, token string, prefs *domain.ReaderPreferences) (string, error) {
+	if !slices.Contains(ReaderThemes, prefs.Theme) {
+		return "", fmt.Errorf("%w: unknown theme %q", ErrInvalidPreferences, prefs.Theme)
+	}
+	if !slices.Contains(ReaderFontSizes, prefs.FontSize) {
+		return "", fmt.Errorf("%w: unknown font size %q", ErrInvalidPreferences, prefs.FontSize)
+	}
+
+	if token == "" {
+		token = readerTokenPrefix + rand.Text()
+	}
+	if err := s.repo.SavePreferences(ctx, hashToken(token), prefs); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// Delete forgets the preferences saved under token
+func (s *ReaderPreferencesService) Delete(ctx context.Context, token string) error {
+	if token == "" {
+		return domain.ErrReaderPreferencesNotFound
+	}
+
+	return s.repo.DeletePreferences(ctx, hashToken(token))
+}
+
+// Cleanup deletes preferences that have not been read or saved within the TTL
+func (s *ReaderPreferencesService) Cleanup(ctx context.Context) error {
+	deleted, err := s.repo.DeleteUnseenPreferences(ctx, s.clock.Now().Add(-s.cfg.TTL))
+	if err != nil {
+		return err
+	}
+
+	if deleted > 0 {
+		log.Info().Int("deleted", deleted).Msg("Deleted expired reader preferences")
+	}
+
+	return nil
+}
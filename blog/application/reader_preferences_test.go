@@ -0,0 +1,91 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/clock"
+)
+
+func TestReaderPreferencesService_SaveAndGet(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := clock.Func(func() time.Time { return now })
+	repo := newFakeReaderPreferencesRepository(c)
+	service := NewReaderPreferencesService(repo, &ReaderPreferencesConfig{TTL: 24 * time.Hour}, WithReaderPreferencesClock(c))
+	ctx := context.Background()
+
+	token, err := service.Save(ctx, "", &domain.ReaderPreferences{Theme: "dark", FontSize: "large"})
+	if err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if !strings.HasPrefix(token, readerTokenPrefix) {
+		t.Errorf("Save() token = %q, want a new %s token", token, readerTokenPrefix)
+	}
+	if _, ok := repo.prefs[token]; ok {
+		t.Error("Expected the token to be stored only as a hash")
+	}
+
+	again, err := service.Save(ctx, token, &domain.ReaderPreferences{Theme: "light"})
+	if err != nil || again != token {
+		t.Fatalf("Save() with a token = %q, %v, want the same token", again, err)
+	}
+
+	prefs, err := service.Get(ctx, token)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if prefs.Theme != "light" || prefs.FontSize != "" {
+		t.Errorf("Get() = %+v, want the replaced preferences", prefs)
+	}
+
+	if _, err := service.Get(ctx, "gbr_unknown"); !errors.Is(err, domain.ErrReaderPreferencesNotFound) {
+		t.Errorf("Get() with an unknown token error = %v, want ErrReaderPreferencesNotFound", err)
+	}
+
+	if _, err := service.Save(ctx, token, &domain.ReaderPreferences{Theme: "sepia"}); !errors.Is(err, ErrInvalidPreferences) {
+		t.Errorf("Save() with an unknown theme error = %v, want ErrInvalidPreferences", err)
+	}
+
+	if err := service.Delete(ctx, token); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := service.Get(ctx, token); !errors.Is(err, domain.ErrReaderPreferencesNotFound) {
+		t.Errorf("Get() after Delete() error = %v, want ErrReaderPreferencesNotFound", err)
+	}
+}
+
+func TestReaderPreferencesService_Expiry(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := clock.Func(func() time.Time { return now })
+	repo := newFakeReaderPreferencesRepository(c)
+	service := NewReaderPreferencesService(repo, &ReaderPreferencesConfig{TTL: 24 * time.Hour}, WithReaderPreferencesClock(c))
+	ctx := context.Background()
+
+	kept, _ := service.Save(ctx, "", &domain.ReaderPreferences{Theme: "dark"})
+	expired, _ := service.Save(ctx, "", &domain.ReaderPreferences{Theme: "light"})
+
+	// Reading preferences keeps them alive for another TTL
+	now = now.Add(20 * time.Hour)
+	if _, err := service.Get(ctx, kept); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	now = now.Add(20 * time.Hour)
+	if _, err := service.Get(ctx, expired); !errors.Is(err, domain.ErrReaderPreferencesNotFound) {
+		t.Errorf("Get() of expired preferences error = %v, want ErrReaderPreferencesNotFound", err)
+	}
+
+	if err := service.Cleanup(ctx); err != nil {
+		t.Fatalf("Cleanup() error = %v", err)
+	}
+	if len(repo.prefs) != 1 {
+		t.Errorf("Expected only the recently read preferences to remain, have %d", len(repo.prefs))
+	}
+	if _, err := service.Get(ctx, kept); err != nil {
+		t.Errorf("Get() of kept preferences error = %v", err)
+	}
+}
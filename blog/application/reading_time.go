@@ -0,0 +1,39 @@
+package application
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// wordsPerMinute is the reading speed used to estimate reading time
+const wordsPerMinute = 200
+
+// wordCountKey stores the number of prose words in the document being converted
+var wordCountKey = parser.NewContextKey()
+
+// wordCounter counts the words in a document's text, leaving out code blocks and markup
+type wordCounter struct{}
+
+func (wordCounter) Transform(node *ast.Document, reader text.Reader, pc parser.Context) {
+	source := reader.Source()
+	words := 0
+	ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		// Code blocks hold their content as lines rather than text nodes, so they are skipped already
+		if t, ok := n.(*ast.Text); ok {
+			words += len(bytes.Fields(t.Segment.Value(source)))
+		}
+		return ast.WalkContinue, nil
+	})
+	pc.Set(wordCountKey, words)
+}
+
+// readingMinutes estimates how long words take to read, rounding up so any text takes at least a minute
+func readingMinutes(words int) int {
+	return (words + wordsPerMinute - 1) / wordsPerMinute
+}
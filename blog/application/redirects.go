@@ -0,0 +1,86 @@
+package application
+
+import (
+	"context"
+	"errors"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/rs/zerolog/log"
+)
+
+// WithRedirects keeps the URLs of published posts working after their file is renamed
+func WithRedirects(redirects domain.RedirectRepository) PostServiceOption {
+	return func(s *PostService) {
+		s.redirects = redirects
+	}
+}
+
+// recordSlugChange redirects the slug a published post had before it was saved to the post
+// Only merged renames count, since those change the public URL. A post taking a slug that used to redirect
+// elsewhere takes the URL over, so that redirect is dropped. Failures are logged rather than returned, since
+// the post itself was saved.
+func (s *PostService) recordSlugChange(ctx context.Context, previous *domain.Post, post *domain.Post) {
+	if s.redirects == nil || post.Branch != s.mainBranchName {
+		return
+	}
+
+	if post.Slug != "" {
+		err := s.redirects.DeleteRedirect(ctx, post.Slug)
+		if err != nil && !errors.Is(err, domain.ErrRedirectNotFound) {
+			log.Error().Err(err).Str("slug", post.Slug).Msg("Failed to delete redirect")
+		}
+	}
+
+	// Drafts were never public, so their old slugs have no links to keep
+	if previous == nil || previous.Slug == "" || previous.Slug == post.Slug || previous.PublishedAt.IsZero() {
+		return
+	}
+
+	redirect := &domain.Redirect{FromSlug: previous.Slug, PostID: post.ID}
+	if err := s.redirects.SaveRedirect(ctx, redirect); err != nil {
+		log.Error().Err(err).Str("postID", post.ID).Str("slug", previous.Slug).Msg("Failed to record redirect")
+		return
+	}
+	log.Info().Str("postID", post.ID).Str("from", previous.Slug).Str("to", post.Slug).Msg("Recorded redirect for renamed post")
+}
+
+// GetRedirectTarget returns the published post an old slug redirects to, or domain.ErrPostNotFound
+func (s *PostService) GetRedirectTarget(ctx context.Context, slug string) (*domain.Post, error) {
+	if s.redirects == nil {
+		return nil, domain.ErrPostNotFound
+	}
+
+	redirect, err := s.redirects.GetRedirect(ctx, slug)
+	if errors.Is(err, domain.ErrRedirectNotFound) {
+		return nil, domain.ErrPostNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	post, err := s.repo.GetPost(ctx, redirect.PostID)
+	if err != nil {
+		return nil, err
+	}
+	if post.PublishedAt.IsZero() {
+		return nil, domain.ErrPostNotFound
+	}
+
+	return post, nil
+}
+
+// ListRedirects returns every redirect from an old slug, newest first
+func (s *PostService) ListRedirects(ctx context.Context) ([]*domain.Redirect, error) {
+	if s.redirects == nil {
+		return []*domain.Redirect{}, nil
+	}
+	return s.redirects.ListRedirects(ctx)
+}
+
+// DeleteRedirect stops redirecting slug
+func (s *PostService) DeleteRedirect(ctx context.Context, slug string) error {
+	if s.redirects == nil {
+		return domain.ErrRedirectNotFound
+	}
+	return s.redirects.DeleteRedirect(ctx, slug)
+}
@@ -0,0 +1,70 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+)
+
+func TestPostService_RecordSlugChange(t *testing.T) {
+	published := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	redirects := newFakeRedirectRepository(&domain.Redirect{FromSlug: "reused", PostID: "003"})
+	service := NewPostService(newFakePostRepository(), newFakeImageRepository(), nil, NewMarkdownRenderer(), "main", WithRedirects(redirects))
+	defer service.Close()
+	ctx := context.Background()
+
+	service.recordSlugChange(ctx,
+		&domain.Post{ID: "001", Slug: "old-name", PublishedAt: published},
+		&domain.Post{ID: "001", Slug: "new-name", Branch: "main", PublishedAt: published})
+	if redirect, err := redirects.GetRedirect(ctx, "old-name"); err != nil || redirect.PostID != "001" {
+		t.Errorf("GetRedirect(old-name) = %+v, %v, want a redirect to post 001", redirect, err)
+	}
+
+	service.recordSlugChange(ctx,
+		&domain.Post{ID: "002", Slug: "draft-name"},
+		&domain.Post{ID: "002", Slug: "renamed-draft", Branch: "main"})
+	if _, err := redirects.GetRedirect(ctx, "draft-name"); !errors.Is(err, domain.ErrRedirectNotFound) {
+		t.Errorf("GetRedirect(draft-name) error = %v, want no redirect for a draft", err)
+	}
+
+	service.recordSlugChange(ctx,
+		&domain.Post{ID: "001", Slug: "new-name", PublishedAt: published},
+		&domain.Post{ID: "001", Slug: "branch-name", Branch: "feature", PublishedAt: published})
+	if _, err := redirects.GetRedirect(ctx, "new-name"); !errors.Is(err, domain.ErrRedirectNotFound) {
+		t.Errorf("GetRedirect(new-name) error = %v, want no redirect for a rename off the main branch", err)
+	}
+
+	service.recordSlugChange(ctx, nil, &domain.Post{ID: "004", Slug: "reused", Branch: "main"})
+	if _, err := redirects.GetRedirect(ctx, "reused"); !errors.Is(err, domain.ErrRedirectNotFound) {
+		t.Errorf("GetRedirect(reused) error = %v, want the redirect dropped for the post taking the slug", err)
+	}
+}
+
+func TestPostService_GetRedirectTarget(t *testing.T) {
+	published := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := newFakePostRepository(
+		&domain.Post{ID: "001", Slug: "new-name", PublishedAt: published},
+		&domain.Post{ID: "002", Slug: "draft"},
+	)
+	redirects := newFakeRedirectRepository(
+		&domain.Redirect{FromSlug: "old-name", PostID: "001"},
+		&domain.Redirect{FromSlug: "unpublished", PostID: "002"},
+	)
+	service := NewPostService(repo, newFakeImageRepository(), nil, NewMarkdownRenderer(), "main", WithRedirects(redirects))
+	defer service.Close()
+	ctx := context.Background()
+
+	post, err := service.GetRedirectTarget(ctx, "old-name")
+	if err != nil || post.URLPath() != "/posts/new-name" {
+		t.Errorf("GetRedirectTarget() = %+v, %v, want post 001", post, err)
+	}
+
+	for _, slug := range []string{"unpublished", "missing"} {
+		if _, err := service.GetRedirectTarget(ctx, slug); !errors.Is(err, domain.ErrPostNotFound) {
+			t.Errorf("GetRedirectTarget(%q) error = %v, want ErrPostNotFound", slug, err)
+		}
+	}
+}
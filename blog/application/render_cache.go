@@ -0,0 +1,104 @@
+package application
+
+import (
+	"sync"
+
+	"github.com/google/go-github/v75/github"
+)
+
+// renderKey identifies one version of a file by its path and git blob SHA
+type renderKey struct {
+	path    string
+	blobSHA string
+}
+
+// previewRenderLimit is how many renders of preview branches are kept for promotion when they merge
+const previewRenderLimit = 200
+
+// renderCache shares rendered posts between the branches of a single sync
+// The same file version is often on several branches, e.g. after a merge, and renders identically on each.
+// A nil cache is valid and never holds anything.
+type renderCache struct {
+	mu      sync.Mutex
+	results map[renderKey]*MarkdownProcessingResult
+	// limit bounds how many renders are kept, dropping the oldest first, or is 0 to keep them all
+	limit int
+	order []renderKey
+	hits  int
+}
+
+func newRenderCache() *renderCache {
+	return &renderCache{
+		results: make(map[renderKey]*MarkdownProcessingResult),
+	}
+}
+
+// newBoundedRenderCache returns a cache keeping the limit most recently added renders
+func newBoundedRenderCache(limit int) *renderCache {
+	c := newRenderCache()
+	c.limit = limit
+	return c
+}
+
+// get returns the render of a file version, if it has been rendered in this sync
+func (c *renderCache) get(path string, blobSHA string) (*MarkdownProcessingResult, bool) {
+	if c == nil || blobSHA == "" {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	result, ok := c.results[renderKey{path, blobSHA}]
+	if ok {
+		c.hits++
+	}
+	return result, ok
+}
+
+func (c *renderCache) put(path string, blobSHA string, result *MarkdownProcessingResult) {
+	if c == nil || blobSHA == "" {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := renderKey{path, blobSHA}
+	if _, ok := c.results[key]; !ok && c.limit > 0 {
+		c.order = append(c.order, key)
+		for len(c.order) > c.limit {
+			delete(c.results, c.order[0])
+			c.order = c.order[1:]
+		}
+	}
+	c.results[key] = result
+}
+
+// clear forgets every render
+// Rendered posts link images by their current hash, so renders go stale whenever images change.
+func (c *renderCache) clear() {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.results)
+	c.order = nil
+}
+
+// skipped returns how many renders were reused
+func (c *renderCache) skipped() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits
+}
+
+// fileBlobSHA returns the blob SHA a commit recorded for path, or "" if the commit does not list it
+func fileBlobSHA(commit *github.RepositoryCommit, path string) string {
+	for _, f := range commit.Files {
+		if f.GetFilename() == path {
+			return f.GetSHA()
+		}
+	}
+	return ""
+}
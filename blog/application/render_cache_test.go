@@ -0,0 +1,163 @@
+package application
+
+import (
+	"testing"
+
+	"github.com/google/go-github/v75/github"
+)
+
+func TestPostService_SyncRepositoryChanges_ReusesRendersAcrossBranches(t *testing.T) {
+	repo := newFakePostRepository()
+	source := newFakeSourceRepository()
+	source.branches = []*github.Branch{{Name: github.Ptr("main")}, {Name: github.Ptr("feature")}}
+
+	commit := testCommit("a", "posts/001-shared.md")
+	commit.Files[0].SHA = github.Ptr("blob1")
+	source.commits["a"] = commit
+	source.files["posts/001-shared.md"] = []byte("# Shared\n\nBody")
+
+	service := NewPostService(repo, newFakeImageRepository(), source, NewMarkdownRenderer(), "main")
+	defer service.Close()
+
+	if err := service.SyncRepositoryChanges(); err != nil {
+		t.Fatalf("SyncRepositoryChanges() error = %v", err)
+	}
+
+	if source.getFileCalls != 1 {
+		t.Errorf("file fetched %d times, want once for both branches", source.getFileCalls)
+	}
+
+	post, err := repo.GetPost(t.Context(), "001")
+	if err != nil {
+		t.Fatalf("post was not synced: %v", err)
+	}
+	if post.Branch != "main" || post.PublishedAt.IsZero() {
+		t.Errorf("main branch did not win: %+v", post)
+	}
+}
+
+// blobCommit is a commit changing one file to the given blob
+func blobCommit(sha string, path string, blobSHA string) *github.RepositoryCommit {
+	commit := testCommit(sha, path)
+	commit.Files[0].SHA = github.Ptr(blobSHA)
+	return commit
+}
+
+func TestPostService_MergePromotesPreviewRender(t *testing.T) {
+	repo := newFakePostRepository()
+	source := newFakeSourceRepository()
+	source.commits["f"] = blobCommit("f", "posts/001-post.md", "blob1")
+	source.files["f:posts/001-post.md"] = []byte("# Preview\n\nBody")
+
+	service := NewPostService(repo, newFakeImageRepository(), source, NewMarkdownRenderer(), "main")
+	defer service.Close()
+
+	push := func(branch string, before string, after string) {
+		t.Helper()
+		tasks, err := service.planPushEvent(&github.PushEvent{
+			Ref:    github.Ptr("refs/heads/" + branch),
+			Before: github.Ptr(before),
+			After:  github.Ptr(after),
+		})
+		if err != nil {
+			t.Fatalf("planPushEvent(%s) error = %v", after, err)
+		}
+		for _, task := range tasks {
+			if err := task.run(t.Context()); err != nil {
+				t.Fatalf("task %s failed: %v", task.file.Path, err)
+			}
+		}
+	}
+
+	push("feature", zeroSHA, "f")
+	if source.getFileCalls != 1 {
+		t.Fatalf("file fetched %d times for the preview, want once", source.getFileCalls)
+	}
+
+	// The merge leaves the previewed file as it was
+	source.commits["m"] = blobCommit("m", "posts/001-post.md", "blob1")
+	source.comparisons["a...m"] = &github.CommitsComparison{Commits: []*github.RepositoryCommit{testCommit("m")}}
+	push("main", "a", "m")
+
+	if source.getFileCalls != 1 {
+		t.Errorf("file fetched %d times, want the preview promoted without fetching it again", source.getFileCalls)
+	}
+	post := repo.posts["001"]
+	if post.Branch != "main" || post.CommitSHA != "m" || post.Title != "Preview" || post.PublishedAt.IsZero() {
+		t.Errorf("Expected the preview to be published from main, got %+v", post)
+	}
+
+	// A file changed by the merge is rendered from main
+	source.commits["n"] = blobCommit("n", "posts/001-post.md", "blob2")
+	source.files["n:posts/001-post.md"] = []byte("# Merged\n\nBody")
+	source.comparisons["m...n"] = &github.CommitsComparison{Commits: []*github.RepositoryCommit{testCommit("n")}}
+	push("main", "m", "n")
+
+	if post := repo.posts["001"]; post.Title != "Merged" {
+		t.Errorf("Expected the changed file to be rendered again, got the post titled %q", post.Title)
+	}
+}
+
+func TestPostService_ImageChangeDropsPreviewRenders(t *testing.T) {
+	source := newFakeSourceRepository()
+	source.files["images/cat.png"] = encodePNG(t, testImage(8, 8, func(x, y float64) float64 { return x }))
+
+	service := NewPostService(newFakePostRepository(), newFakeImageRepository(), source, NewMarkdownRenderer(), "main")
+	defer service.Close()
+
+	// Renders link images by hash, so a changed image makes them stale
+	service.previewRenders.put("posts/001-post.md", "blob1", &MarkdownProcessingResult{Title: "Preview"})
+	if err := service.processImageFile(t.Context(), "images/cat.png", "f"); err != nil {
+		t.Fatalf("processImageFile() error = %v", err)
+	}
+	if _, ok := service.previewRenders.get("posts/001-post.md", "blob1"); ok {
+		t.Error("Expected the preview render to be dropped when an image changed")
+	}
+}
+
+func TestRenderCache_Bounded(t *testing.T) {
+	cache := newBoundedRenderCache(2)
+	for _, blob := range []string{"a", "b", "a", "c"} {
+		cache.put("posts/001-a.md", blob, &MarkdownProcessingResult{Title: blob})
+	}
+
+	if _, ok := cache.get("posts/001-a.md", "a"); ok {
+		t.Error("Expected the oldest render to be dropped")
+	}
+	for _, blob := range []string{"b", "c"} {
+		if _, ok := cache.get("posts/001-a.md", blob); !ok {
+			t.Errorf("Expected render %s to be kept", blob)
+		}
+	}
+}
+
+func TestRenderCache(t *testing.T) {
+	var nilCache *renderCache
+	nilCache.put("posts/001-a.md", "blob", &MarkdownProcessingResult{})
+	if _, ok := nilCache.get("posts/001-a.md", "blob"); ok {
+		t.Error("nil cache returned a render")
+	}
+
+	cache := newRenderCache()
+	result := &MarkdownProcessingResult{Title: "A"}
+	cache.put("posts/001-a.md", "", result)
+	if _, ok := cache.get("posts/001-a.md", ""); ok {
+		t.Error("render without a blob SHA was cached")
+	}
+
+	cache.put("posts/001-a.md", "blob", result)
+	if got, ok := cache.get("posts/001-a.md", "blob"); !ok || got != result {
+		t.Errorf("get() = %v, %v", got, ok)
+	}
+	if _, ok := cache.get("posts/001-a.md", "other"); ok {
+		t.Error("render of another version was returned")
+	}
+
+	cache.clear()
+	if _, ok := cache.get("posts/001-a.md", "blob"); ok {
+		t.Error("render survived clear")
+	}
+	if cache.skipped() != 1 {
+		t.Errorf("skipped() = %d, want 1", cache.skipped())
+	}
+}
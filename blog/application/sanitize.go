@@ -0,0 +1,47 @@
+package application
+
+import (
+	"regexp"
+
+	"github.com/microcosm-cc/bluemonday"
+)
+
+// SanitizeMode selects how rendered post HTML is cleaned before it is stored
+type SanitizeMode string
+
+const (
+	// SanitizeNone keeps raw HTML from posts, for blogs whose authors are all trusted
+	SanitizeNone SanitizeMode = "none"
+	// SanitizeUGC applies bluemonday's policy for user generated content, removing scripts, event handlers,
+	// iframes and styles while keeping the markup the renderer itself produces
+	SanitizeUGC SanitizeMode = "ugc"
+)
+
+// parseSanitizeMode returns the mode named by value, or SanitizeNone when it names none
+func parseSanitizeMode(value string) SanitizeMode {
+	switch mode := SanitizeMode(value); mode {
+	case SanitizeUGC:
+		return mode
+	default:
+		return SanitizeNone
+	}
+}
+
+// rendererClasses matches the class attributes the renderer and its extensions emit
+var rendererClasses = regexp.MustCompile(`^(language-[\w.+#-]+|math (inline|display)|mermaid|footnotes|footnote-ref|footnote-backref)$`)
+
+// sanitizePolicy returns the bluemonday policy for mode, or nil when HTML is stored as rendered
+func sanitizePolicy(mode SanitizeMode) *bluemonday.Policy {
+	if mode != SanitizeUGC {
+		return nil
+	}
+
+	p := bluemonday.UGCPolicy()
+	p.AllowAttrs("class").Matching(rendererClasses).OnElements("a", "code", "div", "pre", "span")
+	// Footnotes
+	p.AllowAttrs("role").Matching(regexp.MustCompile(`^doc-(noteref|backlink|endnotes)$`)).OnElements("a", "div")
+	// Task list items are rendered as disabled checkboxes
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").Matching(regexp.MustCompile(`^(|checked|disabled)$`)).OnElements("input")
+	return p
+}
@@ -0,0 +1,60 @@
+package application
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMarkdownRendererImpl_Render_Sanitize(t *testing.T) {
+	source := "# Title\n\n" +
+		"## Section\n\n" +
+		"<script>alert(1)</script>\n\n" +
+		"<img src=\"/images/a.png\" onerror=\"alert(2)\">\n\n" +
+		"<a href=\"javascript:alert(3)\">link</a> and a note[^1] and $x$\n\n" +
+		"- [x] done\n\n" +
+		"```go\nfunc main() {}\n```\n\n" +
+		"```mermaid\ngraph TD\n```\n\n" +
+		"[^1]: The note."
+	cfg := &MarkdownConfig{Footnotes: true, Math: true, Sanitize: SanitizeUGC}
+
+	result, err := NewMarkdownRenderer(WithMarkdownExtensions(cfg)).Render([]byte(source))
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	html := string(result.HTMLContent)
+
+	for _, unsafe := range []string{"<script", "onerror", "javascript:"} {
+		if strings.Contains(html, unsafe) {
+			t.Errorf("sanitized HTML contains %q: %s", unsafe, html)
+		}
+	}
+	for _, kept := range []string{
+		`<h2 id="section">`,
+		`<img src="/images/a.png"`,
+		`<code class="language-go">`,
+		`<pre class="mermaid">`,
+		`<span class="math inline">`,
+		`class="footnote-ref" role="doc-noteref"`,
+		`<input checked="" disabled="" type="checkbox"`,
+	} {
+		if !strings.Contains(html, kept) {
+			t.Errorf("sanitized HTML lost %q: %s", kept, html)
+		}
+	}
+
+	result, err = NewMarkdownRenderer().Render([]byte(source))
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if !strings.Contains(string(result.HTMLContent), "<script>alert(1)</script>") {
+		t.Error("raw HTML should be kept when sanitization is off")
+	}
+}
+
+func TestParseSanitizeMode(t *testing.T) {
+	for value, want := range map[string]SanitizeMode{"": SanitizeNone, "ugc": SanitizeUGC, "bogus": SanitizeNone} {
+		if got := parseSanitizeMode(value); got != want {
+			t.Errorf("parseSanitizeMode(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
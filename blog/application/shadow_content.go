@@ -0,0 +1,228 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrShadowUnavailable is returned when shadow builds are not configured or can't be used with the publish mode
+	ErrShadowUnavailable = errors.New("shadow builds are unavailable")
+	// ErrShadowBuildInProgress is returned when a shadow build is already running
+	ErrShadowBuildInProgress = errors.New("shadow build already in progress")
+	// ErrShadowNotReady is returned when there is no completed shadow build to promote
+	ErrShadowNotReady = errors.New("no completed shadow build")
+	// ErrShadowStale is returned when the main branch moved on after the shadow build started
+	ErrShadowStale = errors.New("main branch changed since the shadow build")
+)
+
+// ShadowState is where the shadow namespace is in its build and promotion
+type ShadowState string
+
+const (
+	// ShadowEmpty means nothing has been built since startup, or the last build was discarded
+	ShadowEmpty ShadowState = "empty"
+	// ShadowBuilding means the repository is being rendered into the shadow namespace
+	ShadowBuilding ShadowState = "building"
+	// ShadowReady means a build completed and can be promoted
+	ShadowReady ShadowState = "ready"
+	// ShadowFailed means the last build failed; it can't be promoted
+	ShadowFailed ShadowState = "failed"
+	// ShadowPromoted means the last build replaced the live posts
+	ShadowPromoted ShadowState = "promoted"
+)
+
+// ShadowStatus reports on the last shadow build
+type ShadowStatus struct {
+	State ShadowState
+	// Head is the main branch commit the build started from
+	Head string
+	// Posts is how many posts the build rendered, or how many became live when it was promoted
+	Posts      int
+	StartedAt  time.Time
+	FinishedAt time.Time
+	PromotedAt time.Time
+	Err        error
+}
+
+// WithShadowContent renders full rebuilds into repo, so they can be checked before they replace the live posts
+// Without it, shadow builds are unavailable.
+func WithShadowContent(repo domain.ShadowPostRepository) PostServiceOption {
+	return func(s *PostService) {
+		s.shadowRepo = repo
+	}
+}
+
+// ShadowStatus returns the state of the last shadow build
+// Builds are tracked in memory, so a build interrupted by a restart can't be promoted.
+func (s *PostService) ShadowStatus() ShadowStatus {
+	s.shadowMu.Lock()
+	defer s.shadowMu.Unlock()
+	if s.shadowStatus.State == "" {
+		return ShadowStatus{State: ShadowEmpty}
+	}
+	return s.shadowStatus
+}
+
+// StartShadowBuild renders every branch of the repository into the shadow namespace in the background,
+// replacing any earlier build. Live posts keep being served and updated while it runs.
+func (s *PostService) StartShadowBuild() error {
+	if err := s.checkShadowAvailable(); err != nil {
+		return err
+	}
+
+	s.shadowMu.Lock()
+	defer s.shadowMu.Unlock()
+	if s.shadowStatus.State == ShadowBuilding {
+		return ErrShadowBuildInProgress
+	}
+	s.shadowStatus = ShadowStatus{State: ShadowBuilding, StartedAt: s.clock.Now()}
+
+	s.wg.Go(func() {
+		head, posts, err := s.buildShadow()
+
+		s.shadowMu.Lock()
+		defer s.shadowMu.Unlock()
+		s.shadowStatus.Head = head
+		s.shadowStatus.Posts = posts
+		s.shadowStatus.FinishedAt = s.clock.Now()
+		if err != nil {
+			s.shadowStatus.State = ShadowFailed
+			s.shadowStatus.Err = err
+			log.Error().Err(err).Msg("Shadow build failed")
+			return
+		}
+		s.shadowStatus.State = ShadowReady
+		log.Info().Str("head", head).Int("posts", posts).Dur("duration", s.shadowStatus.FinishedAt.Sub(s.shadowStatus.StartedAt)).Msg("Shadow build completed")
+	})
+
+	return nil
+}
+
+// buildShadow empties the shadow namespace and renders the repository into it,
+// returning the main branch commit it started from and how many posts it rendered
+func (s *PostService) buildShadow() (string, int, error) {
+	head, err := s.sourceRepo.GetCommit(s.ctx, s.mainBranchName)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to get head of %s: %w", s.mainBranchName, err)
+	}
+
+	if err := s.shadowRepo.Reset(s.ctx); err != nil {
+		return head.GetSHA(), 0, fmt.Errorf("failed to reset shadow posts: %w", err)
+	}
+
+	shadow := s.newShadowService()
+	defer shadow.Close()
+	stop := context.AfterFunc(s.ctx, shadow.cancel)
+	defer stop()
+
+	branches, err := s.sourceRepo.ListBranches(s.ctx)
+	if err != nil {
+		return head.GetSHA(), 0, fmt.Errorf("failed to retrieve branches: %w", err)
+	}
+	if err := shadow.processBranches(time.Time{}, branches); err != nil {
+		return head.GetSHA(), 0, fmt.Errorf("failed to process branches: %w", err)
+	}
+
+	posts, err := s.shadowRepo.ListPosts(s.ctx)
+	if err != nil {
+		return head.GetSHA(), 0, fmt.Errorf("failed to count shadow posts: %w", err)
+	}
+
+	return head.GetSHA(), len(posts), nil
+}
+
+// newShadowService returns a service rendering into the shadow namespace the way this one renders live posts
+// It records no sync jobs, dead letters or processed commits, which describe the live posts.
+func (s *PostService) newShadowService() *PostService {
+	shadow := NewPostService(s.shadowRepo, s.imageRepo, s.sourceRepo, s.markdown, s.mainBranchName,
+		WithClock(s.clock),
+		WithContentLayout(s.layout),
+		WithPostIDFunc(s.postID),
+		WithImageQuota(s.imageQuota),
+		WithPublishing(s.publishing),
+		WithContentSigning(s.signingKey),
+	)
+	shadow.workers = make(chan struct{}, cap(s.workers))
+	shadow.timestamps = s.timestamps
+	shadow.metadata = s.metadata
+	return shadow
+}
+
+// PromoteShadow replaces the live posts with the last shadow build in a single step, returning how many posts are live
+// The build must have completed, and the main branch must not have moved since it started, or pushes made
+// during the build would be lost.
+func (s *PostService) PromoteShadow(ctx context.Context) (int, error) {
+	if err := s.checkShadowAvailable(); err != nil {
+		return 0, err
+	}
+
+	s.shadowMu.Lock()
+	defer s.shadowMu.Unlock()
+	switch s.shadowStatus.State {
+	case ShadowBuilding:
+		return 0, ErrShadowBuildInProgress
+	case ShadowReady:
+	default:
+		return 0, ErrShadowNotReady
+	}
+
+	head, err := s.sourceRepo.GetCommit(ctx, s.mainBranchName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get head of %s: %w", s.mainBranchName, err)
+	}
+	if head.GetSHA() != s.shadowStatus.Head {
+		return 0, fmt.Errorf("%w: built from %s, now at %s", ErrShadowStale, s.shadowStatus.Head, head.GetSHA())
+	}
+
+	posts, err := s.shadowRepo.Promote(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to promote shadow posts: %w", err)
+	}
+	s.postCache.invalidate()
+	s.contentChanged()
+
+	s.shadowStatus.State = ShadowPromoted
+	s.shadowStatus.Posts = posts
+	s.shadowStatus.PromotedAt = s.clock.Now()
+	log.Info().Str("head", head.GetSHA()).Int("posts", posts).Msg("Promoted shadow build")
+
+	return posts, nil
+}
+
+// DiscardShadow deletes the last shadow build
+func (s *PostService) DiscardShadow(ctx context.Context) error {
+	if err := s.checkShadowAvailable(); err != nil {
+		return err
+	}
+
+	s.shadowMu.Lock()
+	defer s.shadowMu.Unlock()
+	if s.shadowStatus.State == ShadowBuilding {
+		return ErrShadowBuildInProgress
+	}
+
+	if err := s.shadowRepo.Reset(ctx); err != nil {
+		return fmt.Errorf("failed to reset shadow posts: %w", err)
+	}
+	s.shadowStatus = ShadowStatus{State: ShadowEmpty}
+
+	return nil
+}
+
+// checkShadowAvailable reports why shadow builds can't be used, if they can't
+// A build renders the main branch as merged, which only matches the live posts when merges publish.
+func (s *PostService) checkShadowAvailable() error {
+	if s.shadowRepo == nil {
+		return fmt.Errorf("%w: no shadow namespace is configured", ErrShadowUnavailable)
+	}
+	if !s.publishesOnMerge() {
+		return fmt.Errorf("%w: posts are published on %s", ErrShadowUnavailable, s.publishing.Mode)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/google/go-github/v75/github"
+)
+
+// fakeShadowPostRepository is an in-memory domain.ShadowPostRepository promoting into live
+type fakeShadowPostRepository struct {
+	*fakePostRepository
+	live *fakePostRepository
+}
+
+func (f *fakeShadowPostRepository) Reset(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.posts = make(map[string]*domain.Post)
+	return nil
+}
+
+func (f *fakeShadowPostRepository) Promote(ctx context.Context) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.live.mu.Lock()
+	defer f.live.mu.Unlock()
+	f.live.posts, f.posts = f.posts, make(map[string]*domain.Post)
+	return len(f.live.posts), nil
+}
+
+// waitForShadowBuild waits for the shadow build started on service to finish
+func waitForShadowBuild(t *testing.T, service *PostService) ShadowStatus {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if status := service.ShadowStatus(); status.State != ShadowBuilding {
+			return status
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("Timed out waiting for the shadow build")
+	return ShadowStatus{}
+}
+
+func TestPostService_ShadowBuild(t *testing.T) {
+	source := newFakeSourceRepository()
+	source.commits["head1"] = testCommit("head1", "posts/001-new.md")
+	source.commits["main"] = source.commits["head1"]
+	source.files["posts/001-new.md"] = []byte("# New\n\nBody")
+	source.branches = []*github.Branch{{Name: github.Ptr("main")}}
+
+	live := newFakePostRepository(&domain.Post{ID: "002", Title: "Old"})
+	shadow := &fakeShadowPostRepository{fakePostRepository: newFakePostRepository(), live: live}
+	service := NewPostService(live, newFakeImageRepository(), source, NewMarkdownRenderer(), "main",
+		WithShadowContent(shadow),
+	)
+	defer service.Close()
+
+	if _, err := service.PromoteShadow(t.Context()); !errors.Is(err, ErrShadowNotReady) {
+		t.Fatalf("PromoteShadow() before a build error = %v, want ErrShadowNotReady", err)
+	}
+
+	if err := service.StartShadowBuild(); err != nil {
+		t.Fatalf("StartShadowBuild() error = %v", err)
+	}
+	status := waitForShadowBuild(t, service)
+	if status.State != ShadowReady || status.Head != "head1" || status.Posts != 1 {
+		t.Fatalf("ShadowStatus() = %+v, want a ready build of head1 with one post", status)
+	}
+	if _, ok := live.posts["001"]; ok {
+		t.Fatal("shadow build wrote to the live posts")
+	}
+	if _, ok := shadow.posts["001"]; !ok {
+		t.Fatal("shadow build did not render the post")
+	}
+
+	posts, err := service.PromoteShadow(t.Context())
+	if err != nil {
+		t.Fatalf("PromoteShadow() error = %v", err)
+	}
+	if posts != 1 {
+		t.Errorf("PromoteShadow() = %d posts, want 1", posts)
+	}
+	if _, ok := live.posts["001"]; !ok {
+		t.Error("promoted post is not live")
+	}
+	if _, ok := live.posts["002"]; ok {
+		t.Error("post missing from the shadow build is still live")
+	}
+	if state := service.ShadowStatus().State; state != ShadowPromoted {
+		t.Errorf("ShadowStatus().State = %s, want %s", state, ShadowPromoted)
+	}
+}
+
+func TestPostService_PromoteShadow_Stale(t *testing.T) {
+	source := newFakeSourceRepository()
+	source.commits["head1"] = testCommit("head1", "posts/001-new.md")
+	source.commits["main"] = source.commits["head1"]
+	source.files["posts/001-new.md"] = []byte("# New\n\nBody")
+	source.branches = []*github.Branch{{Name: github.Ptr("main")}}
+
+	live := newFakePostRepository()
+	shadow := &fakeShadowPostRepository{fakePostRepository: newFakePostRepository(), live: live}
+	service := NewPostService(live, newFakeImageRepository(), source, NewMarkdownRenderer(), "main",
+		WithShadowContent(shadow),
+	)
+	defer service.Close()
+
+	if err := service.StartShadowBuild(); err != nil {
+		t.Fatalf("StartShadowBuild() error = %v", err)
+	}
+	waitForShadowBuild(t, service)
+
+	source.mu.Lock()
+	source.commits["main"] = &github.RepositoryCommit{SHA: github.Ptr("head2")}
+	source.mu.Unlock()
+
+	if _, err := service.PromoteShadow(t.Context()); !errors.Is(err, ErrShadowStale) {
+		t.Fatalf("PromoteShadow() after main moved error = %v, want ErrShadowStale", err)
+	}
+	if len(live.posts) != 0 {
+		t.Error("stale build was promoted")
+	}
+
+	if err := service.DiscardShadow(t.Context()); err != nil {
+		t.Fatalf("DiscardShadow() error = %v", err)
+	}
+	if state := service.ShadowStatus().State; state != ShadowEmpty {
+		t.Errorf("ShadowStatus().State after discarding = %s, want %s", state, ShadowEmpty)
+	}
+	if len(shadow.posts) != 0 {
+		t.Error("discarded build was kept")
+	}
+}
+
+func TestPostService_StartShadowBuild_Unavailable(t *testing.T) {
+	service := NewPostService(newFakePostRepository(), newFakeImageRepository(), newFakeSourceRepository(), NewMarkdownRenderer(), "main")
+	defer service.Close()
+
+	if err := service.StartShadowBuild(); !errors.Is(err, ErrShadowUnavailable) {
+		t.Errorf("StartShadowBuild() without a shadow namespace error = %v, want ErrShadowUnavailable", err)
+	}
+}
@@ -0,0 +1,122 @@
+package application
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+)
+
+var (
+	// ErrInvalidSignature is returned when stored post HTML does not match its signature
+	ErrInvalidSignature = errors.New("invalid content signature")
+	// ErrUnsignedContent is returned when a post was rendered without a signing key
+	ErrUnsignedContent = errors.New("content is not signed")
+)
+
+type SigningConfig struct {
+	// Key is the base64 ed25519 private key seed; signing is disabled when it is empty
+	Key string
+}
+
+func NewSigningConfig() *SigningConfig {
+	return &SigningConfig{
+		Key: os.Getenv("CONTENT_SIGNING_KEY"),
+	}
+}
+
+// PrivateKey decodes the configured key, returning nil when signing is disabled
+func (c *SigningConfig) PrivateKey() (ed25519.PrivateKey, error) {
+	if c.Key == "" {
+		return nil, nil
+	}
+
+	seed, err := base64.StdEncoding.DecodeString(c.Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode signing key: %w", err)
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("signing key must be a %d byte seed, got %d bytes", ed25519.SeedSize, len(seed))
+	}
+
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// WithContentSigning signs the HTML of every post rendered from now on
+func WithContentSigning(key ed25519.PrivateKey) PostServiceOption {
+	return func(s *PostService) {
+		s.signingKey = key
+	}
+}
+
+// SigningPublicKey returns the key post signatures can be checked with, or nil when signing is disabled
+func (s *PostService) SigningPublicKey() ed25519.PublicKey {
+	if s.signingKey == nil {
+		return nil
+	}
+	return s.signingKey.Public().(ed25519.PublicKey)
+}
+
+// signPost signs a post's rendered HTML, clearing any old signature when signing is disabled
+func (s *PostService) signPost(post *domain.Post) {
+	post.Signature = ""
+	if s.signingKey != nil {
+		post.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(s.signingKey, post.HTMLContent))
+	}
+}
+
+// ParsePublicKey decodes a base64 ed25519 public key
+func ParsePublicKey(encoded string) (ed25519.PublicKey, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode public key: %w", err)
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("public key must be %d bytes, got %d bytes", ed25519.PublicKeySize, len(key))
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// VerifyContent checks content against a base64 ed25519 signature
+func VerifyContent(key ed25519.PublicKey, content []byte, signature string) error {
+	if signature == "" {
+		return ErrUnsignedContent
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil || !ed25519.Verify(key, content, sig) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// SignatureCheck is the result of verifying one stored post
+type SignatureCheck struct {
+	PostID string
+	// Err is nil when the signature is valid, ErrUnsignedContent or ErrInvalidSignature when it is not,
+	// or the error met reading the post's HTML
+	Err error
+}
+
+// VerifyPostSignatures checks the stored HTML of every post against its signature
+func VerifyPostSignatures(ctx context.Context, repo domain.PostRepository, key ed25519.PublicKey) ([]SignatureCheck, error) {
+	posts, err := repo.ListPosts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	checks := make([]SignatureCheck, 0, len(posts))
+	for _, post := range posts {
+		content, err := repo.GetPostHTML(ctx, post.ID)
+		if err != nil {
+			checks = append(checks, SignatureCheck{PostID: post.ID, Err: err})
+			continue
+		}
+		checks = append(checks, SignatureCheck{PostID: post.ID, Err: VerifyContent(key, content, post.Signature)})
+	}
+	return checks, nil
+}
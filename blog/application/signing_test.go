@@ -0,0 +1,89 @@
+package application
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+)
+
+func TestSigningConfig_PrivateKey(t *testing.T) {
+	seed := strings.Repeat("k", ed25519.SeedSize)
+
+	key, err := (&SigningConfig{}).PrivateKey()
+	if err != nil || key != nil {
+		t.Errorf("Expected no key when signing is disabled, got %v, %v", key, err)
+	}
+
+	key, err = (&SigningConfig{Key: base64.StdEncoding.EncodeToString([]byte(seed))}).PrivateKey()
+	if err != nil {
+		t.Fatalf("PrivateKey() error = %v", err)
+	}
+	if !key.Equal(ed25519.NewKeyFromSeed([]byte(seed))) {
+		t.Error("PrivateKey() did not derive the key from the seed")
+	}
+
+	for _, bad := range []string{"not base64!", base64.StdEncoding.EncodeToString([]byte("short"))} {
+		if _, err := (&SigningConfig{Key: bad}).PrivateKey(); err == nil {
+			t.Errorf("Expected an error for key %q", bad)
+		}
+	}
+}
+
+func TestPostService_ProcessPostFile_SignsContent(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo := newFakePostRepository()
+	source := newFakeSourceRepository()
+	source.files["posts/001-foo.md"] = []byte("# Foo\n\nBody")
+	service := NewPostService(repo, newFakeImageRepository(), source, NewMarkdownRenderer(), "main", WithContentSigning(privateKey))
+	defer service.Close()
+
+	fileInfo := commitFileInfo{path: "posts/001-foo.md", createdAt: time.Now(), modifiedAt: time.Now()}
+	if err := service.processPostFile(t.Context(), "001", fileInfo, "head", "main", nil); err != nil {
+		t.Fatalf("processPostFile() error = %v", err)
+	}
+
+	post, _ := repo.GetPost(t.Context(), "001")
+	if err := VerifyContent(service.SigningPublicKey(), post.HTMLContent, post.Signature); err != nil {
+		t.Errorf("VerifyContent() error = %v", err)
+	}
+}
+
+func TestVerifyPostSignatures(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sign := func(content string) string {
+		return base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, []byte(content)))
+	}
+
+	repo := newFakePostRepository(
+		&domain.Post{ID: "001", HTMLContent: []byte("<p>ok</p>"), Signature: sign("<p>ok</p>")},
+		&domain.Post{ID: "002", HTMLContent: []byte("<p>tampered</p>"), Signature: sign("<p>original</p>")},
+		&domain.Post{ID: "003", HTMLContent: []byte("<p>unsigned</p>")},
+	)
+
+	checks, err := VerifyPostSignatures(t.Context(), repo, publicKey)
+	if err != nil {
+		t.Fatalf("VerifyPostSignatures() error = %v", err)
+	}
+
+	want := map[string]error{"001": nil, "002": ErrInvalidSignature, "003": ErrUnsignedContent}
+	if len(checks) != len(want) {
+		t.Fatalf("Expected %d checks, got %d", len(want), len(checks))
+	}
+	for _, check := range checks {
+		if !errors.Is(check.Err, want[check.PostID]) || (want[check.PostID] == nil && check.Err != nil) {
+			t.Errorf("post %s: Err = %v, want %v", check.PostID, check.Err, want[check.PostID])
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// postNumberPrefix is the ID prefix of a post file name, which is left out of its slug
+	postNumberPrefix = regexp.MustCompile(`^\d+-`)
+	// nonSlugRuns are the runs of characters a slug replaces with a single hyphen
+	nonSlugRuns = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// postSlug derives a post's slug from its source path, or returns "" when the name has nothing to use
+// Example: "posts/001-My Post.md" -> "my-post". Slugs of only digits are refused, so they can't be mistaken for IDs.
+func postSlug(sourcePath string) string {
+	name := strings.TrimSuffix(path.Base(sourcePath), ".md")
+	name = postNumberPrefix.ReplaceAllString(name, "")
+	slug := strings.Trim(nonSlugRuns.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if strings.Trim(slug, "0123456789-") == "" {
+		return ""
+	}
+	return slug
+}
+
+// assignSlug gives post the slug of its file name, unless another post already has it
+// A post that loses a slug to another is still served at its ID URL.
+func (s *PostService) assignSlug(ctx context.Context, post *domain.Post) error {
+	slug := postSlug(post.SourcePath)
+	if slug == "" {
+		return nil
+	}
+
+	owner, err := s.repo.GetPostBySlug(ctx, slug)
+	if err != nil && !errors.Is(err, domain.ErrPostNotFound) {
+		return fmt.Errorf("failed to check slug %s: %w", slug, err)
+	}
+	if err == nil && owner.ID != post.ID {
+		log.Warn().Str("postID", post.ID).Str("slug", slug).Str("owner", owner.ID).Msg("Slug is taken by another post")
+		return nil
+	}
+
+	post.Slug = slug
+	return nil
+}
+
+// GetPublishedPostBySlug retrieves a published post with its HTML by slug
+func (s *PostService) GetPublishedPostBySlug(ctx context.Context, slug string) (*domain.Post, error) {
+	post, err := s.repo.GetPostBySlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetPublishedPost(ctx, post.ID)
+}
@@ -0,0 +1,69 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+)
+
+func TestPostSlug(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"posts/001-my-post.md", "my-post"},
+		{"posts/002-My Post, Again!.md", "my-post-again"},
+		{"posts/003-2024.md", ""},
+		{"posts/004-2024-in-review.md", "2024-in-review"},
+		{"posts/005-.md", ""},
+		{"posts/006-café.md", "caf"},
+	}
+
+	for _, tt := range tests {
+		if got := postSlug(tt.path); got != tt.want {
+			t.Errorf("postSlug(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestPostService_AssignSlug(t *testing.T) {
+	repo := newFakePostRepository(&domain.Post{ID: "001", Slug: "my-post", SourcePath: "posts/001-my-post.md"})
+	service := NewPostService(repo, newFakeImageRepository(), nil, NewMarkdownRenderer(), "main")
+	defer service.Close()
+	ctx := context.Background()
+
+	owner := &domain.Post{ID: "001", SourcePath: "posts/001-my-post.md"}
+	if err := service.assignSlug(ctx, owner); err != nil || owner.Slug != "my-post" {
+		t.Errorf("assignSlug() = %q, %v, want the post to keep its slug", owner.Slug, err)
+	}
+
+	other := &domain.Post{ID: "002", SourcePath: "posts/002-my-post.md"}
+	if err := service.assignSlug(ctx, other); err != nil || other.Slug != "" {
+		t.Errorf("assignSlug() = %q, %v, want no slug for a post whose slug is taken", other.Slug, err)
+	}
+}
+
+func TestPostService_GetPublishedPostBySlug(t *testing.T) {
+	published := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := newFakePostRepository(
+		&domain.Post{ID: "001", Slug: "published", PublishedAt: published},
+		&domain.Post{ID: "002", Slug: "draft"},
+	)
+	service := NewPostService(repo, newFakeImageRepository(), nil, NewMarkdownRenderer(), "main")
+	defer service.Close()
+	ctx := context.Background()
+
+	post, err := service.GetPublishedPostBySlug(ctx, "published")
+	if err != nil || post.ID != "001" || post.URLPath() != "/posts/published" {
+		t.Errorf("GetPublishedPostBySlug() = %+v, %v, want post 001", post, err)
+	}
+
+	for _, slug := range []string{"draft", "missing", "001"} {
+		if _, err := service.GetPublishedPostBySlug(ctx, slug); !errors.Is(err, domain.ErrPostNotFound) {
+			t.Errorf("GetPublishedPostBySlug(%q) error = %v, want ErrPostNotFound", slug, err)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package application
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// defaultSnippetLength is how many characters a snippet taken from the first paragraph may have
+const defaultSnippetLength = 200
+
+// moreMarker is the HTML comment that ends a post's snippet when it is written on a line of its own
+const moreMarker = "<!--more-->"
+
+// snippetKey stores the plain text snippet of the document being converted
+var snippetKey = parser.NewContextKey()
+
+// snippetExtractor takes a plain text snippet from a document, with its formatting stripped
+// Everything before a <!--more--> marker is used in full; without one, the first paragraph is cut to maxLength.
+type snippetExtractor struct {
+	maxLength int
+}
+
+func (e snippetExtractor) Transform(node *ast.Document, reader text.Reader, pc parser.Context) {
+	source := reader.Source()
+
+	var before []string
+	for n := node.FirstChild(); n != nil; n = n.NextSibling() {
+		if isMoreMarker(n, source) {
+			if snippet := strings.Join(before, " "); snippet != "" {
+				pc.Set(snippetKey, snippet)
+				return
+			}
+			break
+		}
+		before = append(before, blockTexts(n, source)...)
+	}
+
+	// The first paragraph is the first block of prose, so the title and any leading headings, lists or code are skipped
+	for n := node.FirstChild(); n != nil; n = n.NextSibling() {
+		if _, ok := n.(*ast.Paragraph); !ok {
+			continue
+		}
+		if snippet := plainText(n, source); snippet != "" {
+			pc.Set(snippetKey, truncateSnippet(snippet, e.maxLength))
+			return
+		}
+	}
+}
+
+// isMoreMarker reports whether a top-level block is a <!--more--> marker, allowing spaces inside the comment
+func isMoreMarker(n ast.Node, source []byte) bool {
+	block, ok := n.(*ast.HTMLBlock)
+	if !ok || block.HTMLBlockType != ast.HTMLBlockType2 {
+		return false
+	}
+
+	var raw bytes.Buffer
+	lines := block.Lines()
+	for i := range lines.Len() {
+		line := lines.At(i)
+		raw.Write(line.Value(source))
+	}
+	comment := strings.Join(strings.Fields(raw.String()), "")
+	return strings.EqualFold(comment, moreMarker)
+}
+
+// blockTexts returns the plain text of each paragraph within a top-level block, leaving out headings and code
+func blockTexts(n ast.Node, source []byte) []string {
+	var texts []string
+	_ = ast.Walk(n, func(c ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch c.(type) {
+		case *ast.Heading:
+			return ast.WalkSkipChildren, nil
+		case *ast.Paragraph, *ast.TextBlock:
+			if t := plainText(c, source); t != "" {
+				texts = append(texts, t)
+			}
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return texts
+}
+
+// plainText returns the text of an inline container as a reader sees it, with markup, images and raw HTML left out
+// Line breaks become spaces, and escapes and character references are resolved.
+func plainText(n ast.Node, source []byte) string {
+	var buf bytes.Buffer
+	_ = ast.Walk(n, func(c ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch t := c.(type) {
+		case *ast.Image, *ast.RawHTML:
+			return ast.WalkSkipChildren, nil
+		case *ast.AutoLink:
+			buf.Write(t.Label(source))
+		case *ast.Text:
+			value := t.Segment.Value(source)
+			if !t.IsRaw() {
+				value = util.ResolveEntityNames(util.ResolveNumericReferences(util.UnescapePunctuations(value)))
+			}
+			buf.Write(value)
+			if t.SoftLineBreak() || t.HardLineBreak() {
+				buf.WriteByte(' ')
+			}
+		case *ast.String:
+			buf.Write(t.Value)
+		}
+		return ast.WalkContinue, nil
+	})
+	return strings.Join(strings.Fields(buf.String()), " ")
+}
+
+// truncateSnippet cuts a snippet longer than maxLength characters back to the last whole word, marking the cut
+func truncateSnippet(snippet string, maxLength int) string {
+	if utf8.RuneCountInString(snippet) <= maxLength {
+		return snippet
+	}
+
+	runes := []rune(snippet)
+	cut := string(runes[:maxLength])
+	if lastSpace := strings.LastIndexAny(cut, " \t"); lastSpace > 0 {
+		cut = cut[:lastSpace]
+	}
+	return cut + "..."
+}
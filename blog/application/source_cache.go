@@ -0,0 +1,199 @@
+package application
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/google/go-github/v75/github"
+	"github.com/rs/zerolog/log"
+)
+
+var _ domain.SourceRepository = (*CachingSourceRepository)(nil)
+
+// CachingSourceRepository wraps a SourceRepository, keeping commits, trees, comparisons and file contents in a persistent cache
+// Lookups by commit SHA are served from the cache without contacting the source, since they cannot change.
+// A file not cached at a commit is still served without a fetch when the commit's tree or changes name a blob
+// already cached for any path or commit, so files a resync finds unchanged are not downloaded again.
+// Branch lookups always go to the source, but fall back to the last cached copy if it is unreachable.
+type CachingSourceRepository struct {
+	domain.SourceRepository
+	cache domain.SourceCache
+}
+
+// NewCachingSourceRepository wraps source with a cache
+func NewCachingSourceRepository(source domain.SourceRepository, cache domain.SourceCache) *CachingSourceRepository {
+	return &CachingSourceRepository{
+		SourceRepository: source,
+		cache:            cache,
+	}
+}
+
+// GetCommit returns a commit from the cache, fetching and caching it on a miss
+func (c *CachingSourceRepository) GetCommit(ctx context.Context, sha string) (*github.RepositoryCommit, error) {
+	if !isCommitSHA(sha) {
+		return c.SourceRepository.GetCommit(ctx, sha)
+	}
+
+	commit, err := c.cache.GetCommit(ctx, sha)
+	if err == nil {
+		return commit, nil
+	}
+	if !errors.Is(err, domain.ErrSourceCacheMiss) {
+		log.Warn().Err(err).Str("sha", sha).Msg("Failed to read commit from source cache")
+	}
+
+	commit, err = c.SourceRepository.GetCommit(ctx, sha)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.cache.SaveCommit(ctx, commit); err != nil {
+		log.Warn().Err(err).Str("sha", sha).Msg("Failed to cache commit")
+	}
+
+	return commit, nil
+}
+
+// GetTree returns a commit's tree from the cache, fetching and caching it on a miss
+func (c *CachingSourceRepository) GetTree(ctx context.Context, sha string) (*github.Tree, error) {
+	if !isCommitSHA(sha) {
+		return c.SourceRepository.GetTree(ctx, sha)
+	}
+
+	tree, err := c.cache.GetTree(ctx, sha)
+	if err == nil {
+		return tree, nil
+	}
+	if !errors.Is(err, domain.ErrSourceCacheMiss) {
+		log.Warn().Err(err).Str("sha", sha).Msg("Failed to read tree from source cache")
+	}
+
+	tree, err = c.SourceRepository.GetTree(ctx, sha)
+	if err != nil {
+		return nil, err
+	}
+
+	// A truncated tree is missing files, so it is fetched again rather than relied on
+	if !tree.GetTruncated() {
+		if err := c.cache.SaveTree(ctx, sha, tree); err != nil {
+			log.Warn().Err(err).Str("sha", sha).Msg("Failed to cache tree")
+		}
+	}
+
+	return tree, nil
+}
+
+// CompareCommits returns a comparison from the cache when both ends are commit SHAs, fetching and caching it on a miss
+func (c *CachingSourceRepository) CompareCommits(ctx context.Context, baseCommit string, headCommit string) (*github.CommitsComparison, error) {
+	if !isCommitSHA(baseCommit) || !isCommitSHA(headCommit) {
+		return c.SourceRepository.CompareCommits(ctx, baseCommit, headCommit)
+	}
+
+	comparison, err := c.cache.GetComparison(ctx, baseCommit, headCommit)
+	if err == nil {
+		return comparison, nil
+	}
+	if !errors.Is(err, domain.ErrSourceCacheMiss) {
+		log.Warn().Err(err).Str("base", baseCommit).Str("head", headCommit).Msg("Failed to read comparison from source cache")
+	}
+
+	comparison, err = c.SourceRepository.CompareCommits(ctx, baseCommit, headCommit)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.cache.SaveComparison(ctx, baseCommit, headCommit, comparison); err != nil {
+		log.Warn().Err(err).Str("base", baseCommit).Str("head", headCommit).Msg("Failed to cache comparison")
+	}
+
+	return comparison, nil
+}
+
+// GetCommitsInRange returns the commits of a cached comparison, so it shares the cache with CompareCommits
+func (c *CachingSourceRepository) GetCommitsInRange(ctx context.Context, baseCommit string, headCommit string) ([]*github.RepositoryCommit, error) {
+	comparison, err := c.CompareCommits(ctx, baseCommit, headCommit)
+	if err != nil {
+		return nil, err
+	}
+	return comparison.Commits, nil
+}
+
+// GetFileContents returns a file from the cache when ref is a commit SHA, otherwise from the source
+func (c *CachingSourceRepository) GetFileContents(ctx context.Context, path string, ref string) ([]byte, error) {
+	if isCommitSHA(ref) {
+		content, err := c.cache.GetFile(ctx, path, ref)
+		if err == nil {
+			return content, nil
+		}
+		if !errors.Is(err, domain.ErrSourceCacheMiss) {
+			log.Warn().Err(err).Str("path", path).Str("ref", ref).Msg("Failed to read file from source cache")
+		}
+
+		if content, ok := c.cachedBlob(ctx, path, ref); ok {
+			if err := c.cache.SaveFile(ctx, path, ref, content); err != nil {
+				log.Warn().Err(err).Str("path", path).Str("ref", ref).Msg("Failed to cache file")
+			}
+			return content, nil
+		}
+	}
+
+	content, err := c.SourceRepository.GetFileContents(ctx, path, ref)
+	if err != nil {
+		if isCommitSHA(ref) || ctx.Err() != nil {
+			return nil, err
+		}
+
+		// Branches move, so a cached copy may be stale, but it lets posts render while the source is down
+		cached, cacheErr := c.cache.GetFile(ctx, path, ref)
+		if cacheErr != nil {
+			return nil, err
+		}
+		log.Warn().Err(err).Str("path", path).Str("ref", ref).Msg("Source unavailable, serving cached file")
+		return cached, nil
+	}
+
+	if err := c.cache.SaveFile(ctx, path, ref, content); err != nil {
+		log.Warn().Err(err).Str("path", path).Str("ref", ref).Msg("Failed to cache file")
+	}
+
+	return content, nil
+}
+
+// cachedBlob returns the contents of a file at a commit when the cache knows the file's blob SHA and holds that blob
+// The blob SHA comes from the commit's cached tree, or from its cached changes; neither is fetched for the lookup.
+func (c *CachingSourceRepository) cachedBlob(ctx context.Context, path string, sha string) ([]byte, bool) {
+	blobSHA := ""
+	if tree, err := c.cache.GetTree(ctx, sha); err == nil {
+		for _, entry := range tree.Entries {
+			if entry.GetPath() == path && entry.GetType() == "blob" {
+				blobSHA = entry.GetSHA()
+				break
+			}
+		}
+	} else if commit, err := c.cache.GetCommit(ctx, sha); err == nil {
+		blobSHA = fileBlobSHA(commit, path)
+	}
+	if blobSHA == "" {
+		return nil, false
+	}
+
+	content, err := c.cache.GetBlob(ctx, blobSHA)
+	if err != nil {
+		if !errors.Is(err, domain.ErrSourceCacheMiss) {
+			log.Warn().Err(err).Str("blob", blobSHA).Msg("Failed to read blob from source cache")
+		}
+		return nil, false
+	}
+	return content, true
+}
+
+// isCommitSHA reports whether ref is a full SHA-1 or SHA-256 commit hash rather than a branch or tag
+func isCommitSHA(ref string) bool {
+	if len(ref) != 40 && len(ref) != 64 {
+		return false
+	}
+	_, err := hex.DecodeString(ref)
+	return err == nil
+}
@@ -0,0 +1,154 @@
+package application
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/google/go-github/v75/github"
+)
+
+func TestCachingSourceRepository_GetCommit(t *testing.T) {
+	source := newFakeSourceRepository()
+	sha := strings.Repeat("a", 40)
+	source.commits[sha] = testCommit(sha, "posts/001-test.md")
+
+	repo := NewCachingSourceRepository(source, newFakeSourceCache())
+	ctx := context.Background()
+
+	for range 2 {
+		commit, err := repo.GetCommit(ctx, sha)
+		if err != nil {
+			t.Fatalf("GetCommit() error = %v", err)
+		}
+		if commit.GetSHA() != sha {
+			t.Errorf("GetCommit() sha = %s, want %s", commit.GetSHA(), sha)
+		}
+	}
+
+	if source.getCommitCalls != 1 {
+		t.Errorf("source GetCommit called %d times, want 1", source.getCommitCalls)
+	}
+}
+
+func TestCachingSourceRepository_GetFileContents_CommitSHA(t *testing.T) {
+	source := newFakeSourceRepository()
+	source.files["posts/001-test.md"] = []byte("# Test")
+
+	repo := NewCachingSourceRepository(source, newFakeSourceCache())
+	ctx := context.Background()
+	sha := strings.Repeat("b", 40)
+
+	for range 2 {
+		content, err := repo.GetFileContents(ctx, "posts/001-test.md", sha)
+		if err != nil {
+			t.Fatalf("GetFileContents() error = %v", err)
+		}
+		if string(content) != "# Test" {
+			t.Errorf("GetFileContents() = %q, want %q", content, "# Test")
+		}
+	}
+
+	if source.getFileCalls != 1 {
+		t.Errorf("source GetFileContents called %d times, want 1", source.getFileCalls)
+	}
+}
+
+func TestCachingSourceRepository_GetFileContents_BranchFallback(t *testing.T) {
+	source := newFakeSourceRepository()
+	source.files["posts/001-test.md"] = []byte("# Test")
+
+	repo := NewCachingSourceRepository(source, newFakeSourceCache())
+	ctx := context.Background()
+
+	if _, err := repo.GetFileContents(ctx, "posts/001-test.md", "main"); err != nil {
+		t.Fatalf("GetFileContents() error = %v", err)
+	}
+
+	// Branch refs are always fetched fresh while the source is reachable
+	source.files["posts/001-test.md"] = []byte("# Updated")
+	content, err := repo.GetFileContents(ctx, "posts/001-test.md", "main")
+	if err != nil {
+		t.Fatalf("GetFileContents() error = %v", err)
+	}
+	if string(content) != "# Updated" {
+		t.Errorf("GetFileContents() = %q, want %q", content, "# Updated")
+	}
+
+	delete(source.files, "posts/001-test.md")
+	content, err = repo.GetFileContents(ctx, "posts/001-test.md", "main")
+	if err != nil {
+		t.Fatalf("GetFileContents() with source unavailable error = %v", err)
+	}
+	if string(content) != "# Updated" {
+		t.Errorf("GetFileContents() fallback = %q, want %q", content, "# Updated")
+	}
+
+	if _, err := repo.GetFileContents(ctx, "posts/002-missing.md", "main"); err == nil {
+		t.Error("GetFileContents() for an uncached missing file should fail")
+	}
+}
+
+func TestCachingSourceRepository_TreesAndComparisons(t *testing.T) {
+	source := newFakeSourceRepository()
+	base, head := strings.Repeat("a", 40), strings.Repeat("b", 40)
+	source.trees[head] = &github.Tree{Entries: []*github.TreeEntry{{Path: github.Ptr("posts/001-test.md"), Type: github.Ptr("blob")}}}
+	source.comparisons[base+"..."+head] = &github.CommitsComparison{Status: github.Ptr("ahead"), Commits: []*github.RepositoryCommit{testCommit(head)}}
+
+	repo := NewCachingSourceRepository(source, newFakeSourceCache())
+	ctx := context.Background()
+	if _, err := repo.GetTree(ctx, head); err != nil {
+		t.Fatalf("GetTree() error = %v", err)
+	}
+	if _, err := repo.CompareCommits(ctx, base, head); err != nil {
+		t.Fatalf("CompareCommits() error = %v", err)
+	}
+
+	// Both are served from the cache once the source has forgotten them
+	delete(source.trees, head)
+	delete(source.comparisons, base+"..."+head)
+	if tree, err := repo.GetTree(ctx, head); err != nil || len(tree.Entries) != 1 {
+		t.Errorf("GetTree() = %v, %v, want the cached tree", tree, err)
+	}
+	if commits, err := repo.GetCommitsInRange(ctx, base, head); err != nil || len(commits) != 1 {
+		t.Errorf("GetCommitsInRange() = %v, %v, want the cached comparison's commits", commits, err)
+	}
+	if _, err := repo.CompareCommits(ctx, base, "main"); err == nil {
+		t.Error("Expected a comparison with a branch to go to the source")
+	}
+}
+
+func TestCachingSourceRepository_GetFileContents_KnownBlob(t *testing.T) {
+	source := newFakeSourceRepository()
+	content := []byte("# Test")
+	source.files["posts/001-test.md"] = content
+
+	cache := newFakeSourceCache()
+	repo := NewCachingSourceRepository(source, cache)
+	ctx := context.Background()
+	first, second, third := strings.Repeat("a", 40), strings.Repeat("b", 40), strings.Repeat("c", 40)
+	if _, err := repo.GetFileContents(ctx, "posts/001-test.md", first); err != nil {
+		t.Fatalf("GetFileContents() error = %v", err)
+	}
+
+	// A later commit whose tree lists the same blob, even at another path, is served from the cache
+	cache.trees[second] = &github.Tree{Entries: []*github.TreeEntry{
+		{Path: github.Ptr("posts/001-renamed.md"), Type: github.Ptr("blob"), SHA: github.Ptr(domain.GitBlobSHA(content))},
+	}}
+	got, err := repo.GetFileContents(ctx, "posts/001-renamed.md", second)
+	if err != nil || string(got) != "# Test" {
+		t.Fatalf("GetFileContents() = %q, %v, want the cached blob", got, err)
+	}
+	// So is a commit whose cached changes list it
+	commit := testCommit(third, "posts/001-test.md")
+	commit.Files[0].SHA = github.Ptr(domain.GitBlobSHA(content))
+	cache.commits[third] = commit
+	if got, err := repo.GetFileContents(ctx, "posts/001-test.md", third); err != nil || string(got) != "# Test" {
+		t.Fatalf("GetFileContents() = %q, %v, want the cached blob", got, err)
+	}
+
+	if source.getFileCalls != 1 {
+		t.Errorf("source GetFileContents called %d times, want 1", source.getFileCalls)
+	}
+}
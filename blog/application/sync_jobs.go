@@ -0,0 +1,84 @@
+package application
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/rs/zerolog/log"
+)
+
+// ListSyncJobs returns recent sync jobs, newest first
+func (s *PostService) ListSyncJobs(ctx context.Context, limit int, offset int) ([]*domain.SyncJob, error) {
+	if s.syncJobs == nil {
+		return []*domain.SyncJob{}, nil
+	}
+	return s.syncJobs.ListJobs(ctx, limit, offset)
+}
+
+// GetSyncJob returns a sync job with the status of each of its files
+func (s *PostService) GetSyncJob(ctx context.Context, id int64) (*domain.SyncJob, error) {
+	if s.syncJobs == nil {
+		return nil, fmt.Errorf("%w: %d", domain.ErrSyncJobNotFound, id)
+	}
+	return s.syncJobs.GetJob(ctx, id)
+}
+
+// startSyncJob records a new job for a push, tag or release moving ref from before to after
+// Returns a zero ID when job tracking is disabled
+func (s *PostService) startSyncJob(ref string, before string, after string) (int64, error) {
+	if s.syncJobs == nil {
+		return 0, nil
+	}
+
+	job := &domain.SyncJob{
+		Ref:    ref,
+		Before: before,
+		After:  after,
+	}
+	if err := s.syncJobs.CreateJob(s.ctx, job); err != nil {
+		return 0, fmt.Errorf("failed to create sync job: %w", err)
+	}
+
+	return job.ID, nil
+}
+
+// addSyncJobFiles records the files a job is about to process
+// Tracking failures are logged rather than failing the push
+func (s *PostService) addSyncJobFiles(jobID int64, tasks []syncTask) {
+	if s.syncJobs == nil {
+		return
+	}
+
+	files := make([]*domain.SyncJobFile, 0, len(tasks))
+	for _, task := range tasks {
+		files = append(files, &task.file)
+	}
+
+	if err := s.syncJobs.AddFiles(s.ctx, jobID, files); err != nil {
+		log.Error().Err(err).Int64("jobID", jobID).Msg("Failed to record sync job files")
+	}
+}
+
+// completeSyncJobFile records the outcome of processing a file
+// The service context may already be cancelled during shutdown, so the update runs without it
+func (s *PostService) completeSyncJobFile(jobID int64, path string, fileErr error) {
+	if s.syncJobs == nil {
+		return
+	}
+
+	if err := s.syncJobs.CompleteFile(context.WithoutCancel(s.ctx), jobID, path, fileErr); err != nil {
+		log.Error().Err(err).Int64("jobID", jobID).Str("path", path).Msg("Failed to record sync job file status")
+	}
+}
+
+// failSyncJob marks a job as failed when its push could not be processed
+func (s *PostService) failSyncJob(jobID int64, jobErr error) {
+	if s.syncJobs == nil {
+		return
+	}
+
+	if err := s.syncJobs.FailJob(s.ctx, jobID, jobErr); err != nil {
+		log.Error().Err(err).Int64("jobID", jobID).Msg("Failed to record sync job failure")
+	}
+}
@@ -0,0 +1,185 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"runtime/debug"
+	"strconv"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultTaskRetryMaxAttempts = 3
+	defaultTaskRetryBaseDelay   = 2 * time.Second
+	defaultTaskRetryMaxDelay    = time.Minute
+	defaultTaskPoisonAfter      = 3
+)
+
+// ErrPoisoned is returned for a file quarantined after failing repeatedly at the same version
+var ErrPoisoned = errors.New("file is quarantined after failing repeatedly")
+
+var (
+	syncTaskRetries = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "goblog_sync_task_retries_total",
+		Help: "Post and image files retried after failing to process.",
+	})
+
+	syncFilesPoisoned = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "goblog_sync_files_poisoned_total",
+		Help: "Post and image files quarantined after failing repeatedly.",
+	})
+)
+
+// TaskRetryConfig controls how a file that fails to process is retried, and when it is given up on
+type TaskRetryConfig struct {
+	// MaxAttempts is how many times a file is processed within a sync before it is dead-lettered
+	MaxAttempts int
+	// BaseDelay is the backoff before the first retry; it doubles with every attempt
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff
+	MaxDelay time.Duration
+	// PoisonAfter is how many syncs a version of a file may fail before it is quarantined; zero never quarantines
+	PoisonAfter int
+}
+
+func NewTaskRetryConfig() *TaskRetryConfig {
+	cfg := &TaskRetryConfig{
+		MaxAttempts: defaultTaskRetryMaxAttempts,
+		BaseDelay:   defaultTaskRetryBaseDelay,
+		MaxDelay:    defaultTaskRetryMaxDelay,
+		PoisonAfter: defaultTaskPoisonAfter,
+	}
+
+	if n, err := strconv.Atoi(os.Getenv("SYNC_RETRY_MAX_ATTEMPTS")); err == nil && n > 0 {
+		cfg.MaxAttempts = n
+	}
+	if d, err := time.ParseDuration(os.Getenv("SYNC_RETRY_BASE_DELAY")); err == nil && d > 0 {
+		cfg.BaseDelay = d
+	}
+	if d, err := time.ParseDuration(os.Getenv("SYNC_RETRY_MAX_DELAY")); err == nil && d > 0 {
+		cfg.MaxDelay = d
+	}
+	if n, err := strconv.Atoi(os.Getenv("SYNC_POISON_AFTER")); err == nil && n >= 0 {
+		cfg.PoisonAfter = n
+	}
+
+	return cfg
+}
+
+// WithTaskRetries retries files that fail to process, and quarantines versions of files that keep failing
+// Without it each file is processed once per sync and never quarantined.
+func WithTaskRetries(cfg *TaskRetryConfig) PostServiceOption {
+	return func(s *PostService) {
+		s.taskRetries = cfg
+	}
+}
+
+// backoff returns the delay before the given retry, with up to 50% jitter
+func (c *TaskRetryConfig) backoff(retry int) time.Duration {
+	delay := c.BaseDelay << (retry - 1)
+	if delay <= 0 || delay > c.MaxDelay {
+		delay = c.MaxDelay
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// processFile runs fn for the file at path and ref, retrying it according to the retry policy
+// A panic, such as one from a markdown extension, fails the attempt instead of the server.
+// Quarantined files fail straight away with ErrPoisoned. The worker slot is kept while backing off,
+// so a run of failures slows the sync down rather than starting more work.
+func (s *PostService) processFile(ctx context.Context, path string, ref string, fn func(ctx context.Context) error) error {
+	if s.poisoned(ctx, path, ref) {
+		return fmt.Errorf("%w: %s at %s", ErrPoisoned, path, ref)
+	}
+
+	maxAttempts := 1
+	if s.taskRetries != nil {
+		maxAttempts = max(s.taskRetries.MaxAttempts, 1)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := runRecovered(ctx, fn)
+		if err == nil || attempt >= maxAttempts || ctx.Err() != nil {
+			return err
+		}
+
+		delay := s.taskRetries.backoff(attempt)
+		log.Warn().Err(err).Str("path", path).Int("attempt", attempt).Dur("delay", delay).Msg("Retrying file")
+		syncTaskRetries.Inc()
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// runRecovered runs fn, turning a panic into an error
+func runRecovered(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error().Interface("panic", r).Bytes("stack", debug.Stack()).Msg("Recovered from panic processing file")
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn(ctx)
+}
+
+// poisoned reports whether the file at path is quarantined at ref
+// A failure to look it up is logged and treated as not quarantined, so it can't stop a sync.
+func (s *PostService) poisoned(ctx context.Context, path string, ref string) bool {
+	if s.deadLetters == nil || s.taskRetries == nil || s.taskRetries.PoisonAfter == 0 {
+		return false
+	}
+
+	dl, err := s.deadLetters.GetDeadLetter(ctx, path)
+	if errors.Is(err, domain.ErrDeadLetterNotFound) {
+		return false
+	}
+	if err != nil {
+		log.Error().Err(err).Str("path", path).Msg("Failed to check whether file is quarantined")
+		return false
+	}
+
+	return !dl.PoisonedAt.IsZero() && dl.Ref == ref
+}
+
+// poisonIfRepeated quarantines a dead-lettered file once its current version has failed PoisonAfter times
+func (s *PostService) poisonIfRepeated(ctx context.Context, path string) {
+	if s.taskRetries == nil || s.taskRetries.PoisonAfter == 0 {
+		return
+	}
+
+	dl, err := s.deadLetters.GetDeadLetter(ctx, path)
+	if err != nil {
+		log.Error().Err(err).Str("path", path).Msg("Failed to read dead letter")
+		return
+	}
+	if !dl.PoisonedAt.IsZero() || dl.RefAttempts < s.taskRetries.PoisonAfter {
+		return
+	}
+
+	if err := s.deadLetters.Poison(ctx, path); err != nil {
+		log.Error().Err(err).Str("path", path).Msg("Failed to quarantine file")
+		return
+	}
+	syncFilesPoisoned.Inc()
+	log.Warn().Str("path", path).Str("ref", dl.Ref).Int("failures", dl.RefAttempts).Msg("Quarantined file after repeated failures")
+}
+
+// ReleaseDeadLetter lifts the quarantine of a file so the next sync that includes it processes it again
+func (s *PostService) ReleaseDeadLetter(ctx context.Context, path string) error {
+	if s.deadLetters == nil {
+		return fmt.Errorf("%w: %s", domain.ErrDeadLetterNotFound, path)
+	}
+	return s.deadLetters.Release(ctx, path)
+}
@@ -0,0 +1,111 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+)
+
+func newRetryTestService(deadLetters *fakeDeadLetterRepository, cfg *TaskRetryConfig) *PostService {
+	return NewPostService(newFakePostRepository(), newFakeImageRepository(), nil, NewMarkdownRenderer(), "main",
+		WithDeadLetters(deadLetters),
+		WithTaskRetries(cfg),
+	)
+}
+
+func TestPostService_ProcessFileRetries(t *testing.T) {
+	service := newRetryTestService(newFakeDeadLetterRepository(), &TaskRetryConfig{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond})
+	defer service.Close()
+	ctx := context.Background()
+
+	calls := 0
+	err := service.processFile(ctx, "posts/001-test.md", "abc", func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return errors.New("temporary")
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Errorf("processFile() = %v after %d calls, want success on the third", err, calls)
+	}
+
+	calls = 0
+	err = service.processFile(ctx, "posts/001-test.md", "abc", func(ctx context.Context) error {
+		calls++
+		panic("extension crashed")
+	})
+	if err == nil || !strings.Contains(err.Error(), "extension crashed") || calls != 3 {
+		t.Errorf("processFile() = %v after %d calls, want the panic as an error after 3 calls", err, calls)
+	}
+}
+
+func TestPostService_PoisonsRepeatedFailures(t *testing.T) {
+	deadLetters := newFakeDeadLetterRepository()
+	service := newRetryTestService(deadLetters, &TaskRetryConfig{MaxAttempts: 1, PoisonAfter: 2})
+	defer service.Close()
+	ctx := context.Background()
+
+	calls := 0
+	fail := func(ctx context.Context) error {
+		calls++
+		return errors.New("malformed")
+	}
+	process := func(ref string) error {
+		err := service.processFile(ctx, "posts/001-test.md", ref, fail)
+		service.recordFileResult("posts/001-test.md", ref, err)
+		return err
+	}
+
+	for range 2 {
+		if err := process("abc"); errors.Is(err, ErrPoisoned) {
+			t.Fatalf("processFile() = %v before the file was quarantined", err)
+		}
+	}
+	if err := process("abc"); !errors.Is(err, ErrPoisoned) || calls != 2 {
+		t.Errorf("processFile() = %v after %d calls, want ErrPoisoned without running it again", err, calls)
+	}
+	dl, err := deadLetters.GetDeadLetter(ctx, "posts/001-test.md")
+	if err != nil || dl.Attempts != 2 || dl.PoisonedAt.IsZero() {
+		t.Errorf("GetDeadLetter() = %+v, %v, want 2 attempts and the file quarantined", dl, err)
+	}
+
+	if err := service.ReleaseDeadLetter(ctx, "posts/001-test.md"); err != nil {
+		t.Fatalf("ReleaseDeadLetter() error = %v", err)
+	}
+	if err := process("abc"); errors.Is(err, ErrPoisoned) || calls != 3 {
+		t.Errorf("processFile() = %v after %d calls, want the released file processed", err, calls)
+	}
+
+	if err := service.ReleaseDeadLetter(ctx, "posts/missing.md"); !errors.Is(err, domain.ErrDeadLetterNotFound) {
+		t.Errorf("ReleaseDeadLetter() error = %v, want ErrDeadLetterNotFound", err)
+	}
+}
+
+func TestPostService_NewVersionLiftsQuarantine(t *testing.T) {
+	deadLetters := newFakeDeadLetterRepository()
+	service := newRetryTestService(deadLetters, &TaskRetryConfig{MaxAttempts: 1, PoisonAfter: 1})
+	defer service.Close()
+	ctx := context.Background()
+
+	service.recordFileResult("posts/001-test.md", "abc", errors.New("malformed"))
+	if !service.poisoned(ctx, "posts/001-test.md", "abc") {
+		t.Fatal("Expected the failing version to be quarantined")
+	}
+	if service.poisoned(ctx, "posts/001-test.md", "def") {
+		t.Error("Expected another version of the file not to be quarantined")
+	}
+
+	ran := false
+	err := service.processFile(ctx, "posts/001-test.md", "def", func(ctx context.Context) error {
+		ran = true
+		return nil
+	})
+	if err != nil || !ran {
+		t.Errorf("processFile() = %v, ran = %v, want the new version processed", err, ran)
+	}
+}
@@ -0,0 +1,58 @@
+package application
+
+import (
+	"time"
+
+	"github.com/google/go-github/v75/github"
+)
+
+// TimestampSource chooses which time a post's UpdatedAt is taken from
+type TimestampSource string
+
+const (
+	// TimestampAuthor uses the commit's author date, which is kept when a commit is rebased
+	TimestampAuthor TimestampSource = "author"
+	// TimestampCommitter uses the commit's committer date, which moves whenever the commit is rewritten
+	TimestampCommitter TimestampSource = "committer"
+	// TimestampPush uses the time the commit was pushed
+	// Syncs do not see pushes, so they fall back to the committer date.
+	TimestampPush TimestampSource = "push"
+)
+
+// parseTimestampSource returns the source named by value, or TimestampAuthor when it names none
+func parseTimestampSource(value string) TimestampSource {
+	switch source := TimestampSource(value); source {
+	case TimestampCommitter, TimestampPush:
+		return source
+	default:
+		return TimestampAuthor
+	}
+}
+
+// WithTimestampSource sets which commit time posts record as their last update
+func WithTimestampSource(cfg *SyncConfig) PostServiceOption {
+	return func(s *PostService) {
+		s.timestamps = cfg.Timestamps
+	}
+}
+
+// commitTime returns the time a commit changed a post, according to the configured source
+// pushedAt is when the push delivering the commit happened, or zero during a sync.
+func (s *PostService) commitTime(commit *github.RepositoryCommit, pushedAt time.Time) time.Time {
+	author := commit.GetCommit().GetAuthor().GetDate().Time
+	committer := commit.GetCommit().GetCommitter().GetDate().Time
+
+	switch s.timestamps {
+	case TimestampPush:
+		if !pushedAt.IsZero() {
+			return pushedAt
+		}
+		fallthrough
+	case TimestampCommitter:
+		if !committer.IsZero() {
+			return committer
+		}
+	}
+
+	return author
+}
@@ -0,0 +1,41 @@
+package application
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v75/github"
+)
+
+func TestPostService_CommitTime(t *testing.T) {
+	authored := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	committed := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	pushed := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	commit := &github.RepositoryCommit{
+		Commit: &github.Commit{
+			Author:    &github.CommitAuthor{Date: &github.Timestamp{Time: authored}},
+			Committer: &github.CommitAuthor{Date: &github.Timestamp{Time: committed}},
+		},
+	}
+
+	tests := []struct {
+		source   string
+		pushedAt time.Time
+		want     time.Time
+	}{
+		{"", pushed, authored},
+		{"unknown", pushed, authored},
+		{"author", pushed, authored},
+		{"committer", pushed, committed},
+		{"push", pushed, pushed},
+		{"push", time.Time{}, committed},
+	}
+
+	for _, tt := range tests {
+		service := &PostService{timestamps: parseTimestampSource(tt.source)}
+		if got := service.commitTime(commit, tt.pushedAt); !got.Equal(tt.want) {
+			t.Errorf("commitTime(%q, pushedAt=%v) = %v, want %v", tt.source, tt.pushedAt, got, tt.want)
+		}
+	}
+}
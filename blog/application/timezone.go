@@ -0,0 +1,75 @@
+package application
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// TimezoneConfig names the time zone dates are shown and scheduled in
+// Times are always stored in UTC; the zone only affects how they are read from front matter and displayed.
+type TimezoneConfig struct {
+	// Name is an IANA time zone name such as Europe/London, defaulting to UTC
+	Name string
+}
+
+func NewTimezoneConfig() *TimezoneConfig {
+	name := os.Getenv("SITE_TIMEZONE")
+	if name == "" {
+		name = "UTC"
+	}
+
+	return &TimezoneConfig{
+		Name: name,
+	}
+}
+
+// Location loads the configured time zone
+func (c *TimezoneConfig) Location() (*time.Location, error) {
+	loc, err := time.LoadLocation(c.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load time zone %q: %w", c.Name, err)
+	}
+	return loc, nil
+}
+
+// WithTimezone interprets front matter times written without a UTC offset in loc
+func WithTimezone(loc *time.Location) MarkdownOption {
+	return func(o *markdownOptions) {
+		o.location = loc
+	}
+}
+
+// wallClockIn returns the instant at which clocks in loc show the given date and time
+// A time skipped by a daylight saving change is moved forward by the length of the gap, as clocks are,
+// and a time repeated when clocks go back means its first occurrence.
+func wallClockIn(year int, month time.Month, day, hour, min, sec int, loc *time.Location) time.Time {
+	wall := time.Date(year, month, day, hour, min, sec, 0, time.UTC)
+
+	// Offsets a day either side of the wall time cover both sides of any transition near it
+	_, before := wall.Add(-24 * time.Hour).In(loc).Zone()
+	_, after := wall.Add(24 * time.Hour).In(loc).Zone()
+	candidates := []time.Time{
+		wall.Add(-time.Duration(before) * time.Second).In(loc),
+		wall.Add(-time.Duration(after) * time.Second).In(loc),
+	}
+
+	var match time.Time
+	for _, t := range candidates {
+		y, m, d := t.Date()
+		if y == year && m == month && d == day && t.Hour() == hour && t.Minute() == min && t.Second() == sec {
+			if match.IsZero() || t.Before(match) {
+				match = t
+			}
+		}
+	}
+	if !match.IsZero() {
+		return match
+	}
+
+	// Neither offset shows the wall time, so it falls in a gap; the offset in use before the gap moves it forward
+	if candidates[0].After(candidates[1]) {
+		return candidates[0]
+	}
+	return candidates[1]
+}
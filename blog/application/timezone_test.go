@@ -0,0 +1,141 @@
+package application
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/shared/clock"
+	"github.com/google/go-github/v75/github"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+	return loc
+}
+
+func TestParsePublishAt(t *testing.T) {
+	newYork := mustLoadLocation(t, "America/New_York")
+
+	tests := []struct {
+		name  string
+		value string
+		want  time.Time
+	}{
+		{
+			name:  "Explicit offset is kept",
+			value: "2024-06-01T09:00:00+02:00",
+			want:  time.Date(2024, 6, 1, 7, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "Local time in summer",
+			value: "2024-06-01 09:00",
+			want:  time.Date(2024, 6, 1, 13, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "Local time in winter",
+			value: "2024-01-15T09:00:00",
+			want:  time.Date(2024, 1, 15, 14, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "Date is local midnight",
+			value: "2024-06-01",
+			want:  time.Date(2024, 6, 1, 4, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "Last minute before clocks go forward",
+			value: "2024-03-10 01:59",
+			want:  time.Date(2024, 3, 10, 6, 59, 0, 0, time.UTC),
+		},
+		{
+			name:  "Skipped time moves forward by the gap",
+			value: "2024-03-10 02:30",
+			want:  time.Date(2024, 3, 10, 7, 30, 0, 0, time.UTC),
+		},
+		{
+			name:  "First minute after clocks go forward",
+			value: "2024-03-10 03:00",
+			want:  time.Date(2024, 3, 10, 7, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "Repeated time is its first occurrence",
+			value: "2024-11-03 01:30",
+			want:  time.Date(2024, 11, 3, 5, 30, 0, 0, time.UTC),
+		},
+		{
+			name:  "First minute after clocks go back",
+			value: "2024-11-03 02:00",
+			want:  time.Date(2024, 11, 3, 7, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePublishAt(tt.value, newYork)
+			if err != nil {
+				t.Fatalf("parsePublishAt() error = %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parsePublishAt(%q) = %v, want %v", tt.value, got.UTC(), tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePublishAt_SouthernHemisphere(t *testing.T) {
+	sydney := mustLoadLocation(t, "Australia/Sydney")
+
+	// Clocks in Sydney go forward from 02:00 to 03:00 on the first Sunday of October
+	got, err := parsePublishAt("2024-10-06 02:15", sydney)
+	if err != nil {
+		t.Fatalf("parsePublishAt() error = %v", err)
+	}
+	if want := time.Date(2024, 10, 5, 16, 15, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("parsePublishAt() = %v, want %v", got.UTC(), want)
+	}
+}
+
+func TestPostService_ProcessBranches_SchedulesAcrossDST(t *testing.T) {
+	newYork := mustLoadLocation(t, "America/New_York")
+
+	source := newFakeSourceRepository()
+	repo := newFakePostRepository()
+	// Half an hour after clocks went forward, when local clocks read 03:30
+	now := time.Date(2024, 3, 10, 7, 30, 0, 0, time.UTC)
+	service := NewPostService(repo, newFakeImageRepository(), source, NewMarkdownRenderer(WithTimezone(newYork)), "main",
+		WithClock(clock.Fixed(now)),
+	)
+	defer service.Close()
+
+	source.commits["a"] = testCommit("a", "posts/001-skipped.md", "posts/002-later.md")
+	source.files["posts/001-skipped.md"] = []byte("---\npublish_at: 2024-03-10 02:30\n---\n# Skipped\n\nBody")
+	source.files["posts/002-later.md"] = []byte("---\npublish_at: 2024-03-10 03:45\n---\n# Later\n\nBody")
+
+	branches := []*github.Branch{{Name: github.Ptr("main")}}
+	if err := service.processBranches(time.Time{}, branches); err != nil {
+		t.Fatalf("processBranches() error = %v", err)
+	}
+
+	if repo.posts["001"].PublishedAt.IsZero() {
+		t.Error("post scheduled in the skipped hour was not published once clocks passed it")
+	}
+	if !repo.posts["002"].PublishedAt.IsZero() {
+		t.Error("post scheduled after the local time was published")
+	}
+
+	// Clocks reach 03:45 local, still UTC-4, fifteen minutes later
+	service.clock = clock.Fixed(now.Add(15 * time.Minute))
+	if err := service.publishDuePosts(context.Background()); err != nil {
+		t.Fatalf("publishDuePosts() error = %v", err)
+	}
+	if repo.posts["002"].PublishedAt.IsZero() {
+		t.Error("post was not published at its local time")
+	}
+	if got := repo.posts["002"].PublishAt; !got.Equal(time.Date(2024, 3, 10, 7, 45, 0, 0, time.UTC)) {
+		t.Errorf("PublishAt = %v, want 07:45 UTC", got.UTC())
+	}
+}
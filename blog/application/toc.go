@@ -0,0 +1,89 @@
+package application
+
+import (
+	"bytes"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// tocMinHeadings is how many headings a post needs before it gets a table of contents
+const tocMinHeadings = 3
+
+// tocKey stores the table of contents of the document being converted
+var tocKey = parser.NewContextKey()
+
+// tocExtractor builds a nested table of contents from a document's headings
+// Heading IDs come from parser.WithAutoHeadingID, which assigns them before transformers run.
+type tocExtractor struct{}
+
+func (tocExtractor) Transform(node *ast.Document, reader text.Reader, pc parser.Context) {
+	source := reader.Source()
+
+	var headings []*domain.Heading
+	for n := node.FirstChild(); n != nil; n = n.NextSibling() {
+		h, ok := n.(*ast.Heading)
+		if !ok {
+			continue
+		}
+		// A leading top-level heading is the post title, not a section
+		if n == node.FirstChild() && h.Level == 1 {
+			continue
+		}
+
+		id, _ := h.AttributeString("id")
+		idBytes, _ := id.([]byte)
+		headings = append(headings, &domain.Heading{
+			Level: h.Level,
+			ID:    string(idBytes),
+			Text:  string(nodeText(h, source)),
+		})
+	}
+
+	if len(headings) < tocMinHeadings {
+		return
+	}
+	pc.Set(tocKey, nestHeadings(headings))
+}
+
+// nestHeadings turns a flat list of headings into a tree, placing each heading under the nearest shallower one
+func nestHeadings(headings []*domain.Heading) []*domain.Heading {
+	var roots []*domain.Heading
+	var stack []*domain.Heading
+	for _, h := range headings {
+		for len(stack) > 0 && stack[len(stack)-1].Level >= h.Level {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			roots = append(roots, h)
+		} else {
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, h)
+		}
+		stack = append(stack, h)
+	}
+	return roots
+}
+
+// nodeText returns the plain text inside a node, without any markup
+func nodeText(n ast.Node, source []byte) []byte {
+	var buf bytes.Buffer
+	_ = ast.Walk(n, func(c ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch t := c.(type) {
+		case *ast.Text:
+			buf.Write(t.Segment.Value(source))
+			if t.SoftLineBreak() {
+				buf.WriteByte(' ')
+			}
+		case *ast.String:
+			buf.Write(t.Value)
+		}
+		return ast.WalkContinue, nil
+	})
+	return buf.Bytes()
+}
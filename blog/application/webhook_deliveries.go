@@ -0,0 +1,113 @@
+package application
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/rs/zerolog/log"
+)
+
+// webhookDeliveryRetention is how long handled delivery IDs are remembered
+// GitHub only offers redelivery of recent deliveries, so a week covers manual redeliveries too.
+const webhookDeliveryRetention = 7 * 24 * time.Hour
+
+// maxRecentWebhookFailures is how many refused or failed deliveries WebhookStatus keeps
+const maxRecentWebhookFailures = 20
+
+// WebhookStatus reports on the webhook deliveries received since startup, to debug a webhook that isn't working
+type WebhookStatus struct {
+	// Deliveries counts deliveries with a valid signature
+	Deliveries     int
+	LastDeliveryAt time.Time
+	LastEvent      string
+	LastPingAt     time.Time
+	// PingProblems are the problems found in the hook's configuration by the last ping, empty if there were none
+	PingProblems []string
+	// RecentFailures are the latest refused or failed deliveries, newest first
+	RecentFailures []WebhookFailure
+}
+
+// WebhookFailure describes a delivery that was refused or could not be handled
+type WebhookFailure struct {
+	DeliveryID string
+	Event      string
+	Reason     string
+	At         time.Time
+}
+
+// WithWebhookDeliveries remembers handled webhook deliveries so redeliveries are skipped
+func WithWebhookDeliveries(deliveries domain.WebhookDeliveryRepository) PostServiceOption {
+	return func(s *PostService) {
+		s.deliveries = deliveries
+	}
+}
+
+// ClaimDelivery reports whether a webhook delivery is new, recording it so later redeliveries are skipped
+// Deliveries without an ID, or any delivery when deliveries aren't tracked, are always new.
+func (s *PostService) ClaimDelivery(ctx context.Context, id string) (bool, error) {
+	if s.deliveries == nil || id == "" {
+		return true, nil
+	}
+	return s.deliveries.ClaimDelivery(ctx, id)
+}
+
+// ReleaseDelivery forgets a claimed delivery whose handling failed, so GitHub can redeliver it
+func (s *PostService) ReleaseDelivery(ctx context.Context, id string) {
+	if s.deliveries == nil || id == "" {
+		return
+	}
+
+	if err := s.deliveries.ReleaseDelivery(ctx, id); err != nil {
+		log.Warn().Err(err).Str("deliveryID", id).Msg("Failed to release webhook delivery")
+	}
+}
+
+// pruneWebhookDeliveries forgets deliveries too old to be redelivered
+func (s *PostService) pruneWebhookDeliveries() {
+	if s.deliveries == nil {
+		return
+	}
+
+	if err := s.deliveries.PruneDeliveries(s.ctx, s.clock.Now().UTC().Add(-webhookDeliveryRetention)); err != nil {
+		log.Warn().Err(err).Msg("Failed to prune webhook deliveries")
+	}
+}
+
+// RecordWebhookDelivery notes a delivery with a valid signature for WebhookStatus
+func (s *PostService) RecordWebhookDelivery(event string) {
+	s.webhookMu.Lock()
+	defer s.webhookMu.Unlock()
+	s.webhookStatus.Deliveries++
+	s.webhookStatus.LastDeliveryAt = s.clock.Now().UTC()
+	s.webhookStatus.LastEvent = event
+}
+
+// RecordWebhookPing notes a ping and the problems found in the configuration of the hook that sent it
+func (s *PostService) RecordWebhookPing(problems []string) {
+	s.webhookMu.Lock()
+	defer s.webhookMu.Unlock()
+	s.webhookStatus.LastPingAt = s.clock.Now().UTC()
+	s.webhookStatus.PingProblems = problems
+}
+
+// RecordWebhookFailure notes a delivery that was refused or could not be handled, keeping the most recent ones
+func (s *PostService) RecordWebhookFailure(deliveryID string, event string, reason string) {
+	failure := WebhookFailure{DeliveryID: deliveryID, Event: event, Reason: reason, At: s.clock.Now().UTC()}
+
+	s.webhookMu.Lock()
+	defer s.webhookMu.Unlock()
+	failures := append([]WebhookFailure{failure}, s.webhookStatus.RecentFailures...)
+	s.webhookStatus.RecentFailures = failures[:min(len(failures), maxRecentWebhookFailures)]
+}
+
+// WebhookStatus returns what has been recorded about webhook deliveries since startup
+func (s *PostService) WebhookStatus() WebhookStatus {
+	s.webhookMu.Lock()
+	defer s.webhookMu.Unlock()
+	status := s.webhookStatus
+	status.PingProblems = slices.Clone(status.PingProblems)
+	status.RecentFailures = slices.Clone(status.RecentFailures)
+	return status
+}
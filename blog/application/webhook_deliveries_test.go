@@ -0,0 +1,96 @@
+package application
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v75/github"
+)
+
+func TestPostService_ClaimDelivery(t *testing.T) {
+	service := NewPostService(newFakePostRepository(), newFakeImageRepository(), nil, NewMarkdownRenderer(), "main",
+		WithWebhookDeliveries(newFakeWebhookDeliveryRepository()),
+	)
+	defer service.Close()
+	ctx := t.Context()
+
+	if claimed, err := service.ClaimDelivery(ctx, "delivery-1"); err != nil || !claimed {
+		t.Fatalf("ClaimDelivery() = %v, %v, want a new delivery", claimed, err)
+	}
+	if claimed, _ := service.ClaimDelivery(ctx, "delivery-1"); claimed {
+		t.Error("Expected a redelivery to be a duplicate")
+	}
+
+	service.ReleaseDelivery(ctx, "delivery-1")
+	if claimed, _ := service.ClaimDelivery(ctx, "delivery-1"); !claimed {
+		t.Error("Expected a released delivery to be handled again")
+	}
+
+	for range 2 {
+		if claimed, _ := service.ClaimDelivery(ctx, ""); !claimed {
+			t.Error("Expected deliveries without an ID to always be handled")
+		}
+	}
+}
+
+func TestPostService_WebhookStatus(t *testing.T) {
+	service := NewPostService(newFakePostRepository(), newFakeImageRepository(), nil, NewMarkdownRenderer(), "main")
+	defer service.Close()
+
+	service.RecordWebhookDelivery("ping")
+	service.RecordWebhookPing([]string{"not subscribed to push events"})
+	service.RecordWebhookDelivery("push")
+	for i := range maxRecentWebhookFailures + 1 {
+		service.RecordWebhookFailure(fmt.Sprintf("delivery-%d", i), "push", "Branch not accepted")
+	}
+
+	status := service.WebhookStatus()
+	if status.Deliveries != 2 || status.LastEvent != "push" || status.LastDeliveryAt.IsZero() {
+		t.Errorf("WebhookStatus() = %+v, want two deliveries, the last a push", status)
+	}
+	if len(status.PingProblems) != 1 || status.LastPingAt.IsZero() {
+		t.Errorf("PingProblems = %v, want the problem found by the ping", status.PingProblems)
+	}
+	if len(status.RecentFailures) != maxRecentWebhookFailures {
+		t.Errorf("RecentFailures = %d, want %d", len(status.RecentFailures), maxRecentWebhookFailures)
+	}
+	if got := status.RecentFailures[0].DeliveryID; got != fmt.Sprintf("delivery-%d", maxRecentWebhookFailures) {
+		t.Errorf("RecentFailures[0] = %q, want the newest failure first", got)
+	}
+}
+
+func TestPostService_ProcessPostFile_SameVersionIsNotRepublished(t *testing.T) {
+	source := newFakeSourceRepository()
+	repo := newFakePostRepository()
+	service := NewPostService(repo, newFakeImageRepository(), source, NewMarkdownRenderer(), "main")
+	defer service.Close()
+
+	source.commits["a"] = testCommit("a", "posts/001-test.md")
+	source.files["posts/001-test.md"] = []byte("# Test\n\nBody")
+	branches := []*github.Branch{{Name: github.Ptr("main")}}
+
+	if err := service.processBranches(time.Time{}, branches); err != nil {
+		t.Fatalf("processBranches() error = %v", err)
+	}
+	published := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo.posts["001"].PublishedAt = published
+
+	// A second pass over the same commit, as an overlapping sync or a redelivered push would make
+	if err := service.processBranches(time.Time{}, branches); err != nil {
+		t.Fatalf("processBranches() error = %v", err)
+	}
+	if got := repo.posts["001"].PublishedAt; !got.Equal(published) {
+		t.Errorf("PublishedAt = %v, want it kept at %v", got, published)
+	}
+
+	// A new commit of the same file is a new version and is published again
+	delete(source.commits, "a")
+	source.commits["b"] = testCommit("b", "posts/001-test.md")
+	if err := service.processBranches(time.Time{}, branches); err != nil {
+		t.Fatalf("processBranches() error = %v", err)
+	}
+	if got := repo.posts["001"]; got.CommitSHA != "b" || got.PublishedAt.Equal(published) {
+		t.Errorf("Expected commit b to be published anew, got commit %s published at %v", got.CommitSHA, got.PublishedAt)
+	}
+}
@@ -0,0 +1,342 @@
+package application
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const (
+	// WebmentionPath and PingbackPath are where mentions are received, below the site's base URL
+	WebmentionPath = "/webmention"
+	PingbackPath   = "/xmlrpc"
+
+	defaultWebmentionQueue        = 100
+	defaultWebmentionFetchTimeout = 10 * time.Second
+	// maxWebmentionSourceBytes is how much of a source page is read when looking for the link
+	maxWebmentionSourceBytes = 1 << 20
+	maxWebmentionRedirects   = 5
+)
+
+var (
+	// ErrInvalidWebmention is returned for a mention whose source or target can't be accepted
+	ErrInvalidWebmention = errors.New("invalid webmention")
+	// ErrWebmentionTargetNotFound is returned for a mention of a URL on the blog that isn't a published post
+	ErrWebmentionTargetNotFound = errors.New("webmention target is not a published post")
+	// ErrWebmentionQueueFull is returned when too many mentions are waiting to be verified
+	ErrWebmentionQueueFull = errors.New("too many webmentions waiting to be verified")
+	// errPrivateAddress is returned for a source on a private network, which must not be fetched
+	errPrivateAddress = errors.New("address is not public")
+)
+
+type WebmentionConfig struct {
+	// Enabled receives webmentions and pingbacks, which fetches the pages that send them
+	Enabled bool
+	// BaseURL is where the blog is served; only mentions of posts below it are accepted
+	BaseURL string
+	// Queue is how many mentions may wait to be verified before more are refused
+	Queue int
+	// FetchTimeout bounds fetching a mention's source
+	FetchTimeout time.Duration
+}
+
+func NewWebmentionConfig() *WebmentionConfig {
+	baseURL := strings.TrimSuffix(os.Getenv("SITE_BASE_URL"), "/")
+	if baseURL == "" {
+		baseURL = blogURL
+	}
+
+	cfg := &WebmentionConfig{
+		Enabled:      os.Getenv("WEBMENTIONS") == "true",
+		BaseURL:      baseURL,
+		Queue:        defaultWebmentionQueue,
+		FetchTimeout: defaultWebmentionFetchTimeout,
+	}
+	if d, err := time.ParseDuration(os.Getenv("WEBMENTION_FETCH_TIMEOUT")); err == nil && d > 0 {
+		cfg.FetchTimeout = d
+	}
+
+	return cfg
+}
+
+// WithWebmentions receives webmentions and pingbacks of published posts when cfg enables them, storing
+// the ones whose source really links to the post in webmentions for moderation
+func WithWebmentions(webmentions domain.WebmentionRepository, cfg *WebmentionConfig) PostServiceOption {
+	return func(s *PostService) {
+		if !cfg.Enabled {
+			return
+		}
+		s.webmentions = webmentions
+		s.webmentionConfig = cfg
+		s.webmentionClient = newPublicClient(cfg.FetchTimeout)
+	}
+}
+
+// webmentionJob is a received mention waiting to be verified
+type webmentionJob struct {
+	source   string
+	target   string
+	postID   string
+	protocol domain.WebmentionProtocol
+}
+
+// WebmentionEndpoints returns the URLs mentions are received at, or empty strings when they are not
+func (s *PostService) WebmentionEndpoints() (webmention string, pingback string) {
+	if s.webmentions == nil {
+		return "", ""
+	}
+	return s.webmentionConfig.BaseURL + WebmentionPath, s.webmentionConfig.BaseURL + PingbackPath
+}
+
+// ReceiveWebmention accepts a mention of a published post for verification in the background
+// The target must be a post URL on the blog, and the source another http or https URL. It returns
+// ErrInvalidWebmention or ErrWebmentionTargetNotFound for mentions that can't be accepted, and
+// ErrWebmentionQueueFull when the sender should try again later.
+func (s *PostService) ReceiveWebmention(ctx context.Context, source string, target string, protocol domain.WebmentionProtocol) error {
+	if s.webmentions == nil {
+		return fmt.Errorf("%w: webmentions are not enabled", ErrInvalidWebmention)
+	}
+
+	sourceURL, err := url.Parse(source)
+	if err != nil || (sourceURL.Scheme != "http" && sourceURL.Scheme != "https") || sourceURL.Host == "" {
+		return fmt.Errorf("%w: source must be an http or https URL", ErrInvalidWebmention)
+	}
+	if stripFragment(source) == stripFragment(target) {
+		return fmt.Errorf("%w: source and target are the same", ErrInvalidWebmention)
+	}
+
+	post, err := s.webmentionTarget(ctx, target)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case s.webmentionCh <- webmentionJob{source: source, target: target, postID: post.ID, protocol: protocol}:
+		return nil
+	default:
+		return ErrWebmentionQueueFull
+	}
+}
+
+// webmentionTarget returns the published post a target URL on the blog names by slug, ID or old slug
+func (s *PostService) webmentionTarget(ctx context.Context, target string) (*domain.Post, error) {
+	key, found := strings.CutPrefix(stripFragment(target), s.webmentionConfig.BaseURL+"/posts/")
+	key, _, _ = strings.Cut(key, "?")
+	if !found || key == "" || strings.Contains(key, "/") {
+		return nil, fmt.Errorf("%w: target must be a post on %s", ErrInvalidWebmention, s.webmentionConfig.BaseURL)
+	}
+
+	post, err := s.GetPublishedPostBySlug(ctx, key)
+	if errors.Is(err, domain.ErrPostNotFound) {
+		post, err = s.GetPublishedPost(ctx, key)
+	}
+	if errors.Is(err, domain.ErrPostNotFound) {
+		post, err = s.GetRedirectTarget(ctx, key)
+	}
+	if errors.Is(err, domain.ErrPostNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrWebmentionTargetNotFound, target)
+	}
+	return post, err
+}
+
+// startWebmentionVerifier verifies received mentions one at a time until the service is closed
+// Mentions still waiting when it closes are dropped; senders may send them again.
+func (s *PostService) startWebmentionVerifier() {
+	s.webmentionCh = make(chan webmentionJob, s.webmentionConfig.Queue)
+	s.wg.Go(func() {
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case job := <-s.webmentionCh:
+				if err := s.verifyWebmention(s.ctx, job); err != nil {
+					log.Warn().Err(err).Str("source", job.source).Str("postID", job.postID).Msg("Failed to verify webmention")
+				}
+			}
+		}
+	})
+}
+
+// verifyWebmention fetches a mention's source and stores the mention if it links to the target
+// A source that is gone or no longer links to the target removes the mention it sent before.
+func (s *PostService) verifyWebmention(ctx context.Context, job webmentionJob) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, job.source, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/html, */*;q=0.5")
+	req.Header.Set("User-Agent", "goblog-webmention (+"+s.webmentionConfig.BaseURL+")")
+
+	resp, err := s.webmentionClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch source: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusGone || resp.StatusCode == http.StatusNotFound {
+		return s.webmentions.DeleteWebmention(ctx, job.source, job.postID)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("source responded %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxWebmentionSourceBytes))
+	if err != nil {
+		return fmt.Errorf("failed to read source: %w", err)
+	}
+
+	var title string
+	var links bool
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "text/html" || mediaType == "application/xhtml+xml" {
+		title, links = htmlLinksTo(body, resp.Request.URL, job.target)
+	} else {
+		links = bytes.Contains(body, []byte(job.target))
+	}
+	if !links {
+		log.Info().Str("source", job.source).Str("target", job.target).Msg("Webmention source does not link to its target")
+		return s.webmentions.DeleteWebmention(ctx, job.source, job.postID)
+	}
+
+	now := s.clock.Now().UTC()
+	mention := &domain.Webmention{
+		Source:     job.source,
+		Target:     job.target,
+		PostID:     job.postID,
+		Protocol:   job.protocol,
+		Title:      title,
+		Status:     domain.WebmentionPending,
+		ReceivedAt: now,
+		VerifiedAt: now,
+	}
+	if err := s.webmentions.SaveWebmention(ctx, mention); err != nil {
+		return err
+	}
+	log.Info().Str("source", job.source).Str("postID", job.postID).Str("status", string(mention.Status)).Msg("Verified webmention")
+	return nil
+}
+
+// htmlLinksTo returns the title of an HTML page, and whether one of its links, images or media points at target
+// Relative references are resolved against base, the URL the page was fetched from.
+func htmlLinksTo(page []byte, base *url.URL, target string) (string, bool) {
+	doc, err := html.Parse(bytes.NewReader(page))
+	if err != nil {
+		return "", false
+	}
+
+	target = stripFragment(target)
+	var title string
+	found := false
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			if n.DataAtom == atom.Title && title == "" && n.FirstChild != nil {
+				title = strings.TrimSpace(n.FirstChild.Data)
+			}
+			for _, attr := range n.Attr {
+				if attr.Namespace != "" || (attr.Key != "href" && attr.Key != "src") {
+					continue
+				}
+				ref, err := base.Parse(strings.TrimSpace(attr.Val))
+				if err == nil && stripFragment(ref.String()) == target {
+					found = true
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	return title, found
+}
+
+// stripFragment removes the #fragment of a URL, which never changes the page it names
+func stripFragment(rawURL string) string {
+	u, _, _ := strings.Cut(rawURL, "#")
+	return u
+}
+
+// ListWebmentions returns the received mentions with status, or every one when status is empty, newest first
+func (s *PostService) ListWebmentions(ctx context.Context, status domain.WebmentionStatus) ([]*domain.Webmention, error) {
+	switch status {
+	case "", domain.WebmentionPending, domain.WebmentionApproved, domain.WebmentionRejected:
+	default:
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidWebmention, status)
+	}
+	if s.webmentions == nil {
+		return []*domain.Webmention{}, nil
+	}
+	return s.webmentions.ListWebmentions(ctx, status)
+}
+
+// PostWebmentions returns the approved mentions of a post, oldest first
+func (s *PostService) PostWebmentions(ctx context.Context, postID string) ([]*domain.Webmention, error) {
+	if s.webmentions == nil {
+		return []*domain.Webmention{}, nil
+	}
+	return s.webmentions.ListPostWebmentions(ctx, postID, domain.WebmentionApproved)
+}
+
+// ModerateWebmention approves or rejects a mention
+func (s *PostService) ModerateWebmention(ctx context.Context, id int64, status domain.WebmentionStatus) error {
+	if s.webmentions == nil {
+		return fmt.Errorf("%w: %d", domain.ErrWebmentionNotFound, id)
+	}
+	return s.webmentions.SetWebmentionStatus(ctx, id, status)
+}
+
+// newPublicClient fetches URLs given by other sites, such as mention sources, refusing to connect to loopback,
+// private or link-local addresses so a sender can't make the server request its own network
+func newPublicClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network string, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			addr, err := netip.ParseAddr(host)
+			if err != nil || !isPublicAddr(addr) {
+				return fmt.Errorf("%w: %s", errPrivateAddress, host)
+			}
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext, Proxy: nil},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxWebmentionRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxWebmentionRedirects)
+			}
+			return nil
+		},
+	}
+}
+
+// sharedAddressSpace is the carrier-grade NAT range, which is private although net/netip doesn't say so
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// isPublicAddr reports whether addr is routable on the internet
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !sharedAddressSpace.Contains(addr)
+}
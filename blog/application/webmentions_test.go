@@ -0,0 +1,177 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+)
+
+func newWebmentionTestService(t *testing.T, mentions *fakeWebmentionRepository) *PostService {
+	t.Helper()
+	published := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := newFakePostRepository(
+		&domain.Post{ID: "001", Slug: "first", PublishedAt: published},
+		&domain.Post{ID: "002", Slug: "draft"},
+	)
+	service := NewPostService(repo, newFakeImageRepository(), nil, NewMarkdownRenderer(), "main",
+		WithWebmentions(mentions, &WebmentionConfig{Enabled: true, BaseURL: "https://blog.example", Queue: 10, FetchTimeout: time.Second}),
+	)
+	// The test server listens on loopback, which the real client refuses to fetch
+	service.webmentionClient = http.DefaultClient
+	t.Cleanup(func() { service.Close() })
+	return service
+}
+
+func TestPostService_ReceiveWebmention_Rejects(t *testing.T) {
+	service := newWebmentionTestService(t, newFakeWebmentionRepository())
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		source string
+		target string
+		want   error
+	}{
+		{"source is not http", "ftp://a.example/reply", "https://blog.example/posts/first", ErrInvalidWebmention},
+		{"source has no host", "https:///reply", "https://blog.example/posts/first", ErrInvalidWebmention},
+		{"source is the target", "https://blog.example/posts/first#reply", "https://blog.example/posts/first", ErrInvalidWebmention},
+		{"target is on another site", "https://a.example/reply", "https://other.example/posts/first", ErrInvalidWebmention},
+		{"target is not a post", "https://a.example/reply", "https://blog.example/", ErrInvalidWebmention},
+		{"target is not a post page", "https://a.example/reply", "https://blog.example/posts/first/extra", ErrInvalidWebmention},
+		{"target does not exist", "https://a.example/reply", "https://blog.example/posts/missing", ErrWebmentionTargetNotFound},
+		{"target is unpublished", "https://a.example/reply", "https://blog.example/posts/draft", ErrWebmentionTargetNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := service.ReceiveWebmention(ctx, tt.source, tt.target, domain.ProtocolWebmention); !errors.Is(err, tt.want) {
+				t.Errorf("ReceiveWebmention(%q, %q) error = %v, want %v", tt.source, tt.target, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPostService_ReceiveWebmention_Disabled(t *testing.T) {
+	service := NewPostService(newFakePostRepository(), newFakeImageRepository(), nil, NewMarkdownRenderer(), "main",
+		WithWebmentions(newFakeWebmentionRepository(), &WebmentionConfig{}),
+	)
+	defer service.Close()
+
+	if webmention, pingback := service.WebmentionEndpoints(); webmention != "" || pingback != "" {
+		t.Errorf("WebmentionEndpoints() = %q, %q, want none when disabled", webmention, pingback)
+	}
+	if err := service.ReceiveWebmention(context.Background(), "https://a.example/reply", "https://blog.example/posts/first", domain.ProtocolWebmention); !errors.Is(err, ErrInvalidWebmention) {
+		t.Errorf("ReceiveWebmention() error = %v, want ErrInvalidWebmention", err)
+	}
+}
+
+func TestPostService_ReceiveWebmention_Verifies(t *testing.T) {
+	pages := map[string]string{
+		"/links":    `<html><head><title> A reply </title></head><body><a href="https://blog.example/posts/first#comments">great post</a></body></html>`,
+		"/by-id":    `<html><body><img src="https://blog.example/posts/001"></body></html>`,
+		"/no-links": `<html><body><a href="https://blog.example/posts/first-not">another post</a></body></html>`,
+	}
+	var gone atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if gone.Load() {
+			w.WriteHeader(http.StatusGone)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(pages[r.URL.Path]))
+	}))
+	defer server.Close()
+
+	mentions := newFakeWebmentionRepository()
+	service := newWebmentionTestService(t, mentions)
+	ctx := context.Background()
+
+	receive := func(path string, target string) {
+		t.Helper()
+		if err := service.ReceiveWebmention(ctx, server.URL+path, target, domain.ProtocolWebmention); err != nil {
+			t.Fatalf("ReceiveWebmention(%s) error = %v", path, err)
+		}
+		select {
+		case <-mentions.changed:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out verifying the mention from %s", path)
+		}
+	}
+
+	receive("/links", "https://blog.example/posts/first")
+	mention := mentions.get(server.URL+"/links", "001")
+	if mention == nil || mention.Status != domain.WebmentionPending || mention.Title != "A reply" {
+		t.Errorf("Expected a pending mention titled from the page, got %+v", mention)
+	}
+
+	receive("/by-id", "https://blog.example/posts/001")
+	if mentions.get(server.URL+"/by-id", "001") == nil {
+		t.Error("Expected a mention linking the post by ID to be stored")
+	}
+
+	receive("/no-links", "https://blog.example/posts/first")
+	if mention := mentions.get(server.URL+"/no-links", "001"); mention != nil {
+		t.Errorf("Expected a source without a link to be dropped, got %+v", mention)
+	}
+
+	if err := service.ModerateWebmention(ctx, mention.ID, domain.WebmentionApproved); err != nil {
+		t.Fatalf("ModerateWebmention() error = %v", err)
+	}
+	approved, err := service.PostWebmentions(ctx, "001")
+	if err != nil || len(approved) != 1 || approved[0].Source != server.URL+"/links" {
+		t.Errorf("PostWebmentions() = %+v, %v, want the approved mention", approved, err)
+	}
+
+	// The source page was deleted, so its mention goes too when it is sent again
+	gone.Store(true)
+	receive("/links", "https://blog.example/posts/first")
+	if mention := mentions.get(server.URL+"/links", "001"); mention != nil {
+		t.Errorf("Expected a gone source to be dropped, got %+v", mention)
+	}
+}
+
+func TestPostService_ListWebmentions_UnknownStatus(t *testing.T) {
+	service := newWebmentionTestService(t, newFakeWebmentionRepository())
+
+	if _, err := service.ListWebmentions(context.Background(), "spam"); !errors.Is(err, ErrInvalidWebmention) {
+		t.Errorf("ListWebmentions(spam) error = %v, want ErrInvalidWebmention", err)
+	}
+}
+
+func TestIsPublicAddr(t *testing.T) {
+	tests := map[string]bool{
+		"93.184.216.34":        true,
+		"2606:2800:220:1::":    true,
+		"127.0.0.1":            false,
+		"10.1.2.3":             false,
+		"192.168.0.1":          false,
+		"169.254.169.254":      false,
+		"100.64.0.1":           false,
+		"0.0.0.0":              false,
+		"::1":                  false,
+		"fd00::1":              false,
+		"::ffff:127.0.0.1":     false,
+		"::ffff:93.184.216.34": true,
+	}
+	for addr, want := range tests {
+		if got := isPublicAddr(netip.MustParseAddr(addr)); got != want {
+			t.Errorf("isPublicAddr(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+func TestPublicClient_RefusesPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	_, err := newPublicClient(time.Second).Get(server.URL)
+	if !errors.Is(err, errPrivateAddress) {
+		t.Errorf("Get(%s) error = %v, want errPrivateAddress", server.URL, err)
+	}
+}
@@ -0,0 +1,96 @@
+package application
+
+import (
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	defaultSyncWorkers        = 4
+	defaultSyncStartupTimeout = 2 * time.Minute
+	defaultSyncOverlap        = 10 * time.Minute
+)
+
+var syncQueueDepth = promauto.NewGauge(prometheus.GaugeOpts{
+	Name: "goblog_sync_queue_depth",
+	Help: "File tasks running on or waiting for a sync worker.",
+})
+
+type SyncConfig struct {
+	// Workers is the most files fetched and rendered at the same time
+	Workers int
+	// StartupTimeout is how long the initial sync may hold back readiness
+	StartupTimeout time.Duration
+	// Overlap is how far before the last update a sync starts listing commits
+	// Commit dates come from the author's clock, so a margin catches commits dated slightly in the past.
+	Overlap time.Duration
+	// Timestamps is the commit time posts record as their last update
+	Timestamps TimestampSource
+}
+
+func NewSyncConfig() *SyncConfig {
+	workers := defaultSyncWorkers
+	if n, err := strconv.Atoi(os.Getenv("SYNC_WORKERS")); err == nil && n > 0 {
+		workers = n
+	}
+
+	startupTimeout := defaultSyncStartupTimeout
+	if d, err := time.ParseDuration(os.Getenv("SYNC_STARTUP_TIMEOUT")); err == nil && d > 0 {
+		startupTimeout = d
+	}
+
+	overlap := defaultSyncOverlap
+	if d, err := time.ParseDuration(os.Getenv("SYNC_OVERLAP")); err == nil && d >= 0 {
+		overlap = d
+	}
+
+	return &SyncConfig{
+		Workers:        workers,
+		StartupTimeout: startupTimeout,
+		Overlap:        overlap,
+		Timestamps:     parseTimestampSource(os.Getenv("POST_TIMESTAMP_SOURCE")),
+	}
+}
+
+// WithSyncWorkers bounds how many files are fetched and rendered concurrently
+func WithSyncWorkers(cfg *SyncConfig) PostServiceOption {
+	return func(s *PostService) {
+		s.workers = make(chan struct{}, max(cfg.Workers, 1))
+	}
+}
+
+// WithSyncOverlap sets how far before the last update a sync starts listing commits
+func WithSyncOverlap(cfg *SyncConfig) PostServiceOption {
+	return func(s *PostService) {
+		s.syncOverlap = cfg.Overlap
+	}
+}
+
+// acquireWorker blocks until a worker slot is free and returns a function releasing it
+// Slots are not given up on shutdown: cancelled work fails fast and frees its slot quickly.
+func (s *PostService) acquireWorker() func() {
+	syncQueueDepth.Inc()
+	s.workers <- struct{}{}
+	return func() {
+		<-s.workers
+		syncQueueDepth.Dec()
+	}
+}
+
+// forEachBounded runs fn for every item on the service's worker pool and waits for all of them to finish
+func forEachBounded[T any](s *PostService, items []T, fn func(T)) {
+	var wg sync.WaitGroup
+	for _, item := range items {
+		release := s.acquireWorker()
+		wg.Go(func() {
+			defer release()
+			fn(item)
+		})
+	}
+	wg.Wait()
+}
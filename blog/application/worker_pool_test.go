@@ -0,0 +1,92 @@
+package application
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v75/github"
+)
+
+func TestForEachBounded_LimitsConcurrency(t *testing.T) {
+	service := NewPostService(newFakePostRepository(), newFakeImageRepository(), nil, NewMarkdownRenderer(), "main",
+		WithSyncWorkers(&SyncConfig{Workers: 2}),
+	)
+	defer service.Close()
+
+	var running, peak atomic.Int32
+	var mu sync.Mutex
+	var seen []int
+
+	forEachBounded(service, []int{1, 2, 3, 4, 5, 6}, func(n int) {
+		current := running.Add(1)
+		for {
+			p := peak.Load()
+			if current <= p || peak.CompareAndSwap(p, current) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		running.Add(-1)
+
+		mu.Lock()
+		seen = append(seen, n)
+		mu.Unlock()
+	})
+
+	if len(seen) != 6 {
+		t.Errorf("processed %d items, want 6", len(seen))
+	}
+	if peak.Load() > 2 {
+		t.Errorf("peak concurrency = %d, want at most 2", peak.Load())
+	}
+}
+
+func TestPostService_ProcessBranches_MainLast(t *testing.T) {
+	source := newFakeSourceRepository()
+	repo := newFakePostRepository()
+	service := NewPostService(repo, newFakeImageRepository(), source, NewMarkdownRenderer(), "main")
+	defer service.Close()
+
+	// Both branches see the same commit; the last branch processed decides the post state
+	source.commits["a"] = testCommit("a", "posts/001-shared.md")
+	source.files["posts/001-shared.md"] = []byte("# Shared\n\nBody")
+
+	branches := []*github.Branch{{Name: github.Ptr("main")}, {Name: github.Ptr("zz-draft")}}
+	if err := service.processBranches(time.Time{}, branches); err != nil {
+		t.Fatalf("processBranches() error = %v", err)
+	}
+
+	post := repo.posts["001"]
+	if post == nil {
+		t.Fatal("post was not saved")
+	}
+	if post.PublishedAt.IsZero() {
+		t.Error("draft branch processed after main left the post unpublished")
+	}
+}
+
+func TestPostService_StartInitialSync_ReadyAfterSync(t *testing.T) {
+	source := newFakeSourceRepository()
+	repo := newFakePostRepository()
+	service := NewPostService(repo, newFakeImageRepository(), source, NewMarkdownRenderer(), "main")
+	defer service.Close()
+
+	source.branches = []*github.Branch{{Name: github.Ptr("main")}}
+	source.commits["a"] = testCommit("a", "posts/001-startup.md")
+	source.files["posts/001-startup.md"] = []byte("# Startup\n\nBody")
+
+	ready := make(chan struct{})
+	service.StartInitialSync(time.Minute, func() { close(ready) })
+
+	select {
+	case <-ready:
+	case <-time.After(5 * time.Second):
+		t.Fatal("ready was not called after the initial sync")
+	}
+
+	if _, err := repo.GetPost(t.Context(), "001"); err != nil {
+		t.Errorf("post not synced before ready: %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+)
+
+// PostState selects a group of posts that are not live
+type PostState string
+
+const (
+	// PostStateUnpublished is a draft: rendered from a branch but neither published nor scheduled
+	PostStateUnpublished PostState = "unpublished"
+	// PostStateScheduled is merged and waiting for its publish time
+	PostStateScheduled PostState = "scheduled"
+	// PostStateFailed is a post file on the dead-letter list
+	PostStateFailed PostState = "failed"
+)
+
+// ErrInvalidPostState is returned when posts are listed by a state that does not exist
+var ErrInvalidPostState = errors.New("invalid post state")
+
+// WorkingPost is a post the blog knows about but does not serve
+type WorkingPost struct {
+	Post *domain.Post
+	// Failure is set for failed posts; their Post may never have been saved and only carries the ID and source
+	Failure *domain.DeadLetter
+}
+
+// ListWorkingPosts returns the posts in state, so authors can see what happened to their drafts
+func (s *PostService) ListWorkingPosts(ctx context.Context, state PostState) ([]*WorkingPost, error) {
+	var posts []*domain.Post
+	var err error
+	switch state {
+	case PostStateUnpublished:
+		posts, err = s.repo.ListUnpublishedPosts(ctx)
+	case PostStateScheduled:
+		posts, err = s.repo.ListScheduledPosts(ctx)
+	case PostStateFailed:
+		return s.listFailedPosts(ctx)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrInvalidPostState, state)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	working := make([]*WorkingPost, 0, len(posts))
+	for _, p := range posts {
+		working = append(working, &WorkingPost{Post: p})
+	}
+	return working, nil
+}
+
+// listFailedPosts returns the post files on the dead-letter list, most recently failed first
+func (s *PostService) listFailedPosts(ctx context.Context) ([]*WorkingPost, error) {
+	letters, err := s.ListDeadLetters(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	working := make([]*WorkingPost, 0)
+	for _, dl := range letters {
+		postID := s.postID(dl.Path)
+		if !s.isPostFile(dl.Path) {
+			continue
+		}
+
+		post, err := s.repo.GetPost(ctx, postID)
+		if errors.Is(err, domain.ErrPostNotFound) {
+			post = &domain.Post{ID: postID, SourcePath: dl.Path, CommitSHA: dl.Ref}
+		} else if err != nil {
+			return nil, err
+		}
+
+		working = append(working, &WorkingPost{Post: post, Failure: dl})
+	}
+	return working, nil
+}
@@ -0,0 +1,74 @@
+package application
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+)
+
+func TestPostService_ListWorkingPosts(t *testing.T) {
+	now := time.Now()
+	repo := newFakePostRepository(
+		&domain.Post{ID: "001", Title: "Draft", Branch: "feature", CommitSHA: "abc", UpdatedAt: now},
+		&domain.Post{ID: "002", Title: "Scheduled", Branch: "main", PublishAt: now.Add(time.Hour)},
+		&domain.Post{ID: "003", Title: "Live", Branch: "main", PublishedAt: now},
+	)
+	deadLetters := newFakeDeadLetterRepository(
+		&domain.DeadLetter{Path: "posts/001-draft.md", Ref: "def", Error: "render failed"},
+		&domain.DeadLetter{Path: "posts/004-new.md", Ref: "ghi", Error: "fetch failed"},
+		&domain.DeadLetter{Path: "images/cat.png", Ref: "jkl", Error: "too large"},
+	)
+
+	service := NewPostService(repo, newFakeImageRepository(), newFakeSourceRepository(), NewMarkdownRenderer(), "main",
+		WithDeadLetters(deadLetters),
+	)
+	defer service.Close()
+
+	tests := []struct {
+		state PostState
+		want  []string
+	}{
+		{PostStateUnpublished, []string{"001"}},
+		{PostStateScheduled, []string{"002"}},
+		{PostStateFailed, []string{"001", "004"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.state), func(t *testing.T) {
+			posts, err := service.ListWorkingPosts(t.Context(), tt.state)
+			if err != nil {
+				t.Fatalf("ListWorkingPosts() error = %v", err)
+			}
+
+			var ids []string
+			for _, wp := range posts {
+				ids = append(ids, wp.Post.ID)
+				if (tt.state == PostStateFailed) != (wp.Failure != nil) {
+					t.Errorf("post %s has failure %v", wp.Post.ID, wp.Failure)
+				}
+			}
+			if len(ids) != len(tt.want) {
+				t.Fatalf("ids = %v, want %v", ids, tt.want)
+			}
+			for i := range ids {
+				if ids[i] != tt.want[i] {
+					t.Errorf("ids = %v, want %v", ids, tt.want)
+				}
+			}
+		})
+	}
+
+	t.Run("failed post never saved", func(t *testing.T) {
+		posts, _ := service.ListWorkingPosts(t.Context(), PostStateFailed)
+		unsaved := posts[1].Post
+		if unsaved.SourcePath != "posts/004-new.md" || unsaved.CommitSHA != "ghi" {
+			t.Errorf("Unexpected provenance for unsaved post: %+v", unsaved)
+		}
+	})
+
+	if _, err := service.ListWorkingPosts(t.Context(), "live"); !errors.Is(err, ErrInvalidPostState) {
+		t.Errorf("Expected ErrInvalidPostState, got %v", err)
+	}
+}
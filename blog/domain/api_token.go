@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+)
+
+// ErrAPITokenNotFound is returned when a token does not exist or has been revoked
+var ErrAPITokenNotFound = errors.New("api token not found")
+
+// Scope is a permission granted to an API token
+type Scope string
+
+const (
+	// ScopeRead allows reading non-public data
+	ScopeRead Scope = "read"
+	// ScopeAdmin allows every operation, including changing posts and managing tokens
+	ScopeAdmin Scope = "admin"
+)
+
+// APIToken is a bearer credential for the API
+// Only a hash of the token is stored; the token itself is shown once, when it is created.
+type APIToken struct {
+	ID         int64
+	Name       string
+	Scopes     []Scope
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	RevokedAt  time.Time
+}
+
+// HasScope reports whether the token grants scope
+// The admin scope implies every other scope.
+func (t *APIToken) HasScope(scope Scope) bool {
+	return slices.Contains(t.Scopes, scope) || slices.Contains(t.Scopes, ScopeAdmin)
+}
+
+type APITokenRepository interface {
+	// CreateToken stores a new token under the hash of its secret and assigns its ID
+	CreateToken(ctx context.Context, token *APIToken, hash string) error
+
+	// GetTokenByHash returns the active token with the given hash, or ErrAPITokenNotFound
+	GetTokenByHash(ctx context.Context, hash string) (*APIToken, error)
+
+	// ListTokens returns every token, including revoked ones, newest first
+	ListTokens(ctx context.Context) ([]*APIToken, error)
+
+	// RevokeToken stops a token from authenticating, returning ErrAPITokenNotFound if it is not active
+	RevokeToken(ctx context.Context, id int64) error
+
+	// TouchToken records that a token was just used
+	TouchToken(ctx context.Context, id int64) error
+}
@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrDeadLetterNotFound is returned when a file is not on the dead-letter list
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+// DeadLetter is a repository file that failed to process even after retries
+// It stays on the list until the file is processed successfully.
+type DeadLetter struct {
+	Path string
+	// Ref is the commit SHA or branch the file was last fetched at
+	Ref      string
+	Error    string
+	Attempts int
+	// RefAttempts counts the failures at Ref; it starts over when another version of the file fails
+	RefAttempts   int
+	FirstFailedAt time.Time
+	LastFailedAt  time.Time
+	// PoisonedAt is when the file was quarantined for failing too often at Ref, or zero if it is not
+	PoisonedAt time.Time
+}
+
+type DeadLetterRepository interface {
+	// RecordFailure adds a file to the dead-letter list, or updates it if it is already there
+	RecordFailure(ctx context.Context, path string, ref string, fileErr error) error
+
+	// Resolve removes a file from the dead-letter list
+	Resolve(ctx context.Context, path string) error
+
+	// GetDeadLetter returns ErrDeadLetterNotFound if the file is not on the list
+	GetDeadLetter(ctx context.Context, path string) (*DeadLetter, error)
+
+	// Poison quarantines a file at its current ref; a failure at another ref lifts the quarantine
+	Poison(ctx context.Context, path string) error
+
+	// Release lifts a file's quarantine and restarts its count of failures at its ref
+	// It returns ErrDeadLetterNotFound if the file is not on the list.
+	Release(ctx context.Context, path string) error
+
+	// ListDeadLetters returns every dead-lettered file, most recently failed first
+	ListDeadLetters(ctx context.Context) ([]*DeadLetter, error)
+}
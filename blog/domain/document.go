@@ -0,0 +1,36 @@
+package domain
+
+// Document is the structure of a rendered post, for frontends that lay posts out themselves instead of using its HTML
+type Document struct {
+	Blocks []*DocumentNode
+}
+
+// DocumentNode is a block or inline element of a rendered post
+// Type names the markdown element in snake case, such as heading, paragraph, fenced_code_block, text or link;
+// the other fields are only set for the elements they describe.
+type DocumentNode struct {
+	Type string
+	// Text is the content of leaf nodes: text, code, math, diagrams, raw HTML, and the alt text of images
+	Text string
+	// LineBreak is set on text followed by a line break
+	LineBreak bool
+	// Level is the level of a heading, or 1 for emphasis and 2 for strong emphasis
+	Level int
+	// ID is the anchor of a heading
+	ID string
+	// Language is the info string language of a fenced code block
+	Language string
+	// URL is the destination of a link or image, as it appears in the post's HTML
+	URL   string
+	Title string
+	// Ordered and Start describe a list
+	Ordered bool
+	Start   int
+	// Checked is set on a ticked task list checkbox
+	Checked bool
+	// Align is the alignment of a table cell: left, right or center
+	Align string
+	// Display is set on math shown as its own block
+	Display  bool
+	Children []*DocumentNode
+}
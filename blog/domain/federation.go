@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Follower is an ActivityPub actor, such as a Mastodon account, following the blog
+type Follower struct {
+	// ActorID is the URL of the actor
+	ActorID string
+	// Inbox is where activities for the actor are delivered
+	Inbox string
+	// SharedInbox receives activities for every actor on the follower's server, or is empty if it has none
+	SharedInbox string
+	FollowedAt  time.Time
+}
+
+type FederationRepository interface {
+	// SaveFollower records a follower, or updates the inboxes of one following already
+	SaveFollower(ctx context.Context, follower *Follower) error
+
+	// DeleteFollower removes a follower, if actorID is following
+	DeleteFollower(ctx context.Context, actorID string) error
+
+	// ListFollowers returns every follower, in the order they followed
+	ListFollowers(ctx context.Context) ([]*Follower, error)
+
+	// MarkAnnounced records that a post was announced to followers, returning false if it already had been
+	MarkAnnounced(ctx context.Context, postID string, at time.Time) (bool, error)
+
+	// ForgetAnnounced forgets that a post was announced, returning false if it hadn't been
+	ForgetAnnounced(ctx context.Context, postID string) (bool, error)
+}
@@ -2,9 +2,16 @@ package domain
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
+// ErrImageNotFound is returned when a requested image does not exist
+var ErrImageNotFound = errors.New("image not found")
+
+// ErrImageContentMismatch is returned when an image file's content is not the format its extension names
+var ErrImageContentMismatch = errors.New("image content does not match its extension")
+
 // Image represents an image file stored from the repository
 type Image struct {
 	Path      string
@@ -12,15 +19,41 @@ type Image struct {
 	Content   []byte
 	UpdatedAt time.Time
 	CreatedAt time.Time
+	// OrphanedAt is when the image was first found to be unreferenced by any post
+	// It is zero while at least one post references the image
+	OrphanedAt time.Time
+	// PerceptualHash fingerprints what the image looks like, so resized or re-encoded copies hash alike
+	// It is empty for formats that can't be decoded, such as SVG
+	PerceptualHash string
 }
 
 type ImageRepository interface {
 	// SaveImage saves an image to both filesystem and database
 	SaveImage(ctx context.Context, img *Image) error
-	
+
 	// GetImage retrieves an image record from the database
 	GetImage(ctx context.Context, path string) (*Image, error)
-	
+
+	// GetImageByHash retrieves an image record by the hash of its content
+	GetImageByHash(ctx context.Context, hash string) (*Image, error)
+
+	// GetImageContent reads the stored bytes of an image
+	GetImageContent(ctx context.Context, path string) ([]byte, error)
+
 	// DeleteImage removes an image from both filesystem and database
 	DeleteImage(ctx context.Context, path string) error
+
+	// FlagOrphanedImages marks images no post references as orphaned at the given time,
+	// and clears the flag on previously orphaned images that are referenced again
+	FlagOrphanedImages(ctx context.Context, at time.Time) error
+
+	// CountPostsWithoutImageRefs counts the posts saved before their image references were recorded,
+	// which must be rendered again before any image can be known to be orphaned
+	CountPostsWithoutImageRefs(ctx context.Context) (int, error)
+
+	// ListOrphanedImages returns every image currently flagged as orphaned, oldest first
+	ListOrphanedImages(ctx context.Context) ([]*Image, error)
+
+	// ListImages returns every stored image, without content, ordered by path
+	ListImages(ctx context.Context) ([]*Image, error)
 }
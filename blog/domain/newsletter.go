@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrSubscriberNotFound is returned when no subscriber has the given email address or token
+var ErrSubscriberNotFound = errors.New("subscriber not found")
+
+// Subscriber is a reader who asked to be emailed new posts
+type Subscriber struct {
+	// Email is the subscriber's address, in lower case
+	Email string
+	// Token is the secret in the subscriber's confirmation and unsubscribe links
+	Token string
+	// SubscribedAt is when the subscription was last requested
+	SubscribedAt time.Time
+	// ConfirmedAt is when the subscriber followed the confirmation link, or zero until they do
+	ConfirmedAt time.Time
+}
+
+type NewsletterRepository interface {
+	// SaveSubscriber records a subscription request, replacing the token of one still waiting for confirmation
+	// A confirmed subscriber is left unchanged.
+	SaveSubscriber(ctx context.Context, subscriber *Subscriber) error
+
+	// GetSubscriber returns the subscriber with an email address, or ErrSubscriberNotFound
+	GetSubscriber(ctx context.Context, email string) (*Subscriber, error)
+
+	// GetSubscriberByToken returns the subscriber with a token, or ErrSubscriberNotFound
+	GetSubscriberByToken(ctx context.Context, token string) (*Subscriber, error)
+
+	// ConfirmSubscriber records that a subscriber confirmed their address, or returns ErrSubscriberNotFound
+	ConfirmSubscriber(ctx context.Context, email string, at time.Time) error
+
+	// DeleteSubscriber removes a subscriber, if there is one with the email address
+	DeleteSubscriber(ctx context.Context, email string) error
+
+	// ListConfirmedSubscribers returns every confirmed subscriber, in the order they confirmed
+	ListConfirmedSubscribers(ctx context.Context) ([]*Subscriber, error)
+
+	// MarkMailed records that a post was emailed to subscribers, returning false if it already had been
+	MarkMailed(ctx context.Context, postID string, at time.Time) (bool, error)
+}
@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// PendingRender is a post or image render, or removal, that has been queued but has not finished
+// Renders still pending when the server starts were interrupted, e.g. by a crash, and are run again.
+type PendingRender struct {
+	Path      string
+	CommitSHA string
+	// Action is SyncActionRemove when the file is being removed, and SyncActionUpsert when it is rendered
+	Action SyncAction
+	// Branch is the branch a post is rendered from; it is empty for images
+	Branch string
+	// BlobSHA is the git blob SHA of the file version, or empty when it is not known
+	BlobSHA    string
+	CreatedAt  time.Time
+	ModifiedAt time.Time
+	// Released is set when the render publishes a post for a tag or release
+	Released bool
+	QueuedAt time.Time
+}
+
+type PendingRenderRepository interface {
+	// SavePendingRenders records renders before they start, replacing any pending render of the same file on the same branch
+	SavePendingRenders(ctx context.Context, renders []*PendingRender) error
+
+	// ClearPendingRender forgets a finished render, unless a render of another commit has been queued for the file since
+	ClearPendingRender(ctx context.Context, branch string, path string, commitSHA string) error
+
+	// ListPendingRenders returns every pending render, oldest first
+	ListPendingRenders(ctx context.Context) ([]*PendingRender, error)
+}
@@ -2,31 +2,154 @@ package domain
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
+// ErrPostNotFound is returned when a requested post does not exist
+var ErrPostNotFound = errors.New("post not found")
+
+// ErrPostIDCollision is returned when two source files derive the same post ID
+var ErrPostIDCollision = errors.New("post ID collision")
+
+// ErrInvalidPath is returned when a post or image path could escape its storage directory
+var ErrInvalidPath = errors.New("invalid path")
+
 // Post represents a blog post
 // A post is created from a Markdown file, and the resulting HTML is stored at HTMLPath.
 // Posts become published when they are merged to main.
 type Post struct {
-	ID          string
+	ID string
+	// Slug names the post in its URL, derived from its file name, or is empty when the post is only addressed by ID
+	Slug        string
 	Title       string
 	Snippet     string
 	HTMLPath    string
 	HTMLContent []byte
+	// SourcePath is the repository path of the markdown the post was rendered from
+	SourcePath string
+	// Branch is the branch the post was last rendered from
+	Branch string
+	// CommitSHA is the commit the post was last rendered from
+	CommitSHA string
+	// Images holds the repository paths of the images the post references
+	Images      []string
 	UpdatedAt   time.Time
 	PublishedAt time.Time
 	CreatedAt   time.Time
+	// PublishAt is when a merged post is scheduled to go live; zero publishes on merge
+	PublishAt time.Time
+	// WordCount and ReadingMinutes describe the length of the post's prose
+	WordCount      int
+	ReadingMinutes int
+	// TOC is the post's table of contents, or nil when the post is too short to need one
+	TOC []*Heading
+	// Signature is the base64 ed25519 signature of HTMLContent, or empty when content signing is disabled
+	Signature string
+	// Language is the post's language tag from its front matter, or empty to use the site language
+	Language string
+	// Document is the structure of the rendered post, or nil when documents are not stored
+	// It is only loaded by GetPostDocument.
+	Document *Document
+}
+
+// URLPath is the path of the post's page, by slug when it has one
+func (p *Post) URLPath() string {
+	if p.Slug != "" {
+		return "/posts/" + p.Slug
+	}
+	return "/posts/" + p.ID
+}
+
+// Heading is an entry in a post's table of contents
+type Heading struct {
+	Level int
+	// ID is the anchor of the heading in the rendered HTML
+	ID       string
+	Text     string
+	Children []*Heading
+}
+
+// PostMetadata describes a post for link previews, in the terms used by Open Graph and Twitter Cards
+type PostMetadata struct {
+	Title       string
+	Description string
+	// URL is the canonical address of the post page
+	URL string
+	// Image is an absolute image URL, or empty when the post has none and no default is configured
+	Image string
+	// TwitterCard is the card type: summary_large_image when there is an image, summary otherwise
+	TwitterCard string
+}
+
+// PostCursor marks a position in the listing of published posts, which is ordered by publication time and then ID
+type PostCursor struct {
+	PublishedAt time.Time
+	ID          string
+}
+
+// PublishedPostPage is a page of published posts, newest first
+type PublishedPostPage struct {
+	Posts []*Post
+	// Total counts every published post, not just those on the page
+	Total int
+	// Next continues the listing after the page, or is nil on the last page
+	Next *PostCursor
+}
+
+// ArchiveMonth counts the posts published in a calendar month
+type ArchiveMonth struct {
+	Year  int
+	Month time.Month
+	Posts int
 }
 
 type PostRepository interface {
 	// SavePost saves a post to both filesystem and database
 	SavePost(ctx context.Context, p *Post) error
-	
+
 	GetPost(ctx context.Context, id string) (*Post, error)
+	// GetPostBySlug retrieves the post with the given slug, or ErrPostNotFound
+	GetPostBySlug(ctx context.Context, slug string) (*Post, error)
+	// GetPostHTML retrieves the rendered HTML for a post
+	GetPostHTML(ctx context.Context, id string) ([]byte, error)
+	// GetPostDocument retrieves the structured document of a post, or nil when none was stored
+	GetPostDocument(ctx context.Context, id string) (*Document, error)
 	GetLatestUpdatedTime(ctx context.Context) (time.Time, error)
 	ListPublishedPosts(ctx context.Context, limit int, offset int) ([]*Post, error)
+	// ListPublishedPostsPage returns up to limit published posts after the cursor, or from the newest when it is nil
+	// Unlike offsets, a cursor keeps its place when posts are published while a client pages through.
+	ListPublishedPostsPage(ctx context.Context, limit int, after *PostCursor) (*PublishedPostPage, error)
+	// ListPublishedPostsBetween returns the posts published at or after start and before end, newest first
+	ListPublishedPostsBetween(ctx context.Context, start time.Time, end time.Time) ([]*Post, error)
+	// ListPublishTimes returns when each published post was published, newest first
+	ListPublishTimes(ctx context.Context) ([]time.Time, error)
+	// ListDuePosts returns unpublished posts whose scheduled publish time is at or before now
+	ListDuePosts(ctx context.Context, now time.Time) ([]*Post, error)
+	// ListPostsByImage returns the posts that reference the image at the given repository path
+	ListPostsByImage(ctx context.Context, imagePath string) ([]*Post, error)
+	// ListUnpublishedPosts returns posts that are neither published nor scheduled, least recently updated first
+	ListUnpublishedPosts(ctx context.Context) ([]*Post, error)
+	// ListScheduledPosts returns unpublished posts with a scheduled publish time, soonest first
+	ListScheduledPosts(ctx context.Context) ([]*Post, error)
+	// ListPosts returns every post, whatever its state, ordered by ID
+	ListPosts(ctx context.Context) ([]*Post, error)
 
 	Publish(ctx context.Context, postID string) error
 	Unpublish(ctx context.Context, postID string) error
+	// DeletePost removes a post and its rendered HTML, returning ErrPostNotFound if it does not exist
+	DeletePost(ctx context.Context, postID string) error
+}
+
+// ShadowPostRepository keeps posts in a shadow namespace, where the whole repository can be rendered out of sight
+// and then swapped in for the live posts at once
+type ShadowPostRepository interface {
+	PostRepository
+
+	// Reset deletes every post in the shadow namespace
+	Reset(ctx context.Context) error
+	// Promote replaces the live posts with the shadow namespace's in a single transaction, leaving it empty,
+	// and returns how many posts are live afterwards
+	// Published posts whose ID was already published keep their publication time.
+	Promote(ctx context.Context) (int, error)
 }
@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// DiagnosticKind classifies a problem found in a rendered post
+type DiagnosticKind string
+
+const (
+	// DiagnosticBrokenLink is a link to a post that does not exist, or that readers can't see
+	DiagnosticBrokenLink DiagnosticKind = "broken_link"
+	// DiagnosticMissingImage is an image that is not stored
+	DiagnosticMissingImage DiagnosticKind = "missing_image"
+)
+
+// PostDiagnostic is a broken reference found in the rendered HTML of a post
+type PostDiagnostic struct {
+	PostID string
+	Kind   DiagnosticKind
+	// Target is the URL as it appears in the post
+	Target    string
+	Message   string
+	CheckedAt time.Time
+}
+
+type PostDiagnosticRepository interface {
+	// ReplaceDiagnostics replaces every diagnostic of a post, clearing them when diagnostics is empty
+	ReplaceDiagnostics(ctx context.Context, postID string, diagnostics []*PostDiagnostic) error
+
+	// ListDiagnostics returns the diagnostics of every post, ordered by post
+	ListDiagnostics(ctx context.Context) ([]*PostDiagnostic, error)
+}
@@ -0,0 +1,22 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// PostViews is how many times a post was viewed over some days
+type PostViews struct {
+	PostID string
+	Views  int
+}
+
+// PostViewRepository stores the number of views of each post per day
+type PostViewRepository interface {
+	// AddPostViews adds views to the counts of day, keyed by post ID
+	// Views of posts that no longer exist are dropped.
+	AddPostViews(ctx context.Context, day time.Time, views map[string]int) error
+
+	// ListPopularPosts returns the published posts with the most views on days from since onwards, most viewed first
+	ListPopularPosts(ctx context.Context, since time.Time, limit int) ([]*PostViews, error)
+}
@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrReaderPreferencesNotFound is returned when no preferences are stored for a token, or they have expired
+var ErrReaderPreferencesNotFound = errors.New("reader preferences not found")
+
+// ReaderPreferences are the display settings an anonymous reader chose
+// They are stored under the hash of a random token kept by the reader, so they can follow the reader
+// to another device without an account.
+type ReaderPreferences struct {
+	Theme     string
+	FontSize  string
+	UpdatedAt time.Time
+	// LastSeenAt is when the preferences were last read or saved; they expire a while after it
+	LastSeenAt time.Time
+}
+
+type ReaderPreferencesRepository interface {
+	// GetPreferences returns the preferences stored under hash and marks them as seen
+	// Preferences last seen before cutoff have expired, and are ErrReaderPreferencesNotFound like missing ones.
+	GetPreferences(ctx context.Context, hash string, cutoff time.Time) (*ReaderPreferences, error)
+
+	// SavePreferences creates or replaces the preferences stored under hash
+	SavePreferences(ctx context.Context, hash string, prefs *ReaderPreferences) error
+
+	// DeletePreferences removes the preferences stored under hash, returning ErrReaderPreferencesNotFound if there are none
+	DeletePreferences(ctx context.Context, hash string) error
+
+	// DeleteUnseenPreferences removes preferences last seen before cutoff and returns how many were removed
+	DeleteUnseenPreferences(ctx context.Context, cutoff time.Time) (int, error)
+}
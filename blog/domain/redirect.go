@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrRedirectNotFound is returned when no redirect is recorded from a slug
+var ErrRedirectNotFound = errors.New("redirect not found")
+
+// Redirect sends readers of a slug a post no longer has to the post
+// It points at the post rather than its new slug, so a post renamed again needs no new redirect for its older slugs.
+type Redirect struct {
+	FromSlug  string
+	PostID    string
+	CreatedAt time.Time
+}
+
+type RedirectRepository interface {
+	// SaveRedirect records a redirect, replacing any other from the same slug
+	SaveRedirect(ctx context.Context, redirect *Redirect) error
+
+	// GetRedirect returns the redirect from slug, or ErrRedirectNotFound
+	GetRedirect(ctx context.Context, slug string) (*Redirect, error)
+
+	// ListRedirects returns every redirect, newest first
+	ListRedirects(ctx context.Context) ([]*Redirect, error)
+
+	// DeleteRedirect removes the redirect from slug, returning ErrRedirectNotFound if there is none
+	DeleteRedirect(ctx context.Context, slug string) error
+}
@@ -2,19 +2,147 @@ package domain
 
 import (
 	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/go-github/v75/github"
 )
 
+// ErrSourceCacheMiss is returned when the source cache holds no entry for a lookup
+var ErrSourceCacheMiss = errors.New("source cache miss")
+
+// ErrSourceFileNotFound is returned when a file does not exist at the requested ref
+var ErrSourceFileNotFound = errors.New("source file not found")
+
 // SourceRepository defines the interface for accessing repository data (e.g., from GitHub).
 // This allows the application to be decoupled from a specific implementation.
 type SourceRepository interface {
 	GetCommitsSince(ctx context.Context, branchName string, since time.Time) ([]*github.RepositoryCommit, error)
 	GetCommitsInRange(ctx context.Context, baseCommit string, headCommit string) ([]*github.RepositoryCommit, error)
+	// CompareCommits returns the commits between baseCommit and headCommit along with the net file changes
+	CompareCommits(ctx context.Context, baseCommit string, headCommit string) (*github.CommitsComparison, error)
 	GetCommit(ctx context.Context, sha string) (*github.RepositoryCommit, error)
+	// GetTree returns every file in the tree of a commit, including those in subdirectories
+	GetTree(ctx context.Context, sha string) (*github.Tree, error)
 	GetFileContents(ctx context.Context, path string, ref string) ([]byte, error)
 	ListBranches(ctx context.Context) ([]*github.Branch, error)
 	GetDefaultBranchName(ctx context.Context) (string, error)
 	GetRepoFullName() string
 }
+
+// PullRequestCommenter writes feedback to pull requests on the source repository.
+type PullRequestCommenter interface {
+	// UpsertComment edits the pull request comment containing marker to body, or creates one if there is none
+	UpsertComment(ctx context.Context, number int, marker string, body string) error
+}
+
+// CheckConclusion is the outcome of a check run
+type CheckConclusion string
+
+const (
+	CheckSuccess CheckConclusion = "success"
+	// CheckNeutral passes with warnings
+	CheckNeutral CheckConclusion = "neutral"
+	CheckFailure CheckConclusion = "failure"
+)
+
+// CheckAnnotationLevel is how serious an annotated problem is
+type CheckAnnotationLevel string
+
+const (
+	CheckAnnotationWarning CheckAnnotationLevel = "warning"
+	CheckAnnotationFailure CheckAnnotationLevel = "failure"
+)
+
+// CheckAnnotation is a problem found on a line of a file
+type CheckAnnotation struct {
+	Path    string
+	Line    int
+	Level   CheckAnnotationLevel
+	Message string
+}
+
+// CheckRun is the completed result of checking a commit
+type CheckRun struct {
+	Name        string
+	HeadSHA     string
+	Conclusion  CheckConclusion
+	Title       string
+	Summary     string
+	Annotations []CheckAnnotation
+}
+
+// CheckReporter reports the results of checks on commits of the source repository
+type CheckReporter interface {
+	// ReportCheck records a completed check run on its head commit
+	ReportCheck(ctx context.Context, run *CheckRun) error
+}
+
+// SourceCache persists source data so repeated syncs and re-renders can skip the upstream repository.
+// Commits, trees and comparisons are keyed by commit SHA; files are keyed by the ref they were fetched at,
+// and can also be found by their blob SHA.
+type SourceCache interface {
+	// GetCommit returns a cached commit, or ErrSourceCacheMiss
+	GetCommit(ctx context.Context, sha string) (*github.RepositoryCommit, error)
+
+	// SaveCommit caches a commit, including its changed files
+	SaveCommit(ctx context.Context, commit *github.RepositoryCommit) error
+
+	// GetFile returns a file's contents cached at ref, or ErrSourceCacheMiss
+	GetFile(ctx context.Context, path string, ref string) ([]byte, error)
+
+	// SaveFile caches a file's contents at ref, replacing anything cached for the same path and ref
+	SaveFile(ctx context.Context, path string, ref string, content []byte) error
+
+	// GetBlob returns the contents of any cached file whose git blob SHA is blobSHA, or ErrSourceCacheMiss
+	GetBlob(ctx context.Context, blobSHA string) ([]byte, error)
+
+	// GetTree returns the cached file tree of a commit, or ErrSourceCacheMiss
+	GetTree(ctx context.Context, sha string) (*github.Tree, error)
+
+	// SaveTree caches the file tree of a commit
+	SaveTree(ctx context.Context, sha string, tree *github.Tree) error
+
+	// GetComparison returns a cached comparison between two commits, or ErrSourceCacheMiss
+	GetComparison(ctx context.Context, base string, head string) (*github.CommitsComparison, error)
+
+	// SaveComparison caches the comparison between two commits
+	SaveComparison(ctx context.Context, base string, head string, comparison *github.CommitsComparison) error
+}
+
+// GitBlobSHA returns the SHA-1 git names a file's contents by, as listed in trees and commits
+func GitBlobSHA(content []byte) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "blob %d\x00", len(content))
+	h.Write(content)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ProcessedCommitRepository remembers which commits have been synced on each branch.
+// Sync windows overlap to tolerate clock skew, so commits seen by an earlier sync can be skipped.
+type ProcessedCommitRepository interface {
+	// ListProcessed returns the SHAs among shas that have already been processed on branch
+	ListProcessed(ctx context.Context, branch string, shas []string) ([]string, error)
+
+	// MarkProcessed records that the commits have been processed on branch
+	MarkProcessed(ctx context.Context, branch string, shas []string) error
+
+	// PruneProcessed forgets commits processed before the given time
+	PruneProcessed(ctx context.Context, before time.Time) error
+}
+
+// WebhookDeliveryRepository remembers the GitHub delivery IDs of webhooks that have been handled.
+// GitHub may deliver the same webhook more than once, and redeliveries carry the original ID.
+type WebhookDeliveryRepository interface {
+	// ClaimDelivery records a delivery ID, returning false if it had already been recorded
+	ClaimDelivery(ctx context.Context, id string) (bool, error)
+
+	// ReleaseDelivery forgets a delivery ID, so a failed delivery can be handled again when redelivered
+	ReleaseDelivery(ctx context.Context, id string) error
+
+	// PruneDeliveries forgets deliveries received before the given time
+	PruneDeliveries(ctx context.Context, before time.Time) error
+}
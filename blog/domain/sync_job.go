@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrSyncJobNotFound is returned when a requested sync job does not exist
+var ErrSyncJobNotFound = errors.New("sync job not found")
+
+// SyncStatus is the processing state of a sync job or one of its files
+type SyncStatus string
+
+const (
+	SyncStatusPending   SyncStatus = "pending"
+	SyncStatusSucceeded SyncStatus = "succeeded"
+	SyncStatusFailed    SyncStatus = "failed"
+)
+
+// SyncAction is what a sync job does with a file
+type SyncAction string
+
+const (
+	SyncActionUpsert SyncAction = "upsert"
+	SyncActionRemove SyncAction = "remove"
+)
+
+// SyncJob records the processing of a single push event
+// The job succeeds once every file has been processed successfully,
+// and fails if the push could not be analyzed or any file failed.
+type SyncJob struct {
+	ID         int64
+	Ref        string
+	Before     string
+	After      string
+	Status     SyncStatus
+	Error      string
+	Files      []*SyncJobFile
+	CreatedAt  time.Time
+	FinishedAt time.Time
+}
+
+// SyncJobFile is the processing state of one file within a sync job
+type SyncJobFile struct {
+	Path      string
+	CommitSHA string
+	Action    SyncAction
+	Status    SyncStatus
+	Error     string
+	UpdatedAt time.Time
+}
+
+type SyncJobRepository interface {
+	// CreateJob records a new pending job and sets its ID
+	CreateJob(ctx context.Context, job *SyncJob) error
+
+	// AddFiles records the files a job will process, all initially pending
+	AddFiles(ctx context.Context, jobID int64, files []*SyncJobFile) error
+
+	// CompleteFile records the outcome of processing a file, finishing the job once no files are pending
+	CompleteFile(ctx context.Context, jobID int64, path string, fileErr error) error
+
+	// FailJob marks a job as failed, e.g. when its push could not be analyzed
+	FailJob(ctx context.Context, jobID int64, jobErr error) error
+
+	// GetJob retrieves a job along with its files
+	GetJob(ctx context.Context, id int64) (*SyncJob, error)
+
+	// ListJobs returns jobs newest first, without their files
+	ListJobs(ctx context.Context, limit int, offset int) ([]*SyncJob, error)
+}
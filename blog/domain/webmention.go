@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrWebmentionNotFound is returned when a requested webmention does not exist
+var ErrWebmentionNotFound = errors.New("webmention not found")
+
+// WebmentionStatus is where a mention is in moderation
+type WebmentionStatus string
+
+const (
+	// WebmentionPending mentions are verified but not yet shown
+	WebmentionPending  WebmentionStatus = "pending"
+	WebmentionApproved WebmentionStatus = "approved"
+	WebmentionRejected WebmentionStatus = "rejected"
+)
+
+// WebmentionProtocol is how a mention was sent
+type WebmentionProtocol string
+
+const (
+	ProtocolWebmention WebmentionProtocol = "webmention"
+	ProtocolPingback   WebmentionProtocol = "pingback"
+)
+
+// Webmention is a page on another site that links to a post, verified by fetching it
+type Webmention struct {
+	ID     int64
+	Source string
+	// Target is the URL of the post the source links to, as the sender gave it
+	Target   string
+	PostID   string
+	Protocol WebmentionProtocol
+	// Title is the title of the source page, or empty when it has none
+	Title  string
+	Status WebmentionStatus
+	// ReceivedAt is when the mention was first verified; VerifiedAt is when it was last verified
+	ReceivedAt time.Time
+	VerifiedAt time.Time
+}
+
+type WebmentionRepository interface {
+	// SaveWebmention records a verified mention, or updates the one from the same source to the same post
+	// An updated mention keeps its status and the time it was received. The mention's ID is set.
+	SaveWebmention(ctx context.Context, mention *Webmention) error
+
+	// DeleteWebmention removes the mention from source to a post, if there is one
+	DeleteWebmention(ctx context.Context, source string, postID string) error
+
+	// ListWebmentions returns the mentions with status, or every mention when status is empty, newest first
+	ListWebmentions(ctx context.Context, status WebmentionStatus) ([]*Webmention, error)
+
+	// ListPostWebmentions returns the mentions of a post with status, oldest first
+	ListPostWebmentions(ctx context.Context, postID string, status WebmentionStatus) ([]*Webmention, error)
+
+	// SetWebmentionStatus moderates a mention, returning ErrWebmentionNotFound if there is none with id
+	SetWebmentionStatus(ctx context.Context, id int64, status WebmentionStatus) error
+}
@@ -0,0 +1,851 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/application"
+	"github.com/dfryer1193/goblog/blog/domain"
+	sourcegithub "github.com/dfryer1193/goblog/shared/github"
+	"github.com/dfryer1193/goblog/shared/metrics"
+	"github.com/dfryer1193/mjolnir/utils/errorx"
+	"github.com/dfryer1193/mjolnir/utils/httpx"
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// AdminHandler serves the operator-facing JSON API under /admin
+// Every request must carry an API token with the admin scope.
+type AdminHandler struct {
+	postService *application.PostService
+	imageGC     *application.ImageGarbageCollector
+	diskUsage   *application.DiskUsageMonitor
+	auth        *application.AuthService
+	// webhooks is nil unless the webhook is registered at startup
+	webhooks *sourcegithub.WebhookRegistrar
+}
+
+func NewAdminHandler(postService *application.PostService, imageGC *application.ImageGarbageCollector, diskUsage *application.DiskUsageMonitor, auth *application.AuthService, webhooks *sourcegithub.WebhookRegistrar) *AdminHandler {
+	return &AdminHandler{
+		postService: postService,
+		imageGC:     imageGC,
+		diskUsage:   diskUsage,
+		auth:        auth,
+		webhooks:    webhooks,
+	}
+}
+
+func (h *AdminHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/admin", func(r chi.Router) {
+		r.Use(RequireScope(h.auth, domain.ScopeAdmin))
+
+		r.Get("/status", errorx.ErrorHandler(h.HandleStatus))
+		r.Get("/metrics.json", errorx.ErrorHandler(h.HandleMetrics))
+		r.Get("/webhook/status", errorx.ErrorHandler(h.HandleWebhookStatus))
+		r.Get("/images/orphans", errorx.ErrorHandler(h.HandleListOrphanedImages))
+		r.Get("/images/similar", errorx.ErrorHandler(h.HandleListSimilarImages))
+		r.Get("/dead-letters", errorx.ErrorHandler(h.HandleListDeadLetters))
+		r.Post("/dead-letters/release", errorx.ErrorHandler(h.HandleReleaseDeadLetter))
+		r.Get("/diagnostics", errorx.ErrorHandler(h.HandleListDiagnostics))
+		r.Get("/redirects", errorx.ErrorHandler(h.HandleListRedirects))
+		r.Delete("/redirects/{slug}", errorx.ErrorHandler(h.HandleDeleteRedirect))
+		r.Get("/webmentions", errorx.ErrorHandler(h.HandleListWebmentions))
+		r.Post("/webmentions/{id}/approve", errorx.ErrorHandler(h.HandleApproveWebmention))
+		r.Post("/webmentions/{id}/reject", errorx.ErrorHandler(h.HandleRejectWebmention))
+		r.Get("/followers", errorx.ErrorHandler(h.HandleListFollowers))
+
+		r.Get("/posts", errorx.ErrorHandler(h.HandleListWorkingPosts))
+		r.Get("/posts/{id}/diff", errorx.ErrorHandler(h.HandleDiffPost))
+		r.Post("/posts/{id}/publish", errorx.ErrorHandler(h.HandlePublishPost))
+		r.Post("/posts/{id}/unpublish", errorx.ErrorHandler(h.HandleUnpublishPost))
+		r.Delete("/posts/{id}", errorx.ErrorHandler(h.HandleDeletePost))
+		r.Post("/sync", errorx.ErrorHandler(h.HandleResync))
+
+		r.Get("/shadow", errorx.ErrorHandler(h.HandleShadowStatus))
+		r.Post("/shadow/build", errorx.ErrorHandler(h.HandleShadowBuild))
+		r.Post("/shadow/promote", errorx.ErrorHandler(h.HandlePromoteShadow))
+		r.Delete("/shadow", errorx.ErrorHandler(h.HandleDiscardShadow))
+
+		r.Get("/tokens", errorx.ErrorHandler(h.HandleListTokens))
+		r.Post("/tokens", errorx.ErrorHandler(h.HandleCreateToken))
+		r.Delete("/tokens/{id}", errorx.ErrorHandler(h.HandleRevokeToken))
+	})
+}
+
+type diskUsageResponse struct {
+	PostsBytes     int64     `json:"posts_bytes"`
+	ImagesBytes    int64     `json:"images_bytes"`
+	DatabaseBytes  int64     `json:"database_bytes"`
+	TotalBytes     int64     `json:"total_bytes"`
+	SoftLimitBytes int64     `json:"soft_limit_bytes"`
+	HardLimitBytes int64     `json:"hard_limit_bytes"`
+	SoftExceeded   bool      `json:"soft_limit_exceeded"`
+	HardExceeded   bool      `json:"hard_limit_exceeded"`
+	MeasuredAt     time.Time `json:"measured_at"`
+}
+
+type previewStatsResponse struct {
+	Active         int        `json:"active"`
+	DeletedExpired int        `json:"deleted_expired"`
+	DeletedClosed  int        `json:"deleted_closed"`
+	LastCleanupAt  *time.Time `json:"last_cleanup_at,omitempty"`
+}
+
+type webhookStatusResponse struct {
+	State     string    `json:"state"`
+	URL       string    `json:"url"`
+	CheckedAt time.Time `json:"checked_at"`
+	Error     string    `json:"error,omitempty"`
+}
+
+type statusResponse struct {
+	DiskUsage diskUsageResponse      `json:"disk_usage"`
+	Previews  previewStatsResponse   `json:"previews"`
+	Webhook   *webhookStatusResponse `json:"webhook,omitempty"`
+}
+
+// HandleStatus reports the operational state of the blog
+func (h *AdminHandler) HandleStatus(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	usage := h.diskUsage.Usage()
+	soft, hard := h.diskUsage.Limits()
+	previews := h.postService.PreviewStats()
+
+	resp := statusResponse{
+		DiskUsage: diskUsageResponse{
+			PostsBytes:     usage.Posts,
+			ImagesBytes:    usage.Images,
+			DatabaseBytes:  usage.Database,
+			TotalBytes:     usage.Total(),
+			SoftLimitBytes: soft,
+			HardLimitBytes: hard,
+			SoftExceeded:   soft > 0 && usage.Total() > soft,
+			HardExceeded:   hard > 0 && usage.Total() > hard,
+			MeasuredAt:     usage.MeasuredAt,
+		},
+		Previews: previewStatsResponse{
+			Active:         previews.Active,
+			DeletedExpired: previews.DeletedExpired,
+			DeletedClosed:  previews.DeletedClosed,
+		},
+	}
+	if !previews.LastRunAt.IsZero() {
+		resp.Previews.LastCleanupAt = &previews.LastRunAt
+	}
+	resp.Webhook = h.webhookRegistration()
+
+	if err := httpx.RespondJSON(w, r, http.StatusOK, resp); err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	return nil
+}
+
+// webhookRegistration returns the outcome of registering the webhook at startup, or nil if it isn't registered automatically
+func (h *AdminHandler) webhookRegistration() *webhookStatusResponse {
+	if h.webhooks == nil {
+		return nil
+	}
+
+	webhook := h.webhooks.Status()
+	resp := &webhookStatusResponse{
+		State:     string(webhook.State),
+		URL:       webhook.URL,
+		CheckedAt: webhook.CheckedAt,
+	}
+	if webhook.Err != nil {
+		resp.Error = webhook.Err.Error()
+	}
+	return resp
+}
+
+type webhookFailureResponse struct {
+	DeliveryID string    `json:"delivery_id"`
+	Event      string    `json:"event"`
+	Reason     string    `json:"reason"`
+	At         time.Time `json:"at"`
+}
+
+type webhookDeliveriesResponse struct {
+	Registration   *webhookStatusResponse   `json:"registration,omitempty"`
+	Deliveries     int                      `json:"deliveries"`
+	LastDeliveryAt *time.Time               `json:"last_delivery_at,omitempty"`
+	LastEvent      string                   `json:"last_event,omitempty"`
+	LastPingAt     *time.Time               `json:"last_ping_at,omitempty"`
+	PingProblems   []string                 `json:"ping_problems"`
+	RecentFailures []webhookFailureResponse `json:"recent_failures"`
+}
+
+// HandleWebhookStatus reports on the webhook deliveries received since startup, to debug a webhook that isn't working
+func (h *AdminHandler) HandleWebhookStatus(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	status := h.postService.WebhookStatus()
+
+	resp := webhookDeliveriesResponse{
+		Registration:   h.webhookRegistration(),
+		Deliveries:     status.Deliveries,
+		LastEvent:      status.LastEvent,
+		PingProblems:   append([]string{}, status.PingProblems...),
+		RecentFailures: make([]webhookFailureResponse, 0, len(status.RecentFailures)),
+	}
+	if !status.LastDeliveryAt.IsZero() {
+		resp.LastDeliveryAt = &status.LastDeliveryAt
+	}
+	if !status.LastPingAt.IsZero() {
+		resp.LastPingAt = &status.LastPingAt
+	}
+	for _, failure := range status.RecentFailures {
+		resp.RecentFailures = append(resp.RecentFailures, webhookFailureResponse{
+			DeliveryID: failure.DeliveryID,
+			Event:      failure.Event,
+			Reason:     failure.Reason,
+			At:         failure.At,
+		})
+	}
+
+	if err := httpx.RespondJSON(w, r, http.StatusOK, resp); err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	return nil
+}
+
+type orphanedImageResponse struct {
+	Path        string    `json:"path"`
+	Hash        string    `json:"hash"`
+	OrphanedAt  time.Time `json:"orphaned_at"`
+	DeleteAfter time.Time `json:"delete_after"`
+}
+
+// HandleMetrics returns the goblog counters and gauges exported on /metrics as a plain JSON object
+func (h *AdminHandler) HandleMetrics(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	snapshot, err := metrics.Snapshot(prometheus.DefaultGatherer, "goblog_")
+	if err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	if err := httpx.RespondJSON(w, r, http.StatusOK, snapshot); err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	return nil
+}
+
+// HandleListOrphanedImages lists images no post references, for review before they are collected
+func (h *AdminHandler) HandleListOrphanedImages(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	orphans, err := h.imageGC.ListOrphans(r.Context())
+	if err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	resp := make([]orphanedImageResponse, 0, len(orphans))
+	for _, o := range orphans {
+		resp = append(resp, orphanedImageResponse{
+			Path:        o.Path,
+			Hash:        o.Hash,
+			OrphanedAt:  o.OrphanedAt,
+			DeleteAfter: o.DeleteAfter,
+		})
+	}
+
+	if err := httpx.RespondJSON(w, r, http.StatusOK, resp); err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	return nil
+}
+
+type similarImageResponse struct {
+	Path      string `json:"path"`
+	SimilarTo string `json:"similar_to"`
+	Distance  int    `json:"distance"`
+	Identical bool   `json:"identical"`
+}
+
+// HandleListSimilarImages lists pairs of images that look alike, so redundant copies can be removed from the repository
+// ?distance= sets how many of the 64 hash bits may differ.
+func (h *AdminHandler) HandleListSimilarImages(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	distance, err := queryInt(r, "distance")
+	if err != nil {
+		return errorx.BadRequestErr(err)
+	}
+	if r.URL.Query().Get("distance") == "" {
+		distance = application.DefaultSimilarImageDistance
+	}
+	if distance < 0 || distance > 64 {
+		return errorx.BadRequestErr(fmt.Errorf("distance must be between 0 and 64, got %d", distance))
+	}
+
+	similar, err := h.postService.FindSimilarImages(r.Context(), distance)
+	if err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	resp := make([]similarImageResponse, 0, len(similar))
+	for _, s := range similar {
+		resp = append(resp, similarImageResponse{
+			Path:      s.Path,
+			SimilarTo: s.SimilarTo,
+			Distance:  s.Distance,
+			Identical: s.Identical,
+		})
+	}
+
+	if err := httpx.RespondJSON(w, r, http.StatusOK, resp); err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	return nil
+}
+
+type deadLetterResponse struct {
+	Path          string     `json:"path"`
+	Ref           string     `json:"ref"`
+	Error         string     `json:"error"`
+	Attempts      int        `json:"attempts"`
+	RefAttempts   int        `json:"ref_attempts"`
+	FirstFailedAt time.Time  `json:"first_failed_at"`
+	LastFailedAt  time.Time  `json:"last_failed_at"`
+	Poisoned      bool       `json:"poisoned"`
+	PoisonedAt    *time.Time `json:"poisoned_at,omitempty"`
+}
+
+func newDeadLetterResponse(dl *domain.DeadLetter) deadLetterResponse {
+	resp := deadLetterResponse{
+		Path:          dl.Path,
+		Ref:           dl.Ref,
+		Error:         dl.Error,
+		Attempts:      dl.Attempts,
+		RefAttempts:   dl.RefAttempts,
+		FirstFailedAt: dl.FirstFailedAt,
+		LastFailedAt:  dl.LastFailedAt,
+		Poisoned:      !dl.PoisonedAt.IsZero(),
+	}
+	if resp.Poisoned {
+		resp.PoisonedAt = &dl.PoisonedAt
+	}
+	return resp
+}
+
+// HandleListDeadLetters lists files that failed to process even after retrying
+func (h *AdminHandler) HandleListDeadLetters(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	letters, err := h.postService.ListDeadLetters(r.Context())
+	if err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	resp := make([]deadLetterResponse, 0, len(letters))
+	for _, dl := range letters {
+		resp = append(resp, newDeadLetterResponse(dl))
+	}
+
+	if err := httpx.RespondJSON(w, r, http.StatusOK, resp); err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	return nil
+}
+
+// HandleReleaseDeadLetter lifts the quarantine of the file at ?path, so the next sync including it processes it again
+func (h *AdminHandler) HandleReleaseDeadLetter(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	path := r.URL.Query().Get("path")
+	if path == "" {
+		return errorx.BadRequestErr(fmt.Errorf("path is required"))
+	}
+
+	err := h.postService.ReleaseDeadLetter(r.Context(), path)
+	if errors.Is(err, domain.ErrDeadLetterNotFound) {
+		return errorx.NewApiError(err, http.StatusNotFound)
+	}
+	if err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+type redirectResponse struct {
+	From      string    `json:"from"`
+	PostID    string    `json:"post_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// HandleListRedirects lists the old slugs that redirect to renamed posts, newest first
+func (h *AdminHandler) HandleListRedirects(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	redirects, err := h.postService.ListRedirects(r.Context())
+	if err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	resp := make([]redirectResponse, 0, len(redirects))
+	for _, redirect := range redirects {
+		resp = append(resp, redirectResponse{
+			From:      "/posts/" + redirect.FromSlug,
+			PostID:    redirect.PostID,
+			CreatedAt: redirect.CreatedAt,
+		})
+	}
+
+	if err := httpx.RespondJSON(w, r, http.StatusOK, resp); err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	return nil
+}
+
+// HandleDeleteRedirect stops redirecting an old slug, which then serves 404
+func (h *AdminHandler) HandleDeleteRedirect(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	err := h.postService.DeleteRedirect(r.Context(), chi.URLParam(r, "slug"))
+	if errors.Is(err, domain.ErrRedirectNotFound) {
+		return errorx.NewApiError(err, http.StatusNotFound)
+	}
+	if err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// HandleListWebmentions lists received mentions newest first, optionally only those with ?status
+func (h *AdminHandler) HandleListWebmentions(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	mentions, err := h.postService.ListWebmentions(r.Context(), domain.WebmentionStatus(r.URL.Query().Get("status")))
+	if errors.Is(err, application.ErrInvalidWebmention) {
+		return errorx.BadRequestErr(err)
+	}
+	if err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	if err := httpx.RespondJSON(w, r, http.StatusOK, newWebmentionResponses(mentions)); err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	return nil
+}
+
+// HandleApproveWebmention shows a mention with its post
+func (h *AdminHandler) HandleApproveWebmention(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	return h.moderateWebmention(w, r, domain.WebmentionApproved)
+}
+
+// HandleRejectWebmention hides a mention; it stays rejected if its source sends it again
+func (h *AdminHandler) HandleRejectWebmention(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	return h.moderateWebmention(w, r, domain.WebmentionRejected)
+}
+
+func (h *AdminHandler) moderateWebmention(w http.ResponseWriter, r *http.Request, status domain.WebmentionStatus) *errorx.ApiError {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		return errorx.BadRequestErr(fmt.Errorf("invalid webmention id: %w", err))
+	}
+
+	err = h.postService.ModerateWebmention(r.Context(), id, status)
+	if errors.Is(err, domain.ErrWebmentionNotFound) {
+		return errorx.NewApiError(err, http.StatusNotFound)
+	}
+	if err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+type followerResponse struct {
+	Actor      string    `json:"actor"`
+	Inbox      string    `json:"inbox"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+// HandleListFollowers lists the ActivityPub actors following the blog, in the order they followed
+func (h *AdminHandler) HandleListFollowers(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	followers, err := h.postService.ListFollowers(r.Context())
+	if err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	resp := make([]followerResponse, 0, len(followers))
+	for _, follower := range followers {
+		inbox := follower.SharedInbox
+		if inbox == "" {
+			inbox = follower.Inbox
+		}
+		resp = append(resp, followerResponse{
+			Actor:      follower.ActorID,
+			Inbox:      inbox,
+			FollowedAt: follower.FollowedAt,
+		})
+	}
+
+	if err := httpx.RespondJSON(w, r, http.StatusOK, resp); err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	return nil
+}
+
+type postDiagnosticResponse struct {
+	PostID    string    `json:"post_id"`
+	Kind      string    `json:"kind"`
+	Target    string    `json:"target"`
+	Message   string    `json:"message"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// HandleListDiagnostics lists the broken links and images found in rendered posts, optionally for one ?post=
+func (h *AdminHandler) HandleListDiagnostics(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	diagnostics, err := h.postService.ListDiagnostics(r.Context())
+	if err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	postID := r.URL.Query().Get("post")
+	resp := make([]postDiagnosticResponse, 0, len(diagnostics))
+	for _, d := range diagnostics {
+		if postID != "" && d.PostID != postID {
+			continue
+		}
+		resp = append(resp, postDiagnosticResponse{
+			PostID:    d.PostID,
+			Kind:      string(d.Kind),
+			Target:    d.Target,
+			Message:   d.Message,
+			CheckedAt: d.CheckedAt,
+		})
+	}
+
+	if err := httpx.RespondJSON(w, r, http.StatusOK, resp); err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	return nil
+}
+
+type workingPostResponse struct {
+	ID         string              `json:"id"`
+	Title      string              `json:"title,omitempty"`
+	SourcePath string              `json:"source_path,omitempty"`
+	Branch     string              `json:"branch,omitempty"`
+	CommitSHA  string              `json:"commit_sha,omitempty"`
+	UpdatedAt  *time.Time          `json:"updated_at,omitempty"`
+	PublishAt  *time.Time          `json:"publish_at,omitempty"`
+	Failure    *deadLetterResponse `json:"failure,omitempty"`
+}
+
+// HandleListWorkingPosts lists posts the blog knows about but does not serve
+// ?state selects unpublished (the default), scheduled or failed posts.
+func (h *AdminHandler) HandleListWorkingPosts(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	state := application.PostState(r.URL.Query().Get("state"))
+	if state == "" {
+		state = application.PostStateUnpublished
+	}
+
+	posts, err := h.postService.ListWorkingPosts(r.Context(), state)
+	if errors.Is(err, application.ErrInvalidPostState) {
+		return errorx.BadRequestErr(err)
+	}
+	if err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	resp := make([]workingPostResponse, 0, len(posts))
+	for _, wp := range posts {
+		p := wp.Post
+		item := workingPostResponse{
+			ID:         p.ID,
+			Title:      p.Title,
+			SourcePath: p.SourcePath,
+			Branch:     p.Branch,
+			CommitSHA:  p.CommitSHA,
+		}
+		if !p.UpdatedAt.IsZero() {
+			item.UpdatedAt = &p.UpdatedAt
+		}
+		if !p.PublishAt.IsZero() {
+			item.PublishAt = &p.PublishAt
+		}
+		if dl := wp.Failure; dl != nil {
+			failure := newDeadLetterResponse(dl)
+			item.Failure = &failure
+		}
+		resp = append(resp, item)
+	}
+
+	if err := httpx.RespondJSON(w, r, http.StatusOK, resp); err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	return nil
+}
+
+// HandleDiffPost compares the rendered HTML of a post at two commits of the content repository
+// ?from is required and ?to defaults to the commit the post was last rendered from. ?format selects a unified
+// diff (the default) or a side-by-side HTML table.
+func (h *AdminHandler) HandleDiffPost(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format != "" && format != "unified" && format != "side-by-side" {
+		return errorx.BadRequestErr(fmt.Errorf("%w: unknown format %q", application.ErrInvalidDiff, format))
+	}
+
+	diff, err := h.postService.DiffPost(r.Context(), chi.URLParam(r, "id"), query.Get("from"), query.Get("to"))
+	if errors.Is(err, domain.ErrPostNotFound) {
+		return errorx.NewApiError(err, http.StatusNotFound)
+	}
+	if errors.Is(err, application.ErrInvalidDiff) {
+		return errorx.BadRequestErr(err)
+	}
+	if err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	body := diff.Unified()
+	w.Header().Set("Content-Type", "text/x-diff; charset=utf-8")
+	if format == "side-by-side" {
+		body = diff.SideBySide()
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(body))
+	return nil
+}
+
+// HandlePublishPost publishes a post immediately
+func (h *AdminHandler) HandlePublishPost(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	return respondPostAction(w, h.postService.PublishPost(r.Context(), chi.URLParam(r, "id")))
+}
+
+// HandleUnpublishPost takes a post offline without deleting it
+func (h *AdminHandler) HandleUnpublishPost(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	return respondPostAction(w, h.postService.UnpublishPost(r.Context(), chi.URLParam(r, "id")))
+}
+
+// HandleDeletePost removes a post and its rendered HTML
+func (h *AdminHandler) HandleDeletePost(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	return respondPostAction(w, h.postService.DeletePost(r.Context(), chi.URLParam(r, "id")))
+}
+
+// respondPostAction maps the result of a post action to a response, writing 204 on success
+func respondPostAction(w http.ResponseWriter, err error) *errorx.ApiError {
+	if errors.Is(err, domain.ErrPostNotFound) {
+		return errorx.NewApiError(err, http.StatusNotFound)
+	}
+	if err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// HandleResync starts reprocessing the full history of every branch
+func (h *AdminHandler) HandleResync(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	err := h.postService.StartResync()
+	if errors.Is(err, application.ErrResyncInProgress) {
+		return errorx.NewApiError(err, http.StatusConflict)
+	}
+	if err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	return nil
+}
+
+type shadowStatusResponse struct {
+	State      string     `json:"state"`
+	Head       string     `json:"head,omitempty"`
+	Posts      int        `json:"posts"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	PromotedAt *time.Time `json:"promoted_at,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// HandleShadowStatus reports on the last shadow build
+func (h *AdminHandler) HandleShadowStatus(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	status := h.postService.ShadowStatus()
+
+	resp := shadowStatusResponse{
+		State: string(status.State),
+		Head:  status.Head,
+		Posts: status.Posts,
+	}
+	if !status.StartedAt.IsZero() {
+		resp.StartedAt = &status.StartedAt
+	}
+	if !status.FinishedAt.IsZero() {
+		resp.FinishedAt = &status.FinishedAt
+	}
+	if !status.PromotedAt.IsZero() {
+		resp.PromotedAt = &status.PromotedAt
+	}
+	if status.Err != nil {
+		resp.Error = status.Err.Error()
+	}
+
+	if err := httpx.RespondJSON(w, r, http.StatusOK, resp); err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	return nil
+}
+
+// HandleShadowBuild starts rendering the whole repository into the shadow namespace
+func (h *AdminHandler) HandleShadowBuild(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	if err := h.postService.StartShadowBuild(); err != nil {
+		return shadowError(err)
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	return nil
+}
+
+type promoteShadowResponse struct {
+	Posts int `json:"posts"`
+}
+
+// HandlePromoteShadow replaces the live posts with the last shadow build
+func (h *AdminHandler) HandlePromoteShadow(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	posts, err := h.postService.PromoteShadow(r.Context())
+	if err != nil {
+		return shadowError(err)
+	}
+
+	if err := httpx.RespondJSON(w, r, http.StatusOK, promoteShadowResponse{Posts: posts}); err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	return nil
+}
+
+// HandleDiscardShadow deletes the last shadow build
+func (h *AdminHandler) HandleDiscardShadow(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	if err := h.postService.DiscardShadow(r.Context()); err != nil {
+		return shadowError(err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// shadowError maps a shadow build error to a response, rejecting requests the shadow namespace isn't ready for
+func shadowError(err error) *errorx.ApiError {
+	switch {
+	case errors.Is(err, application.ErrShadowUnavailable),
+		errors.Is(err, application.ErrShadowBuildInProgress),
+		errors.Is(err, application.ErrShadowNotReady),
+		errors.Is(err, application.ErrShadowStale):
+		return errorx.NewApiError(err, http.StatusConflict)
+	default:
+		return errorx.InternalServerErr(err)
+	}
+}
+
+type apiTokenResponse struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	// Token is the secret, only included when the token is created
+	Token string `json:"token,omitempty"`
+}
+
+func newAPITokenResponse(token *domain.APIToken) apiTokenResponse {
+	resp := apiTokenResponse{
+		ID:        token.ID,
+		Name:      token.Name,
+		Scopes:    make([]string, 0, len(token.Scopes)),
+		CreatedAt: token.CreatedAt,
+	}
+
+	for _, scope := range token.Scopes {
+		resp.Scopes = append(resp.Scopes, string(scope))
+	}
+	if !token.LastUsedAt.IsZero() {
+		resp.LastUsedAt = &token.LastUsedAt
+	}
+	if !token.RevokedAt.IsZero() {
+		resp.RevokedAt = &token.RevokedAt
+	}
+
+	return resp
+}
+
+// HandleListTokens lists every API token without its secret
+func (h *AdminHandler) HandleListTokens(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	tokens, err := h.auth.ListTokens(r.Context())
+	if err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	resp := make([]apiTokenResponse, 0, len(tokens))
+	for _, token := range tokens {
+		resp = append(resp, newAPITokenResponse(token))
+	}
+
+	if err := httpx.RespondJSON(w, r, http.StatusOK, resp); err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	return nil
+}
+
+type createTokenRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+// HandleCreateToken issues a new API token, returning its secret this one time
+func (h *AdminHandler) HandleCreateToken(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	var req createTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return errorx.BadRequestErr(fmt.Errorf("invalid request body: %w", err))
+	}
+
+	scopes := make([]domain.Scope, 0, len(req.Scopes))
+	for _, scope := range req.Scopes {
+		scopes = append(scopes, domain.Scope(scope))
+	}
+
+	secret, token, err := h.auth.CreateToken(r.Context(), req.Name, scopes)
+	if errors.Is(err, application.ErrInvalidScope) {
+		return errorx.BadRequestErr(err)
+	}
+	if err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	resp := newAPITokenResponse(token)
+	resp.Token = secret
+	if err := httpx.RespondJSON(w, r, http.StatusCreated, resp); err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	return nil
+}
+
+// HandleRevokeToken stops a token from authenticating
+func (h *AdminHandler) HandleRevokeToken(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		return errorx.BadRequestErr(fmt.Errorf("invalid token id: %w", err))
+	}
+
+	err = h.auth.RevokeToken(r.Context(), id)
+	if errors.Is(err, domain.ErrAPITokenNotFound) {
+		return errorx.NewApiError(err, http.StatusNotFound)
+	}
+	if err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
@@ -0,0 +1,84 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dfryer1193/mjolnir/utils/errorx"
+	"github.com/dfryer1193/mjolnir/utils/httpx"
+	"github.com/go-chi/chi/v5"
+)
+
+type archiveMonthResponse struct {
+	Year  int    `json:"year"`
+	Month int    `json:"month"`
+	Posts int    `json:"posts"`
+	URL   string `json:"url"`
+}
+
+// HandleArchive counts the published posts in each month that has any, newest first
+func (h *PostHandler) HandleArchive(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	etag := contentETag(h.postService.ContentVersion())
+	lastModified := h.postService.ContentModifiedAt()
+	if notModified(w, r, etag, lastModified) {
+		return nil
+	}
+
+	months, err := h.postService.ArchiveMonths(r.Context())
+	if err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	resp := make([]archiveMonthResponse, 0, len(months))
+	for _, m := range months {
+		resp = append(resp, archiveMonthResponse{
+			Year:  m.Year,
+			Month: int(m.Month),
+			Posts: m.Posts,
+			URL:   fmt.Sprintf("/api/archive/%d/%02d", m.Year, m.Month),
+		})
+	}
+
+	setPageCacheHeaders(w, etag, lastModified)
+	if err := httpx.RespondJSON(w, r, http.StatusOK, resp); err != nil {
+		return errorx.InternalServerErr(err)
+	}
+	return nil
+}
+
+// HandleArchiveMonth lists the posts published in /api/archive/{year}/{month}, newest first
+func (h *PostHandler) HandleArchiveMonth(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	year, err := strconv.Atoi(chi.URLParam(r, "year"))
+	if err != nil || year < 1 || year > 9999 {
+		return errorx.BadRequestErr(errors.New("year must be a number between 1 and 9999"))
+	}
+	month, err := strconv.Atoi(chi.URLParam(r, "month"))
+	if err != nil || month < 1 || month > 12 {
+		return errorx.BadRequestErr(errors.New("month must be a number between 1 and 12"))
+	}
+
+	etag := contentETag(h.postService.ContentVersion())
+	lastModified := h.postService.ContentModifiedAt()
+	if notModified(w, r, etag, lastModified) {
+		return nil
+	}
+
+	posts, err := h.postService.ArchivePosts(r.Context(), year, time.Month(month))
+	if err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	resp := make([]postSummaryResponse, 0, len(posts))
+	for _, post := range posts {
+		resp = append(resp, newPostSummaryResponse(post))
+	}
+
+	setPageCacheHeaders(w, etag, lastModified)
+	if err := httpx.RespondJSON(w, r, http.StatusOK, resp); err != nil {
+		return errorx.InternalServerErr(err)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/dfryer1193/goblog/blog/application"
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/mjolnir/utils/errorx"
+)
+
+// RequireScope rejects requests that don't carry a bearer API token granting scope
+// Missing or unknown tokens get a 401; tokens without the scope get a 403.
+func RequireScope(auth *application.AuthService, scope domain.Scope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return errorx.ErrorHandler(func(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+			secret, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="goblog"`)
+				return errorx.UnauthorizedErr(errors.New("missing bearer token"))
+			}
+
+			token, err := auth.Authenticate(r.Context(), secret)
+			if errors.Is(err, domain.ErrAPITokenNotFound) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="goblog", error="invalid_token"`)
+				return errorx.UnauthorizedErr(errors.New("invalid bearer token"))
+			}
+			if err != nil {
+				return errorx.InternalServerErr(err)
+			}
+
+			if !token.HasScope(scope) {
+				return errorx.NewApiError(fmt.Errorf("token lacks the %s scope", scope), http.StatusForbidden)
+			}
+
+			next.ServeHTTP(w, r)
+			return nil
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/dfryer1193/goblog/blog/application"
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/blog/persistence"
+	"github.com/dfryer1193/goblog/shared/db/sqlite"
+	"github.com/go-chi/chi/v5"
+)
+
+// newTestDB opens a migrated database that is closed when the test ends
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	database := sqlite.NewSQLiteDB(&sqlite.SQLiteConfig{
+		Path: filepath.Join(t.TempDir(), "test.db"),
+	})
+	if err := database.Connect(); err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	return database.DB()
+}
+
+// newTestAuthService returns an AuthService backed by a fresh database
+func newTestAuthService(t *testing.T) *application.AuthService {
+	t.Helper()
+	return application.NewAuthService(persistence.NewAPITokenRepository(newTestDB(t)), &application.AuthConfig{})
+}
+
+func TestRequireScope(t *testing.T) {
+	auth := newTestAuthService(t)
+	ctx := context.Background()
+
+	readSecret, _, err := auth.CreateToken(ctx, "reader", []domain.Scope{domain.ScopeRead})
+	if err != nil {
+		t.Fatalf("CreateToken failed: %v", err)
+	}
+	adminSecret, _, err := auth.CreateToken(ctx, "operator", []domain.Scope{domain.ScopeAdmin})
+	if err != nil {
+		t.Fatalf("CreateToken failed: %v", err)
+	}
+	revokedSecret, revoked, err := auth.CreateToken(ctx, "former", []domain.Scope{domain.ScopeAdmin})
+	if err != nil {
+		t.Fatalf("CreateToken failed: %v", err)
+	}
+	if err := auth.RevokeToken(ctx, revoked.ID); err != nil {
+		t.Fatalf("RevokeToken failed: %v", err)
+	}
+
+	handler := RequireScope(auth, domain.ScopeAdmin)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantStatus    int
+		wantChallenge string
+	}{
+		{name: "missing header", wantStatus: http.StatusUnauthorized, wantChallenge: `Bearer realm="goblog"`},
+		{name: "not a bearer token", authorization: "Basic " + adminSecret, wantStatus: http.StatusUnauthorized, wantChallenge: `Bearer realm="goblog"`},
+		{name: "unknown token", authorization: "Bearer gbt_unknown", wantStatus: http.StatusUnauthorized, wantChallenge: `Bearer realm="goblog", error="invalid_token"`},
+		{name: "revoked token", authorization: "Bearer " + revokedSecret, wantStatus: http.StatusUnauthorized, wantChallenge: `Bearer realm="goblog", error="invalid_token"`},
+		{name: "token without the scope", authorization: "Bearer " + readSecret, wantStatus: http.StatusForbidden},
+		{name: "admin token", authorization: "Bearer " + adminSecret, wantStatus: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin/status", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("WWW-Authenticate"); got != tt.wantChallenge {
+				t.Errorf("WWW-Authenticate = %q, want %q", got, tt.wantChallenge)
+			}
+		})
+	}
+}
+
+func TestRequireScope_AdminImpliesRead(t *testing.T) {
+	auth := newTestAuthService(t)
+	adminSecret, _, err := auth.CreateToken(context.Background(), "operator", []domain.Scope{domain.ScopeAdmin})
+	if err != nil {
+		t.Fatalf("CreateToken failed: %v", err)
+	}
+
+	handler := RequireScope(auth, domain.ScopeRead)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/sync/jobs", nil)
+	req.Header.Set("Authorization", "Bearer "+adminSecret)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+}
+
+func TestAdminHandler_RequiresAdminScope(t *testing.T) {
+	auth := newTestAuthService(t)
+	readSecret, _, err := auth.CreateToken(context.Background(), "reader", []domain.Scope{domain.ScopeRead})
+	if err != nil {
+		t.Fatalf("CreateToken failed: %v", err)
+	}
+
+	r := chi.NewRouter()
+	NewAdminHandler(nil, nil, nil, auth, nil).RegisterRoutes(r)
+
+	for authorization, want := range map[string]int{
+		"":                     http.StatusUnauthorized,
+		"Bearer " + readSecret: http.StatusForbidden,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/admin/sync", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+
+		if rec.Code != want {
+			t.Errorf("POST /admin/sync with %q: status = %d, want %d", authorization, rec.Code, want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pageCacheControl lets browsers and CDNs store pages but revalidate them on every request
+// Revalidation is cheap: unchanged pages are answered with 304 before any rendering happens.
+const pageCacheControl = "public, no-cache"
+
+// contentETag is the ETag of any page rendered at the given site content version
+func contentETag(version int64) string {
+	return `"v` + strconv.FormatInt(version, 36) + `"`
+}
+
+// postETag is the ETag of a page showing a single post at the given post version
+func postETag(version string) string {
+	return `"p` + version + `"`
+}
+
+// notModified reports whether the client's copy of a page is current, answering with a 304 if so
+// Caching headers are only set here and on successful responses, so errors are never revalidated.
+func notModified(w http.ResponseWriter, r *http.Request, etag string, lastModified time.Time) bool {
+	if !clientIsCurrent(r, etag, lastModified) {
+		return false
+	}
+
+	setPageCacheHeaders(w, etag, lastModified)
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// clientIsCurrent checks If-None-Match, or If-Modified-Since when the client sent no ETag, as RFC 9110 requires
+func clientIsCurrent(r *http.Request, etag string, lastModified time.Time) bool {
+	if header := r.Header.Get("If-None-Match"); header != "" {
+		return etagMatches(header, etag)
+	}
+
+	if lastModified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	// HTTP dates have whole seconds, so a page changed within the second the client saw must still be sent
+	return !lastModified.Truncate(time.Second).After(since)
+}
+
+// setPageCacheHeaders sets the validators of a page, leaving out Last-Modified when it is zero
+func setPageCacheHeaders(w http.ResponseWriter, etag string, lastModified time.Time) {
+	w.Header().Set("Cache-Control", pageCacheControl)
+	w.Header().Set("ETag", etag)
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+}
+
+// etagMatches reports whether an If-None-Match header lists etag, using weak comparison
+func etagMatches(header string, etag string) bool {
+	for candidate := range strings.SplitSeq(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,189 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/dfryer1193/goblog/blog/application"
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/blog/theme"
+)
+
+// SiteExporter writes the public pages of the blog to a directory, for hosting without the server
+// Pages are rendered by the same code that serves them, and laid out so that their URLs resolve on
+// static hosts that serve index.html for a directory, such as S3 websites or Netlify.
+type SiteExporter struct {
+	pages       *PostHandler
+	postService *application.PostService
+	theme       *theme.Theme
+}
+
+// ExportStats counts what an export wrote
+type ExportStats struct {
+	Posts      int
+	IndexPages int
+	Images     int
+}
+
+func NewSiteExporter(postService *application.PostService, theme *theme.Theme) *SiteExporter {
+	return &SiteExporter{
+		pages:       NewPostHandler(postService, theme),
+		postService: postService,
+		theme:       theme,
+	}
+}
+
+// Export writes every published post, the index pages, the feeds, the sitemap, the theme's static files
+// and the images the posts use into dir, with pages redirecting the old URLs of renamed posts
+func (e *SiteExporter) Export(ctx context.Context, dir string) (*ExportStats, error) {
+	stats := &ExportStats{}
+
+	for page := 1; ; page++ {
+		indexPage, err := e.pages.indexPage(ctx, page)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list posts: %w", err)
+		}
+		indexPage.Static = true
+
+		var buf bytes.Buffer
+		if err := e.theme.RenderIndex(&buf, indexPage); err != nil {
+			return nil, err
+		}
+		name := "index.html"
+		if page > 1 {
+			name = path.Join("page", strconv.Itoa(page), "index.html")
+		}
+		if err := writeExportFile(dir, name, buf.Bytes()); err != nil {
+			return nil, err
+		}
+		stats.IndexPages++
+
+		if indexPage.NextPage == 0 {
+			break
+		}
+	}
+
+	posts, err := e.postService.PublishedPosts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, listed := range posts {
+		post, err := e.postService.GetPublishedPost(ctx, listed.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load post %s: %w", listed.ID, err)
+		}
+
+		var buf bytes.Buffer
+		if err := e.theme.RenderPost(&buf, e.pages.postPage(post)); err != nil {
+			return nil, err
+		}
+		if err := writeExportFile(dir, strings.TrimPrefix(post.URLPath(), "/")+"/index.html", buf.Bytes()); err != nil {
+			return nil, err
+		}
+		// Static hosts can't redirect, so the ID URL gets a page that does
+		if post.Slug != "" {
+			if err := writeExportFile(dir, path.Join("posts", post.ID, "index.html"), exportRedirect(post.URLPath())); err != nil {
+				return nil, err
+			}
+		}
+		stats.Posts++
+	}
+
+	redirects, err := e.postService.ListRedirects(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, redirect := range redirects {
+		post, err := e.postService.GetRedirectTarget(ctx, redirect.FromSlug)
+		if errors.Is(err, domain.ErrPostNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to load post %s: %w", redirect.PostID, err)
+		}
+		if err := writeExportFile(dir, path.Join("posts", redirect.FromSlug, "index.html"), exportRedirect(post.URLPath())); err != nil {
+			return nil, err
+		}
+	}
+
+	feed, err := e.pages.feed(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeExportFile(dir, strings.TrimPrefix(FeedPath, "/"), feed); err != nil {
+		return nil, err
+	}
+
+	opml, err := e.pages.opml()
+	if err != nil {
+		return nil, err
+	}
+	if err := writeExportFile(dir, strings.TrimPrefix(OPMLPath, "/"), opml); err != nil {
+		return nil, err
+	}
+
+	sitemap, err := e.pages.sitemap(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeExportFile(dir, strings.TrimPrefix(SitemapPath, "/"), sitemap); err != nil {
+		return nil, err
+	}
+
+	if err := e.theme.WriteStatic(filepath.Join(dir, "static")); err != nil {
+		return nil, err
+	}
+
+	images, err := e.postService.PublishedImages(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, stored := range images {
+		img, err := e.postService.GetImageByHash(ctx, stored.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load image %s: %w", stored.Path, err)
+		}
+		// Posts link images by content hash, and the server redirects their paths there
+		for _, name := range []string{strings.TrimPrefix(application.ImageURLPath(img.Hash, img.Path), "/"), img.Path} {
+			if err := writeExportFile(dir, name, img.Content); err != nil {
+				return nil, err
+			}
+		}
+		stats.Images++
+	}
+
+	return stats, nil
+}
+
+// exportRedirect is a page that sends browsers and crawlers on to target
+func exportRedirect(target string) []byte {
+	escaped := html.EscapeString(target)
+	return []byte(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><link rel="canonical" href="` + escaped + `"><meta http-equiv="refresh" content="0; url=` + escaped + `"></head>
+<body><a href="` + escaped + `">` + escaped + `</a></body></html>
+`)
+}
+
+// writeExportFile writes content to the slash-separated name below dir
+func writeExportFile(dir string, name string, content []byte) error {
+	if !filepath.IsLocal(filepath.FromSlash(name)) {
+		return fmt.Errorf("%w: %s", domain.ErrInvalidPath, name)
+	}
+
+	target := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", name, err)
+	}
+	if err := os.WriteFile(target, content, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/dfryer1193/goblog/blog/application"
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/activitypub"
+	"github.com/dfryer1193/mjolnir/utils/errorx"
+	"github.com/go-chi/chi/v5"
+)
+
+// maxActivityBody bounds an activity delivered to the inbox
+const maxActivityBody = 1 << 20
+
+// FederationHandler serves the blog's ActivityPub actor, so users of Mastodon and other servers can follow it
+// Every route answers 404 when federation is disabled.
+type FederationHandler struct {
+	postService *application.PostService
+}
+
+func NewFederationHandler(postService *application.PostService) *FederationHandler {
+	return &FederationHandler{
+		postService: postService,
+	}
+}
+
+func (h *FederationHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/.well-known/webfinger", errorx.ErrorHandler(h.HandleWebFinger))
+	r.Get(application.FederationActorPath, errorx.ErrorHandler(h.HandleActor))
+	r.Get(application.FederationOutboxPath, errorx.ErrorHandler(h.HandleOutbox))
+	r.Get(application.FederationFollowersPath, errorx.ErrorHandler(h.HandleFollowers))
+	r.Get(application.FederationPostsPath+"{id}", errorx.ErrorHandler(h.HandleNote))
+	r.Post(application.FederationInboxPath, errorx.ErrorHandler(h.HandleInbox))
+}
+
+// HandleWebFinger resolves ?resource=acct:user@host to the blog's actor
+func (h *FederationHandler) HandleWebFinger(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	resource := r.URL.Query().Get("resource")
+	if resource == "" {
+		return errorx.BadRequestErr(errors.New("resource is required"))
+	}
+
+	finger, err := h.postService.FederationWebFinger(resource)
+	if apiErr := federationError(err); apiErr != nil {
+		return apiErr
+	}
+	return writeActivityJSON(w, activitypub.JRDContentType, finger)
+}
+
+// HandleActor serves the blog's actor document, with the key its requests are signed with
+func (h *FederationHandler) HandleActor(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	actor, err := h.postService.FederationActor()
+	if apiErr := federationError(err); apiErr != nil {
+		return apiErr
+	}
+	return writeActivityJSON(w, activitypub.ContentType, actor)
+}
+
+// HandleOutbox lists the latest published posts as the activities that created them
+func (h *FederationHandler) HandleOutbox(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	outbox, err := h.postService.FederationOutbox(r.Context())
+	if apiErr := federationError(err); apiErr != nil {
+		return apiErr
+	}
+	return writeActivityJSON(w, activitypub.ContentType, outbox)
+}
+
+// HandleFollowers counts the blog's followers
+func (h *FederationHandler) HandleFollowers(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	followers, err := h.postService.FederationFollowers(r.Context())
+	if apiErr := federationError(err); apiErr != nil {
+		return apiErr
+	}
+	return writeActivityJSON(w, activitypub.ContentType, followers)
+}
+
+// HandleNote serves a published post as the note its followers were sent
+func (h *FederationHandler) HandleNote(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	note, err := h.postService.FederationNote(r.Context(), chi.URLParam(r, "id"))
+	if apiErr := federationError(err); apiErr != nil {
+		return apiErr
+	}
+	return writeActivityJSON(w, activitypub.ContentType, note)
+}
+
+// HandleInbox receives activities from other servers, answering 202 once they are acted on or ignored
+func (h *FederationHandler) HandleInbox(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxActivityBody))
+	if err != nil {
+		return errorx.BadRequestErr(fmt.Errorf("failed to read activity: %w", err))
+	}
+
+	err = h.postService.ReceiveActivity(r.Context(), r, body)
+	if apiErr := federationError(err); apiErr != nil {
+		return apiErr
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	return nil
+}
+
+func federationError(err error) *errorx.ApiError {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, application.ErrFederationDisabled), errors.Is(err, application.ErrUnknownResource), errors.Is(err, domain.ErrPostNotFound):
+		return errorx.NewApiError(err, http.StatusNotFound)
+	case errors.Is(err, application.ErrInvalidActivity):
+		return errorx.BadRequestErr(err)
+	case errors.Is(err, activitypub.ErrInvalidSignature):
+		return errorx.NewApiError(err, http.StatusUnauthorized)
+	default:
+		return errorx.InternalServerErr(err)
+	}
+}
+
+// writeActivityJSON writes v with an ActivityPub or WebFinger media type, which httpx.RespondJSON can't set
+func writeActivityJSON(w http.ResponseWriter, contentType string, v any) *errorx.ApiError {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	w.Header().Set("Content-Type", contentType+"; charset=utf-8")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+	return nil
+}
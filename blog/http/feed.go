@@ -0,0 +1,130 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/application"
+	"github.com/rs/zerolog/log"
+)
+
+// FeedPath is where the site's Atom feed is served
+const FeedPath = "/feed.xml"
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title     string    `xml:"title"`
+	ID        string    `xml:"id"`
+	Published string    `xml:"published"`
+	Updated   string    `xml:"updated"`
+	Link      atomLink  `xml:"link"`
+	Summary   *atomText `xml:"summary,omitempty"`
+	Content   *atomText `xml:"content,omitempty"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// HandleFeed serves the latest published posts as an Atom feed
+func (h *PostHandler) HandleFeed(w http.ResponseWriter, r *http.Request) {
+	etag := contentETag(h.postService.ContentVersion())
+	lastModified := h.postService.ContentModifiedAt()
+	if notModified(w, r, etag, lastModified) {
+		return
+	}
+
+	feed, err := h.feed(r.Context())
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Failed to build feed")
+		http.Error(w, "Error building feed", http.StatusInternalServerError)
+		return
+	}
+
+	setPageCacheHeaders(w, etag, lastModified)
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(feed)
+}
+
+// feed encodes the feed's entries as an Atom document
+func (h *PostHandler) feed(ctx context.Context) ([]byte, error) {
+	entries, err := h.postService.FeedEntries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	site := h.theme.Site()
+	baseURL := strings.TrimSuffix(site.BaseURL, "/")
+	feed := atomFeed{
+		Title:  site.Title,
+		ID:     baseURL + "/",
+		Author: atomAuthor{Name: site.Title},
+		Links: []atomLink{
+			{Href: baseURL + "/"},
+			{Href: baseURL + FeedPath, Rel: "self", Type: "application/atom+xml"},
+		},
+	}
+
+	var updated time.Time
+	for _, entry := range entries {
+		feed.Entries = append(feed.Entries, newAtomEntry(entry, baseURL))
+		if entry.UpdatedAt.After(updated) {
+			updated = entry.UpdatedAt
+		}
+	}
+	feed.Updated = updated.UTC().Format(time.RFC3339)
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	if err := xml.NewEncoder(&buf).Encode(feed); err != nil {
+		return nil, fmt.Errorf("failed to encode feed: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// newAtomEntry carries the post's HTML when the feed has it, and its snippet otherwise
+func newAtomEntry(entry *application.FeedEntry, baseURL string) atomEntry {
+	post := entry.Post
+	url := baseURL + post.URLPath()
+	atom := atomEntry{
+		Title: post.Title,
+		// The ID stays the same if the post gains or changes a slug, so readers don't see it as a new entry
+		ID:        baseURL + "/posts/" + post.ID,
+		Published: post.PublishedAt.UTC().Format(time.RFC3339),
+		Updated:   entry.UpdatedAt.UTC().Format(time.RFC3339),
+		Link:      atomLink{Href: url},
+	}
+
+	if post.HTMLContent != nil {
+		atom.Content = &atomText{Type: "html", Body: string(post.HTMLContent)}
+	} else {
+		atom.Summary = &atomText{Type: "text", Body: post.Snippet}
+	}
+	return atom
+}
@@ -0,0 +1,123 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"mime"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/dfryer1193/goblog/blog/application"
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	svgContentType = "image/svg+xml"
+	// svgContentSecurityPolicy lets an SVG draw itself but not run scripts, load anything or act as its origin
+	svgContentSecurityPolicy = "default-src 'none'; style-src 'unsafe-inline'; sandbox"
+)
+
+// imageHashRegex matches the sha256 content hashes images are addressed by
+var imageHashRegex = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// ImageHandler serves stored images
+// Images are addressed by content hash so they can be cached forever;
+// path URLs redirect to the hash of the current content.
+type ImageHandler struct {
+	postService *application.PostService
+}
+
+func NewImageHandler(postService *application.PostService) *ImageHandler {
+	return &ImageHandler{
+		postService: postService,
+	}
+}
+
+func (h *ImageHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/images/*", h.HandleImage)
+}
+
+func (h *ImageHandler) HandleImage(w http.ResponseWriter, r *http.Request) {
+	// Images may sit in folders below the images directory; only hash URLs are always a single segment
+	file := chi.URLParam(r, "*")
+	ext := path.Ext(file)
+	name := strings.TrimSuffix(file, ext)
+
+	if imageHashRegex.MatchString(name) {
+		h.serveByHash(w, r, name, ext)
+		return
+	}
+
+	h.redirectToHash(w, r, h.postService.ImagePath(file))
+}
+
+// serveByHash serves image content that can never change at this URL
+func (h *ImageHandler) serveByHash(w http.ResponseWriter, r *http.Request, hash string, ext string) {
+	img, err := h.postService.GetImageByHash(r.Context(), hash)
+	if errors.Is(err, domain.ErrImageNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Str("hash", hash).Msg("Failed to get image")
+		http.Error(w, "Error loading image", http.StatusInternalServerError)
+		return
+	}
+
+	// The type follows the stored image, so the extension in the URL can't make it anything else, such as HTML
+	canonical := application.ImageURLPath(hash, img.Path)
+	if ext != path.Ext(canonical) {
+		http.Redirect(w, r, canonical, http.StatusMovedPermanently)
+		return
+	}
+
+	contentType := imageContentType(img)
+	w.Header().Set("Content-Type", contentType)
+	if strings.HasPrefix(contentType, svgContentType) {
+		// SVGs can carry scripts, which would run in the blog's origin when one is opened directly
+		w.Header().Set("Content-Security-Policy", svgContentSecurityPolicy)
+	}
+	// Browsers must not second-guess the type, or a crafted image could be rendered as HTML
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("ETag", `"`+hash+`"`)
+
+	http.ServeContent(w, r, "", img.UpdatedAt, bytes.NewReader(img.Content))
+}
+
+// imageContentType returns the media type of an image from its stored path
+// Repositories may hold images with unusual or missing extensions, so those are sniffed, but only ever served as
+// an image or as plain bytes.
+func imageContentType(img *domain.Image) string {
+	if contentType := mime.TypeByExtension(strings.ToLower(path.Ext(img.Path))); contentType != "" {
+		return contentType
+	}
+
+	contentType := http.DetectContentType(img.Content)
+	if !strings.HasPrefix(contentType, "image/") {
+		return "application/octet-stream"
+	}
+	return contentType
+}
+
+// redirectToHash sends path URLs to the content-addressed URL of the current image
+func (h *ImageHandler) redirectToHash(w http.ResponseWriter, r *http.Request, imagePath string) {
+	img, err := h.postService.GetImage(r.Context(), imagePath)
+	if errors.Is(err, domain.ErrImageNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Str("path", imagePath).Msg("Failed to get image")
+		http.Error(w, "Error loading image", http.StatusInternalServerError)
+		return
+	}
+
+	// The target changes whenever the image does, so the redirect itself must not be cached
+	w.Header().Set("Cache-Control", "no-cache")
+	http.Redirect(w, r, application.ImageURLPath(img.Hash, img.Path), http.StatusFound)
+}
@@ -0,0 +1,112 @@
+package http
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/application"
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/blog/persistence"
+	"github.com/dfryer1193/goblog/shared/blob"
+	"github.com/go-chi/chi/v5"
+)
+
+// newTestImageRouter serves /images/ from a fresh database holding images, keyed by the hash of their content
+func newTestImageRouter(t *testing.T, images map[string][]byte) (chi.Router, map[string]string) {
+	t.Helper()
+	db := newTestDB(t)
+	postRepo := persistence.NewPostRepository(db, persistence.WithBlobStore(blob.NewLocalStore(t.TempDir())))
+	imageRepo := persistence.NewImageRepository(db, persistence.WithBlobStore(blob.NewLocalStore(t.TempDir())))
+
+	hashes := make(map[string]string, len(images))
+	now := time.Now().UTC()
+	for imagePath, content := range images {
+		sum := sha256.Sum256(content)
+		hashes[imagePath] = hex.EncodeToString(sum[:])
+		img := &domain.Image{Path: imagePath, Hash: hashes[imagePath], Content: content, UpdatedAt: now, CreatedAt: now}
+		if err := imageRepo.SaveImage(context.Background(), img); err != nil {
+			t.Fatalf("SaveImage(%s) failed: %v", imagePath, err)
+		}
+	}
+
+	postService := application.NewPostService(postRepo, imageRepo, nil, nil, "main")
+	t.Cleanup(func() { postService.Close() })
+
+	r := chi.NewRouter()
+	NewImageHandler(postService).RegisterRoutes(r)
+	return r, hashes
+}
+
+func TestImageHandler_SandboxesSVG(t *testing.T) {
+	svg := []byte(`<svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`)
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	r, hashes := newTestImageRouter(t, map[string][]byte{
+		"images/diagram.svg": svg,
+		"images/cat.png":     png,
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/images/"+hashes["images/diagram.svg"]+".svg", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if got := rec.Header().Get("Content-Security-Policy"); got != svgContentSecurityPolicy {
+		t.Errorf("Content-Security-Policy = %q, want %q", got, svgContentSecurityPolicy)
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/images/"+hashes["images/cat.png"]+".png", nil))
+	if got := rec.Header().Get("Content-Security-Policy"); got != "" {
+		t.Errorf("Content-Security-Policy on a PNG = %q, want none", got)
+	}
+}
+
+func TestImageHandler_ContentTypeFollowsStoredPath(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	r, hashes := newTestImageRouter(t, map[string][]byte{
+		"images/cat.png": png,
+		"images/notes":   []byte("<html><script>alert(1)</script></html>"),
+	})
+	cat := hashes["images/cat.png"]
+
+	tests := []struct {
+		name         string
+		target       string
+		wantStatus   int
+		wantType     string
+		wantLocation string
+	}{
+		{name: "stored extension", target: "/images/" + cat + ".png", wantStatus: http.StatusOK, wantType: "image/png"},
+		{name: "other extension", target: "/images/" + cat + ".html", wantStatus: http.StatusMovedPermanently, wantLocation: "/images/" + cat + ".png"},
+		{name: "no extension", target: "/images/" + cat, wantStatus: http.StatusMovedPermanently, wantLocation: "/images/" + cat + ".png"},
+		{name: "sniffed as something other than an image", target: "/images/" + hashes["images/notes"], wantStatus: http.StatusOK, wantType: "application/octet-stream"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Location"); got != tt.wantLocation {
+				t.Errorf("Location = %q, want %q", got, tt.wantLocation)
+			}
+			if tt.wantType == "" {
+				return
+			}
+			if got := rec.Header().Get("Content-Type"); got != tt.wantType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantType)
+			}
+			if got := rec.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+				t.Errorf("X-Content-Type-Options = %q, want nosniff", got)
+			}
+		})
+	}
+}
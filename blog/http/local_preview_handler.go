@@ -0,0 +1,195 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/application"
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/blog/theme"
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// LocalPreviewEventsPath streams a reload event to preview pages when the checkout changes
+const LocalPreviewEventsPath = "/_preview/events"
+
+// reloadScript is added to every preview page so it reloads itself when the checkout changes
+const reloadScript = `<script>new EventSource("` + LocalPreviewEventsPath + `").onmessage = () => location.reload();</script>`
+
+// LocalPreviewHandler serves a local checkout of the content repository in the theme, reloading pages as it changes
+// The theme is loaded for every page, so edits to a theme directory show up as well.
+type LocalPreviewHandler struct {
+	site        *application.LocalSite
+	themeConfig *theme.ThemeConfig
+	// interval is how often the checkout is checked for changes
+	interval time.Duration
+}
+
+func NewLocalPreviewHandler(site *application.LocalSite, themeConfig *theme.ThemeConfig, interval time.Duration) *LocalPreviewHandler {
+	return &LocalPreviewHandler{
+		site:        site,
+		themeConfig: themeConfig,
+		interval:    interval,
+	}
+}
+
+func (h *LocalPreviewHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/", h.HandleIndex)
+	r.Get("/posts/{id}", h.HandlePost)
+	r.Get(LocalPreviewEventsPath, h.HandleEvents)
+	r.Handle("/images/*", http.StripPrefix("/images/", http.FileServerFS(h.site.Images())))
+	r.Handle("/static/*", http.HandlerFunc(h.HandleStatic))
+	// Relative links between posts are rendered as /<file name>
+	r.Get("/{slug}", h.HandlePostLink)
+}
+
+func (h *LocalPreviewHandler) HandleIndex(w http.ResponseWriter, r *http.Request) {
+	blogTheme, err := theme.Load(h.themeConfig)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	posts, err := h.site.Posts()
+	if err != nil {
+		log.Warn().Err(err).Msg("Some posts failed to render")
+	}
+
+	var buf bytes.Buffer
+	if err := blogTheme.RenderIndex(&buf, &theme.IndexPage{Site: blogTheme.Site(), Posts: posts}); err != nil {
+		h.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	h.writePage(w, buf.Bytes())
+}
+
+// HandlePost serves a post, or the error rendering it so it can be fixed while the page waits to reload
+func (h *LocalPreviewHandler) HandlePost(w http.ResponseWriter, r *http.Request) {
+	blogTheme, err := theme.Load(h.themeConfig)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	post, err := h.site.Post(chi.URLParam(r, "id"))
+	if errors.Is(err, domain.ErrPostNotFound) {
+		h.writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	site := blogTheme.Site()
+	postPage := &theme.PostPage{
+		Site:    site,
+		Post:    post,
+		Content: template.HTML(post.HTMLContent),
+		Meta: domain.PostMetadata{
+			Title:       post.Title,
+			Description: post.Snippet,
+			URL:         strings.TrimSuffix(site.BaseURL, "/") + "/posts/" + post.ID,
+			TwitterCard: "summary",
+		},
+		HasMath:    application.HasMath(post.HTMLContent),
+		HasMermaid: application.HasMermaid(post.HTMLContent),
+	}
+
+	var buf bytes.Buffer
+	if err := blogTheme.RenderPost(&buf, postPage); err != nil {
+		h.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	h.writePage(w, buf.Bytes())
+}
+
+// HandlePostLink redirects a link to a post's file, such as /001-my-post, to the post
+func (h *LocalPreviewHandler) HandlePostLink(w http.ResponseWriter, r *http.Request) {
+	id, _, _ := strings.Cut(chi.URLParam(r, "slug"), "-")
+	http.Redirect(w, r, "/posts/"+id, http.StatusFound)
+}
+
+func (h *LocalPreviewHandler) HandleStatic(w http.ResponseWriter, r *http.Request) {
+	blogTheme, err := theme.Load(h.themeConfig)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	http.StripPrefix("/static/", blogTheme.StaticHandler()).ServeHTTP(w, r)
+}
+
+// HandleEvents streams a single reload event once the checkout or theme changes
+func (h *LocalPreviewHandler) HandleEvents(w http.ResponseWriter, r *http.Request) {
+	version, err := h.version()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	rc := http.NewResponseController(w)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+
+		current, err := h.version()
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to check the checkout for changes")
+			continue
+		}
+		if current != version {
+			fmt.Fprint(w, "data: reload\n\n")
+			rc.Flush()
+			return
+		}
+	}
+}
+
+func (h *LocalPreviewHandler) version() (string, error) {
+	if h.themeConfig.Dir == "" {
+		return h.site.Version()
+	}
+	return h.site.Version(h.themeConfig.Dir)
+}
+
+// writePage writes a rendered page with the reload script added before the end of its body
+func (h *LocalPreviewHandler) writePage(w http.ResponseWriter, page []byte) {
+	if i := bytes.LastIndex(page, []byte("</body>")); i >= 0 {
+		page = append(page[:i:i], append([]byte(reloadScript), page[i:]...)...)
+	} else {
+		page = append(page, reloadScript...)
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(page)
+}
+
+// writeError shows an error in place of the page, which reloads once the checkout changes
+func (h *LocalPreviewHandler) writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<!DOCTYPE html>\n<html><body><pre>%s</pre>%s</body></html>\n", html.EscapeString(err.Error()), reloadScript)
+}
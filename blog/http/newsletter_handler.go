@@ -0,0 +1,108 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+
+	"github.com/dfryer1193/goblog/blog/application"
+	"github.com/dfryer1193/mjolnir/utils/errorx"
+	"github.com/go-chi/chi/v5"
+)
+
+// maxSubscribeBody bounds a subscription request, which only carries an email address
+const maxSubscribeBody = 4 << 10
+
+// NewsletterHandler lets readers subscribe to be emailed new posts
+// Confirmation and unsubscribe links are opened from email, so they answer with plain text rather than JSON.
+type NewsletterHandler struct {
+	postService *application.PostService
+}
+
+func NewNewsletterHandler(postService *application.PostService) *NewsletterHandler {
+	return &NewsletterHandler{
+		postService: postService,
+	}
+}
+
+func (h *NewsletterHandler) RegisterRoutes(r chi.Router) {
+	r.Post("/api/subscribe", errorx.ErrorHandler(h.HandleSubscribe))
+	r.Get(application.NewsletterConfirmPath, errorx.ErrorHandler(h.HandleConfirm))
+	// Mail clients unsubscribe with a POST to the same link (RFC 8058)
+	r.Get(application.NewsletterUnsubscribePath, errorx.ErrorHandler(h.HandleUnsubscribe))
+	r.Post(application.NewsletterUnsubscribePath, errorx.ErrorHandler(h.HandleUnsubscribe))
+}
+
+type subscribeRequest struct {
+	Email string `json:"email"`
+}
+
+// HandleSubscribe accepts an email address as JSON or a form, answering 202 once a confirmation link is on its way
+func (h *NewsletterHandler) HandleSubscribe(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	r.Body = http.MaxBytesReader(w, r.Body, maxSubscribeBody)
+
+	var req subscribeRequest
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "application/json" {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return errorx.BadRequestErr(fmt.Errorf("invalid request body: %w", err))
+		}
+	} else {
+		if err := r.ParseForm(); err != nil {
+			return errorx.BadRequestErr(fmt.Errorf("invalid form: %w", err))
+		}
+		req.Email = r.PostForm.Get("email")
+	}
+	if req.Email == "" {
+		return errorx.BadRequestErr(errors.New("email is required"))
+	}
+
+	if apiErr := newsletterError(h.postService.Subscribe(r.Context(), req.Email)); apiErr != nil {
+		return apiErr
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	return nil
+}
+
+// HandleConfirm confirms the subscription a ?token= confirmation link was sent for
+func (h *NewsletterHandler) HandleConfirm(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	err := h.postService.ConfirmSubscription(r.Context(), r.URL.Query().Get("token"))
+	if apiErr := newsletterError(err); apiErr != nil {
+		return apiErr
+	}
+
+	writeNewsletterText(w, "Your subscription is confirmed. New posts will be emailed to you.")
+	return nil
+}
+
+// HandleUnsubscribe removes the subscriber a ?token= unsubscribe link was sent to
+func (h *NewsletterHandler) HandleUnsubscribe(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	err := h.postService.Unsubscribe(r.Context(), r.URL.Query().Get("token"))
+	if apiErr := newsletterError(err); apiErr != nil {
+		return apiErr
+	}
+
+	writeNewsletterText(w, "You have been unsubscribed and won't be emailed new posts.")
+	return nil
+}
+
+func newsletterError(err error) *errorx.ApiError {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, application.ErrNewsletterDisabled), errors.Is(err, application.ErrInvalidSubscriptionToken):
+		return errorx.NewApiError(err, http.StatusNotFound)
+	case errors.Is(err, application.ErrInvalidEmail):
+		return errorx.BadRequestErr(err)
+	default:
+		return errorx.InternalServerErr(err)
+	}
+}
+
+func writeNewsletterText(w http.ResponseWriter, text string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(text + "\n"))
+}
@@ -0,0 +1,70 @@
+package http
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// OPMLPath is where the list of the site's feeds is served, for importing into a feed reader in one step
+const OPMLPath = "/feeds.opml"
+
+type opmlDocument struct {
+	XMLName xml.Name      `xml:"opml"`
+	Version string        `xml:"version,attr"`
+	Title   string        `xml:"head>title"`
+	Feeds   []opmlOutline `xml:"body>outline"`
+}
+
+type opmlOutline struct {
+	Type    string `xml:"type,attr"`
+	Text    string `xml:"text,attr"`
+	Title   string `xml:"title,attr"`
+	XMLURL  string `xml:"xmlUrl,attr"`
+	HTMLURL string `xml:"htmlUrl,attr"`
+}
+
+// HandleOPML lists every feed the site serves as an OPML subscription list
+// The list only changes with the site's configuration, so it is cached like any other page.
+func (h *PostHandler) HandleOPML(w http.ResponseWriter, r *http.Request) {
+	opml, err := h.opml()
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Failed to build feed list")
+		http.Error(w, "Error building feed list", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Cache-Control", pageCacheControl)
+	w.Header().Set("Content-Type", "text/x-opml; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(opml)
+}
+
+// opml encodes the site's feeds as an OPML document
+// The site has a single feed of all its posts; feeds added later belong in this list too.
+func (h *PostHandler) opml() ([]byte, error) {
+	site := h.theme.Site()
+	baseURL := strings.TrimSuffix(site.BaseURL, "/")
+	doc := opmlDocument{
+		Version: "2.0",
+		Title:   site.Title,
+		Feeds: []opmlOutline{{
+			Type:    "rss",
+			Text:    site.Title,
+			Title:   site.Title,
+			XMLURL:  baseURL + FeedPath,
+			HTMLURL: baseURL + "/",
+		}},
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	if err := xml.NewEncoder(&buf).Encode(doc); err != nil {
+		return nil, fmt.Errorf("failed to encode feed list: %w", err)
+	}
+	return buf.Bytes(), nil
+}
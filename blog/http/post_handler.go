@@ -0,0 +1,440 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"html/template"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/application"
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/blog/theme"
+	"github.com/dfryer1193/mjolnir/utils/errorx"
+	"github.com/dfryer1193/mjolnir/utils/httpx"
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+)
+
+const postsPerPage = 10
+
+// PostHandler serves the public, themed HTML pages of the blog
+type PostHandler struct {
+	postService *application.PostService
+	theme       *theme.Theme
+}
+
+func NewPostHandler(postService *application.PostService, theme *theme.Theme) *PostHandler {
+	return &PostHandler{
+		postService: postService,
+		theme:       theme,
+	}
+}
+
+func (h *PostHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/", h.HandleIndex)
+	r.Get("/posts/{post}", h.HandlePost)
+	r.Get("/previews/{id}", h.HandlePreview)
+	r.Get(FeedPath, h.HandleFeed)
+	r.Get(SitemapPath, h.HandleSitemap)
+	r.Get(OPMLPath, h.HandleOPML)
+	r.Get("/api/posts", errorx.ErrorHandler(h.HandleListPosts))
+	r.Get("/api/posts/popular", errorx.ErrorHandler(h.HandlePopularPosts))
+	r.Get("/api/posts/{id}/metadata", errorx.ErrorHandler(h.HandlePostMetadata))
+	r.Get("/api/posts/{id}/content", h.HandlePostContent)
+	r.Get("/api/posts/{id}/document", errorx.ErrorHandler(h.HandlePostDocument))
+	r.Get("/api/posts/{id}/find", errorx.ErrorHandler(h.HandleFindInPost))
+	r.Get("/api/archive", errorx.ErrorHandler(h.HandleArchive))
+	r.Get("/api/archive/{year}/{month}", errorx.ErrorHandler(h.HandleArchiveMonth))
+	r.Get("/api/signing-key", errorx.ErrorHandler(h.HandleSigningKey))
+	r.Handle("/static/*", http.StripPrefix("/static/", h.theme.StaticHandler()))
+}
+
+func (h *PostHandler) HandleIndex(w http.ResponseWriter, r *http.Request) {
+	page := 1
+	if p := r.URL.Query().Get("page"); p != "" {
+		parsed, err := strconv.Atoi(p)
+		if err != nil || parsed < 1 {
+			http.Error(w, "Invalid page", http.StatusBadRequest)
+			return
+		}
+		page = parsed
+	}
+
+	etag := contentETag(h.postService.ContentVersion())
+	lastModified := h.postService.ContentModifiedAt()
+	if notModified(w, r, etag, lastModified) {
+		return
+	}
+
+	indexPage, err := h.indexPage(r.Context(), page)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Failed to list published posts")
+		http.Error(w, "Error listing posts", http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := h.theme.RenderIndex(&buf, indexPage); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Failed to render index page")
+		http.Error(w, "Error rendering page", http.StatusInternalServerError)
+		return
+	}
+
+	writeHTML(w, buf.Bytes(), etag, lastModified)
+}
+
+// indexPage lists a page of published posts, newest first
+func (h *PostHandler) indexPage(ctx context.Context, page int) (*theme.IndexPage, error) {
+	// Fetch one extra post to find out whether there is a next page
+	posts, err := h.postService.ListPublishedPosts(ctx, postsPerPage+1, (page-1)*postsPerPage)
+	if err != nil {
+		return nil, err
+	}
+
+	indexPage := &theme.IndexPage{
+		Site:  h.theme.Site(),
+		Posts: posts,
+	}
+	if len(posts) > postsPerPage {
+		indexPage.Posts = posts[:postsPerPage]
+		indexPage.NextPage = page + 1
+	}
+	if page > 1 {
+		indexPage.PrevPage = page - 1
+	}
+	return indexPage, nil
+}
+
+// postPage shows a post, along with the metadata and scripts it needs
+func (h *PostHandler) postPage(post *domain.Post) *theme.PostPage {
+	site := h.theme.Site()
+	return &theme.PostPage{
+		Site: site,
+		Post: post,
+		// Post HTML is produced by our own markdown renderer, so it is trusted here
+		Content:    template.HTML(post.HTMLContent),
+		Meta:       h.postService.PostMetadata(post, site.BaseURL),
+		HasMath:    application.HasMath(post.HTMLContent),
+		HasMermaid: application.HasMermaid(post.HTMLContent),
+	}
+}
+
+// HandlePost serves a published post in the theme at /posts/{slug}, redirecting /posts/{id} and old slugs there
+// Its validators only change with the post, so clients polling it are answered with 304 while other posts change.
+func (h *PostHandler) HandlePost(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "post")
+
+	post, err := h.postService.GetPublishedPostBySlug(r.Context(), id)
+	if errors.Is(err, domain.ErrPostNotFound) {
+		post, err = h.postService.GetPublishedPost(r.Context(), id)
+	}
+	if errors.Is(err, domain.ErrPostNotFound) {
+		// A slug the post had before its file was renamed
+		post, err = h.postService.GetRedirectTarget(r.Context(), id)
+	}
+	if errors.Is(err, domain.ErrPostNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Str("postID", id).Msg("Failed to get post")
+		http.Error(w, "Error loading post", http.StatusInternalServerError)
+		return
+	}
+
+	// Links from before the post had its slug keep working
+	if id != post.Slug && (post.Slug != "" || id != post.ID) {
+		target := post.URLPath()
+		if r.URL.RawQuery != "" {
+			target += "?" + r.URL.RawQuery
+		}
+		http.Redirect(w, r, target, http.StatusMovedPermanently)
+		return
+	}
+
+	// A reader revalidating a cached page has still viewed it
+	h.postService.RecordPostView(post.ID)
+
+	// Advertise where other sites can send mentions of the post
+	if webmention, pingback := h.postService.WebmentionEndpoints(); webmention != "" {
+		w.Header().Add("Link", "<"+webmention+`>; rel="webmention"`)
+		w.Header().Set("X-Pingback", pingback)
+	}
+
+	etag := postETag(h.postService.PostVersion(post))
+	lastModified := h.postService.PostModifiedAt(post)
+	if notModified(w, r, etag, lastModified) {
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := h.theme.RenderPost(&buf, h.postPage(post)); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Str("postID", id).Msg("Failed to render post page")
+		http.Error(w, "Error rendering page", http.StatusInternalServerError)
+		return
+	}
+
+	writeHTML(w, buf.Bytes(), etag, lastModified)
+}
+
+// HandlePreview serves a draft through the signed link posted on its pull request
+// Previews are never cached or indexed, since the draft changes with every push.
+func (h *PostHandler) HandlePreview(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	post, err := h.postService.GetPreviewPost(r.Context(), id, r.URL.Query().Get("token"))
+	if errors.Is(err, domain.ErrPostNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Str("postID", id).Msg("Failed to get preview")
+		http.Error(w, "Error loading preview", http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := h.theme.RenderPost(&buf, h.postPage(post)); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Str("postID", id).Msg("Failed to render preview page")
+		http.Error(w, "Error rendering page", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Robots-Tag", "noindex")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+type postMetadataResponse struct {
+	Title          string `json:"title"`
+	Description    string `json:"description"`
+	URL            string `json:"url"`
+	Image          string `json:"image,omitempty"`
+	TwitterCard    string `json:"twitter_card"`
+	WordCount      int    `json:"word_count,omitempty"`
+	ReadingMinutes int    `json:"reading_minutes,omitempty"`
+}
+
+// HandlePostMetadata returns a published post's link preview metadata, for frontends that render pages themselves
+func (h *PostHandler) HandlePostMetadata(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	post, err := h.postService.GetPublishedPost(r.Context(), chi.URLParam(r, "id"))
+	if errors.Is(err, domain.ErrPostNotFound) {
+		return errorx.NewApiError(err, http.StatusNotFound)
+	}
+	if err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	meta := h.postService.PostMetadata(post, h.theme.Site().BaseURL)
+	resp := postMetadataResponse{
+		Title:          meta.Title,
+		Description:    meta.Description,
+		URL:            meta.URL,
+		Image:          meta.Image,
+		TwitterCard:    meta.TwitterCard,
+		WordCount:      post.WordCount,
+		ReadingMinutes: post.ReadingMinutes,
+	}
+
+	if err := httpx.RespondJSON(w, r, http.StatusOK, resp); err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	return nil
+}
+
+// HandlePostContent serves a published post's stored HTML without the theme, along with its signature
+// The signature in X-Content-Signature covers exactly the response body.
+func (h *PostHandler) HandlePostContent(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	post, err := h.postService.GetPublishedPost(r.Context(), id)
+	if errors.Is(err, domain.ErrPostNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Str("postID", id).Msg("Failed to get post")
+		http.Error(w, "Error loading post", http.StatusInternalServerError)
+		return
+	}
+
+	etag := postETag(h.postService.PostVersion(post))
+	lastModified := h.postService.PostModifiedAt(post)
+	if notModified(w, r, etag, lastModified) {
+		return
+	}
+
+	if post.Signature != "" {
+		w.Header().Set("X-Content-Signature", "ed25519="+post.Signature)
+	}
+	writeHTML(w, post.HTMLContent, etag, lastModified)
+}
+
+type documentNodeResponse struct {
+	Type      string                 `json:"type"`
+	Text      string                 `json:"text,omitempty"`
+	LineBreak bool                   `json:"line_break,omitempty"`
+	Level     int                    `json:"level,omitempty"`
+	ID        string                 `json:"id,omitempty"`
+	Language  string                 `json:"language,omitempty"`
+	URL       string                 `json:"url,omitempty"`
+	Title     string                 `json:"title,omitempty"`
+	Ordered   bool                   `json:"ordered,omitempty"`
+	Start     int                    `json:"start,omitempty"`
+	Checked   bool                   `json:"checked,omitempty"`
+	Align     string                 `json:"align,omitempty"`
+	Display   bool                   `json:"display,omitempty"`
+	Children  []documentNodeResponse `json:"children,omitempty"`
+}
+
+func newDocumentNodeResponses(nodes []*domain.DocumentNode) []documentNodeResponse {
+	resp := make([]documentNodeResponse, 0, len(nodes))
+	for _, n := range nodes {
+		resp = append(resp, documentNodeResponse{
+			Type:      n.Type,
+			Text:      n.Text,
+			LineBreak: n.LineBreak,
+			Level:     n.Level,
+			ID:        n.ID,
+			Language:  n.Language,
+			URL:       n.URL,
+			Title:     n.Title,
+			Ordered:   n.Ordered,
+			Start:     n.Start,
+			Checked:   n.Checked,
+			Align:     n.Align,
+			Display:   n.Display,
+			Children:  newDocumentNodeResponses(n.Children),
+		})
+	}
+	return resp
+}
+
+type postDocumentResponse struct {
+	ID             string                 `json:"id"`
+	Title          string                 `json:"title"`
+	Language       string                 `json:"language,omitempty"`
+	PublishedAt    time.Time              `json:"published_at"`
+	UpdatedAt      time.Time              `json:"updated_at"`
+	WordCount      int                    `json:"word_count,omitempty"`
+	ReadingMinutes int                    `json:"reading_minutes,omitempty"`
+	Blocks         []documentNodeResponse `json:"blocks"`
+}
+
+// HandlePostDocument serves the structure of a published post as JSON, for frontends that don't use its HTML
+// It shares the validators of the post's other representations, since they all change together.
+func (h *PostHandler) HandlePostDocument(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	post, err := h.postService.GetPublishedPostDocument(r.Context(), chi.URLParam(r, "id"))
+	if errors.Is(err, domain.ErrPostNotFound) || errors.Is(err, application.ErrNoDocument) {
+		return errorx.NewApiError(err, http.StatusNotFound)
+	}
+	if err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	etag := postETag(h.postService.PostVersion(post))
+	lastModified := h.postService.PostModifiedAt(post)
+	if notModified(w, r, etag, lastModified) {
+		return nil
+	}
+
+	resp := postDocumentResponse{
+		ID:             post.ID,
+		Title:          post.Title,
+		Language:       post.Language,
+		PublishedAt:    post.PublishedAt,
+		UpdatedAt:      post.UpdatedAt,
+		WordCount:      post.WordCount,
+		ReadingMinutes: post.ReadingMinutes,
+		Blocks:         newDocumentNodeResponses(post.Document.Blocks),
+	}
+
+	setPageCacheHeaders(w, etag, lastModified)
+	if err := httpx.RespondJSON(w, r, http.StatusOK, resp); err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	return nil
+}
+
+type postMatchResponse struct {
+	Block  int      `json:"block"`
+	Anchor string   `json:"anchor,omitempty"`
+	Text   string   `json:"text"`
+	Ranges [][2]int `json:"ranges"`
+}
+
+type findInPostResponse struct {
+	PostID  string              `json:"post_id"`
+	Query   string              `json:"query"`
+	Total   int                 `json:"total"`
+	Matches []postMatchResponse `json:"matches"`
+}
+
+// HandleFindInPost returns the paragraphs and other blocks of a published post that contain ?q=, ignoring case
+// Each match carries the ID of the heading it falls under, so a page can scroll to it.
+func (h *PostHandler) HandleFindInPost(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	result, err := h.postService.FindInPost(r.Context(), chi.URLParam(r, "id"), r.URL.Query().Get("q"))
+	if errors.Is(err, application.ErrInvalidQuery) {
+		return errorx.BadRequestErr(err)
+	}
+	if errors.Is(err, domain.ErrPostNotFound) {
+		return errorx.NewApiError(err, http.StatusNotFound)
+	}
+	if err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	resp := findInPostResponse{
+		PostID:  result.PostID,
+		Query:   result.Query,
+		Total:   result.Total,
+		Matches: make([]postMatchResponse, 0, len(result.Matches)),
+	}
+	for _, m := range result.Matches {
+		resp.Matches = append(resp.Matches, postMatchResponse{Block: m.Block, Anchor: m.Anchor, Text: m.Text, Ranges: m.Ranges})
+	}
+
+	if err := httpx.RespondJSON(w, r, http.StatusOK, resp); err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	return nil
+}
+
+type signingKeyResponse struct {
+	Algorithm string `json:"algorithm"`
+	PublicKey string `json:"public_key"`
+}
+
+// HandleSigningKey returns the public key that post content signatures can be checked with
+func (h *PostHandler) HandleSigningKey(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	key := h.postService.SigningPublicKey()
+	if key == nil {
+		return errorx.NewApiError(errors.New("content signing is disabled"), http.StatusNotFound)
+	}
+
+	resp := signingKeyResponse{
+		Algorithm: "ed25519",
+		PublicKey: base64.StdEncoding.EncodeToString(key),
+	}
+	if err := httpx.RespondJSON(w, r, http.StatusOK, resp); err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	return nil
+}
+
+func writeHTML(w http.ResponseWriter, content []byte, etag string, lastModified time.Time) {
+	setPageCacheHeaders(w, etag, lastModified)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(content)
+}
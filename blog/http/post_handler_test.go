@@ -0,0 +1,42 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+)
+
+func TestPostDetailResponses_ReadingTime(t *testing.T) {
+	published := time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)
+	r := newTestPostRouter(t, &domain.Post{
+		ID:             "001",
+		Title:          "Long read",
+		HTMLPath:       "001.html",
+		HTMLContent:    []byte("<p>Words</p>"),
+		CreatedAt:      published,
+		PublishedAt:    published,
+		WordCount:      1200,
+		ReadingMinutes: 6,
+		Document:       &domain.Document{Blocks: []*domain.DocumentNode{{Type: "paragraph"}}},
+	})
+
+	for _, target := range []string{"/api/posts/001/metadata", "/api/posts/001/document"} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s: status = %d, want %d", target, rec.Code, http.StatusOK)
+		}
+
+		var body map[string]any
+		if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+			t.Fatalf("GET %s: failed to decode response: %v", target, err)
+		}
+		if body["word_count"] != float64(1200) || body["reading_minutes"] != float64(6) {
+			t.Errorf("GET %s: word_count = %v, reading_minutes = %v, want 1200 and 6", target, body["word_count"], body["reading_minutes"])
+		}
+	}
+}
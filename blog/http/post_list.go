@@ -0,0 +1,153 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/application"
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/mjolnir/utils/errorx"
+	"github.com/dfryer1193/mjolnir/utils/httpx"
+)
+
+type postSummaryResponse struct {
+	ID             string    `json:"id"`
+	Title          string    `json:"title"`
+	Snippet        string    `json:"snippet"`
+	URL            string    `json:"url"`
+	PublishedAt    time.Time `json:"published_at"`
+	WordCount      int       `json:"word_count,omitempty"`
+	ReadingMinutes int       `json:"reading_minutes,omitempty"`
+}
+
+// defaultPopularWindow and maxPopularWindow are in days
+const (
+	defaultPopularWindow = 30
+	maxPopularWindow     = 365
+)
+
+type popularPostResponse struct {
+	postSummaryResponse
+	Views int `json:"views"`
+}
+
+type postListResponse struct {
+	Posts      []postSummaryResponse `json:"posts"`
+	Total      int                   `json:"total"`
+	NextCursor string                `json:"next_cursor,omitempty"`
+}
+
+func newPostSummaryResponse(post *domain.Post) postSummaryResponse {
+	return postSummaryResponse{
+		ID:             post.ID,
+		Title:          post.Title,
+		Snippet:        post.Snippet,
+		URL:            post.URLPath(),
+		PublishedAt:    post.PublishedAt,
+		WordCount:      post.WordCount,
+		ReadingMinutes: post.ReadingMinutes,
+	}
+}
+
+// HandleListPosts lists published posts, newest first, a page of ?limit= at a time
+// Pages after the first are fetched with the ?cursor= from the previous page's next_cursor or Link header.
+func (h *PostHandler) HandleListPosts(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	limit, err := queryInt(r, "limit")
+	if err != nil {
+		return errorx.BadRequestErr(err)
+	}
+	if limit < 0 {
+		return errorx.BadRequestErr(errors.New("limit must not be negative"))
+	}
+	cursor := r.URL.Query().Get("cursor")
+
+	etag := contentETag(h.postService.ContentVersion())
+	lastModified := h.postService.ContentModifiedAt()
+	if notModified(w, r, etag, lastModified) {
+		return nil
+	}
+
+	page, err := h.postService.ListPublishedPostsPage(r.Context(), limit, cursor)
+	if errors.Is(err, application.ErrInvalidCursor) {
+		return errorx.BadRequestErr(err)
+	}
+	if err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	resp := postListResponse{
+		Posts:      make([]postSummaryResponse, 0, len(page.Posts)),
+		Total:      page.Total,
+		NextCursor: page.NextCursor,
+	}
+	for _, post := range page.Posts {
+		resp.Posts = append(resp.Posts, newPostSummaryResponse(post))
+	}
+
+	if page.NextCursor != "" {
+		query := url.Values{"cursor": {page.NextCursor}}
+		if limit > 0 {
+			query.Set("limit", strconv.Itoa(limit))
+		}
+		w.Header().Add("Link", fmt.Sprintf(`<%s?%s>; rel="next"`, r.URL.Path, query.Encode()))
+	}
+	w.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+
+	setPageCacheHeaders(w, etag, lastModified)
+	if err := httpx.RespondJSON(w, r, http.StatusOK, resp); err != nil {
+		return errorx.InternalServerErr(err)
+	}
+	return nil
+}
+
+// HandlePopularPosts lists the published posts most viewed over ?window= days, such as 7d, up to ?limit=
+// Views are written in batches, so the latest take a few seconds to count.
+func (h *PostHandler) HandlePopularPosts(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	limit, err := queryInt(r, "limit")
+	if err != nil {
+		return errorx.BadRequestErr(err)
+	}
+	if limit < 0 {
+		return errorx.BadRequestErr(errors.New("limit must not be negative"))
+	}
+	days, err := parsePopularWindow(r.URL.Query().Get("window"))
+	if err != nil {
+		return errorx.BadRequestErr(err)
+	}
+
+	popular, err := h.postService.PopularPosts(r.Context(), days, limit)
+	if err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	resp := make([]popularPostResponse, 0, len(popular))
+	for _, p := range popular {
+		resp = append(resp, popularPostResponse{postSummaryResponse: newPostSummaryResponse(p.Post), Views: p.Views})
+	}
+
+	// Counts change with every view, so they aren't validated like pages, only cached briefly
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	if err := httpx.RespondJSON(w, r, http.StatusOK, resp); err != nil {
+		return errorx.InternalServerErr(err)
+	}
+	return nil
+}
+
+// parsePopularWindow reads a window of whole days written like 30d, defaulting to defaultPopularWindow
+func parsePopularWindow(window string) (int, error) {
+	if window == "" {
+		return defaultPopularWindow, nil
+	}
+
+	digits, found := strings.CutSuffix(window, "d")
+	days, err := strconv.Atoi(digits)
+	if !found || err != nil || days < 1 || days > maxPopularWindow {
+		return 0, fmt.Errorf("window must be between 1d and %dd, got %q", maxPopularWindow, window)
+	}
+	return days, nil
+}
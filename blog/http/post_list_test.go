@@ -0,0 +1,140 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/application"
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/blog/persistence"
+	"github.com/dfryer1193/goblog/blog/theme"
+	"github.com/dfryer1193/goblog/shared/blob"
+	"github.com/go-chi/chi/v5"
+)
+
+// newTestPostRouter serves the post routes from a fresh database holding posts
+func newTestPostRouter(t *testing.T, posts ...*domain.Post) chi.Router {
+	t.Helper()
+	db := newTestDB(t)
+	blobs := blob.NewLocalStore(t.TempDir())
+	postRepo := persistence.NewPostRepository(db, persistence.WithBlobStore(blobs))
+	imageRepo := persistence.NewImageRepository(db, persistence.WithBlobStore(blobs))
+
+	for _, post := range posts {
+		if err := postRepo.SavePost(context.Background(), post); err != nil {
+			t.Fatalf("SavePost(%s) failed: %v", post.ID, err)
+		}
+	}
+
+	postService := application.NewPostService(postRepo, imageRepo, nil, nil, "main")
+	t.Cleanup(func() { postService.Close() })
+
+	blogTheme, err := theme.Load(&theme.ThemeConfig{SiteTitle: "Test Blog", BaseURL: "https://blog.example"})
+	if err != nil {
+		t.Fatalf("failed to load theme: %v", err)
+	}
+
+	r := chi.NewRouter()
+	NewPostHandler(postService, blogTheme).RegisterRoutes(r)
+	return r
+}
+
+func TestHandleListPosts(t *testing.T) {
+	published := time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)
+	var posts []*domain.Post
+	for i := range 3 {
+		id := fmt.Sprintf("%03d", i+1)
+		posts = append(posts, &domain.Post{
+			ID:             id,
+			Title:          "Post " + id,
+			HTMLPath:       id + ".html",
+			CreatedAt:      published,
+			PublishedAt:    published.Add(time.Duration(i) * time.Hour),
+			WordCount:      400 * (i + 1),
+			ReadingMinutes: 2 * (i + 1),
+		})
+	}
+	r := newTestPostRouter(t, posts...)
+
+	get := func(target string, header http.Header) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+	decode := func(rec *httptest.ResponseRecorder) postListResponse {
+		t.Helper()
+		var resp postListResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return resp
+	}
+
+	first := get("/api/posts?limit=2", nil)
+	if first.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", first.Code, http.StatusOK)
+	}
+	if got := first.Header().Get("X-Total-Count"); got != strconv.Itoa(len(posts)) {
+		t.Errorf("X-Total-Count = %q, want %q", got, strconv.Itoa(len(posts)))
+	}
+	page := decode(first)
+	if len(page.Posts) != 2 || page.Posts[0].ID != "003" || page.Posts[1].ID != "002" || page.Total != 3 {
+		t.Fatalf("first page = %+v, want posts 003 and 002 of 3", page)
+	}
+	if page.Posts[0].WordCount != 1200 || page.Posts[0].ReadingMinutes != 6 {
+		t.Errorf("post 003 word_count = %d, reading_minutes = %d, want 1200 and 6", page.Posts[0].WordCount, page.Posts[0].ReadingMinutes)
+	}
+
+	next := regexp.MustCompile(`^<(/api/posts\?[^>]+)>; rel="next"$`).FindStringSubmatch(first.Header().Get("Link"))
+	if next == nil {
+		t.Fatalf("Link = %q, want a next page", first.Header().Get("Link"))
+	}
+
+	second := get(next[1], nil)
+	if second.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", second.Code, http.StatusOK)
+	}
+	if got := second.Header().Get("Link"); got != "" {
+		t.Errorf("Link = %q on the last page, want none", got)
+	}
+	page = decode(second)
+	if len(page.Posts) != 1 || page.Posts[0].ID != "001" || page.NextCursor != "" {
+		t.Fatalf("second page = %+v, want only post 001", page)
+	}
+
+	etag := first.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("ETag is missing")
+	}
+	if rec := get("/api/posts?limit=2", http.Header{"If-None-Match": {etag}}); rec.Code != http.StatusNotModified {
+		t.Errorf("status with a current ETag = %d, want %d", rec.Code, http.StatusNotModified)
+	}
+}
+
+func TestHandleListPosts_BadRequest(t *testing.T) {
+	r := newTestPostRouter(t)
+
+	for _, target := range []string{
+		"/api/posts?limit=-1",
+		"/api/posts?limit=ten",
+		"/api/posts?cursor=not-a-cursor",
+	} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("GET %s: status = %d, want %d", target, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/application"
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/mjolnir/utils/errorx"
+	"github.com/dfryer1193/mjolnir/utils/httpx"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	// readerTokenCookie holds the token a reader's preferences are stored under
+	readerTokenCookie = "goblog_reader"
+	// maxPreferencesBody bounds a preferences request, which only carries a few short strings
+	maxPreferencesBody = 4 << 10
+)
+
+// ReaderPreferencesHandler lets anonymous readers keep their display settings on the server
+// The frontend reads them on load and saves them when the reader changes a setting. A reader can
+// carry the token to another device and restore it there.
+type ReaderPreferencesHandler struct {
+	preferences *application.ReaderPreferencesService
+}
+
+func NewReaderPreferencesHandler(preferences *application.ReaderPreferencesService) *ReaderPreferencesHandler {
+	return &ReaderPreferencesHandler{
+		preferences: preferences,
+	}
+}
+
+func (h *ReaderPreferencesHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/api/preferences", func(r chi.Router) {
+		r.Get("/", errorx.ErrorHandler(h.HandleGetPreferences))
+		r.Put("/", errorx.ErrorHandler(h.HandleSavePreferences))
+		r.Delete("/", errorx.ErrorHandler(h.HandleDeletePreferences))
+		r.Post("/restore", errorx.ErrorHandler(h.HandleRestorePreferences))
+	})
+}
+
+type readerPreferencesRequest struct {
+	Theme    string `json:"theme"`
+	FontSize string `json:"font_size"`
+}
+
+type restorePreferencesRequest struct {
+	Token string `json:"token"`
+}
+
+type readerPreferencesResponse struct {
+	Token     string    `json:"token"`
+	Theme     string    `json:"theme"`
+	FontSize  string    `json:"font_size"`
+	UpdatedAt time.Time `json:"updated_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// HandleGetPreferences returns the preferences saved under the reader's cookie, or 404 when there are none
+func (h *ReaderPreferencesHandler) HandleGetPreferences(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	token := readerToken(r)
+	prefs, err := h.preferences.Get(r.Context(), token)
+	if errors.Is(err, domain.ErrReaderPreferencesNotFound) {
+		clearReaderToken(w, r)
+		return errorx.NewApiError(err, http.StatusNotFound)
+	}
+	if err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	// Reading the preferences extended them, so the cookie is extended to match
+	return h.respond(w, r, http.StatusOK, token, prefs)
+}
+
+// HandleSavePreferences replaces the reader's preferences, issuing a token cookie on the first save
+func (h *ReaderPreferencesHandler) HandleSavePreferences(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	var req readerPreferencesRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxPreferencesBody)).Decode(&req); err != nil {
+		return errorx.BadRequestErr(fmt.Errorf("invalid request body: %w", err))
+	}
+
+	prefs := &domain.ReaderPreferences{Theme: req.Theme, FontSize: req.FontSize}
+	token, err := h.preferences.Save(r.Context(), readerToken(r), prefs)
+	if errors.Is(err, application.ErrInvalidPreferences) {
+		return errorx.BadRequestErr(err)
+	}
+	if err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	return h.respond(w, r, http.StatusOK, token, prefs)
+}
+
+// HandleDeletePreferences forgets the reader's preferences and their cookie
+func (h *ReaderPreferencesHandler) HandleDeletePreferences(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	err := h.preferences.Delete(r.Context(), readerToken(r))
+	if err != nil && !errors.Is(err, domain.ErrReaderPreferencesNotFound) {
+		return errorx.InternalServerErr(err)
+	}
+
+	clearReaderToken(w, r)
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// HandleRestorePreferences adopts a token from another device, so both share the same preferences
+func (h *ReaderPreferencesHandler) HandleRestorePreferences(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	var req restorePreferencesRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxPreferencesBody)).Decode(&req); err != nil {
+		return errorx.BadRequestErr(fmt.Errorf("invalid request body: %w", err))
+	}
+
+	prefs, err := h.preferences.Get(r.Context(), req.Token)
+	if errors.Is(err, domain.ErrReaderPreferencesNotFound) {
+		return errorx.NewApiError(err, http.StatusNotFound)
+	}
+	if err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	return h.respond(w, r, http.StatusOK, req.Token, prefs)
+}
+
+// respond sets the token cookie for another TTL and writes the preferences
+// The responses are particular to the reader, so shared caches must not keep them.
+func (h *ReaderPreferencesHandler) respond(w http.ResponseWriter, r *http.Request, status int, token string, prefs *domain.ReaderPreferences) *errorx.ApiError {
+	ttl := h.preferences.TTL()
+	http.SetCookie(w, readerTokenCookieFor(r, token, int(ttl.Seconds())))
+	w.Header().Set("Cache-Control", "no-store")
+
+	resp := readerPreferencesResponse{
+		Token:     token,
+		Theme:     prefs.Theme,
+		FontSize:  prefs.FontSize,
+		UpdatedAt: prefs.UpdatedAt,
+		ExpiresAt: prefs.LastSeenAt.Add(ttl),
+	}
+	if err := httpx.RespondJSON(w, r, status, resp); err != nil {
+		return errorx.InternalServerErr(err)
+	}
+	return nil
+}
+
+// readerToken returns the token from the reader's cookie, or empty when there is none
+func readerToken(r *http.Request) string {
+	cookie, err := r.Cookie(readerTokenCookie)
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
+
+// clearReaderToken removes the reader's token cookie, if they sent one
+func clearReaderToken(w http.ResponseWriter, r *http.Request) {
+	if readerToken(r) != "" {
+		http.SetCookie(w, readerTokenCookieFor(r, "", -1))
+	}
+}
+
+// readerTokenCookieFor builds the token cookie, which scripts cannot read and is only sent over HTTPS when the site uses it
+func readerTokenCookieFor(r *http.Request, token string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     readerTokenCookie,
+		Value:    token,
+		Path:     "/api/preferences",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteLaxMode,
+	}
+}
@@ -0,0 +1,82 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// SitemapPath is where the site's sitemap is served
+const SitemapPath = "/sitemap.xml"
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// HandleSitemap lists the home page and every published post for search engines
+func (h *PostHandler) HandleSitemap(w http.ResponseWriter, r *http.Request) {
+	etag := contentETag(h.postService.ContentVersion())
+	lastModified := h.postService.ContentModifiedAt()
+	if notModified(w, r, etag, lastModified) {
+		return
+	}
+
+	sitemap, err := h.sitemap(r.Context())
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Failed to build sitemap")
+		http.Error(w, "Error building sitemap", http.StatusInternalServerError)
+		return
+	}
+
+	setPageCacheHeaders(w, etag, lastModified)
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(sitemap)
+}
+
+// sitemap encodes the URLs of the home page and the published posts, dated by their last change
+func (h *PostHandler) sitemap(ctx context.Context) ([]byte, error) {
+	posts, err := h.postService.PublishedPosts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	baseURL := strings.TrimSuffix(h.theme.Site().BaseURL, "/")
+	urls := sitemapURLSet{URLs: []sitemapURL{{Loc: baseURL + "/"}}}
+	var latest time.Time
+	for _, post := range posts {
+		modified := post.PublishedAt
+		if post.UpdatedAt.After(modified) {
+			modified = post.UpdatedAt
+		}
+		if modified.After(latest) {
+			latest = modified
+		}
+		urls.URLs = append(urls.URLs, sitemapURL{
+			Loc:     baseURL + post.URLPath(),
+			LastMod: modified.UTC().Format(time.RFC3339),
+		})
+	}
+	if !latest.IsZero() {
+		urls.URLs[0].LastMod = latest.UTC().Format(time.RFC3339)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	if err := xml.NewEncoder(&buf).Encode(urls); err != nil {
+		return nil, fmt.Errorf("failed to encode sitemap: %w", err)
+	}
+	return buf.Bytes(), nil
+}
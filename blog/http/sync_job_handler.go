@@ -0,0 +1,154 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/application"
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/mjolnir/utils/errorx"
+	"github.com/dfryer1193/mjolnir/utils/httpx"
+	"github.com/go-chi/chi/v5"
+)
+
+// SyncJobHandler serves the status of webhook push processing
+// Jobs name commits and paths on branches that may not be public, so requests need an API token with the read scope.
+type SyncJobHandler struct {
+	postService *application.PostService
+	auth        *application.AuthService
+}
+
+func NewSyncJobHandler(postService *application.PostService, auth *application.AuthService) *SyncJobHandler {
+	return &SyncJobHandler{
+		postService: postService,
+		auth:        auth,
+	}
+}
+
+func (h *SyncJobHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/api/sync/jobs", func(r chi.Router) {
+		r.Use(RequireScope(h.auth, domain.ScopeRead))
+
+		r.Get("/", errorx.ErrorHandler(h.HandleListJobs))
+		r.Get("/{id}", errorx.ErrorHandler(h.HandleGetJob))
+	})
+}
+
+type syncJobFileResponse struct {
+	Path      string    `json:"path"`
+	CommitSHA string    `json:"commit_sha"`
+	Action    string    `json:"action"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type syncJobResponse struct {
+	ID         int64                 `json:"id"`
+	Ref        string                `json:"ref"`
+	Before     string                `json:"before"`
+	After      string                `json:"after"`
+	Status     string                `json:"status"`
+	Error      string                `json:"error,omitempty"`
+	Files      []syncJobFileResponse `json:"files,omitempty"`
+	CreatedAt  time.Time             `json:"created_at"`
+	FinishedAt *time.Time            `json:"finished_at,omitempty"`
+}
+
+func newSyncJobResponse(job *domain.SyncJob) syncJobResponse {
+	resp := syncJobResponse{
+		ID:        job.ID,
+		Ref:       job.Ref,
+		Before:    job.Before,
+		After:     job.After,
+		Status:    string(job.Status),
+		Error:     job.Error,
+		CreatedAt: job.CreatedAt,
+	}
+
+	if !job.FinishedAt.IsZero() {
+		resp.FinishedAt = &job.FinishedAt
+	}
+
+	for _, f := range job.Files {
+		resp.Files = append(resp.Files, syncJobFileResponse{
+			Path:      f.Path,
+			CommitSHA: f.CommitSHA,
+			Action:    string(f.Action),
+			Status:    string(f.Status),
+			Error:     f.Error,
+			UpdatedAt: f.UpdatedAt,
+		})
+	}
+
+	return resp
+}
+
+// HandleListJobs lists recent sync jobs, newest first
+// Supports ?limit and ?offset query parameters
+func (h *SyncJobHandler) HandleListJobs(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	limit, err := queryInt(r, "limit")
+	if err != nil {
+		return errorx.BadRequestErr(err)
+	}
+	offset, err := queryInt(r, "offset")
+	if err != nil {
+		return errorx.BadRequestErr(err)
+	}
+
+	jobs, err := h.postService.ListSyncJobs(r.Context(), limit, offset)
+	if err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	resp := make([]syncJobResponse, 0, len(jobs))
+	for _, job := range jobs {
+		resp = append(resp, newSyncJobResponse(job))
+	}
+
+	if err := httpx.RespondJSON(w, r, http.StatusOK, resp); err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	return nil
+}
+
+// HandleGetJob returns a single sync job with the status of each file
+func (h *SyncJobHandler) HandleGetJob(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		return errorx.BadRequestErr(fmt.Errorf("invalid job id: %w", err))
+	}
+
+	job, err := h.postService.GetSyncJob(r.Context(), id)
+	if errors.Is(err, domain.ErrSyncJobNotFound) {
+		return errorx.NewApiError(err, http.StatusNotFound)
+	}
+	if err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	if err := httpx.RespondJSON(w, r, http.StatusOK, newSyncJobResponse(job)); err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	return nil
+}
+
+// queryInt parses an optional integer query parameter, returning zero when absent
+func queryInt(r *http.Request, name string) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", name, err)
+	}
+
+	return n, nil
+}
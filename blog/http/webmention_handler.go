@@ -0,0 +1,205 @@
+package http
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/application"
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/mjolnir/utils/errorx"
+	"github.com/dfryer1193/mjolnir/utils/httpx"
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// maxMentionBody bounds a webmention or pingback request, which only carries two URLs
+const maxMentionBody = 16 << 10
+
+// Pingback fault codes, from the Pingback 1.0 specification and the XML-RPC fault interoperability spec
+const (
+	pingbackFaultGeneric         = 0
+	pingbackFaultTargetNotFound  = 32
+	pingbackFaultTargetInvalid   = 33
+	xmlrpcFaultMethodNotFound    = -32601
+	xmlrpcFaultInvalidParameters = -32602
+)
+
+// WebmentionHandler receives webmentions and pingbacks from other sites, and lists the approved ones
+// Mentions are verified in the background, so both endpoints only answer whether a mention was accepted.
+type WebmentionHandler struct {
+	postService *application.PostService
+}
+
+func NewWebmentionHandler(postService *application.PostService) *WebmentionHandler {
+	return &WebmentionHandler{
+		postService: postService,
+	}
+}
+
+func (h *WebmentionHandler) RegisterRoutes(r chi.Router) {
+	r.Post(application.WebmentionPath, errorx.ErrorHandler(h.HandleWebmention))
+	r.Post(application.PingbackPath, h.HandlePingback)
+	r.Get("/api/posts/{id}/mentions", errorx.ErrorHandler(h.HandleListPostMentions))
+}
+
+// HandleWebmention accepts a form-encoded source and target, answering 202 while the source is verified
+func (h *WebmentionHandler) HandleWebmention(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	r.Body = http.MaxBytesReader(w, r.Body, maxMentionBody)
+	if err := r.ParseForm(); err != nil {
+		return errorx.BadRequestErr(fmt.Errorf("invalid form: %w", err))
+	}
+
+	source, target := r.PostForm.Get("source"), r.PostForm.Get("target")
+	if source == "" || target == "" {
+		return errorx.BadRequestErr(errors.New("source and target are required"))
+	}
+
+	err := h.postService.ReceiveWebmention(r.Context(), source, target, domain.ProtocolWebmention)
+	if apiErr := webmentionError(err); apiErr != nil {
+		return apiErr
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	return nil
+}
+
+func webmentionError(err error) *errorx.ApiError {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, application.ErrInvalidWebmention), errors.Is(err, application.ErrWebmentionTargetNotFound):
+		return errorx.BadRequestErr(err)
+	case errors.Is(err, application.ErrWebmentionQueueFull):
+		return errorx.NewApiError(err, http.StatusServiceUnavailable)
+	default:
+		return errorx.InternalServerErr(err)
+	}
+}
+
+type xmlrpcCall struct {
+	MethodName string        `xml:"methodName"`
+	Params     []xmlrpcValue `xml:"params>param>value"`
+}
+
+// xmlrpcValue is a string parameter, which XML-RPC allows to be sent with or without a <string> element
+type xmlrpcValue struct {
+	String *string `xml:"string"`
+	Text   string  `xml:",chardata"`
+}
+
+func (v xmlrpcValue) string() string {
+	if v.String != nil {
+		return strings.TrimSpace(*v.String)
+	}
+	return strings.TrimSpace(v.Text)
+}
+
+// HandlePingback accepts a pingback.ping XML-RPC call, for blogs that don't send webmentions yet
+// XML-RPC reports errors as faults in a 200 response rather than through the status code.
+func (h *WebmentionHandler) HandlePingback(w http.ResponseWriter, r *http.Request) {
+	var call xmlrpcCall
+	if err := xml.NewDecoder(http.MaxBytesReader(w, r.Body, maxMentionBody)).Decode(&call); err != nil {
+		writePingbackFault(w, xmlrpcFaultInvalidParameters, "invalid XML-RPC request")
+		return
+	}
+	if call.MethodName != "pingback.ping" {
+		writePingbackFault(w, xmlrpcFaultMethodNotFound, "unknown method "+call.MethodName)
+		return
+	}
+	if len(call.Params) != 2 {
+		writePingbackFault(w, xmlrpcFaultInvalidParameters, "pingback.ping takes a source and a target")
+		return
+	}
+
+	err := h.postService.ReceiveWebmention(r.Context(), call.Params[0].string(), call.Params[1].string(), domain.ProtocolPingback)
+	switch {
+	case err == nil:
+		writePingbackResponse(w, "Pingback received, and will appear once it is verified and approved")
+	case errors.Is(err, application.ErrWebmentionTargetNotFound):
+		writePingbackFault(w, pingbackFaultTargetNotFound, err.Error())
+	case errors.Is(err, application.ErrInvalidWebmention):
+		writePingbackFault(w, pingbackFaultTargetInvalid, err.Error())
+	case errors.Is(err, application.ErrWebmentionQueueFull):
+		writePingbackFault(w, pingbackFaultGeneric, err.Error())
+	default:
+		log.Ctx(r.Context()).Error().Err(err).Msg("Failed to receive pingback")
+		writePingbackFault(w, pingbackFaultGeneric, "failed to receive pingback")
+	}
+}
+
+func writePingbackResponse(w http.ResponseWriter, message string) {
+	var escaped strings.Builder
+	xml.EscapeText(&escaped, []byte(message))
+	writeXMLRPC(w, `<params><param><value><string>`+escaped.String()+`</string></value></param></params>`)
+}
+
+func writePingbackFault(w http.ResponseWriter, code int, message string) {
+	var escaped strings.Builder
+	xml.EscapeText(&escaped, []byte(message))
+	writeXMLRPC(w, fmt.Sprintf(`<fault><value><struct>`+
+		`<member><name>faultCode</name><value><int>%d</int></value></member>`+
+		`<member><name>faultString</name><value><string>%s</string></value></member>`+
+		`</struct></value></fault>`, code, escaped.String()))
+}
+
+func writeXMLRPC(w http.ResponseWriter, body string) {
+	w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(xml.Header + "<methodResponse>" + body + "</methodResponse>\n"))
+}
+
+type webmentionResponse struct {
+	ID         int64     `json:"id"`
+	Source     string    `json:"source"`
+	Target     string    `json:"target"`
+	PostID     string    `json:"post_id"`
+	Protocol   string    `json:"protocol"`
+	Title      string    `json:"title,omitempty"`
+	Status     string    `json:"status"`
+	ReceivedAt time.Time `json:"received_at"`
+	VerifiedAt time.Time `json:"verified_at"`
+}
+
+func newWebmentionResponses(mentions []*domain.Webmention) []webmentionResponse {
+	resp := make([]webmentionResponse, 0, len(mentions))
+	for _, mention := range mentions {
+		resp = append(resp, webmentionResponse{
+			ID:         mention.ID,
+			Source:     mention.Source,
+			Target:     mention.Target,
+			PostID:     mention.PostID,
+			Protocol:   string(mention.Protocol),
+			Title:      mention.Title,
+			Status:     string(mention.Status),
+			ReceivedAt: mention.ReceivedAt,
+			VerifiedAt: mention.VerifiedAt,
+		})
+	}
+	return resp
+}
+
+// HandleListPostMentions returns the approved mentions of a published post, oldest first
+func (h *WebmentionHandler) HandleListPostMentions(w http.ResponseWriter, r *http.Request) *errorx.ApiError {
+	post, err := h.postService.GetPublishedPost(r.Context(), chi.URLParam(r, "id"))
+	if errors.Is(err, domain.ErrPostNotFound) {
+		return errorx.NewApiError(err, http.StatusNotFound)
+	}
+	if err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	mentions, err := h.postService.PostWebmentions(r.Context(), post.ID)
+	if err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	if err := httpx.RespondJSON(w, r, http.StatusOK, newWebmentionResponses(mentions)); err != nil {
+		return errorx.InternalServerErr(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,215 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/clock"
+	"github.com/dfryer1193/goblog/shared/db"
+)
+
+var _ domain.APITokenRepository = (*SQLiteAPITokenRepository)(nil)
+
+// SQLiteAPITokenRepository implements domain.APITokenRepository using SQL database (SQLite)
+type SQLiteAPITokenRepository struct {
+	db    *sql.DB
+	clock clock.Clock
+}
+
+// NewAPITokenRepository creates a new SQLiteAPITokenRepository from a standard sql.DB
+func NewAPITokenRepository(db *sql.DB, opts ...Option) *SQLiteAPITokenRepository {
+	o := newOptions(opts)
+	return &SQLiteAPITokenRepository{
+		db:    db,
+		clock: o.clock,
+	}
+}
+
+// apiTokenColumns lists the columns read by apiTokenRow.scan, in scan order
+const apiTokenColumns = `id, name, scopes, created_at, last_used_at, revoked_at`
+
+const insertAPITokenQuery = `
+	INSERT INTO api_tokens (name, token_hash, scopes, created_at)
+	VALUES (?, ?, ?, ?)
+`
+
+// CreateToken stores a new token under the hash of its secret and assigns its ID
+func (r *SQLiteAPITokenRepository) CreateToken(ctx context.Context, token *domain.APIToken, hash string) error {
+	if token == nil {
+		return fmt.Errorf("api token cannot be nil")
+	}
+
+	if hash == "" {
+		return fmt.Errorf("api token hash cannot be empty")
+	}
+
+	if token.CreatedAt.IsZero() {
+		token.CreatedAt = r.clock.Now().UTC()
+	}
+
+	executor := db.GetExecutor(ctx, r.db)
+	result, err := executor.ExecContext(ctx, insertAPITokenQuery, token.Name, hash, joinScopes(token.Scopes), token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert api token: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get api token ID: %w", err)
+	}
+	token.ID = id
+
+	return nil
+}
+
+const getAPITokenByHashQuery = `
+	SELECT ` + apiTokenColumns + `
+	FROM api_tokens
+	WHERE token_hash = ? AND revoked_at IS NULL
+`
+
+// GetTokenByHash returns the active token with the given hash
+func (r *SQLiteAPITokenRepository) GetTokenByHash(ctx context.Context, hash string) (*domain.APIToken, error) {
+	var row apiTokenRow
+	err := row.scan(r.db.QueryRowContext(ctx, getAPITokenByHashQuery, hash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrAPITokenNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api token: %w", err)
+	}
+
+	return row.toDomain(), nil
+}
+
+const listAPITokensQuery = `
+	SELECT ` + apiTokenColumns + `
+	FROM api_tokens
+	ORDER BY id DESC
+`
+
+// ListTokens returns every token, including revoked ones, newest first
+func (r *SQLiteAPITokenRepository) ListTokens(ctx context.Context) ([]*domain.APIToken, error) {
+	rows, err := r.db.QueryContext(ctx, listAPITokensQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api tokens: %w", err)
+	}
+	defer rows.Close()
+
+	tokens := make([]*domain.APIToken, 0)
+	for rows.Next() {
+		var row apiTokenRow
+		if err := row.scan(rows); err != nil {
+			return nil, fmt.Errorf("failed to scan api token row: %w", err)
+		}
+		tokens = append(tokens, row.toDomain())
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating api token rows: %w", err)
+	}
+
+	return tokens, nil
+}
+
+const revokeAPITokenQuery = `
+	UPDATE api_tokens
+	SET revoked_at = ?
+	WHERE id = ? AND revoked_at IS NULL
+`
+
+// RevokeToken stops a token from authenticating
+func (r *SQLiteAPITokenRepository) RevokeToken(ctx context.Context, id int64) error {
+	executor := db.GetExecutor(ctx, r.db)
+	result, err := executor.ExecContext(ctx, revokeAPITokenQuery, r.clock.Now().UTC(), id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke api token: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check revoked api token: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%w: %d", domain.ErrAPITokenNotFound, id)
+	}
+
+	return nil
+}
+
+const touchAPITokenQuery = `
+	UPDATE api_tokens SET last_used_at = ? WHERE id = ?
+`
+
+// TouchToken records that a token was just used
+func (r *SQLiteAPITokenRepository) TouchToken(ctx context.Context, id int64) error {
+	executor := db.GetExecutor(ctx, r.db)
+	if _, err := executor.ExecContext(ctx, touchAPITokenQuery, r.clock.Now().UTC(), id); err != nil {
+		return fmt.Errorf("failed to record api token use: %w", err)
+	}
+
+	return nil
+}
+
+func joinScopes(scopes []domain.Scope) string {
+	parts := make([]string, len(scopes))
+	for i, s := range scopes {
+		parts[i] = string(s)
+	}
+	return strings.Join(parts, ",")
+}
+
+func splitScopes(value string) []domain.Scope {
+	var scopes []domain.Scope
+	for part := range strings.SplitSeq(value, ",") {
+		if part != "" {
+			scopes = append(scopes, domain.Scope(part))
+		}
+	}
+	return scopes
+}
+
+// apiTokenRow is a private struct used to scan api token rows
+type apiTokenRow struct {
+	ID         int64        `db:"id"`
+	Name       string       `db:"name"`
+	Scopes     string       `db:"scopes"`
+	CreatedAt  sql.NullTime `db:"created_at"`
+	LastUsedAt sql.NullTime `db:"last_used_at"`
+	RevokedAt  sql.NullTime `db:"revoked_at"`
+}
+
+func (tr *apiTokenRow) scan(s rowScanner) error {
+	return s.Scan(
+		&tr.ID,
+		&tr.Name,
+		&tr.Scopes,
+		&tr.CreatedAt,
+		&tr.LastUsedAt,
+		&tr.RevokedAt,
+	)
+}
+
+func (tr *apiTokenRow) toDomain() *domain.APIToken {
+	token := &domain.APIToken{
+		ID:     tr.ID,
+		Name:   tr.Name,
+		Scopes: splitScopes(tr.Scopes),
+	}
+
+	if tr.CreatedAt.Valid {
+		token.CreatedAt = tr.CreatedAt.Time
+	}
+	if tr.LastUsedAt.Valid {
+		token.LastUsedAt = tr.LastUsedAt.Time
+	}
+	if tr.RevokedAt.Valid {
+		token.RevokedAt = tr.RevokedAt.Time
+	}
+
+	return token
+}
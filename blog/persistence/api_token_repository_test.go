@@ -0,0 +1,59 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+)
+
+func TestAPITokenRepository_CreateAndRevoke(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewAPITokenRepository(db)
+	ctx := context.Background()
+
+	token := &domain.APIToken{Name: "deploy", Scopes: []domain.Scope{domain.ScopeRead, domain.ScopeAdmin}}
+	if err := repo.CreateToken(ctx, token, "hash-1"); err != nil {
+		t.Fatalf("Failed to create token: %v", err)
+	}
+	if token.ID == 0 {
+		t.Fatal("Expected token ID to be assigned")
+	}
+
+	found, err := repo.GetTokenByHash(ctx, "hash-1")
+	if err != nil {
+		t.Fatalf("Failed to get token: %v", err)
+	}
+	if found.Name != "deploy" || !slices.Equal(found.Scopes, token.Scopes) {
+		t.Errorf("Unexpected token: %+v", found)
+	}
+
+	if err := repo.TouchToken(ctx, token.ID); err != nil {
+		t.Fatalf("Failed to touch token: %v", err)
+	}
+
+	if err := repo.RevokeToken(ctx, token.ID); err != nil {
+		t.Fatalf("Failed to revoke token: %v", err)
+	}
+	if _, err := repo.GetTokenByHash(ctx, "hash-1"); !errors.Is(err, domain.ErrAPITokenNotFound) {
+		t.Errorf("Expected revoked token to be not found, got %v", err)
+	}
+	if err := repo.RevokeToken(ctx, token.ID); !errors.Is(err, domain.ErrAPITokenNotFound) {
+		t.Errorf("Expected revoking twice to fail with ErrAPITokenNotFound, got %v", err)
+	}
+
+	tokens, err := repo.ListTokens(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list tokens: %v", err)
+	}
+	if len(tokens) != 1 {
+		t.Fatalf("Expected 1 token, got %d", len(tokens))
+	}
+	if tokens[0].RevokedAt.IsZero() || tokens[0].LastUsedAt.IsZero() {
+		t.Errorf("Expected revoked and last used times to be set: %+v", tokens[0])
+	}
+}
@@ -0,0 +1,188 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/clock"
+	"github.com/dfryer1193/goblog/shared/db"
+)
+
+var _ domain.DeadLetterRepository = (*SQLiteDeadLetterRepository)(nil)
+
+// SQLiteDeadLetterRepository implements domain.DeadLetterRepository using SQL database (SQLite)
+type SQLiteDeadLetterRepository struct {
+	db    *sql.DB
+	clock clock.Clock
+}
+
+// NewDeadLetterRepository creates a new SQLiteDeadLetterRepository from a standard sql.DB
+func NewDeadLetterRepository(db *sql.DB, opts ...Option) *SQLiteDeadLetterRepository {
+	o := newOptions(opts)
+	return &SQLiteDeadLetterRepository{
+		db:    db,
+		clock: o.clock,
+	}
+}
+
+// upsertDeadLetterQuery counts failures at the same ref separately, and lifts the quarantine of a file
+// when another version of it fails
+const upsertDeadLetterQuery = `
+	INSERT INTO dead_letters (path, ref, error, attempts, ref_attempts, first_failed_at, last_failed_at)
+	VALUES (?, ?, ?, 1, 1, ?, ?)
+	ON CONFLICT(path) DO UPDATE SET
+		error = excluded.error,
+		attempts = dead_letters.attempts + 1,
+		ref_attempts = CASE WHEN dead_letters.ref = excluded.ref THEN dead_letters.ref_attempts + 1 ELSE 1 END,
+		poisoned_at = CASE WHEN dead_letters.ref = excluded.ref THEN dead_letters.poisoned_at ELSE NULL END,
+		ref = excluded.ref,
+		last_failed_at = excluded.last_failed_at
+`
+
+// RecordFailure adds a file to the dead-letter list, counting repeated failures
+func (r *SQLiteDeadLetterRepository) RecordFailure(ctx context.Context, path string, ref string, fileErr error) error {
+	if path == "" {
+		return fmt.Errorf("dead letter path cannot be empty")
+	}
+
+	errMsg := "unknown error"
+	if fileErr != nil {
+		errMsg = fileErr.Error()
+	}
+
+	now := r.clock.Now().UTC()
+	executor := db.GetExecutor(ctx, r.db)
+	if _, err := executor.ExecContext(ctx, upsertDeadLetterQuery, path, ref, errMsg, now, now); err != nil {
+		return fmt.Errorf("failed to record dead letter: %w", err)
+	}
+
+	return nil
+}
+
+const deleteDeadLetterQuery = `
+	DELETE FROM dead_letters WHERE path = ?
+`
+
+// Resolve removes a file from the dead-letter list
+func (r *SQLiteDeadLetterRepository) Resolve(ctx context.Context, path string) error {
+	executor := db.GetExecutor(ctx, r.db)
+	if _, err := executor.ExecContext(ctx, deleteDeadLetterQuery, path); err != nil {
+		return fmt.Errorf("failed to resolve dead letter: %w", err)
+	}
+
+	return nil
+}
+
+const getDeadLetterQuery = `
+	SELECT ` + deadLetterColumns + `
+	FROM dead_letters
+	WHERE path = ?
+`
+
+// GetDeadLetter returns the dead-letter entry of a file
+func (r *SQLiteDeadLetterRepository) GetDeadLetter(ctx context.Context, path string) (*domain.DeadLetter, error) {
+	row := db.GetExecutor(ctx, r.db).QueryRowContext(ctx, getDeadLetterQuery, path)
+	dl, err := scanDeadLetter(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", domain.ErrDeadLetterNotFound, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dead letter: %w", err)
+	}
+
+	return dl, nil
+}
+
+const poisonDeadLetterQuery = `
+	UPDATE dead_letters SET poisoned_at = ? WHERE path = ? AND poisoned_at IS NULL
+`
+
+// Poison quarantines a file at its current ref, keeping the time it was first quarantined
+func (r *SQLiteDeadLetterRepository) Poison(ctx context.Context, path string) error {
+	executor := db.GetExecutor(ctx, r.db)
+	if _, err := executor.ExecContext(ctx, poisonDeadLetterQuery, r.clock.Now().UTC(), path); err != nil {
+		return fmt.Errorf("failed to poison dead letter: %w", err)
+	}
+
+	return nil
+}
+
+const releaseDeadLetterQuery = `
+	UPDATE dead_letters SET poisoned_at = NULL, ref_attempts = 0 WHERE path = ?
+`
+
+// Release lifts a file's quarantine and restarts its count of failures at its ref
+func (r *SQLiteDeadLetterRepository) Release(ctx context.Context, path string) error {
+	executor := db.GetExecutor(ctx, r.db)
+	result, err := executor.ExecContext(ctx, releaseDeadLetterQuery, path)
+	if err != nil {
+		return fmt.Errorf("failed to release dead letter: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to release dead letter: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrDeadLetterNotFound, path)
+	}
+
+	return nil
+}
+
+const deadLetterColumns = "path, ref, error, attempts, ref_attempts, first_failed_at, last_failed_at, poisoned_at"
+
+const listDeadLettersQuery = `
+	SELECT ` + deadLetterColumns + `
+	FROM dead_letters
+	ORDER BY last_failed_at DESC
+`
+
+// ListDeadLetters returns every dead-lettered file, most recently failed first
+func (r *SQLiteDeadLetterRepository) ListDeadLetters(ctx context.Context) ([]*domain.DeadLetter, error) {
+	rows, err := r.db.QueryContext(ctx, listDeadLettersQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	letters := make([]*domain.DeadLetter, 0)
+	for rows.Next() {
+		dl, err := scanDeadLetter(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan dead letter row: %w", err)
+		}
+		letters = append(letters, dl)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating dead letter rows: %w", err)
+	}
+
+	return letters, nil
+}
+
+// scanDeadLetter reads a row of deadLetterColumns
+func scanDeadLetter(row rowScanner) (*domain.DeadLetter, error) {
+	var dl domain.DeadLetter
+	var poisonedAt sql.NullTime
+	err := row.Scan(
+		&dl.Path,
+		&dl.Ref,
+		&dl.Error,
+		&dl.Attempts,
+		&dl.RefAttempts,
+		&dl.FirstFailedAt,
+		&dl.LastFailedAt,
+		&poisonedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	dl.PoisonedAt = poisonedAt.Time
+
+	return &dl, nil
+}
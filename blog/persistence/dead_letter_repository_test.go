@@ -0,0 +1,136 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/clock"
+)
+
+func TestDeadLetterRepository_RecordAndResolve(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewDeadLetterRepository(db)
+	ctx := context.Background()
+
+	if err := repo.RecordFailure(ctx, "posts/001-test.md", "abc", errors.New("first")); err != nil {
+		t.Fatalf("Failed to record failure: %v", err)
+	}
+	if err := repo.RecordFailure(ctx, "posts/001-test.md", "def", errors.New("second")); err != nil {
+		t.Fatalf("Failed to record failure: %v", err)
+	}
+
+	letters, err := repo.ListDeadLetters(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list dead letters: %v", err)
+	}
+	if len(letters) != 1 {
+		t.Fatalf("Expected 1 dead letter, got %d", len(letters))
+	}
+
+	dl := letters[0]
+	if dl.Attempts != 2 || dl.Ref != "def" || dl.Error != "second" {
+		t.Errorf("Unexpected dead letter: %+v", dl)
+	}
+	if dl.LastFailedAt.Before(dl.FirstFailedAt) {
+		t.Errorf("LastFailedAt %v is before FirstFailedAt %v", dl.LastFailedAt, dl.FirstFailedAt)
+	}
+
+	if err := repo.Resolve(ctx, "posts/001-test.md"); err != nil {
+		t.Fatalf("Failed to resolve dead letter: %v", err)
+	}
+
+	letters, err = repo.ListDeadLetters(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list dead letters: %v", err)
+	}
+	if len(letters) != 0 {
+		t.Errorf("Expected no dead letters after resolve, got %d", len(letters))
+	}
+}
+
+func TestDeadLetterRepository_WithClock(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	repo := NewDeadLetterRepository(db, WithClock(clock.Fixed(now)))
+	ctx := context.Background()
+
+	if err := repo.RecordFailure(ctx, "posts/001-test.md", "abc", errors.New("failed")); err != nil {
+		t.Fatalf("Failed to record failure: %v", err)
+	}
+
+	letters, err := repo.ListDeadLetters(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list dead letters: %v", err)
+	}
+	if len(letters) != 1 {
+		t.Fatalf("Expected 1 dead letter, got %d", len(letters))
+	}
+	if !letters[0].FirstFailedAt.Equal(now) || !letters[0].LastFailedAt.Equal(now) {
+		t.Errorf("Expected failure times %v, got %v and %v", now, letters[0].FirstFailedAt, letters[0].LastFailedAt)
+	}
+}
+
+func TestDeadLetterRepository_Poison(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	repo := NewDeadLetterRepository(db, WithClock(clock.Fixed(now)))
+	ctx := context.Background()
+
+	if _, err := repo.GetDeadLetter(ctx, "posts/001-test.md"); !errors.Is(err, domain.ErrDeadLetterNotFound) {
+		t.Errorf("GetDeadLetter() error = %v, want ErrDeadLetterNotFound", err)
+	}
+	if err := repo.Release(ctx, "posts/001-test.md"); !errors.Is(err, domain.ErrDeadLetterNotFound) {
+		t.Errorf("Release() error = %v, want ErrDeadLetterNotFound", err)
+	}
+
+	for range 2 {
+		if err := repo.RecordFailure(ctx, "posts/001-test.md", "abc", errors.New("failed")); err != nil {
+			t.Fatalf("Failed to record failure: %v", err)
+		}
+	}
+	if err := repo.Poison(ctx, "posts/001-test.md"); err != nil {
+		t.Fatalf("Poison() error = %v", err)
+	}
+
+	dl, err := repo.GetDeadLetter(ctx, "posts/001-test.md")
+	if err != nil {
+		t.Fatalf("GetDeadLetter() error = %v", err)
+	}
+	if dl.Attempts != 2 || dl.RefAttempts != 2 || !dl.PoisonedAt.Equal(now) {
+		t.Errorf("Unexpected dead letter after poisoning: %+v", dl)
+	}
+
+	if err := repo.RecordFailure(ctx, "posts/001-test.md", "def", errors.New("failed again")); err != nil {
+		t.Fatalf("Failed to record failure: %v", err)
+	}
+	dl, err = repo.GetDeadLetter(ctx, "posts/001-test.md")
+	if err != nil {
+		t.Fatalf("GetDeadLetter() error = %v", err)
+	}
+	if dl.Attempts != 3 || dl.RefAttempts != 1 || !dl.PoisonedAt.IsZero() {
+		t.Errorf("Unexpected dead letter after a new version failed: %+v", dl)
+	}
+
+	if err := repo.Poison(ctx, "posts/001-test.md"); err != nil {
+		t.Fatalf("Poison() error = %v", err)
+	}
+	if err := repo.Release(ctx, "posts/001-test.md"); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	dl, err = repo.GetDeadLetter(ctx, "posts/001-test.md")
+	if err != nil {
+		t.Fatalf("GetDeadLetter() error = %v", err)
+	}
+	if dl.RefAttempts != 0 || !dl.PoisonedAt.IsZero() {
+		t.Errorf("Unexpected dead letter after release: %+v", dl)
+	}
+}
@@ -0,0 +1,129 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/db"
+)
+
+var _ domain.FederationRepository = (*SQLiteFederationRepository)(nil)
+
+// SQLiteFederationRepository implements domain.FederationRepository using SQL database (SQLite)
+type SQLiteFederationRepository struct {
+	db *sql.DB
+}
+
+// NewFederationRepository creates a new SQLiteFederationRepository from a standard sql.DB
+func NewFederationRepository(db *sql.DB) *SQLiteFederationRepository {
+	return &SQLiteFederationRepository{
+		db: db,
+	}
+}
+
+const saveFollowerQuery = `
+	INSERT INTO followers (actor_id, inbox, shared_inbox, followed_at)
+	VALUES (?, ?, ?, ?)
+	ON CONFLICT(actor_id) DO UPDATE SET
+		inbox = excluded.inbox,
+		shared_inbox = excluded.shared_inbox
+`
+
+// SaveFollower records a follower, keeping when an existing one first followed
+func (r *SQLiteFederationRepository) SaveFollower(ctx context.Context, follower *domain.Follower) error {
+	if follower == nil {
+		return fmt.Errorf("follower cannot be nil")
+	}
+
+	executor := db.GetExecutor(ctx, r.db)
+	if _, err := executor.ExecContext(ctx, saveFollowerQuery, follower.ActorID, follower.Inbox, follower.SharedInbox, follower.FollowedAt); err != nil {
+		return fmt.Errorf("failed to save follower: %w", err)
+	}
+	return nil
+}
+
+const deleteFollowerQuery = `
+	DELETE FROM followers WHERE actor_id = ?
+`
+
+// DeleteFollower removes a follower, if actorID is following
+func (r *SQLiteFederationRepository) DeleteFollower(ctx context.Context, actorID string) error {
+	executor := db.GetExecutor(ctx, r.db)
+	if _, err := executor.ExecContext(ctx, deleteFollowerQuery, actorID); err != nil {
+		return fmt.Errorf("failed to delete follower: %w", err)
+	}
+	return nil
+}
+
+const listFollowersQuery = `
+	SELECT actor_id, inbox, shared_inbox, followed_at
+	FROM followers
+	ORDER BY followed_at, actor_id
+`
+
+// ListFollowers returns every follower, in the order they followed
+func (r *SQLiteFederationRepository) ListFollowers(ctx context.Context) ([]*domain.Follower, error) {
+	rows, err := r.db.QueryContext(ctx, listFollowersQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list followers: %w", err)
+	}
+	defer rows.Close()
+
+	followers := make([]*domain.Follower, 0)
+	for rows.Next() {
+		var f domain.Follower
+		if err := rows.Scan(&f.ActorID, &f.Inbox, &f.SharedInbox, &f.FollowedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan follower row: %w", err)
+		}
+		followers = append(followers, &f)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating follower rows: %w", err)
+	}
+
+	return followers, nil
+}
+
+const markAnnouncedQuery = `
+	INSERT INTO federated_posts (post_id, announced_at)
+	VALUES (?, ?)
+	ON CONFLICT(post_id) DO NOTHING
+`
+
+// MarkAnnounced records that a post was announced to followers, returning false if it already had been
+func (r *SQLiteFederationRepository) MarkAnnounced(ctx context.Context, postID string, at time.Time) (bool, error) {
+	executor := db.GetExecutor(ctx, r.db)
+	result, err := executor.ExecContext(ctx, markAnnouncedQuery, postID, at)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark post announced: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to check announced post: %w", err)
+	}
+	return affected > 0, nil
+}
+
+const forgetAnnouncedQuery = `
+	DELETE FROM federated_posts WHERE post_id = ?
+`
+
+// ForgetAnnounced forgets that a post was announced, returning false if it hadn't been
+func (r *SQLiteFederationRepository) ForgetAnnounced(ctx context.Context, postID string) (bool, error) {
+	executor := db.GetExecutor(ctx, r.db)
+	result, err := executor.ExecContext(ctx, forgetAnnouncedQuery, postID)
+	if err != nil {
+		return false, fmt.Errorf("failed to forget announced post: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to check announced post: %w", err)
+	}
+	return affected > 0, nil
+}
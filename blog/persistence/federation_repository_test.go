@@ -0,0 +1,81 @@
+package persistence
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+)
+
+func TestFederationRepository_Followers(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := NewFederationRepository(db)
+	ctx := context.Background()
+
+	follow := func(actorID string, sharedInbox string, at time.Time) {
+		t.Helper()
+		err := repo.SaveFollower(ctx, &domain.Follower{ActorID: actorID, Inbox: actorID + "/inbox", SharedInbox: sharedInbox, FollowedAt: at})
+		if err != nil {
+			t.Fatalf("SaveFollower(%s) error = %v", actorID, err)
+		}
+	}
+	follow("https://a.example/users/alex", "https://a.example/inbox", now)
+	follow("https://b.example/users/sam", "", now.Add(time.Hour))
+	// Following again, after the server gained a shared inbox
+	follow("https://b.example/users/sam", "https://b.example/inbox", now.Add(2*time.Hour))
+
+	followers, err := repo.ListFollowers(ctx)
+	if err != nil {
+		t.Fatalf("ListFollowers() error = %v", err)
+	}
+	if len(followers) != 2 || followers[0].ActorID != "https://a.example/users/alex" {
+		t.Fatalf("Expected followers in the order they followed, got %+v", followers)
+	}
+	if sam := followers[1]; sam.SharedInbox != "https://b.example/inbox" || !sam.FollowedAt.Equal(now.Add(time.Hour)) {
+		t.Errorf("Expected following again to update the inboxes and keep the time, got %+v", sam)
+	}
+
+	if err := repo.DeleteFollower(ctx, "https://a.example/users/alex"); err != nil {
+		t.Fatalf("DeleteFollower() error = %v", err)
+	}
+	if err := repo.DeleteFollower(ctx, "https://a.example/users/alex"); err != nil {
+		t.Errorf("Expected deleting a missing follower to succeed, got %v", err)
+	}
+	followers, err = repo.ListFollowers(ctx)
+	if err != nil {
+		t.Fatalf("ListFollowers() error = %v", err)
+	}
+	if len(followers) != 1 {
+		t.Errorf("Expected 1 follower left, got %+v", followers)
+	}
+}
+
+func TestFederationRepository_Announced(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := NewFederationRepository(db)
+	ctx := context.Background()
+
+	if first, err := repo.MarkAnnounced(ctx, "001", now); err != nil || !first {
+		t.Errorf("MarkAnnounced() = %v, %v, want true the first time", first, err)
+	}
+	if first, err := repo.MarkAnnounced(ctx, "001", now.Add(time.Hour)); err != nil || first {
+		t.Errorf("MarkAnnounced() = %v, %v, want false once announced", first, err)
+	}
+
+	if announced, err := repo.ForgetAnnounced(ctx, "001"); err != nil || !announced {
+		t.Errorf("ForgetAnnounced() = %v, %v, want true for an announced post", announced, err)
+	}
+	if announced, err := repo.ForgetAnnounced(ctx, "001"); err != nil || announced {
+		t.Errorf("ForgetAnnounced() = %v, %v, want false once forgotten", announced, err)
+	}
+	if first, err := repo.MarkAnnounced(ctx, "001", now); err != nil || !first {
+		t.Errorf("MarkAnnounced() = %v, %v, want true after forgetting", first, err)
+	}
+}
@@ -0,0 +1,20 @@
+package persistence
+
+import "os"
+
+// HTMLStorageConfig decides where rendered post HTML is kept
+type HTMLStorageConfig struct {
+	// Database keeps HTML in the posts table, so every instance sharing the database can serve it
+	// Otherwise it is kept only in the blob store.
+	Database bool
+	// Cache keeps copies of HTML read from the database in the blob store, named by their content so
+	// a copy is never stale. It only applies when Database is set.
+	Cache bool
+}
+
+func NewHTMLStorageConfig() *HTMLStorageConfig {
+	return &HTMLStorageConfig{
+		Database: os.Getenv("POST_HTML_STORE") == "database",
+		Cache:    os.Getenv("POST_HTML_CACHE") != "false",
+	}
+}
@@ -0,0 +1,115 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/blob"
+)
+
+func TestPostRepository_DatabaseHTML(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	blobs := blob.NewLocalStore(t.TempDir())
+	repo := NewPostRepository(db, WithBlobStore(blobs), WithHTMLStorage(&HTMLStorageConfig{Database: true, Cache: true}))
+	ctx := context.Background()
+
+	post := &domain.Post{ID: "001", Title: "Post", HTMLPath: "001.html", HTMLContent: []byte("<p>one</p>"), CreatedAt: time.Now()}
+	if err := repo.SavePost(ctx, post); err != nil {
+		t.Fatalf("SavePost() error = %v", err)
+	}
+	if post.HTMLPath == "001.html" {
+		t.Fatal("Expected the cached file to be named by content")
+	}
+	cached := post.HTMLPath
+
+	// Another instance sharing the database has no cached copy, and reads the HTML from the database
+	other := NewPostRepository(db, WithBlobStore(blob.NewLocalStore(t.TempDir())), WithHTMLStorage(&HTMLStorageConfig{Database: true, Cache: true}))
+	if content, err := other.GetPostHTML(ctx, "001"); err != nil || string(content) != "<p>one</p>" {
+		t.Errorf("GetPostHTML() without a cached copy = %q, %v", content, err)
+	}
+
+	post.HTMLPath = "001.html"
+	post.HTMLContent = []byte("<p>two</p>")
+	if err := repo.SavePost(ctx, post); err != nil {
+		t.Fatalf("SavePost() error = %v", err)
+	}
+	if content, err := other.GetPostHTML(ctx, "001"); err != nil || string(content) != "<p>two</p>" {
+		t.Errorf("GetPostHTML() after an update = %q, %v, want the new content rather than a stale copy", content, err)
+	}
+	if _, err := blobs.Get(ctx, cached); !errors.Is(err, blob.ErrNotFound) {
+		t.Errorf("Expected the replaced cached copy to be removed, got %v", err)
+	}
+
+	post.HTMLPath = "001.html"
+	post.HTMLContent = nil
+	if err := repo.SavePost(ctx, post); err != nil {
+		t.Fatalf("SavePost() error = %v", err)
+	}
+	if content, err := other.GetPostHTML(ctx, "001"); err != nil || len(content) != 0 {
+		t.Errorf("GetPostHTML() of an empty post = %q, %v", content, err)
+	}
+}
+
+func TestPostRepository_DatabaseHTMLWithoutCache(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	blobs := blob.NewLocalStore(t.TempDir())
+	ctx := context.Background()
+
+	// A post saved while HTML was kept only in the blob store is still served from there
+	legacy := NewPostRepository(db, WithBlobStore(blobs))
+	if err := legacy.SavePost(ctx, &domain.Post{ID: "001", Title: "Old", HTMLPath: "001.html", HTMLContent: []byte("<p>old</p>"), CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("SavePost() error = %v", err)
+	}
+
+	repo := NewPostRepository(db, WithBlobStore(blobs), WithHTMLStorage(&HTMLStorageConfig{Database: true}))
+	if content, err := repo.GetPostHTML(ctx, "001"); err != nil || string(content) != "<p>old</p>" {
+		t.Errorf("GetPostHTML() of a post saved to the blob store = %q, %v", content, err)
+	}
+
+	post := &domain.Post{ID: "002", Title: "New", HTMLPath: "002.html", HTMLContent: []byte("<p>new</p>"), CreatedAt: time.Now()}
+	if err := repo.SavePost(ctx, post); err != nil {
+		t.Fatalf("SavePost() error = %v", err)
+	}
+	if content, err := repo.GetPostHTML(ctx, "002"); err != nil || string(content) != "<p>new</p>" {
+		t.Errorf("GetPostHTML() = %q, %v", content, err)
+	}
+	if _, err := blobs.Get(ctx, post.HTMLPath); !errors.Is(err, blob.ErrNotFound) {
+		t.Errorf("Expected no cached copy with caching disabled, got %v", err)
+	}
+
+	// Resaving moves the old post into the database and removes its file
+	if err := repo.SavePost(ctx, &domain.Post{ID: "001", Title: "Old", HTMLPath: "001.html", HTMLContent: []byte("<p>old</p>"), CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("SavePost() error = %v", err)
+	}
+	if _, err := blobs.Get(ctx, "001.html"); !errors.Is(err, blob.ErrNotFound) {
+		t.Errorf("Expected the blob store file to be removed, got %v", err)
+	}
+	if content, err := repo.GetPostHTML(ctx, "001"); err != nil || string(content) != "<p>old</p>" {
+		t.Errorf("GetPostHTML() after resaving = %q, %v", content, err)
+	}
+}
+
+func TestShadowPostRepository_PromoteDatabaseHTML(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	storage := WithHTMLStorage(&HTMLStorageConfig{Database: true})
+	live := NewPostRepository(db, WithBlobStore(blob.NewLocalStore(t.TempDir())), storage)
+	shadow := NewShadowPostRepository(db, WithBlobStore(blob.NewLocalStore(t.TempDir())), storage)
+	ctx := context.Background()
+
+	if err := shadow.SavePost(ctx, &domain.Post{ID: "001", Title: "Post", HTMLPath: "001.html", HTMLContent: []byte("<p>shadow</p>"), CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("SavePost() error = %v", err)
+	}
+	if _, err := shadow.Promote(ctx); err != nil {
+		t.Fatalf("Promote() error = %v", err)
+	}
+
+	if content, err := live.GetPostHTML(ctx, "001"); err != nil || string(content) != "<p>shadow</p>" {
+		t.Errorf("GetPostHTML() after promoting = %q, %v, want the shadow content", content, err)
+	}
+}
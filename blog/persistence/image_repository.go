@@ -3,52 +3,56 @@ package persistence
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/blob"
 	"github.com/dfryer1193/goblog/shared/db"
 )
 
 var _ domain.ImageRepository = (*SQLiteImageRepository)(nil)
 
+// imageDir is where image files are kept unless a blob store is configured
 const imageDir = "./images"
 
 // SQLiteImageRepository implements domain.ImageRepository using SQL database (SQLite)
 type SQLiteImageRepository struct {
-	db *sql.DB
+	db    *sql.DB
+	blobs blob.Store
 }
 
 // NewImageRepository creates a new SQLiteImageRepository from a standard sql.DB
-func NewImageRepository(sqlDB *sql.DB) *SQLiteImageRepository {
-	info, err := os.Stat(imageDir)
-	if os.IsNotExist(err) {
-		err := os.MkdirAll(imageDir, 0755)
-		if err != nil {
-			panic(fmt.Sprintf("failed to create image directory: %v", err))
-		}
-	} else if err != nil {
-		panic(fmt.Sprintf("failed to stat image directory: %v", err))
-	} else if !info.IsDir() {
-		panic(fmt.Sprintf("image path exists but is not a directory: %s", imageDir))
+func NewImageRepository(sqlDB *sql.DB, opts ...Option) *SQLiteImageRepository {
+	o := newOptions(opts)
+	if o.blobs == nil {
+		o.blobs = blob.NewLocalStore(imageDir)
 	}
 
 	return &SQLiteImageRepository{
-		db: sqlDB,
+		db:    sqlDB,
+		blobs: o.blobs,
 	}
 }
 
+// imageKey returns the blob key for an image: its path below images/, so folders are kept
+func imageKey(imagePath string) string {
+	return strings.TrimPrefix(imagePath, "images/")
+}
+
 const upsertImageQuery = `
-	INSERT INTO images (path, hash, updated_at, created_at)
-	VALUES (?, ?, ?, ?)
+	INSERT INTO images (path, hash, perceptual_hash, updated_at, created_at)
+	VALUES (?, ?, ?, ?, ?)
 	ON CONFLICT(path) DO UPDATE SET
 		hash = excluded.hash,
+		perceptual_hash = excluded.perceptual_hash,
 		updated_at = excluded.updated_at,
 		created_at = COALESCE(images.created_at, excluded.created_at)
 `
 
-// SaveImage saves an image to both filesystem and database within a transaction
+// SaveImage saves an image to both the blob store and database within a transaction
 func (r *SQLiteImageRepository) SaveImage(ctx context.Context, img *domain.Image) error {
 	if img == nil {
 		return fmt.Errorf("image cannot be nil")
@@ -58,6 +62,12 @@ func (r *SQLiteImageRepository) SaveImage(ctx context.Context, img *domain.Image
 		return fmt.Errorf("image path cannot be empty")
 	}
 
+	imagePath, err := cleanPath(img.Path)
+	if err != nil {
+		return err
+	}
+	img.Path = imagePath
+
 	// Run filesystem and database operations in a transaction
 	return db.RunInTransaction(ctx, r.db, func(txCtx context.Context) error {
 		// Upsert to database first
@@ -71,10 +81,16 @@ func (r *SQLiteImageRepository) SaveImage(ctx context.Context, img *domain.Image
 			createdAt = img.CreatedAt
 		}
 
+		var perceptualHash any
+		if img.PerceptualHash != "" {
+			perceptualHash = img.PerceptualHash
+		}
+
 		executor := db.GetExecutor(txCtx, r.db)
 		_, err := executor.ExecContext(txCtx, upsertImageQuery,
 			img.Path,
 			img.Hash,
+			perceptualHash,
 			updatedAt,
 			createdAt,
 		)
@@ -83,10 +99,7 @@ func (r *SQLiteImageRepository) SaveImage(ctx context.Context, img *domain.Image
 			return fmt.Errorf("failed to upsert image record: %w", err)
 		}
 
-		filename := filepath.Base(img.Path)
-		localPath := filepath.Join(imageDir, filename)
-
-		if err := os.WriteFile(localPath, img.Content, 0644); err != nil {
+		if err := r.blobs.Put(txCtx, imageKey(img.Path), img.Content); err != nil {
 			return fmt.Errorf("failed to write image file: %w", err)
 		}
 
@@ -95,7 +108,7 @@ func (r *SQLiteImageRepository) SaveImage(ctx context.Context, img *domain.Image
 }
 
 const getImageQuery = `
-	SELECT path, hash, updated_at, created_at
+	SELECT path, hash, perceptual_hash, updated_at, created_at, orphaned_at
 	FROM images
 	WHERE path = ?
 `
@@ -110,12 +123,14 @@ func (r *SQLiteImageRepository) GetImage(ctx context.Context, path string) (*dom
 	err := r.db.QueryRowContext(ctx, getImageQuery, path).Scan(
 		&row.Path,
 		&row.Hash,
+		&row.PerceptualHash,
 		&row.UpdatedAt,
 		&row.CreatedAt,
+		&row.OrphanedAt,
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("image not found: %s", path)
+		return nil, fmt.Errorf("%w: %s", domain.ErrImageNotFound, path)
 	}
 
 	if err != nil {
@@ -125,11 +140,60 @@ func (r *SQLiteImageRepository) GetImage(ctx context.Context, path string) (*dom
 	return row.toDomain(), nil
 }
 
+const getImageByHashQuery = `
+	SELECT path, hash, perceptual_hash, updated_at, created_at, orphaned_at
+	FROM images
+	WHERE hash = ?
+	ORDER BY path
+	LIMIT 1
+`
+
+// GetImageByHash retrieves an image by the hash of its content
+// Identical content stored under several paths resolves to the first path
+func (r *SQLiteImageRepository) GetImageByHash(ctx context.Context, hash string) (*domain.Image, error) {
+	if hash == "" {
+		return nil, fmt.Errorf("image hash cannot be empty")
+	}
+
+	var row imageRow
+	err := r.db.QueryRowContext(ctx, getImageByHashQuery, hash).Scan(
+		&row.Path,
+		&row.Hash,
+		&row.PerceptualHash,
+		&row.UpdatedAt,
+		&row.CreatedAt,
+		&row.OrphanedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", domain.ErrImageNotFound, hash)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get image by hash: %w", err)
+	}
+
+	return row.toDomain(), nil
+}
+
+// GetImageContent reads the stored bytes of an image from the blob store
+func (r *SQLiteImageRepository) GetImageContent(ctx context.Context, path string) ([]byte, error) {
+	content, err := r.blobs.Get(ctx, imageKey(path))
+	if errors.Is(err, blob.ErrNotFound) {
+		return nil, fmt.Errorf("%w: %s", domain.ErrImageNotFound, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image file: %w", err)
+	}
+
+	return content, nil
+}
+
 const deleteImageQuery = `
 	DELETE FROM images WHERE path = ?
 `
 
-// DeleteImage removes an image from both filesystem and database within a transaction
+// DeleteImage removes an image from both the blob store and database within a transaction
 func (r *SQLiteImageRepository) DeleteImage(ctx context.Context, path string) error {
 	if path == "" {
 		return fmt.Errorf("image path cannot be empty")
@@ -144,11 +208,8 @@ func (r *SQLiteImageRepository) DeleteImage(ctx context.Context, path string) er
 			return fmt.Errorf("failed to delete image record: %w", err)
 		}
 
-		// Then remove from filesystem - if this fails, transaction rolls back
-		filename := filepath.Base(path)
-		localPath := filepath.Join(imageDir, filename)
-
-		if err := os.Remove(localPath); err != nil && !os.IsNotExist(err) {
+		// Then remove the file - if this fails, transaction rolls back
+		if err := r.blobs.Delete(txCtx, imageKey(path)); err != nil {
 			return fmt.Errorf("failed to remove image file: %w", err)
 		}
 
@@ -156,19 +217,159 @@ func (r *SQLiteImageRepository) DeleteImage(ctx context.Context, path string) er
 	})
 }
 
+// UsageBytes returns the bytes used by image files
+func (r *SQLiteImageRepository) UsageBytes(ctx context.Context) (int64, error) {
+	size, err := r.blobs.Size(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to measure image storage: %w", err)
+	}
+	return size, nil
+}
+
+// Images used by shadow posts are kept, since promoting the shadow namespace makes them live
+const flagOrphanedImagesQuery = `
+	UPDATE images
+	SET orphaned_at = ?
+	WHERE orphaned_at IS NULL
+	AND NOT EXISTS (SELECT 1 FROM post_images WHERE post_images.image_path = images.path)
+	AND NOT EXISTS (SELECT 1 FROM shadow_post_images WHERE shadow_post_images.image_path = images.path)
+	AND NOT EXISTS (SELECT 1 FROM posts WHERE images_recorded = 0)
+	AND NOT EXISTS (SELECT 1 FROM shadow_posts WHERE images_recorded = 0)
+`
+
+const clearOrphanedImagesQuery = `
+	UPDATE images
+	SET orphaned_at = NULL
+	WHERE orphaned_at IS NOT NULL
+	AND (
+		EXISTS (SELECT 1 FROM post_images WHERE post_images.image_path = images.path)
+		OR EXISTS (SELECT 1 FROM shadow_post_images WHERE shadow_post_images.image_path = images.path)
+	)
+`
+
+const countPostsWithoutImageRefsQuery = `
+	SELECT (SELECT COUNT(*) FROM posts WHERE images_recorded = 0) + (SELECT COUNT(*) FROM shadow_posts WHERE images_recorded = 0)
+`
+
+// CountPostsWithoutImageRefs counts the posts, live or shadow, saved before their image references were recorded
+func (r *SQLiteImageRepository) CountPostsWithoutImageRefs(ctx context.Context) (int, error) {
+	var count int
+	if err := r.db.QueryRowContext(ctx, countPostsWithoutImageRefsQuery).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count posts without image references: %w", err)
+	}
+	return count, nil
+}
+
+// FlagOrphanedImages marks unreferenced images as orphaned and un-flags images that are referenced again
+// Nothing is flagged while a post's image references are unknown, since it may use any image.
+func (r *SQLiteImageRepository) FlagOrphanedImages(ctx context.Context, at time.Time) error {
+	return db.RunInTransaction(ctx, r.db, func(txCtx context.Context) error {
+		executor := db.GetExecutor(txCtx, r.db)
+		if _, err := executor.ExecContext(txCtx, clearOrphanedImagesQuery); err != nil {
+			return fmt.Errorf("failed to clear orphaned images: %w", err)
+		}
+
+		if _, err := executor.ExecContext(txCtx, flagOrphanedImagesQuery, at); err != nil {
+			return fmt.Errorf("failed to flag orphaned images: %w", err)
+		}
+
+		return nil
+	})
+}
+
+const listOrphanedImagesQuery = `
+	SELECT path, hash, perceptual_hash, updated_at, created_at, orphaned_at
+	FROM images
+	WHERE orphaned_at IS NOT NULL
+	ORDER BY orphaned_at ASC
+`
+
+// ListOrphanedImages returns all images flagged as orphaned, oldest first
+func (r *SQLiteImageRepository) ListOrphanedImages(ctx context.Context) ([]*domain.Image, error) {
+	rows, err := r.db.QueryContext(ctx, listOrphanedImagesQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list orphaned images: %w", err)
+	}
+	defer rows.Close()
+
+	images := make([]*domain.Image, 0)
+	for rows.Next() {
+		var row imageRow
+		err := rows.Scan(
+			&row.Path,
+			&row.Hash,
+			&row.PerceptualHash,
+			&row.UpdatedAt,
+			&row.CreatedAt,
+			&row.OrphanedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan image row: %w", err)
+		}
+		images = append(images, row.toDomain())
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating image rows: %w", err)
+	}
+
+	return images, nil
+}
+
+const listImagesQuery = `
+	SELECT path, hash, perceptual_hash, updated_at, created_at, orphaned_at
+	FROM images
+	ORDER BY path
+`
+
+// ListImages returns every image record, ordered by path
+func (r *SQLiteImageRepository) ListImages(ctx context.Context) ([]*domain.Image, error) {
+	rows, err := r.db.QueryContext(ctx, listImagesQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list images: %w", err)
+	}
+	defer rows.Close()
+
+	images := make([]*domain.Image, 0)
+	for rows.Next() {
+		var row imageRow
+		err := rows.Scan(
+			&row.Path,
+			&row.Hash,
+			&row.PerceptualHash,
+			&row.UpdatedAt,
+			&row.CreatedAt,
+			&row.OrphanedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan image row: %w", err)
+		}
+		images = append(images, row.toDomain())
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating image rows: %w", err)
+	}
+
+	return images, nil
+}
+
 // imageRow is a private struct used to scan database rows
 type imageRow struct {
-	Path      string       `db:"path"`
-	Hash      string       `db:"hash"`
-	UpdatedAt sql.NullTime `db:"updated_at"`
-	CreatedAt sql.NullTime `db:"created_at"`
+	Path           string         `db:"path"`
+	Hash           string         `db:"hash"`
+	PerceptualHash sql.NullString `db:"perceptual_hash"`
+	UpdatedAt      sql.NullTime   `db:"updated_at"`
+	CreatedAt      sql.NullTime   `db:"created_at"`
+	OrphanedAt     sql.NullTime   `db:"orphaned_at"`
 }
 
 // toDomain converts an imageRow to a domain.Image, handling nullable times
 func (ir *imageRow) toDomain() *domain.Image {
 	img := &domain.Image{
-		Path: ir.Path,
-		Hash: ir.Hash,
+		Path:           ir.Path,
+		Hash:           ir.Hash,
+		PerceptualHash: ir.PerceptualHash.String,
 	}
 
 	if ir.UpdatedAt.Valid {
@@ -177,6 +378,9 @@ func (ir *imageRow) toDomain() *domain.Image {
 	if ir.CreatedAt.Valid {
 		img.CreatedAt = ir.CreatedAt.Time
 	}
+	if ir.OrphanedAt.Valid {
+		img.OrphanedAt = ir.OrphanedAt.Time
+	}
 
 	return img
 }
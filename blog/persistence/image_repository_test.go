@@ -3,41 +3,27 @@ package persistence
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/dfryer1193/goblog/blog/domain"
-	_ "modernc.org/sqlite"
+	"github.com/dfryer1193/goblog/shared/blob"
+	"github.com/dfryer1193/goblog/shared/db/sqlite"
 )
 
 func setupTestImageDB(t *testing.T) *sql.DB {
 	t.Helper()
-	db, err := sql.Open("sqlite", ":memory:")
-	if err != nil {
-		t.Fatalf("Failed to open database: %v", err)
-	}
-
-	// Create images table
-	_, err = db.Exec(`
-		CREATE TABLE images (
-			path TEXT PRIMARY KEY,
-			hash TEXT NOT NULL,
-			updated_at TIMESTAMP,
-			created_at TIMESTAMP NOT NULL
-		)
-	`)
-	if err != nil {
-		t.Fatalf("Failed to create images table: %v", err)
-	}
-
-	return db
+	return setupTestDB(t)
 }
 
 func TestImageRepository_SaveImage(t *testing.T) {
 	db := setupTestImageDB(t)
 	defer db.Close()
 
-	repo := NewImageRepository(db)
+	repo := NewImageRepository(db, WithBlobStore(blob.NewLocalStore(t.TempDir())))
 	ctx := context.Background()
 
 	now := time.Now().UTC()
@@ -79,7 +65,7 @@ func TestImageRepository_GetImage(t *testing.T) {
 	db := setupTestImageDB(t)
 	defer db.Close()
 
-	repo := NewImageRepository(db)
+	repo := NewImageRepository(db, WithBlobStore(blob.NewLocalStore(t.TempDir())))
 	ctx := context.Background()
 
 	// Test getting non-existent image
@@ -120,7 +106,7 @@ func TestImageRepository_DeleteImage(t *testing.T) {
 	db := setupTestImageDB(t)
 	defer db.Close()
 
-	repo := NewImageRepository(db)
+	repo := NewImageRepository(db, WithBlobStore(blob.NewLocalStore(t.TempDir())))
 	ctx := context.Background()
 
 	// Insert an image
@@ -154,7 +140,7 @@ func TestImageRepository_SaveImage_NilImage(t *testing.T) {
 	db := setupTestImageDB(t)
 	defer db.Close()
 
-	repo := NewImageRepository(db)
+	repo := NewImageRepository(db, WithBlobStore(blob.NewLocalStore(t.TempDir())))
 	ctx := context.Background()
 
 	err := repo.SaveImage(ctx, nil)
@@ -167,7 +153,7 @@ func TestImageRepository_SaveImage_EmptyPath(t *testing.T) {
 	db := setupTestImageDB(t)
 	defer db.Close()
 
-	repo := NewImageRepository(db)
+	repo := NewImageRepository(db, WithBlobStore(blob.NewLocalStore(t.TempDir())))
 	ctx := context.Background()
 
 	img := &domain.Image{
@@ -180,3 +166,235 @@ func TestImageRepository_SaveImage_EmptyPath(t *testing.T) {
 		t.Error("Expected error for empty path, got nil")
 	}
 }
+
+func TestImageRepository_FlagOrphanedImages(t *testing.T) {
+	db := setupTestImageDB(t)
+	defer db.Close()
+
+	imageRepo := NewImageRepository(db, WithBlobStore(blob.NewLocalStore(t.TempDir())))
+	postRepo := NewPostRepository(db, WithBlobStore(blob.NewLocalStore(t.TempDir())))
+	ctx := context.Background()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	for _, path := range []string{"images/used.png", "images/orphan.png"} {
+		err := imageRepo.SaveImage(ctx, &domain.Image{Path: path, Hash: "h", CreatedAt: now})
+		if err != nil {
+			t.Fatalf("SaveImage failed: %v", err)
+		}
+	}
+
+	post := &domain.Post{
+		ID:          "010",
+		Title:       "Uses an image",
+		HTMLPath:    "010.html",
+		HTMLContent: []byte("<img>"),
+		Images:      []string{"images/used.png"},
+		CreatedAt:   now,
+	}
+	if err := postRepo.SavePost(ctx, post); err != nil {
+		t.Fatalf("SavePost failed: %v", err)
+	}
+
+	if err := imageRepo.FlagOrphanedImages(ctx, now); err != nil {
+		t.Fatalf("FlagOrphanedImages failed: %v", err)
+	}
+
+	orphans, err := imageRepo.ListOrphanedImages(ctx)
+	if err != nil {
+		t.Fatalf("ListOrphanedImages failed: %v", err)
+	}
+	if len(orphans) != 1 || orphans[0].Path != "images/orphan.png" {
+		t.Fatalf("orphans = %v, want only images/orphan.png", orphans)
+	}
+	if !orphans[0].OrphanedAt.Equal(now) {
+		t.Errorf("OrphanedAt = %v, want %v", orphans[0].OrphanedAt, now)
+	}
+
+	// Flagging again later must keep the original orphan time
+	if err := imageRepo.FlagOrphanedImages(ctx, now.Add(time.Hour)); err != nil {
+		t.Fatalf("FlagOrphanedImages failed: %v", err)
+	}
+	orphan, err := imageRepo.GetImage(ctx, "images/orphan.png")
+	if err != nil {
+		t.Fatalf("GetImage failed: %v", err)
+	}
+	if !orphan.OrphanedAt.Equal(now) {
+		t.Errorf("OrphanedAt changed to %v, want %v", orphan.OrphanedAt, now)
+	}
+
+	// Referencing the orphan again clears the flag
+	post.Images = []string{"images/used.png", "images/orphan.png"}
+	if err := postRepo.SavePost(ctx, post); err != nil {
+		t.Fatalf("SavePost failed: %v", err)
+	}
+	if err := imageRepo.FlagOrphanedImages(ctx, now.Add(2*time.Hour)); err != nil {
+		t.Fatalf("FlagOrphanedImages failed: %v", err)
+	}
+
+	orphans, err = imageRepo.ListOrphanedImages(ctx)
+	if err != nil {
+		t.Fatalf("ListOrphanedImages failed: %v", err)
+	}
+	if len(orphans) != 0 {
+		t.Errorf("orphans = %v, want none", orphans)
+	}
+}
+
+func TestImageRepository_GetImageByHash(t *testing.T) {
+	db := setupTestImageDB(t)
+	defer db.Close()
+
+	repo := NewImageRepository(db, WithBlobStore(blob.NewLocalStore(t.TempDir())))
+	ctx := context.Background()
+
+	img := &domain.Image{
+		Path:      "images/hashed.png",
+		Hash:      "feedface",
+		Content:   []byte("hashed content"),
+		UpdatedAt: time.Now().UTC(),
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := repo.SaveImage(ctx, img); err != nil {
+		t.Fatalf("Failed to save image: %v", err)
+	}
+	defer repo.DeleteImage(ctx, img.Path)
+
+	retrieved, err := repo.GetImageByHash(ctx, "feedface")
+	if err != nil {
+		t.Fatalf("Failed to get image by hash: %v", err)
+	}
+	if retrieved.Path != img.Path {
+		t.Errorf("Path = %q, want %q", retrieved.Path, img.Path)
+	}
+
+	content, err := repo.GetImageContent(ctx, retrieved.Path)
+	if err != nil {
+		t.Fatalf("Failed to get image content: %v", err)
+	}
+	if string(content) != "hashed content" {
+		t.Errorf("Content = %q, want %q", content, "hashed content")
+	}
+
+	_, err = repo.GetImageByHash(ctx, "missing")
+	if !errors.Is(err, domain.ErrImageNotFound) {
+		t.Errorf("Expected ErrImageNotFound, got %v", err)
+	}
+}
+
+func TestImageRepository_SaveImage_KeepsFolders(t *testing.T) {
+	db := setupTestImageDB(t)
+	defer db.Close()
+
+	dir := t.TempDir()
+	repo := NewImageRepository(db, WithBlobStore(blob.NewLocalStore(dir)))
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	for _, img := range []*domain.Image{
+		{Path: "images/2024/photo.jpg", Hash: "a", Content: []byte("2024"), UpdatedAt: now, CreatedAt: now},
+		{Path: "images/2025/photo.jpg", Hash: "b", Content: []byte("2025"), UpdatedAt: now, CreatedAt: now},
+	} {
+		if err := repo.SaveImage(ctx, img); err != nil {
+			t.Fatalf("Failed to save %s: %v", img.Path, err)
+		}
+	}
+
+	content, err := repo.GetImageContent(ctx, "images/2024/photo.jpg")
+	if err != nil {
+		t.Fatalf("Failed to read image: %v", err)
+	}
+	if string(content) != "2024" {
+		t.Errorf("content = %q, want %q; images with the same name overwrote each other", content, "2024")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "2025", "photo.jpg")); err != nil {
+		t.Errorf("Expected image to be stored in its folder: %v", err)
+	}
+}
+
+func TestImageRepository_ListImages(t *testing.T) {
+	db := setupTestImageDB(t)
+	defer db.Close()
+
+	repo := NewImageRepository(db, WithBlobStore(blob.NewLocalStore(t.TempDir())))
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	for _, img := range []*domain.Image{
+		{Path: "images/b.png", Hash: "b", PerceptualHash: "00000000000000ff", Content: []byte("b"), UpdatedAt: now, CreatedAt: now},
+		{Path: "images/a.svg", Hash: "a", Content: []byte("a"), UpdatedAt: now, CreatedAt: now},
+	} {
+		if err := repo.SaveImage(ctx, img); err != nil {
+			t.Fatalf("Failed to save %s: %v", img.Path, err)
+		}
+	}
+
+	images, err := repo.ListImages(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list images: %v", err)
+	}
+	if len(images) != 2 || images[0].Path != "images/a.svg" || images[1].Path != "images/b.png" {
+		t.Fatalf("ListImages() = %+v, want a.svg then b.png", images)
+	}
+	if images[0].PerceptualHash != "" || images[1].PerceptualHash != "00000000000000ff" {
+		t.Errorf("PerceptualHash = %q, %q, want %q, %q", images[0].PerceptualHash, images[1].PerceptualHash, "", "00000000000000ff")
+	}
+	if images[1].Content != nil {
+		t.Error("ListImages() returned content, want records only")
+	}
+}
+
+func TestImageRepository_FlagOrphanedImages_UpgradedDatabase(t *testing.T) {
+	database := sqlite.NewSQLiteDB(&sqlite.SQLiteConfig{Path: filepath.Join(t.TempDir(), "test.db")})
+	if err := database.Connect(); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer database.Close()
+	ctx := context.Background()
+
+	// Posts and images saved before post_images existed
+	status, err := database.MigrationStatus(ctx)
+	if err != nil {
+		t.Fatalf("MigrationStatus() error = %v", err)
+	}
+	if _, err := database.MigrateDown(ctx, status.Version-2); err != nil {
+		t.Fatalf("MigrateDown() error = %v", err)
+	}
+	if _, err := database.DB().Exec(`
+		INSERT INTO posts (id, title, snippet, html_path, created_at) VALUES ('001', 'Old', '', '001.html', CURRENT_TIMESTAMP);
+		INSERT INTO images (path, hash, created_at) VALUES ('images/used.png', 'h', CURRENT_TIMESTAMP);
+	`); err != nil {
+		t.Fatalf("Failed to insert old rows: %v", err)
+	}
+	if _, err := database.MigrateUp(ctx, status.Version); err != nil {
+		t.Fatalf("MigrateUp() error = %v", err)
+	}
+
+	blobs := blob.NewLocalStore(t.TempDir())
+	imageRepo := NewImageRepository(database.DB(), WithBlobStore(blobs))
+	postRepo := NewPostRepository(database.DB(), WithBlobStore(blobs))
+
+	if pending, err := imageRepo.CountPostsWithoutImageRefs(ctx); err != nil || pending != 1 {
+		t.Fatalf("CountPostsWithoutImageRefs() = %d, %v, want the old post", pending, err)
+	}
+	if err := imageRepo.FlagOrphanedImages(ctx, time.Now().UTC()); err != nil {
+		t.Fatalf("FlagOrphanedImages failed: %v", err)
+	}
+	if orphans, _ := imageRepo.ListOrphanedImages(ctx); len(orphans) != 0 {
+		t.Fatalf("orphans = %v, want none before the old post is rendered again", orphans)
+	}
+
+	// Rendering the post again records what it uses, after which unused images are flagged as usual
+	post := &domain.Post{ID: "001", Title: "Old", HTMLPath: "001.html", HTMLContent: []byte("<p>no images</p>"), CreatedAt: time.Now().UTC()}
+	if err := postRepo.SavePost(ctx, post); err != nil {
+		t.Fatalf("SavePost failed: %v", err)
+	}
+	if pending, err := imageRepo.CountPostsWithoutImageRefs(ctx); err != nil || pending != 0 {
+		t.Fatalf("CountPostsWithoutImageRefs() = %d, %v, want none", pending, err)
+	}
+	if err := imageRepo.FlagOrphanedImages(ctx, time.Now().UTC()); err != nil {
+		t.Fatalf("FlagOrphanedImages failed: %v", err)
+	}
+	if orphans, _ := imageRepo.ListOrphanedImages(ctx); len(orphans) != 1 {
+		t.Errorf("orphans = %v, want the image no post uses", orphans)
+	}
+}
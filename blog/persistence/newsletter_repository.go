@@ -0,0 +1,187 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/db"
+)
+
+var _ domain.NewsletterRepository = (*SQLiteNewsletterRepository)(nil)
+
+// SQLiteNewsletterRepository implements domain.NewsletterRepository using SQL database (SQLite)
+type SQLiteNewsletterRepository struct {
+	db *sql.DB
+}
+
+// NewNewsletterRepository creates a new SQLiteNewsletterRepository from a standard sql.DB
+func NewNewsletterRepository(db *sql.DB) *SQLiteNewsletterRepository {
+	return &SQLiteNewsletterRepository{
+		db: db,
+	}
+}
+
+const subscriberColumns = `email, token, subscribed_at, confirmed_at`
+
+const saveSubscriberQuery = `
+	INSERT INTO subscribers (email, token, subscribed_at)
+	VALUES (?, ?, ?)
+	ON CONFLICT(email) DO UPDATE SET
+		token = excluded.token,
+		subscribed_at = excluded.subscribed_at
+	WHERE subscribers.confirmed_at IS NULL
+`
+
+// SaveSubscriber records a subscription request, replacing the token of one still waiting for confirmation
+func (r *SQLiteNewsletterRepository) SaveSubscriber(ctx context.Context, subscriber *domain.Subscriber) error {
+	if subscriber == nil {
+		return fmt.Errorf("subscriber cannot be nil")
+	}
+
+	executor := db.GetExecutor(ctx, r.db)
+	if _, err := executor.ExecContext(ctx, saveSubscriberQuery, subscriber.Email, subscriber.Token, subscriber.SubscribedAt); err != nil {
+		return fmt.Errorf("failed to save subscriber: %w", err)
+	}
+	return nil
+}
+
+const getSubscriberQuery = `
+	SELECT ` + subscriberColumns + `
+	FROM subscribers
+	WHERE email = ?
+`
+
+// GetSubscriber returns the subscriber with an email address, or ErrSubscriberNotFound
+func (r *SQLiteNewsletterRepository) GetSubscriber(ctx context.Context, email string) (*domain.Subscriber, error) {
+	row := db.GetExecutor(ctx, r.db).QueryRowContext(ctx, getSubscriberQuery, email)
+	subscriber, err := scanSubscriber(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", domain.ErrSubscriberNotFound, email)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscriber: %w", err)
+	}
+	return subscriber, nil
+}
+
+const getSubscriberByTokenQuery = `
+	SELECT ` + subscriberColumns + `
+	FROM subscribers
+	WHERE token = ?
+`
+
+// GetSubscriberByToken returns the subscriber with a token, or ErrSubscriberNotFound
+func (r *SQLiteNewsletterRepository) GetSubscriberByToken(ctx context.Context, token string) (*domain.Subscriber, error) {
+	row := db.GetExecutor(ctx, r.db).QueryRowContext(ctx, getSubscriberByTokenQuery, token)
+	subscriber, err := scanSubscriber(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrSubscriberNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscriber: %w", err)
+	}
+	return subscriber, nil
+}
+
+const confirmSubscriberQuery = `
+	UPDATE subscribers SET confirmed_at = COALESCE(confirmed_at, ?) WHERE email = ?
+`
+
+// ConfirmSubscriber records that a subscriber confirmed their address, keeping when they first did
+func (r *SQLiteNewsletterRepository) ConfirmSubscriber(ctx context.Context, email string, at time.Time) error {
+	executor := db.GetExecutor(ctx, r.db)
+	result, err := executor.ExecContext(ctx, confirmSubscriberQuery, at, email)
+	if err != nil {
+		return fmt.Errorf("failed to confirm subscriber: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check confirmed subscriber: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrSubscriberNotFound, email)
+	}
+	return nil
+}
+
+const deleteSubscriberQuery = `
+	DELETE FROM subscribers WHERE email = ?
+`
+
+// DeleteSubscriber removes a subscriber, if there is one with the email address
+func (r *SQLiteNewsletterRepository) DeleteSubscriber(ctx context.Context, email string) error {
+	executor := db.GetExecutor(ctx, r.db)
+	if _, err := executor.ExecContext(ctx, deleteSubscriberQuery, email); err != nil {
+		return fmt.Errorf("failed to delete subscriber: %w", err)
+	}
+	return nil
+}
+
+const listConfirmedSubscribersQuery = `
+	SELECT ` + subscriberColumns + `
+	FROM subscribers
+	WHERE confirmed_at IS NOT NULL
+	ORDER BY confirmed_at, email
+`
+
+// ListConfirmedSubscribers returns every confirmed subscriber, in the order they confirmed
+func (r *SQLiteNewsletterRepository) ListConfirmedSubscribers(ctx context.Context) ([]*domain.Subscriber, error) {
+	rows, err := r.db.QueryContext(ctx, listConfirmedSubscribersQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscribers: %w", err)
+	}
+	defer rows.Close()
+
+	subscribers := make([]*domain.Subscriber, 0)
+	for rows.Next() {
+		subscriber, err := scanSubscriber(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan subscriber row: %w", err)
+		}
+		subscribers = append(subscribers, subscriber)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating subscriber rows: %w", err)
+	}
+
+	return subscribers, nil
+}
+
+const markMailedQuery = `
+	INSERT INTO mailed_posts (post_id, mailed_at)
+	VALUES (?, ?)
+	ON CONFLICT(post_id) DO NOTHING
+`
+
+// MarkMailed records that a post was emailed to subscribers, returning false if it already had been
+func (r *SQLiteNewsletterRepository) MarkMailed(ctx context.Context, postID string, at time.Time) (bool, error) {
+	executor := db.GetExecutor(ctx, r.db)
+	result, err := executor.ExecContext(ctx, markMailedQuery, postID, at)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark post mailed: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to check mailed post: %w", err)
+	}
+	return affected > 0, nil
+}
+
+// scanSubscriber reads a row of subscriberColumns
+func scanSubscriber(row rowScanner) (*domain.Subscriber, error) {
+	var s domain.Subscriber
+	var confirmedAt sql.NullTime
+	if err := row.Scan(&s.Email, &s.Token, &s.SubscribedAt, &confirmedAt); err != nil {
+		return nil, err
+	}
+	s.ConfirmedAt = confirmedAt.Time
+
+	return &s, nil
+}
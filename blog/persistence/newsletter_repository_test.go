@@ -0,0 +1,83 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+)
+
+func TestNewsletterRepository_Subscribers(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := NewNewsletterRepository(db)
+	ctx := context.Background()
+
+	subscribe := func(email string, token string, at time.Time) {
+		t.Helper()
+		if err := repo.SaveSubscriber(ctx, &domain.Subscriber{Email: email, Token: token, SubscribedAt: at}); err != nil {
+			t.Fatalf("SaveSubscriber(%s) error = %v", email, err)
+		}
+	}
+	subscribe("alex@example.com", "t1", now)
+	subscribe("sam@example.com", "t2", now)
+
+	// Asking again before confirming replaces the token
+	subscribe("alex@example.com", "t3", now.Add(time.Hour))
+	if _, err := repo.GetSubscriberByToken(ctx, "t1"); !errors.Is(err, domain.ErrSubscriberNotFound) {
+		t.Errorf("Expected the replaced token to be forgotten, got %v", err)
+	}
+	alex, err := repo.GetSubscriberByToken(ctx, "t3")
+	if err != nil || alex.Email != "alex@example.com" || !alex.ConfirmedAt.IsZero() {
+		t.Fatalf("GetSubscriberByToken() = %+v, %v, want alex unconfirmed", alex, err)
+	}
+
+	if err := repo.ConfirmSubscriber(ctx, "alex@example.com", now.Add(2*time.Hour)); err != nil {
+		t.Fatalf("ConfirmSubscriber() error = %v", err)
+	}
+	if err := repo.ConfirmSubscriber(ctx, "nobody@example.com", now); !errors.Is(err, domain.ErrSubscriberNotFound) {
+		t.Errorf("ConfirmSubscriber() of a missing subscriber error = %v, want ErrSubscriberNotFound", err)
+	}
+
+	// A confirmed subscriber keeps their token when asking again
+	subscribe("alex@example.com", "t4", now.Add(3*time.Hour))
+	alex, err = repo.GetSubscriber(ctx, "alex@example.com")
+	if err != nil || alex.Token != "t3" || !alex.ConfirmedAt.Equal(now.Add(2*time.Hour)) {
+		t.Errorf("GetSubscriber() = %+v, %v, want the confirmed subscription unchanged", alex, err)
+	}
+
+	confirmed, err := repo.ListConfirmedSubscribers(ctx)
+	if err != nil {
+		t.Fatalf("ListConfirmedSubscribers() error = %v", err)
+	}
+	if len(confirmed) != 1 || confirmed[0].Email != "alex@example.com" {
+		t.Errorf("Expected only alex to be confirmed, got %+v", confirmed)
+	}
+
+	if err := repo.DeleteSubscriber(ctx, "alex@example.com"); err != nil {
+		t.Fatalf("DeleteSubscriber() error = %v", err)
+	}
+	if _, err := repo.GetSubscriber(ctx, "alex@example.com"); !errors.Is(err, domain.ErrSubscriberNotFound) {
+		t.Errorf("GetSubscriber() after deleting error = %v, want ErrSubscriberNotFound", err)
+	}
+}
+
+func TestNewsletterRepository_MarkMailed(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewNewsletterRepository(db)
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if first, err := repo.MarkMailed(ctx, "001", now); err != nil || !first {
+		t.Fatalf("MarkMailed() = %v, %v, want true the first time", first, err)
+	}
+	if first, err := repo.MarkMailed(ctx, "001", now.Add(time.Hour)); err != nil || first {
+		t.Errorf("MarkMailed() = %v, %v, want false once mailed", first, err)
+	}
+}
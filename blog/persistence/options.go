@@ -0,0 +1,44 @@
+package persistence
+
+import (
+	"github.com/dfryer1193/goblog/shared/blob"
+	"github.com/dfryer1193/goblog/shared/clock"
+)
+
+// Option configures optional repository behaviour
+type Option func(*options)
+
+type options struct {
+	clock       clock.Clock
+	blobs       blob.Store
+	htmlStorage *HTMLStorageConfig
+}
+
+// WithClock sets the clock used to timestamp writes
+func WithClock(c clock.Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+// WithBlobStore sets where a repository keeps its files, instead of a local directory
+func WithBlobStore(store blob.Store) Option {
+	return func(o *options) {
+		o.blobs = store
+	}
+}
+
+// WithHTMLStorage sets where a post repository keeps rendered HTML, instead of only in its blob store
+func WithHTMLStorage(config *HTMLStorageConfig) Option {
+	return func(o *options) {
+		o.htmlStorage = config
+	}
+}
+
+func newOptions(opts []Option) options {
+	o := options{clock: clock.System, htmlStorage: &HTMLStorageConfig{}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
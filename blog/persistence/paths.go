@@ -0,0 +1,38 @@
+package persistence
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+)
+
+// cleanPath normalizes a repository-provided path into a slash-separated relative path
+// Paths that are absolute, contain backslashes or NUL bytes, or climb out of their directory are rejected.
+func cleanPath(p string) (string, error) {
+	if p == "" || strings.ContainsAny(p, "\\\x00") {
+		return "", fmt.Errorf("%w: %q", domain.ErrInvalidPath, p)
+	}
+
+	cleaned := path.Clean(p)
+	if cleaned == "." || !fs.ValidPath(cleaned) {
+		return "", fmt.Errorf("%w: %q", domain.ErrInvalidPath, p)
+	}
+
+	return cleaned, nil
+}
+
+// cleanFileName normalizes a path that must name a single file, with no directories
+func cleanFileName(name string) (string, error) {
+	cleaned, err := cleanPath(name)
+	if err != nil {
+		return "", err
+	}
+	if strings.Contains(cleaned, "/") {
+		return "", fmt.Errorf("%w: %q is not a file name", domain.ErrInvalidPath, name)
+	}
+
+	return cleaned, nil
+}
@@ -0,0 +1,69 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/blob"
+)
+
+func TestCleanPath(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"images/cat.png", "images/cat.png", true},
+		{"./images/cat.png", "images/cat.png", true},
+		{"images//2024/../cat.png", "images/cat.png", true},
+		{"", "", false},
+		{".", "", false},
+		{"../cat.png", "", false},
+		{"images/../../cat.png", "", false},
+		{"/etc/passwd", "", false},
+		{`images\..\..\cat.png`, "", false},
+		{"images/cat\x00.png", "", false},
+	}
+
+	for _, tt := range tests {
+		got, err := cleanPath(tt.in)
+		if tt.ok && (err != nil || got != tt.want) {
+			t.Errorf("cleanPath(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+		if !tt.ok && !errors.Is(err, domain.ErrInvalidPath) {
+			t.Errorf("cleanPath(%q) error = %v, want ErrInvalidPath", tt.in, err)
+		}
+	}
+}
+
+func TestCleanFileName(t *testing.T) {
+	if got, err := cleanFileName("./001.html"); err != nil || got != "001.html" {
+		t.Errorf("cleanFileName(./001.html) = %q, %v", got, err)
+	}
+	if _, err := cleanFileName("sub/001.html"); !errors.Is(err, domain.ErrInvalidPath) {
+		t.Errorf("Expected a nested path to be rejected, got %v", err)
+	}
+}
+
+func TestRepositories_RejectTraversal(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	ctx := context.Background()
+
+	posts := NewPostRepository(db, WithBlobStore(blob.NewLocalStore(t.TempDir())))
+	err := posts.SavePost(ctx, &domain.Post{ID: "001", HTMLPath: "../001.html", HTMLContent: []byte("<p>x</p>")})
+	if !errors.Is(err, domain.ErrInvalidPath) {
+		t.Errorf("SavePost error = %v, want ErrInvalidPath", err)
+	}
+	if _, err := posts.GetPost(ctx, "001"); !errors.Is(err, domain.ErrPostNotFound) {
+		t.Errorf("Expected rejected post not to be stored, got %v", err)
+	}
+
+	images := NewImageRepository(db, WithBlobStore(blob.NewLocalStore(t.TempDir())))
+	err = images.SaveImage(ctx, &domain.Image{Path: "images/../../secret.png", Hash: "abc", Content: []byte("x")})
+	if !errors.Is(err, domain.ErrInvalidPath) {
+		t.Errorf("SaveImage error = %v, want ErrInvalidPath", err)
+	}
+}
@@ -0,0 +1,114 @@
+package persistence
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/clock"
+	"github.com/dfryer1193/goblog/shared/db"
+)
+
+var _ domain.PendingRenderRepository = (*SQLitePendingRenderRepository)(nil)
+
+// SQLitePendingRenderRepository implements domain.PendingRenderRepository using SQL database (SQLite)
+type SQLitePendingRenderRepository struct {
+	db    *sql.DB
+	clock clock.Clock
+}
+
+// NewPendingRenderRepository creates a new SQLitePendingRenderRepository from a standard sql.DB
+func NewPendingRenderRepository(db *sql.DB, opts ...Option) *SQLitePendingRenderRepository {
+	o := newOptions(opts)
+	return &SQLitePendingRenderRepository{
+		db:    db,
+		clock: o.clock,
+	}
+}
+
+const savePendingRenderQuery = `
+	INSERT INTO pending_renders (branch, path, commit_sha, action, blob_sha, created_at, modified_at, released, queued_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT(branch, path) DO UPDATE SET
+		commit_sha = excluded.commit_sha,
+		action = excluded.action,
+		blob_sha = excluded.blob_sha,
+		created_at = excluded.created_at,
+		modified_at = excluded.modified_at,
+		released = excluded.released,
+		queued_at = excluded.queued_at
+`
+
+// SavePendingRenders records renders in a single transaction, stamping them with the time they were queued
+// Renders without an action are recorded as upserts.
+func (r *SQLitePendingRenderRepository) SavePendingRenders(ctx context.Context, renders []*domain.PendingRender) error {
+	now := r.clock.Now().UTC()
+	return db.RunInTransaction(ctx, r.db, func(txCtx context.Context) error {
+		executor := db.GetExecutor(txCtx, r.db)
+		for _, render := range renders {
+			var createdAt, modifiedAt any
+			if !render.CreatedAt.IsZero() {
+				createdAt = render.CreatedAt.UTC()
+			}
+			if !render.ModifiedAt.IsZero() {
+				modifiedAt = render.ModifiedAt.UTC()
+			}
+
+			action := cmp.Or(render.Action, domain.SyncActionUpsert)
+			_, err := executor.ExecContext(txCtx, savePendingRenderQuery,
+				render.Branch, render.Path, render.CommitSHA, action, render.BlobSHA, createdAt, modifiedAt, render.Released, now)
+			if err != nil {
+				return fmt.Errorf("failed to save pending render of %s: %w", render.Path, err)
+			}
+			render.QueuedAt = now
+		}
+		return nil
+	})
+}
+
+const clearPendingRenderQuery = `
+	DELETE FROM pending_renders WHERE branch = ? AND path = ? AND commit_sha = ?
+`
+
+// ClearPendingRender forgets a finished render
+func (r *SQLitePendingRenderRepository) ClearPendingRender(ctx context.Context, branch string, path string, commitSHA string) error {
+	if _, err := r.db.ExecContext(ctx, clearPendingRenderQuery, branch, path, commitSHA); err != nil {
+		return fmt.Errorf("failed to clear pending render of %s: %w", path, err)
+	}
+	return nil
+}
+
+const listPendingRendersQuery = `
+	SELECT branch, path, commit_sha, action, blob_sha, created_at, modified_at, released, queued_at
+	FROM pending_renders
+	ORDER BY queued_at, branch, path
+`
+
+// ListPendingRenders returns every pending render, oldest first
+func (r *SQLitePendingRenderRepository) ListPendingRenders(ctx context.Context) ([]*domain.PendingRender, error) {
+	rows, err := r.db.QueryContext(ctx, listPendingRendersQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending renders: %w", err)
+	}
+	defer rows.Close()
+
+	renders := make([]*domain.PendingRender, 0)
+	for rows.Next() {
+		var render domain.PendingRender
+		var createdAt, modifiedAt sql.NullTime
+		if err := rows.Scan(&render.Branch, &render.Path, &render.CommitSHA, &render.Action, &render.BlobSHA, &createdAt, &modifiedAt, &render.Released, &render.QueuedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan pending render row: %w", err)
+		}
+		render.CreatedAt = createdAt.Time
+		render.ModifiedAt = modifiedAt.Time
+		renders = append(renders, &render)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating pending render rows: %w", err)
+	}
+
+	return renders, nil
+}
@@ -0,0 +1,73 @@
+package persistence
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/clock"
+)
+
+func TestPendingRenderRepository(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	repo := NewPendingRenderRepository(db, WithClock(clock.Func(func() time.Time { return now })))
+	ctx := context.Background()
+
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := repo.SavePendingRenders(ctx, []*domain.PendingRender{
+		{Path: "posts/001-foo.md", CommitSHA: "a", Branch: "main", BlobSHA: "b1", CreatedAt: created, ModifiedAt: now, Released: true},
+		{Path: "images/photo.png", CommitSHA: "a", Action: domain.SyncActionRemove},
+	}); err != nil {
+		t.Fatalf("SavePendingRenders() error = %v", err)
+	}
+
+	now = now.Add(time.Minute)
+	if err := repo.SavePendingRenders(ctx, []*domain.PendingRender{{Path: "posts/001-foo.md", CommitSHA: "a", Branch: "feature"}}); err != nil {
+		t.Fatalf("SavePendingRenders() error = %v", err)
+	}
+
+	renders, err := repo.ListPendingRenders(ctx)
+	if err != nil {
+		t.Fatalf("ListPendingRenders() error = %v", err)
+	}
+	if len(renders) != 3 {
+		t.Fatalf("ListPendingRenders() = %d renders, want 3", len(renders))
+	}
+	if r := renders[0]; r.Path != "images/photo.png" || r.Action != domain.SyncActionRemove {
+		t.Errorf("pending removal = %+v, want its action round-tripped", r)
+	}
+	if r := renders[1]; r.Path != "posts/001-foo.md" || r.Branch != "main" || r.BlobSHA != "b1" || !r.CreatedAt.Equal(created) || !r.Released || r.Action != domain.SyncActionUpsert {
+		t.Errorf("pending render = %+v, want every field round-tripped", r)
+	}
+	if r := renders[2]; r.Branch != "feature" || !r.CreatedAt.IsZero() {
+		t.Errorf("latest pending render = %+v, want the feature branch render last", r)
+	}
+
+	// A newer commit of the same file replaces the pending render, which the older render finishing leaves alone
+	if err := repo.SavePendingRenders(ctx, []*domain.PendingRender{{Path: "posts/001-foo.md", CommitSHA: "b", Branch: "main"}}); err != nil {
+		t.Fatalf("SavePendingRenders() error = %v", err)
+	}
+	if err := repo.ClearPendingRender(ctx, "main", "posts/001-foo.md", "a"); err != nil {
+		t.Fatalf("ClearPendingRender() error = %v", err)
+	}
+	if renders, _ := repo.ListPendingRenders(ctx); len(renders) != 3 {
+		t.Errorf("Expected clearing an older commit to keep the newer render, got %d renders", len(renders))
+	}
+
+	for _, r := range []struct{ branch, path, sha string }{
+		{"main", "posts/001-foo.md", "b"},
+		{"feature", "posts/001-foo.md", "a"},
+		{"", "images/photo.png", "a"},
+	} {
+		if err := repo.ClearPendingRender(ctx, r.branch, r.path, r.sha); err != nil {
+			t.Fatalf("ClearPendingRender() error = %v", err)
+		}
+	}
+	if renders, _ := repo.ListPendingRenders(ctx); len(renders) != 0 {
+		t.Errorf("ListPendingRenders() after clearing = %+v, want none", renders)
+	}
+}
@@ -0,0 +1,87 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/db"
+)
+
+var _ domain.PostDiagnosticRepository = (*SQLitePostDiagnosticRepository)(nil)
+
+// SQLitePostDiagnosticRepository implements domain.PostDiagnosticRepository using SQL database (SQLite)
+type SQLitePostDiagnosticRepository struct {
+	db *sql.DB
+}
+
+// NewPostDiagnosticRepository creates a new SQLitePostDiagnosticRepository from a standard sql.DB
+func NewPostDiagnosticRepository(db *sql.DB) *SQLitePostDiagnosticRepository {
+	return &SQLitePostDiagnosticRepository{db: db}
+}
+
+const deletePostDiagnosticsQuery = `
+	DELETE FROM post_diagnostics WHERE post_id = ?
+`
+
+const insertPostDiagnosticQuery = `
+	INSERT INTO post_diagnostics (post_id, kind, target, message, checked_at)
+	VALUES (?, ?, ?, ?, ?)
+	ON CONFLICT(post_id, kind, target) DO NOTHING
+`
+
+// ReplaceDiagnostics replaces every diagnostic of a post in a single transaction
+func (r *SQLitePostDiagnosticRepository) ReplaceDiagnostics(ctx context.Context, postID string, diagnostics []*domain.PostDiagnostic) error {
+	if postID == "" {
+		return fmt.Errorf("post ID cannot be empty")
+	}
+
+	return db.RunInTransaction(ctx, r.db, func(txCtx context.Context) error {
+		executor := db.GetExecutor(txCtx, r.db)
+		if _, err := executor.ExecContext(txCtx, deletePostDiagnosticsQuery, postID); err != nil {
+			return fmt.Errorf("failed to clear diagnostics of post %s: %w", postID, err)
+		}
+
+		for _, d := range diagnostics {
+			_, err := executor.ExecContext(txCtx, insertPostDiagnosticQuery, postID, string(d.Kind), d.Target, d.Message, d.CheckedAt.UTC())
+			if err != nil {
+				return fmt.Errorf("failed to save diagnostic of post %s: %w", postID, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+const listPostDiagnosticsQuery = `
+	SELECT post_id, kind, target, message, checked_at
+	FROM post_diagnostics
+	ORDER BY post_id, kind, target
+`
+
+// ListDiagnostics returns the diagnostics of every post, ordered by post
+func (r *SQLitePostDiagnosticRepository) ListDiagnostics(ctx context.Context) ([]*domain.PostDiagnostic, error) {
+	rows, err := r.db.QueryContext(ctx, listPostDiagnosticsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list post diagnostics: %w", err)
+	}
+	defer rows.Close()
+
+	diagnostics := make([]*domain.PostDiagnostic, 0)
+	for rows.Next() {
+		var d domain.PostDiagnostic
+		var kind string
+		if err := rows.Scan(&d.PostID, &kind, &d.Target, &d.Message, &d.CheckedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan post diagnostic row: %w", err)
+		}
+		d.Kind = domain.DiagnosticKind(kind)
+		diagnostics = append(diagnostics, &d)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating post diagnostic rows: %w", err)
+	}
+
+	return diagnostics, nil
+}
@@ -0,0 +1,78 @@
+package persistence
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/blob"
+)
+
+func TestPostDiagnosticRepository_ReplaceDiagnostics(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	posts := NewPostRepository(db, WithBlobStore(blob.NewLocalStore(t.TempDir())))
+	repo := NewPostDiagnosticRepository(db)
+	ctx := context.Background()
+
+	for _, id := range []string{"001", "002"} {
+		post := &domain.Post{ID: id, Title: "Post " + id, HTMLPath: id + ".html", HTMLContent: []byte("<p></p>"), CreatedAt: time.Now()}
+		if err := posts.SavePost(ctx, post); err != nil {
+			t.Fatalf("SavePost(%s) error = %v", id, err)
+		}
+	}
+
+	checkedAt := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	diagnostic := func(postID string, kind domain.DiagnosticKind, target string) *domain.PostDiagnostic {
+		return &domain.PostDiagnostic{PostID: postID, Kind: kind, Target: target, Message: "broken", CheckedAt: checkedAt}
+	}
+
+	if err := repo.ReplaceDiagnostics(ctx, "002", []*domain.PostDiagnostic{diagnostic("002", domain.DiagnosticBrokenLink, "/009-gone")}); err != nil {
+		t.Fatalf("ReplaceDiagnostics(002) error = %v", err)
+	}
+	if err := repo.ReplaceDiagnostics(ctx, "001", []*domain.PostDiagnostic{
+		diagnostic("001", domain.DiagnosticMissingImage, "/images/a.png"),
+		diagnostic("001", domain.DiagnosticMissingImage, "/images/a.png"),
+		diagnostic("001", domain.DiagnosticBrokenLink, "/008-gone"),
+	}); err != nil {
+		t.Fatalf("ReplaceDiagnostics(001) error = %v", err)
+	}
+
+	diagnostics, err := repo.ListDiagnostics(ctx)
+	if err != nil {
+		t.Fatalf("ListDiagnostics() error = %v", err)
+	}
+	var got []string
+	for _, d := range diagnostics {
+		got = append(got, d.PostID+" "+string(d.Kind)+" "+d.Target)
+	}
+	want := []string{"001 broken_link /008-gone", "001 missing_image /images/a.png", "002 broken_link /009-gone"}
+	if len(got) != len(want) {
+		t.Fatalf("ListDiagnostics() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("ListDiagnostics()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+	if !diagnostics[0].CheckedAt.Equal(checkedAt) {
+		t.Errorf("CheckedAt = %v, want %v", diagnostics[0].CheckedAt, checkedAt)
+	}
+
+	// Replacing with nothing clears a post, and deleting a post removes its diagnostics
+	if err := repo.ReplaceDiagnostics(ctx, "001", nil); err != nil {
+		t.Fatalf("ReplaceDiagnostics(001, nil) error = %v", err)
+	}
+	if err := posts.DeletePost(ctx, "002"); err != nil {
+		t.Fatalf("DeletePost() error = %v", err)
+	}
+	diagnostics, err = repo.ListDiagnostics(ctx)
+	if err != nil {
+		t.Fatalf("ListDiagnostics() error = %v", err)
+	}
+	if len(diagnostics) != 0 {
+		t.Errorf("Expected no diagnostics, got %d", len(diagnostics))
+	}
+}
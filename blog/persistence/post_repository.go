@@ -3,44 +3,89 @@ package persistence
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
-	"os"
-	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/blob"
+	"github.com/dfryer1193/goblog/shared/clock"
 	"github.com/dfryer1193/goblog/shared/db"
 )
 
 var _ domain.PostRepository = (*SQLitePostRepository)(nil)
 
+// postDir is where rendered HTML is kept unless a blob store is configured
 const postDir = "./posts"
 
 // SQLitePostRepository implements domain.PostRepository using SQL database (SQLite)
 type SQLitePostRepository struct {
-	db *sql.DB
+	db    *sql.DB
+	clock clock.Clock
+	blobs blob.Store
+	// htmlStorage decides whether HTML is kept in the database, with the blob store as a cache
+	htmlStorage *HTMLStorageConfig
+	// tables renames the tables in queries to those of another namespace, or is nil for the live tables
+	tables *strings.Replacer
 }
 
 // NewPostRepository creates a new SQLitePostRepository from a standard sql.DB
-func NewPostRepository(db *sql.DB) *SQLitePostRepository {
+func NewPostRepository(db *sql.DB, opts ...Option) *SQLitePostRepository {
+	o := newOptions(opts)
+	if o.blobs == nil {
+		o.blobs = blob.NewLocalStore(postDir)
+	}
+
 	return &SQLitePostRepository{
-		db: db,
+		db:          db,
+		clock:       o.clock,
+		blobs:       o.blobs,
+		htmlStorage: o.htmlStorage,
+	}
+}
+
+// query returns a query written against the live tables, renamed to the repository's namespace
+func (r *SQLitePostRepository) query(q string) string {
+	if r.tables == nil {
+		return q
 	}
+	return r.tables.Replace(q)
 }
 
+// postColumns lists the columns read by postRow.scan, in scan order
+const postColumns = `id, slug, title, snippet, html_path, updated_at, published_at, created_at, publish_at, source_path, branch, word_count, reading_minutes, commit_sha, toc, signature, language`
+
+// contentColumns hold a post's structured document and, when kept in the database, its HTML
+// They are left out of postColumns so listing posts doesn't read them.
+const contentColumns = `document, html_content`
+
 const upsertPostQuery = `
-	INSERT INTO posts (id, title, snippet, html_path, updated_at, published_at, created_at)
-	VALUES (?, ?, ?, ?, ?, ?, ?)
+	INSERT INTO posts (id, slug, title, snippet, html_path, updated_at, published_at, created_at, publish_at, source_path, branch, word_count, reading_minutes, commit_sha, toc, signature, language, document, html_content)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CAST(? AS BLOB))
 	ON CONFLICT(id) DO UPDATE SET
+		slug = excluded.slug,
 		title = excluded.title,
 		snippet = excluded.snippet,
 		html_path = excluded.html_path,
 		updated_at = excluded.updated_at,
 		published_at = excluded.published_at,
-		created_at = COALESCE(posts.created_at, excluded.created_at)
+		created_at = COALESCE(posts.created_at, excluded.created_at),
+		publish_at = excluded.publish_at,
+		source_path = COALESCE(excluded.source_path, posts.source_path),
+		branch = COALESCE(excluded.branch, posts.branch),
+		word_count = excluded.word_count,
+		reading_minutes = excluded.reading_minutes,
+		commit_sha = COALESCE(excluded.commit_sha, posts.commit_sha),
+		toc = excluded.toc,
+		signature = excluded.signature,
+		language = excluded.language,
+		document = excluded.document,
+		html_content = excluded.html_content
 `
 
-// SavePost saves a post to both filesystem and database within a transaction
+// SavePost saves a post to both the blob store and database within a transaction
+// When HTML is kept in the database, the blob store only receives a copy named by its content.
 func (r *SQLitePostRepository) SavePost(ctx context.Context, p *domain.Post) error {
 	if p == nil {
 		return fmt.Errorf("post cannot be nil")
@@ -50,54 +95,200 @@ func (r *SQLitePostRepository) SavePost(ctx context.Context, p *domain.Post) err
 		return fmt.Errorf("post ID cannot be empty")
 	}
 
+	htmlPath, err := cleanFileName(p.HTMLPath)
+	if err != nil {
+		return err
+	}
+	if r.tables != nil || r.htmlStorage.Database {
+		htmlPath = contentHTMLName(htmlPath, p.HTMLContent)
+	}
+	p.HTMLPath = htmlPath
+
 	// Run filesystem and database operations in a transaction
 	return db.RunInTransaction(ctx, r.db, func(txCtx context.Context) error {
+		executor := db.GetExecutor(txCtx, r.db)
+
+		var previousPath string
+		err := executor.QueryRowContext(txCtx, r.query(getPostHTMLPathQuery), p.ID).Scan(&previousPath)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("failed to get post file: %w", err)
+		}
+
 		// Upsert to database first
-		var updatedAt, publishedAt, createdAt any
+		var slug, updatedAt, publishedAt, createdAt, publishAt, sourcePath, branch, commitSHA, toc, signature, language, document, htmlContent any
+
+		// Posts without a slug store NULL, which the unique index doesn't compare
+		if p.Slug != "" {
+			slug = p.Slug
+		}
 
+		// Times are stored as text and compared as text, so they must all be in one zone
 		if !p.UpdatedAt.IsZero() {
-			updatedAt = p.UpdatedAt
+			updatedAt = p.UpdatedAt.UTC()
 		}
 
 		if !p.PublishedAt.IsZero() {
-			publishedAt = p.PublishedAt
+			publishedAt = p.PublishedAt.UTC()
 		}
 
 		if !p.CreatedAt.IsZero() {
-			createdAt = p.CreatedAt
+			createdAt = p.CreatedAt.UTC()
 		}
 
-		executor := db.GetExecutor(txCtx, r.db)
-		_, err := executor.ExecContext(txCtx, upsertPostQuery,
+		if !p.PublishAt.IsZero() {
+			publishAt = p.PublishAt.UTC()
+		}
+
+		if p.SourcePath != "" {
+			sourcePath = p.SourcePath
+		}
+
+		if p.Branch != "" {
+			branch = p.Branch
+		}
+
+		if p.CommitSHA != "" {
+			commitSHA = p.CommitSHA
+		}
+
+		if len(p.TOC) > 0 {
+			encoded, err := json.Marshal(p.TOC)
+			if err != nil {
+				return fmt.Errorf("failed to encode table of contents: %w", err)
+			}
+			toc = string(encoded)
+		}
+
+		if p.Signature != "" {
+			signature = p.Signature
+		}
+
+		if p.Language != "" {
+			language = p.Language
+		}
+
+		if p.Document != nil {
+			encoded, err := json.Marshal(p.Document)
+			if err != nil {
+				return fmt.Errorf("failed to encode document: %w", err)
+			}
+			document = string(encoded)
+		}
+
+		if r.htmlStorage.Database {
+			// Bound as text and cast, since the driver stores an empty byte slice as NULL, which would make a
+			// post rendered to nothing look like one saved before HTML was kept here
+			htmlContent = string(p.HTMLContent)
+		}
+
+		_, err = executor.ExecContext(txCtx, r.query(upsertPostQuery),
 			p.ID,
+			slug,
 			p.Title,
 			p.Snippet,
 			p.HTMLPath,
 			updatedAt,
 			publishedAt,
 			createdAt,
+			publishAt,
+			sourcePath,
+			branch,
+			p.WordCount,
+			p.ReadingMinutes,
+			commitSHA,
+			toc,
+			signature,
+			language,
+			document,
+			htmlContent,
 		)
 
 		if err != nil {
 			return fmt.Errorf("failed to upsert post: %w", err)
 		}
 
-		// Then write to filesystem - if this fails, transaction rolls back
-		if err := os.MkdirAll(postDir, 0755); err != nil {
-			return fmt.Errorf("failed to create post directory: %w", err)
+		if err := r.saveImageRefs(txCtx, p); err != nil {
+			return err
 		}
 
-		localPath := filepath.Join(postDir, p.HTMLPath)
-		if err := os.WriteFile(localPath, p.HTMLContent, 0644); err != nil {
+		// Then write the HTML - if this fails, transaction rolls back
+		// HTML kept in the database was saved with the row, and is only cached here.
+		if r.htmlStorage.Database {
+			r.cacheHTML(txCtx, p.HTMLPath, p.HTMLContent)
+		} else if err := r.blobs.Put(txCtx, p.HTMLPath, p.HTMLContent); err != nil {
 			return fmt.Errorf("failed to write post file: %w", err)
 		}
 
+		// A post rendered into another namespace and promoted keeps that namespace's file until it is saved again
+		if previousPath != "" && previousPath != p.HTMLPath {
+			return r.releaseHTML(txCtx, previousPath)
+		}
+
 		return nil
 	})
 }
 
+const getPostHTMLPathQuery = `
+	SELECT html_path FROM posts WHERE id = ?
+`
+
+// countHTMLPathRefsQuery counts the posts using a file in both namespaces, so it is run as written rather than through query
+const countHTMLPathRefsQuery = `
+	SELECT (SELECT COUNT(*) FROM posts WHERE html_path = ?) + (SELECT COUNT(*) FROM shadow_posts WHERE html_path = ?)
+`
+
+// releaseHTML removes a post file from the blob store unless a post in either namespace still uses it
+func (r *SQLitePostRepository) releaseHTML(ctx context.Context, htmlPath string) error {
+	var refs int
+	executor := db.GetExecutor(ctx, r.db)
+	if err := executor.QueryRowContext(ctx, countHTMLPathRefsQuery, htmlPath, htmlPath).Scan(&refs); err != nil {
+		return fmt.Errorf("failed to count post file references: %w", err)
+	}
+	if refs > 0 {
+		return nil
+	}
+
+	if err := r.blobs.Delete(ctx, htmlPath); err != nil {
+		return fmt.Errorf("failed to remove post file: %w", err)
+	}
+	return nil
+}
+
+const deletePostImagesQuery = `
+	DELETE FROM post_images WHERE post_id = ?
+`
+
+const insertPostImageQuery = `
+	INSERT OR IGNORE INTO post_images (post_id, image_path) VALUES (?, ?)
+`
+
+const markPostImagesRecordedQuery = `
+	UPDATE posts SET images_recorded = 1 WHERE id = ?
+`
+
+// saveImageRefs replaces the recorded image references for a post
+func (r *SQLitePostRepository) saveImageRefs(ctx context.Context, p *domain.Post) error {
+	executor := db.GetExecutor(ctx, r.db)
+	if _, err := executor.ExecContext(ctx, r.query(deletePostImagesQuery), p.ID); err != nil {
+		return fmt.Errorf("failed to clear post image references: %w", err)
+	}
+
+	for _, imagePath := range p.Images {
+		if _, err := executor.ExecContext(ctx, r.query(insertPostImageQuery), p.ID, imagePath); err != nil {
+			return fmt.Errorf("failed to record post image reference: %w", err)
+		}
+	}
+
+	// Lets image garbage collection trust that a post without references uses no images
+	if _, err := executor.ExecContext(ctx, r.query(markPostImagesRecordedQuery), p.ID); err != nil {
+		return fmt.Errorf("failed to mark post image references recorded: %w", err)
+	}
+
+	return nil
+}
+
 const getPostQuery = `
-		SELECT id, title, snippet, html_path, updated_at, published_at, created_at
+		SELECT ` + postColumns + `
 		FROM posts
 		WHERE id = ?
 `
@@ -109,18 +300,10 @@ func (r *SQLitePostRepository) GetPost(ctx context.Context, id string) (*domain.
 	}
 
 	var row postRow
-	err := r.db.QueryRowContext(ctx, getPostQuery, id).Scan(
-		&row.ID,
-		&row.Title,
-		&row.Snippet,
-		&row.HTMLPath,
-		&row.UpdatedAt,
-		&row.PublishedAt,
-		&row.CreatedAt,
-	)
+	err := row.scan(r.db.QueryRowContext(ctx, r.query(getPostQuery), id))
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("post not found: %s", id)
+		return nil, fmt.Errorf("%w: %s", domain.ErrPostNotFound, id)
 	}
 
 	if err != nil {
@@ -130,6 +313,127 @@ func (r *SQLitePostRepository) GetPost(ctx context.Context, id string) (*domain.
 	return row.toDomain(), nil
 }
 
+const getPostBySlugQuery = `
+		SELECT ` + postColumns + `
+		FROM posts
+		WHERE slug = ?
+`
+
+// GetPostBySlug retrieves a single post by slug
+func (r *SQLitePostRepository) GetPostBySlug(ctx context.Context, slug string) (*domain.Post, error) {
+	if slug == "" {
+		return nil, fmt.Errorf("%w: empty slug", domain.ErrPostNotFound)
+	}
+
+	var row postRow
+	err := row.scan(r.db.QueryRowContext(ctx, r.query(getPostBySlugQuery), slug))
+
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", domain.ErrPostNotFound, slug)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get post by slug: %w", err)
+	}
+
+	return row.toDomain(), nil
+}
+
+// GetPostHTML reads the rendered HTML for a post from the blob store, or from the database when it is kept there
+func (r *SQLitePostRepository) GetPostHTML(ctx context.Context, id string) ([]byte, error) {
+	post, err := r.GetPost(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.htmlStorage.Database {
+		return r.getDatabaseHTML(ctx, post)
+	}
+
+	content, err := r.blobs.Get(ctx, post.HTMLPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read post file: %w", err)
+	}
+
+	return content, nil
+}
+
+const getPostHTMLContentQuery = `
+	SELECT html_content FROM posts WHERE id = ?
+`
+
+// getDatabaseHTML reads a post's HTML from the cache, or from the database on a miss
+// Posts saved before HTML was kept in the database have none there, and are read from the blob store instead.
+func (r *SQLitePostRepository) getDatabaseHTML(ctx context.Context, post *domain.Post) ([]byte, error) {
+	if r.htmlStorage.Cache {
+		if content, err := r.blobs.Get(ctx, post.HTMLPath); err == nil {
+			return content, nil
+		}
+	}
+
+	var content sql.Null[[]byte]
+	err := r.db.QueryRowContext(ctx, r.query(getPostHTMLContentQuery), post.ID).Scan(&content)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", domain.ErrPostNotFound, post.ID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get post HTML: %w", err)
+	}
+
+	if !content.Valid {
+		legacy, err := r.blobs.Get(ctx, post.HTMLPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read post file: %w", err)
+		}
+		return legacy, nil
+	}
+
+	r.cacheHTML(ctx, post.HTMLPath, content.V)
+	return content.V, nil
+}
+
+// cacheHTML copies HTML kept in the database to the blob store, if caching is enabled
+// The copy is only an optimisation, so failing to write it is not an error.
+func (r *SQLitePostRepository) cacheHTML(ctx context.Context, htmlPath string, content []byte) {
+	if r.htmlStorage.Cache {
+		_ = r.blobs.Put(ctx, htmlPath, content)
+	}
+}
+
+const getPostDocumentQuery = `
+	SELECT document FROM posts WHERE id = ?
+`
+
+// GetPostDocument reads the structured document of a post, returning nil when none was stored
+func (r *SQLitePostRepository) GetPostDocument(ctx context.Context, id string) (*domain.Document, error) {
+	var encoded sql.NullString
+	err := r.db.QueryRowContext(ctx, r.query(getPostDocumentQuery), id).Scan(&encoded)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", domain.ErrPostNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get post document: %w", err)
+	}
+	if !encoded.Valid {
+		return nil, nil
+	}
+
+	var document domain.Document
+	if err := json.Unmarshal([]byte(encoded.String), &document); err != nil {
+		return nil, fmt.Errorf("failed to decode post document: %w", err)
+	}
+	return &document, nil
+}
+
+// UsageBytes returns the bytes used by rendered post HTML
+func (r *SQLitePostRepository) UsageBytes(ctx context.Context) (int64, error) {
+	size, err := r.blobs.Size(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to measure post storage: %w", err)
+	}
+	return size, nil
+}
+
 const getLatestUpdatedTimeQuery = `
 		SELECT updated_at FROM posts WHERE updated_at IS NOT NULL ORDER BY updated_at DESC LIMIT 1
 `
@@ -137,7 +441,7 @@ const getLatestUpdatedTimeQuery = `
 // GetLatestUpdatedTime returns the latest updated_at time across all posts
 func (r *SQLitePostRepository) GetLatestUpdatedTime(ctx context.Context) (time.Time, error) {
 	var latestUpdated sql.NullTime
-	err := r.db.QueryRowContext(ctx, getLatestUpdatedTimeQuery).Scan(&latestUpdated)
+	err := r.db.QueryRowContext(ctx, r.query(getLatestUpdatedTimeQuery)).Scan(&latestUpdated)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return time.Time{}, nil
@@ -153,7 +457,7 @@ func (r *SQLitePostRepository) GetLatestUpdatedTime(ctx context.Context) (time.T
 }
 
 const listPublishedPostsQuery = `
-	SELECT id, title, snippet, html_path, updated_at, published_at, created_at
+	SELECT ` + postColumns + `
 	FROM posts
 	WHERE published_at IS NOT NULL
 	ORDER BY published_at DESC
@@ -170,7 +474,7 @@ func (r *SQLitePostRepository) ListPublishedPosts(ctx context.Context, limit, of
 		offset = 0
 	}
 
-	rows, err := r.db.QueryContext(ctx, listPublishedPostsQuery, limit, offset)
+	rows, err := r.db.QueryContext(ctx, r.query(listPublishedPostsQuery), limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list published posts: %w", err)
 	}
@@ -179,16 +483,296 @@ func (r *SQLitePostRepository) ListPublishedPosts(ctx context.Context, limit, of
 	posts := make([]*domain.Post, 0)
 	for rows.Next() {
 		var row postRow
-		err := rows.Scan(
-			&row.ID,
-			&row.Title,
-			&row.Snippet,
-			&row.HTMLPath,
-			&row.UpdatedAt,
-			&row.PublishedAt,
-			&row.CreatedAt,
-		)
-		if err != nil {
+		if err := row.scan(rows); err != nil {
+			return nil, fmt.Errorf("failed to scan post row: %w", err)
+		}
+		posts = append(posts, row.toDomain())
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating post rows: %w", err)
+	}
+
+	return posts, nil
+}
+
+const listPublishedPostsPageQuery = `
+	SELECT ` + postColumns + `
+	FROM posts
+	WHERE published_at IS NOT NULL
+	ORDER BY published_at DESC, id DESC
+	LIMIT ?
+`
+
+const listPublishedPostsAfterQuery = `
+	SELECT ` + postColumns + `
+	FROM posts
+	WHERE published_at IS NOT NULL
+	AND (published_at < ? OR (published_at = ? AND id < ?))
+	ORDER BY published_at DESC, id DESC
+	LIMIT ?
+`
+
+const countPublishedPostsQuery = `
+	SELECT COUNT(*) FROM posts WHERE published_at IS NOT NULL
+`
+
+// ListPublishedPostsPage retrieves a page of published posts by keyset pagination on (published_at, id)
+func (r *SQLitePostRepository) ListPublishedPostsPage(ctx context.Context, limit int, after *domain.PostCursor) (*domain.PublishedPostPage, error) {
+	if limit <= 0 {
+		limit = 10 // Default limit
+	}
+
+	page := &domain.PublishedPostPage{Posts: make([]*domain.Post, 0, limit)}
+	if err := r.db.QueryRowContext(ctx, r.query(countPublishedPostsQuery)).Scan(&page.Total); err != nil {
+		return nil, fmt.Errorf("failed to count published posts: %w", err)
+	}
+
+	// One extra post tells whether there is a next page
+	var rows *sql.Rows
+	var err error
+	if after == nil {
+		rows, err = r.db.QueryContext(ctx, r.query(listPublishedPostsPageQuery), limit+1)
+	} else {
+		publishedAt := after.PublishedAt.UTC()
+		rows, err = r.db.QueryContext(ctx, r.query(listPublishedPostsAfterQuery), publishedAt, publishedAt, after.ID, limit+1)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list published posts: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row postRow
+		if err := row.scan(rows); err != nil {
+			return nil, fmt.Errorf("failed to scan post row: %w", err)
+		}
+		page.Posts = append(page.Posts, row.toDomain())
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating post rows: %w", err)
+	}
+
+	if len(page.Posts) > limit {
+		page.Posts = page.Posts[:limit]
+		last := page.Posts[limit-1]
+		page.Next = &domain.PostCursor{PublishedAt: last.PublishedAt, ID: last.ID}
+	}
+
+	return page, nil
+}
+
+const listPublishedPostsBetweenQuery = `
+	SELECT ` + postColumns + `
+	FROM posts
+	WHERE published_at IS NOT NULL
+	AND published_at >= ?
+	AND published_at < ?
+	ORDER BY published_at DESC
+`
+
+// ListPublishedPostsBetween retrieves the posts published in [start, end), newest first
+func (r *SQLitePostRepository) ListPublishedPostsBetween(ctx context.Context, start time.Time, end time.Time) ([]*domain.Post, error) {
+	rows, err := r.db.QueryContext(ctx, r.query(listPublishedPostsBetweenQuery), start.UTC(), end.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list published posts: %w", err)
+	}
+	defer rows.Close()
+
+	posts := make([]*domain.Post, 0)
+	for rows.Next() {
+		var row postRow
+		if err := row.scan(rows); err != nil {
+			return nil, fmt.Errorf("failed to scan post row: %w", err)
+		}
+		posts = append(posts, row.toDomain())
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating post rows: %w", err)
+	}
+
+	return posts, nil
+}
+
+const listPublishTimesQuery = `
+	SELECT published_at
+	FROM posts
+	WHERE published_at IS NOT NULL
+	ORDER BY published_at DESC
+`
+
+// ListPublishTimes retrieves the publication time of every published post, newest first
+func (r *SQLitePostRepository) ListPublishTimes(ctx context.Context) ([]time.Time, error) {
+	rows, err := r.db.QueryContext(ctx, r.query(listPublishTimesQuery))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list publish times: %w", err)
+	}
+	defer rows.Close()
+
+	times := make([]time.Time, 0)
+	for rows.Next() {
+		var publishedAt time.Time
+		if err := rows.Scan(&publishedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan publish time: %w", err)
+		}
+		times = append(times, publishedAt.UTC())
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating publish times: %w", err)
+	}
+
+	return times, nil
+}
+
+const listDuePostsQuery = `
+	SELECT ` + postColumns + `
+	FROM posts
+	WHERE publish_at IS NOT NULL
+	AND publish_at <= ?
+	AND published_at IS NULL
+	ORDER BY publish_at ASC
+`
+
+const listPostsByImageQuery = `
+	SELECT ` + postColumns + `
+	FROM posts
+	WHERE id IN (SELECT post_id FROM post_images WHERE image_path = ?)
+	ORDER BY id
+`
+
+// ListPostsByImage returns the posts that reference the image at the given repository path
+func (r *SQLitePostRepository) ListPostsByImage(ctx context.Context, imagePath string) ([]*domain.Post, error) {
+	rows, err := r.db.QueryContext(ctx, r.query(listPostsByImageQuery), imagePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list posts by image: %w", err)
+	}
+	defer rows.Close()
+
+	posts := make([]*domain.Post, 0)
+	for rows.Next() {
+		var row postRow
+		if err := row.scan(rows); err != nil {
+			return nil, fmt.Errorf("failed to scan post row: %w", err)
+		}
+		posts = append(posts, row.toDomain())
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating post rows: %w", err)
+	}
+
+	return posts, nil
+}
+
+// ListDuePosts returns unpublished posts whose scheduled publish time is at or before now
+func (r *SQLitePostRepository) ListDuePosts(ctx context.Context, now time.Time) ([]*domain.Post, error) {
+	rows, err := r.db.QueryContext(ctx, r.query(listDuePostsQuery), now.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due posts: %w", err)
+	}
+	defer rows.Close()
+
+	posts := make([]*domain.Post, 0)
+	for rows.Next() {
+		var row postRow
+		if err := row.scan(rows); err != nil {
+			return nil, fmt.Errorf("failed to scan post row: %w", err)
+		}
+		posts = append(posts, row.toDomain())
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating post rows: %w", err)
+	}
+
+	return posts, nil
+}
+
+const listUnpublishedPostsQuery = `
+	SELECT ` + postColumns + `
+	FROM posts
+	WHERE published_at IS NULL
+	AND publish_at IS NULL
+	ORDER BY updated_at ASC
+`
+
+// ListUnpublishedPosts returns posts that are neither published nor scheduled, least recently updated first
+func (r *SQLitePostRepository) ListUnpublishedPosts(ctx context.Context) ([]*domain.Post, error) {
+	rows, err := r.db.QueryContext(ctx, r.query(listUnpublishedPostsQuery))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unpublished posts: %w", err)
+	}
+	defer rows.Close()
+
+	posts := make([]*domain.Post, 0)
+	for rows.Next() {
+		var row postRow
+		if err := row.scan(rows); err != nil {
+			return nil, fmt.Errorf("failed to scan post row: %w", err)
+		}
+		posts = append(posts, row.toDomain())
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating post rows: %w", err)
+	}
+
+	return posts, nil
+}
+
+const listScheduledPostsQuery = `
+	SELECT ` + postColumns + `
+	FROM posts
+	WHERE publish_at IS NOT NULL
+	AND published_at IS NULL
+	ORDER BY publish_at ASC
+`
+
+// ListScheduledPosts returns unpublished posts with a scheduled publish time, soonest first
+func (r *SQLitePostRepository) ListScheduledPosts(ctx context.Context) ([]*domain.Post, error) {
+	rows, err := r.db.QueryContext(ctx, r.query(listScheduledPostsQuery))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scheduled posts: %w", err)
+	}
+	defer rows.Close()
+
+	posts := make([]*domain.Post, 0)
+	for rows.Next() {
+		var row postRow
+		if err := row.scan(rows); err != nil {
+			return nil, fmt.Errorf("failed to scan post row: %w", err)
+		}
+		posts = append(posts, row.toDomain())
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating post rows: %w", err)
+	}
+
+	return posts, nil
+}
+
+const listPostsQuery = `
+	SELECT ` + postColumns + `
+	FROM posts
+	ORDER BY id ASC
+`
+
+// ListPosts returns every post, whatever its state, ordered by ID
+func (r *SQLitePostRepository) ListPosts(ctx context.Context) ([]*domain.Post, error) {
+	rows, err := r.db.QueryContext(ctx, r.query(listPostsQuery))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list posts: %w", err)
+	}
+	defer rows.Close()
+
+	posts := make([]*domain.Post, 0)
+	for rows.Next() {
+		var row postRow
+		if err := row.scan(rows); err != nil {
 			return nil, fmt.Errorf("failed to scan post row: %w", err)
 		}
 		posts = append(posts, row.toDomain())
@@ -219,8 +803,8 @@ func (r *SQLitePostRepository) Publish(ctx context.Context, postID string) error
 		return fmt.Errorf("post ID cannot be empty")
 	}
 
-	now := time.Now().UTC()
-	query := publishPostQuery
+	now := r.clock.Now().UTC()
+	query := r.query(publishPostQuery)
 	_, err := r.db.ExecContext(ctx, query, now, now, postID)
 	if err != nil {
 		return fmt.Errorf("failed to publish post: %w", err)
@@ -235,8 +819,8 @@ func (r *SQLitePostRepository) Unpublish(ctx context.Context, postID string) err
 		return fmt.Errorf("post ID cannot be empty")
 	}
 
-	now := time.Now().UTC()
-	query := unpublishPostQuery
+	now := r.clock.Now().UTC()
+	query := r.query(unpublishPostQuery)
 	_, err := r.db.ExecContext(ctx, query, now, postID)
 	if err != nil {
 		return fmt.Errorf("failed to unpublish post: %w", err)
@@ -245,26 +829,95 @@ func (r *SQLitePostRepository) Unpublish(ctx context.Context, postID string) err
 	return nil
 }
 
+const deletePostQuery = `
+	DELETE FROM posts WHERE id = ?
+`
+
+// DeletePost removes a post from the database and its HTML from the blob store within a transaction
+// Image references are removed along with the post by the post_images foreign key.
+func (r *SQLitePostRepository) DeletePost(ctx context.Context, postID string) error {
+	post, err := r.GetPost(ctx, postID)
+	if err != nil {
+		return err
+	}
+
+	return db.RunInTransaction(ctx, r.db, func(txCtx context.Context) error {
+		executor := db.GetExecutor(txCtx, r.db)
+		if _, err := executor.ExecContext(txCtx, r.query(deletePostQuery), postID); err != nil {
+			return fmt.Errorf("failed to delete post: %w", err)
+		}
+
+		// Remove the file last - if this fails, transaction rolls back
+		return r.releaseHTML(txCtx, post.HTMLPath)
+	})
+}
+
 // postRow is a private struct used to scan database rows
 // It uses sql.NullTime to handle nullable timestamp fields
 // and provides a method to convert to the domain.Post model
 type postRow struct {
-	ID          string       `db:"id"`
-	Title       string       `db:"title"`
-	Snippet     string       `db:"snippet"`
-	HTMLPath    string       `db:"html_path"`
-	UpdatedAt   sql.NullTime `db:"updated_at"`
-	PublishedAt sql.NullTime `db:"published_at"`
-	CreatedAt   sql.NullTime `db:"created_at"`
+	ID             string         `db:"id"`
+	Slug           sql.NullString `db:"slug"`
+	Title          string         `db:"title"`
+	Snippet        string         `db:"snippet"`
+	HTMLPath       string         `db:"html_path"`
+	UpdatedAt      sql.NullTime   `db:"updated_at"`
+	PublishedAt    sql.NullTime   `db:"published_at"`
+	CreatedAt      sql.NullTime   `db:"created_at"`
+	PublishAt      sql.NullTime   `db:"publish_at"`
+	SourcePath     sql.NullString `db:"source_path"`
+	Branch         sql.NullString `db:"branch"`
+	WordCount      int            `db:"word_count"`
+	ReadingMinutes int            `db:"reading_minutes"`
+	CommitSHA      sql.NullString `db:"commit_sha"`
+	TOC            sql.NullString `db:"toc"`
+	Signature      sql.NullString `db:"signature"`
+	Language       sql.NullString `db:"language"`
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scan reads the columns listed in postColumns into the row
+func (pr *postRow) scan(s rowScanner) error {
+	return s.Scan(
+		&pr.ID,
+		&pr.Slug,
+		&pr.Title,
+		&pr.Snippet,
+		&pr.HTMLPath,
+		&pr.UpdatedAt,
+		&pr.PublishedAt,
+		&pr.CreatedAt,
+		&pr.PublishAt,
+		&pr.SourcePath,
+		&pr.Branch,
+		&pr.WordCount,
+		&pr.ReadingMinutes,
+		&pr.CommitSHA,
+		&pr.TOC,
+		&pr.Signature,
+		&pr.Language,
+	)
 }
 
 // toDomain converts a postRow to a domain.Post, handling nullable times
 func (pr *postRow) toDomain() *domain.Post {
 	post := &domain.Post{
-		ID:       pr.ID,
-		Title:    pr.Title,
-		Snippet:  pr.Snippet,
-		HTMLPath: pr.HTMLPath,
+		ID:             pr.ID,
+		Slug:           pr.Slug.String,
+		Title:          pr.Title,
+		Snippet:        pr.Snippet,
+		HTMLPath:       pr.HTMLPath,
+		SourcePath:     pr.SourcePath.String,
+		Branch:         pr.Branch.String,
+		CommitSHA:      pr.CommitSHA.String,
+		Signature:      pr.Signature.String,
+		Language:       pr.Language.String,
+		WordCount:      pr.WordCount,
+		ReadingMinutes: pr.ReadingMinutes,
 	}
 
 	if pr.UpdatedAt.Valid {
@@ -276,6 +929,13 @@ func (pr *postRow) toDomain() *domain.Post {
 	if pr.CreatedAt.Valid {
 		post.CreatedAt = pr.CreatedAt.Time
 	}
+	if pr.PublishAt.Valid {
+		post.PublishAt = pr.PublishAt.Time
+	}
+	// The column is only written from a successfully encoded value, so a decoding failure just drops the TOC
+	if pr.TOC.Valid {
+		_ = json.Unmarshal([]byte(pr.TOC.String), &post.TOC)
+	}
 
 	return post
 }
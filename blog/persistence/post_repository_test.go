@@ -3,19 +3,24 @@ package persistence
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/dfryer1193/goblog/blog/domain"
-	_ "modernc.org/sqlite"
+	"github.com/dfryer1193/goblog/shared/blob"
+	"github.com/dfryer1193/goblog/shared/db/sqlite"
 )
 
 func TestNewPostRepository(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
 
-	repo := NewPostRepository(db)
+	repo := NewPostRepository(db, WithBlobStore(blob.NewLocalStore(t.TempDir())))
 	if repo == nil {
 		t.Fatal("NewPostRepository returned nil")
 	}
@@ -27,7 +32,7 @@ func TestNewPostRepository(t *testing.T) {
 func TestPostRepository_UpsertPost_Insert(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
-	repo := NewPostRepository(db)
+	repo := NewPostRepository(db, WithBlobStore(blob.NewLocalStore(t.TempDir())))
 	ctx := context.Background()
 
 	now := time.Now().UTC().Truncate(time.Second)
@@ -40,6 +45,9 @@ func TestPostRepository_UpsertPost_Insert(t *testing.T) {
 		UpdatedAt:   now,
 		PublishedAt: now,
 		CreatedAt:   now,
+
+		WordCount:      450,
+		ReadingMinutes: 3,
 	}
 
 	err := repo.SavePost(ctx, post)
@@ -73,12 +81,139 @@ func TestPostRepository_UpsertPost_Insert(t *testing.T) {
 	if !retrieved.CreatedAt.Equal(post.CreatedAt) {
 		t.Errorf("CreatedAt = %v, want %v", retrieved.CreatedAt, post.CreatedAt)
 	}
+	if retrieved.WordCount != 450 || retrieved.ReadingMinutes != 3 {
+		t.Errorf("WordCount, ReadingMinutes = %d, %d, want 450, 3", retrieved.WordCount, retrieved.ReadingMinutes)
+	}
+}
+
+func TestPostRepository_SavePost_TOC(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	repo := NewPostRepository(db, WithBlobStore(blob.NewLocalStore(t.TempDir())))
+	ctx := context.Background()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	post := &domain.Post{
+		ID:        "001",
+		Title:     "Test Post",
+		HTMLPath:  "001.html",
+		UpdatedAt: now,
+		CreatedAt: now,
+		TOC: []*domain.Heading{
+			{Level: 2, ID: "setup", Text: "Setup", Children: []*domain.Heading{{Level: 3, ID: "install", Text: "Install"}}},
+			{Level: 2, ID: "usage", Text: "Usage"},
+		},
+	}
+	if err := repo.SavePost(ctx, post); err != nil {
+		t.Fatalf("SavePost failed: %v", err)
+	}
+
+	retrieved, err := repo.GetPost(ctx, "001")
+	if err != nil {
+		t.Fatalf("GetPost failed: %v", err)
+	}
+	if len(retrieved.TOC) != 2 || len(retrieved.TOC[0].Children) != 1 || retrieved.TOC[0].Children[0].ID != "install" {
+		t.Errorf("TOC not round-tripped: %+v", retrieved.TOC)
+	}
+
+	post.TOC = nil
+	if err := repo.SavePost(ctx, post); err != nil {
+		t.Fatalf("SavePost failed: %v", err)
+	}
+	retrieved, err = repo.GetPost(ctx, "001")
+	if err != nil {
+		t.Fatalf("GetPost failed: %v", err)
+	}
+	if retrieved.TOC != nil {
+		t.Errorf("Expected TOC to be cleared, got %+v", retrieved.TOC)
+	}
+}
+
+func TestPostRepository_GetPostDocument(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	repo := NewPostRepository(db, WithBlobStore(blob.NewLocalStore(t.TempDir())))
+	ctx := context.Background()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	post := &domain.Post{
+		ID:        "001",
+		Title:     "Test Post",
+		HTMLPath:  "001.html",
+		UpdatedAt: now,
+		CreatedAt: now,
+		Document: &domain.Document{Blocks: []*domain.DocumentNode{
+			{Type: "heading", Level: 1, ID: "title", Children: []*domain.DocumentNode{{Type: "text", Text: "Title"}}},
+		}},
+	}
+	if err := repo.SavePost(ctx, post); err != nil {
+		t.Fatalf("SavePost failed: %v", err)
+	}
+
+	document, err := repo.GetPostDocument(ctx, "001")
+	if err != nil {
+		t.Fatalf("GetPostDocument failed: %v", err)
+	}
+	if document == nil || len(document.Blocks) != 1 || document.Blocks[0].ID != "title" || document.Blocks[0].Children[0].Text != "Title" {
+		t.Errorf("Document not round-tripped: %+v", document)
+	}
+
+	retrieved, err := repo.GetPost(ctx, "001")
+	if err != nil {
+		t.Fatalf("GetPost failed: %v", err)
+	}
+	if retrieved.Document != nil {
+		t.Error("Expected GetPost to leave the document unloaded")
+	}
+
+	post.Document = nil
+	if err := repo.SavePost(ctx, post); err != nil {
+		t.Fatalf("SavePost failed: %v", err)
+	}
+	if document, err := repo.GetPostDocument(ctx, "001"); err != nil || document != nil {
+		t.Errorf("GetPostDocument() after clearing = %+v, %v, want nil", document, err)
+	}
+
+	if _, err := repo.GetPostDocument(ctx, "missing"); !errors.Is(err, domain.ErrPostNotFound) {
+		t.Errorf("expected ErrPostNotFound, got %v", err)
+	}
+}
+
+func TestPostRepository_ListPosts_Signature(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	repo := NewPostRepository(db, WithBlobStore(blob.NewLocalStore(t.TempDir())))
+	ctx := context.Background()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	for _, p := range []*domain.Post{
+		{ID: "002", Title: "Draft", HTMLPath: "002.html", UpdatedAt: now, CreatedAt: now},
+		{ID: "001", Title: "Signed", HTMLPath: "001.html", UpdatedAt: now, CreatedAt: now, PublishedAt: now, Signature: "c2lnbmF0dXJl", Language: "de"},
+	} {
+		if err := repo.SavePost(ctx, p); err != nil {
+			t.Fatalf("SavePost failed: %v", err)
+		}
+	}
+
+	posts, err := repo.ListPosts(ctx)
+	if err != nil {
+		t.Fatalf("ListPosts failed: %v", err)
+	}
+	if len(posts) != 2 || posts[0].ID != "001" || posts[1].ID != "002" {
+		t.Fatalf("Expected posts 001 and 002 in order, got %v", posts)
+	}
+	if posts[0].Signature != "c2lnbmF0dXJl" || posts[1].Signature != "" {
+		t.Errorf("Signatures = %q, %q", posts[0].Signature, posts[1].Signature)
+	}
+	if posts[0].Language != "de" || posts[1].Language != "" {
+		t.Errorf("Languages = %q, %q", posts[0].Language, posts[1].Language)
+	}
 }
 
 func TestPostRepository_UpsertPost_Update(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
-	repo := NewPostRepository(db)
+	repo := NewPostRepository(db, WithBlobStore(blob.NewLocalStore(t.TempDir())))
 	ctx := context.Background()
 
 	now := time.Now().UTC().Truncate(time.Second)
@@ -129,7 +264,7 @@ func TestPostRepository_UpsertPost_Update(t *testing.T) {
 func TestPostRepository_SavePost_NilPost(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
-	repo := NewPostRepository(db)
+	repo := NewPostRepository(db, WithBlobStore(blob.NewLocalStore(t.TempDir())))
 	ctx := context.Background()
 
 	err := repo.SavePost(ctx, nil)
@@ -141,7 +276,7 @@ func TestPostRepository_SavePost_NilPost(t *testing.T) {
 func TestPostRepository_GetPost_NotFound(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
-	repo := NewPostRepository(db)
+	repo := NewPostRepository(db, WithBlobStore(blob.NewLocalStore(t.TempDir())))
 	ctx := context.Background()
 
 	_, err := repo.GetPost(ctx, "nonexistent")
@@ -153,7 +288,7 @@ func TestPostRepository_GetPost_NotFound(t *testing.T) {
 func TestPostRepository_GetPost_EmptyID(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
-	repo := NewPostRepository(db)
+	repo := NewPostRepository(db, WithBlobStore(blob.NewLocalStore(t.TempDir())))
 	ctx := context.Background()
 
 	_, err := repo.GetPost(ctx, "")
@@ -165,7 +300,7 @@ func TestPostRepository_GetPost_EmptyID(t *testing.T) {
 func TestPostRepository_PublishAndUnpublish(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
-	repo := NewPostRepository(db)
+	repo := NewPostRepository(db, WithBlobStore(blob.NewLocalStore(t.TempDir())))
 	ctx := context.Background()
 
 	now := time.Now().UTC().Truncate(time.Second)
@@ -228,7 +363,7 @@ func TestPostRepository_PublishAndUnpublish(t *testing.T) {
 func TestPostRepository_ListPublishedPosts(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
-	repo := NewPostRepository(db)
+	repo := NewPostRepository(db, WithBlobStore(blob.NewLocalStore(t.TempDir())))
 	ctx := context.Background()
 
 	baseTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
@@ -271,7 +406,7 @@ func TestPostRepository_ListPublishedPosts(t *testing.T) {
 func TestPostRepository_ListPublishedPosts_Pagination(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
-	repo := NewPostRepository(db)
+	repo := NewPostRepository(db, WithBlobStore(blob.NewLocalStore(t.TempDir())))
 	ctx := context.Background()
 
 	baseTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
@@ -334,7 +469,7 @@ func TestPostRepository_ListPublishedPosts_Pagination(t *testing.T) {
 func TestPostRepository_ListPublishedPosts_EmptyResult(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
-	repo := NewPostRepository(db)
+	repo := NewPostRepository(db, WithBlobStore(blob.NewLocalStore(t.TempDir())))
 	ctx := context.Background()
 
 	posts, err := repo.ListPublishedPosts(ctx, 10, 0)
@@ -352,7 +487,7 @@ func TestPostRepository_ListPublishedPosts_EmptyResult(t *testing.T) {
 func TestPostRepository_ListPublishedPosts_DefaultLimit(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
-	repo := NewPostRepository(db)
+	repo := NewPostRepository(db, WithBlobStore(blob.NewLocalStore(t.TempDir())))
 	ctx := context.Background()
 
 	baseTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
@@ -384,7 +519,7 @@ func TestPostRepository_ListPublishedPosts_DefaultLimit(t *testing.T) {
 func TestPostRepository_ListPublishedPosts_NegativeOffset(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
-	repo := NewPostRepository(db)
+	repo := NewPostRepository(db, WithBlobStore(blob.NewLocalStore(t.TempDir())))
 	ctx := context.Background()
 
 	post := &domain.Post{
@@ -413,7 +548,7 @@ func TestPostRepository_ListPublishedPosts_NegativeOffset(t *testing.T) {
 func TestPostRepository_GetLatestUpdatedTime(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
-	repo := NewPostRepository(db)
+	repo := NewPostRepository(db, WithBlobStore(blob.NewLocalStore(t.TempDir())))
 	ctx := context.Background()
 
 	// Test with no posts
@@ -471,38 +606,380 @@ func TestPostRepository_InterfaceCompliance(t *testing.T) {
 }
 
 // setupTestDB creates an in-memory SQLite database for testing
+// setupTestDB opens a fresh database with the full production schema applied
 func setupTestDB(t *testing.T) *sql.DB {
 	t.Helper()
-	db, err := sql.Open("sqlite", ":memory:")
-	if err != nil {
+	database := sqlite.NewSQLiteDB(&sqlite.SQLiteConfig{
+		Path: filepath.Join(t.TempDir(), "test.db"),
+	})
+	if err := database.Connect(); err != nil {
 		t.Fatalf("failed to open test database: %v", err)
 	}
 
-	// Create the posts table
-	_, err = db.Exec(`
-		CREATE TABLE posts (
-			id TEXT PRIMARY KEY,
-			title TEXT NOT NULL,
-			snippet TEXT NOT NULL,
-			html_path TEXT NOT NULL,
-			updated_at TIMESTAMP,
-			published_at TIMESTAMP,
-			created_at TIMESTAMP NOT NULL
-		)
-	`)
+	return database.DB()
+}
+
+func TestPostRepository_GetPostHTML(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	repo := NewPostRepository(db, WithBlobStore(blob.NewLocalStore(t.TempDir())))
+	ctx := context.Background()
+
+	post := &domain.Post{
+		ID:          "002",
+		Title:       "HTML Post",
+		HTMLPath:    "002.html",
+		HTMLContent: []byte("<p>rendered</p>"),
+		CreatedAt:   time.Now().UTC(),
+	}
+	if err := repo.SavePost(ctx, post); err != nil {
+		t.Fatalf("SavePost failed: %v", err)
+	}
+
+	content, err := repo.GetPostHTML(ctx, "002")
+	if err != nil {
+		t.Fatalf("GetPostHTML failed: %v", err)
+	}
+	if string(content) != "<p>rendered</p>" {
+		t.Errorf("content = %q, want %q", content, "<p>rendered</p>")
+	}
+
+	_, err = repo.GetPostHTML(ctx, "missing")
+	if !errors.Is(err, domain.ErrPostNotFound) {
+		t.Errorf("expected ErrPostNotFound, got %v", err)
+	}
+}
+
+func TestPostRepository_ListDuePosts(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	repo := NewPostRepository(db, WithBlobStore(blob.NewLocalStore(t.TempDir())))
+	ctx := context.Background()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	posts := []*domain.Post{
+		{ID: "001", Title: "Due", PublishAt: now.Add(-time.Hour)},
+		{ID: "002", Title: "Future", PublishAt: now.Add(time.Hour)},
+		{ID: "003", Title: "Unscheduled"},
+		{ID: "004", Title: "Already published", PublishAt: now.Add(-2 * time.Hour), PublishedAt: now},
+	}
+	for _, p := range posts {
+		p.HTMLPath = p.ID + ".html"
+		p.CreatedAt = now
+		if err := repo.SavePost(ctx, p); err != nil {
+			t.Fatalf("SavePost(%s) failed: %v", p.ID, err)
+		}
+	}
+
+	due, err := repo.ListDuePosts(ctx, now)
+	if err != nil {
+		t.Fatalf("ListDuePosts failed: %v", err)
+	}
+
+	if len(due) != 1 || due[0].ID != "001" {
+		t.Fatalf("due = %v, want only post 001", due)
+	}
+	if !due[0].PublishAt.Equal(now.Add(-time.Hour)) {
+		t.Errorf("PublishAt = %v, want %v", due[0].PublishAt, now.Add(-time.Hour))
+	}
+}
+
+func TestPostRepository_ListDuePosts_ZonedPublishAt(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	repo := NewPostRepository(db, WithBlobStore(blob.NewLocalStore(t.TempDir())))
+	ctx := context.Background()
+
+	// 09:00 CDT is 14:00 UTC; compared as local text it would sort before any afternoon in UTC
+	publishAt := time.Date(2026, time.October, 16, 9, 0, 0, 0, time.FixedZone("CDT", -5*60*60))
+	post := &domain.Post{ID: "001", Title: "Scheduled", HTMLPath: "001.html", CreatedAt: publishAt, PublishAt: publishAt}
+	if err := repo.SavePost(ctx, post); err != nil {
+		t.Fatalf("SavePost failed: %v", err)
+	}
+
+	due, err := repo.ListDuePosts(ctx, time.Date(2026, time.October, 16, 13, 30, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("ListDuePosts failed: %v", err)
+	}
+	if len(due) != 0 {
+		t.Fatalf("due at 13:30 UTC = %v, want none", due)
+	}
+
+	due, err = repo.ListDuePosts(ctx, time.Date(2026, time.October, 16, 14, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("ListDuePosts failed: %v", err)
+	}
+	if len(due) != 1 || due[0].ID != "001" {
+		t.Fatalf("due at 14:00 UTC = %v, want post 001", due)
+	}
+	if !due[0].PublishAt.Equal(publishAt) {
+		t.Errorf("PublishAt = %v, want %v", due[0].PublishAt, publishAt)
+	}
+}
+
+func TestPostRepository_ListPublishedPostsBetween(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	repo := NewPostRepository(db, WithBlobStore(blob.NewLocalStore(t.TempDir())))
+	ctx := context.Background()
+
+	june := time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)
+	posts := []*domain.Post{
+		{ID: "001", Title: "May", PublishedAt: june.Add(-time.Second)},
+		{ID: "002", Title: "Start of June", PublishedAt: june},
+		{ID: "003", Title: "End of June", PublishedAt: june.AddDate(0, 1, 0).Add(-time.Second)},
+		{ID: "004", Title: "July", PublishedAt: june.AddDate(0, 1, 0)},
+		{ID: "005", Title: "Unpublished"},
+	}
+	for _, p := range posts {
+		p.HTMLPath = p.ID + ".html"
+		p.CreatedAt = june
+		if err := repo.SavePost(ctx, p); err != nil {
+			t.Fatalf("SavePost(%s) failed: %v", p.ID, err)
+		}
+	}
+
+	inJune, err := repo.ListPublishedPostsBetween(ctx, june, june.AddDate(0, 1, 0))
+	if err != nil {
+		t.Fatalf("ListPublishedPostsBetween failed: %v", err)
+	}
+	if len(inJune) != 2 || inJune[0].ID != "003" || inJune[1].ID != "002" {
+		t.Errorf("posts in June = %v, want 003 then 002", inJune)
+	}
+
+	times, err := repo.ListPublishTimes(ctx)
+	if err != nil {
+		t.Fatalf("ListPublishTimes failed: %v", err)
+	}
+	if len(times) != 4 || !times[0].Equal(june.AddDate(0, 1, 0)) || !times[3].Equal(june.Add(-time.Second)) {
+		t.Errorf("publish times = %v, want the 4 published posts newest first", times)
+	}
+}
+
+func TestPostRepository_GetPostBySlug(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	repo := NewPostRepository(db, WithBlobStore(blob.NewLocalStore(t.TempDir())))
+	ctx := context.Background()
+
+	now := time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)
+	for _, p := range []*domain.Post{
+		{ID: "001", Slug: "my-post", Title: "Mine", HTMLPath: "001.html", CreatedAt: now},
+		{ID: "002", Title: "No slug", HTMLPath: "002.html", CreatedAt: now},
+		{ID: "003", Title: "Also no slug", HTMLPath: "003.html", CreatedAt: now},
+	} {
+		if err := repo.SavePost(ctx, p); err != nil {
+			t.Fatalf("SavePost(%s) failed: %v", p.ID, err)
+		}
+	}
+
+	post, err := repo.GetPostBySlug(ctx, "my-post")
+	if err != nil {
+		t.Fatalf("GetPostBySlug failed: %v", err)
+	}
+	if post.ID != "001" || post.Slug != "my-post" {
+		t.Errorf("GetPostBySlug() = %+v, want post 001", post)
+	}
+
+	for _, slug := range []string{"", "missing"} {
+		if _, err := repo.GetPostBySlug(ctx, slug); !errors.Is(err, domain.ErrPostNotFound) {
+			t.Errorf("GetPostBySlug(%q) error = %v, want ErrPostNotFound", slug, err)
+		}
+	}
+
+	if err := repo.SavePost(ctx, &domain.Post{ID: "002", Slug: "my-post", HTMLPath: "002.html", CreatedAt: now}); err == nil {
+		t.Error("Expected saving a second post with the same slug to fail")
+	}
+}
+
+func TestPostRepository_ListPublishedPostsPage(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	repo := NewPostRepository(db, WithBlobStore(blob.NewLocalStore(t.TempDir())))
+	ctx := context.Background()
+
+	published := time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)
+	posts := []*domain.Post{
+		{ID: "001", Title: "Oldest", PublishedAt: published},
+		{ID: "002", Title: "Same time, lower ID", PublishedAt: published.Add(time.Hour)},
+		{ID: "003", Title: "Same time, higher ID", PublishedAt: published.Add(time.Hour)},
+		{ID: "004", Title: "Newest", PublishedAt: published.Add(2 * time.Hour)},
+		{ID: "005", Title: "Unpublished"},
+	}
+	for _, p := range posts {
+		p.HTMLPath = p.ID + ".html"
+		p.CreatedAt = published
+		if err := repo.SavePost(ctx, p); err != nil {
+			t.Fatalf("SavePost(%s) failed: %v", p.ID, err)
+		}
+	}
+
+	var ids []string
+	var after *domain.PostCursor
+	for pages := 0; ; pages++ {
+		if pages > 2 {
+			t.Fatalf("Expected 2 pages, got more: %v", ids)
+		}
+		page, err := repo.ListPublishedPostsPage(ctx, 2, after)
+		if err != nil {
+			t.Fatalf("ListPublishedPostsPage failed: %v", err)
+		}
+		if page.Total != 4 {
+			t.Errorf("Total = %d, want 4", page.Total)
+		}
+		for _, p := range page.Posts {
+			ids = append(ids, p.ID)
+		}
+		if page.Next == nil {
+			break
+		}
+		after = page.Next
+	}
+
+	if strings.Join(ids, ",") != "004,003,002,001" {
+		t.Errorf("pages listed %v, want 004,003,002,001", ids)
+	}
+}
+
+func TestPostRepository_ListPostsByImage(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	repo := NewPostRepository(db, WithBlobStore(blob.NewLocalStore(t.TempDir())))
+	ctx := context.Background()
+
+	posts := []*domain.Post{
+		{ID: "001", Title: "With image", SourcePath: "posts/001-with-image.md", Images: []string{"images/cat.png"}},
+		{ID: "002", Title: "Other image", Images: []string{"images/dog.png"}},
+	}
+	for _, p := range posts {
+		p.HTMLPath = p.ID + ".html"
+		p.CreatedAt = time.Now().UTC()
+		if err := repo.SavePost(ctx, p); err != nil {
+			t.Fatalf("SavePost(%s) failed: %v", p.ID, err)
+		}
+	}
+
+	found, err := repo.ListPostsByImage(ctx, "images/cat.png")
 	if err != nil {
-		t.Fatalf("failed to create posts table: %v", err)
+		t.Fatalf("ListPostsByImage failed: %v", err)
+	}
+
+	if len(found) != 1 || found[0].ID != "001" {
+		t.Fatalf("found = %v, want only post 001", found)
+	}
+	if found[0].SourcePath != "posts/001-with-image.md" {
+		t.Errorf("SourcePath = %q, want %q", found[0].SourcePath, "posts/001-with-image.md")
 	}
+}
+
+func TestPostRepository_DeletePost(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	dir := t.TempDir()
+	repo := NewPostRepository(db, WithBlobStore(blob.NewLocalStore(dir)))
+	ctx := context.Background()
 
-	// Create index
-	_, err = db.Exec(`
-		CREATE INDEX idx_posts_published_at
-		ON posts(published_at DESC)
-		WHERE published_at IS NOT NULL
-	`)
+	post := &domain.Post{
+		ID:          "003",
+		Title:       "Deleted Post",
+		HTMLPath:    "003.html",
+		HTMLContent: []byte("<p>gone</p>"),
+		Images:      []string{"images/a.png"},
+		CreatedAt:   time.Now().UTC(),
+	}
+	if err := repo.SavePost(ctx, post); err != nil {
+		t.Fatalf("SavePost failed: %v", err)
+	}
+
+	if err := repo.DeletePost(ctx, "003"); err != nil {
+		t.Fatalf("DeletePost failed: %v", err)
+	}
+
+	if _, err := repo.GetPost(ctx, "003"); !errors.Is(err, domain.ErrPostNotFound) {
+		t.Errorf("expected ErrPostNotFound after delete, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "003.html")); !os.IsNotExist(err) {
+		t.Errorf("expected post file to be removed, stat error = %v", err)
+	}
+
+	posts, err := repo.ListPostsByImage(ctx, "images/a.png")
 	if err != nil {
-		t.Fatalf("failed to create index: %v", err)
+		t.Fatalf("ListPostsByImage failed: %v", err)
+	}
+	if len(posts) != 0 {
+		t.Errorf("expected image references to be removed, got %d posts", len(posts))
 	}
 
-	return db
+	if err := repo.DeletePost(ctx, "003"); !errors.Is(err, domain.ErrPostNotFound) {
+		t.Errorf("expected ErrPostNotFound deleting a missing post, got %v", err)
+	}
+}
+
+func TestPostRepository_ListUnpublishedPosts(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	repo := NewPostRepository(db, WithBlobStore(blob.NewLocalStore(t.TempDir())))
+	ctx := context.Background()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	posts := []*domain.Post{
+		{ID: "001", Title: "Newer draft", Branch: "feature", UpdatedAt: now},
+		{ID: "002", Title: "Older draft", Branch: "other", UpdatedAt: now.Add(-time.Hour)},
+		{ID: "003", Title: "Scheduled", Branch: "main", UpdatedAt: now, PublishAt: now.Add(time.Hour)},
+		{ID: "004", Title: "Published", Branch: "main", UpdatedAt: now, PublishedAt: now},
+	}
+	for _, p := range posts {
+		p.HTMLPath = p.ID + ".html"
+		p.CreatedAt = now
+		if err := repo.SavePost(ctx, p); err != nil {
+			t.Fatalf("SavePost(%s) failed: %v", p.ID, err)
+		}
+	}
+
+	unpublished, err := repo.ListUnpublishedPosts(ctx)
+	if err != nil {
+		t.Fatalf("ListUnpublishedPosts failed: %v", err)
+	}
+
+	if len(unpublished) != 2 || unpublished[0].ID != "002" || unpublished[1].ID != "001" {
+		t.Fatalf("unpublished = %v, want posts 002 then 001", unpublished)
+	}
+	if unpublished[0].Branch != "other" {
+		t.Errorf("Branch = %q, want %q", unpublished[0].Branch, "other")
+	}
+}
+
+func TestPostRepository_ListScheduledPosts(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	repo := NewPostRepository(db, WithBlobStore(blob.NewLocalStore(t.TempDir())))
+	ctx := context.Background()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	posts := []*domain.Post{
+		{ID: "001", Title: "Later", CommitSHA: "abc", PublishAt: now.Add(2 * time.Hour)},
+		{ID: "002", Title: "Sooner", CommitSHA: "def", PublishAt: now.Add(time.Hour)},
+		{ID: "003", Title: "Draft"},
+		{ID: "004", Title: "Published", PublishAt: now.Add(-time.Hour), PublishedAt: now},
+	}
+	for _, p := range posts {
+		p.HTMLPath = p.ID + ".html"
+		p.CreatedAt = now
+		p.UpdatedAt = now
+		if err := repo.SavePost(ctx, p); err != nil {
+			t.Fatalf("SavePost(%s) failed: %v", p.ID, err)
+		}
+	}
+
+	scheduled, err := repo.ListScheduledPosts(ctx)
+	if err != nil {
+		t.Fatalf("ListScheduledPosts failed: %v", err)
+	}
+
+	if len(scheduled) != 2 || scheduled[0].ID != "002" || scheduled[1].ID != "001" {
+		t.Fatalf("scheduled = %v, want posts 002 then 001", scheduled)
+	}
+	if scheduled[0].CommitSHA != "def" {
+		t.Errorf("CommitSHA = %q, want %q", scheduled[0].CommitSHA, "def")
+	}
 }
@@ -0,0 +1,84 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/db"
+)
+
+var _ domain.PostViewRepository = (*SQLitePostViewRepository)(nil)
+
+// postViewDayLayout is how days are stored, so they sort and compare as text
+const postViewDayLayout = "2006-01-02"
+
+// SQLitePostViewRepository implements domain.PostViewRepository using SQL database (SQLite)
+type SQLitePostViewRepository struct {
+	db *sql.DB
+}
+
+// NewPostViewRepository creates a new SQLitePostViewRepository from a standard sql.DB
+func NewPostViewRepository(db *sql.DB) *SQLitePostViewRepository {
+	return &SQLitePostViewRepository{
+		db: db,
+	}
+}
+
+// addPostViewsQuery skips posts deleted since they were viewed, rather than failing the batch on the foreign key
+const addPostViewsQuery = `
+	INSERT INTO post_views (post_id, day, views)
+	SELECT id, ?, ? FROM posts WHERE id = ?
+	ON CONFLICT(post_id, day) DO UPDATE SET
+		views = views + excluded.views
+`
+
+// AddPostViews adds views to the counts of day in one transaction
+func (r *SQLitePostViewRepository) AddPostViews(ctx context.Context, day time.Time, views map[string]int) error {
+	dayKey := day.Format(postViewDayLayout)
+	return db.RunInTransaction(ctx, r.db, func(ctx context.Context) error {
+		executor := db.GetExecutor(ctx, r.db)
+		for postID, n := range views {
+			if _, err := executor.ExecContext(ctx, addPostViewsQuery, dayKey, n, postID); err != nil {
+				return fmt.Errorf("failed to add views of post %s: %w", postID, err)
+			}
+		}
+		return nil
+	})
+}
+
+const listPopularPostsQuery = `
+	SELECT v.post_id, SUM(v.views) AS total
+	FROM post_views v
+	JOIN posts p ON p.id = v.post_id
+	WHERE v.day >= ? AND p.published_at IS NOT NULL
+	GROUP BY v.post_id
+	ORDER BY total DESC, v.post_id
+	LIMIT ?
+`
+
+// ListPopularPosts returns the published posts with the most views on days from since onwards, most viewed first
+func (r *SQLitePostViewRepository) ListPopularPosts(ctx context.Context, since time.Time, limit int) ([]*domain.PostViews, error) {
+	rows, err := r.db.QueryContext(ctx, listPopularPostsQuery, since.Format(postViewDayLayout), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list popular posts: %w", err)
+	}
+	defer rows.Close()
+
+	popular := make([]*domain.PostViews, 0)
+	for rows.Next() {
+		var views domain.PostViews
+		if err := rows.Scan(&views.PostID, &views.Views); err != nil {
+			return nil, fmt.Errorf("failed to scan post views row: %w", err)
+		}
+		popular = append(popular, &views)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating post views rows: %w", err)
+	}
+
+	return popular, nil
+}
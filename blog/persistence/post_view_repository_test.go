@@ -0,0 +1,67 @@
+package persistence
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/blob"
+)
+
+func TestPostViewRepository(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	posts := NewPostRepository(db, WithBlobStore(blob.NewLocalStore(t.TempDir())))
+	repo := NewPostViewRepository(db)
+	ctx := context.Background()
+
+	published := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, post := range []*domain.Post{
+		{ID: "001", PublishedAt: published},
+		{ID: "002", PublishedAt: published},
+		{ID: "003"},
+	} {
+		post.Title, post.HTMLPath, post.HTMLContent, post.CreatedAt = "Post "+post.ID, post.ID+".html", []byte("<p></p>"), published
+		if err := posts.SavePost(ctx, post); err != nil {
+			t.Fatalf("SavePost(%s) error = %v", post.ID, err)
+		}
+	}
+
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 0, 0, 0, 0, time.UTC) }
+	for _, batch := range []struct {
+		day   time.Time
+		views map[string]int
+	}{
+		{day(1), map[string]int{"001": 1, "002": 50}},
+		{day(30), map[string]int{"001": 2, "002": 3, "003": 100, "gone": 7}},
+		{day(31), map[string]int{"001": 4}},
+		{day(31), map[string]int{"001": 1}},
+	} {
+		if err := repo.AddPostViews(ctx, batch.day, batch.views); err != nil {
+			t.Fatalf("AddPostViews(%s) error = %v", batch.day.Format(time.DateOnly), err)
+		}
+	}
+
+	popular, err := repo.ListPopularPosts(ctx, day(30), 10)
+	if err != nil {
+		t.Fatalf("ListPopularPosts() error = %v", err)
+	}
+	if len(popular) != 2 || *popular[0] != (domain.PostViews{PostID: "001", Views: 7}) || *popular[1] != (domain.PostViews{PostID: "002", Views: 3}) {
+		t.Errorf("ListPopularPosts() = %+v, want 001 with 7 views then 002 with 3, leaving out the draft", popular)
+	}
+
+	popular, err = repo.ListPopularPosts(ctx, day(1), 1)
+	if err != nil || len(popular) != 1 || popular[0].PostID != "002" || popular[0].Views != 53 {
+		t.Errorf("ListPopularPosts(limit 1) = %+v, %v, want 002 with 53 views", popular, err)
+	}
+
+	if err := posts.DeletePost(ctx, "002"); err != nil {
+		t.Fatalf("DeletePost() error = %v", err)
+	}
+	popular, err = repo.ListPopularPosts(ctx, day(1), 10)
+	if err != nil || len(popular) != 1 || popular[0].PostID != "001" {
+		t.Errorf("ListPopularPosts() after deleting 002 = %+v, %v, want only 001", popular, err)
+	}
+}
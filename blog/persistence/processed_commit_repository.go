@@ -0,0 +1,104 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/clock"
+	"github.com/dfryer1193/goblog/shared/db"
+)
+
+var _ domain.ProcessedCommitRepository = (*SQLiteProcessedCommitRepository)(nil)
+
+// SQLiteProcessedCommitRepository implements domain.ProcessedCommitRepository using SQL database (SQLite)
+type SQLiteProcessedCommitRepository struct {
+	db    *sql.DB
+	clock clock.Clock
+}
+
+// NewProcessedCommitRepository creates a new SQLiteProcessedCommitRepository from a standard sql.DB
+func NewProcessedCommitRepository(db *sql.DB, opts ...Option) *SQLiteProcessedCommitRepository {
+	o := newOptions(opts)
+	return &SQLiteProcessedCommitRepository{
+		db:    db,
+		clock: o.clock,
+	}
+}
+
+const listProcessedCommitsQuery = `
+	SELECT sha FROM processed_commits
+	WHERE branch = ? AND sha IN (%s)
+`
+
+// ListProcessed returns the SHAs among shas that have already been processed on branch
+func (r *SQLiteProcessedCommitRepository) ListProcessed(ctx context.Context, branch string, shas []string) ([]string, error) {
+	processed := make([]string, 0)
+	if len(shas) == 0 {
+		return processed, nil
+	}
+
+	args := make([]any, 0, len(shas)+1)
+	args = append(args, branch)
+	for _, sha := range shas {
+		args = append(args, sha)
+	}
+
+	query := fmt.Sprintf(listProcessedCommitsQuery, strings.TrimSuffix(strings.Repeat("?, ", len(shas)), ", "))
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processed commits: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sha string
+		if err := rows.Scan(&sha); err != nil {
+			return nil, fmt.Errorf("failed to scan processed commit row: %w", err)
+		}
+		processed = append(processed, sha)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating processed commit rows: %w", err)
+	}
+
+	return processed, nil
+}
+
+const markProcessedCommitQuery = `
+	INSERT INTO processed_commits (branch, sha, processed_at)
+	VALUES (?, ?, ?)
+	ON CONFLICT(branch, sha) DO UPDATE SET
+		processed_at = excluded.processed_at
+`
+
+// MarkProcessed records that the commits have been processed on branch
+func (r *SQLiteProcessedCommitRepository) MarkProcessed(ctx context.Context, branch string, shas []string) error {
+	now := r.clock.Now().UTC()
+	return db.RunInTransaction(ctx, r.db, func(txCtx context.Context) error {
+		executor := db.GetExecutor(txCtx, r.db)
+		for _, sha := range shas {
+			if _, err := executor.ExecContext(txCtx, markProcessedCommitQuery, branch, sha, now); err != nil {
+				return fmt.Errorf("failed to mark commit %s processed: %w", sha, err)
+			}
+		}
+		return nil
+	})
+}
+
+const pruneProcessedCommitsQuery = `
+	DELETE FROM processed_commits WHERE processed_at < ?
+`
+
+// PruneProcessed forgets commits processed before the given time
+func (r *SQLiteProcessedCommitRepository) PruneProcessed(ctx context.Context, before time.Time) error {
+	executor := db.GetExecutor(ctx, r.db)
+	if _, err := executor.ExecContext(ctx, pruneProcessedCommitsQuery, before); err != nil {
+		return fmt.Errorf("failed to prune processed commits: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package persistence
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/shared/clock"
+)
+
+func TestProcessedCommitRepository(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	repo := NewProcessedCommitRepository(db, WithClock(clock.Fixed(now)))
+	ctx := context.Background()
+
+	if err := repo.MarkProcessed(ctx, "main", []string{"a", "b"}); err != nil {
+		t.Fatalf("MarkProcessed failed: %v", err)
+	}
+	if err := repo.MarkProcessed(ctx, "feature", []string{"c"}); err != nil {
+		t.Fatalf("MarkProcessed failed: %v", err)
+	}
+
+	processed, err := repo.ListProcessed(ctx, "main", []string{"a", "c", "d"})
+	if err != nil {
+		t.Fatalf("ListProcessed failed: %v", err)
+	}
+	if !slices.Equal(processed, []string{"a"}) {
+		t.Errorf("processed = %v, want [a]", processed)
+	}
+
+	if err := repo.PruneProcessed(ctx, now.Add(time.Second)); err != nil {
+		t.Fatalf("PruneProcessed failed: %v", err)
+	}
+	processed, err = repo.ListProcessed(ctx, "main", []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("ListProcessed failed: %v", err)
+	}
+	if len(processed) != 0 {
+		t.Errorf("processed after prune = %v, want none", processed)
+	}
+}
@@ -0,0 +1,132 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/clock"
+	"github.com/dfryer1193/goblog/shared/db"
+)
+
+var _ domain.ReaderPreferencesRepository = (*SQLiteReaderPreferencesRepository)(nil)
+
+// SQLiteReaderPreferencesRepository implements domain.ReaderPreferencesRepository using SQL database (SQLite)
+type SQLiteReaderPreferencesRepository struct {
+	db    *sql.DB
+	clock clock.Clock
+}
+
+// NewReaderPreferencesRepository creates a new SQLiteReaderPreferencesRepository from a standard sql.DB
+func NewReaderPreferencesRepository(db *sql.DB, opts ...Option) *SQLiteReaderPreferencesRepository {
+	o := newOptions(opts)
+	return &SQLiteReaderPreferencesRepository{
+		db:    db,
+		clock: o.clock,
+	}
+}
+
+const getReaderPreferencesQuery = `
+	UPDATE reader_preferences
+	SET last_seen_at = ?
+	WHERE token_hash = ? AND last_seen_at >= ?
+	RETURNING theme, font_size, updated_at, last_seen_at
+`
+
+// GetPreferences returns the unexpired preferences stored under hash and marks them as seen
+func (r *SQLiteReaderPreferencesRepository) GetPreferences(ctx context.Context, hash string, cutoff time.Time) (*domain.ReaderPreferences, error) {
+	executor := db.GetExecutor(ctx, r.db)
+	var prefs domain.ReaderPreferences
+	err := executor.QueryRowContext(ctx, getReaderPreferencesQuery, r.clock.Now().UTC(), hash, cutoff.UTC()).Scan(
+		&prefs.Theme,
+		&prefs.FontSize,
+		&prefs.UpdatedAt,
+		&prefs.LastSeenAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrReaderPreferencesNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reader preferences: %w", err)
+	}
+
+	return &prefs, nil
+}
+
+const saveReaderPreferencesQuery = `
+	INSERT INTO reader_preferences (token_hash, theme, font_size, updated_at, last_seen_at)
+	VALUES (?, ?, ?, ?, ?)
+	ON CONFLICT(token_hash) DO UPDATE SET
+		theme = excluded.theme,
+		font_size = excluded.font_size,
+		updated_at = excluded.updated_at,
+		last_seen_at = excluded.last_seen_at
+`
+
+// SavePreferences creates or replaces the preferences stored under hash
+func (r *SQLiteReaderPreferencesRepository) SavePreferences(ctx context.Context, hash string, prefs *domain.ReaderPreferences) error {
+	if prefs == nil {
+		return fmt.Errorf("reader preferences cannot be nil")
+	}
+
+	if hash == "" {
+		return fmt.Errorf("reader preferences hash cannot be empty")
+	}
+
+	now := r.clock.Now().UTC()
+	prefs.UpdatedAt = now
+	prefs.LastSeenAt = now
+
+	executor := db.GetExecutor(ctx, r.db)
+	if _, err := executor.ExecContext(ctx, saveReaderPreferencesQuery, hash, prefs.Theme, prefs.FontSize, now, now); err != nil {
+		return fmt.Errorf("failed to save reader preferences: %w", err)
+	}
+
+	return nil
+}
+
+const deleteReaderPreferencesQuery = `
+	DELETE FROM reader_preferences WHERE token_hash = ?
+`
+
+// DeletePreferences removes the preferences stored under hash
+func (r *SQLiteReaderPreferencesRepository) DeletePreferences(ctx context.Context, hash string) error {
+	executor := db.GetExecutor(ctx, r.db)
+	result, err := executor.ExecContext(ctx, deleteReaderPreferencesQuery, hash)
+	if err != nil {
+		return fmt.Errorf("failed to delete reader preferences: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check deleted reader preferences: %w", err)
+	}
+	if affected == 0 {
+		return domain.ErrReaderPreferencesNotFound
+	}
+
+	return nil
+}
+
+const deleteUnseenReaderPreferencesQuery = `
+	DELETE FROM reader_preferences WHERE last_seen_at < ?
+`
+
+// DeleteUnseenPreferences removes preferences last seen before cutoff
+func (r *SQLiteReaderPreferencesRepository) DeleteUnseenPreferences(ctx context.Context, cutoff time.Time) (int, error) {
+	executor := db.GetExecutor(ctx, r.db)
+	result, err := executor.ExecContext(ctx, deleteUnseenReaderPreferencesQuery, cutoff.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired reader preferences: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count deleted reader preferences: %w", err)
+	}
+
+	return int(affected), nil
+}
@@ -0,0 +1,58 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/clock"
+)
+
+func TestReaderPreferencesRepository(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := NewReaderPreferencesRepository(db, WithClock(clock.Func(func() time.Time { return now })))
+	ctx := context.Background()
+
+	if err := repo.SavePreferences(ctx, "hash-1", &domain.ReaderPreferences{Theme: "dark", FontSize: "large"}); err != nil {
+		t.Fatalf("Failed to save preferences: %v", err)
+	}
+	if err := repo.SavePreferences(ctx, "hash-1", &domain.ReaderPreferences{Theme: "light"}); err != nil {
+		t.Fatalf("Failed to replace preferences: %v", err)
+	}
+	if err := repo.SavePreferences(ctx, "hash-2", &domain.ReaderPreferences{Theme: "dark"}); err != nil {
+		t.Fatalf("Failed to save preferences: %v", err)
+	}
+
+	now = now.Add(time.Hour)
+	prefs, err := repo.GetPreferences(ctx, "hash-1", now.Add(-2*time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to get preferences: %v", err)
+	}
+	if prefs.Theme != "light" || prefs.FontSize != "" || !prefs.LastSeenAt.Equal(now) || !prefs.UpdatedAt.Equal(now.Add(-time.Hour)) {
+		t.Errorf("Unexpected preferences: %+v", prefs)
+	}
+
+	if _, err := repo.GetPreferences(ctx, "hash-2", now.Add(-time.Minute)); !errors.Is(err, domain.ErrReaderPreferencesNotFound) {
+		t.Errorf("Expected preferences unseen since the cutoff to be not found, got %v", err)
+	}
+
+	deleted, err := repo.DeleteUnseenPreferences(ctx, now.Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("Failed to delete unseen preferences: %v", err)
+	}
+	if deleted != 1 {
+		t.Errorf("Expected 1 unseen preference to be deleted, got %d", deleted)
+	}
+
+	if err := repo.DeletePreferences(ctx, "hash-1"); err != nil {
+		t.Fatalf("Failed to delete preferences: %v", err)
+	}
+	if err := repo.DeletePreferences(ctx, "hash-1"); !errors.Is(err, domain.ErrReaderPreferencesNotFound) {
+		t.Errorf("Expected deleting twice to fail with ErrReaderPreferencesNotFound, got %v", err)
+	}
+}
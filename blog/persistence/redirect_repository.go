@@ -0,0 +1,132 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/clock"
+	"github.com/dfryer1193/goblog/shared/db"
+)
+
+var _ domain.RedirectRepository = (*SQLiteRedirectRepository)(nil)
+
+// SQLiteRedirectRepository implements domain.RedirectRepository using SQL database (SQLite)
+type SQLiteRedirectRepository struct {
+	db    *sql.DB
+	clock clock.Clock
+}
+
+// NewRedirectRepository creates a new SQLiteRedirectRepository from a standard sql.DB
+func NewRedirectRepository(db *sql.DB, opts ...Option) *SQLiteRedirectRepository {
+	o := newOptions(opts)
+	return &SQLiteRedirectRepository{
+		db:    db,
+		clock: o.clock,
+	}
+}
+
+const saveRedirectQuery = `
+	INSERT INTO redirects (from_slug, post_id, created_at)
+	VALUES (?, ?, ?)
+	ON CONFLICT(from_slug) DO UPDATE SET
+		post_id = excluded.post_id,
+		created_at = excluded.created_at
+`
+
+// SaveRedirect records a redirect, replacing any other from the same slug
+func (r *SQLiteRedirectRepository) SaveRedirect(ctx context.Context, redirect *domain.Redirect) error {
+	if redirect == nil {
+		return fmt.Errorf("redirect cannot be nil")
+	}
+
+	if redirect.FromSlug == "" || redirect.PostID == "" {
+		return fmt.Errorf("redirect needs a slug and a post ID")
+	}
+
+	if redirect.CreatedAt.IsZero() {
+		redirect.CreatedAt = r.clock.Now().UTC()
+	}
+
+	executor := db.GetExecutor(ctx, r.db)
+	if _, err := executor.ExecContext(ctx, saveRedirectQuery, redirect.FromSlug, redirect.PostID, redirect.CreatedAt); err != nil {
+		return fmt.Errorf("failed to save redirect: %w", err)
+	}
+
+	return nil
+}
+
+const getRedirectQuery = `
+	SELECT from_slug, post_id, created_at
+	FROM redirects
+	WHERE from_slug = ?
+`
+
+// GetRedirect returns the redirect from slug
+func (r *SQLiteRedirectRepository) GetRedirect(ctx context.Context, slug string) (*domain.Redirect, error) {
+	var redirect domain.Redirect
+	err := r.db.QueryRowContext(ctx, getRedirectQuery, slug).Scan(&redirect.FromSlug, &redirect.PostID, &redirect.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", domain.ErrRedirectNotFound, slug)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get redirect: %w", err)
+	}
+
+	return &redirect, nil
+}
+
+const listRedirectsQuery = `
+	SELECT from_slug, post_id, created_at
+	FROM redirects
+	ORDER BY created_at DESC, from_slug
+`
+
+// ListRedirects returns every redirect, newest first
+func (r *SQLiteRedirectRepository) ListRedirects(ctx context.Context) ([]*domain.Redirect, error) {
+	rows, err := r.db.QueryContext(ctx, listRedirectsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list redirects: %w", err)
+	}
+	defer rows.Close()
+
+	redirects := make([]*domain.Redirect, 0)
+	for rows.Next() {
+		var redirect domain.Redirect
+		if err := rows.Scan(&redirect.FromSlug, &redirect.PostID, &redirect.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan redirect row: %w", err)
+		}
+		redirects = append(redirects, &redirect)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating redirect rows: %w", err)
+	}
+
+	return redirects, nil
+}
+
+const deleteRedirectQuery = `
+	DELETE FROM redirects WHERE from_slug = ?
+`
+
+// DeleteRedirect removes the redirect from slug
+func (r *SQLiteRedirectRepository) DeleteRedirect(ctx context.Context, slug string) error {
+	executor := db.GetExecutor(ctx, r.db)
+	result, err := executor.ExecContext(ctx, deleteRedirectQuery, slug)
+	if err != nil {
+		return fmt.Errorf("failed to delete redirect: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check deleted redirect: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrRedirectNotFound, slug)
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/blob"
+	"github.com/dfryer1193/goblog/shared/clock"
+)
+
+func TestRedirectRepository(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	posts := NewPostRepository(db, WithBlobStore(blob.NewLocalStore(t.TempDir())))
+	repo := NewRedirectRepository(db, WithClock(clock.Func(func() time.Time { return now })))
+	ctx := context.Background()
+
+	for _, id := range []string{"001", "002"} {
+		post := &domain.Post{ID: id, Title: "Post " + id, HTMLPath: id + ".html", HTMLContent: []byte("<p></p>"), CreatedAt: now}
+		if err := posts.SavePost(ctx, post); err != nil {
+			t.Fatalf("SavePost(%s) error = %v", id, err)
+		}
+	}
+
+	if err := repo.SaveRedirect(ctx, &domain.Redirect{FromSlug: "old-name", PostID: "001"}); err != nil {
+		t.Fatalf("Failed to save redirect: %v", err)
+	}
+	now = now.Add(time.Hour)
+	if err := repo.SaveRedirect(ctx, &domain.Redirect{FromSlug: "older-name", PostID: "001"}); err != nil {
+		t.Fatalf("Failed to save redirect: %v", err)
+	}
+	now = now.Add(time.Hour)
+	if err := repo.SaveRedirect(ctx, &domain.Redirect{FromSlug: "old-name", PostID: "002"}); err != nil {
+		t.Fatalf("Failed to replace redirect: %v", err)
+	}
+
+	redirect, err := repo.GetRedirect(ctx, "old-name")
+	if err != nil {
+		t.Fatalf("Failed to get redirect: %v", err)
+	}
+	if redirect.PostID != "002" || !redirect.CreatedAt.Equal(now) {
+		t.Errorf("Unexpected redirect: %+v", redirect)
+	}
+	if _, err := repo.GetRedirect(ctx, "missing"); !errors.Is(err, domain.ErrRedirectNotFound) {
+		t.Errorf("Expected ErrRedirectNotFound, got %v", err)
+	}
+
+	redirects, err := repo.ListRedirects(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list redirects: %v", err)
+	}
+	if len(redirects) != 2 || redirects[0].FromSlug != "old-name" || redirects[1].FromSlug != "older-name" {
+		t.Errorf("Expected redirects newest first, got %+v", redirects)
+	}
+
+	if err := repo.DeleteRedirect(ctx, "old-name"); err != nil {
+		t.Fatalf("Failed to delete redirect: %v", err)
+	}
+	if err := repo.DeleteRedirect(ctx, "old-name"); !errors.Is(err, domain.ErrRedirectNotFound) {
+		t.Errorf("Expected deleting twice to fail with ErrRedirectNotFound, got %v", err)
+	}
+
+	if err := posts.DeletePost(ctx, "001"); err != nil {
+		t.Fatalf("DeletePost() error = %v", err)
+	}
+	if _, err := repo.GetRedirect(ctx, "older-name"); !errors.Is(err, domain.ErrRedirectNotFound) {
+		t.Errorf("Expected redirects to a deleted post to go with it, got %v", err)
+	}
+}
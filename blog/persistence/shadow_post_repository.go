@@ -0,0 +1,165 @@
+package persistence
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/db"
+)
+
+var _ domain.ShadowPostRepository = (*SQLiteShadowPostRepository)(nil)
+
+// shadowTables renames the live post tables to their shadow namespace copies
+var shadowTables = strings.NewReplacer("post_images", "shadow_post_images", "posts", "shadow_posts")
+
+// contentHashLength is how many hex digits of the content hash name a shadow post file
+const contentHashLength = 16
+
+// SQLiteShadowPostRepository keeps posts in the shadow_posts and shadow_post_images tables
+// Its HTML shares the live blob store, named by content so it never overwrites a live post's file, and so
+// promoting it only has to swap table rows.
+type SQLiteShadowPostRepository struct {
+	*SQLitePostRepository
+}
+
+// NewShadowPostRepository creates a SQLiteShadowPostRepository
+// It must be given the same blob store as the live post repository.
+func NewShadowPostRepository(db *sql.DB, opts ...Option) *SQLiteShadowPostRepository {
+	repo := NewPostRepository(db, opts...)
+	repo.tables = shadowTables
+	return &SQLiteShadowPostRepository{SQLitePostRepository: repo}
+}
+
+// contentHTMLName names a post file after its content, e.g. 001.html becomes 001.3f2a...html
+func contentHTMLName(name string, content []byte) string {
+	sum := sha256.Sum256(content)
+	return strings.TrimSuffix(name, ".html") + "." + hex.EncodeToString(sum[:])[:contentHashLength] + ".html"
+}
+
+const listHTMLPathsQuery = `
+	SELECT html_path FROM posts
+`
+
+const clearPostsQuery = `
+	DELETE FROM posts
+`
+
+// Reset deletes every post in the shadow namespace, along with the files no live post uses
+func (r *SQLiteShadowPostRepository) Reset(ctx context.Context) error {
+	paths, err := r.htmlPaths(ctx, r.query(listHTMLPathsQuery))
+	if err != nil {
+		return err
+	}
+
+	if _, err := r.db.ExecContext(ctx, r.query(clearPostsQuery)); err != nil {
+		return fmt.Errorf("failed to clear shadow posts: %w", err)
+	}
+
+	return r.releaseAll(ctx, paths)
+}
+
+// keepPublicationTimesQuery carries the publication time of live posts over to the shadow posts replacing them
+const keepPublicationTimesQuery = `
+	UPDATE shadow_posts
+	SET published_at = (SELECT posts.published_at FROM posts WHERE posts.id = shadow_posts.id)
+	WHERE published_at IS NOT NULL
+	AND EXISTS (SELECT 1 FROM posts WHERE posts.id = shadow_posts.id AND posts.published_at IS NOT NULL)
+`
+
+const promotePostsQuery = `
+	INSERT INTO posts (` + postColumns + `, ` + contentColumns + `, images_recorded)
+	SELECT ` + postColumns + `, ` + contentColumns + `, images_recorded FROM shadow_posts
+`
+
+const promotePostImagesQuery = `
+	INSERT INTO post_images (post_id, image_path)
+	SELECT post_id, image_path FROM shadow_post_images
+`
+
+// Promote replaces the live posts with the shadow namespace's in a single transaction, leaving it empty
+// Files only the old live posts used are removed afterwards; one that can't be is left behind rather than
+// failing a promotion that has already happened.
+func (r *SQLiteShadowPostRepository) Promote(ctx context.Context) (int, error) {
+	var paths []string
+	var promoted int
+	err := db.RunInTransaction(ctx, r.db, func(txCtx context.Context) error {
+		var err error
+		if paths, err = r.htmlPaths(txCtx, listHTMLPathsQuery); err != nil {
+			return err
+		}
+
+		executor := db.GetExecutor(txCtx, r.db)
+		if _, err := executor.ExecContext(txCtx, keepPublicationTimesQuery); err != nil {
+			return fmt.Errorf("failed to keep publication times: %w", err)
+		}
+		// Image references are removed along with the posts by the post_images foreign key
+		if _, err := executor.ExecContext(txCtx, clearPostsQuery); err != nil {
+			return fmt.Errorf("failed to clear live posts: %w", err)
+		}
+
+		result, err := executor.ExecContext(txCtx, promotePostsQuery)
+		if err != nil {
+			return fmt.Errorf("failed to promote shadow posts: %w", err)
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to count promoted posts: %w", err)
+		}
+		promoted = int(n)
+
+		if _, err := executor.ExecContext(txCtx, promotePostImagesQuery); err != nil {
+			return fmt.Errorf("failed to promote shadow post images: %w", err)
+		}
+		if _, err := executor.ExecContext(txCtx, r.query(clearPostsQuery)); err != nil {
+			return fmt.Errorf("failed to clear shadow posts: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// Leftover files only waste space, so failing to remove them doesn't undo the promotion
+	_ = r.releaseAll(ctx, paths)
+	return promoted, nil
+}
+
+// htmlPaths returns the file of each post listed by query
+func (r *SQLiteShadowPostRepository) htmlPaths(ctx context.Context, query string) ([]string, error) {
+	rows, err := db.GetExecutor(ctx, r.db).QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list post files: %w", err)
+	}
+	defer rows.Close()
+
+	var paths []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return nil, fmt.Errorf("failed to scan post file: %w", err)
+		}
+		paths = append(paths, path)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating post files: %w", err)
+	}
+
+	return paths, nil
+}
+
+// releaseAll removes each of the files that no post uses any more
+func (r *SQLiteShadowPostRepository) releaseAll(ctx context.Context, paths []string) error {
+	for _, path := range paths {
+		if err := r.releaseHTML(ctx, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/blob"
+)
+
+func TestShadowPostRepository_Promote(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	blobs := blob.NewLocalStore(t.TempDir())
+	live := NewPostRepository(db, WithBlobStore(blobs))
+	shadow := NewShadowPostRepository(db, WithBlobStore(blobs))
+	ctx := context.Background()
+
+	published := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	rebuilt := published.AddDate(1, 0, 0)
+	save := func(repo domain.PostRepository, id string, content string, publishedAt time.Time, images ...string) {
+		t.Helper()
+		post := &domain.Post{
+			ID:          id,
+			Title:       "Post " + id,
+			HTMLPath:    id + ".html",
+			HTMLContent: []byte(content),
+			CreatedAt:   published,
+			PublishedAt: publishedAt,
+			Images:      images,
+		}
+		if err := repo.SavePost(ctx, post); err != nil {
+			t.Fatalf("SavePost(%s) error = %v", id, err)
+		}
+	}
+
+	save(live, "001", "<p>old one</p>", published)
+	save(live, "002", "<p>old two</p>", published)
+	save(shadow, "001", "<p>new one</p>", rebuilt, "images/new.png")
+	save(shadow, "003", "<p>new three</p>", rebuilt)
+
+	if content, err := live.GetPostHTML(ctx, "001"); err != nil || string(content) != "<p>old one</p>" {
+		t.Fatalf("live GetPostHTML() before promoting = %q, %v, want the live content", content, err)
+	}
+	if _, err := live.GetPost(ctx, "003"); !errors.Is(err, domain.ErrPostNotFound) {
+		t.Fatalf("live GetPost() of a shadow post before promoting error = %v, want ErrPostNotFound", err)
+	}
+
+	promoted, err := shadow.Promote(ctx)
+	if err != nil {
+		t.Fatalf("Promote() error = %v", err)
+	}
+	if promoted != 2 {
+		t.Errorf("Promote() = %d posts, want 2", promoted)
+	}
+
+	post, err := live.GetPost(ctx, "001")
+	if err != nil {
+		t.Fatalf("GetPost() after promoting error = %v", err)
+	}
+	if !post.PublishedAt.Equal(published) {
+		t.Errorf("PublishedAt = %v, want the live publication time %v", post.PublishedAt, published)
+	}
+	if content, err := live.GetPostHTML(ctx, "001"); err != nil || string(content) != "<p>new one</p>" {
+		t.Errorf("GetPostHTML() after promoting = %q, %v, want the shadow content", content, err)
+	}
+	if posts, err := live.ListPostsByImage(ctx, "images/new.png"); err != nil || len(posts) != 1 {
+		t.Errorf("ListPostsByImage() = %v, %v, want the promoted post", posts, err)
+	}
+	if _, err := live.GetPost(ctx, "002"); !errors.Is(err, domain.ErrPostNotFound) {
+		t.Errorf("GetPost() of a post missing from the shadow namespace error = %v, want ErrPostNotFound", err)
+	}
+	if _, err := blobs.Get(ctx, "002.html"); !errors.Is(err, blob.ErrNotFound) {
+		t.Errorf("Expected the replaced post file to be removed, got %v", err)
+	}
+	if posts, err := shadow.ListPosts(ctx); err != nil || len(posts) != 0 {
+		t.Errorf("shadow ListPosts() after promoting = %v, %v, want it empty", posts, err)
+	}
+
+	// Saving a promoted post again moves it back to its own file
+	save(live, "001", "<p>edited</p>", published)
+	if content, err := live.GetPostHTML(ctx, "001"); err != nil || string(content) != "<p>edited</p>" {
+		t.Errorf("GetPostHTML() after saving = %q, %v", content, err)
+	}
+	if _, err := blobs.Get(ctx, post.HTMLPath); !errors.Is(err, blob.ErrNotFound) {
+		t.Errorf("Expected the promoted file %s to be removed once unused, got %v", post.HTMLPath, err)
+	}
+}
+
+func TestShadowPostRepository_Reset(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	blobs := blob.NewLocalStore(t.TempDir())
+	live := NewPostRepository(db, WithBlobStore(blobs))
+	shadow := NewShadowPostRepository(db, WithBlobStore(blobs))
+	ctx := context.Background()
+
+	for _, repo := range []domain.PostRepository{live, shadow} {
+		post := &domain.Post{ID: "001", Title: "Post", HTMLPath: "001.html", HTMLContent: []byte("<p>post</p>"), CreatedAt: time.Now()}
+		if err := repo.SavePost(ctx, post); err != nil {
+			t.Fatalf("SavePost() error = %v", err)
+		}
+	}
+	shadowPost, err := shadow.GetPost(ctx, "001")
+	if err != nil {
+		t.Fatalf("shadow GetPost() error = %v", err)
+	}
+	if shadowPost.HTMLPath == "001.html" {
+		t.Fatal("Expected the shadow post file to be named by content")
+	}
+
+	if err := shadow.Reset(ctx); err != nil {
+		t.Fatalf("Reset() error = %v", err)
+	}
+
+	if _, err := shadow.GetPost(ctx, "001"); !errors.Is(err, domain.ErrPostNotFound) {
+		t.Errorf("shadow GetPost() after reset error = %v, want ErrPostNotFound", err)
+	}
+	if _, err := blobs.Get(ctx, shadowPost.HTMLPath); !errors.Is(err, blob.ErrNotFound) {
+		t.Errorf("Expected the shadow post file to be removed, got %v", err)
+	}
+	if content, err := live.GetPostHTML(ctx, "001"); err != nil || string(content) != "<p>post</p>" {
+		t.Errorf("live GetPostHTML() after reset = %q, %v, want it untouched", content, err)
+	}
+}
@@ -0,0 +1,245 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/clock"
+	"github.com/dfryer1193/goblog/shared/db"
+	"github.com/google/go-github/v75/github"
+)
+
+var _ domain.SourceCache = (*SQLiteSourceCacheRepository)(nil)
+
+// SQLiteSourceCacheRepository implements domain.SourceCache using SQL database (SQLite)
+type SQLiteSourceCacheRepository struct {
+	db    *sql.DB
+	clock clock.Clock
+}
+
+// NewSourceCacheRepository creates a new SQLiteSourceCacheRepository from a standard sql.DB
+func NewSourceCacheRepository(db *sql.DB, opts ...Option) *SQLiteSourceCacheRepository {
+	o := newOptions(opts)
+	return &SQLiteSourceCacheRepository{
+		db:    db,
+		clock: o.clock,
+	}
+}
+
+const getSourceCommitQuery = `
+	SELECT data FROM source_commits WHERE sha = ?
+`
+
+// GetCommit returns a cached commit, or domain.ErrSourceCacheMiss
+func (r *SQLiteSourceCacheRepository) GetCommit(ctx context.Context, sha string) (*github.RepositoryCommit, error) {
+	var data []byte
+	err := r.db.QueryRowContext(ctx, getSourceCommitQuery, sha).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrSourceCacheMiss
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cached commit: %w", err)
+	}
+
+	var commit github.RepositoryCommit
+	if err := json.Unmarshal(data, &commit); err != nil {
+		return nil, fmt.Errorf("failed to decode cached commit %s: %w", sha, err)
+	}
+
+	return &commit, nil
+}
+
+const upsertSourceCommitQuery = `
+	INSERT INTO source_commits (sha, data, cached_at)
+	VALUES (?, ?, ?)
+	ON CONFLICT(sha) DO UPDATE SET
+		data = excluded.data,
+		cached_at = excluded.cached_at
+`
+
+// SaveCommit caches a commit, including its changed files
+func (r *SQLiteSourceCacheRepository) SaveCommit(ctx context.Context, commit *github.RepositoryCommit) error {
+	if commit == nil || commit.GetSHA() == "" {
+		return fmt.Errorf("commit SHA cannot be empty")
+	}
+
+	data, err := json.Marshal(commit)
+	if err != nil {
+		return fmt.Errorf("failed to encode commit %s: %w", commit.GetSHA(), err)
+	}
+
+	executor := db.GetExecutor(ctx, r.db)
+	if _, err := executor.ExecContext(ctx, upsertSourceCommitQuery, commit.GetSHA(), data, r.clock.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to cache commit: %w", err)
+	}
+
+	return nil
+}
+
+const getSourceFileQuery = `
+	SELECT content FROM source_files WHERE path = ? AND ref = ?
+`
+
+// GetFile returns a file's contents cached at ref, or domain.ErrSourceCacheMiss
+func (r *SQLiteSourceCacheRepository) GetFile(ctx context.Context, path string, ref string) ([]byte, error) {
+	var content []byte
+	err := r.db.QueryRowContext(ctx, getSourceFileQuery, path, ref).Scan(&content)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrSourceCacheMiss
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cached file: %w", err)
+	}
+
+	return content, nil
+}
+
+const upsertSourceFileQuery = `
+	INSERT INTO source_files (path, ref, content, blob_sha, cached_at)
+	VALUES (?, ?, ?, ?, ?)
+	ON CONFLICT(path, ref) DO UPDATE SET
+		content = excluded.content,
+		blob_sha = excluded.blob_sha,
+		cached_at = excluded.cached_at
+`
+
+// SaveFile caches a file's contents at ref
+func (r *SQLiteSourceCacheRepository) SaveFile(ctx context.Context, path string, ref string, content []byte) error {
+	if path == "" || ref == "" {
+		return fmt.Errorf("cached file path and ref cannot be empty")
+	}
+
+	executor := db.GetExecutor(ctx, r.db)
+	if _, err := executor.ExecContext(ctx, upsertSourceFileQuery, path, ref, content, domain.GitBlobSHA(content), r.clock.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to cache file: %w", err)
+	}
+
+	return nil
+}
+
+const getSourceBlobQuery = `
+	SELECT content FROM source_files WHERE blob_sha = ? LIMIT 1
+`
+
+// GetBlob returns the contents of any cached file whose git blob SHA is blobSHA, or domain.ErrSourceCacheMiss
+// Files cached before blob SHAs were recorded have none, and are only found by path and ref.
+func (r *SQLiteSourceCacheRepository) GetBlob(ctx context.Context, blobSHA string) ([]byte, error) {
+	if blobSHA == "" {
+		return nil, domain.ErrSourceCacheMiss
+	}
+
+	var content []byte
+	err := r.db.QueryRowContext(ctx, getSourceBlobQuery, blobSHA).Scan(&content)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrSourceCacheMiss
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cached blob: %w", err)
+	}
+
+	return content, nil
+}
+
+const getSourceTreeQuery = `
+	SELECT data FROM source_trees WHERE sha = ?
+`
+
+// GetTree returns the cached file tree of a commit, or domain.ErrSourceCacheMiss
+func (r *SQLiteSourceCacheRepository) GetTree(ctx context.Context, sha string) (*github.Tree, error) {
+	var data []byte
+	err := r.db.QueryRowContext(ctx, getSourceTreeQuery, sha).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrSourceCacheMiss
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cached tree: %w", err)
+	}
+
+	var tree github.Tree
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return nil, fmt.Errorf("failed to decode cached tree of %s: %w", sha, err)
+	}
+
+	return &tree, nil
+}
+
+const upsertSourceTreeQuery = `
+	INSERT INTO source_trees (sha, data, cached_at)
+	VALUES (?, ?, ?)
+	ON CONFLICT(sha) DO UPDATE SET
+		data = excluded.data,
+		cached_at = excluded.cached_at
+`
+
+// SaveTree caches the file tree of a commit
+func (r *SQLiteSourceCacheRepository) SaveTree(ctx context.Context, sha string, tree *github.Tree) error {
+	if sha == "" || tree == nil {
+		return fmt.Errorf("tree and its commit SHA cannot be empty")
+	}
+
+	data, err := json.Marshal(tree)
+	if err != nil {
+		return fmt.Errorf("failed to encode tree of %s: %w", sha, err)
+	}
+
+	executor := db.GetExecutor(ctx, r.db)
+	if _, err := executor.ExecContext(ctx, upsertSourceTreeQuery, sha, data, r.clock.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to cache tree: %w", err)
+	}
+
+	return nil
+}
+
+const getSourceComparisonQuery = `
+	SELECT data FROM source_comparisons WHERE base = ? AND head = ?
+`
+
+// GetComparison returns a cached comparison between two commits, or domain.ErrSourceCacheMiss
+func (r *SQLiteSourceCacheRepository) GetComparison(ctx context.Context, base string, head string) (*github.CommitsComparison, error) {
+	var data []byte
+	err := r.db.QueryRowContext(ctx, getSourceComparisonQuery, base, head).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrSourceCacheMiss
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cached comparison: %w", err)
+	}
+
+	var comparison github.CommitsComparison
+	if err := json.Unmarshal(data, &comparison); err != nil {
+		return nil, fmt.Errorf("failed to decode cached comparison %s...%s: %w", base, head, err)
+	}
+
+	return &comparison, nil
+}
+
+const upsertSourceComparisonQuery = `
+	INSERT INTO source_comparisons (base, head, data, cached_at)
+	VALUES (?, ?, ?, ?)
+	ON CONFLICT(base, head) DO UPDATE SET
+		data = excluded.data,
+		cached_at = excluded.cached_at
+`
+
+// SaveComparison caches the comparison between two commits
+func (r *SQLiteSourceCacheRepository) SaveComparison(ctx context.Context, base string, head string, comparison *github.CommitsComparison) error {
+	if base == "" || head == "" || comparison == nil {
+		return fmt.Errorf("comparison and its commit SHAs cannot be empty")
+	}
+
+	data, err := json.Marshal(comparison)
+	if err != nil {
+		return fmt.Errorf("failed to encode comparison %s...%s: %w", base, head, err)
+	}
+
+	executor := db.GetExecutor(ctx, r.db)
+	if _, err := executor.ExecContext(ctx, upsertSourceComparisonQuery, base, head, data, r.clock.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to cache comparison: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/google/go-github/v75/github"
+)
+
+func TestSourceCacheRepository_Commits(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewSourceCacheRepository(db)
+	ctx := context.Background()
+
+	if _, err := repo.GetCommit(ctx, "abc"); !errors.Is(err, domain.ErrSourceCacheMiss) {
+		t.Fatalf("Expected cache miss, got %v", err)
+	}
+
+	commit := &github.RepositoryCommit{
+		SHA:   github.Ptr("abc"),
+		Files: []*github.CommitFile{{Filename: github.Ptr("posts/001-test.md"), Status: github.Ptr("added")}},
+	}
+	if err := repo.SaveCommit(ctx, commit); err != nil {
+		t.Fatalf("Failed to save commit: %v", err)
+	}
+
+	cached, err := repo.GetCommit(ctx, "abc")
+	if err != nil {
+		t.Fatalf("Failed to get cached commit: %v", err)
+	}
+	if cached.GetSHA() != "abc" || len(cached.Files) != 1 || cached.Files[0].GetFilename() != "posts/001-test.md" {
+		t.Errorf("Unexpected cached commit: %+v", cached)
+	}
+}
+
+func TestSourceCacheRepository_Files(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewSourceCacheRepository(db)
+	ctx := context.Background()
+
+	if err := repo.SaveFile(ctx, "posts/001-test.md", "abc", []byte("first")); err != nil {
+		t.Fatalf("Failed to save file: %v", err)
+	}
+	if err := repo.SaveFile(ctx, "posts/001-test.md", "main", []byte("second")); err != nil {
+		t.Fatalf("Failed to save file: %v", err)
+	}
+	if err := repo.SaveFile(ctx, "posts/001-test.md", "main", []byte("third")); err != nil {
+		t.Fatalf("Failed to save file: %v", err)
+	}
+
+	content, err := repo.GetFile(ctx, "posts/001-test.md", "abc")
+	if err != nil {
+		t.Fatalf("Failed to get cached file: %v", err)
+	}
+	if string(content) != "first" {
+		t.Errorf("Expected content at abc to be %q, got %q", "first", content)
+	}
+
+	if _, err := repo.GetFile(ctx, "posts/001-test.md", "ghi"); !errors.Is(err, domain.ErrSourceCacheMiss) {
+		t.Errorf("Expected cache miss for unknown ref, got %v", err)
+	}
+
+	content, err = repo.GetFile(ctx, "posts/001-test.md", "main")
+	if err != nil {
+		t.Fatalf("Failed to get cached file: %v", err)
+	}
+	if string(content) != "third" {
+		t.Errorf("Expected content at main to be replaced with %q, got %q", "third", content)
+	}
+}
+
+func TestSourceCacheRepository_Blobs(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewSourceCacheRepository(db)
+	ctx := context.Background()
+
+	content := []byte("hello")
+	if err := repo.SaveFile(ctx, "posts/001-test.md", "abc", content); err != nil {
+		t.Fatalf("Failed to save file: %v", err)
+	}
+
+	// The SHA git gives the same contents, so it can be matched against trees and commits
+	cached, err := repo.GetBlob(ctx, "b6fc4c620b67d95f953a5c1c1230aaab5db5a1b0")
+	if err != nil || string(cached) != "hello" {
+		t.Fatalf("GetBlob() = %q, %v, want the file's contents", cached, err)
+	}
+	if _, err := repo.GetBlob(ctx, ""); !errors.Is(err, domain.ErrSourceCacheMiss) {
+		t.Errorf("Expected cache miss for an empty blob SHA, got %v", err)
+	}
+}
+
+func TestSourceCacheRepository_TreesAndComparisons(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewSourceCacheRepository(db)
+	ctx := context.Background()
+
+	if _, err := repo.GetTree(ctx, "abc"); !errors.Is(err, domain.ErrSourceCacheMiss) {
+		t.Fatalf("Expected cache miss, got %v", err)
+	}
+	tree := &github.Tree{Entries: []*github.TreeEntry{{Path: github.Ptr("posts/001-test.md"), SHA: github.Ptr("blob")}}}
+	if err := repo.SaveTree(ctx, "abc", tree); err != nil {
+		t.Fatalf("Failed to save tree: %v", err)
+	}
+	cachedTree, err := repo.GetTree(ctx, "abc")
+	if err != nil || len(cachedTree.Entries) != 1 || cachedTree.Entries[0].GetPath() != "posts/001-test.md" {
+		t.Errorf("GetTree() = %+v, %v", cachedTree, err)
+	}
+
+	if _, err := repo.GetComparison(ctx, "abc", "def"); !errors.Is(err, domain.ErrSourceCacheMiss) {
+		t.Fatalf("Expected cache miss, got %v", err)
+	}
+	comparison := &github.CommitsComparison{Status: github.Ptr("ahead"), Commits: []*github.RepositoryCommit{{SHA: github.Ptr("def")}}}
+	if err := repo.SaveComparison(ctx, "abc", "def", comparison); err != nil {
+		t.Fatalf("Failed to save comparison: %v", err)
+	}
+	cachedComparison, err := repo.GetComparison(ctx, "abc", "def")
+	if err != nil || cachedComparison.GetStatus() != "ahead" || len(cachedComparison.Commits) != 1 {
+		t.Errorf("GetComparison() = %+v, %v", cachedComparison, err)
+	}
+	if _, err := repo.GetComparison(ctx, "def", "abc"); !errors.Is(err, domain.ErrSourceCacheMiss) {
+		t.Errorf("Expected the reverse comparison to miss, got %v", err)
+	}
+}
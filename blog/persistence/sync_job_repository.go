@@ -0,0 +1,329 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/clock"
+	"github.com/dfryer1193/goblog/shared/db"
+)
+
+var _ domain.SyncJobRepository = (*SQLiteSyncJobRepository)(nil)
+
+// SQLiteSyncJobRepository implements domain.SyncJobRepository using SQL database (SQLite)
+type SQLiteSyncJobRepository struct {
+	db    *sql.DB
+	clock clock.Clock
+}
+
+// NewSyncJobRepository creates a new SQLiteSyncJobRepository from a standard sql.DB
+func NewSyncJobRepository(db *sql.DB, opts ...Option) *SQLiteSyncJobRepository {
+	o := newOptions(opts)
+	return &SQLiteSyncJobRepository{
+		db:    db,
+		clock: o.clock,
+	}
+}
+
+const insertSyncJobQuery = `
+	INSERT INTO sync_jobs (ref, before_sha, after_sha, status, created_at)
+	VALUES (?, ?, ?, ?, ?)
+`
+
+// CreateJob inserts a new pending job and assigns its ID
+func (r *SQLiteSyncJobRepository) CreateJob(ctx context.Context, job *domain.SyncJob) error {
+	if job == nil {
+		return fmt.Errorf("sync job cannot be nil")
+	}
+
+	job.Status = domain.SyncStatusPending
+	if job.CreatedAt.IsZero() {
+		job.CreatedAt = r.clock.Now().UTC()
+	}
+
+	executor := db.GetExecutor(ctx, r.db)
+	result, err := executor.ExecContext(ctx, insertSyncJobQuery, job.Ref, job.Before, job.After, job.Status, job.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert sync job: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get sync job ID: %w", err)
+	}
+	job.ID = id
+
+	return nil
+}
+
+const insertSyncJobFileQuery = `
+	INSERT INTO sync_job_files (job_id, path, commit_sha, action, status, updated_at)
+	VALUES (?, ?, ?, ?, ?, ?)
+	ON CONFLICT(job_id, path) DO UPDATE SET
+		commit_sha = excluded.commit_sha,
+		action = excluded.action,
+		status = excluded.status,
+		updated_at = excluded.updated_at
+`
+
+// AddFiles records the files a job will process
+// A job with no files to process is finished immediately
+func (r *SQLiteSyncJobRepository) AddFiles(ctx context.Context, jobID int64, files []*domain.SyncJobFile) error {
+	return db.RunInTransaction(ctx, r.db, func(txCtx context.Context) error {
+		executor := db.GetExecutor(txCtx, r.db)
+		now := r.clock.Now().UTC()
+
+		for _, f := range files {
+			_, err := executor.ExecContext(txCtx, insertSyncJobFileQuery,
+				jobID,
+				f.Path,
+				f.CommitSHA,
+				f.Action,
+				domain.SyncStatusPending,
+				now,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to insert sync job file: %w", err)
+			}
+		}
+
+		return r.finishIfDone(txCtx, jobID)
+	})
+}
+
+const completeSyncJobFileQuery = `
+	UPDATE sync_job_files
+	SET status = ?, error = ?, updated_at = ?
+	WHERE job_id = ? AND path = ?
+`
+
+// CompleteFile records the outcome of a file and finishes the job once no files are pending
+func (r *SQLiteSyncJobRepository) CompleteFile(ctx context.Context, jobID int64, path string, fileErr error) error {
+	status := domain.SyncStatusSucceeded
+	var errMsg any
+	if fileErr != nil {
+		status = domain.SyncStatusFailed
+		errMsg = fileErr.Error()
+	}
+
+	return db.RunInTransaction(ctx, r.db, func(txCtx context.Context) error {
+		executor := db.GetExecutor(txCtx, r.db)
+		result, err := executor.ExecContext(txCtx, completeSyncJobFileQuery, status, errMsg, r.clock.Now().UTC(), jobID, path)
+		if err != nil {
+			return fmt.Errorf("failed to update sync job file: %w", err)
+		}
+
+		if n, err := result.RowsAffected(); err == nil && n == 0 {
+			return fmt.Errorf("sync job %d has no file %s", jobID, path)
+		}
+
+		return r.finishIfDone(txCtx, jobID)
+	})
+}
+
+const finishSyncJobQuery = `
+	UPDATE sync_jobs
+	SET status = CASE
+			WHEN EXISTS (SELECT 1 FROM sync_job_files WHERE job_id = ? AND status = 'failed') THEN 'failed'
+			ELSE 'succeeded'
+		END,
+		finished_at = ?
+	WHERE id = ?
+	AND status = 'pending'
+	AND NOT EXISTS (SELECT 1 FROM sync_job_files WHERE job_id = ? AND status = 'pending')
+`
+
+// finishIfDone sets the final status of a job once none of its files are pending
+func (r *SQLiteSyncJobRepository) finishIfDone(ctx context.Context, jobID int64) error {
+	executor := db.GetExecutor(ctx, r.db)
+	if _, err := executor.ExecContext(ctx, finishSyncJobQuery, jobID, r.clock.Now().UTC(), jobID, jobID); err != nil {
+		return fmt.Errorf("failed to finish sync job: %w", err)
+	}
+	return nil
+}
+
+const failSyncJobQuery = `
+	UPDATE sync_jobs
+	SET status = 'failed', error = ?, finished_at = ?
+	WHERE id = ?
+`
+
+// FailJob marks a job as failed with the given error
+func (r *SQLiteSyncJobRepository) FailJob(ctx context.Context, jobID int64, jobErr error) error {
+	var errMsg any
+	if jobErr != nil {
+		errMsg = jobErr.Error()
+	}
+
+	executor := db.GetExecutor(ctx, r.db)
+	if _, err := executor.ExecContext(ctx, failSyncJobQuery, errMsg, r.clock.Now().UTC(), jobID); err != nil {
+		return fmt.Errorf("failed to fail sync job: %w", err)
+	}
+	return nil
+}
+
+const getSyncJobQuery = `
+	SELECT id, ref, before_sha, after_sha, status, error, created_at, finished_at
+	FROM sync_jobs
+	WHERE id = ?
+`
+
+const listSyncJobFilesQuery = `
+	SELECT path, commit_sha, action, status, error, updated_at
+	FROM sync_job_files
+	WHERE job_id = ?
+	ORDER BY path
+`
+
+// GetJob retrieves a job along with its files
+func (r *SQLiteSyncJobRepository) GetJob(ctx context.Context, id int64) (*domain.SyncJob, error) {
+	var row syncJobRow
+	err := row.scan(r.db.QueryRowContext(ctx, getSyncJobQuery, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %d", domain.ErrSyncJobNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sync job: %w", err)
+	}
+	job := row.toDomain()
+
+	rows, err := r.db.QueryContext(ctx, listSyncJobFilesQuery, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sync job files: %w", err)
+	}
+	defer rows.Close()
+
+	job.Files = make([]*domain.SyncJobFile, 0)
+	for rows.Next() {
+		var fileRow syncJobFileRow
+		err := rows.Scan(
+			&fileRow.Path,
+			&fileRow.CommitSHA,
+			&fileRow.Action,
+			&fileRow.Status,
+			&fileRow.Error,
+			&fileRow.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan sync job file row: %w", err)
+		}
+		job.Files = append(job.Files, fileRow.toDomain())
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating sync job file rows: %w", err)
+	}
+
+	return job, nil
+}
+
+const listSyncJobsQuery = `
+	SELECT id, ref, before_sha, after_sha, status, error, created_at, finished_at
+	FROM sync_jobs
+	ORDER BY id DESC
+	LIMIT ? OFFSET ?
+`
+
+// ListJobs returns jobs newest first, without their files
+func (r *SQLiteSyncJobRepository) ListJobs(ctx context.Context, limit int, offset int) ([]*domain.SyncJob, error) {
+	if limit <= 0 {
+		limit = 20 // Default limit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	rows, err := r.db.QueryContext(ctx, listSyncJobsQuery, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sync jobs: %w", err)
+	}
+	defer rows.Close()
+
+	jobs := make([]*domain.SyncJob, 0)
+	for rows.Next() {
+		var row syncJobRow
+		if err := row.scan(rows); err != nil {
+			return nil, fmt.Errorf("failed to scan sync job row: %w", err)
+		}
+		jobs = append(jobs, row.toDomain())
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating sync job rows: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// syncJobRow is a private struct used to scan sync job rows
+type syncJobRow struct {
+	ID         int64          `db:"id"`
+	Ref        string         `db:"ref"`
+	Before     string         `db:"before_sha"`
+	After      string         `db:"after_sha"`
+	Status     string         `db:"status"`
+	Error      sql.NullString `db:"error"`
+	CreatedAt  sql.NullTime   `db:"created_at"`
+	FinishedAt sql.NullTime   `db:"finished_at"`
+}
+
+func (jr *syncJobRow) scan(s rowScanner) error {
+	return s.Scan(
+		&jr.ID,
+		&jr.Ref,
+		&jr.Before,
+		&jr.After,
+		&jr.Status,
+		&jr.Error,
+		&jr.CreatedAt,
+		&jr.FinishedAt,
+	)
+}
+
+func (jr *syncJobRow) toDomain() *domain.SyncJob {
+	job := &domain.SyncJob{
+		ID:     jr.ID,
+		Ref:    jr.Ref,
+		Before: jr.Before,
+		After:  jr.After,
+		Status: domain.SyncStatus(jr.Status),
+		Error:  jr.Error.String,
+	}
+
+	if jr.CreatedAt.Valid {
+		job.CreatedAt = jr.CreatedAt.Time
+	}
+	if jr.FinishedAt.Valid {
+		job.FinishedAt = jr.FinishedAt.Time
+	}
+
+	return job
+}
+
+// syncJobFileRow is a private struct used to scan sync job file rows
+type syncJobFileRow struct {
+	Path      string         `db:"path"`
+	CommitSHA string         `db:"commit_sha"`
+	Action    string         `db:"action"`
+	Status    string         `db:"status"`
+	Error     sql.NullString `db:"error"`
+	UpdatedAt sql.NullTime   `db:"updated_at"`
+}
+
+func (fr *syncJobFileRow) toDomain() *domain.SyncJobFile {
+	file := &domain.SyncJobFile{
+		Path:      fr.Path,
+		CommitSHA: fr.CommitSHA,
+		Action:    domain.SyncAction(fr.Action),
+		Status:    domain.SyncStatus(fr.Status),
+		Error:     fr.Error.String,
+	}
+
+	if fr.UpdatedAt.Valid {
+		file.UpdatedAt = fr.UpdatedAt.Time
+	}
+
+	return file
+}
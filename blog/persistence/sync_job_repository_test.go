@@ -0,0 +1,149 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+)
+
+func TestSyncJobRepository_Lifecycle(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewSyncJobRepository(db)
+	ctx := context.Background()
+
+	job := &domain.SyncJob{Ref: "refs/heads/main", Before: "aaa", After: "bbb"}
+	if err := repo.CreateJob(ctx, job); err != nil {
+		t.Fatalf("Failed to create job: %v", err)
+	}
+	if job.ID == 0 {
+		t.Fatal("Expected job ID to be assigned")
+	}
+
+	files := []*domain.SyncJobFile{
+		{Path: "posts/001.md", CommitSHA: "bbb", Action: domain.SyncActionUpsert},
+		{Path: "images/cat.png", CommitSHA: "bbb", Action: domain.SyncActionRemove},
+	}
+	if err := repo.AddFiles(ctx, job.ID, files); err != nil {
+		t.Fatalf("Failed to add files: %v", err)
+	}
+
+	if err := repo.CompleteFile(ctx, job.ID, "posts/001.md", nil); err != nil {
+		t.Fatalf("Failed to complete file: %v", err)
+	}
+
+	got, err := repo.GetJob(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("Failed to get job: %v", err)
+	}
+	if got.Status != domain.SyncStatusPending {
+		t.Errorf("Status = %q, want %q while files are pending", got.Status, domain.SyncStatusPending)
+	}
+
+	if err := repo.CompleteFile(ctx, job.ID, "images/cat.png", errors.New("boom")); err != nil {
+		t.Fatalf("Failed to complete file: %v", err)
+	}
+
+	got, err = repo.GetJob(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("Failed to get job: %v", err)
+	}
+	if got.Status != domain.SyncStatusFailed {
+		t.Errorf("Status = %q, want %q", got.Status, domain.SyncStatusFailed)
+	}
+	if got.FinishedAt.IsZero() {
+		t.Error("Expected FinishedAt to be set")
+	}
+	if len(got.Files) != 2 {
+		t.Fatalf("Expected 2 files, got %d", len(got.Files))
+	}
+
+	// Files are ordered by path
+	if got.Files[0].Path != "images/cat.png" || got.Files[0].Status != domain.SyncStatusFailed || got.Files[0].Error != "boom" {
+		t.Errorf("Unexpected image file: %+v", got.Files[0])
+	}
+	if got.Files[1].Path != "posts/001.md" || got.Files[1].Status != domain.SyncStatusSucceeded {
+		t.Errorf("Unexpected post file: %+v", got.Files[1])
+	}
+}
+
+func TestSyncJobRepository_EmptyJobSucceeds(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewSyncJobRepository(db)
+	ctx := context.Background()
+
+	job := &domain.SyncJob{Ref: "refs/heads/main", After: "bbb"}
+	if err := repo.CreateJob(ctx, job); err != nil {
+		t.Fatalf("Failed to create job: %v", err)
+	}
+	if err := repo.AddFiles(ctx, job.ID, nil); err != nil {
+		t.Fatalf("Failed to add files: %v", err)
+	}
+
+	got, err := repo.GetJob(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("Failed to get job: %v", err)
+	}
+	if got.Status != domain.SyncStatusSucceeded {
+		t.Errorf("Status = %q, want %q", got.Status, domain.SyncStatusSucceeded)
+	}
+}
+
+func TestSyncJobRepository_FailJob(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewSyncJobRepository(db)
+	ctx := context.Background()
+
+	job := &domain.SyncJob{Ref: "refs/heads/main", After: "bbb"}
+	if err := repo.CreateJob(ctx, job); err != nil {
+		t.Fatalf("Failed to create job: %v", err)
+	}
+	if err := repo.FailJob(ctx, job.ID, errors.New("compare failed")); err != nil {
+		t.Fatalf("Failed to fail job: %v", err)
+	}
+
+	got, err := repo.GetJob(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("Failed to get job: %v", err)
+	}
+	if got.Status != domain.SyncStatusFailed || got.Error != "compare failed" {
+		t.Errorf("Unexpected job: %+v", got)
+	}
+}
+
+func TestSyncJobRepository_ListAndNotFound(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewSyncJobRepository(db)
+	ctx := context.Background()
+
+	for _, after := range []string{"one", "two", "three"} {
+		if err := repo.CreateJob(ctx, &domain.SyncJob{Ref: "refs/heads/main", After: after}); err != nil {
+			t.Fatalf("Failed to create job: %v", err)
+		}
+	}
+
+	jobs, err := repo.ListJobs(ctx, 2, 0)
+	if err != nil {
+		t.Fatalf("Failed to list jobs: %v", err)
+	}
+	if len(jobs) != 2 {
+		t.Fatalf("Expected 2 jobs, got %d", len(jobs))
+	}
+	if jobs[0].After != "three" {
+		t.Errorf("Expected newest job first, got %q", jobs[0].After)
+	}
+
+	_, err = repo.GetJob(ctx, 999)
+	if !errors.Is(err, domain.ErrSyncJobNotFound) {
+		t.Errorf("Expected ErrSyncJobNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/clock"
+	"github.com/dfryer1193/goblog/shared/db"
+)
+
+var _ domain.WebhookDeliveryRepository = (*SQLiteWebhookDeliveryRepository)(nil)
+
+// SQLiteWebhookDeliveryRepository implements domain.WebhookDeliveryRepository using SQL database (SQLite)
+type SQLiteWebhookDeliveryRepository struct {
+	db    *sql.DB
+	clock clock.Clock
+}
+
+// NewWebhookDeliveryRepository creates a new SQLiteWebhookDeliveryRepository from a standard sql.DB
+func NewWebhookDeliveryRepository(db *sql.DB, opts ...Option) *SQLiteWebhookDeliveryRepository {
+	o := newOptions(opts)
+	return &SQLiteWebhookDeliveryRepository{
+		db:    db,
+		clock: o.clock,
+	}
+}
+
+const claimDeliveryQuery = `
+	INSERT INTO webhook_deliveries (id, received_at)
+	VALUES (?, ?)
+	ON CONFLICT(id) DO NOTHING
+`
+
+// ClaimDelivery records a delivery ID, returning false if it had already been recorded
+// The insert is a single statement, so concurrent deliveries with the same ID can't both claim it.
+func (r *SQLiteWebhookDeliveryRepository) ClaimDelivery(ctx context.Context, id string) (bool, error) {
+	executor := db.GetExecutor(ctx, r.db)
+	result, err := executor.ExecContext(ctx, claimDeliveryQuery, id, r.clock.Now().UTC())
+	if err != nil {
+		return false, fmt.Errorf("failed to record webhook delivery: %w", err)
+	}
+
+	claimed, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return claimed == 1, nil
+}
+
+const releaseDeliveryQuery = `
+	DELETE FROM webhook_deliveries WHERE id = ?
+`
+
+// ReleaseDelivery forgets a delivery ID, so a failed delivery can be handled again when redelivered
+func (r *SQLiteWebhookDeliveryRepository) ReleaseDelivery(ctx context.Context, id string) error {
+	executor := db.GetExecutor(ctx, r.db)
+	if _, err := executor.ExecContext(ctx, releaseDeliveryQuery, id); err != nil {
+		return fmt.Errorf("failed to release webhook delivery: %w", err)
+	}
+	return nil
+}
+
+const pruneDeliveriesQuery = `
+	DELETE FROM webhook_deliveries WHERE received_at < ?
+`
+
+// PruneDeliveries forgets deliveries received before the given time
+func (r *SQLiteWebhookDeliveryRepository) PruneDeliveries(ctx context.Context, before time.Time) error {
+	executor := db.GetExecutor(ctx, r.db)
+	if _, err := executor.ExecContext(ctx, pruneDeliveriesQuery, before); err != nil {
+		return fmt.Errorf("failed to prune webhook deliveries: %w", err)
+	}
+	return nil
+}
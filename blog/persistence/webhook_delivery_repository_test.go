@@ -0,0 +1,48 @@
+package persistence
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/shared/clock"
+)
+
+func TestWebhookDeliveryRepository(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	repo := NewWebhookDeliveryRepository(db, WithClock(clock.Fixed(now)))
+	ctx := context.Background()
+
+	claimed, err := repo.ClaimDelivery(ctx, "delivery-1")
+	if err != nil {
+		t.Fatalf("ClaimDelivery failed: %v", err)
+	}
+	if !claimed {
+		t.Error("Expected the first delivery to be claimed")
+	}
+
+	claimed, err = repo.ClaimDelivery(ctx, "delivery-1")
+	if err != nil {
+		t.Fatalf("ClaimDelivery failed: %v", err)
+	}
+	if claimed {
+		t.Error("Expected a redelivery to be reported as a duplicate")
+	}
+
+	if err := repo.ReleaseDelivery(ctx, "delivery-1"); err != nil {
+		t.Fatalf("ReleaseDelivery failed: %v", err)
+	}
+	if claimed, _ := repo.ClaimDelivery(ctx, "delivery-1"); !claimed {
+		t.Error("Expected a released delivery to be claimable again")
+	}
+
+	if err := repo.PruneDeliveries(ctx, now.Add(time.Second)); err != nil {
+		t.Fatalf("PruneDeliveries failed: %v", err)
+	}
+	if claimed, _ := repo.ClaimDelivery(ctx, "delivery-1"); !claimed {
+		t.Error("Expected a pruned delivery to be claimable again")
+	}
+}
@@ -0,0 +1,137 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/db"
+)
+
+var _ domain.WebmentionRepository = (*SQLiteWebmentionRepository)(nil)
+
+// SQLiteWebmentionRepository implements domain.WebmentionRepository using SQL database (SQLite)
+type SQLiteWebmentionRepository struct {
+	db *sql.DB
+}
+
+// NewWebmentionRepository creates a new SQLiteWebmentionRepository from a standard sql.DB
+func NewWebmentionRepository(db *sql.DB) *SQLiteWebmentionRepository {
+	return &SQLiteWebmentionRepository{
+		db: db,
+	}
+}
+
+const webmentionColumns = `id, source, target, post_id, protocol, title, status, received_at, verified_at`
+
+const saveWebmentionQuery = `
+	INSERT INTO webmentions (source, target, post_id, protocol, title, status, received_at, verified_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT(source, post_id) DO UPDATE SET
+		target = excluded.target,
+		protocol = excluded.protocol,
+		title = excluded.title,
+		verified_at = excluded.verified_at
+	RETURNING id, status, received_at
+`
+
+// SaveWebmention records a verified mention, keeping the status and received time of an existing one
+func (r *SQLiteWebmentionRepository) SaveWebmention(ctx context.Context, mention *domain.Webmention) error {
+	if mention == nil {
+		return fmt.Errorf("webmention cannot be nil")
+	}
+
+	executor := db.GetExecutor(ctx, r.db)
+	err := executor.QueryRowContext(ctx, saveWebmentionQuery,
+		mention.Source, mention.Target, mention.PostID, mention.Protocol, mention.Title, mention.Status, mention.ReceivedAt, mention.VerifiedAt,
+	).Scan(&mention.ID, &mention.Status, &mention.ReceivedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save webmention: %w", err)
+	}
+
+	return nil
+}
+
+const deleteWebmentionQuery = `
+	DELETE FROM webmentions WHERE source = ? AND post_id = ?
+`
+
+// DeleteWebmention removes the mention from source to a post, if there is one
+func (r *SQLiteWebmentionRepository) DeleteWebmention(ctx context.Context, source string, postID string) error {
+	executor := db.GetExecutor(ctx, r.db)
+	if _, err := executor.ExecContext(ctx, deleteWebmentionQuery, source, postID); err != nil {
+		return fmt.Errorf("failed to delete webmention: %w", err)
+	}
+	return nil
+}
+
+const listWebmentionsQuery = `
+	SELECT ` + webmentionColumns + `
+	FROM webmentions
+	WHERE ? = '' OR status = ?
+	ORDER BY received_at DESC, id DESC
+`
+
+// ListWebmentions returns the mentions with status, or every mention when status is empty, newest first
+func (r *SQLiteWebmentionRepository) ListWebmentions(ctx context.Context, status domain.WebmentionStatus) ([]*domain.Webmention, error) {
+	return r.queryWebmentions(ctx, listWebmentionsQuery, status, status)
+}
+
+const listPostWebmentionsQuery = `
+	SELECT ` + webmentionColumns + `
+	FROM webmentions
+	WHERE post_id = ? AND status = ?
+	ORDER BY received_at, id
+`
+
+// ListPostWebmentions returns the mentions of a post with status, oldest first
+func (r *SQLiteWebmentionRepository) ListPostWebmentions(ctx context.Context, postID string, status domain.WebmentionStatus) ([]*domain.Webmention, error) {
+	return r.queryWebmentions(ctx, listPostWebmentionsQuery, postID, status)
+}
+
+func (r *SQLiteWebmentionRepository) queryWebmentions(ctx context.Context, query string, args ...any) ([]*domain.Webmention, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webmentions: %w", err)
+	}
+	defer rows.Close()
+
+	mentions := make([]*domain.Webmention, 0)
+	for rows.Next() {
+		var m domain.Webmention
+		if err := rows.Scan(&m.ID, &m.Source, &m.Target, &m.PostID, &m.Protocol, &m.Title, &m.Status, &m.ReceivedAt, &m.VerifiedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan webmention row: %w", err)
+		}
+		mentions = append(mentions, &m)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webmention rows: %w", err)
+	}
+
+	return mentions, nil
+}
+
+const setWebmentionStatusQuery = `
+	UPDATE webmentions SET status = ? WHERE id = ?
+`
+
+// SetWebmentionStatus moderates a mention
+func (r *SQLiteWebmentionRepository) SetWebmentionStatus(ctx context.Context, id int64, status domain.WebmentionStatus) error {
+	executor := db.GetExecutor(ctx, r.db)
+	result, err := executor.ExecContext(ctx, setWebmentionStatusQuery, status, id)
+	if err != nil {
+		return fmt.Errorf("failed to set webmention status: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check moderated webmention: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%w: %d", domain.ErrWebmentionNotFound, id)
+	}
+
+	return nil
+}
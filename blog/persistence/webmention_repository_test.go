@@ -0,0 +1,114 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/dfryer1193/goblog/shared/blob"
+)
+
+func TestWebmentionRepository(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	posts := NewPostRepository(db, WithBlobStore(blob.NewLocalStore(t.TempDir())))
+	repo := NewWebmentionRepository(db)
+	ctx := context.Background()
+
+	for _, id := range []string{"001", "002"} {
+		post := &domain.Post{ID: id, Title: "Post " + id, HTMLPath: id + ".html", HTMLContent: []byte("<p></p>"), CreatedAt: now}
+		if err := posts.SavePost(ctx, post); err != nil {
+			t.Fatalf("SavePost(%s) error = %v", id, err)
+		}
+	}
+
+	mention := func(source string, postID string, at time.Time) *domain.Webmention {
+		return &domain.Webmention{
+			Source:     source,
+			Target:     "https://blog.example/posts/" + postID,
+			PostID:     postID,
+			Protocol:   domain.ProtocolWebmention,
+			Title:      "Reply from " + source,
+			Status:     domain.WebmentionPending,
+			ReceivedAt: at,
+			VerifiedAt: at,
+		}
+	}
+
+	first := mention("https://a.example/reply", "001", now)
+	if err := repo.SaveWebmention(ctx, first); err != nil {
+		t.Fatalf("Failed to save webmention: %v", err)
+	}
+	if first.ID == 0 {
+		t.Error("Expected SaveWebmention to set the ID")
+	}
+	if err := repo.SaveWebmention(ctx, mention("https://b.example/reply", "001", now.Add(time.Hour))); err != nil {
+		t.Fatalf("Failed to save webmention: %v", err)
+	}
+	if err := repo.SaveWebmention(ctx, mention("https://a.example/reply", "002", now.Add(2*time.Hour))); err != nil {
+		t.Fatalf("Failed to save webmention: %v", err)
+	}
+
+	if err := repo.SetWebmentionStatus(ctx, first.ID, domain.WebmentionApproved); err != nil {
+		t.Fatalf("Failed to approve webmention: %v", err)
+	}
+	if err := repo.SetWebmentionStatus(ctx, 999, domain.WebmentionApproved); !errors.Is(err, domain.ErrWebmentionNotFound) {
+		t.Errorf("Expected ErrWebmentionNotFound, got %v", err)
+	}
+
+	// The source sends the mention again after editing its page
+	resent := mention("https://a.example/reply", "001", now.Add(3*time.Hour))
+	resent.Title = "Edited reply"
+	if err := repo.SaveWebmention(ctx, resent); err != nil {
+		t.Fatalf("Failed to update webmention: %v", err)
+	}
+	if resent.ID != first.ID || resent.Status != domain.WebmentionApproved || !resent.ReceivedAt.Equal(now) {
+		t.Errorf("Expected the resent mention to keep its ID, status and received time, got %+v", resent)
+	}
+
+	all, err := repo.ListWebmentions(ctx, "")
+	if err != nil {
+		t.Fatalf("Failed to list webmentions: %v", err)
+	}
+	if len(all) != 3 || all[0].PostID != "002" || all[2].ID != first.ID {
+		t.Errorf("Expected every webmention newest first, got %+v", all)
+	}
+
+	pending, err := repo.ListWebmentions(ctx, domain.WebmentionPending)
+	if err != nil {
+		t.Fatalf("Failed to list webmentions: %v", err)
+	}
+	if len(pending) != 2 {
+		t.Errorf("Expected 2 pending webmentions, got %+v", pending)
+	}
+
+	approved, err := repo.ListPostWebmentions(ctx, "001", domain.WebmentionApproved)
+	if err != nil {
+		t.Fatalf("Failed to list post webmentions: %v", err)
+	}
+	if len(approved) != 1 || approved[0].Title != "Edited reply" || !approved[0].VerifiedAt.Equal(now.Add(3*time.Hour)) {
+		t.Errorf("Unexpected approved webmentions: %+v", approved)
+	}
+
+	if err := repo.DeleteWebmention(ctx, "https://a.example/reply", "001"); err != nil {
+		t.Fatalf("Failed to delete webmention: %v", err)
+	}
+	if err := repo.DeleteWebmention(ctx, "https://a.example/reply", "001"); err != nil {
+		t.Errorf("Expected deleting a missing webmention to succeed, got %v", err)
+	}
+
+	if err := posts.DeletePost(ctx, "002"); err != nil {
+		t.Fatalf("DeletePost() error = %v", err)
+	}
+	all, err = repo.ListWebmentions(ctx, "")
+	if err != nil {
+		t.Fatalf("Failed to list webmentions: %v", err)
+	}
+	if len(all) != 1 || all[0].Source != "https://b.example/reply" {
+		t.Errorf("Expected deleting posts to delete their webmentions, got %+v", all)
+	}
+}
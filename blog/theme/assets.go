@@ -0,0 +1,117 @@
+package theme
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// assetHashLength is how many hex digits of a static file's content hash go into its fingerprinted name
+const assetHashLength = 12
+
+// assets maps static files to fingerprinted names that change with their content, so they can be cached forever
+type assets struct {
+	// urls maps a static file's name to its fingerprinted URL
+	urls map[string]string
+	// files maps a fingerprinted name back to the static file
+	files map[string]string
+}
+
+// fingerprintAssets hashes every file in static, which is the overlay of layers
+// Files are listed from each layer, since an overlay only lists the directories of its upper layer.
+func fingerprintAssets(static fs.FS, layers ...fs.FS) (*assets, error) {
+	a := &assets{urls: make(map[string]string), files: make(map[string]string)}
+	for _, layer := range layers {
+		err := fs.WalkDir(layer, ".", func(name string, d fs.DirEntry, err error) error {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			if _, ok := a.urls[name]; ok {
+				return nil
+			}
+
+			content, err := fs.ReadFile(static, name)
+			if err != nil {
+				return err
+			}
+			sum := sha256.Sum256(content)
+			fingerprinted := fingerprintName(name, hex.EncodeToString(sum[:])[:assetHashLength])
+			a.urls[name] = "/static/" + fingerprinted
+			a.files[fingerprinted] = name
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to fingerprint static files: %w", err)
+		}
+	}
+	return a, nil
+}
+
+// fingerprintName puts hash before the extension of name, e.g. style.css becomes style.<hash>.css
+func fingerprintName(name string, hash string) string {
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + hash + ext
+}
+
+// url returns the fingerprinted URL of a static file, or its plain URL if the theme has no such file
+func (a *assets) url(name string) string {
+	if url, ok := a.urls[name]; ok {
+		return url
+	}
+	return "/static/" + name
+}
+
+// handler serves static files, caching those requested by their fingerprinted name forever
+// Plain names keep working for templates that don't use asset, and are cached as the file server decides.
+func (a *assets) handler(static fs.FS) http.Handler {
+	files := http.FileServerFS(static)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name, ok := a.files[strings.TrimPrefix(r.URL.Path, "/")]
+		if !ok {
+			files.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		r2 := new(http.Request)
+		*r2 = *r
+		u := *r.URL
+		u.Path = "/" + name
+		u.RawPath = ""
+		r2.URL = &u
+		files.ServeHTTP(w, r2)
+	})
+}
+
+// write copies every static file into dir, under its plain name and its fingerprinted one
+func (a *assets) write(static fs.FS, dir string) error {
+	for fingerprinted, name := range a.files {
+		content, err := fs.ReadFile(static, name)
+		if err != nil {
+			return fmt.Errorf("failed to read static file %s: %w", name, err)
+		}
+		for _, target := range []string{name, fingerprinted} {
+			path := filepath.Join(dir, filepath.FromSlash(target))
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return fmt.Errorf("failed to create directory for %s: %w", target, err)
+			}
+			if err := os.WriteFile(path, content, 0644); err != nil {
+				return fmt.Errorf("failed to write static file %s: %w", target, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package theme
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+)
+
+// defaultLanguage is the language every lookup falls back to, so its message file defines every key
+const defaultLanguage = "en"
+
+//go:embed i18n/*.json
+var defaultMessages embed.FS
+
+// Messages holds one language's template strings and date formatting, read from i18n/<lang>.json
+type Messages struct {
+	// DateFormat is a Go time layout; month names in it are written in English and replaced by Months
+	DateFormat string `json:"date_format"`
+	// Months are the twelve month names, starting with January
+	Months []string `json:"months"`
+	// Strings are fmt format strings, keyed by the name templates look them up with
+	Strings map[string]string `json:"strings"`
+}
+
+// catalog holds the messages of every language, keyed by lower case language tag
+type catalog struct {
+	languages map[string]*Messages
+	// site is the language used when a page's own language has no message for a key
+	site string
+}
+
+// loadCatalog reads the message files in i18n/ of each filesystem in turn
+// A later file for the same language adds to or replaces the strings of an earlier one.
+func loadCatalog(site string, files ...fs.FS) (*catalog, error) {
+	c := &catalog{languages: make(map[string]*Messages), site: strings.ToLower(site)}
+
+	for _, fsys := range files {
+		names, err := fs.Glob(fsys, "i18n/*.json")
+		if err != nil {
+			return nil, fmt.Errorf("failed to list message files: %w", err)
+		}
+
+		for _, name := range names {
+			content, err := fs.ReadFile(fsys, name)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read message file %s: %w", name, err)
+			}
+
+			var messages Messages
+			if err := json.Unmarshal(content, &messages); err != nil {
+				return nil, fmt.Errorf("failed to parse message file %s: %w", name, err)
+			}
+			if len(messages.Months) != 0 && len(messages.Months) != 12 {
+				return nil, fmt.Errorf("message file %s must list 12 months, got %d", name, len(messages.Months))
+			}
+
+			c.merge(strings.ToLower(strings.TrimSuffix(path.Base(name), ".json")), &messages)
+		}
+	}
+
+	return c, nil
+}
+
+func (c *catalog) merge(lang string, messages *Messages) {
+	existing, ok := c.languages[lang]
+	if !ok {
+		existing = &Messages{Strings: make(map[string]string)}
+		c.languages[lang] = existing
+	}
+
+	if messages.DateFormat != "" {
+		existing.DateFormat = messages.DateFormat
+	}
+	if len(messages.Months) != 0 {
+		existing.Months = messages.Months
+	}
+	for key, value := range messages.Strings {
+		existing.Strings[key] = value
+	}
+}
+
+// chain lists the messages to search for lang, most specific first
+// A regional tag such as de-AT falls back to de, then to the site language, then to English.
+func (c *catalog) chain(lang string) []*Messages {
+	var chain []*Messages
+	for _, tag := range []string{strings.ToLower(lang), c.site, defaultLanguage} {
+		for tag != "" {
+			if messages, ok := c.languages[tag]; ok {
+				chain = append(chain, messages)
+			}
+			base, _, found := strings.Cut(tag, "-")
+			if !found {
+				break
+			}
+			tag = base
+		}
+	}
+	return chain
+}
+
+// translate formats the message for key in lang, returning the key itself when no language defines it
+func (c *catalog) translate(lang string, key string, args ...any) string {
+	for _, messages := range c.chain(lang) {
+		if format, ok := messages.Strings[key]; ok {
+			return fmt.Sprintf(format, args...)
+		}
+	}
+	return key
+}
+
+// formatDate formats t with the date layout and month names of lang
+func (c *catalog) formatDate(lang string, t time.Time) string {
+	layout, months := "", []string(nil)
+	for _, messages := range c.chain(lang) {
+		if layout == "" {
+			layout = messages.DateFormat
+		}
+		if months == nil && len(messages.Months) == 12 {
+			months = messages.Months
+		}
+	}
+	if layout == "" {
+		layout = "January 2, 2006"
+	}
+
+	formatted := t.Format(layout)
+	if months != nil {
+		formatted = strings.ReplaceAll(formatted, t.Month().String(), months[t.Month()-1])
+	}
+	return formatted
+}
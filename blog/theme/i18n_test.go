@@ -0,0 +1,73 @@
+package theme
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestCatalog(t *testing.T) {
+	override := fstest.MapFS{
+		"i18n/de.json": {Data: []byte(`{"strings": {"home": "Start"}}`)},
+		"i18n/nl.json": {Data: []byte(`{"strings": {"home": "Thuis"}}`)},
+	}
+	c, err := loadCatalog("fr", defaultMessages, override)
+	if err != nil {
+		t.Fatalf("loadCatalog() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		lang string
+		key  string
+		args []any
+		want string
+	}{
+		{name: "Default language", lang: "en", key: "reading_time", args: []any{5}, want: "5 min read"},
+		{name: "Translated", lang: "de", key: "reading_time", args: []any{5}, want: "5 Min. Lesezeit"},
+		{name: "Override replaces a string", lang: "de", key: "home", want: "Start"},
+		{name: "Override keeps other strings", lang: "de", key: "no_posts", want: "Noch keine Beiträge."},
+		{name: "Regional tag falls back to its base", lang: "de-AT", key: "home", want: "Start"},
+		{name: "Language added by a theme", lang: "nl", key: "home", want: "Thuis"},
+		{name: "Missing string falls back to the site language", lang: "nl", key: "contents", want: "Sommaire"},
+		{name: "Unknown language uses the site language", lang: "xx", key: "home", want: "Accueil"},
+		{name: "Unknown key", lang: "en", key: "missing", want: "missing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.translate(tt.lang, tt.key, tt.args...); got != tt.want {
+				t.Errorf("translate(%q, %q) = %q, want %q", tt.lang, tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCatalog_FormatDate(t *testing.T) {
+	c, err := loadCatalog("en", defaultMessages)
+	if err != nil {
+		t.Fatalf("loadCatalog() error = %v", err)
+	}
+
+	date := time.Date(2025, 3, 9, 12, 0, 0, 0, time.UTC)
+	for lang, want := range map[string]string{
+		"en": "March 9, 2025",
+		"de": "9. März 2025",
+		"fr": "9 mars 2025",
+		"es": "9 de marzo de 2025",
+		"xx": "March 9, 2025",
+	} {
+		if got := c.formatDate(lang, date); got != want {
+			t.Errorf("formatDate(%q) = %q, want %q", lang, got, want)
+		}
+	}
+}
+
+func TestLoadCatalog_InvalidMonths(t *testing.T) {
+	files := fstest.MapFS{
+		"i18n/en.json": {Data: []byte(`{"months": ["January"]}`)},
+	}
+	if _, err := loadCatalog("en", files); err == nil {
+		t.Error("expected an error for a message file without twelve months")
+	}
+}
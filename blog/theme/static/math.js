@@ -0,0 +1,9 @@
+// Typesets the \(...\) and \[...\] math that goldmark passes through, once KaTeX's auto-render has loaded.
+document.addEventListener("DOMContentLoaded", function () {
+	renderMathInElement(document.querySelector(".post-content"), {
+		delimiters: [
+			{ left: "\\(", right: "\\)", display: false },
+			{ left: "\\[", right: "\\]", display: true },
+		],
+	});
+});
@@ -0,0 +1,5 @@
+// Draws the <pre class="mermaid"> diagrams in a post once Mermaid has loaded.
+document.addEventListener("DOMContentLoaded", function () {
+	mermaid.initialize({ startOnLoad: false });
+	mermaid.run({ querySelector: ".post-content pre.mermaid" });
+});
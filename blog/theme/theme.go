@@ -0,0 +1,296 @@
+package theme
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+)
+
+const (
+	defaultSiteTitle = "goblog"
+	defaultBaseURL   = "https://blog.werewolves.fyi"
+)
+
+//go:embed templates/*.html
+var defaultTemplates embed.FS
+
+//go:embed static
+var defaultStatic embed.FS
+
+// sharedTemplates are parsed into every page and may be overridden by a theme
+var sharedTemplates = []string{"layout.html", "header.html", "nav.html", "footer.html"}
+
+// pageTemplates are the pages the theme knows how to render
+var pageTemplates = []string{"index.html", "post.html"}
+
+type ThemeConfig struct {
+	// Dir is an optional directory containing template overrides.
+	// Any template or static file present in Dir replaces the embedded default.
+	Dir       string
+	SiteTitle string
+	BaseURL   string
+	// KaTeXURL is the base URL of the KaTeX distribution loaded on pages with math, or empty to load none
+	KaTeXURL string
+	// MermaidURL is the Mermaid script loaded on pages with diagrams, or empty to load none
+	MermaidURL string
+	// Location is the time zone dates are displayed in, defaulting to UTC
+	Location *time.Location
+	// Language is the site's language tag, used for pages whose post does not set its own
+	Language string
+}
+
+func NewThemeConfig() *ThemeConfig {
+	siteTitle := os.Getenv("SITE_TITLE")
+	if siteTitle == "" {
+		siteTitle = defaultSiteTitle
+	}
+
+	baseURL := os.Getenv("SITE_BASE_URL")
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+
+	language := os.Getenv("SITE_LANGUAGE")
+	if language == "" {
+		language = defaultLanguage
+	}
+
+	return &ThemeConfig{
+		Dir:        os.Getenv("THEME_DIR"),
+		SiteTitle:  siteTitle,
+		BaseURL:    baseURL,
+		KaTeXURL:   strings.TrimSuffix(os.Getenv("KATEX_URL"), "/"),
+		MermaidURL: os.Getenv("MERMAID_URL"),
+		Language:   language,
+	}
+}
+
+// Site holds the site-wide values available to every template
+type Site struct {
+	Title      string
+	BaseURL    string
+	KaTeXURL   string
+	MermaidURL string
+	// Language is the site's language tag
+	Language string
+	Year     int
+}
+
+// IndexPage is the data passed to the index template
+type IndexPage struct {
+	Site     Site
+	Posts    []*domain.Post
+	PrevPage int
+	NextPage int
+	// Static links the other pages as directories, for a site exported to static hosting
+	Static bool
+}
+
+// PageURL returns the URL of another page of the index
+func (p *IndexPage) PageURL(page int) string {
+	if page <= 1 {
+		return "/"
+	}
+	if p.Static {
+		return fmt.Sprintf("/page/%d/", page)
+	}
+	return fmt.Sprintf("/?page=%d", page)
+}
+
+// Lang is the language the index page is shown in
+func (p *IndexPage) Lang() string {
+	return p.Site.Language
+}
+
+// PostPage is the data passed to the post template
+type PostPage struct {
+	Site    Site
+	Post    *domain.Post
+	Content template.HTML
+	// Meta fills the page's Open Graph and Twitter Card tags
+	Meta domain.PostMetadata
+	// HasMath is set when Content contains math for KaTeX to typeset
+	HasMath bool
+	// HasMermaid is set when Content contains diagrams for Mermaid to draw
+	HasMermaid bool
+}
+
+// Lang is the language the post page is shown in, the post's own when it declares one
+func (p *PostPage) Lang() string {
+	if p.Post != nil && p.Post.Language != "" {
+		return p.Post.Language
+	}
+	return p.Site.Language
+}
+
+// Theme renders pages by wrapping them in the shared layout
+type Theme struct {
+	site     Site
+	location *time.Location
+	pages    map[string]*template.Template
+	static   fs.FS
+	assets   *assets
+}
+
+// Load parses the embedded default templates, replacing any that are present in cfg.Dir
+func Load(cfg *ThemeConfig) (*Theme, error) {
+	templates := fs.FS(defaultTemplates)
+	static, err := fs.Sub(defaultStatic, "static")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open embedded static files: %w", err)
+	}
+
+	staticLayers := []fs.FS{static}
+	messageFiles := []fs.FS{defaultMessages}
+	if cfg.Dir != "" {
+		info, err := os.Stat(cfg.Dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat theme directory: %w", err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("theme path exists but is not a directory: %s", cfg.Dir)
+		}
+
+		themeFS := os.DirFS(cfg.Dir)
+		themeStatic, err := fs.Sub(themeFS, "static")
+		if err != nil {
+			return nil, fmt.Errorf("failed to open theme static files: %w", err)
+		}
+		templates = &overlayFS{upper: themeFS, lower: templates}
+		messageFiles = append(messageFiles, themeFS)
+		static = &overlayFS{upper: themeStatic, lower: static}
+		staticLayers = append(staticLayers, themeStatic)
+	}
+
+	assets, err := fingerprintAssets(static, staticLayers...)
+	if err != nil {
+		return nil, err
+	}
+
+	language := cfg.Language
+	if language == "" {
+		language = defaultLanguage
+	}
+	messages, err := loadCatalog(language, messageFiles...)
+	if err != nil {
+		return nil, err
+	}
+
+	location := cfg.Location
+	if location == nil {
+		location = time.UTC
+	}
+	funcs := template.FuncMap{
+		// local converts a stored UTC time to the site's time zone for display
+		"local": func(t time.Time) time.Time { return t.In(location) },
+		// t looks up a message in the page's language and formats it with args
+		"t": messages.translate,
+		// date formats a stored UTC time as a date in the site's time zone and the page's language
+		"date": func(lang string, t time.Time) string { return messages.formatDate(lang, t.In(location)) },
+		// asset returns the URL of a static file, fingerprinted by its content so it can be cached forever
+		"asset": assets.url,
+	}
+
+	pages := make(map[string]*template.Template, len(pageTemplates))
+	for _, page := range pageTemplates {
+		files := append([]string{}, sharedTemplates...)
+		files = append(files, page)
+
+		tmpl := template.New(page).Funcs(funcs)
+		for _, file := range files {
+			content, err := fs.ReadFile(templates, "templates/"+file)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read template %s: %w", file, err)
+			}
+			target := tmpl
+			if file != page {
+				target = tmpl.New(file)
+			}
+			if _, err := target.Parse(string(content)); err != nil {
+				return nil, fmt.Errorf("failed to parse template %s: %w", file, err)
+			}
+		}
+		pages[page] = tmpl
+	}
+
+	return &Theme{
+		site: Site{
+			Title:      cfg.SiteTitle,
+			BaseURL:    cfg.BaseURL,
+			KaTeXURL:   cfg.KaTeXURL,
+			MermaidURL: cfg.MermaidURL,
+			Language:   language,
+		},
+		location: location,
+		pages:    pages,
+		static:   static,
+		assets:   assets,
+	}, nil
+}
+
+// Site returns the site-wide template values
+func (t *Theme) Site() Site {
+	site := t.site
+	site.Year = time.Now().In(t.location).Year()
+	return site
+}
+
+// RenderIndex renders the post listing page
+func (t *Theme) RenderIndex(w io.Writer, page *IndexPage) error {
+	return t.render(w, "index.html", page)
+}
+
+// RenderPost renders a single post page
+func (t *Theme) RenderPost(w io.Writer, page *PostPage) error {
+	return t.render(w, "post.html", page)
+}
+
+// WriteStatic writes the theme's static assets to dir under both their plain and fingerprinted names
+func (t *Theme) WriteStatic(dir string) error {
+	return t.assets.write(t.static, dir)
+}
+
+// StaticHandler serves the theme's static assets, by their plain or fingerprinted names
+func (t *Theme) StaticHandler() http.Handler {
+	return t.assets.handler(t.static)
+}
+
+func (t *Theme) render(w io.Writer, page string, data any) error {
+	tmpl, ok := t.pages[page]
+	if !ok {
+		return fmt.Errorf("unknown page template: %s", page)
+	}
+
+	if err := tmpl.ExecuteTemplate(w, "layout", data); err != nil {
+		return fmt.Errorf("failed to render %s: %w", page, err)
+	}
+
+	return nil
+}
+
+// overlayFS serves files from upper when they exist, falling back to lower
+type overlayFS struct {
+	upper fs.FS
+	lower fs.FS
+}
+
+func (o *overlayFS) Open(name string) (fs.File, error) {
+	f, err := o.upper.Open(name)
+	if err == nil {
+		return f, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	return o.lower.Open(name)
+}
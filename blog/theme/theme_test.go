@@ -0,0 +1,389 @@
+package theme
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+)
+
+func TestLoad_Defaults(t *testing.T) {
+	th, err := Load(&ThemeConfig{SiteTitle: "Test Blog"})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	for _, page := range pageTemplates {
+		if _, ok := th.pages[page]; !ok {
+			t.Errorf("page template %s not loaded", page)
+		}
+	}
+}
+
+func TestLoad_MissingDir(t *testing.T) {
+	_, err := Load(&ThemeConfig{Dir: filepath.Join(t.TempDir(), "missing")})
+	if err == nil {
+		t.Error("expected error for missing theme directory")
+	}
+}
+
+func TestRenderIndex(t *testing.T) {
+	th, err := Load(&ThemeConfig{SiteTitle: "Test Blog"})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	published := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	page := &IndexPage{
+		Site: th.Site(),
+		Posts: []*domain.Post{
+			{ID: "001", Title: "First Post", Snippet: "Hello there", PublishedAt: published},
+		},
+		NextPage: 2,
+	}
+
+	var buf bytes.Buffer
+	if err := th.RenderIndex(&buf, page); err != nil {
+		t.Fatalf("RenderIndex() error = %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"<title>Test Blog</title>",
+		`<a href="/posts/001">First Post</a>`,
+		"Hello there",
+		"June 1, 2024",
+		`href="/?page=2"`,
+		`class="site-footer"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("index output missing %q\n%s", want, out)
+		}
+	}
+
+	if strings.Contains(out, `rel="prev"`) {
+		t.Error("first page should not link to a previous page")
+	}
+}
+
+func TestRenderPost(t *testing.T) {
+	th, err := Load(&ThemeConfig{SiteTitle: "Test Blog"})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	page := &PostPage{
+		Site:    th.Site(),
+		Post:    &domain.Post{ID: "001", Title: "First <Post>"},
+		Content: template.HTML("<p>Body <strong>text</strong></p>"),
+	}
+
+	var buf bytes.Buffer
+	if err := th.RenderPost(&buf, page); err != nil {
+		t.Fatalf("RenderPost() error = %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "<title>First &lt;Post&gt; - Test Blog</title>") {
+		t.Errorf("post title not escaped in output\n%s", out)
+	}
+	if !strings.Contains(out, "<p>Body <strong>text</strong></p>") {
+		t.Errorf("post content not rendered verbatim\n%s", out)
+	}
+	if strings.Contains(out, `class="toc"`) {
+		t.Errorf("post without headings should not have a table of contents\n%s", out)
+	}
+}
+
+func TestRenderPost_TOC(t *testing.T) {
+	th, err := Load(&ThemeConfig{SiteTitle: "Test Blog"})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	page := &PostPage{
+		Site: th.Site(),
+		Post: &domain.Post{ID: "001", Title: "Long", TOC: []*domain.Heading{
+			{Level: 2, ID: "setup", Text: "Setup", Children: []*domain.Heading{
+				{Level: 3, ID: "install", Text: "Install & run"},
+			}},
+			{Level: 2, ID: "usage", Text: "Usage"},
+		}},
+	}
+
+	var buf bytes.Buffer
+	if err := th.RenderPost(&buf, page); err != nil {
+		t.Fatalf("RenderPost() error = %v", err)
+	}
+
+	want := `<ol><li><a href="#setup">Setup</a><ol><li><a href="#install">Install &amp; run</a></li></ol></li><li><a href="#usage">Usage</a></li></ol>`
+	if out := buf.String(); !strings.Contains(out, want) {
+		t.Errorf("table of contents not rendered\n%s", out)
+	}
+}
+
+func TestLoad_OverridesTemplatesAndStatic(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "templates"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "static"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	footer := `{{define "footer"}}<footer>custom footer</footer>{{end}}`
+	if err := os.WriteFile(filepath.Join(dir, "templates", "footer.html"), []byte(footer), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "static", "style.css"), []byte("body{color:red}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	th, err := Load(&ThemeConfig{Dir: dir, SiteTitle: "Test Blog"})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := th.RenderIndex(&buf, &IndexPage{Site: th.Site()}); err != nil {
+		t.Fatalf("RenderIndex() error = %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "custom footer") {
+		t.Errorf("footer override not applied\n%s", out)
+	}
+	if !strings.Contains(out, `class="site-header"`) {
+		t.Errorf("default header should still be used\n%s", out)
+	}
+
+	rr := httptest.NewRecorder()
+	th.StaticHandler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/style.css", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("static status = %d, want %d", rr.Code, http.StatusOK)
+	}
+	if rr.Body.String() != "body{color:red}" {
+		t.Errorf("static override not served, got %q", rr.Body.String())
+	}
+}
+
+func TestRenderPost_Metadata(t *testing.T) {
+	th, err := Load(&ThemeConfig{SiteTitle: "Test Blog"})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	page := &PostPage{
+		Site: th.Site(),
+		Post: &domain.Post{ID: "001", Title: "First Post"},
+		Meta: domain.PostMetadata{
+			Title:       "First Post",
+			Description: "Hello & welcome",
+			URL:         "https://blog.example.com/posts/001",
+			Image:       "https://blog.example.com/images/abc.png",
+			TwitterCard: "summary_large_image",
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := th.RenderPost(&buf, page); err != nil {
+		t.Fatalf("RenderPost() error = %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		`<meta property="og:title" content="First Post">`,
+		`<meta property="og:description" content="Hello &amp; welcome">`,
+		`<meta property="og:url" content="https://blog.example.com/posts/001">`,
+		`<meta property="og:image" content="https://blog.example.com/images/abc.png">`,
+		`<meta name="twitter:card" content="summary_large_image">`,
+		`<meta name="twitter:image" content="https://blog.example.com/images/abc.png">`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("post output missing %q\n%s", want, out)
+		}
+	}
+}
+
+func TestRenderPost_Scripts(t *testing.T) {
+	th, err := Load(&ThemeConfig{
+		SiteTitle:  "Test Blog",
+		KaTeXURL:   "https://cdn.example.com/katex",
+		MermaidURL: "https://cdn.example.com/mermaid.min.js",
+	})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	katex := `<script defer src="https://cdn.example.com/katex/katex.min.js"></script>`
+	mermaid := `<script defer src="https://cdn.example.com/mermaid.min.js"></script>`
+	for _, page := range []*PostPage{
+		{HasMath: false, HasMermaid: false},
+		{HasMath: true, HasMermaid: false},
+		{HasMath: false, HasMermaid: true},
+	} {
+		page.Site = th.Site()
+		page.Post = &domain.Post{ID: "001", Title: "Scripts"}
+
+		var buf bytes.Buffer
+		if err := th.RenderPost(&buf, page); err != nil {
+			t.Fatalf("RenderPost() error = %v", err)
+		}
+
+		out := buf.String()
+		if loaded := strings.Contains(out, katex); loaded != page.HasMath {
+			t.Errorf("HasMath = %v, but KaTeX loaded = %v\n%s", page.HasMath, loaded, out)
+		}
+		if loaded := strings.Contains(out, mermaid); loaded != page.HasMermaid {
+			t.Errorf("HasMermaid = %v, but Mermaid loaded = %v\n%s", page.HasMermaid, loaded, out)
+		}
+	}
+}
+
+func TestRenderPost_Location(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+	th, err := Load(&ThemeConfig{SiteTitle: "Test Blog", Location: tokyo})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// Late on the 1st in UTC is already the 2nd in Tokyo
+	published := time.Date(2025, 6, 1, 20, 0, 0, 0, time.UTC)
+	page := &PostPage{
+		Site: th.Site(),
+		Post: &domain.Post{ID: "001", Title: "Post", PublishedAt: published, UpdatedAt: published},
+	}
+
+	var buf bytes.Buffer
+	if err := th.RenderPost(&buf, page); err != nil {
+		t.Fatalf("RenderPost() error = %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, `<time datetime="2025-06-02T05:00:00&#43;09:00">June 2, 2025</time>`) {
+		t.Errorf("publish date not shown in the site's time zone\n%s", out)
+	}
+}
+
+func TestRenderPost_Language(t *testing.T) {
+	th, err := Load(&ThemeConfig{SiteTitle: "Test Blog", Language: "en"})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	published := time.Date(2025, 3, 9, 12, 0, 0, 0, time.UTC)
+	page := &PostPage{
+		Site: th.Site(),
+		Post: &domain.Post{ID: "001", Title: "Beitrag", Language: "de", PublishedAt: published, ReadingMinutes: 3},
+	}
+
+	var buf bytes.Buffer
+	if err := th.RenderPost(&buf, page); err != nil {
+		t.Fatalf("RenderPost() error = %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		`<html lang="de">`,
+		`>9. März 2025</time>`,
+		`>3 Min. Lesezeit</span>`,
+		`<a href="/">Startseite</a>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\n%s", want, out)
+		}
+	}
+
+	buf.Reset()
+	if err := th.RenderIndex(&buf, &IndexPage{Site: th.Site()}); err != nil {
+		t.Fatalf("RenderIndex() error = %v", err)
+	}
+	if out := buf.String(); !strings.Contains(out, `<html lang="en">`) || !strings.Contains(out, "No posts yet.") {
+		t.Errorf("index should use the site language\n%s", out)
+	}
+}
+
+func TestStaticHandler_Fingerprints(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "static"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "static", "style.css"), []byte("body{color:red}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	th, err := Load(&ThemeConfig{Dir: dir, SiteTitle: "Test Blog"})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	url := th.assets.url("style.css")
+	if !strings.HasPrefix(url, "/static/style.") || !strings.HasSuffix(url, ".css") || len(url) != len("/static/style..css")+assetHashLength {
+		t.Fatalf("asset URL = %q, want the style sheet fingerprinted", url)
+	}
+	if math := th.assets.url("math.js"); math == "/static/math.js" {
+		t.Error("Expected embedded files to be fingerprinted alongside the theme's")
+	}
+
+	var buf bytes.Buffer
+	if err := th.RenderIndex(&buf, &IndexPage{Site: th.Site()}); err != nil {
+		t.Fatalf("RenderIndex() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), `href="`+url+`"`) {
+		t.Errorf("index does not link the fingerprinted style sheet %s\n%s", url, buf.String())
+	}
+
+	rr := httptest.NewRecorder()
+	th.StaticHandler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, strings.TrimPrefix(url, "/static"), nil))
+	if rr.Code != http.StatusOK || rr.Body.String() != "body{color:red}" {
+		t.Fatalf("fingerprinted asset = %d %q, want the theme's style sheet", rr.Code, rr.Body.String())
+	}
+	if got := rr.Header().Get("Cache-Control"); !strings.Contains(got, "immutable") {
+		t.Errorf("Cache-Control = %q, want a fingerprinted asset cached forever", got)
+	}
+
+	rr = httptest.NewRecorder()
+	th.StaticHandler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/style.css", nil))
+	if rr.Code != http.StatusOK || rr.Header().Get("Cache-Control") != "" {
+		t.Errorf("plain asset = %d with Cache-Control %q, want it served without the long cache", rr.Code, rr.Header().Get("Cache-Control"))
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "static", "style.css"), []byte("body{color:blue}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := Load(&ThemeConfig{Dir: dir, SiteTitle: "Test Blog"})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if reloaded.assets.url("style.css") == url {
+		t.Error("Expected a changed style sheet to get a new URL")
+	}
+}
+
+func TestIndexPage_PageURL(t *testing.T) {
+	served := &IndexPage{}
+	exported := &IndexPage{Static: true}
+	for _, tt := range []struct {
+		page     *IndexPage
+		n        int
+		expected string
+	}{
+		{served, 1, "/"},
+		{served, 2, "/?page=2"},
+		{exported, 1, "/"},
+		{exported, 3, "/page/3/"},
+	} {
+		if got := tt.page.PageURL(tt.n); got != tt.expected {
+			t.Errorf("PageURL(%d) with Static = %v = %q, want %q", tt.n, tt.page.Static, got, tt.expected)
+		}
+	}
+}
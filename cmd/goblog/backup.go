@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/dfryer1193/goblog/shared/backup"
+	"github.com/dfryer1193/goblog/shared/blob"
+	"github.com/dfryer1193/goblog/shared/db/sqlite"
+)
+
+// blobDirs returns the local directories of the post and image stores, or none if they are kept in S3
+func blobDirs(cfg *blob.Config) map[string]string {
+	if cfg.Backend != blob.BackendLocal {
+		return nil
+	}
+	return map[string]string{
+		"posts":  filepath.Join(cfg.Dir, "posts"),
+		"images": filepath.Join(cfg.Dir, "images"),
+	}
+}
+
+// runBackup writes an archive of a snapshot of the database and the local post and image stores
+func runBackup(args []string) error {
+	flags := flag.NewFlagSet("backup", flag.ExitOnError)
+	out := flags.String("out", "", "path of the archive to write, e.g. goblog.tar.gz")
+	flags.Parse(args)
+
+	if *out == "" {
+		flags.Usage()
+		return fmt.Errorf("-out is required")
+	}
+
+	blobConfig := blob.NewConfig()
+	dirs := blobDirs(blobConfig)
+	if dirs == nil {
+		fmt.Printf("Posts and images are kept in %s; only the database is archived\n", blobConfig.Backend)
+	}
+
+	dbClient := sqlite.NewSQLiteDB(sqlite.NewSQLiteConfig())
+	if err := dbClient.Connect(); err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer dbClient.Close()
+
+	tmpDir, err := os.MkdirTemp("", "goblog-backup-")
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	snapshot := filepath.Join(tmpDir, backup.DatabaseName)
+	if err := dbClient.Backup(context.Background(), snapshot); err != nil {
+		return err
+	}
+
+	// The archive is written beside its destination and renamed into place, so it is never seen half written
+	tmp, err := os.CreateTemp(filepath.Dir(*out), ".goblog-backup-*")
+	if err != nil {
+		return fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := backup.Write(tmp, snapshot, dirs); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), *out); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	fmt.Printf("Wrote %s\n", *out)
+	return nil
+}
+
+// runRestore replaces the database and the local post and image stores with the contents of an archive
+func runRestore(args []string) error {
+	flags := flag.NewFlagSet("restore", flag.ExitOnError)
+	in := flags.String("in", "", "path of an archive written by goblog backup")
+	force := flags.Bool("force", false, "replace an existing database")
+	flags.Parse(args)
+
+	if *in == "" {
+		flags.Usage()
+		return fmt.Errorf("-in is required")
+	}
+
+	dbPath := sqlite.NewSQLiteConfig().Path
+	if _, err := os.Stat(dbPath); !errors.Is(err, fs.ErrNotExist) && !*force {
+		return fmt.Errorf("%s already exists; stop the server and pass -force to replace it", dbPath)
+	}
+
+	blobConfig := blob.NewConfig()
+	dirs := blobDirs(blobConfig)
+	if dirs == nil {
+		fmt.Printf("Posts and images are kept in %s; only the database is restored\n", blobConfig.Backend)
+	}
+
+	f, err := os.Open(*in)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer f.Close()
+
+	if err := backup.Restore(f, dbPath, dirs); err != nil {
+		return err
+	}
+
+	fmt.Printf("Restored %s\n", *in)
+	return nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/dfryer1193/goblog/blog/application"
+	bloghttp "github.com/dfryer1193/goblog/blog/http"
+	"github.com/dfryer1193/goblog/blog/persistence"
+	"github.com/dfryer1193/goblog/blog/theme"
+	"github.com/dfryer1193/goblog/shared/blob"
+	"github.com/dfryer1193/goblog/shared/db/sqlite"
+)
+
+// export writes the published site to a directory of static files
+func export(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	out := flags.String("out", "", "directory to write the site to, e.g. ./dist")
+	force := flags.Bool("force", false, "replace an existing directory")
+	flags.Parse(args)
+
+	if *out == "" {
+		flags.Usage()
+		return fmt.Errorf("-out is required")
+	}
+	if _, err := os.Stat(*out); !errors.Is(err, fs.ErrNotExist) && !*force {
+		return fmt.Errorf("%s already exists; pass -force to replace it", *out)
+	}
+
+	location, err := application.NewTimezoneConfig().Location()
+	if err != nil {
+		return fmt.Errorf("invalid SITE_TIMEZONE: %w", err)
+	}
+	themeConfig := theme.NewThemeConfig()
+	themeConfig.Location = location
+	blogTheme, err := theme.Load(themeConfig)
+	if err != nil {
+		return fmt.Errorf("failed to load theme: %w", err)
+	}
+
+	dbClient := sqlite.NewSQLiteDB(sqlite.NewSQLiteConfig())
+	if err := dbClient.Connect(); err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer dbClient.Close()
+
+	blobConfig := blob.NewConfig()
+	postBlobs, err := blob.New(blobConfig, "posts")
+	if err != nil {
+		return fmt.Errorf("failed to open post storage: %w", err)
+	}
+	imageBlobs, err := blob.New(blobConfig, "images")
+	if err != nil {
+		return fmt.Errorf("failed to open image storage: %w", err)
+	}
+
+	// Only stored posts are read, so there is no source repository to sync from
+	postService := application.NewPostService(
+		persistence.NewPostRepository(dbClient.DB(), persistence.WithBlobStore(postBlobs), persistence.WithHTMLStorage(persistence.NewHTMLStorageConfig())),
+		persistence.NewImageRepository(dbClient.DB(), persistence.WithBlobStore(imageBlobs)),
+		nil,
+		application.NewMarkdownRenderer(),
+		"",
+		application.WithMetadata(application.NewMetadataConfig()),
+		application.WithFeed(application.NewFeedConfig()),
+		application.WithRedirects(persistence.NewRedirectRepository(dbClient.DB())),
+	)
+	defer postService.Close()
+
+	// The site is written beside its destination and swapped in, so a failed export leaves the old one alone
+	tmpDir, err := os.MkdirTemp(filepath.Dir(filepath.Clean(*out)), ".goblog-export-")
+	if err != nil {
+		return fmt.Errorf("failed to create export directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	stats, err := bloghttp.NewSiteExporter(postService, blogTheme).Export(context.Background(), tmpDir)
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpDir, 0755); err != nil {
+		return fmt.Errorf("failed to write %s: %w", *out, err)
+	}
+	if err := os.RemoveAll(*out); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", *out, err)
+	}
+	if err := os.Rename(tmpDir, *out); err != nil {
+		return fmt.Errorf("failed to write %s: %w", *out, err)
+	}
+
+	fmt.Printf("Wrote %d posts, %d index pages and %d images to %s\n", stats.Posts, stats.IndexPages, stats.Images, *out)
+	return nil
+}
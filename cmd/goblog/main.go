@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	// Embedded so SITE_TIMEZONE works on hosts without a zoneinfo database
+	_ "time/tzdata"
+
+	sourcegithub "github.com/dfryer1193/goblog/shared/github"
+	webhookhttp "github.com/dfryer1193/goblog/webhook/http"
+
+	"github.com/google/go-github/v75/github"
+)
+
+const authTokenEnv = "GITHUB_AUTH_TOKEN"
+
+const usage = `Usage: goblog <command> [flags]
+
+Commands:
+  serve       Run the blog server
+  init-repo   Create a content repository and its push webhook
+  verify      Check stored post HTML against its signatures
+  backup      Archive the database and the post and image stores
+  restore     Replace the database and the post and image stores from an archive
+  migrate     Apply, revert or report database migrations (up, down, status)
+  preview     Serve a local checkout of the content repository with live reload
+  export      Write the published site to a directory of static files
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "serve":
+		err = serve(os.Args[2:])
+	case "init-repo":
+		err = initRepo(os.Args[2:])
+	case "verify":
+		err = verify(os.Args[2:])
+	case "backup":
+		err = runBackup(os.Args[2:])
+	case "restore":
+		err = runRestore(os.Args[2:])
+	case "migrate":
+		err = migrate(os.Args[2:])
+	case "preview":
+		err = preview(os.Args[2:])
+	case "export":
+		err = export(os.Args[2:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "goblog %s: %v\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
+
+// initRepo creates a content repository with the expected layout and a webhook pointing at the server
+func initRepo(args []string) error {
+	flags := flag.NewFlagSet("init-repo", flag.ExitOnError)
+	name := flags.String("name", "", "name of the repository to create")
+	org := flags.String("org", "", "organization to create the repository in (default: the authenticated user)")
+	private := flags.Bool("private", false, "create a private repository")
+	server := flags.String("server", "", "public URL of the goblog server, e.g. https://blog.example.com")
+	secret := flags.String("webhook-secret", "", "secret used to sign webhook deliveries (default: generated)")
+	flags.Parse(args)
+
+	if *name == "" || *server == "" {
+		flags.Usage()
+		return fmt.Errorf("-name and -server are required")
+	}
+
+	authToken := os.Getenv(authTokenEnv)
+	if authToken == "" {
+		return fmt.Errorf("environment variable %s is not set", authTokenEnv)
+	}
+
+	if *secret == "" {
+		*secret = rand.Text()
+	}
+
+	client := github.NewClient(nil).WithAuthToken(authToken)
+	repo, err := sourcegithub.BootstrapRepository(context.Background(), client, &sourcegithub.BootstrapConfig{
+		Org:           *org,
+		Name:          *name,
+		Private:       *private,
+		WebhookURL:    strings.TrimSuffix(*server, "/") + webhookhttp.WebhookPath,
+		WebhookSecret: *secret,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Created %s\n\n", repo.GetHTMLURL())
+	fmt.Println("Start the server with:")
+	fmt.Printf("  GITHUB_REPO=%s\n", repo.GetFullName())
+	fmt.Printf("  WEBHOOK_SECRET=%s\n", *secret)
+	return nil
+}
+
+// newGithubClient authenticates as the GitHub App installation on the repository when an app is configured,
+// and with the GITHUB_AUTH_TOKEN personal access token otherwise
+func newGithubClient(owner string, gitRepo string) (*github.Client, error) {
+	if appConfig := sourcegithub.NewAppConfig(); appConfig.Enabled() {
+		return sourcegithub.NewAppClient(appConfig, owner, gitRepo)
+	}
+
+	authToken := os.Getenv(authTokenEnv)
+	if authToken == "" {
+		return nil, fmt.Errorf("environment variable %s or GITHUB_APP_ID must be set", authTokenEnv)
+	}
+	return github.NewClient(nil).WithAuthToken(authToken), nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dfryer1193/goblog/shared/db/sqlite"
+)
+
+const migrateUsage = `Usage: goblog migrate <up|down|status> [flags]
+`
+
+// migrate applies, reverts or reports database migrations without starting the server
+func migrate(args []string) error {
+	if len(args) < 1 {
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return fmt.Errorf("a migrate command is required")
+	}
+
+	flags := flag.NewFlagSet("migrate "+args[0], flag.ExitOnError)
+	to := flags.Int("to", 0, "version to migrate up to (default: the latest)")
+	steps := flags.Int("steps", 1, "number of migrations to revert")
+	flags.Parse(args[1:])
+
+	dbClient := sqlite.NewSQLiteDB(sqlite.NewSQLiteConfig())
+	if err := dbClient.Open(); err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer dbClient.Close()
+
+	ctx := context.Background()
+	switch args[0] {
+	case "up":
+		applied, err := dbClient.MigrateUp(ctx, *to)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Applied %d migrations\n", applied)
+	case "down":
+		if *steps < 1 {
+			return fmt.Errorf("-steps must be at least 1")
+		}
+		reverted, err := dbClient.MigrateDown(ctx, *steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Reverted %d migrations\n", reverted)
+	case "status":
+		return printMigrationStatus(ctx, dbClient)
+	default:
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return fmt.Errorf("unknown migrate command %q", args[0])
+	}
+
+	return printMigrationStatus(ctx, dbClient)
+}
+
+func printMigrationStatus(ctx context.Context, dbClient *sqlite.SQLiteDB) error {
+	status, err := dbClient.MigrationStatus(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+	for _, m := range status.Migrations {
+		applied := "pending"
+		if !m.AppliedAt.IsZero() {
+			applied = m.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", m.Version, m.Name, applied)
+	}
+	w.Flush()
+
+	fmt.Printf("\nSchema version: %d\n", status.Version)
+	if len(status.Unknown) > 0 {
+		fmt.Printf("Applied by a newer build: %v\n", status.Unknown)
+	}
+	if d := status.Dirty; d != nil {
+		fmt.Printf("Interrupted: migration %d %s, started %s\n", d.Version, d.Direction, d.StartedAt.Format(time.RFC3339))
+		fmt.Println("The server will not start until goblog migrate up or down is run.")
+	}
+	return nil
+}
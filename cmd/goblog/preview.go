@@ -0,0 +1,65 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/application"
+	bloghttp "github.com/dfryer1193/goblog/blog/http"
+	"github.com/dfryer1193/goblog/blog/theme"
+)
+
+// preview serves a local checkout of the content repository as it would be published, reloading pages as it changes
+func preview(args []string) error {
+	flags := flag.NewFlagSet("preview", flag.ExitOnError)
+	addr := flags.String("addr", "localhost:8000", "address to serve the preview on")
+	interval := flags.Duration("interval", 500*time.Millisecond, "how often to check the checkout for changes")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: goblog preview [flags] [dir]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	dir := "."
+	if flags.NArg() > 0 {
+		dir = flags.Arg(0)
+	}
+	layout, err := application.NewContentLayout(application.NewContentLayoutConfig())
+	if err != nil {
+		return fmt.Errorf("invalid CONTENT_POST_IDS: %w", err)
+	}
+	if info, err := os.Stat(filepath.Join(dir, layout.PostsDir())); err != nil || !info.IsDir() {
+		return fmt.Errorf("%s has no %s directory", dir, layout.PostsDir())
+	}
+
+	location, err := application.NewTimezoneConfig().Location()
+	if err != nil {
+		return fmt.Errorf("invalid SITE_TIMEZONE: %w", err)
+	}
+
+	// Links are rooted at the preview server instead of the published site
+	renderer := application.NewMarkdownRenderer(
+		application.WithMarkdownExtensions(application.NewMarkdownConfig()),
+		application.WithFrontMatterValidation(application.NewFrontMatterConfig()),
+		application.WithTimezone(location),
+		application.WithLinkBaseURL(""),
+		application.WithMarkdownLayout(layout),
+	)
+
+	themeConfig := theme.NewThemeConfig()
+	themeConfig.BaseURL = "http://" + *addr
+	themeConfig.Location = location
+	if _, err := theme.Load(themeConfig); err != nil {
+		return fmt.Errorf("failed to load theme: %w", err)
+	}
+
+	r := newRouter()
+	bloghttp.NewLocalPreviewHandler(application.NewLocalSite(dir, layout, renderer), themeConfig, *interval).RegisterRoutes(r)
+
+	fmt.Printf("Previewing %s at http://%s\n", dir, *addr)
+	return http.ListenAndServe(*addr, r)
+}
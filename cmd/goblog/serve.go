@@ -0,0 +1,338 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/application"
+	"github.com/dfryer1193/goblog/blog/domain"
+	bloghttp "github.com/dfryer1193/goblog/blog/http"
+	"github.com/dfryer1193/goblog/blog/persistence"
+	"github.com/dfryer1193/goblog/blog/theme"
+	"github.com/dfryer1193/goblog/shared/blob"
+	"github.com/dfryer1193/goblog/shared/cors"
+	"github.com/dfryer1193/goblog/shared/db/sqlite"
+	sourcegithub "github.com/dfryer1193/goblog/shared/github"
+	"github.com/dfryer1193/goblog/shared/health"
+	"github.com/dfryer1193/goblog/shared/httplog"
+	"github.com/dfryer1193/goblog/shared/mail"
+	"github.com/dfryer1193/goblog/shared/notify"
+	"github.com/dfryer1193/goblog/shared/scheduler"
+	webhookhttp "github.com/dfryer1193/goblog/webhook/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	port            = 8080
+	shutdownTimeout = 5 * time.Second
+	defaultRepo     = "dfryer1193/blog"
+	repoEnv         = "GITHUB_REPO"
+
+	// publishCheckInterval is how often scheduled posts are checked for publication
+	publishCheckInterval = time.Minute
+)
+
+// serve runs the blog server until it is interrupted
+func serve(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	flags.Parse(args)
+
+	configureLogging()
+
+	dbClient := sqlite.NewSQLiteDB(sqlite.NewSQLiteConfig())
+	if err := dbClient.Connect(); err != nil {
+		log.Fatal().Err(err).Msg("Failed to connect to database")
+	}
+	defer dbClient.Close()
+
+	repoOwner, repoName, ok := strings.Cut(cmp.Or(os.Getenv(repoEnv), defaultRepo), "/")
+	if !ok || repoOwner == "" || repoName == "" {
+		log.Fatal().Msgf("Environment variable %s must be in the form owner/name", repoEnv)
+	}
+
+	githubClient, err := newGithubClient(repoOwner, repoName)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to authenticate with GitHub")
+	}
+	sourceRepo := sourcegithub.NewGithubSourceRepository(githubClient, repoOwner, repoName,
+		sourcegithub.WithRetry(sourcegithub.NewRetryConfig()),
+		sourcegithub.WithRawContent(sourcegithub.NewRawContentConfig()),
+		sourcegithub.WithListConfig(sourcegithub.NewListConfig()),
+		sourcegithub.WithLFS(sourcegithub.NewLFSConfig()),
+		sourcegithub.WithConditionalRequests(),
+	)
+
+	var webhooks *sourcegithub.WebhookRegistrar
+	if webhookConfig := sourcegithub.NewWebhookConfig(); webhookConfig.AutoRegister {
+		if webhookConfig.PublicURL == "" {
+			log.Fatal().Msg("PUBLIC_URL must be set when WEBHOOK_AUTO_REGISTER is enabled")
+		}
+
+		webhookURL := strings.TrimSuffix(webhookConfig.PublicURL, "/") + webhookhttp.WebhookPath
+		webhooks = sourcegithub.NewWebhookRegistrar(githubClient, repoOwner, repoName, webhookURL, webhookConfig.Secret)
+		if err := webhooks.Ensure(context.Background()); err != nil {
+			log.Error().Err(err).Str("url", webhookURL).Msg("Failed to register webhook")
+		} else {
+			log.Info().Str("url", webhookURL).Str("state", string(webhooks.Status().State)).Msg("Webhook registered")
+		}
+	}
+
+	mainBranchName, err := sourceRepo.GetDefaultBranchName(context.Background())
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to determine default branch")
+	}
+
+	blobConfig := blob.NewConfig()
+	postBlobs, err := blob.New(blobConfig, "posts")
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to create post storage")
+	}
+	imageBlobs, err := blob.New(blobConfig, "images")
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to create image storage")
+	}
+
+	htmlStorage := persistence.NewHTMLStorageConfig()
+	postRepo := persistence.NewPostRepository(dbClient.DB(), persistence.WithBlobStore(postBlobs), persistence.WithHTMLStorage(htmlStorage))
+	imageRepo := persistence.NewImageRepository(dbClient.DB(), persistence.WithBlobStore(imageBlobs))
+	syncJobRepo := persistence.NewSyncJobRepository(dbClient.DB())
+	deadLetterRepo := persistence.NewDeadLetterRepository(dbClient.DB())
+	authService := application.NewAuthService(persistence.NewAPITokenRepository(dbClient.DB()), application.NewAuthConfig())
+	cachedSourceRepo := application.NewCachingSourceRepository(sourceRepo, persistence.NewSourceCacheRepository(dbClient.DB()))
+	diskQuotaConfig := application.NewDiskQuotaConfig()
+	diskUsage := application.NewDiskUsageMonitor(postRepo, imageRepo, dbClient, diskQuotaConfig)
+	if err := diskUsage.Refresh(context.Background()); err != nil {
+		log.Error().Err(err).Msg("Failed to measure disk usage")
+	}
+
+	signingKey, err := application.NewSigningConfig().PrivateKey()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid CONTENT_SIGNING_KEY")
+	}
+
+	location, err := application.NewTimezoneConfig().Location()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid SITE_TIMEZONE")
+	}
+
+	federationConfig := application.NewFederationConfig()
+	federationKey, err := federationConfig.PrivateKey()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid FEDERATION_KEY_FILE")
+	}
+
+	newsletterConfig := application.NewNewsletterConfig()
+	var newsletterMailer mail.Sender
+	if newsletterConfig.Enabled {
+		newsletterMailer, err = mail.NewSMTPSender(mail.NewConfig())
+		if err != nil {
+			log.Fatal().Err(err).Msg("NEWSLETTER needs SMTP_HOST and SMTP_FROM to send through")
+		}
+	}
+
+	var notifier notify.Notifier
+	notificationRouter, err := notify.NewRouter(notify.NewConfig(), nil)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid NOTIFY_FAILURES or NOTIFY_PUBLISHES")
+	}
+	if notificationRouter.Enabled() {
+		notifier = notificationRouter
+	}
+
+	contentLayout, err := application.NewContentLayout(application.NewContentLayoutConfig())
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid CONTENT_POST_IDS")
+	}
+
+	publishingConfig := application.NewPublishingConfig()
+	if err := publishingConfig.Validate(); err != nil {
+		log.Fatal().Err(err).Msg("Invalid PUBLISH_MODE or PUBLISH_TAG_PATTERN")
+	}
+
+	syncConfig := application.NewSyncConfig()
+	previewConfig := application.NewPreviewRetentionConfig()
+	postService := application.NewPostService(
+		postRepo,
+		imageRepo,
+		cachedSourceRepo,
+		application.NewMarkdownRenderer(
+			application.WithImageResolver(application.ImageRepositoryResolver(imageRepo)),
+			application.WithMarkdownExtensions(application.NewMarkdownConfig()),
+			application.WithFrontMatterValidation(application.NewFrontMatterConfig()),
+			application.WithTimezone(location),
+			application.WithImageURLs(application.NewImageURLConfig()),
+			application.WithMarkdownLayout(contentLayout),
+		),
+		mainBranchName,
+		application.WithContentLayout(contentLayout),
+		application.WithImageQuota(diskUsage),
+		application.WithSyncJobs(syncJobRepo),
+		application.WithDeadLetters(deadLetterRepo),
+		application.WithRedirects(persistence.NewRedirectRepository(dbClient.DB())),
+		application.WithTaskRetries(application.NewTaskRetryConfig()),
+		application.WithSyncWorkers(syncConfig),
+		application.WithSyncOverlap(syncConfig),
+		application.WithTimestampSource(syncConfig),
+		application.WithProcessedCommits(persistence.NewProcessedCommitRepository(dbClient.DB())),
+		application.WithWebhookDeliveries(persistence.NewWebhookDeliveryRepository(dbClient.DB())),
+		application.WithPreviewRetention(previewConfig),
+		application.WithMetadata(application.NewMetadataConfig()),
+		application.WithFeed(application.NewFeedConfig()),
+		application.WithArchiveTimezone(location),
+		application.WithContentSigning(signingKey),
+		application.WithPublishing(publishingConfig),
+		application.WithPullRequestPreviews(
+			sourcegithub.NewPullRequestCommenter(githubClient, repoOwner, repoName),
+			application.NewPullRequestPreviewConfig(),
+		),
+		application.WithPullRequestChecks(sourcegithub.NewCheckReporter(githubClient, repoOwner, repoName)),
+		application.WithPostDiagnostics(persistence.NewPostDiagnosticRepository(dbClient.DB())),
+		application.WithShadowContent(persistence.NewShadowPostRepository(dbClient.DB(), persistence.WithBlobStore(postBlobs), persistence.WithHTMLStorage(htmlStorage))),
+		application.WithPostCache(application.NewPostCacheConfig()),
+		application.WithPendingRenders(persistence.NewPendingRenderRepository(dbClient.DB())),
+		application.WithPostViews(persistence.NewPostViewRepository(dbClient.DB()), application.NewPostViewConfig()),
+		application.WithWebmentions(persistence.NewWebmentionRepository(dbClient.DB()), application.NewWebmentionConfig()),
+		application.WithFederation(persistence.NewFederationRepository(dbClient.DB()), federationConfig, federationKey),
+		application.WithNewsletter(persistence.NewNewsletterRepository(dbClient.DB()), newsletterMailer, newsletterConfig),
+		application.WithNotifications(notifier, application.NewNotificationConfig()),
+	)
+	defer postService.Close()
+	postService.StartPublishScheduler(publishCheckInterval)
+	if _, err := postService.RecoverPendingRenders(); err != nil {
+		log.Error().Err(err).Msg("Failed to recover interrupted renders")
+	}
+
+	readiness := health.New()
+	postService.StartInitialSync(syncConfig.StartupTimeout, readiness.MarkReady)
+
+	// Posts saved before their image references were recorded hold back image collection until rendered again
+	if pending, err := imageRepo.CountPostsWithoutImageRefs(context.Background()); err != nil {
+		log.Error().Err(err).Msg("Failed to count posts without image references")
+	} else if pending > 0 {
+		log.Info().Int("posts", pending).Msg("Rendering every post again to record the images they use")
+		if err := postService.StartResync(); err != nil {
+			log.Error().Err(err).Msg("Failed to start resync")
+		}
+	}
+
+	linkCheckConfig := application.NewLinkCheckConfig()
+	imageGCConfig := application.NewImageGCConfig()
+	imageGC := application.NewImageGarbageCollector(imageRepo, imageGCConfig)
+	readerPreferencesConfig := application.NewReaderPreferencesConfig()
+	readerPreferences := application.NewReaderPreferencesService(persistence.NewReaderPreferencesRepository(dbClient.DB()), readerPreferencesConfig)
+
+	jobs := scheduler.New()
+	defer jobs.Close()
+	jobs.Every("image-gc", imageGCConfig.Interval, imageGC.Run)
+	jobs.Every("disk-usage", diskQuotaConfig.Interval, diskUsage.Refresh)
+	jobs.Every("preview-cleanup", previewConfig.Interval, postService.CleanupPreviews)
+	jobs.Every("link-check", linkCheckConfig.Interval, postService.CheckLinks)
+	jobs.Every("reader-preferences-cleanup", readerPreferencesConfig.Interval, readerPreferences.Cleanup)
+	if digestConfig := application.NewOwnerDigestConfig(); digestConfig.To != "" {
+		mailer, err := mail.NewSMTPSender(mail.NewConfig())
+		if err != nil {
+			log.Fatal().Err(err).Msg("DIGEST_EMAIL needs SMTP_HOST and SMTP_FROM to send through")
+		}
+		jobs.Every("owner-digest", digestConfig.Period(), application.NewOwnerDigest(deadLetterRepo, mailer, digestConfig).Run)
+	}
+
+	themeConfig := theme.NewThemeConfig()
+	themeConfig.Location = location
+	blogTheme, err := theme.Load(themeConfig)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load theme")
+	}
+
+	r := newRouter()
+	r.Use(cors.Middleware(cors.NewConfig(), "/api/"))
+	readiness.RegisterRoutes(r)
+	webhookhttp.NewWebhookHandler(postService, webhookhttp.NewFilterConfig(repoOwner+"/"+repoName)).RegisterRoutes(r)
+	bloghttp.NewPostHandler(postService, blogTheme).RegisterRoutes(r)
+	bloghttp.NewImageHandler(postService).RegisterRoutes(r)
+	bloghttp.NewWebmentionHandler(postService).RegisterRoutes(r)
+	bloghttp.NewFederationHandler(postService).RegisterRoutes(r)
+	bloghttp.NewNewsletterHandler(postService).RegisterRoutes(r)
+	bloghttp.NewAdminHandler(postService, imageGC, diskUsage, authService, webhooks).RegisterRoutes(r)
+	bloghttp.NewSyncJobHandler(postService, authService).RegisterRoutes(r)
+	bloghttp.NewReaderPreferencesHandler(readerPreferences).RegisterRoutes(r)
+	// Counters describe traffic and sync activity that isn't public, so scrapers authenticate like the API
+	r.With(bloghttp.RequireScope(authService, domain.ScopeRead)).Handle("/metrics", promhttp.Handler())
+
+	srv := &http.Server{
+		Addr:    fmt.Sprintf(":%d", port),
+		Handler: r,
+	}
+
+	go func() {
+		log.Info().Msg("Starting server on port :" + fmt.Sprint(port))
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal().Err(err).Msg("Failed to start server")
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	<-quit
+
+	log.Info().Msg("Shutting down server...")
+	postService.BeginShutdown()
+	readiness.MarkNotReady()
+
+	// Keep serving while load balancers notice, so webhooks are refused with a Retry-After rather than a closed connection
+	// A second signal stops waiting.
+	drainPeriod := health.NewShutdownConfig().DrainPeriod
+	log.Info().Dur("drainPeriod", drainPeriod).Msg("Draining requests before stopping")
+	select {
+	case <-time.After(drainPeriod):
+	case <-quit:
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Fatal().Err(err).Msg("Failed to shutdown server")
+	}
+
+	log.Info().Msg("Server stopped")
+	return nil
+}
+
+// configureLogging writes human readable logs unless LOG_FORMAT=json asks for one JSON object per line
+// Code logging through a context without a request logger falls back to the global logger.
+func configureLogging() {
+	zerolog.DefaultContextLogger = &log.Logger
+	if os.Getenv("LOG_FORMAT") == "json" {
+		return
+	}
+
+	log.Logger = log.Output(zerolog.ConsoleWriter{
+		Out:        os.Stdout,
+		TimeFormat: time.RFC3339Nano,
+	})
+}
+
+// newRouter creates the chi router with request IDs, access logging and panic recovery
+// Recovery runs inside the logger so a panicking request is still logged, as a 500.
+func newRouter() *chi.Mux {
+	r := chi.NewRouter()
+	r.Use(middleware.RealIP)
+	r.Use(httplog.Middleware)
+	r.Use(middleware.Recoverer)
+	return r
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"flag"
+	"fmt"
+
+	"github.com/dfryer1193/goblog/blog/application"
+	"github.com/dfryer1193/goblog/blog/persistence"
+	"github.com/dfryer1193/goblog/shared/blob"
+	"github.com/dfryer1193/goblog/shared/db/sqlite"
+)
+
+// verify checks the stored HTML of every post against its signature
+func verify(args []string) error {
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	publicKey := flags.String("public-key", "", "base64 ed25519 public key (default: derived from CONTENT_SIGNING_KEY)")
+	flags.Parse(args)
+
+	var key ed25519.PublicKey
+	if *publicKey != "" {
+		parsed, err := application.ParsePublicKey(*publicKey)
+		if err != nil {
+			return err
+		}
+		key = parsed
+	} else {
+		privateKey, err := application.NewSigningConfig().PrivateKey()
+		if err != nil {
+			return err
+		}
+		if privateKey == nil {
+			return fmt.Errorf("-public-key or CONTENT_SIGNING_KEY is required")
+		}
+		key = privateKey.Public().(ed25519.PublicKey)
+	}
+
+	dbClient := sqlite.NewSQLiteDB(sqlite.NewSQLiteConfig())
+	if err := dbClient.Connect(); err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer dbClient.Close()
+
+	postBlobs, err := blob.New(blob.NewConfig(), "posts")
+	if err != nil {
+		return fmt.Errorf("failed to open post storage: %w", err)
+	}
+
+	checks, err := application.VerifyPostSignatures(context.Background(), persistence.NewPostRepository(dbClient.DB(), persistence.WithBlobStore(postBlobs), persistence.WithHTMLStorage(persistence.NewHTMLStorageConfig())), key)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, check := range checks {
+		if check.Err != nil {
+			failed++
+			fmt.Printf("%s: %v\n", check.PostID, check.Err)
+		}
+	}
+
+	fmt.Printf("%d of %d posts verified\n", len(checks)-failed, len(checks))
+	if failed > 0 {
+		return fmt.Errorf("%d posts failed verification", failed)
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package activitypub
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+const (
+	// ContentType is the media type activities are sent and served as
+	ContentType = "application/activity+json"
+	// LDContentType is the JSON-LD media type some servers request activities as instead
+	LDContentType = `application/ld+json; profile="https://www.w3.org/ns/activitystreams"`
+	// JRDContentType is the media type of WebFinger responses
+	JRDContentType = "application/jrd+json"
+
+	// Public addresses an object to everyone, rather than only to followers
+	Public = "https://www.w3.org/ns/activitystreams#Public"
+
+	activityStreamsContext = "https://www.w3.org/ns/activitystreams"
+	securityContext        = "https://w3id.org/security/v1"
+)
+
+// ActorContext is the JSON-LD context of an actor document, which needs the security vocabulary for its key
+var ActorContext = []string{activityStreamsContext, securityContext}
+
+// Context is the JSON-LD context of activities, objects and collections
+const Context = activityStreamsContext
+
+// Actor is an account that sends and receives activities
+type Actor struct {
+	Context           any        `json:"@context,omitempty"`
+	ID                string     `json:"id"`
+	Type              string     `json:"type"`
+	PreferredUsername string     `json:"preferredUsername,omitempty"`
+	Name              string     `json:"name,omitempty"`
+	Summary           string     `json:"summary,omitempty"`
+	URL               string     `json:"url,omitempty"`
+	Inbox             string     `json:"inbox"`
+	Outbox            string     `json:"outbox,omitempty"`
+	Followers         string     `json:"followers,omitempty"`
+	Endpoints         *Endpoints `json:"endpoints,omitempty"`
+	PublicKey         *PublicKey `json:"publicKey,omitempty"`
+}
+
+type Endpoints struct {
+	// SharedInbox receives activities for every actor on a server
+	SharedInbox string `json:"sharedInbox,omitempty"`
+}
+
+// PublicKey is the key an actor's requests are signed with
+type PublicKey struct {
+	ID           string `json:"id"`
+	Owner        string `json:"owner"`
+	PublicKeyPEM string `json:"publicKeyPem"`
+}
+
+// Activity is something an actor did, such as creating a note or following another actor
+// Object is the object itself or its ID; received activities leave it as raw JSON for the receiver to decode.
+type Activity struct {
+	Context   any      `json:"@context,omitempty"`
+	ID        string   `json:"id"`
+	Type      string   `json:"type"`
+	Actor     string   `json:"actor"`
+	Published string   `json:"published,omitempty"`
+	To        []string `json:"to,omitempty"`
+	CC        []string `json:"cc,omitempty"`
+	Object    any      `json:"object"`
+}
+
+// ReceivedActivity is an activity as it arrives in an inbox
+type ReceivedActivity struct {
+	ID     string          `json:"id"`
+	Type   string          `json:"type"`
+	Actor  string          `json:"actor"`
+	Object json.RawMessage `json:"object"`
+}
+
+// ObjectID returns the ID of the activity's object, whether it was sent as an ID or embedded
+func (a *ReceivedActivity) ObjectID() string {
+	var id string
+	if err := json.Unmarshal(a.Object, &id); err == nil {
+		return id
+	}
+	var object struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(a.Object, &object); err == nil {
+		return object.ID
+	}
+	return ""
+}
+
+// Note is a short post, which is how most servers show a blog post to their users
+type Note struct {
+	Context      any      `json:"@context,omitempty"`
+	ID           string   `json:"id"`
+	Type         string   `json:"type"`
+	AttributedTo string   `json:"attributedTo"`
+	Content      string   `json:"content"`
+	URL          string   `json:"url,omitempty"`
+	Published    string   `json:"published,omitempty"`
+	Updated      string   `json:"updated,omitempty"`
+	To           []string `json:"to,omitempty"`
+	CC           []string `json:"cc,omitempty"`
+}
+
+// Tombstone replaces an object that was deleted
+type Tombstone struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
+
+// OrderedCollection is a list of items, such as an outbox, newest first
+type OrderedCollection struct {
+	Context      any    `json:"@context,omitempty"`
+	ID           string `json:"id"`
+	Type         string `json:"type"`
+	TotalItems   int    `json:"totalItems"`
+	OrderedItems []any  `json:"orderedItems,omitempty"`
+}
+
+// WebFinger is the JSON resource descriptor that resolves an acct: address to an actor
+type WebFinger struct {
+	Subject string          `json:"subject"`
+	Aliases []string        `json:"aliases,omitempty"`
+	Links   []WebFingerLink `json:"links"`
+}
+
+type WebFingerLink struct {
+	Rel  string `json:"rel"`
+	Type string `json:"type,omitempty"`
+	Href string `json:"href"`
+}
+
+// ParsePrivateKey decodes a PEM RSA private key in PKCS #1 or PKCS #8 form
+func ParsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key is a %T, not an RSA key", parsed)
+	}
+	return key, nil
+}
+
+// PublicKeyPEM encodes a public key the way actor documents carry it
+func PublicKeyPEM(key *rsa.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode public key: %w", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), nil
+}
+
+// ParsePublicKey decodes the PEM RSA public key of an actor document
+func ParsePublicKey(data string) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key is a %T, not an RSA key", parsed)
+	}
+	return key, nil
+}
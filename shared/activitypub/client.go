@@ -0,0 +1,127 @@
+package activitypub
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxActorBytes bounds an actor document, which is a few kilobytes even with a long profile
+const maxActorBytes = 1 << 20
+
+// ErrRejected is returned when a server refuses a delivery outright, so sending it again won't help
+var ErrRejected = errors.New("activity rejected")
+
+// Client signs and sends requests to other servers as one actor
+type Client struct {
+	http      *http.Client
+	keyID     string
+	key       *rsa.PrivateKey
+	userAgent string
+}
+
+// NewClient sends requests through httpClient, signed with key, which keyID names in the actor's document
+func NewClient(httpClient *http.Client, keyID string, key *rsa.PrivateKey, userAgent string) *Client {
+	return &Client{
+		http:      httpClient,
+		keyID:     keyID,
+		key:       key,
+		userAgent: userAgent,
+	}
+}
+
+// Deliver posts activity to an inbox
+// A 4xx response other than 429 returns ErrRejected; other failures may succeed if retried.
+func (c *Client) Deliver(ctx context.Context, inbox string, activity any) error {
+	body, err := json.Marshal(activity)
+	if err != nil {
+		return fmt.Errorf("failed to encode activity: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, inbox, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", ContentType)
+	if err := c.sign(req, body); err != nil {
+		return err
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to deliver to %s: %w", inbox, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxActorBytes))
+
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+		return fmt.Errorf("%w: %s responded %s", ErrRejected, inbox, resp.Status)
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s responded %s", inbox, resp.Status)
+	}
+	return nil
+}
+
+// FetchActor fetches the document of the actor with id
+// The request is signed, since servers in secure mode only answer signed requests.
+func (c *Client) FetchActor(ctx context.Context, id string) (*Actor, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, id, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", ContentType+", "+LDContentType)
+	if err := c.sign(req, nil); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch actor %s: %w", id, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch actor %s: %s", id, resp.Status)
+	}
+
+	var actor Actor
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxActorBytes)).Decode(&actor); err != nil {
+		return nil, fmt.Errorf("failed to decode actor %s: %w", id, err)
+	}
+	if actor.ID != id || actor.Inbox == "" {
+		return nil, fmt.Errorf("%s is not an actor", id)
+	}
+	return &actor, nil
+}
+
+// FetchKey returns the public key with keyID, from the document of the actor that owns it
+func (c *Client) FetchKey(ctx context.Context, keyID string) (*Actor, *rsa.PublicKey, error) {
+	actorID, _, _ := strings.Cut(keyID, "#")
+	actor, err := c.FetchActor(ctx, actorID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if actor.PublicKey == nil || actor.PublicKey.ID != keyID || actor.PublicKey.Owner != actor.ID {
+		return nil, nil, fmt.Errorf("%w: %s does not have key %s", ErrInvalidSignature, actor.ID, keyID)
+	}
+
+	key, err := ParsePublicKey(actor.PublicKey.PublicKeyPEM)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	return actor, key, nil
+}
+
+func (c *Client) sign(req *http.Request, body []byte) error {
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	return SignRequest(req, c.keyID, c.key, body, time.Now())
+}
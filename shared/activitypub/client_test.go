@@ -0,0 +1,108 @@
+package activitypub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClient_Deliver(t *testing.T) {
+	key := newTestKey(t)
+	var received ReceivedActivity
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		sig, err := ParseSignature(r)
+		if err == nil {
+			err = sig.Verify(r, body, &key.PublicKey, time.Now())
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("Content-Type") != ContentType {
+			http.Error(w, "wrong content type", http.StatusUnsupportedMediaType)
+			return
+		}
+		json.Unmarshal(body, &received)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.Client(), "https://blog.example/federation/actor#main-key", key, "goblog")
+	activity := &Activity{ID: "https://blog.example/1#create", Type: "Create", Actor: "https://blog.example/federation/actor", Object: "https://blog.example/1"}
+	if err := client.Deliver(context.Background(), server.URL+"/inbox", activity); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+	if received.Type != "Create" || received.ObjectID() != "https://blog.example/1" {
+		t.Errorf("Inbox received %+v, want the Create activity", received)
+	}
+}
+
+func TestClient_DeliverRejected(t *testing.T) {
+	tests := []struct {
+		status   int
+		rejected bool
+	}{
+		{http.StatusForbidden, true},
+		{http.StatusGone, true},
+		{http.StatusTooManyRequests, false},
+		{http.StatusBadGateway, false},
+	}
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+		}))
+
+		client := NewClient(server.Client(), "https://blog.example/federation/actor#main-key", newTestKey(t), "")
+		err := client.Deliver(context.Background(), server.URL+"/inbox", &Activity{Type: "Create"})
+		if err == nil || errors.Is(err, ErrRejected) != tt.rejected {
+			t.Errorf("Deliver() to an inbox responding %d error = %v, want rejected %v", tt.status, err, tt.rejected)
+		}
+		server.Close()
+	}
+}
+
+func TestClient_FetchKey(t *testing.T) {
+	key := newTestKey(t)
+	keyPEM, err := PublicKeyPEM(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("PublicKeyPEM() error = %v", err)
+	}
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actorID := server.URL + r.URL.Path
+		owner := actorID
+		if r.URL.Path == "/users/impostor" {
+			owner = server.URL + "/users/alex"
+		}
+		json.NewEncoder(w).Encode(&Actor{
+			ID:        actorID,
+			Type:      "Person",
+			Inbox:     actorID + "/inbox",
+			PublicKey: &PublicKey{ID: actorID + "#main-key", Owner: owner, PublicKeyPEM: keyPEM},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.Client(), "https://blog.example/federation/actor#main-key", key, "")
+	actor, fetched, err := client.FetchKey(context.Background(), server.URL+"/users/alex#main-key")
+	if err != nil {
+		t.Fatalf("FetchKey() error = %v", err)
+	}
+	if actor.Inbox != server.URL+"/users/alex/inbox" || !fetched.Equal(&key.PublicKey) {
+		t.Errorf("FetchKey() = %+v, want alex's actor and key", actor)
+	}
+
+	if _, _, err := client.FetchKey(context.Background(), server.URL+"/users/alex#other-key"); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("FetchKey() of a key the actor doesn't have error = %v, want ErrInvalidSignature", err)
+	}
+	if _, _, err := client.FetchKey(context.Background(), server.URL+"/users/impostor#main-key"); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("FetchKey() of a key owned by another actor error = %v, want ErrInvalidSignature", err)
+	}
+}
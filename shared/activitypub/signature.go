@@ -0,0 +1,144 @@
+package activitypub
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// maxClockSkew is how far the Date of a signed request may be from now
+const maxClockSkew = time.Hour
+
+// ErrInvalidSignature is returned for a request whose HTTP signature is missing or doesn't verify
+var ErrInvalidSignature = errors.New("invalid http signature")
+
+// Signature is the parsed Signature header of a request
+type Signature struct {
+	// KeyID is the ID of the key the request was signed with, usually the signer's actor ID with a fragment
+	KeyID   string
+	Headers []string
+	value   []byte
+}
+
+// SignRequest signs req with the draft-cavage HTTP Signatures scheme that Mastodon and most other servers expect
+// The signature covers the request target, host and date, and the digest of body for requests that have one.
+func SignRequest(req *http.Request, keyID string, key *rsa.PrivateKey, body []byte, now time.Time) error {
+	req.Header.Set("Date", now.UTC().Format(http.TimeFormat))
+	headers := []string{"(request-target)", "host", "date"}
+	if body != nil {
+		req.Header.Set("Digest", bodyDigest(body))
+		headers = append(headers, "digest")
+	}
+
+	hashed := sha256.Sum256([]byte(signingString(req, headers)))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hashed[:])
+	if err != nil {
+		return fmt.Errorf("failed to sign request: %w", err)
+	}
+
+	req.Header.Set("Signature", fmt.Sprintf(`keyId="%s",algorithm="rsa-sha256",headers="%s",signature="%s"`,
+		keyID, strings.Join(headers, " "), base64.StdEncoding.EncodeToString(sig)))
+	return nil
+}
+
+// ParseSignature reads the Signature header of req, so the key it names can be looked up
+func ParseSignature(req *http.Request) (*Signature, error) {
+	header := req.Header.Get("Signature")
+	if header == "" {
+		return nil, fmt.Errorf("%w: no Signature header", ErrInvalidSignature)
+	}
+
+	params := make(map[string]string)
+	for _, param := range strings.Split(header, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if !ok {
+			continue
+		}
+		params[name] = strings.Trim(value, `"`)
+	}
+
+	if params["keyId"] == "" || params["signature"] == "" {
+		return nil, fmt.Errorf("%w: no keyId or signature", ErrInvalidSignature)
+	}
+	if algorithm := params["algorithm"]; algorithm != "" && algorithm != "rsa-sha256" && algorithm != "hs2019" {
+		return nil, fmt.Errorf("%w: unsupported algorithm %s", ErrInvalidSignature, algorithm)
+	}
+	value, err := base64.StdEncoding.DecodeString(params["signature"])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature is not base64", ErrInvalidSignature)
+	}
+	headers := []string{"date"}
+	if params["headers"] != "" {
+		headers = strings.Fields(strings.ToLower(params["headers"]))
+	}
+
+	return &Signature{KeyID: params["keyId"], Headers: headers, value: value}, nil
+}
+
+// Verify checks the signature of req against key
+// The signature must cover the request target, host and date, and the digest of body when there is one. The
+// digest must match body, and the date must be within an hour of now, so a captured request can't be replayed later.
+func (s *Signature) Verify(req *http.Request, body []byte, key *rsa.PublicKey, now time.Time) error {
+	required := []string{"(request-target)", "host", "date"}
+	if len(body) > 0 {
+		required = append(required, "digest")
+	}
+	for _, header := range required {
+		if !slices.Contains(s.Headers, header) {
+			return fmt.Errorf("%w: %s is not signed", ErrInvalidSignature, header)
+		}
+	}
+
+	date, err := http.ParseTime(req.Header.Get("Date"))
+	if err != nil {
+		return fmt.Errorf("%w: invalid Date", ErrInvalidSignature)
+	}
+	if skew := now.Sub(date); skew > maxClockSkew || skew < -maxClockSkew {
+		return fmt.Errorf("%w: Date %s is too far from now", ErrInvalidSignature, req.Header.Get("Date"))
+	}
+	if len(body) > 0 && subtle.ConstantTimeCompare([]byte(req.Header.Get("Digest")), []byte(bodyDigest(body))) != 1 {
+		return fmt.Errorf("%w: Digest does not match the body", ErrInvalidSignature)
+	}
+
+	hashed := sha256.Sum256([]byte(signingString(req, s.Headers)))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, hashed[:], s.value); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	return nil
+}
+
+// signingString is the text a signature over headers signs
+func signingString(req *http.Request, headers []string) string {
+	lines := make([]string, 0, len(headers))
+	for _, header := range headers {
+		var value string
+		switch header {
+		case "(request-target)":
+			value = strings.ToLower(req.Method) + " " + req.URL.RequestURI()
+		case "host":
+			value = req.Host
+			if value == "" {
+				value = req.URL.Host
+			}
+		default:
+			value = strings.Join(req.Header.Values(header), ", ")
+		}
+		lines = append(lines, header+": "+value)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// bodyDigest is the Digest header of a request with body
+func bodyDigest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return "SHA-256=" + base64.StdEncoding.EncodeToString(sum[:])
+}
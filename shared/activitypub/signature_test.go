@@ -0,0 +1,141 @@
+package activitypub
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var (
+	testKeyOnce sync.Once
+	testKey     *rsa.PrivateKey
+)
+
+// newTestKey returns a key shared by the package's tests, since generating one takes a while
+func newTestKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	testKeyOnce.Do(func() {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatalf("Failed to generate key: %v", err)
+		}
+		testKey = key
+	})
+	return testKey
+}
+
+func TestSignRequest_Verifies(t *testing.T) {
+	key := newTestKey(t)
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	body := []byte(`{"type":"Follow"}`)
+
+	req := httptest.NewRequest(http.MethodPost, "https://blog.example/federation/inbox", strings.NewReader(string(body)))
+	if err := SignRequest(req, "https://a.example/users/alex#main-key", key, body, now); err != nil {
+		t.Fatalf("SignRequest() error = %v", err)
+	}
+
+	sig, err := ParseSignature(req)
+	if err != nil {
+		t.Fatalf("ParseSignature() error = %v", err)
+	}
+	if sig.KeyID != "https://a.example/users/alex#main-key" {
+		t.Errorf("KeyID = %q, want the signing key", sig.KeyID)
+	}
+	if err := sig.Verify(req, body, &key.PublicKey, now.Add(time.Minute)); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+}
+
+func TestSignature_VerifyRejects(t *testing.T) {
+	key := newTestKey(t)
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	body := []byte(`{"type":"Follow"}`)
+
+	tests := []struct {
+		name   string
+		change func(req *http.Request) ([]byte, *rsa.PublicKey, time.Time)
+	}{
+		{"another key", func(req *http.Request) ([]byte, *rsa.PublicKey, time.Time) {
+			return body, &other.PublicKey, now
+		}},
+		{"changed body", func(req *http.Request) ([]byte, *rsa.PublicKey, time.Time) {
+			return []byte(`{"type":"Undo"}`), &key.PublicKey, now
+		}},
+		{"changed path", func(req *http.Request) ([]byte, *rsa.PublicKey, time.Time) {
+			req.URL.Path = "/other/inbox"
+			return body, &key.PublicKey, now
+		}},
+		{"changed date", func(req *http.Request) ([]byte, *rsa.PublicKey, time.Time) {
+			req.Header.Set("Date", now.Add(time.Second).Format(http.TimeFormat))
+			return body, &key.PublicKey, now
+		}},
+		{"replayed later", func(req *http.Request) ([]byte, *rsa.PublicKey, time.Time) {
+			return body, &key.PublicKey, now.Add(2 * time.Hour)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "https://blog.example/federation/inbox", nil)
+			if err := SignRequest(req, "https://a.example/users/alex#main-key", key, body, now); err != nil {
+				t.Fatalf("SignRequest() error = %v", err)
+			}
+			sig, err := ParseSignature(req)
+			if err != nil {
+				t.Fatalf("ParseSignature() error = %v", err)
+			}
+
+			body, key, at := tt.change(req)
+			if err := sig.Verify(req, body, key, at); !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("Verify() error = %v, want ErrInvalidSignature", err)
+			}
+		})
+	}
+}
+
+func TestSignature_VerifyRequiresSignedHeaders(t *testing.T) {
+	key := newTestKey(t)
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	// A GET is signed without a digest, which a request with a body can't be verified by
+	req := httptest.NewRequest(http.MethodPost, "https://blog.example/federation/inbox", nil)
+	if err := SignRequest(req, "https://a.example/users/alex#main-key", key, nil, now); err != nil {
+		t.Fatalf("SignRequest() error = %v", err)
+	}
+	sig, err := ParseSignature(req)
+	if err != nil {
+		t.Fatalf("ParseSignature() error = %v", err)
+	}
+	if err := sig.Verify(req, []byte(`{}`), &key.PublicKey, now); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify() error = %v, want ErrInvalidSignature for an unsigned digest", err)
+	}
+
+	if _, err := ParseSignature(httptest.NewRequest(http.MethodPost, "/", nil)); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("ParseSignature() error = %v, want ErrInvalidSignature without a Signature header", err)
+	}
+}
+
+func TestPublicKeyPEM_RoundTrips(t *testing.T) {
+	key := newTestKey(t)
+
+	encoded, err := PublicKeyPEM(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("PublicKeyPEM() error = %v", err)
+	}
+	decoded, err := ParsePublicKey(encoded)
+	if err != nil {
+		t.Fatalf("ParsePublicKey() error = %v", err)
+	}
+	if !decoded.Equal(&key.PublicKey) {
+		t.Error("Expected the decoded key to equal the encoded one")
+	}
+}
@@ -0,0 +1,253 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// DatabaseName is the name of the database snapshot in an archive
+const DatabaseName = "goblog.db"
+
+const (
+	// stagingSuffix names the copy a restore unpacks beside each destination
+	stagingSuffix = ".restore"
+	// replacedSuffix names a directory a restore has moved aside while replacing it
+	replacedSuffix = ".old"
+)
+
+// Write writes a gzipped tar archive of the database file at dbPath and the files under each of dirs
+// dirs maps a top-level name in the archive, such as "posts", to a local directory; a directory that does
+// not exist is left out. dbPath must be a snapshot no one is writing to, such as one made by sqlite's Backup.
+func Write(w io.Writer, dbPath string, dirs map[string]string) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	if err := addFile(tw, DatabaseName, dbPath); err != nil {
+		return err
+	}
+	for _, name := range slices.Sorted(maps.Keys(dirs)) {
+		if err := addDir(tw, name, dirs[name]); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to finish archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to finish archive: %w", err)
+	}
+	return nil
+}
+
+// addDir adds dir and the regular files under it, skipping the temporary files of unfinished blob writes
+func addDir(tw *tar.Writer, name string, dir string) error {
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		entry := path.Join(name, filepath.ToSlash(rel))
+
+		switch {
+		case d.IsDir():
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			header, err := tar.FileInfoHeader(info, "")
+			if err != nil {
+				return err
+			}
+			header.Name = entry + "/"
+			return tw.WriteHeader(header)
+		case !d.Type().IsRegular(), strings.HasPrefix(d.Name(), ".tmp-"):
+			return nil
+		default:
+			err := addFile(tw, entry, p)
+			// A file removed since the directory was listed is simply no longer part of the backup
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to archive %s: %w", dir, err)
+	}
+	return nil
+}
+
+// addFile adds the file at p under name
+// The header is taken from the open file, so a file replaced since it was listed is archived whole.
+func addFile(tw *tar.Writer, name string, p string) error {
+	f, err := os.Open(p)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", p, err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", p, err)
+	}
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return fmt.Errorf("failed to archive %s: %w", p, err)
+	}
+	header.Name = name
+
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to archive %s: %w", p, err)
+	}
+	if _, err := io.CopyN(tw, f, info.Size()); err != nil {
+		return fmt.Errorf("failed to archive %s: %w", p, err)
+	}
+	return nil
+}
+
+// Restore unpacks an archive written by Write, replacing the database at dbPath and each of dirs
+// Everything is unpacked beside its destination and only moved into place once the whole archive has been
+// read, so a damaged archive leaves the existing files untouched. Directories missing from the archive, and
+// entries for directories not in dirs, are left alone. Nothing may be using the database while it runs.
+func Restore(r io.Reader, dbPath string, dirs map[string]string) error {
+	staged := map[string]string{DatabaseName: dbPath + stagingSuffix}
+	for name, dir := range dirs {
+		staged[name] = filepath.Clean(dir) + stagingSuffix
+	}
+	removeAll := func() {
+		for _, p := range staged {
+			os.RemoveAll(p)
+		}
+	}
+	// Clear anything an earlier restore left behind, and whatever this one leaves
+	removeAll()
+	defer removeAll()
+
+	if err := extract(r, staged); err != nil {
+		return err
+	}
+	if _, err := os.Stat(staged[DatabaseName]); err != nil {
+		return fmt.Errorf("archive has no %s", DatabaseName)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(dirs)) {
+		if err := replaceDir(staged[name], dirs[name]); err != nil {
+			return err
+		}
+	}
+
+	// A write-ahead log left by the old database would be applied to the restored one
+	for _, p := range []string{dbPath + "-wal", dbPath + "-shm"} {
+		if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to remove %s: %w", p, err)
+		}
+	}
+	if err := os.Rename(staged[DatabaseName], dbPath); err != nil {
+		return fmt.Errorf("failed to restore database: %w", err)
+	}
+
+	return nil
+}
+
+// extract unpacks each entry whose top-level name is in staged under the path staged for it
+func extract(r io.Reader, staged map[string]string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("failed to read archive: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive: %w", err)
+		}
+
+		name := strings.TrimSuffix(header.Name, "/")
+		if !fs.ValidPath(name) || name == "." {
+			return fmt.Errorf("invalid archive entry %q", header.Name)
+		}
+		top, rest, _ := strings.Cut(name, "/")
+		base, ok := staged[top]
+		if !ok {
+			continue
+		}
+		dest := filepath.Join(base, filepath.FromSlash(rest))
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(dest, 0755); err != nil {
+				return fmt.Errorf("failed to create %s: %w", dest, err)
+			}
+		case tar.TypeReg:
+			if err := extractFile(tr, dest, header.FileInfo().Mode().Perm()); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported archive entry %q", header.Name)
+		}
+	}
+}
+
+func extractFile(r io.Reader, dest string, mode fs.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(dest), err)
+	}
+
+	f, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", dest, err)
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s: %w", dest, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", dest, err)
+	}
+	return nil
+}
+
+// replaceDir moves the staged directory into place of dir, if the archive had one
+func replaceDir(staged string, dir string) error {
+	if _, err := os.Stat(staged); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	old := filepath.Clean(dir) + replacedSuffix
+	if err := os.RemoveAll(old); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", old, err)
+	}
+	if err := os.Rename(dir, old); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to move %s aside: %w", dir, err)
+	}
+	if err := os.Rename(staged, dir); err != nil {
+		return fmt.Errorf("failed to restore %s: %w", dir, err)
+	}
+	if err := os.RemoveAll(old); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", old, err)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", path, err)
+	}
+	return string(content)
+}
+
+func TestWriteRestore(t *testing.T) {
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "goblog.db"), "database")
+	writeFile(t, filepath.Join(src, "posts", "001.html"), "<p>post</p>")
+	writeFile(t, filepath.Join(src, "posts", ".tmp-123"), "partial")
+	writeFile(t, filepath.Join(src, "images", "a", "b.png"), "png")
+	if err := os.Mkdir(filepath.Join(src, "empty"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	var archive bytes.Buffer
+	err := Write(&archive, filepath.Join(src, "goblog.db"), map[string]string{
+		"posts":   filepath.Join(src, "posts"),
+		"images":  filepath.Join(src, "images"),
+		"empty":   filepath.Join(src, "empty"),
+		"missing": filepath.Join(src, "missing"),
+	})
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	dst := t.TempDir()
+	dbPath := filepath.Join(dst, "goblog.db")
+	writeFile(t, dbPath, "old database")
+	writeFile(t, dbPath+"-wal", "old log")
+	writeFile(t, filepath.Join(dst, "posts", "002.html"), "<p>old post</p>")
+	writeFile(t, filepath.Join(dst, "missing", "kept"), "kept")
+
+	err = Restore(&archive, dbPath, map[string]string{
+		"posts":   filepath.Join(dst, "posts"),
+		"images":  filepath.Join(dst, "images"),
+		"empty":   filepath.Join(dst, "empty"),
+		"missing": filepath.Join(dst, "missing"),
+	})
+	if err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	if got := readFile(t, dbPath); got != "database" {
+		t.Errorf("database = %q, want the archived one", got)
+	}
+	if _, err := os.Stat(dbPath + "-wal"); !os.IsNotExist(err) {
+		t.Error("Expected the old write-ahead log to be removed")
+	}
+	if got := readFile(t, filepath.Join(dst, "posts", "001.html")); got != "<p>post</p>" {
+		t.Errorf("posts/001.html = %q, want the archived post", got)
+	}
+	if got := readFile(t, filepath.Join(dst, "images", "a", "b.png")); got != "png" {
+		t.Errorf("images/a/b.png = %q, want the archived image", got)
+	}
+	for _, path := range []string{"posts/002.html", "posts/.tmp-123", "posts.old", "posts.restore", "goblog.db.restore"} {
+		if _, err := os.Stat(filepath.Join(dst, path)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s not to exist", path)
+		}
+	}
+	if info, err := os.Stat(filepath.Join(dst, "empty")); err != nil || !info.IsDir() {
+		t.Errorf("Expected the empty directory to be restored, got %v", err)
+	}
+	if got := readFile(t, filepath.Join(dst, "missing", "kept")); got != "kept" {
+		t.Errorf("missing/kept = %q, want a directory missing from the archive left alone", got)
+	}
+}
+
+func TestRestore_DamagedArchive(t *testing.T) {
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "goblog.db"), "database")
+	writeFile(t, filepath.Join(src, "posts", "001.html"), "<p>post</p>")
+
+	var archive bytes.Buffer
+	if err := Write(&archive, filepath.Join(src, "goblog.db"), map[string]string{"posts": filepath.Join(src, "posts")}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	truncated := archive.Bytes()[:archive.Len()/2]
+
+	dst := t.TempDir()
+	dbPath := filepath.Join(dst, "goblog.db")
+	writeFile(t, dbPath, "old database")
+	writeFile(t, filepath.Join(dst, "posts", "002.html"), "<p>old post</p>")
+
+	if err := Restore(bytes.NewReader(truncated), dbPath, map[string]string{"posts": filepath.Join(dst, "posts")}); err == nil {
+		t.Fatal("Restore() should return error for a truncated archive")
+	}
+
+	if got := readFile(t, dbPath); got != "old database" {
+		t.Errorf("database = %q, want it untouched", got)
+	}
+	if got := readFile(t, filepath.Join(dst, "posts", "002.html")); got != "<p>old post</p>" {
+		t.Errorf("posts/002.html = %q, want it untouched", got)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "posts.restore")); !os.IsNotExist(err) {
+		t.Error("Expected the staged directory to be removed")
+	}
+}
+
+func TestRestore_RejectsEscapingEntries(t *testing.T) {
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+	content := []byte("escaped")
+	if err := tw.WriteHeader(&tar.Header{Name: "posts/../../escaped", Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	tw.Close()
+	gz.Close()
+
+	dst := t.TempDir()
+	if err := Restore(&archive, filepath.Join(dst, "goblog.db"), map[string]string{"posts": filepath.Join(dst, "posts")}); err == nil {
+		t.Fatal("Restore() should reject an entry outside its directory")
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dst), "escaped")); !os.IsNotExist(err) {
+		t.Error("Expected no file to be written outside the destination")
+	}
+}
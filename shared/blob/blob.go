@@ -0,0 +1,69 @@
+package blob
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ErrNotFound is returned when a requested blob does not exist
+var ErrNotFound = errors.New("blob not found")
+
+// Store keeps rendered artifacts, such as post HTML and images, by key
+// Keys are slash-separated relative paths.
+type Store interface {
+	Put(ctx context.Context, key string, content []byte) error
+	// Get returns ErrNotFound if the key does not exist
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Delete succeeds if the key does not exist
+	Delete(ctx context.Context, key string) error
+	// Size returns the total bytes stored
+	Size(ctx context.Context) (int64, error)
+}
+
+const (
+	BackendLocal = "local"
+	BackendS3    = "s3"
+)
+
+type Config struct {
+	// Backend is BackendLocal or BackendS3
+	Backend string
+	// Dir is the directory local stores are created under
+	Dir string
+	S3  S3Config
+}
+
+func NewConfig() *Config {
+	return &Config{
+		Backend: cmp.Or(os.Getenv("BLOB_STORE"), BackendLocal),
+		Dir:     cmp.Or(os.Getenv("BLOB_DIR"), "."),
+		S3: S3Config{
+			Endpoint:        cmp.Or(os.Getenv("S3_ENDPOINT"), defaultS3Endpoint),
+			Region:          cmp.Or(os.Getenv("S3_REGION"), defaultS3Region),
+			Bucket:          os.Getenv("S3_BUCKET"),
+			Prefix:          os.Getenv("S3_PREFIX"),
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("S3_SESSION_TOKEN"),
+		},
+	}
+}
+
+// New creates the store for one kind of artifact, e.g. "posts" or "images"
+// Local stores use the directory name under Dir; S3 stores use it as a key prefix.
+func New(cfg *Config, name string) (Store, error) {
+	switch cfg.Backend {
+	case BackendLocal:
+		return NewLocalStore(filepath.Join(cfg.Dir, name)), nil
+	case BackendS3:
+		s3 := cfg.S3
+		s3.Prefix = s3.Prefix + name + "/"
+		return NewS3Store(&s3)
+	default:
+		return nil, fmt.Errorf("unknown blob store %q", cfg.Backend)
+	}
+}
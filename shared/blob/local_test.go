@@ -0,0 +1,73 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDirSize(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "nested"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a.html"), make([]byte, 10), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "nested", "b.png"), make([]byte, 25), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	size, err := dirSize(dir)
+	if err != nil {
+		t.Fatalf("dirSize() error = %v", err)
+	}
+	if size != 35 {
+		t.Errorf("dirSize() = %d, want 35", size)
+	}
+}
+
+func TestDirSize_MissingDir(t *testing.T) {
+	size, err := dirSize(filepath.Join(t.TempDir(), "missing"))
+	if err != nil {
+		t.Fatalf("dirSize() error = %v", err)
+	}
+	if size != 0 {
+		t.Errorf("dirSize() = %d, want 0", size)
+	}
+}
+
+func TestLocalStore_RoundTrip(t *testing.T) {
+	store := NewLocalStore(filepath.Join(t.TempDir(), "posts"))
+	ctx := context.Background()
+
+	if _, err := store.Get(ctx, "001.html"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get of a missing blob error = %v, want ErrNotFound", err)
+	}
+
+	if err := store.Put(ctx, "001.html", []byte("<p>hello</p>")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	content, err := store.Get(ctx, "001.html")
+	if err != nil || string(content) != "<p>hello</p>" {
+		t.Errorf("Get = %q, %v", content, err)
+	}
+
+	if err := store.Delete(ctx, "001.html"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := store.Delete(ctx, "001.html"); err != nil {
+		t.Errorf("Delete of a missing blob failed: %v", err)
+	}
+}
+
+func TestLocalStore_RejectsEscapingKeys(t *testing.T) {
+	store := NewLocalStore(t.TempDir())
+	for _, key := range []string{"../secret", "/etc/passwd", "", "."} {
+		if err := store.Put(context.Background(), key, nil); err == nil {
+			t.Errorf("Put(%q) succeeded, want an error", key)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	defaultS3Endpoint = "https://s3.amazonaws.com"
+	defaultS3Region   = "us-east-1"
+
+	// maxS3ErrorBody bounds how much of an error response is read into the returned error
+	maxS3ErrorBody = 1024
+)
+
+type S3Config struct {
+	// Endpoint is the S3 API base URL, e.g. a MinIO server
+	Endpoint string
+	Region   string
+	Bucket   string
+	// Prefix is prepended to every key
+	Prefix          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken is only needed for temporary credentials
+	SessionToken string
+}
+
+// S3Store is a Store backed by an S3 compatible bucket
+// Requests use path-style URLs and Signature Version 4, so MinIO works as well as AWS.
+type S3Store struct {
+	cfg      *S3Config
+	endpoint *url.URL
+	client   *http.Client
+	now      func() time.Time
+}
+
+var _ Store = (*S3Store)(nil)
+
+func NewS3Store(cfg *S3Config) (*S3Store, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("S3 bucket is not set")
+	}
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, fmt.Errorf("S3 credentials are not set")
+	}
+
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", cfg.Endpoint)
+	}
+
+	return &S3Store{
+		cfg:      cfg,
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: time.Minute},
+		now:      time.Now,
+	}, nil
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, content []byte) error {
+	resp, err := s.do(ctx, http.MethodPut, s.cfg.Prefix+key, nil, content)
+	if err != nil {
+		return fmt.Errorf("failed to put %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to put %s: %w", key, s3Error(resp))
+	}
+	return nil
+}
+
+func (s *S3Store) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := s.do(ctx, http.MethodGet, s.cfg.Prefix+key, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get %s: %w", key, s3Error(resp))
+	}
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	return content, nil
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, s.cfg.Prefix+key, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+
+	// S3 responds 204 whether or not the object existed
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to delete %s: %w", key, s3Error(resp))
+	}
+	return nil
+}
+
+// listObjectsResult is the part of a ListObjectsV2 response needed to total object sizes
+type listObjectsResult struct {
+	Contents []struct {
+		Size int64 `xml:"Size"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// Size totals the objects under the store's prefix, which takes one request per 1000 objects
+func (s *S3Store) Size(ctx context.Context) (int64, error) {
+	var total int64
+	query := url.Values{"list-type": {"2"}, "prefix": {s.cfg.Prefix}}
+	for {
+		resp, err := s.do(ctx, http.MethodGet, "", query, nil)
+		if err != nil {
+			return 0, fmt.Errorf("failed to list objects: %w", err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			err := s3Error(resp)
+			resp.Body.Close()
+			return 0, fmt.Errorf("failed to list objects: %w", err)
+		}
+
+		var result listObjectsResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return 0, fmt.Errorf("failed to decode object listing: %w", err)
+		}
+
+		for _, obj := range result.Contents {
+			total += obj.Size
+		}
+
+		if !result.IsTruncated {
+			return total, nil
+		}
+		query.Set("continuation-token", result.NextContinuationToken)
+	}
+}
+
+// do sends a signed request for an object key, or for the bucket itself when key is empty
+func (s *S3Store) do(ctx context.Context, method string, key string, query url.Values, body []byte) (*http.Response, error) {
+	u := *s.endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + s.cfg.Bucket + "/" + key
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body == nil {
+		req.Body = nil
+		req.ContentLength = 0
+	}
+
+	s.sign(req, body)
+	return s.client.Do(req)
+}
+
+// sign adds a Signature Version 4 Authorization header covering the host, the payload hash and any headers already set
+func (s *S3Store) sign(req *http.Request, body []byte) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	payloadHash := sha256Hex(body)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if s.cfg.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.cfg.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.Join(values, ",")
+	}
+	names := slices.Sorted(func(yield func(string) bool) {
+		for name := range headers {
+			if !yield(name) {
+				return
+			}
+		}
+	})
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		uriEncode(req.URL.Path, false),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.cfg.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+s.cfg.SecretAccessKey), date)
+	key = hmacSHA256(key, s.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.cfg.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// canonicalQuery encodes query parameters sorted by name, as Signature Version 4 requires
+func canonicalQuery(query url.Values) string {
+	parts := make([]string, 0, len(query))
+	for name, values := range query {
+		for _, value := range values {
+			parts = append(parts, uriEncode(name, true)+"="+uriEncode(value, true))
+		}
+	}
+	slices.Sort(parts)
+	return strings.Join(parts, "&")
+}
+
+// uriEncode percent-encodes everything except unreserved characters, and slashes unless encodeSlash is set
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3Error describes an unexpected S3 response, including the start of its error document
+func s3Error(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxS3ErrorBody))
+	return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+}
@@ -0,0 +1,25 @@
+package clock
+
+import "time"
+
+// Clock reports the current time
+// Code that makes decisions based on the time takes a Clock so tests can control it.
+type Clock interface {
+	Now() time.Time
+}
+
+// Func adapts a function to a Clock
+type Func func() time.Time
+
+// Now calls f
+func (f Func) Now() time.Time {
+	return f()
+}
+
+// System is the Clock backed by the system time
+var System Clock = Func(time.Now)
+
+// Fixed returns a Clock that always reports t
+func Fixed(t time.Time) Clock {
+	return Func(func() time.Time { return t })
+}
@@ -0,0 +1,25 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFixed(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	c := Fixed(now)
+
+	if got := c.Now(); !got.Equal(now) {
+		t.Errorf("Now() = %v, want %v", got, now)
+	}
+}
+
+func TestSystem(t *testing.T) {
+	before := time.Now()
+	got := System.Now()
+	after := time.Now()
+
+	if got.Before(before) || got.After(after) {
+		t.Errorf("Now() = %v, want between %v and %v", got, before, after)
+	}
+}
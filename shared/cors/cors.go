@@ -0,0 +1,111 @@
+package cors
+
+import (
+	"cmp"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dfryer1193/goblog/shared/env"
+)
+
+const (
+	defaultAllowedMethods = "GET, POST, PUT, PATCH, DELETE"
+	defaultAllowedHeaders = "Authorization, Content-Type"
+	// defaultExposedHeaders are the response headers scripts need to page through and revalidate the API
+	defaultExposedHeaders = "X-Request-ID, Link, X-Total-Count, ETag"
+	defaultMaxAge         = 10 * time.Minute
+)
+
+type Config struct {
+	// AllowedOrigins are the origins allowed to call the API, or "*" for any; cross-origin calls are refused when empty
+	AllowedOrigins []string
+	// AllowedMethods and AllowedHeaders are what preflight requests are told cross-origin calls may use
+	AllowedMethods []string
+	AllowedHeaders []string
+	// ExposedHeaders are the response headers scripts on allowed origins may read, beyond the few browsers always allow
+	ExposedHeaders []string
+	// MaxAge is how long browsers may cache a preflight response
+	MaxAge time.Duration
+}
+
+func NewConfig() *Config {
+	maxAge := defaultMaxAge
+	if n, err := strconv.Atoi(os.Getenv("CORS_MAX_AGE_SECONDS")); err == nil && n >= 0 {
+		maxAge = time.Duration(n) * time.Second
+	}
+
+	return &Config{
+		AllowedOrigins: env.SplitList(os.Getenv("CORS_ALLOWED_ORIGINS")),
+		AllowedMethods: env.SplitList(cmp.Or(os.Getenv("CORS_ALLOWED_METHODS"), defaultAllowedMethods)),
+		AllowedHeaders: env.SplitList(cmp.Or(os.Getenv("CORS_ALLOWED_HEADERS"), defaultAllowedHeaders)),
+		ExposedHeaders: env.SplitList(cmp.Or(os.Getenv("CORS_EXPOSED_HEADERS"), defaultExposedHeaders)),
+		MaxAge:         maxAge,
+	}
+}
+
+// Enabled reports whether any origin may call the API cross-origin
+func (c *Config) Enabled() bool {
+	return len(c.AllowedOrigins) > 0
+}
+
+// allowsOrigin reports whether origin may make cross-origin requests
+func (c *Config) allowsOrigin(origin string) bool {
+	return slices.Contains(c.AllowedOrigins, "*") || slices.Contains(c.AllowedOrigins, origin)
+}
+
+// Middleware lets browsers on the allowed origins call the routes below prefix, such as "/api/"
+// Preflight requests are answered directly, since routes only register the methods they serve. Requests from
+// other origins, or without an Origin, are passed on untouched, so browsers keep blocking their responses.
+// It must be used on the router rather than a route group, so preflights are answered before routing.
+func Middleware(cfg *Config, prefix string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !cfg.Enabled() {
+			return next
+		}
+
+		methods := strings.Join(cfg.AllowedMethods, ", ")
+		headers := strings.Join(cfg.AllowedHeaders, ", ")
+		exposed := strings.Join(cfg.ExposedHeaders, ", ")
+		maxAge := strconv.Itoa(int(cfg.MaxAge.Seconds()))
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !strings.HasPrefix(r.URL.Path, prefix) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Responses differ by origin, so caches must not serve one origin's response to another
+			w.Header().Add("Vary", "Origin")
+			origin := r.Header.Get("Origin")
+			if origin == "" || !cfg.allowsOrigin(origin) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if slices.Contains(cfg.AllowedOrigins, "*") {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			} else {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+			}
+			if exposed != "" {
+				w.Header().Set("Access-Control-Expose-Headers", exposed)
+			}
+
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				w.Header().Add("Vary", "Access-Control-Request-Method")
+				w.Header().Add("Vary", "Access-Control-Request-Headers")
+				w.Header().Set("Access-Control-Allow-Methods", methods)
+				w.Header().Set("Access-Control-Allow-Headers", headers)
+				w.Header().Set("Access-Control-Max-Age", maxAge)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
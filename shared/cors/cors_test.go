@@ -0,0 +1,128 @@
+package cors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func newTestRouter(cfg *Config) *chi.Mux {
+	r := chi.NewRouter()
+	r.Use(Middleware(cfg, "/api/"))
+	r.Get("/api/posts", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("[]"))
+	})
+	r.Get("/feed.xml", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<feed/>"))
+	})
+	return r
+}
+
+func request(r http.Handler, method string, path string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMiddleware(t *testing.T) {
+	cfg := &Config{
+		AllowedOrigins: []string{"https://app.example"},
+		AllowedMethods: []string{"GET", "POST"},
+		AllowedHeaders: []string{"Authorization", "Content-Type"},
+		ExposedHeaders: []string{"Link", "X-Total-Count"},
+		MaxAge:         time.Minute,
+	}
+	r := newTestRouter(cfg)
+
+	rec := request(r, http.MethodGet, "/api/posts", map[string]string{"Origin": "https://app.example"})
+	if rec.Code != http.StatusOK || rec.Header().Get("Access-Control-Allow-Origin") != "https://app.example" {
+		t.Errorf("GET from an allowed origin = %d with Allow-Origin %q, want 200 allowing the origin", rec.Code, rec.Header().Get("Access-Control-Allow-Origin"))
+	}
+	if got := rec.Header().Get("Access-Control-Expose-Headers"); got != "Link, X-Total-Count" {
+		t.Errorf("Expose-Headers = %q, want %q", got, "Link, X-Total-Count")
+	}
+	if !slices.Contains(rec.Header().Values("Vary"), "Origin") {
+		t.Errorf("Vary = %v, want it to include Origin", rec.Header().Values("Vary"))
+	}
+
+	rec = request(r, http.MethodOptions, "/api/posts", map[string]string{
+		"Origin":                         "https://app.example",
+		"Access-Control-Request-Method":  "POST",
+		"Access-Control-Request-Headers": "authorization",
+	})
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("preflight status = %d, want 204", rec.Code)
+	}
+	want := map[string]string{
+		"Access-Control-Allow-Origin":   "https://app.example",
+		"Access-Control-Allow-Methods":  "GET, POST",
+		"Access-Control-Allow-Headers":  "Authorization, Content-Type",
+		"Access-Control-Max-Age":        "60",
+		"Access-Control-Expose-Headers": "Link, X-Total-Count",
+	}
+	for name, value := range want {
+		if got := rec.Header().Get(name); got != value {
+			t.Errorf("preflight %s = %q, want %q", name, got, value)
+		}
+	}
+
+	rec = request(r, http.MethodGet, "/api/posts", map[string]string{"Origin": "https://evil.example"})
+	if rec.Code != http.StatusOK || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("GET from another origin = %d with Allow-Origin %q, want it served without CORS headers", rec.Code, rec.Header().Get("Access-Control-Allow-Origin"))
+	}
+	rec = request(r, http.MethodOptions, "/api/posts", map[string]string{"Origin": "https://evil.example", "Access-Control-Request-Method": "POST"})
+	if rec.Header().Get("Access-Control-Allow-Methods") != "" {
+		t.Error("Expected a preflight from another origin not to be allowed")
+	}
+
+	rec = request(r, http.MethodGet, "/feed.xml", map[string]string{"Origin": "https://app.example"})
+	if rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Error("Expected routes outside the prefix to be left alone")
+	}
+}
+
+func TestMiddleware_AnyOrigin(t *testing.T) {
+	r := newTestRouter(&Config{AllowedOrigins: []string{"*"}})
+
+	rec := request(r, http.MethodGet, "/api/posts", map[string]string{"Origin": "https://app.example"})
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Allow-Origin = %q, want *", got)
+	}
+}
+
+func TestMiddleware_Disabled(t *testing.T) {
+	r := newTestRouter(&Config{})
+
+	rec := request(r, http.MethodOptions, "/api/posts", map[string]string{"Origin": "https://app.example", "Access-Control-Request-Method": "GET"})
+	if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("preflight without allowed origins = %d with Allow-Origin %q, want 405 without CORS headers", rec.Code, rec.Header().Get("Access-Control-Allow-Origin"))
+	}
+}
+
+func TestNewConfig(t *testing.T) {
+	t.Setenv("CORS_ALLOWED_ORIGINS", " https://a.example, ,https://b.example")
+	t.Setenv("CORS_ALLOWED_METHODS", "")
+	t.Setenv("CORS_MAX_AGE_SECONDS", "30")
+
+	cfg := NewConfig()
+	if !slices.Equal(cfg.AllowedOrigins, []string{"https://a.example", "https://b.example"}) {
+		t.Errorf("AllowedOrigins = %v", cfg.AllowedOrigins)
+	}
+	if !slices.Equal(cfg.AllowedMethods, []string{"GET", "POST", "PUT", "PATCH", "DELETE"}) {
+		t.Errorf("AllowedMethods = %v, want the defaults", cfg.AllowedMethods)
+	}
+	if !slices.Equal(cfg.ExposedHeaders, []string{"X-Request-ID", "Link", "X-Total-Count", "ETag"}) {
+		t.Errorf("ExposedHeaders = %v, want the defaults", cfg.ExposedHeaders)
+	}
+	if cfg.MaxAge != 30*time.Second {
+		t.Errorf("MaxAge = %v, want 30s", cfg.MaxAge)
+	}
+}
@@ -1,8 +1,13 @@
 package sqlite
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"time"
 )
 
 // migration represents a single database migration
@@ -10,6 +15,8 @@ type migration struct {
 	version int
 	name    string
 	up      string
+	// down reverts up; it is run by goblog migrate down
+	down string
 }
 
 // migrations is the ordered list of all database migrations
@@ -33,6 +40,10 @@ var migrations = []migration{
 			ON posts(published_at DESC)
 			WHERE published_at IS NOT NULL;
 		`,
+		down: `
+			DROP INDEX IF EXISTS idx_posts_published_at;
+			DROP TABLE IF EXISTS posts;
+		`,
 	},
 	{
 		version: 2,
@@ -48,12 +59,627 @@ var migrations = []migration{
 			CREATE INDEX IF NOT EXISTS idx_images_updated_at 
 			ON images(updated_at DESC);
 		`,
+		down: `
+			DROP INDEX IF EXISTS idx_images_updated_at;
+			DROP TABLE IF EXISTS images;
+		`,
+	},
+	{
+		version: 3,
+		name:    "create_post_images_table",
+		up: `
+			CREATE TABLE IF NOT EXISTS post_images (
+				post_id TEXT NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+				image_path TEXT NOT NULL,
+				PRIMARY KEY (post_id, image_path)
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_post_images_image_path
+			ON post_images(image_path);
+
+			ALTER TABLE images ADD COLUMN orphaned_at TIMESTAMP;
+		`,
+		down: `
+			ALTER TABLE images DROP COLUMN orphaned_at;
+
+			DROP INDEX IF EXISTS idx_post_images_image_path;
+			DROP TABLE IF EXISTS post_images;
+		`,
+	},
+	{
+		version: 4,
+		name:    "add_posts_publish_at",
+		up: `
+			ALTER TABLE posts ADD COLUMN publish_at TIMESTAMP;
+
+			CREATE INDEX IF NOT EXISTS idx_posts_publish_at
+			ON posts(publish_at)
+			WHERE publish_at IS NOT NULL AND published_at IS NULL;
+		`,
+		down: `
+			DROP INDEX IF EXISTS idx_posts_publish_at;
+			ALTER TABLE posts DROP COLUMN publish_at;
+		`,
+	},
+	{
+		version: 5,
+		name:    "create_sync_jobs_tables",
+		up: `
+			CREATE TABLE IF NOT EXISTS sync_jobs (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				ref TEXT NOT NULL,
+				before_sha TEXT NOT NULL,
+				after_sha TEXT NOT NULL,
+				status TEXT NOT NULL,
+				error TEXT,
+				created_at TIMESTAMP NOT NULL,
+				finished_at TIMESTAMP
+			);
+
+			CREATE TABLE IF NOT EXISTS sync_job_files (
+				job_id INTEGER NOT NULL REFERENCES sync_jobs(id) ON DELETE CASCADE,
+				path TEXT NOT NULL,
+				commit_sha TEXT NOT NULL,
+				action TEXT NOT NULL,
+				status TEXT NOT NULL,
+				error TEXT,
+				updated_at TIMESTAMP,
+				PRIMARY KEY (job_id, path)
+			);
+		`,
+		down: `
+			DROP TABLE IF EXISTS sync_job_files;
+			DROP TABLE IF EXISTS sync_jobs;
+		`,
+	},
+	{
+		version: 6,
+		name:    "add_posts_source_path_and_images_hash_index",
+		up: `
+			ALTER TABLE posts ADD COLUMN source_path TEXT;
+
+			CREATE INDEX IF NOT EXISTS idx_images_hash
+			ON images(hash);
+		`,
+		down: `
+			DROP INDEX IF EXISTS idx_images_hash;
+			ALTER TABLE posts DROP COLUMN source_path;
+		`,
+	},
+	{
+		version: 7,
+		name:    "create_dead_letters_table",
+		up: `
+			CREATE TABLE IF NOT EXISTS dead_letters (
+				path TEXT PRIMARY KEY,
+				ref TEXT NOT NULL,
+				error TEXT NOT NULL,
+				attempts INTEGER NOT NULL DEFAULT 1,
+				first_failed_at TIMESTAMP NOT NULL,
+				last_failed_at TIMESTAMP NOT NULL
+			);
+		`,
+		down: `
+			DROP TABLE IF EXISTS dead_letters;
+		`,
+	},
+	{
+		version: 8,
+		name:    "create_source_cache_tables",
+		up: `
+			CREATE TABLE IF NOT EXISTS source_commits (
+				sha TEXT PRIMARY KEY,
+				data BLOB NOT NULL,
+				cached_at TIMESTAMP NOT NULL
+			);
+
+			CREATE TABLE IF NOT EXISTS source_files (
+				path TEXT NOT NULL,
+				ref TEXT NOT NULL,
+				content BLOB NOT NULL,
+				cached_at TIMESTAMP NOT NULL,
+				PRIMARY KEY (path, ref)
+			);
+		`,
+		down: `
+			DROP TABLE IF EXISTS source_files;
+			DROP TABLE IF EXISTS source_commits;
+		`,
+	},
+	{
+		version: 9,
+		name:    "create_api_tokens_table",
+		up: `
+			CREATE TABLE IF NOT EXISTS api_tokens (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				name TEXT NOT NULL,
+				token_hash TEXT NOT NULL UNIQUE,
+				scopes TEXT NOT NULL,
+				created_at TIMESTAMP NOT NULL,
+				last_used_at TIMESTAMP,
+				revoked_at TIMESTAMP
+			);
+		`,
+		down: `
+			DROP TABLE IF EXISTS api_tokens;
+		`,
+	},
+	{
+		version: 10,
+		name:    "add_posts_branch",
+		up: `
+			ALTER TABLE posts ADD COLUMN branch TEXT;
+		`,
+		down: `
+			ALTER TABLE posts DROP COLUMN branch;
+		`,
+	},
+	{
+		version: 11,
+		name:    "create_processed_commits_table",
+		up: `
+			CREATE TABLE IF NOT EXISTS processed_commits (
+				branch TEXT NOT NULL,
+				sha TEXT NOT NULL,
+				processed_at TIMESTAMP NOT NULL,
+				PRIMARY KEY (branch, sha)
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_processed_commits_processed_at ON processed_commits(processed_at);
+		`,
+		down: `
+			DROP INDEX IF EXISTS idx_processed_commits_processed_at;
+			DROP TABLE IF EXISTS processed_commits;
+		`,
+	},
+	{
+		version: 12,
+		name:    "add_posts_reading_time",
+		up: `
+			ALTER TABLE posts ADD COLUMN word_count INTEGER NOT NULL DEFAULT 0;
+			ALTER TABLE posts ADD COLUMN reading_minutes INTEGER NOT NULL DEFAULT 0;
+		`,
+		down: `
+			ALTER TABLE posts DROP COLUMN reading_minutes;
+			ALTER TABLE posts DROP COLUMN word_count;
+		`,
+	},
+	{
+		version: 13,
+		name:    "add_posts_commit_sha",
+		up: `
+			ALTER TABLE posts ADD COLUMN commit_sha TEXT;
+		`,
+		down: `
+			ALTER TABLE posts DROP COLUMN commit_sha;
+		`,
+	},
+	{
+		version: 14,
+		name:    "add_posts_toc",
+		up: `
+			ALTER TABLE posts ADD COLUMN toc TEXT;
+		`,
+		down: `
+			ALTER TABLE posts DROP COLUMN toc;
+		`,
+	},
+	{
+		version: 15,
+		name:    "add_posts_signature",
+		up: `
+			ALTER TABLE posts ADD COLUMN signature TEXT;
+		`,
+		down: `
+			ALTER TABLE posts DROP COLUMN signature;
+		`,
+	},
+	{
+		version: 16,
+		name:    "add_posts_language",
+		up: `
+			ALTER TABLE posts ADD COLUMN language TEXT;
+		`,
+		down: `
+			ALTER TABLE posts DROP COLUMN language;
+		`,
+	},
+	{
+		version: 17,
+		name:    "create_webhook_deliveries_table",
+		up: `
+			CREATE TABLE IF NOT EXISTS webhook_deliveries (
+				id TEXT PRIMARY KEY,
+				received_at TIMESTAMP NOT NULL
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_received_at ON webhook_deliveries(received_at);
+		`,
+		down: `
+			DROP INDEX IF EXISTS idx_webhook_deliveries_received_at;
+			DROP TABLE IF EXISTS webhook_deliveries;
+		`,
+	},
+	{
+		version: 18,
+		name:    "add_images_perceptual_hash",
+		up: `
+			ALTER TABLE images ADD COLUMN perceptual_hash TEXT;
+		`,
+		down: `
+			ALTER TABLE images DROP COLUMN perceptual_hash;
+		`,
+	},
+	{
+		version: 19,
+		name:    "create_shadow_posts_tables",
+		// The shadow namespace mirrors posts and post_images, so columns added to those must be added here too
+		up: `
+			CREATE TABLE IF NOT EXISTS shadow_posts (
+				id TEXT PRIMARY KEY,
+				title TEXT NOT NULL,
+				snippet TEXT NOT NULL,
+				html_path TEXT NOT NULL,
+				updated_at TIMESTAMP,
+				published_at TIMESTAMP,
+				created_at TIMESTAMP NOT NULL,
+				publish_at TIMESTAMP,
+				source_path TEXT,
+				branch TEXT,
+				word_count INTEGER NOT NULL DEFAULT 0,
+				reading_minutes INTEGER NOT NULL DEFAULT 0,
+				commit_sha TEXT,
+				toc TEXT,
+				signature TEXT,
+				language TEXT
+			);
+
+			CREATE TABLE IF NOT EXISTS shadow_post_images (
+				post_id TEXT NOT NULL REFERENCES shadow_posts(id) ON DELETE CASCADE,
+				image_path TEXT NOT NULL,
+				PRIMARY KEY (post_id, image_path)
+			);
+		`,
+		down: `
+			DROP TABLE IF EXISTS shadow_post_images;
+			DROP TABLE IF EXISTS shadow_posts;
+		`,
+	},
+	{
+		version: 20,
+		name:    "create_post_diagnostics_table",
+		up: `
+			CREATE TABLE IF NOT EXISTS post_diagnostics (
+				post_id TEXT NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+				kind TEXT NOT NULL,
+				target TEXT NOT NULL,
+				message TEXT NOT NULL,
+				checked_at TIMESTAMP NOT NULL,
+				PRIMARY KEY (post_id, kind, target)
+			);
+		`,
+		down: `
+			DROP TABLE IF EXISTS post_diagnostics;
+		`,
+	},
+	{
+		version: 21,
+		name:    "add_posts_document",
+		up: `
+			ALTER TABLE posts ADD COLUMN document TEXT;
+			ALTER TABLE shadow_posts ADD COLUMN document TEXT;
+		`,
+		down: `
+			ALTER TABLE shadow_posts DROP COLUMN document;
+			ALTER TABLE posts DROP COLUMN document;
+		`,
+	},
+	{
+		version: 22,
+		name:    "add_posts_html_content",
+		up: `
+			ALTER TABLE posts ADD COLUMN html_content BLOB;
+			ALTER TABLE shadow_posts ADD COLUMN html_content BLOB;
+		`,
+		down: `
+			ALTER TABLE shadow_posts DROP COLUMN html_content;
+			ALTER TABLE posts DROP COLUMN html_content;
+		`,
+	},
+	{
+		version: 23,
+		name:    "create_pending_renders_table",
+		up: `
+			CREATE TABLE IF NOT EXISTS pending_renders (
+				branch TEXT NOT NULL,
+				path TEXT NOT NULL,
+				commit_sha TEXT NOT NULL,
+				blob_sha TEXT NOT NULL DEFAULT '',
+				created_at TIMESTAMP,
+				modified_at TIMESTAMP,
+				released INTEGER NOT NULL DEFAULT 0,
+				queued_at TIMESTAMP NOT NULL,
+				PRIMARY KEY (branch, path)
+			);
+		`,
+		down: `
+			DROP TABLE IF EXISTS pending_renders;
+		`,
+	},
+	{
+		version: 24,
+		name:    "add_dead_letters_poison",
+		up: `
+			ALTER TABLE dead_letters ADD COLUMN ref_attempts INTEGER NOT NULL DEFAULT 1;
+			ALTER TABLE dead_letters ADD COLUMN poisoned_at TIMESTAMP;
+		`,
+		down: `
+			ALTER TABLE dead_letters DROP COLUMN poisoned_at;
+			ALTER TABLE dead_letters DROP COLUMN ref_attempts;
+		`,
+	},
+	{
+		version: 25,
+		name:    "create_reader_preferences_table",
+		up: `
+			CREATE TABLE IF NOT EXISTS reader_preferences (
+				token_hash TEXT PRIMARY KEY,
+				theme TEXT NOT NULL DEFAULT '',
+				font_size TEXT NOT NULL DEFAULT '',
+				updated_at TIMESTAMP NOT NULL,
+				last_seen_at TIMESTAMP NOT NULL
+			);
+			CREATE INDEX IF NOT EXISTS idx_reader_preferences_last_seen_at ON reader_preferences(last_seen_at);
+		`,
+		down: `
+			DROP TABLE IF EXISTS reader_preferences;
+		`,
+	},
+	{
+		version: 26,
+		name:    "add_posts_slug",
+		// Existing posts take the slug of their file name when it is already in slug form, and the rest
+		// gain one the next time they are rendered
+		up: `
+			ALTER TABLE posts ADD COLUMN slug TEXT;
+			ALTER TABLE shadow_posts ADD COLUMN slug TEXT;
+
+			WITH derived AS (
+				SELECT id, lower(substr(source_path, instr(source_path, '-') + 1, length(source_path) - instr(source_path, '-') - 3)) AS slug
+				FROM posts
+				WHERE source_path GLOB 'posts/[0-9]*-?*.md'
+			)
+			UPDATE posts
+			SET slug = (SELECT slug FROM derived WHERE derived.id = posts.id)
+			WHERE id IN (
+				SELECT d.id FROM derived d
+				WHERE d.slug GLOB '*[a-z]*'
+				AND d.slug NOT GLOB '*[^a-z0-9-]*'
+				AND d.slug NOT GLOB '-*' AND d.slug NOT GLOB '*-' AND d.slug NOT GLOB '*--*'
+				AND NOT EXISTS (SELECT 1 FROM derived other WHERE other.slug = d.slug AND other.id < d.id)
+			);
+
+			CREATE UNIQUE INDEX IF NOT EXISTS idx_posts_slug ON posts(slug);
+			CREATE UNIQUE INDEX IF NOT EXISTS idx_shadow_posts_slug ON shadow_posts(slug);
+		`,
+		down: `
+			DROP INDEX IF EXISTS idx_shadow_posts_slug;
+			DROP INDEX IF EXISTS idx_posts_slug;
+			ALTER TABLE shadow_posts DROP COLUMN slug;
+			ALTER TABLE posts DROP COLUMN slug;
+		`,
+	},
+	{
+		version: 27,
+		name:    "create_redirects_table",
+		up: `
+			CREATE TABLE IF NOT EXISTS redirects (
+				from_slug TEXT PRIMARY KEY,
+				post_id TEXT NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+				created_at TIMESTAMP NOT NULL
+			);
+		`,
+		down: `
+			DROP TABLE IF EXISTS redirects;
+		`,
+	},
+	{
+		version: 28,
+		name:    "create_post_views_table",
+		up: `
+			CREATE TABLE IF NOT EXISTS post_views (
+				post_id TEXT NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+				day TEXT NOT NULL,
+				views INTEGER NOT NULL,
+				PRIMARY KEY (post_id, day)
+			);
+			CREATE INDEX IF NOT EXISTS idx_post_views_day ON post_views(day);
+		`,
+		down: `
+			DROP TABLE IF EXISTS post_views;
+		`,
+	},
+	{
+		version: 29,
+		name:    "create_webmentions_table",
+		up: `
+			CREATE TABLE IF NOT EXISTS webmentions (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				source TEXT NOT NULL,
+				target TEXT NOT NULL,
+				post_id TEXT NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+				protocol TEXT NOT NULL,
+				title TEXT NOT NULL DEFAULT '',
+				status TEXT NOT NULL,
+				received_at TIMESTAMP NOT NULL,
+				verified_at TIMESTAMP NOT NULL,
+				UNIQUE (source, post_id)
+			);
+			CREATE INDEX IF NOT EXISTS idx_webmentions_post ON webmentions(post_id, status);
+		`,
+		down: `
+			DROP TABLE IF EXISTS webmentions;
+		`,
+	},
+	{
+		version: 30,
+		name:    "create_federation_tables",
+		up: `
+			CREATE TABLE IF NOT EXISTS followers (
+				actor_id TEXT PRIMARY KEY,
+				inbox TEXT NOT NULL,
+				shared_inbox TEXT NOT NULL DEFAULT '',
+				followed_at TIMESTAMP NOT NULL
+			);
+			CREATE TABLE IF NOT EXISTS federated_posts (
+				post_id TEXT PRIMARY KEY,
+				announced_at TIMESTAMP NOT NULL
+			);
+		`,
+		down: `
+			DROP TABLE IF EXISTS federated_posts;
+			DROP TABLE IF EXISTS followers;
+		`,
+	},
+	{
+		version: 31,
+		name:    "create_newsletter_tables",
+		up: `
+			CREATE TABLE IF NOT EXISTS subscribers (
+				email TEXT PRIMARY KEY,
+				token TEXT NOT NULL UNIQUE,
+				subscribed_at TIMESTAMP NOT NULL,
+				confirmed_at TIMESTAMP
+			);
+			CREATE TABLE IF NOT EXISTS mailed_posts (
+				post_id TEXT PRIMARY KEY,
+				mailed_at TIMESTAMP NOT NULL
+			);
+		`,
+		down: `
+			DROP TABLE IF EXISTS mailed_posts;
+			DROP TABLE IF EXISTS subscribers;
+		`,
+	},
+	{
+		version: 32,
+		name:    "add_pending_renders_action",
+		up: `
+			ALTER TABLE pending_renders ADD COLUMN action TEXT NOT NULL DEFAULT 'upsert';
+		`,
+		down: `
+			DELETE FROM pending_renders WHERE action != 'upsert';
+			ALTER TABLE pending_renders DROP COLUMN action;
+		`,
+	},
+	{
+		version: 33,
+		name:    "create_source_tree_and_comparison_cache",
+		up: `
+			CREATE TABLE IF NOT EXISTS source_trees (
+				sha TEXT PRIMARY KEY,
+				data BLOB NOT NULL,
+				cached_at TIMESTAMP NOT NULL
+			);
+			CREATE TABLE IF NOT EXISTS source_comparisons (
+				base TEXT NOT NULL,
+				head TEXT NOT NULL,
+				data BLOB NOT NULL,
+				cached_at TIMESTAMP NOT NULL,
+				PRIMARY KEY (base, head)
+			);
+			ALTER TABLE source_files ADD COLUMN blob_sha TEXT NOT NULL DEFAULT '';
+			CREATE INDEX IF NOT EXISTS idx_source_files_blob_sha ON source_files (blob_sha);
+		`,
+		down: `
+			DROP INDEX IF EXISTS idx_source_files_blob_sha;
+			ALTER TABLE source_files DROP COLUMN blob_sha;
+			DROP TABLE IF EXISTS source_comparisons;
+			DROP TABLE IF EXISTS source_trees;
+		`,
+	},
+	{
+		version: 34,
+		name:    "add_posts_images_recorded",
+		// post_images started out empty, and image references live only in rendered HTML, so they can't be
+		// backfilled here. Posts with references are known to have them recorded; the rest are rendered again
+		// before images are garbage collected, and any image flagged in the meantime gets its grace period back.
+		up: `
+			ALTER TABLE posts ADD COLUMN images_recorded INTEGER NOT NULL DEFAULT 0;
+			ALTER TABLE shadow_posts ADD COLUMN images_recorded INTEGER NOT NULL DEFAULT 0;
+
+			UPDATE posts SET images_recorded = 1
+			WHERE EXISTS (SELECT 1 FROM post_images WHERE post_images.post_id = posts.id);
+			UPDATE shadow_posts SET images_recorded = 1
+			WHERE EXISTS (SELECT 1 FROM shadow_post_images WHERE shadow_post_images.post_id = shadow_posts.id);
+
+			UPDATE images SET orphaned_at = NULL
+			WHERE EXISTS (SELECT 1 FROM posts WHERE images_recorded = 0)
+			OR EXISTS (SELECT 1 FROM shadow_posts WHERE images_recorded = 0);
+		`,
+		down: `
+			ALTER TABLE shadow_posts DROP COLUMN images_recorded;
+			ALTER TABLE posts DROP COLUMN images_recorded;
+		`,
 	},
 }
 
+const (
+	migrateUp   = "up"
+	migrateDown = "down"
+)
+
+// MigrationState is a known migration and when it was applied
+type MigrationState struct {
+	Version int
+	Name    string
+	// AppliedAt is zero for a migration that has not been applied
+	AppliedAt time.Time
+}
+
+// DirtyMigration is a migration that was interrupted before its run finished
+type DirtyMigration struct {
+	Version   int
+	Direction string
+	StartedAt time.Time
+}
+
+// MigrationStatus describes the schema of a database
+type MigrationStatus struct {
+	// Version is the latest migration applied, or zero for an empty database
+	Version    int
+	Migrations []MigrationState
+	// Unknown lists applied versions this build has no migration for, e.g. after running an older build
+	Unknown []int
+	// Dirty is the migration a run was interrupted in, or nil
+	Dirty *DirtyMigration
+}
+
+// ErrDirty is returned when an earlier migration run was interrupted, so the server doesn't migrate on top of it
+var ErrDirty = errors.New("database has an interrupted migration")
+
 // runMigrations executes all pending migrations
+// It refuses to run after an interrupted migration until goblog migrate has been used to move on from it.
 func runMigrations(db *sql.DB) error {
-	_, err := db.Exec(`
+	ctx := context.Background()
+	if err := createMigrationTables(ctx, db); err != nil {
+		return err
+	}
+
+	dirty, err := dirtyMigration(ctx, db)
+	if err != nil {
+		return err
+	}
+	if dirty != nil {
+		return fmt.Errorf("%w: migration %d %s was not finished; run goblog migrate status", ErrDirty, dirty.Version, dirty.Direction)
+	}
+
+	_, err = migrateTo(ctx, db, latestVersion())
+	return err
+}
+
+func createMigrationTables(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `
 		CREATE TABLE IF NOT EXISTS schema_migrations (
 			version INTEGER PRIMARY KEY,
 			name TEXT NOT NULL,
@@ -64,43 +690,222 @@ func runMigrations(db *sql.DB) error {
 		return fmt.Errorf("failed to create schema_migrations table: %w", err)
 	}
 
-	currentVersion := 0
-	err = db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&currentVersion)
+	// A row here marks a migration as running; older builds left it behind when a run was interrupted
+	_, err = db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations_dirty (
+			version INTEGER NOT NULL,
+			direction TEXT NOT NULL,
+			started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations_dirty table: %w", err)
+	}
+
+	return nil
+}
+
+// currentVersion returns the latest migration applied
+func currentVersion(ctx context.Context, db *sql.DB) (int, error) {
+	version := 0
+	err := db.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get current schema version: %w", err)
+	}
+	return version, nil
+}
+
+func dirtyMigration(ctx context.Context, db *sql.DB) (*DirtyMigration, error) {
+	var dirty DirtyMigration
+	err := db.QueryRowContext(ctx, "SELECT version, direction, started_at FROM schema_migrations_dirty").
+		Scan(&dirty.Version, &dirty.Direction, &dirty.StartedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for an interrupted migration: %w", err)
+	}
+	return &dirty, nil
+}
+
+// clearDirtyQuery forgets an interrupted migration
+const clearDirtyQuery = "DELETE FROM schema_migrations_dirty"
+
+// clearDirty forgets an interrupted migration, so goblog migrate can carry on from the version recorded
+func clearDirty(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, clearDirtyQuery); err != nil {
+		return fmt.Errorf("failed to clear interrupted migration: %w", err)
+	}
+	return nil
+}
+
+// migrateTo applies or reverts migrations until the latest one applied is target, returning how many ran
+func migrateTo(ctx context.Context, db *sql.DB, target int) (int, error) {
+	current, err := currentVersion(ctx, db)
 	if err != nil {
-		return fmt.Errorf("failed to get current schema version: %w", err)
+		return 0, err
 	}
 
-	// Run pending migrations
+	ran := 0
 	for _, m := range migrations {
-		if m.version <= currentVersion {
-			continue // Already applied
+		if m.version <= current || m.version > target {
+			continue
+		}
+		if err := runMigration(ctx, db, m, migrateUp); err != nil {
+			return ran, err
 		}
+		ran++
+	}
 
-		tx, err := db.Begin()
-		if err != nil {
-			return fmt.Errorf("failed to begin transaction for migration %d: %w", m.version, err)
+	for _, m := range slices.Backward(migrations) {
+		if m.version > current || m.version <= target {
+			continue
+		}
+		if err := runMigration(ctx, db, m, migrateDown); err != nil {
+			return ran, err
 		}
+		ran++
+	}
+
+	return ran, nil
+}
+
+// runMigration applies or reverts a migration in a transaction that also records it
+// SQLite rolls back DDL with the rest of a transaction, so an interrupted migration leaves no trace. The running
+// mark is written and cleared in the same transaction, so it is never left behind by this build; a mark found on
+// start was left by an older build that wrote it separately, and is still honoured.
+func runMigration(ctx context.Context, db *sql.DB, m migration, direction string) error {
+	script, record := m.up, "INSERT INTO schema_migrations (version, name) VALUES (?, ?)"
+	if direction == migrateDown {
+		script, record = m.down, "DELETE FROM schema_migrations WHERE version = ? AND name = ?"
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction for migration %d: %w", m.version, err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO schema_migrations_dirty (version, direction) VALUES (?, ?)", m.version, direction)
+	if err != nil {
+		return fmt.Errorf("failed to mark migration %d as running: %w", m.version, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return fmt.Errorf("failed to execute migration %d (%s) %s: %w", m.version, m.name, direction, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, record, m.version, m.name); err != nil {
+		return fmt.Errorf("failed to record migration %d: %w", m.version, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, clearDirtyQuery); err != nil {
+		return fmt.Errorf("failed to clear the running mark of migration %d: %w", m.version, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %d: %w", m.version, err)
+	}
 
-		_, err = tx.Exec(m.up)
-		if err != nil {
-			tx.Rollback()
-			return fmt.Errorf("failed to execute migration %d (%s): %w", m.version, m.name, err)
+	return nil
+}
+
+// MigrationStatus reports which migrations have been applied, and any interrupted migration
+func (s *SQLiteDB) MigrationStatus(ctx context.Context) (*MigrationStatus, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+	if err := createMigrationTables(ctx, s.db); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations ORDER BY version")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt sql.NullTime
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan applied migration: %w", err)
 		}
+		applied[version] = appliedAt.Time
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating applied migrations: %w", err)
+	}
 
-		_, err = tx.Exec(
-			"INSERT INTO schema_migrations (version, name) VALUES (?, ?)",
-			m.version,
-			m.name,
-		)
-		if err != nil {
-			tx.Rollback()
-			return fmt.Errorf("failed to record migration %d: %w", m.version, err)
+	status := &MigrationStatus{}
+	for _, m := range migrations {
+		appliedAt, ok := applied[m.version]
+		status.Migrations = append(status.Migrations, MigrationState{Version: m.version, Name: m.name, AppliedAt: appliedAt})
+		if ok {
+			status.Version = m.version
+			delete(applied, m.version)
 		}
+	}
+	status.Unknown = slices.Sorted(maps.Keys(applied))
+	if len(status.Unknown) > 0 {
+		status.Version = max(status.Version, status.Unknown[len(status.Unknown)-1])
+	}
+
+	if status.Dirty, err = dirtyMigration(ctx, s.db); err != nil {
+		return nil, err
+	}
+
+	return status, nil
+}
 
-		if err := tx.Commit(); err != nil {
-			return fmt.Errorf("failed to commit migration %d: %w", m.version, err)
+// MigrateUp applies pending migrations up to and including target, or all of them if target is zero
+// It moves on from an interrupted migration, which left the schema at the version recorded.
+func (s *SQLiteDB) MigrateUp(ctx context.Context, target int) (int, error) {
+	return s.migrate(ctx, func(current int) int {
+		if target == 0 {
+			return latestVersion()
 		}
+		return max(target, current)
+	})
+}
+
+// MigrateDown reverts the latest steps migrations
+// It moves on from an interrupted migration, which left the schema at the version recorded.
+func (s *SQLiteDB) MigrateDown(ctx context.Context, steps int) (int, error) {
+	return s.migrate(ctx, func(current int) int {
+		return max(current-steps, 0)
+	})
+}
+
+// migrate moves the schema to the version target picks from the current one
+func (s *SQLiteDB) migrate(ctx context.Context, target func(current int) int) (int, error) {
+	if s.db == nil {
+		return 0, fmt.Errorf("database not connected")
+	}
+	if err := createMigrationTables(ctx, s.db); err != nil {
+		return 0, err
 	}
 
-	return nil
+	current, err := currentVersion(ctx, s.db)
+	if err != nil {
+		return 0, err
+	}
+	if current > latestVersion() {
+		return 0, fmt.Errorf("database is at version %d, newer than the latest migration %d known to this build", current, latestVersion())
+	}
+	version := target(current)
+	if version > latestVersion() {
+		return 0, fmt.Errorf("no migration %d", version)
+	}
+
+	if err := clearDirty(ctx, s.db); err != nil {
+		return 0, err
+	}
+	return migrateTo(ctx, s.db, version)
+}
+
+// latestVersion returns the version of the last migration
+func latestVersion() int {
+	return migrations[len(migrations)-1].version
 }
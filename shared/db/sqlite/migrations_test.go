@@ -1,8 +1,12 @@
 package sqlite
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"path/filepath"
+	"reflect"
+	"slices"
 	"testing"
 )
 
@@ -155,3 +159,229 @@ func TestPostsTableSchema(t *testing.T) {
 	}
 }
 
+// schemaOf describes every table's columns and every index, ignoring the migration bookkeeping tables
+func schemaOf(t *testing.T, db *sql.DB) map[string][]string {
+	t.Helper()
+
+	rows, err := db.Query(`
+		SELECT type, name FROM sqlite_master
+		WHERE name NOT LIKE 'sqlite_%' AND name NOT LIKE 'schema_migrations%'
+	`)
+	if err != nil {
+		t.Fatalf("Failed to list schema: %v", err)
+	}
+	var tables, indexes []string
+	for rows.Next() {
+		var kind, name string
+		if err := rows.Scan(&kind, &name); err != nil {
+			t.Fatalf("Failed to scan schema: %v", err)
+		}
+		if kind == "table" {
+			tables = append(tables, name)
+		} else {
+			indexes = append(indexes, name)
+		}
+	}
+	rows.Close()
+
+	schema := map[string][]string{}
+	for _, table := range tables {
+		columns, err := db.Query("SELECT name FROM pragma_table_info(?) ORDER BY name", table)
+		if err != nil {
+			t.Fatalf("Failed to list columns of %s: %v", table, err)
+		}
+		for columns.Next() {
+			var name string
+			if err := columns.Scan(&name); err != nil {
+				t.Fatalf("Failed to scan column: %v", err)
+			}
+			schema[table] = append(schema[table], name)
+		}
+		columns.Close()
+	}
+	for _, index := range indexes {
+		schema["index "+index] = nil
+	}
+	return schema
+}
+
+func TestMigrateDownAndUp(t *testing.T) {
+	database := NewSQLiteDB(&SQLiteConfig{Path: filepath.Join(t.TempDir(), "test.db")})
+	if err := database.Connect(); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer database.Close()
+	ctx := context.Background()
+
+	want := schemaOf(t, database.DB())
+
+	reverted, err := database.MigrateDown(ctx, 2)
+	if err != nil {
+		t.Fatalf("MigrateDown() error = %v", err)
+	}
+	if reverted != 2 {
+		t.Errorf("MigrateDown() reverted %d migrations, want 2", reverted)
+	}
+	status, err := database.MigrationStatus(ctx)
+	if err != nil {
+		t.Fatalf("MigrationStatus() error = %v", err)
+	}
+	if status.Version != latestVersion()-2 {
+		t.Errorf("Version = %d, want %d", status.Version, latestVersion()-2)
+	}
+	last := status.Migrations[len(status.Migrations)-1]
+	if !last.AppliedAt.IsZero() || status.Migrations[0].AppliedAt.IsZero() {
+		t.Errorf("Migrations = %+v, want the last two pending", status.Migrations)
+	}
+
+	// Every down script must run, and leave nothing behind
+	if _, err := database.MigrateDown(ctx, latestVersion()); err != nil {
+		t.Fatalf("MigrateDown() to an empty database error = %v", err)
+	}
+	if schema := schemaOf(t, database.DB()); len(schema) != 0 {
+		t.Errorf("schema after reverting every migration = %v, want it empty", schema)
+	}
+
+	applied, err := database.MigrateUp(ctx, 0)
+	if err != nil {
+		t.Fatalf("MigrateUp() error = %v", err)
+	}
+	if applied != latestVersion() {
+		t.Errorf("MigrateUp() applied %d migrations, want %d", applied, latestVersion())
+	}
+	if got := schemaOf(t, database.DB()); !reflect.DeepEqual(got, want) {
+		t.Errorf("schema after migrating down and up = %v, want %v", got, want)
+	}
+}
+
+func TestMigration_PostSlugs(t *testing.T) {
+	database := NewSQLiteDB(&SQLiteConfig{Path: filepath.Join(t.TempDir(), "test.db")})
+	if err := database.Connect(); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer database.Close()
+	ctx := context.Background()
+
+	if _, err := database.MigrateDown(ctx, latestVersion()-25); err != nil {
+		t.Fatalf("MigrateDown() error = %v", err)
+	}
+	posts := map[string]string{
+		"001": "posts/001-hello-world.md",
+		"002": "posts/002-Mixed-Case.md",
+		"003": "posts/003-has spaces.md",
+		"004": "posts/004-hello-world.md",
+		"005": "posts/005-2024.md",
+	}
+	for id, sourcePath := range posts {
+		if _, err := database.DB().Exec(`
+			INSERT INTO posts (id, title, snippet, html_path, created_at, source_path)
+			VALUES (?, '', '', '', CURRENT_TIMESTAMP, ?)
+		`, id, sourcePath); err != nil {
+			t.Fatalf("Failed to insert post: %v", err)
+		}
+	}
+	if _, err := database.MigrateUp(ctx, 26); err != nil {
+		t.Fatalf("MigrateUp() error = %v", err)
+	}
+
+	// Names already in slug form are kept, except a duplicate, and the rest wait to be rendered again
+	want := map[string]string{"001": "hello-world", "002": "mixed-case"}
+	rows, err := database.DB().Query("SELECT id, slug FROM posts")
+	if err != nil {
+		t.Fatalf("Failed to query slugs: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		var slug sql.NullString
+		if err := rows.Scan(&id, &slug); err != nil {
+			t.Fatalf("Failed to scan slug: %v", err)
+		}
+		if slug.String != want[id] {
+			t.Errorf("slug of %s = %q, want %q", id, slug.String, want[id])
+		}
+	}
+
+	if _, err := database.DB().Exec("UPDATE posts SET slug = 'hello-world' WHERE id = '004'"); err == nil {
+		t.Error("Expected a duplicate slug to be refused")
+	}
+}
+
+func TestRunMigrations_Dirty(t *testing.T) {
+	cfg := &SQLiteConfig{Path: filepath.Join(t.TempDir(), "test.db")}
+	ctx := context.Background()
+
+	database := NewSQLiteDB(cfg)
+	if err := database.Connect(); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	// As if the server stopped while migrating
+	if _, err := database.DB().Exec("INSERT INTO schema_migrations_dirty (version, direction) VALUES (?, ?)", latestVersion()+1, "up"); err != nil {
+		t.Fatalf("Failed to mark migration dirty: %v", err)
+	}
+	database.Close()
+
+	if err := database.Connect(); !errors.Is(err, ErrDirty) {
+		t.Fatalf("Connect() error = %v, want ErrDirty", err)
+	}
+
+	if err := database.Open(); err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	status, err := database.MigrationStatus(ctx)
+	if err != nil {
+		t.Fatalf("MigrationStatus() error = %v", err)
+	}
+	if status.Dirty == nil || status.Dirty.Version != latestVersion()+1 || status.Dirty.Direction != "up" {
+		t.Errorf("Dirty = %+v, want the interrupted migration", status.Dirty)
+	}
+
+	if _, err := database.MigrateUp(ctx, 0); err != nil {
+		t.Fatalf("MigrateUp() error = %v", err)
+	}
+	database.Close()
+
+	if err := database.Connect(); err != nil {
+		t.Fatalf("Connect() after MigrateUp() error = %v", err)
+	}
+	database.Close()
+}
+
+func TestRunMigrations_FailureRollsBack(t *testing.T) {
+	database := NewSQLiteDB(&SQLiteConfig{Path: filepath.Join(t.TempDir(), "test.db")})
+	if err := database.Connect(); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	database.Close()
+
+	original := migrations
+	migrations = append(slices.Clip(original), migration{
+		version: latestVersion() + 1,
+		name:    "broken",
+		up: `
+			CREATE TABLE half_done (id INTEGER);
+			CREATE TABLE broken (
+		`,
+	})
+	defer func() { migrations = original }()
+
+	if err := database.Connect(); err == nil {
+		t.Fatal("Connect() should fail on a broken migration")
+	}
+
+	if err := database.Open(); err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer database.Close()
+
+	status, err := database.MigrationStatus(context.Background())
+	if err != nil {
+		t.Fatalf("MigrationStatus() error = %v", err)
+	}
+	if status.Version != latestVersion()-1 || status.Dirty != nil {
+		t.Errorf("status = version %d, dirty %+v, want the previous version and not dirty", status.Version, status.Dirty)
+	}
+	if _, ok := schemaOf(t, database.DB())["half_done"]; ok {
+		t.Error("Expected the failed migration's changes to be rolled back")
+	}
+}
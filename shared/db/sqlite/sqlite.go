@@ -1,9 +1,12 @@
 package sqlite
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
+	"strconv"
 
 	"github.com/dfryer1193/goblog/shared/db"
 	_ "modernc.org/sqlite"
@@ -12,10 +15,14 @@ import (
 const (
 	// DefaultPath is the default path for the SQLite database
 	defaultPath = "./goblog.db"
+	// defaultMaxTransactions serializes transactions, since SQLite only allows one writer at a time
+	defaultMaxTransactions = 1
 )
 
 type SQLiteConfig struct {
 	Path string
+	// MaxTransactions is the most transactions open at once; zero leaves them unlimited
+	MaxTransactions int
 }
 
 func NewSQLiteConfig() *SQLiteConfig {
@@ -24,41 +31,65 @@ func NewSQLiteConfig() *SQLiteConfig {
 		path = defaultPath
 	}
 
+	maxTransactions := defaultMaxTransactions
+	if n, err := strconv.Atoi(os.Getenv("SQLITE_MAX_TRANSACTIONS")); err == nil && n >= 0 {
+		maxTransactions = n
+	}
+
 	return &SQLiteConfig{
-		Path: path,
+		Path:            path,
+		MaxTransactions: maxTransactions,
 	}
 }
 
 // SQLiteDB implements both db.Database and db.TransactionManager interfaces
 type SQLiteDB struct {
-	dbPath string
-	db     *sql.DB
+	dbPath          string
+	maxTransactions int
+	db              *sql.DB
 }
 
 // NewSQLiteDB creates a new SQLite database instance
-func NewSQLiteDB(cfg *SQLiteConfig) db.Database {
+func NewSQLiteDB(cfg *SQLiteConfig) *SQLiteDB {
 	return &SQLiteDB{
-		dbPath: cfg.Path,
+		dbPath:          cfg.Path,
+		maxTransactions: cfg.MaxTransactions,
 	}
 }
 
 // Ensure SQLiteDB implements Database interface
 var _ db.Database = (*SQLiteDB)(nil)
 
-// Connect opens a connection to the SQLite database
+// Connect opens a connection to the SQLite database and applies pending migrations
 func (s *SQLiteDB) Connect() error {
+	if err := s.Open(); err != nil {
+		return err
+	}
+
+	// Run migrations
+	if err := runMigrations(s.db); err != nil {
+		s.db.Close()
+		s.db = nil
+		return fmt.Errorf("failed to run migrations: %w", err)
+	}
+
+	return nil
+}
+
+// Open opens a connection to the SQLite database without migrating it, for tools that manage the schema
+func (s *SQLiteDB) Open() error {
 	if s.db != nil {
 		return fmt.Errorf("database already connected")
 	}
 
-	db, err := sql.Open("sqlite", s.dbPath)
+	conn, err := sql.Open("sqlite", s.dbPath)
 	if err != nil {
 		return fmt.Errorf("failed to open database: %w", err)
 	}
 
 	// Test the connection
-	if err := db.Ping(); err != nil {
-		db.Close()
+	if err := conn.Ping(); err != nil {
+		conn.Close()
 		return fmt.Errorf("failed to ping database: %w", err)
 	}
 
@@ -72,21 +103,14 @@ func (s *SQLiteDB) Connect() error {
 	}
 
 	for _, pragma := range pragmas {
-		if _, err := db.Exec(pragma); err != nil {
-			db.Close()
+		if _, err := conn.Exec(pragma); err != nil {
+			conn.Close()
 			return fmt.Errorf("failed to set pragma %q: %w", pragma, err)
 		}
 	}
 
-	s.db = db
-
-	// Run migrations
-	if err := runMigrations(db); err != nil {
-		db.Close()
-		s.db = nil
-		return fmt.Errorf("failed to run migrations: %w", err)
-	}
-
+	s.db = conn
+	db.LimitTransactions(conn, s.maxTransactions)
 	return nil
 }
 
@@ -96,11 +120,43 @@ func (s *SQLiteDB) Close() error {
 		return nil
 	}
 
+	db.LimitTransactions(s.db, 0)
 	err := s.db.Close()
 	s.db = nil
 	return err
 }
 
+// UsageBytes returns the on-disk size of the database, including its WAL and shared-memory files
+func (s *SQLiteDB) UsageBytes(ctx context.Context) (int64, error) {
+	var total int64
+	for _, path := range []string{s.dbPath, s.dbPath + "-wal", s.dbPath + "-shm"} {
+		info, err := os.Stat(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to stat database file: %w", err)
+		}
+		total += info.Size()
+	}
+
+	return total, nil
+}
+
+// Backup writes a consistent copy of the database to path, which must not exist
+// It can run while the database is in use; the copy holds everything committed when it began.
+func (s *SQLiteDB) Backup(ctx context.Context, path string) error {
+	if s.db == nil {
+		return fmt.Errorf("database not connected")
+	}
+
+	if _, err := s.db.ExecContext(ctx, "VACUUM INTO ?", path); err != nil {
+		return fmt.Errorf("failed to back up database: %w", err)
+	}
+
+	return nil
+}
+
 // DB returns the underlying *sql.DB instance
 func (s *SQLiteDB) DB() *sql.DB {
 	return s.db
@@ -1,6 +1,7 @@
 package sqlite
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"testing"
@@ -174,6 +175,45 @@ func TestSQLiteDB_BasicOperations(t *testing.T) {
 	}
 }
 
+func TestSQLiteDB_Backup(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	database := NewSQLiteDB(&SQLiteConfig{Path: filepath.Join(tmpDir, "test.db")})
+	if err := database.Connect(); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer database.Close()
+
+	if _, err := database.DB().Exec("CREATE TABLE test_table (name TEXT NOT NULL)"); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	if _, err := database.DB().Exec("INSERT INTO test_table (name) VALUES (?)", "test"); err != nil {
+		t.Fatalf("Failed to insert data: %v", err)
+	}
+
+	backupPath := filepath.Join(tmpDir, "backup.db")
+	if err := database.Backup(context.Background(), backupPath); err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+	if err := database.Backup(context.Background(), backupPath); err == nil {
+		t.Error("Backup() should return error when the file exists")
+	}
+
+	backup := NewSQLiteDB(&SQLiteConfig{Path: backupPath})
+	if err := backup.Connect(); err != nil {
+		t.Fatalf("Connect() to backup error = %v", err)
+	}
+	defer backup.Close()
+
+	var name string
+	if err := backup.DB().QueryRow("SELECT name FROM test_table").Scan(&name); err != nil {
+		t.Fatalf("Failed to query backup: %v", err)
+	}
+	if name != "test" {
+		t.Errorf("Expected name = 'test', got %q", name)
+	}
+}
+
 func TestSQLiteDB_InterfaceCompliance(t *testing.T) {
 	var _ db.Database = (*SQLiteDB)(nil)
 }
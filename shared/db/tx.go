@@ -4,6 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
 // txKey is the key type for storing transaction in context
@@ -33,10 +38,59 @@ func GetExecutor(ctx context.Context, db *sql.DB) interface {
 	return db
 }
 
+var (
+	transactionWait = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "goblog_db_transaction_wait_seconds",
+		Help:    "Time transactions waited for a slot before beginning.",
+		Buckets: []float64{.001, .005, .01, .05, .1, .5, 1, 5, 10},
+	})
+
+	transactionsWaiting = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "goblog_db_transactions_waiting",
+		Help: "Transactions waiting for a slot before beginning.",
+	})
+)
+
+// transactionLimits holds the semaphore of each database whose transactions are limited, see LimitTransactions
+var transactionLimits sync.Map
+
+// LimitTransactions bounds how many transactions RunInTransaction keeps open on db at the same time
+// SQLite allows one writer at a time, so queuing transactions here is cheaper than having them contend for
+// the database lock. n of zero or less removes the limit.
+func LimitTransactions(db *sql.DB, n int) {
+	if n <= 0 {
+		transactionLimits.Delete(db)
+		return
+	}
+	transactionLimits.Store(db, make(chan struct{}, n))
+}
+
+// acquireTransaction waits for a transaction slot on db and returns a function releasing it
+func acquireTransaction(ctx context.Context, db *sql.DB) (func(), error) {
+	limit, ok := transactionLimits.Load(db)
+	if !ok {
+		return func() {}, nil
+	}
+	slots := limit.(chan struct{})
+
+	start := time.Now()
+	transactionsWaiting.Inc()
+	defer transactionsWaiting.Dec()
+
+	select {
+	case slots <- struct{}{}:
+		transactionWait.Observe(time.Since(start).Seconds())
+		return func() { <-slots }, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("failed to begin transaction: %w", ctx.Err())
+	}
+}
+
 // RunInTransaction executes a function within a database transaction
 // If a transaction already exists in the context, it reuses that transaction
 // and does not commit or rollback (delegating that to the outer transaction)
 // If no transaction exists, it creates one, and commits or rolls back based on the result
+// New transactions wait for a slot if the database's transactions are limited.
 func RunInTransaction(ctx context.Context, db *sql.DB, fn func(ctx context.Context) error) error {
 	// Check if we're already in a transaction
 	if _, ok := GetTx(ctx); ok {
@@ -44,6 +98,12 @@ func RunInTransaction(ctx context.Context, db *sql.DB, fn func(ctx context.Conte
 		return fn(ctx)
 	}
 
+	release, err := acquireTransaction(ctx, db)
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	// Start a new transaction
 	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
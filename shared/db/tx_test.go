@@ -3,7 +3,11 @@ package db
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 )
@@ -210,3 +214,77 @@ func TestGetExecutor_WithoutTransaction(t *testing.T) {
 		t.Error("Expected executor to be the database")
 	}
 }
+
+func TestRunInTransaction_Limited(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	LimitTransactions(db, 2)
+	defer LimitTransactions(db, 0)
+
+	var running, most atomic.Int32
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			err := RunInTransaction(context.Background(), db, func(txCtx context.Context) error {
+				n := running.Add(1)
+				defer running.Add(-1)
+				for {
+					m := most.Load()
+					if n <= m || most.CompareAndSwap(m, n) {
+						break
+					}
+				}
+				time.Sleep(5 * time.Millisecond)
+				return nil
+			})
+			if err != nil {
+				t.Errorf("RunInTransaction failed: %v", err)
+			}
+		})
+	}
+	wg.Wait()
+
+	if most.Load() > 2 {
+		t.Errorf("%d transactions ran at once, want at most 2", most.Load())
+	}
+}
+
+func TestRunInTransaction_LimitedWaitCancelled(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	LimitTransactions(db, 1)
+	defer LimitTransactions(db, 0)
+
+	holding := make(chan struct{})
+	done := make(chan struct{})
+	go RunInTransaction(context.Background(), db, func(txCtx context.Context) error {
+		close(holding)
+		<-done
+		return nil
+	})
+	<-holding
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	called := false
+	err := RunInTransaction(ctx, db, func(txCtx context.Context) error {
+		called = true
+		return nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) || called {
+		t.Errorf("RunInTransaction() = %v, called = %v, want it to give up waiting", err, called)
+	}
+
+	// A nested transaction reuses its slot rather than waiting for another
+	close(done)
+	err = RunInTransaction(context.Background(), db, func(outerCtx context.Context) error {
+		return RunInTransaction(outerCtx, db, func(innerCtx context.Context) error {
+			return nil
+		})
+	})
+	if err != nil {
+		t.Errorf("Nested RunInTransaction failed: %v", err)
+	}
+}
@@ -0,0 +1,14 @@
+package env
+
+import "strings"
+
+// SplitList splits a comma separated setting, trimming spaces and dropping empty entries
+func SplitList(value string) []string {
+	var items []string
+	for item := range strings.SplitSeq(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
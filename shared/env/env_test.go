@@ -0,0 +1,24 @@
+package env
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSplitList(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []string
+	}{
+		{"", nil},
+		{" , ,", nil},
+		{"push", []string{"push"}},
+		{"push, release ,,create", []string{"push", "release", "create"}},
+	}
+
+	for _, tt := range tests {
+		if got := SplitList(tt.value); !slices.Equal(got, tt.want) {
+			t.Errorf("SplitList(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
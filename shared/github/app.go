@@ -0,0 +1,230 @@
+package github
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v75/github"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// appJWTLifetime is how long the JWTs requesting installation tokens are valid; GitHub allows at most 10 minutes
+	appJWTLifetime = 9 * time.Minute
+	// appJWTClockSkew backdates JWTs, so a server clock running slightly ahead of GitHub's isn't refused
+	appJWTClockSkew = time.Minute
+	// appTokenRenewBefore is how long before an installation token expires it is replaced
+	appTokenRenewBefore = 5 * time.Minute
+)
+
+// AppConfig authenticates as a GitHub App installation, as an alternative to a personal access token
+// Installation tokens last an hour and are renewed automatically.
+type AppConfig struct {
+	AppID int64
+	// PrivateKeyFile is the PEM private key generated for the app
+	PrivateKeyFile string
+	// InstallationID is the app's installation on the content repository, or 0 to look it up from the repository
+	InstallationID int64
+}
+
+func NewAppConfig() *AppConfig {
+	appID, _ := strconv.ParseInt(os.Getenv("GITHUB_APP_ID"), 10, 64)
+	installationID, _ := strconv.ParseInt(os.Getenv("GITHUB_APP_INSTALLATION_ID"), 10, 64)
+
+	return &AppConfig{
+		AppID:          appID,
+		PrivateKeyFile: os.Getenv("GITHUB_APP_PRIVATE_KEY_FILE"),
+		InstallationID: installationID,
+	}
+}
+
+// Enabled reports whether an app is configured
+func (c *AppConfig) Enabled() bool {
+	return c.AppID != 0
+}
+
+// AppTransport authenticates requests as a GitHub App installation on a repository
+// The installation token is created on first use and replaced shortly before it expires, or as soon as
+// GitHub refuses it, so clients using the transport never need to handle renewal.
+type AppTransport struct {
+	base           http.RoundTripper
+	appID          int64
+	key            *rsa.PrivateKey
+	installationID int64
+	owner          string
+	gitRepo        string
+	// apiURL overrides the GitHub API URL tokens are requested from, for tests
+	apiURL string
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// NewAppTransport reads the app's private key and returns a transport authenticating through base
+// A nil base uses http.DefaultTransport.
+func NewAppTransport(cfg *AppConfig, owner string, gitRepo string, base http.RoundTripper) (*AppTransport, error) {
+	if cfg.PrivateKeyFile == "" {
+		return nil, errors.New("GITHUB_APP_PRIVATE_KEY_FILE must be set with GITHUB_APP_ID")
+	}
+	data, err := os.ReadFile(cfg.PrivateKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read app private key: %w", err)
+	}
+	key, err := parseAppPrivateKey(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &AppTransport{
+		base:           base,
+		appID:          cfg.AppID,
+		key:            key,
+		installationID: cfg.InstallationID,
+		owner:          owner,
+		gitRepo:        gitRepo,
+	}, nil
+}
+
+// NewAppClient returns a client authenticated as the app's installation on the repository
+func NewAppClient(cfg *AppConfig, owner string, gitRepo string) (*github.Client, error) {
+	transport, err := NewAppTransport(cfg, owner, gitRepo, nil)
+	if err != nil {
+		return nil, err
+	}
+	return github.NewClient(&http.Client{Transport: transport}), nil
+}
+
+// parseAppPrivateKey reads an RSA key in the PKCS #1 form GitHub generates, or in PKCS #8
+func parseAppPrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("app private key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse app private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("app private key is not an RSA key")
+	}
+	return key, nil
+}
+
+func (t *AppTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.installationToken(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	// A RoundTripper must not modify the request it is given
+	authed := req.Clone(req.Context())
+	authed.Header.Set("Authorization", "Bearer "+token)
+	resp, err := t.base.RoundTrip(authed)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized {
+		t.expire(token)
+	}
+	return resp, err
+}
+
+// installationToken returns the current installation token, creating a new one when it is missing or about to expire
+// Requests wait for a renewal in progress rather than each creating a token.
+func (t *AppTransport) installationToken(ctx context.Context) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.token != "" && time.Until(t.expiresAt) > appTokenRenewBefore {
+		return t.token, nil
+	}
+
+	apps, err := t.appClient()
+	if err != nil {
+		return "", err
+	}
+	if t.installationID == 0 {
+		installation, _, err := apps.Apps.FindRepositoryInstallation(ctx, t.owner, t.gitRepo)
+		if err != nil {
+			return "", handleGithubError(fmt.Sprintf("finding app installation on %s/%s", t.owner, t.gitRepo), err)
+		}
+		t.installationID = installation.GetID()
+	}
+
+	token, _, err := apps.Apps.CreateInstallationToken(ctx, t.installationID, nil)
+	if err != nil {
+		return "", handleGithubError(fmt.Sprintf("creating token for app installation %d", t.installationID), err)
+	}
+	t.token = token.GetToken()
+	t.expiresAt = token.GetExpiresAt().Time
+	log.Debug().Int64("installation", t.installationID).Time("expiresAt", t.expiresAt).Msg("Renewed GitHub App installation token")
+	return t.token, nil
+}
+
+// expire drops a token GitHub refused, unless it has already been replaced
+func (t *AppTransport) expire(token string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.token == token {
+		t.token = ""
+	}
+}
+
+// appClient returns a client authenticated as the app itself, which may only manage its installations
+func (t *AppTransport) appClient() (*github.Client, error) {
+	jwt, err := t.appJWT(time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	client := github.NewClient(&http.Client{Transport: t.base}).WithAuthToken(jwt)
+	if t.apiURL != "" {
+		baseURL, err := url.Parse(t.apiURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid API URL: %w", err)
+		}
+		client.BaseURL = baseURL
+	}
+	return client, nil
+}
+
+// appJWT signs the short-lived RS256 JWT that identifies the app
+func (t *AppTransport) appJWT(now time.Time) (string, error) {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims, err := json.Marshal(map[string]any{
+		"iat": now.Add(-appJWTClockSkew).Unix(),
+		"exp": now.Add(appJWTLifetime).Unix(),
+		"iss": strconv.FormatInt(t.appID, 10),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	signed := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, t.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign app JWT: %w", err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
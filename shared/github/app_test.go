@@ -0,0 +1,128 @@
+package github
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v75/github"
+)
+
+// verifyAppJWT checks a bearer JWT was signed by key for the app, returning its issuer
+func verifyAppJWT(t *testing.T, key *rsa.PrivateKey, authorization string) string {
+	t.Helper()
+	parts := strings.Split(strings.TrimPrefix(authorization, "Bearer "), ".")
+	if len(parts) != 3 {
+		t.Fatalf("Authorization = %q, want a bearer JWT", authorization)
+	}
+	signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+		t.Fatalf("JWT signature invalid: %v", err)
+	}
+
+	payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	var claims struct {
+		Issuer string `json:"iss"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		t.Fatalf("JWT claims invalid: %v", err)
+	}
+	return claims.Issuer
+}
+
+func TestAppTransport(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "app.pem")
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err := os.WriteFile(keyFile, keyPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first token is close enough to expiring to be renewed on the next request
+	var tokens atomic.Int32
+	lifetimes := []time.Duration{time.Minute, time.Hour, time.Hour}
+	var unauthorized atomic.Bool
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repos/owner/repo/installation", func(w http.ResponseWriter, r *http.Request) {
+		if iss := verifyAppJWT(t, key, r.Header.Get("Authorization")); iss != "123" {
+			t.Errorf("JWT issuer = %q, want the app ID", iss)
+		}
+		w.Write([]byte(`{"id":42}`))
+	})
+	mux.HandleFunc("POST /app/installations/42/access_tokens", func(w http.ResponseWriter, r *http.Request) {
+		verifyAppJWT(t, key, r.Header.Get("Authorization"))
+		n := tokens.Add(1)
+		expiresAt := time.Now().Add(lifetimes[n-1]).UTC().Format(time.RFC3339)
+		fmt.Fprintf(w, `{"token":"token-%d","expires_at":%q}`, n, expiresAt)
+	})
+	mux.HandleFunc("GET /repos/owner/repo", func(w http.ResponseWriter, r *http.Request) {
+		if unauthorized.CompareAndSwap(true, false) {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"message":"Bad credentials"}`))
+			return
+		}
+		w.Write([]byte(`{"default_branch":"` + strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ") + `"}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	transport, err := NewAppTransport(&AppConfig{AppID: 123, PrivateKeyFile: keyFile}, "owner", "repo", srv.Client().Transport)
+	if err != nil {
+		t.Fatalf("NewAppTransport() error = %v", err)
+	}
+	transport.apiURL = srv.URL + "/"
+	client := github.NewClient(&http.Client{Transport: transport})
+	client.BaseURL, _ = url.Parse(srv.URL + "/")
+	repo := NewGithubSourceRepository(client, "owner", "repo", WithRetry(testRetryConfig()))
+
+	for i, want := range []string{"token-1", "token-2", "token-2"} {
+		branch, err := repo.GetDefaultBranchName(t.Context())
+		if err != nil {
+			t.Fatalf("GetDefaultBranchName() error = %v", err)
+		}
+		if branch != want {
+			t.Errorf("request %d authenticated with %q, want %q", i+1, branch, want)
+		}
+	}
+
+	// A refused token is replaced by the next request
+	unauthorized.Store(true)
+	if _, _, err := client.Repositories.Get(t.Context(), "owner", "repo"); err == nil {
+		t.Fatal("Expected the refused request to fail")
+	}
+	if branch, err := repo.GetDefaultBranchName(t.Context()); err != nil || branch != "token-3" {
+		t.Errorf("GetDefaultBranchName() = %q, %v, want a new token", branch, err)
+	}
+}
+
+func TestNewAppTransport_InvalidKey(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "app.pem")
+	if err := os.WriteFile(keyFile, []byte("not a key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewAppTransport(&AppConfig{AppID: 123, PrivateKeyFile: keyFile}, "owner", "repo", nil); err == nil {
+		t.Error("Expected a key that is not PEM encoded to be refused")
+	}
+	if _, err := NewAppTransport(&AppConfig{AppID: 123}, "owner", "repo", nil); err == nil {
+		t.Error("Expected a missing key file to be refused")
+	}
+}
@@ -0,0 +1,60 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-github/v75/github"
+)
+
+// isTooLargeError reports whether the contents API refused a file for being too large to return
+func isTooLargeError(err error) bool {
+	var errResp *github.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.Response == nil || errResp.Response.StatusCode != http.StatusForbidden {
+		return false
+	}
+
+	for _, e := range errResp.Errors {
+		if e.Code == "too_large" {
+			return true
+		}
+	}
+	return false
+}
+
+// getBlobContents downloads a blob by SHA through the Git blobs API, which has no 1MB limit
+func (g *GithubSourceRepository) getBlobContents(ctx context.Context, path string, sha string) ([]byte, error) {
+	op := fmt.Sprintf("getting blob %s for file %s", sha, path)
+	content, _, err := withRetry(ctx, g.retry, g.rate, op, func() ([]byte, *github.Response, error) {
+		return g.client.Git.GetBlobRaw(ctx, g.owner, g.gitRepo, sha)
+	})
+	if err != nil {
+		return nil, handleGithubError(op, err)
+	}
+	return content, nil
+}
+
+// getLargeFileContents finds a file's blob SHA in the tree at ref and downloads the blob
+// This is used when the contents API will not even describe the file
+func (g *GithubSourceRepository) getLargeFileContents(ctx context.Context, path string, ref string) ([]byte, error) {
+	op := fmt.Sprintf("getting tree at ref %s", ref)
+	tree, _, err := withRetry(ctx, g.retry, g.rate, op, func() (*github.Tree, *github.Response, error) {
+		return g.client.Git.GetTree(ctx, g.owner, g.gitRepo, ref, true)
+	})
+	if err != nil {
+		return nil, handleGithubError(op, err)
+	}
+
+	for _, entry := range tree.Entries {
+		if entry.GetPath() == path && entry.GetType() == "blob" {
+			return g.getBlobContents(ctx, path, entry.GetSHA())
+		}
+	}
+
+	if tree.GetTruncated() {
+		return nil, fmt.Errorf("github: file %s not found in truncated tree at ref %s", path, ref)
+	}
+	return nil, fmt.Errorf("github: file %s not found in tree at ref %s", path, ref)
+}
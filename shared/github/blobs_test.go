@@ -0,0 +1,99 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/google/go-github/v75/github"
+)
+
+func newTestRepository(t *testing.T, mux *http.ServeMux) *GithubSourceRepository {
+	t.Helper()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	client := github.NewClient(srv.Client())
+	client.BaseURL, _ = url.Parse(srv.URL + "/")
+
+	return NewGithubSourceRepository(client, "owner", "repo", WithRetry(testRetryConfig())).(*GithubSourceRepository)
+}
+
+func TestGetFileContents_LargeFileUsesBlob(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/owner/repo/contents/images/big.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"type":"file","encoding":"none","content":"","sha":"blobsha","path":"images/big.png"}`))
+	})
+	mux.HandleFunc("/repos/owner/repo/git/blobs/blobsha", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("large content"))
+	})
+
+	repo := newTestRepository(t, mux)
+	content, err := repo.GetFileContents(context.Background(), "images/big.png", "main")
+	if err != nil {
+		t.Fatalf("GetFileContents failed: %v", err)
+	}
+	if string(content) != "large content" {
+		t.Errorf("content = %q, want %q", content, "large content")
+	}
+}
+
+func TestGetFileContents_TooLargeUsesTree(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/owner/repo/contents/images/huge.png", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"message":"This API returns blobs up to 100 MB in size.","errors":[{"resource":"Blob","field":"data","code":"too_large"}]}`))
+	})
+	mux.HandleFunc("/repos/owner/repo/git/trees/main", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"sha":"treesha","tree":[{"path":"images/huge.png","type":"blob","sha":"hugesha"}],"truncated":false}`))
+	})
+	mux.HandleFunc("/repos/owner/repo/git/blobs/hugesha", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("huge content"))
+	})
+
+	repo := newTestRepository(t, mux)
+	content, err := repo.GetFileContents(context.Background(), "images/huge.png", "main")
+	if err != nil {
+		t.Fatalf("GetFileContents failed: %v", err)
+	}
+	if string(content) != "huge content" {
+		t.Errorf("content = %q, want %q", content, "huge content")
+	}
+}
+
+func TestGetFileContents_NotFound(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/owner/repo/contents/posts/001-gone.md", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message":"Not Found"}`))
+	})
+
+	repo := newTestRepository(t, mux)
+	_, err := repo.GetFileContents(context.Background(), "posts/001-gone.md", "main")
+	if !errors.Is(err, domain.ErrSourceFileNotFound) {
+		t.Errorf("Expected ErrSourceFileNotFound, got %v", err)
+	}
+}
+
+func TestGetTree(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/owner/repo/git/trees/abc123", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("recursive") == "" {
+			t.Error("Expected the tree to be fetched recursively")
+		}
+		w.Write([]byte(`{"sha":"treesha","tree":[{"path":"posts","type":"tree","sha":"dirsha"},{"path":"posts/001-a.md","type":"blob","sha":"postsha"}],"truncated":false}`))
+	})
+
+	repo := newTestRepository(t, mux)
+	tree, err := repo.GetTree(context.Background(), "abc123")
+	if err != nil {
+		t.Fatalf("GetTree failed: %v", err)
+	}
+	if len(tree.Entries) != 2 || tree.Entries[1].GetPath() != "posts/001-a.md" || tree.Entries[1].GetSHA() != "postsha" {
+		t.Errorf("Unexpected tree entries: %v", tree.Entries)
+	}
+}
@@ -0,0 +1,78 @@
+package github
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v75/github"
+)
+
+// samplePost is committed to new content repositories so the first sync has something to render.
+const samplePost = `# Hello, world
+
+This is the first post on the blog. Posts live in ` + "`posts/`" + ` and are named
+` + "`NNN-title.md`" + `, where ` + "`NNN`" + ` is the post's ID. Images go in ` + "`images/`" + ` and can
+be linked relatively, e.g. ` + "`![a photo](../images/photo.png)`" + `.
+
+Pushing to the default branch publishes a post. Other branches are rendered as
+unpublished previews.
+`
+
+// starterFiles are created, in order, in every new content repository.
+var starterFiles = []struct {
+	path    string
+	content string
+}{
+	{path: "posts/001-hello-world.md", content: samplePost},
+	// Git does not track empty directories
+	{path: "images/.gitkeep", content: ""},
+}
+
+// BootstrapConfig describes the content repository created by BootstrapRepository.
+type BootstrapConfig struct {
+	// Org is the organization that owns the repository; empty creates it for the authenticated user.
+	Org     string
+	Name    string
+	Private bool
+	// WebhookURL is the server's push webhook endpoint.
+	WebhookURL string
+	// WebhookSecret signs webhook deliveries and must match the server's WEBHOOK_SECRET.
+	WebhookSecret string
+}
+
+// BootstrapRepository creates a content repository with the layout goblog expects and a push webhook.
+func BootstrapRepository(ctx context.Context, client *github.Client, cfg *BootstrapConfig) (*github.Repository, error) {
+	if cfg.Name == "" {
+		return nil, fmt.Errorf("repository name cannot be empty")
+	}
+	if cfg.WebhookURL == "" || cfg.WebhookSecret == "" {
+		return nil, fmt.Errorf("webhook URL and secret are required")
+	}
+
+	repo, _, err := client.Repositories.Create(ctx, cfg.Org, &github.Repository{
+		Name:        github.Ptr(cfg.Name),
+		Description: github.Ptr("Blog posts published by goblog"),
+		Private:     github.Ptr(cfg.Private),
+	})
+	if err != nil {
+		return nil, handleGithubError("create repository", err)
+	}
+
+	owner := repo.GetOwner().GetLogin()
+	for _, f := range starterFiles {
+		_, _, err := client.Repositories.CreateFile(ctx, owner, repo.GetName(), f.path, &github.RepositoryContentFileOptions{
+			Message: github.Ptr("Add " + f.path),
+			Content: []byte(f.content),
+		})
+		if err != nil {
+			return nil, handleGithubError("create "+f.path, err)
+		}
+	}
+
+	_, _, err = client.Repositories.CreateHook(ctx, owner, repo.GetName(), pushHook(cfg.WebhookURL, cfg.WebhookSecret))
+	if err != nil {
+		return nil, handleGithubError("create webhook", err)
+	}
+
+	return repo, nil
+}
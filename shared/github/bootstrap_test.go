@@ -0,0 +1,71 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"testing"
+
+	"github.com/google/go-github/v75/github"
+)
+
+func TestBootstrapRepository(t *testing.T) {
+	var created []string
+	var hook github.Hook
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /user/repos", func(w http.ResponseWriter, r *http.Request) {
+		var repo github.Repository
+		json.NewDecoder(r.Body).Decode(&repo)
+		if !repo.GetPrivate() {
+			t.Error("Expected a private repository")
+		}
+		json.NewEncoder(w).Encode(&github.Repository{
+			Name:  repo.Name,
+			Owner: &github.User{Login: github.Ptr("owner")},
+		})
+	})
+	mux.HandleFunc("PUT /repos/owner/content/contents/{path...}", func(w http.ResponseWriter, r *http.Request) {
+		created = append(created, r.PathValue("path"))
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("{}"))
+	})
+	mux.HandleFunc("POST /repos/owner/content/hooks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&hook)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("{}"))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(srv.URL + "/")
+
+	repo, err := BootstrapRepository(context.Background(), client, &BootstrapConfig{
+		Name:          "content",
+		Private:       true,
+		WebhookURL:    "https://blog.example.com/webhook/git",
+		WebhookSecret: "secret",
+	})
+	if err != nil {
+		t.Fatalf("BootstrapRepository failed: %v", err)
+	}
+	if repo.GetName() != "content" {
+		t.Errorf("repo name = %q, want %q", repo.GetName(), "content")
+	}
+
+	want := []string{"posts/001-hello-world.md", "images/.gitkeep"}
+	if !slices.Equal(created, want) {
+		t.Errorf("created files = %v, want %v", created, want)
+	}
+
+	if !slices.Equal(hook.Events, []string{"push", "create", "release", "pull_request"}) {
+		t.Errorf("hook events = %v, want [push create release]", hook.Events)
+	}
+	if hook.Config.GetURL() != "https://blog.example.com/webhook/git" || hook.Config.GetSecret() != "secret" || hook.Config.GetContentType() != "json" {
+		t.Errorf("unexpected hook config: %+v", hook.Config)
+	}
+}
@@ -0,0 +1,87 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/google/go-github/v75/github"
+)
+
+// maxAnnotationsPerRequest is how many annotations GitHub accepts in one check run request
+const maxAnnotationsPerRequest = 50
+
+// CheckReporter is an implementation of domain.CheckReporter that uses the GitHub Checks API.
+// Only GitHub Apps may create check runs, so the client must authenticate as an app installation
+// with write access to checks.
+type CheckReporter struct {
+	client  *github.Client
+	owner   string
+	gitRepo string
+}
+
+// NewCheckReporter creates a CheckReporter for the commits of owner/gitRepo.
+func NewCheckReporter(client *github.Client, owner string, gitRepo string) domain.CheckReporter {
+	return &CheckReporter{
+		client:  client,
+		owner:   owner,
+		gitRepo: gitRepo,
+	}
+}
+
+// ReportCheck creates a completed check run on the run's head commit.
+// Annotations beyond the first request's limit are added by updating the run in batches.
+func (c *CheckReporter) ReportCheck(ctx context.Context, run *domain.CheckRun) error {
+	op := fmt.Sprintf("create check run %s on %s", run.Name, run.HeadSHA)
+	first, rest := splitAnnotations(run.Annotations)
+	created, _, err := c.client.Checks.CreateCheckRun(ctx, c.owner, c.gitRepo, github.CreateCheckRunOptions{
+		Name:        run.Name,
+		HeadSHA:     run.HeadSHA,
+		Status:      github.Ptr("completed"),
+		Conclusion:  github.Ptr(string(run.Conclusion)),
+		CompletedAt: &github.Timestamp{Time: time.Now()},
+		Output:      checkRunOutput(run, first),
+	})
+	if err != nil {
+		return handleGithubError(op, err)
+	}
+
+	for len(rest) > 0 {
+		var batch []domain.CheckAnnotation
+		batch, rest = splitAnnotations(rest)
+		op := fmt.Sprintf("annotate check run %d on %s", created.GetID(), run.HeadSHA)
+		if _, _, err := c.client.Checks.UpdateCheckRun(ctx, c.owner, c.gitRepo, created.GetID(), github.UpdateCheckRunOptions{
+			Name:   run.Name,
+			Output: checkRunOutput(run, batch),
+		}); err != nil {
+			return handleGithubError(op, err)
+		}
+	}
+
+	return nil
+}
+
+// splitAnnotations returns the annotations that fit in one request, and the rest
+func splitAnnotations(annotations []domain.CheckAnnotation) ([]domain.CheckAnnotation, []domain.CheckAnnotation) {
+	n := min(len(annotations), maxAnnotationsPerRequest)
+	return annotations[:n], annotations[n:]
+}
+
+// checkRunOutput is the output of a check run carrying annotations
+func checkRunOutput(run *domain.CheckRun, annotations []domain.CheckAnnotation) *github.CheckRunOutput {
+	output := &github.CheckRunOutput{
+		Title:   github.Ptr(run.Title),
+		Summary: github.Ptr(run.Summary),
+	}
+	for _, annotation := range annotations {
+		output.Annotations = append(output.Annotations, &github.CheckRunAnnotation{
+			Path:            github.Ptr(annotation.Path),
+			StartLine:       github.Ptr(annotation.Line),
+			EndLine:         github.Ptr(annotation.Line),
+			AnnotationLevel: github.Ptr(string(annotation.Level)),
+			Message:         github.Ptr(annotation.Message),
+		})
+	}
+	return output
+}
@@ -0,0 +1,62 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/google/go-github/v75/github"
+)
+
+func TestCheckReporter_ReportCheck(t *testing.T) {
+	var created github.CreateCheckRunOptions
+	var updates []github.UpdateCheckRunOptions
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /repos/owner/repo/check-runs", func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&created)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id": 9}`))
+	})
+	mux.HandleFunc("PATCH /repos/owner/repo/check-runs/9", func(w http.ResponseWriter, r *http.Request) {
+		var update github.UpdateCheckRunOptions
+		json.NewDecoder(r.Body).Decode(&update)
+		updates = append(updates, update)
+		w.Write([]byte(`{"id": 9}`))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(srv.URL + "/")
+
+	run := &domain.CheckRun{Name: "goblog/front-matter", HeadSHA: "abc123", Conclusion: domain.CheckFailure, Title: "1 invalid post"}
+	for i := range 120 {
+		run.Annotations = append(run.Annotations, domain.CheckAnnotation{
+			Path:    "posts/001-post.md",
+			Line:    i + 2,
+			Level:   domain.CheckAnnotationFailure,
+			Message: fmt.Sprintf("problem %d", i),
+		})
+	}
+
+	if err := NewCheckReporter(client, "owner", "repo").ReportCheck(t.Context(), run); err != nil {
+		t.Fatalf("ReportCheck() error = %v", err)
+	}
+
+	if created.HeadSHA != "abc123" || created.GetConclusion() != "failure" || created.GetStatus() != "completed" {
+		t.Errorf("created check run = %+v", created)
+	}
+	if got := len(created.Output.Annotations); got != 50 {
+		t.Errorf("created with %d annotations, want 50", got)
+	}
+	if len(updates) != 2 || len(updates[0].Output.Annotations) != 50 || len(updates[1].Output.Annotations) != 20 {
+		t.Fatalf("updates = %d, want batches of 50 and 20", len(updates))
+	}
+	if a := updates[1].Output.Annotations[19]; a.GetStartLine() != 121 || a.GetMessage() != "problem 119" || a.GetAnnotationLevel() != "failure" {
+		t.Errorf("last annotation = %+v", a)
+	}
+}
@@ -0,0 +1,74 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dfryer1193/goblog/blog/domain"
+	"github.com/google/go-github/v75/github"
+)
+
+// PullRequestCommenter is an implementation of domain.PullRequestCommenter that uses the GitHub API.
+// The client's token needs write access to the repository's pull requests.
+type PullRequestCommenter struct {
+	client  *github.Client
+	owner   string
+	gitRepo string
+}
+
+// NewPullRequestCommenter creates a PullRequestCommenter for the pull requests of owner/gitRepo.
+func NewPullRequestCommenter(client *github.Client, owner string, gitRepo string) domain.PullRequestCommenter {
+	return &PullRequestCommenter{
+		client:  client,
+		owner:   owner,
+		gitRepo: gitRepo,
+	}
+}
+
+// UpsertComment edits the pull request comment containing marker, or creates one if there is none.
+// Editing keeps a single comment up to date as a pull request changes, rather than adding one per push.
+func (c *PullRequestCommenter) UpsertComment(ctx context.Context, number int, marker string, body string) error {
+	existing, err := c.findComment(ctx, number, marker)
+	if err != nil {
+		return err
+	}
+
+	comment := &github.IssueComment{Body: github.Ptr(body)}
+	if existing == nil {
+		op := fmt.Sprintf("create comment on pull request #%d", number)
+		if _, _, err := c.client.Issues.CreateComment(ctx, c.owner, c.gitRepo, number, comment); err != nil {
+			return handleGithubError(op, err)
+		}
+		return nil
+	}
+
+	op := fmt.Sprintf("edit comment %d on pull request #%d", existing.GetID(), number)
+	if _, _, err := c.client.Issues.EditComment(ctx, c.owner, c.gitRepo, existing.GetID(), comment); err != nil {
+		return handleGithubError(op, err)
+	}
+	return nil
+}
+
+// findComment returns the first comment on a pull request containing marker, or nil if there is none.
+func (c *PullRequestCommenter) findComment(ctx context.Context, number int, marker string) (*github.IssueComment, error) {
+	op := fmt.Sprintf("list comments on pull request #%d", number)
+	opts := &github.IssueListCommentsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		comments, resp, err := c.client.Issues.ListComments(ctx, c.owner, c.gitRepo, number, opts)
+		if err != nil {
+			return nil, handleGithubError(op, err)
+		}
+
+		for _, comment := range comments {
+			if strings.Contains(comment.GetBody(), marker) {
+				return comment, nil
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return nil, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
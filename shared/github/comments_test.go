@@ -0,0 +1,70 @@
+package github
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-github/v75/github"
+)
+
+func TestPullRequestCommenter_UpsertComment(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing []*github.IssueComment
+		wantCall string
+	}{
+		{
+			name:     "creates a comment",
+			existing: []*github.IssueComment{{ID: github.Ptr(int64(1)), Body: github.Ptr("Looks good")}},
+			wantCall: "create 7",
+		},
+		{
+			name: "edits the marked comment",
+			existing: []*github.IssueComment{
+				{ID: github.Ptr(int64(1)), Body: github.Ptr("Looks good")},
+				{ID: github.Ptr(int64(2)), Body: github.Ptr("<!-- marker -->\nOld previews")},
+			},
+			wantCall: "edit 2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /repos/owner/repo/issues/7/comments", func(w http.ResponseWriter, r *http.Request) {
+				json.NewEncoder(w).Encode(tt.existing)
+			})
+			mux.HandleFunc("POST /repos/owner/repo/issues/{number}/comments", func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, "create "+r.PathValue("number"))
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte("{}"))
+			})
+			mux.HandleFunc("PATCH /repos/owner/repo/issues/comments/{id}", func(w http.ResponseWriter, r *http.Request) {
+				var comment github.IssueComment
+				json.NewDecoder(r.Body).Decode(&comment)
+				if comment.GetBody() != "<!-- marker -->\nNew previews" {
+					t.Errorf("edited body = %q", comment.GetBody())
+				}
+				calls = append(calls, "edit "+r.PathValue("id"))
+				w.Write([]byte("{}"))
+			})
+			srv := httptest.NewServer(mux)
+			t.Cleanup(srv.Close)
+
+			client := github.NewClient(nil)
+			client.BaseURL, _ = url.Parse(srv.URL + "/")
+
+			commenter := NewPullRequestCommenter(client, "owner", "repo")
+			if err := commenter.UpsertComment(t.Context(), 7, "<!-- marker -->", "<!-- marker -->\nNew previews"); err != nil {
+				t.Fatalf("UpsertComment() error = %v", err)
+			}
+			if len(calls) != 1 || calls[0] != tt.wantCall {
+				t.Errorf("calls = %v, want [%s]", calls, tt.wantCall)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package github
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/google/go-github/v75/github"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	// maxConditionalEntries bounds the responses kept to revalidate with If-None-Match
+	maxConditionalEntries = 256
+	// maxConditionalBody is the largest response body kept; larger ones are always fetched in full
+	maxConditionalBody = 1 << 20
+)
+
+var notModifiedResponses = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "goblog_github_not_modified_total",
+	Help: "GitHub API reads answered 304 Not Modified and served from the last response.",
+})
+
+// WithConditionalRequests revalidates repeated API reads with the ETag of the last response
+// GitHub answers a resource that hasn't changed with 304 Not Modified, which does not count against the rate limit,
+// so polling branches or refetching a file at a branch during a retried webhook costs nothing when nothing moved.
+func WithConditionalRequests() Option {
+	return func(g *GithubSourceRepository) {
+		httpClient := g.client.Client()
+		httpClient.Transport = &conditionalTransport{
+			base:    transportOrDefault(httpClient.Transport),
+			host:    g.client.BaseURL.Host,
+			entries: make(map[string]conditionalEntry),
+		}
+
+		client := github.NewClient(httpClient)
+		client.BaseURL = g.client.BaseURL
+		client.UploadURL = g.client.UploadURL
+		g.client = client
+	}
+}
+
+// transportOrDefault returns transport, or http.DefaultTransport when it is nil as http.Client would
+func transportOrDefault(transport http.RoundTripper) http.RoundTripper {
+	if transport == nil {
+		return http.DefaultTransport
+	}
+	return transport
+}
+
+// conditionalTransport remembers the ETag and body of API reads, and serves the body again when GitHub says it is unchanged
+// Only requests to the API host are revalidated; the raw content fetcher manages its own ETags.
+type conditionalTransport struct {
+	base http.RoundTripper
+	host string
+
+	mu      sync.Mutex
+	entries map[string]conditionalEntry
+	// order holds the keys of entries from oldest to newest, for eviction
+	order []string
+}
+
+type conditionalEntry struct {
+	etag string
+	body []byte
+}
+
+func (t *conditionalTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.URL.Host != t.host || req.Header.Get("If-None-Match") != "" {
+		return t.base.RoundTrip(req)
+	}
+
+	// The media type picks the representation, so it is part of the key
+	key := req.Header.Get("Accept") + " " + req.URL.String()
+	entry, ok := t.get(key)
+	if ok {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", entry.etag)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if ok && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		notModifiedResponses.Inc()
+		// The 304 carries the current rate limit headers, which are kept
+		resp.StatusCode = http.StatusOK
+		resp.Status = "200 OK"
+		resp.Body = io.NopCloser(bytes.NewReader(entry.body))
+		resp.ContentLength = int64(len(entry.body))
+		return resp, nil
+	}
+
+	etag := resp.Header.Get("ETag")
+	if resp.StatusCode != http.StatusOK || etag == "" || resp.ContentLength > maxConditionalBody {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxConditionalBody+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if len(body) > maxConditionalBody {
+		// Too large to keep, so the rest is streamed after what was read
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return resp, nil
+	}
+
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	t.put(key, conditionalEntry{etag: etag, body: body})
+	return resp, nil
+}
+
+func (t *conditionalTransport) get(key string) (conditionalEntry, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry, ok := t.entries[key]
+	return entry, ok
+}
+
+// put stores the entry for key, evicting the oldest entry once the transport holds maxConditionalEntries
+func (t *conditionalTransport) put(key string, entry conditionalEntry) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.entries[key]; !ok {
+		t.order = append(t.order, key)
+		if len(t.order) > maxConditionalEntries {
+			delete(t.entries, t.order[0])
+			t.order = t.order[1:]
+		}
+	}
+	t.entries[key] = entry
+}
@@ -0,0 +1,61 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-github/v75/github"
+)
+
+func TestWithConditionalRequests(t *testing.T) {
+	requests, notModified := 0, 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/owner/repo/branches", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("X-RateLimit-Remaining", "4999")
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`[{"name":"main"}]`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client := github.NewClient(srv.Client())
+	client.BaseURL, _ = url.Parse(srv.URL + "/")
+	repo := NewGithubSourceRepository(client, "owner", "repo", WithRetry(testRetryConfig()), WithConditionalRequests())
+
+	for i := 0; i < 3; i++ {
+		branches, err := repo.ListBranches(context.Background())
+		if err != nil {
+			t.Fatalf("ListBranches %d failed: %v", i, err)
+		}
+		if len(branches) != 1 || branches[0].GetName() != "main" {
+			t.Errorf("ListBranches %d = %v, want the main branch", i, branches)
+		}
+	}
+
+	if requests != 3 || notModified != 2 {
+		t.Errorf("requests = %d, not modified = %d, want 3 requests revalidated after the first", requests, notModified)
+	}
+}
+
+func TestConditionalTransport_Eviction(t *testing.T) {
+	transport := &conditionalTransport{entries: make(map[string]conditionalEntry)}
+	for i := 0; i <= maxConditionalEntries; i++ {
+		transport.put(string(rune('a'+i)), conditionalEntry{etag: "v1"})
+	}
+
+	if len(transport.entries) != maxConditionalEntries {
+		t.Errorf("entries = %d, want %d", len(transport.entries), maxConditionalEntries)
+	}
+	if _, ok := transport.get("a"); ok {
+		t.Error("Expected the oldest entry to be evicted")
+	}
+}
@@ -0,0 +1,140 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v75/github"
+)
+
+const (
+	defaultLFSMediaURL = "https://media.githubusercontent.com/media/"
+
+	// lfsPointerVersion is the first line of every Git LFS pointer file
+	lfsPointerVersion = "version https://git-lfs.github.com/spec/v1"
+	// maxLFSPointerSize is the largest file read as a pointer; git-lfs itself never writes them larger
+	maxLFSPointerSize = 1024
+)
+
+// LFSConfig controls resolving Git LFS pointer files to the objects they stand for
+// Files tracked by LFS are stored in Git as short text pointers, which every GitHub API returns in place of the file.
+type LFSConfig struct {
+	Enabled bool
+	// MediaURL is the host serving LFS objects by path, overridable for GitHub Enterprise
+	MediaURL string
+}
+
+func NewLFSConfig() *LFSConfig {
+	mediaURL := os.Getenv("GITHUB_LFS_MEDIA_URL")
+	if mediaURL == "" {
+		mediaURL = defaultLFSMediaURL
+	}
+	if !strings.HasSuffix(mediaURL, "/") {
+		mediaURL += "/"
+	}
+
+	return &LFSConfig{
+		Enabled:  os.Getenv("GITHUB_LFS") != "false",
+		MediaURL: mediaURL,
+	}
+}
+
+// WithLFS downloads the LFS object when a fetched file turns out to be an LFS pointer
+func WithLFS(cfg *LFSConfig) Option {
+	return func(g *GithubSourceRepository) {
+		if cfg.Enabled {
+			g.lfsMediaURL = cfg.MediaURL
+		}
+	}
+}
+
+// lfsPointer identifies an LFS object by the SHA-256 of its content and its size
+type lfsPointer struct {
+	oid  string
+	size int64
+}
+
+// parseLFSPointer reads content as an LFS pointer, returning false if it is not one
+func parseLFSPointer(content []byte) (lfsPointer, bool) {
+	if len(content) > maxLFSPointerSize || !bytes.HasPrefix(content, []byte(lfsPointerVersion+"\n")) {
+		return lfsPointer{}, false
+	}
+
+	var pointer lfsPointer
+	for _, line := range strings.Split(string(content), "\n") {
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "oid":
+			oid, ok := strings.CutPrefix(value, "sha256:")
+			if !ok || len(oid) != sha256.Size*2 {
+				return lfsPointer{}, false
+			}
+			pointer.oid = oid
+		case "size":
+			size, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || size < 0 {
+				return lfsPointer{}, false
+			}
+			pointer.size = size
+		}
+	}
+	return pointer, pointer.oid != ""
+}
+
+// getLFSObject downloads the object a pointer at path stands for from the media host, checking it against the pointer
+func (g *GithubSourceRepository) getLFSObject(ctx context.Context, path string, ref string, pointer lfsPointer) ([]byte, error) {
+	objectURL := contentURL(g.lfsMediaURL, g.owner, g.gitRepo, path, ref)
+	op := fmt.Sprintf("downloading LFS object for file %s at ref %s", path, ref)
+
+	// LFS downloads count against the LFS bandwidth quota, not the API rate limit, so they are not throttled
+	content, _, err := withRetry(ctx, g.retry, nil, op, func() ([]byte, *github.Response, error) {
+		return fetchLFSObject(ctx, g.client.Client(), objectURL, pointer)
+	})
+	if err != nil {
+		return nil, handleGithubError(op, err)
+	}
+
+	sum := sha256.Sum256(content)
+	if hex.EncodeToString(sum[:]) != pointer.oid {
+		return nil, fmt.Errorf("github: %s: content does not match oid sha256:%s", op, pointer.oid)
+	}
+	return content, nil
+}
+
+// fetchLFSObject downloads an object using the GitHub client's HTTP client, which carries the auth token
+func fetchLFSObject(ctx context.Context, client *http.Client, objectURL string, pointer lfsPointer) ([]byte, *github.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, objectURL, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	ghResp := &github.Response{Response: resp}
+
+	if resp.StatusCode != http.StatusOK {
+		// Report as an API error so retries treat it like any other GitHub failure
+		return nil, ghResp, &github.ErrorResponse{Response: resp, Message: resp.Status}
+	}
+
+	// Reading one byte past the expected size is enough to tell the object is the wrong one
+	content, err := io.ReadAll(io.LimitReader(resp.Body, pointer.size+1))
+	if err != nil {
+		return nil, ghResp, fmt.Errorf("failed to read LFS object: %w", err)
+	}
+	if int64(len(content)) != pointer.size {
+		return nil, ghResp, fmt.Errorf("LFS object is not %d bytes", pointer.size)
+	}
+	return content, ghResp, nil
+}
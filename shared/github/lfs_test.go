@@ -0,0 +1,73 @@
+package github
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-github/v75/github"
+)
+
+func testLFSPointer(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return fmt.Sprintf("%s\noid sha256:%s\nsize %d\n", lfsPointerVersion, hex.EncodeToString(sum[:]), len(content))
+}
+
+func TestParseLFSPointer(t *testing.T) {
+	pointer, ok := parseLFSPointer([]byte(testLFSPointer("photo")))
+	if !ok || pointer.size != 5 {
+		t.Errorf("parseLFSPointer() = %+v, %v, want a 5 byte object", pointer, ok)
+	}
+
+	for _, content := range []string{
+		"# Not a pointer\n",
+		lfsPointerVersion + "\nsize 5\n",
+		lfsPointerVersion + "\noid md5:abc\nsize 5\n",
+	} {
+		if _, ok := parseLFSPointer([]byte(content)); ok {
+			t.Errorf("parseLFSPointer(%q) accepted an invalid pointer", content)
+		}
+	}
+}
+
+func TestGetFileContents_LFS(t *testing.T) {
+	const photo = "large photo"
+	mediaContent := photo
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/owner/repo/contents/images/photo.jpg", func(w http.ResponseWriter, r *http.Request) {
+		encoded := base64.StdEncoding.EncodeToString([]byte(testLFSPointer(photo)))
+		fmt.Fprintf(w, `{"type":"file","encoding":"base64","content":%q,"path":"images/photo.jpg"}`, encoded)
+	})
+	mux.HandleFunc("/media/owner/repo/main/images/photo.jpg", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(mediaContent))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client := github.NewClient(srv.Client())
+	client.BaseURL, _ = url.Parse(srv.URL + "/")
+	repo := NewGithubSourceRepository(client, "owner", "repo",
+		WithRetry(testRetryConfig()),
+		WithLFS(&LFSConfig{Enabled: true, MediaURL: srv.URL + "/media/"}),
+	)
+
+	content, err := repo.GetFileContents(context.Background(), "images/photo.jpg", "main")
+	if err != nil {
+		t.Fatalf("GetFileContents failed: %v", err)
+	}
+	if string(content) != photo {
+		t.Errorf("content = %q, want the LFS object", content)
+	}
+
+	mediaContent = "other photo"
+	if _, err := repo.GetFileContents(context.Background(), "images/photo.jpg", "main"); err == nil {
+		t.Error("Expected an object not matching the pointer to be refused")
+	}
+}
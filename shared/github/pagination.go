@@ -0,0 +1,25 @@
+package github
+
+import (
+	"os"
+	"strconv"
+)
+
+const defaultMaxCommits = 1000
+
+// ListConfig limits how much history a single listing walks through
+type ListConfig struct {
+	// MaxCommits caps the commits collected across pages; listings beyond it are truncated with a warning
+	MaxCommits int
+}
+
+func NewListConfig() *ListConfig {
+	maxCommits := defaultMaxCommits
+	if n, err := strconv.Atoi(os.Getenv("GITHUB_MAX_COMMITS")); err == nil && n > 0 {
+		maxCommits = n
+	}
+
+	return &ListConfig{
+		MaxCommits: maxCommits,
+	}
+}
@@ -0,0 +1,79 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// commitPage writes a page of commits, linking to the next page unless it is the last
+func commitPage(w http.ResponseWriter, r *http.Request, page int, lastPage int) {
+	if page < lastPage {
+		w.Header().Set("Link", fmt.Sprintf(`<%s?page=%d>; rel="next"`, r.URL.Path, page+1))
+	}
+	fmt.Fprintf(w, `[{"sha":"sha-%d-a"},{"sha":"sha-%d-b"}]`, page, page)
+}
+
+func TestGetCommitsSince_Paginates(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/owner/repo/commits", func(w http.ResponseWriter, r *http.Request) {
+		page := 1
+		fmt.Sscan(r.URL.Query().Get("page"), &page)
+		commitPage(w, r, page, 3)
+	})
+
+	repo := newTestRepository(t, mux)
+	commits, err := repo.GetCommitsSince(context.Background(), "main", time.Time{})
+	if err != nil {
+		t.Fatalf("GetCommitsSince failed: %v", err)
+	}
+	if len(commits) != 6 {
+		t.Errorf("got %d commits, want 6", len(commits))
+	}
+}
+
+func TestGetCommitsSince_TruncatesAtCap(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/owner/repo/commits", func(w http.ResponseWriter, r *http.Request) {
+		page := 1
+		fmt.Sscan(r.URL.Query().Get("page"), &page)
+		commitPage(w, r, page, 100)
+	})
+
+	repo := newTestRepository(t, mux)
+	repo.list = &ListConfig{MaxCommits: 3}
+
+	commits, err := repo.GetCommitsSince(context.Background(), "main", time.Time{})
+	if err != nil {
+		t.Fatalf("GetCommitsSince failed: %v", err)
+	}
+	if len(commits) != 3 {
+		t.Errorf("got %d commits, want 3", len(commits))
+	}
+}
+
+func TestCompareCommits_Paginates(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/owner/repo/compare/base...head", func(w http.ResponseWriter, r *http.Request) {
+		page := 1
+		fmt.Sscan(r.URL.Query().Get("page"), &page)
+		if page < 2 {
+			w.Header().Set("Link", fmt.Sprintf(`<%s?page=2>; rel="next"`, r.URL.Path))
+		}
+		fmt.Fprintf(w, `{"commits":[{"sha":"c%d"}],"files":[{"filename":"posts/001-a.md","status":"modified"}]}`, page)
+	})
+
+	repo := newTestRepository(t, mux)
+	comparison, err := repo.CompareCommits(context.Background(), "base", "head")
+	if err != nil {
+		t.Fatalf("CompareCommits failed: %v", err)
+	}
+	if len(comparison.Commits) != 2 {
+		t.Errorf("got %d commits, want 2", len(comparison.Commits))
+	}
+	if len(comparison.Files) != 1 {
+		t.Errorf("got %d files, want 1 after removing repeats", len(comparison.Files))
+	}
+}
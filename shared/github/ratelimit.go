@@ -0,0 +1,96 @@
+package github
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v75/github"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog/log"
+)
+
+// throttleFraction is the share of the hourly quota below which requests are spread out until the reset
+const throttleFraction = 0.1
+
+var (
+	rateLimitRemaining = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "goblog_github_rate_limit_remaining",
+		Help: "GitHub API requests remaining in the current rate limit window.",
+	})
+
+	rateLimitLimit = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "goblog_github_rate_limit",
+		Help: "GitHub API requests allowed per rate limit window.",
+	})
+)
+
+// rateLimit is the last known state of the GitHub API rate limit
+type rateLimit struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
+// rateTracker records the rate limit reported by each response and slows requests down as it runs low
+type rateTracker struct {
+	mu   sync.Mutex
+	last rateLimit
+}
+
+// observe records the X-RateLimit-* headers of a response
+func (t *rateTracker) observe(resp *github.Response) {
+	if resp == nil || resp.Rate.Limit == 0 {
+		return
+	}
+
+	t.mu.Lock()
+	t.last = rateLimit{
+		Limit:     resp.Rate.Limit,
+		Remaining: resp.Rate.Remaining,
+		Reset:     resp.Rate.Reset.Time,
+	}
+	t.mu.Unlock()
+
+	rateLimitRemaining.Set(float64(resp.Rate.Remaining))
+	rateLimitLimit.Set(float64(resp.Rate.Limit))
+}
+
+// delay returns how long to wait before the next request so the remaining quota lasts until the reset
+func (t *rateTracker) delay() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	untilReset := time.Until(t.last.Reset)
+	if t.last.Limit == 0 || untilReset <= 0 {
+		return 0
+	}
+	if float64(t.last.Remaining) >= float64(t.last.Limit)*throttleFraction {
+		return 0
+	}
+	if t.last.Remaining <= 0 {
+		return untilReset
+	}
+
+	return untilReset / time.Duration(t.last.Remaining)
+}
+
+// throttle waits out the adaptive delay, capped at maxDelay so a nearly exhausted quota fails fast instead of hanging
+func (t *rateTracker) throttle(ctx context.Context, maxDelay time.Duration) error {
+	wait := min(t.delay(), maxDelay)
+	if wait <= 0 {
+		return nil
+	}
+
+	log.Debug().Dur("wait", wait).Msg("GitHub rate limit running low, throttling")
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,49 @@
+package github
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v75/github"
+)
+
+func TestRateTracker_Delay(t *testing.T) {
+	reset := time.Now().Add(100 * time.Second)
+
+	tests := []struct {
+		name      string
+		remaining int
+		wantMin   time.Duration
+		wantMax   time.Duration
+	}{
+		{"plenty remaining", 4000, 0, 0},
+		{"running low", 100, 900 * time.Millisecond, time.Second},
+		{"exhausted", 0, 99 * time.Second, 100 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := &rateTracker{}
+			tracker.observe(&github.Response{Rate: github.Rate{
+				Limit:     5000,
+				Remaining: tt.remaining,
+				Reset:     github.Timestamp{Time: reset},
+			}})
+
+			delay := tracker.delay()
+			if delay < tt.wantMin || delay > tt.wantMax {
+				t.Errorf("delay = %v, want between %v and %v", delay, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestRateTracker_IgnoresResponsesWithoutRate(t *testing.T) {
+	tracker := &rateTracker{}
+	tracker.observe(nil)
+	tracker.observe(&github.Response{})
+
+	if delay := tracker.delay(); delay != 0 {
+		t.Errorf("delay = %v, want 0 before any rate is known", delay)
+	}
+}
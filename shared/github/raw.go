@@ -0,0 +1,149 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/google/go-github/v75/github"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultRawContentBaseURL = "https://raw.githubusercontent.com/"
+
+	// maxRawCacheEntries bounds the number of files kept for conditional requests
+	maxRawCacheEntries = 256
+)
+
+// RawContentConfig controls fetching file contents from raw.githubusercontent.com
+// Raw downloads are not limited to 1MB like the contents API, skip the base64 round trip,
+// and do not count against the REST API rate limit.
+type RawContentConfig struct {
+	Enabled bool
+	// BaseURL is the raw content host, overridable for GitHub Enterprise
+	BaseURL string
+}
+
+func NewRawContentConfig() *RawContentConfig {
+	baseURL := os.Getenv("GITHUB_RAW_CONTENT_URL")
+	if baseURL == "" {
+		baseURL = defaultRawContentBaseURL
+	}
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+
+	return &RawContentConfig{
+		Enabled: os.Getenv("GITHUB_RAW_CONTENT") == "true",
+		BaseURL: baseURL,
+	}
+}
+
+// WithRawContent fetches file contents from raw.githubusercontent.com, falling back to the contents API on failure.
+func WithRawContent(cfg *RawContentConfig) Option {
+	return func(g *GithubSourceRepository) {
+		if cfg.Enabled {
+			g.raw = &rawFetcher{
+				baseURL: cfg.BaseURL,
+				cache:   make(map[string]rawCacheEntry),
+			}
+		}
+	}
+}
+
+// rawFetcher downloads files from the raw content host, revalidating previously fetched files by ETag
+type rawFetcher struct {
+	baseURL string
+
+	mu    sync.Mutex
+	cache map[string]rawCacheEntry
+}
+
+type rawCacheEntry struct {
+	etag    string
+	content []byte
+}
+
+func (f *rawFetcher) fileURL(owner string, gitRepo string, path string, ref string) string {
+	return contentURL(f.baseURL, owner, gitRepo, path, ref)
+}
+
+// contentURL returns the URL of a file at ref on a host serving repository files by path, such as the raw content host
+func contentURL(baseURL string, owner string, gitRepo string, path string, ref string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return baseURL + url.PathEscape(owner) + "/" + url.PathEscape(gitRepo) + "/" + url.PathEscape(ref) + "/" + strings.Join(segments, "/")
+}
+
+// fetch downloads a file using the GitHub client's HTTP client, which carries the auth token
+func (f *rawFetcher) fetch(ctx context.Context, client *http.Client, fileURL string) ([]byte, *github.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fileURL, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	f.mu.Lock()
+	cached, hasCached := f.cache[fileURL]
+	f.mu.Unlock()
+	if hasCached {
+		req.Header.Set("If-None-Match", cached.etag)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	ghResp := &github.Response{Response: resp}
+
+	if resp.StatusCode == http.StatusNotModified && hasCached {
+		return cached.content, ghResp, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		// Report as an API error so retries treat it like any other GitHub failure
+		return nil, ghResp, &github.ErrorResponse{Response: resp, Message: resp.Status}
+	}
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, ghResp, fmt.Errorf("failed to read raw content: %w", err)
+	}
+
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		f.mu.Lock()
+		if len(f.cache) >= maxRawCacheEntries {
+			clear(f.cache)
+		}
+		f.cache[fileURL] = rawCacheEntry{etag: etag, content: content}
+		f.mu.Unlock()
+	}
+
+	return content, ghResp, nil
+}
+
+// getRawFileContents fetches a file from the raw content host
+// ok is false when the caller should fall back to the contents API
+func (g *GithubSourceRepository) getRawFileContents(ctx context.Context, path string, ref string) ([]byte, bool) {
+	fileURL := g.raw.fileURL(g.owner, g.gitRepo, path, ref)
+	op := fmt.Sprintf("downloading raw file %s at ref %s", path, ref)
+
+	// Raw downloads do not count against the API rate limit, so they are not throttled
+	content, _, err := withRetry(ctx, g.retry, nil, op, func() ([]byte, *github.Response, error) {
+		return g.raw.fetch(ctx, g.client.Client(), fileURL)
+	})
+	if err != nil {
+		log.Warn().Err(handleGithubError(op, err)).Msg("Raw content fetch failed, falling back to contents API")
+		return nil, false
+	}
+
+	return content, true
+}
@@ -0,0 +1,66 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRawFetcher_FileURL(t *testing.T) {
+	f := &rawFetcher{baseURL: defaultRawContentBaseURL}
+
+	got := f.fileURL("owner", "repo", "images/my photo.png", "main")
+	want := "https://raw.githubusercontent.com/owner/repo/main/images/my%20photo.png"
+	if got != want {
+		t.Errorf("fileURL = %q, want %q", got, want)
+	}
+}
+
+func TestRawFetcher_ConditionalFetch(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("hello"))
+	}))
+	defer srv.Close()
+
+	f := &rawFetcher{baseURL: srv.URL + "/", cache: make(map[string]rawCacheEntry)}
+	fileURL := f.fileURL("owner", "repo", "posts/001-test.md", "main")
+
+	for i := 0; i < 2; i++ {
+		content, _, err := f.fetch(context.Background(), srv.Client(), fileURL)
+		if err != nil {
+			t.Fatalf("fetch %d failed: %v", i, err)
+		}
+		if string(content) != "hello" {
+			t.Errorf("fetch %d content = %q, want %q", i, content, "hello")
+		}
+	}
+
+	if requests != 2 {
+		t.Errorf("requests = %d, want 2", requests)
+	}
+}
+
+func TestRawFetcher_ErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+
+	f := &rawFetcher{baseURL: srv.URL + "/", cache: make(map[string]rawCacheEntry)}
+	_, _, err := f.fetch(context.Background(), srv.Client(), f.fileURL("owner", "repo", "missing.md", "main"))
+	if err == nil {
+		t.Fatal("Expected error for missing file")
+	}
+
+	if _, retryable := retryDelay(err); retryable {
+		t.Error("Expected a 404 not to be retried")
+	}
+}
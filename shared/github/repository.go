@@ -4,10 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/dfryer1193/goblog/blog/domain"
 	"github.com/google/go-github/v75/github"
+	"github.com/rs/zerolog/log"
 )
 
 // GithubSourceRepository is an implementation of domain.SourceRepository that uses the GitHub API.
@@ -15,57 +17,199 @@ type GithubSourceRepository struct {
 	client  *github.Client
 	owner   string
 	gitRepo string
+	retry   *RetryConfig
+	raw     *rawFetcher
+	rate    *rateTracker
+	list    *ListConfig
+
+	// lfsMediaURL is the host LFS objects are downloaded from, or empty to return LFS pointers as they are
+	lfsMediaURL string
+}
+
+// Option configures optional GithubSourceRepository behaviour.
+type Option func(*GithubSourceRepository)
+
+// WithRetry overrides the policy used to retry failed API calls.
+func WithRetry(cfg *RetryConfig) Option {
+	return func(g *GithubSourceRepository) {
+		g.retry = cfg
+	}
+}
+
+// WithListConfig overrides the limits applied when listing commits.
+func WithListConfig(cfg *ListConfig) Option {
+	return func(g *GithubSourceRepository) {
+		g.list = cfg
+	}
 }
 
 // NewGithubSourceRepository creates a new GithubSourceRepository.
-func NewGithubSourceRepository(client *github.Client, owner string, gitRepo string) domain.SourceRepository {
-	return &GithubSourceRepository{
+func NewGithubSourceRepository(client *github.Client, owner string, gitRepo string, opts ...Option) domain.SourceRepository {
+	g := &GithubSourceRepository{
 		client:  client,
 		owner:   owner,
 		gitRepo: gitRepo,
+		rate:    &rateTracker{},
+		list:    &ListConfig{MaxCommits: defaultMaxCommits},
+		retry: &RetryConfig{
+			MaxAttempts: defaultRetryMaxAttempts,
+			BaseDelay:   defaultRetryBaseDelay,
+			MaxDelay:    defaultRetryMaxDelay,
+		},
+	}
+
+	for _, opt := range opts {
+		opt(g)
 	}
+
+	return g
 }
 
 // GetCommitsSince fetches commits for a branch since a given time.
 func (g *GithubSourceRepository) GetCommitsSince(ctx context.Context, branchName string, since time.Time) ([]*github.RepositoryCommit, error) {
 	op := fmt.Sprintf("listing commits for branch %s", branchName)
-	commits, _, err := g.client.Repositories.ListCommits(ctx, g.owner, g.gitRepo, &github.CommitsListOptions{
-		SHA:   branchName,
-		Since: since,
-	})
-	if err != nil {
-		return nil, handleGithubError(op, err)
+	var allCommits []*github.RepositoryCommit
+	opts := &github.CommitsListOptions{
+		SHA:         branchName,
+		Since:       since,
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	for {
+		commits, resp, err := withRetry(ctx, g.retry, g.rate, op, func() ([]*github.RepositoryCommit, *github.Response, error) {
+			return g.client.Repositories.ListCommits(ctx, g.owner, g.gitRepo, opts)
+		})
+		if err != nil {
+			return nil, handleGithubError(op, err)
+		}
+
+		allCommits = append(allCommits, commits...)
+		if resp.NextPage == 0 {
+			break
+		}
+		if len(allCommits) >= g.list.MaxCommits {
+			log.Warn().Str("branch", branchName).Int("maxCommits", g.list.MaxCommits).Msg("Commit listing truncated, older changes will be missed")
+			allCommits = allCommits[:g.list.MaxCommits]
+			break
+		}
+		opts.Page = resp.NextPage
 	}
-	return commits, nil
+	return allCommits, nil
 }
 
 // GetCommitsInRange fetches commits between baseCommit and headCommit (inclusive).
 // This is useful for processing all commits in a push event.
 func (g *GithubSourceRepository) GetCommitsInRange(ctx context.Context, baseCommit string, headCommit string) ([]*github.RepositoryCommit, error) {
-	op := fmt.Sprintf("comparing commits %s...%s", baseCommit, headCommit)
-	comparison, _, err := g.client.Repositories.CompareCommits(ctx, g.owner, g.gitRepo, baseCommit, headCommit, nil)
+	comparison, err := g.CompareCommits(ctx, baseCommit, headCommit)
 	if err != nil {
-		return nil, handleGithubError(op, err)
+		return nil, err
 	}
 	return comparison.Commits, nil
 }
 
+// CompareCommits fetches the commits between baseCommit and headCommit along with the net file changes.
+// A single call covers the whole range, which is far cheaper than fetching every commit.
+func (g *GithubSourceRepository) CompareCommits(ctx context.Context, baseCommit string, headCommit string) (*github.CommitsComparison, error) {
+	op := fmt.Sprintf("comparing commits %s...%s", baseCommit, headCommit)
+	var comparison *github.CommitsComparison
+	seenFiles := make(map[string]bool)
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := withRetry(ctx, g.retry, g.rate, op, func() (*github.CommitsComparison, *github.Response, error) {
+			return g.client.Repositories.CompareCommits(ctx, g.owner, g.gitRepo, baseCommit, headCommit, opts)
+		})
+		if err != nil {
+			return nil, handleGithubError(op, err)
+		}
+
+		if comparison == nil {
+			comparison = page
+			comparison.Files = nil
+		} else {
+			comparison.Commits = append(comparison.Commits, page.Commits...)
+		}
+
+		// Changed files may be repeated on every page
+		for _, f := range page.Files {
+			if !seenFiles[f.GetFilename()] {
+				seenFiles[f.GetFilename()] = true
+				comparison.Files = append(comparison.Files, f)
+			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		if len(comparison.Commits) >= g.list.MaxCommits {
+			log.Warn().Str("base", baseCommit).Str("head", headCommit).Int("maxCommits", g.list.MaxCommits).Msg("Commit comparison truncated, later changes will be missed")
+			comparison.Commits = comparison.Commits[:g.list.MaxCommits]
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return comparison, nil
+}
+
 // GetCommit fetches a single commit by its SHA.
 func (g *GithubSourceRepository) GetCommit(ctx context.Context, sha string) (*github.RepositoryCommit, error) {
 	op := fmt.Sprintf("getting commit %s", sha)
-	commit, _, err := g.client.Repositories.GetCommit(ctx, g.owner, g.gitRepo, sha, nil)
+	commit, _, err := withRetry(ctx, g.retry, g.rate, op, func() (*github.RepositoryCommit, *github.Response, error) {
+		return g.client.Repositories.GetCommit(ctx, g.owner, g.gitRepo, sha, nil)
+	})
 	if err != nil {
 		return nil, handleGithubError(op, err)
 	}
 	return commit, nil
 }
 
+// GetTree fetches the full file tree of a commit, descending into subdirectories.
+func (g *GithubSourceRepository) GetTree(ctx context.Context, sha string) (*github.Tree, error) {
+	op := fmt.Sprintf("getting tree of %s", sha)
+	tree, _, err := withRetry(ctx, g.retry, g.rate, op, func() (*github.Tree, *github.Response, error) {
+		return g.client.Git.GetTree(ctx, g.owner, g.gitRepo, sha, true)
+	})
+	if err != nil {
+		return nil, handleGithubError(op, err)
+	}
+	if tree.GetTruncated() {
+		log.Warn().Str("sha", sha).Msg("Tree truncated, files past the limit will be missed")
+	}
+	return tree, nil
+}
+
 // GetFileContents fetches the contents of a file at a specific ref (branch, tag, or commit SHA).
+// Files tracked by Git LFS are downloaded in place of their pointers when WithLFS is enabled.
 func (g *GithubSourceRepository) GetFileContents(ctx context.Context, path string, ref string) ([]byte, error) {
+	content, err := g.getFileContents(ctx, path, ref)
+	if err != nil || g.lfsMediaURL == "" {
+		return content, err
+	}
+	if pointer, ok := parseLFSPointer(content); ok {
+		return g.getLFSObject(ctx, path, ref, pointer)
+	}
+	return content, nil
+}
+
+// getFileContents fetches the file as stored in Git
+func (g *GithubSourceRepository) getFileContents(ctx context.Context, path string, ref string) ([]byte, error) {
+	if g.raw != nil {
+		if content, ok := g.getRawFileContents(ctx, path, ref); ok {
+			return content, nil
+		}
+	}
+
 	op := fmt.Sprintf("getting file %s at ref %s", path, ref)
-	fileContent, _, _, err := g.client.Repositories.GetContents(ctx, g.owner, g.gitRepo, path, &github.RepositoryContentGetOptions{
-		Ref: ref,
+	fileContent, _, err := withRetry(ctx, g.retry, g.rate, op, func() (*github.RepositoryContent, *github.Response, error) {
+		fileContent, _, resp, err := g.client.Repositories.GetContents(ctx, g.owner, g.gitRepo, path, &github.RepositoryContentGetOptions{
+			Ref: ref,
+		})
+		return fileContent, resp, err
 	})
+	if isTooLargeError(err) {
+		return g.getLargeFileContents(ctx, path, ref)
+	}
+	if isNotFoundError(err) {
+		return nil, fmt.Errorf("github: %s: %w", op, domain.ErrSourceFileNotFound)
+	}
 	if err != nil {
 		return nil, handleGithubError(op, err)
 	}
@@ -73,6 +217,11 @@ func (g *GithubSourceRepository) GetFileContents(ctx context.Context, path strin
 	if fileContent == nil {
 		return nil, fmt.Errorf("github: %s returned nil file content", op)
 	}
+
+	// Files over 1MB are described without their content; download the blob instead
+	if fileContent.GetEncoding() == "none" {
+		return g.getBlobContents(ctx, path, fileContent.GetSHA())
+	}
 	
 	content, err := fileContent.GetContent()
 	if err != nil {
@@ -90,7 +239,9 @@ func (g *GithubSourceRepository) ListBranches(ctx context.Context) ([]*github.Br
 		ListOptions: github.ListOptions{PerPage: 100},
 	}
 	for {
-		branches, resp, err := g.client.Repositories.ListBranches(ctx, g.owner, g.gitRepo, opts)
+		branches, resp, err := withRetry(ctx, g.retry, g.rate, op, func() ([]*github.Branch, *github.Response, error) {
+			return g.client.Repositories.ListBranches(ctx, g.owner, g.gitRepo, opts)
+		})
 		if err != nil {
 			return nil, handleGithubError(op, err)
 		}
@@ -112,7 +263,9 @@ func (g *GithubSourceRepository) GetRepoFullName() string {
 // GetDefaultBranchName fetches the repository metadata and returns the name of the default branch.
 func (g *GithubSourceRepository) GetDefaultBranchName(ctx context.Context) (string, error) {
 	op := fmt.Sprintf("getting repository info for %s/%s", g.owner, g.gitRepo)
-	repo, _, err := g.client.Repositories.Get(ctx, g.owner, g.gitRepo)
+	repo, _, err := withRetry(ctx, g.retry, g.rate, op, func() (*github.Repository, *github.Response, error) {
+		return g.client.Repositories.Get(ctx, g.owner, g.gitRepo)
+	})
 	if err != nil {
 		return "", handleGithubError(op, err)
 	}
@@ -120,6 +273,12 @@ func (g *GithubSourceRepository) GetDefaultBranchName(ctx context.Context) (stri
 }
 
 // handleGithubError inspects an error from the go-github client and returns a more informative, structured error.
+// isNotFoundError reports whether GitHub answered with a 404
+func isNotFoundError(err error) bool {
+	var errResp *github.ErrorResponse
+	return errors.As(err, &errResp) && errResp.Response != nil && errResp.Response.StatusCode == http.StatusNotFound
+}
+
 func handleGithubError(op string, err error) error {
 	if err == nil {
 		return nil
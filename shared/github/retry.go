@@ -0,0 +1,151 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/google/go-github/v75/github"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultRetryMaxAttempts = 5
+	defaultRetryBaseDelay   = time.Second
+	defaultRetryMaxDelay    = time.Minute
+)
+
+// RetryConfig controls how failed GitHub API calls are retried
+type RetryConfig struct {
+	// MaxAttempts is the total number of attempts per call, including the first
+	MaxAttempts int
+	// BaseDelay is the backoff before the first retry; it doubles with every attempt
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff, and is the longest Retry-After or rate limit reset that will be waited for
+	MaxDelay time.Duration
+}
+
+func NewRetryConfig() *RetryConfig {
+	cfg := &RetryConfig{
+		MaxAttempts: defaultRetryMaxAttempts,
+		BaseDelay:   defaultRetryBaseDelay,
+		MaxDelay:    defaultRetryMaxDelay,
+	}
+
+	if n, err := strconv.Atoi(os.Getenv("GITHUB_RETRY_MAX_ATTEMPTS")); err == nil && n > 0 {
+		cfg.MaxAttempts = n
+	}
+	if d, err := time.ParseDuration(os.Getenv("GITHUB_RETRY_BASE_DELAY")); err == nil && d > 0 {
+		cfg.BaseDelay = d
+	}
+	if d, err := time.ParseDuration(os.Getenv("GITHUB_RETRY_MAX_DELAY")); err == nil && d > 0 {
+		cfg.MaxDelay = d
+	}
+
+	return cfg
+}
+
+// backoff returns the delay before the given retry, with up to 50% jitter
+func (c *RetryConfig) backoff(retry int) time.Duration {
+	delay := c.BaseDelay << (retry - 1)
+	if delay <= 0 || delay > c.MaxDelay {
+		delay = c.MaxDelay
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// withRetry calls fn until it succeeds, fails permanently, or runs out of attempts
+// Transient failures (5xx, rate limits, network errors) are retried with exponential backoff,
+// waiting as long as GitHub asks through Retry-After or the rate limit reset time.
+// Requests are spread out as the rate limit tracked by rate runs low.
+func withRetry[T any](ctx context.Context, cfg *RetryConfig, rate *rateTracker, op string, fn func() (T, *github.Response, error)) (T, *github.Response, error) {
+	for attempt := 1; ; attempt++ {
+		if rate != nil {
+			if err := rate.throttle(ctx, cfg.MaxDelay); err != nil {
+				var zero T
+				return zero, nil, err
+			}
+		}
+
+		result, resp, err := fn()
+		if rate != nil {
+			rate.observe(resp)
+		}
+		if err == nil {
+			return result, resp, nil
+		}
+
+		wait, retryable := retryDelay(err)
+		if !retryable || attempt >= cfg.MaxAttempts || ctx.Err() != nil {
+			return result, resp, err
+		}
+
+		if wait == 0 {
+			wait = cfg.backoff(attempt)
+		} else if wait > cfg.MaxDelay {
+			return result, resp, fmt.Errorf("retry after %s exceeds maximum delay: %w", wait.Round(time.Second), err)
+		}
+
+		log.Warn().Err(err).Str("op", op).Int("attempt", attempt).Dur("wait", wait).Msg("GitHub request failed, retrying")
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return result, resp, err
+		case <-timer.C:
+		}
+	}
+}
+
+// retryDelay reports whether an error is transient, and how long GitHub asked us to wait if it said so
+func retryDelay(err error) (time.Duration, bool) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return 0, false
+	}
+
+	var rateErr *github.RateLimitError
+	if errors.As(err, &rateErr) {
+		return max(time.Until(rateErr.Rate.Reset.Time), time.Second), true
+	}
+
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &abuseErr) {
+		if abuseErr.RetryAfter != nil {
+			return *abuseErr.RetryAfter, true
+		}
+		return 0, true
+	}
+
+	var errResp *github.ErrorResponse
+	if errors.As(err, &errResp) {
+		if errResp.Response == nil {
+			return 0, false
+		}
+		switch status := errResp.Response.StatusCode; {
+		case status == http.StatusTooManyRequests:
+			return parseRetryAfter(errResp.Response), true
+		case status >= http.StatusInternalServerError:
+			return parseRetryAfter(errResp.Response), true
+		default:
+			return 0, false
+		}
+	}
+
+	// Anything else is a transport failure such as a reset connection or timeout
+	return 0, true
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds, returning zero if absent
+func parseRetryAfter(resp *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
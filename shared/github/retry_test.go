@@ -0,0 +1,124 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v75/github"
+)
+
+func testRetryConfig() *RetryConfig {
+	return &RetryConfig{
+		MaxAttempts: 3,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    10 * time.Millisecond,
+	}
+}
+
+func errorResponse(status int, header http.Header) *github.ErrorResponse {
+	return &github.ErrorResponse{
+		Response: &http.Response{StatusCode: status, Header: header},
+	}
+}
+
+func TestWithRetry_RetriesTransientErrors(t *testing.T) {
+	calls := 0
+	result, _, err := withRetry(context.Background(), testRetryConfig(), nil, "test", func() (string, *github.Response, error) {
+		calls++
+		if calls < 3 {
+			return "", nil, errorResponse(http.StatusBadGateway, http.Header{})
+		}
+		return "ok", nil, nil
+	})
+
+	if err != nil {
+		t.Fatalf("withRetry failed: %v", err)
+	}
+	if result != "ok" {
+		t.Errorf("result = %q, want %q", result, "ok")
+	}
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+}
+
+func TestWithRetry_GivesUpAfterMaxAttempts(t *testing.T) {
+	calls := 0
+	_, _, err := withRetry(context.Background(), testRetryConfig(), nil, "test", func() (string, *github.Response, error) {
+		calls++
+		return "", nil, errorResponse(http.StatusInternalServerError, http.Header{})
+	})
+
+	if err == nil {
+		t.Fatal("Expected error after exhausting attempts")
+	}
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+}
+
+func TestWithRetry_DoesNotRetryClientErrors(t *testing.T) {
+	calls := 0
+	_, _, err := withRetry(context.Background(), testRetryConfig(), nil, "test", func() (string, *github.Response, error) {
+		calls++
+		return "", nil, errorResponse(http.StatusNotFound, http.Header{})
+	})
+
+	if err == nil {
+		t.Fatal("Expected error")
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestWithRetry_RetryAfterBeyondMaxDelay(t *testing.T) {
+	calls := 0
+	_, _, err := withRetry(context.Background(), testRetryConfig(), nil, "test", func() (string, *github.Response, error) {
+		calls++
+		return "", nil, errorResponse(http.StatusTooManyRequests, http.Header{"Retry-After": []string{"120"}})
+	})
+
+	if err == nil {
+		t.Fatal("Expected error when Retry-After exceeds the maximum delay")
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	retryAfter := 3 * time.Second
+	reset := time.Now().Add(5 * time.Second)
+
+	tests := []struct {
+		name      string
+		err       error
+		wantWait  time.Duration
+		retryable bool
+	}{
+		{"server error", errorResponse(http.StatusServiceUnavailable, http.Header{}), 0, true},
+		{"server error with Retry-After", errorResponse(http.StatusServiceUnavailable, http.Header{"Retry-After": []string{"7"}}), 7 * time.Second, true},
+		{"not found", errorResponse(http.StatusNotFound, http.Header{}), 0, false},
+		{"abuse rate limit", &github.AbuseRateLimitError{RetryAfter: &retryAfter}, retryAfter, true},
+		{"rate limit", &github.RateLimitError{Rate: github.Rate{Reset: github.Timestamp{Time: reset}}}, 5 * time.Second, true},
+		{"cancelled", context.Canceled, 0, false},
+		{"network", errors.New("connection reset by peer"), 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wait, retryable := retryDelay(tt.err)
+			if retryable != tt.retryable {
+				t.Errorf("retryable = %v, want %v", retryable, tt.retryable)
+			}
+			// Rate limit resets are measured from now, so allow for elapsed time
+			if wait > tt.wantWait || wait < tt.wantWait-time.Second {
+				t.Errorf("wait = %v, want %v", wait, tt.wantWait)
+			}
+		})
+	}
+}
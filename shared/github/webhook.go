@@ -0,0 +1,167 @@
+package github
+
+import (
+	"context"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v75/github"
+)
+
+// WebhookState is the outcome of checking the repository's push webhook.
+type WebhookState string
+
+const (
+	// WebhookCreated means no webhook pointed at the server, so one was added.
+	WebhookCreated WebhookState = "created"
+	// WebhookUpdated means the webhook existed but its events or settings were wrong.
+	WebhookUpdated WebhookState = "updated"
+	// WebhookVerified means the webhook already had the right settings.
+	WebhookVerified WebhookState = "verified"
+	// WebhookFailed means the webhook could not be checked or fixed.
+	WebhookFailed WebhookState = "failed"
+)
+
+type WebhookConfig struct {
+	// AutoRegister creates or fixes the push webhook at startup.
+	AutoRegister bool
+	// PublicURL is the base URL GitHub uses to reach the server.
+	PublicURL string
+	// Secret signs webhook deliveries.
+	Secret string
+}
+
+func NewWebhookConfig() *WebhookConfig {
+	return &WebhookConfig{
+		AutoRegister: os.Getenv("WEBHOOK_AUTO_REGISTER") == "true",
+		PublicURL:    os.Getenv("PUBLIC_URL"),
+		Secret:       os.Getenv("WEBHOOK_SECRET"),
+	}
+}
+
+// WebhookStatus reports the result of the most recent webhook check.
+type WebhookStatus struct {
+	State     WebhookState
+	URL       string
+	CheckedAt time.Time
+	Err       error
+}
+
+// WebhookRegistrar makes sure the content repository sends push events to the server.
+type WebhookRegistrar struct {
+	client  *github.Client
+	owner   string
+	gitRepo string
+	url     string
+	secret  string
+
+	mu     sync.Mutex
+	status WebhookStatus
+}
+
+// NewWebhookRegistrar creates a WebhookRegistrar for the webhook delivering to url.
+func NewWebhookRegistrar(client *github.Client, owner string, gitRepo string, url string, secret string) *WebhookRegistrar {
+	return &WebhookRegistrar{
+		client:  client,
+		owner:   owner,
+		gitRepo: gitRepo,
+		url:     url,
+		secret:  secret,
+		status:  WebhookStatus{URL: url},
+	}
+}
+
+// Ensure creates the push webhook, or fixes its events and settings if it already exists.
+// GitHub never reveals a webhook's secret, so the configured secret is always written.
+func (w *WebhookRegistrar) Ensure(ctx context.Context) error {
+	state, err := w.ensure(ctx)
+	if err != nil {
+		state = WebhookFailed
+	}
+
+	w.mu.Lock()
+	w.status = WebhookStatus{State: state, URL: w.url, CheckedAt: time.Now().UTC(), Err: err}
+	w.mu.Unlock()
+
+	return err
+}
+
+func (w *WebhookRegistrar) ensure(ctx context.Context) (WebhookState, error) {
+	existing, err := w.findHook(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	want := pushHook(w.url, w.secret)
+	if existing == nil {
+		if _, _, err := w.client.Repositories.CreateHook(ctx, w.owner, w.gitRepo, want); err != nil {
+			return "", handleGithubError("create webhook", err)
+		}
+		return WebhookCreated, nil
+	}
+
+	state := WebhookVerified
+	if !existing.GetActive() || !sameEvents(existing.Events, want.Events) || existing.GetConfig().GetContentType() != want.GetConfig().GetContentType() {
+		state = WebhookUpdated
+	}
+
+	if _, _, err := w.client.Repositories.EditHook(ctx, w.owner, w.gitRepo, existing.GetID(), want); err != nil {
+		return "", handleGithubError("edit webhook", err)
+	}
+
+	return state, nil
+}
+
+// findHook returns the repository webhook delivering to the server, or nil if there is none.
+func (w *WebhookRegistrar) findHook(ctx context.Context) (*github.Hook, error) {
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		hooks, resp, err := w.client.Repositories.ListHooks(ctx, w.owner, w.gitRepo, opts)
+		if err != nil {
+			return nil, handleGithubError("list webhooks", err)
+		}
+
+		for _, hook := range hooks {
+			if hook.GetConfig().GetURL() == w.url {
+				return hook, nil
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return nil, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// Status returns the result of the most recent call to Ensure.
+func (w *WebhookRegistrar) Status() WebhookStatus {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.status
+}
+
+// pushHook describes an active webhook delivering push, tag, release and pull request events as JSON.
+// Tag and release events only publish posts when PUBLISH_MODE asks for them,
+// and pull request events are only handled when preview comments are enabled.
+func pushHook(url string, secret string) *github.Hook {
+	return &github.Hook{
+		Events: []string{"push", "create", "release", "pull_request"},
+		Active: github.Ptr(true),
+		Config: &github.HookConfig{
+			URL:         github.Ptr(url),
+			ContentType: github.Ptr("json"),
+			Secret:      github.Ptr(secret),
+		},
+	}
+}
+
+// sameEvents reports whether two webhooks subscribe to the same events, in any order.
+func sameEvents(a []string, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(a, b)
+}
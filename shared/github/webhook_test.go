@@ -0,0 +1,127 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-github/v75/github"
+)
+
+const testWebhookURL = "https://blog.example.com/webhook/git"
+
+// newHooksServer serves a single page of existing hooks and records created and edited hooks
+func newHooksServer(t *testing.T, existing []*github.Hook) (*github.Client, *[]string) {
+	var calls []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repos/owner/repo/hooks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(existing)
+	})
+	mux.HandleFunc("POST /repos/owner/repo/hooks", func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "create")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("{}"))
+	})
+	mux.HandleFunc("PATCH /repos/owner/repo/hooks/{id}", func(w http.ResponseWriter, r *http.Request) {
+		var hook github.Hook
+		json.NewDecoder(r.Body).Decode(&hook)
+		if hook.GetConfig().GetSecret() != "secret" {
+			t.Errorf("edited hook secret = %q, want %q", hook.GetConfig().GetSecret(), "secret")
+		}
+		calls = append(calls, "edit "+r.PathValue("id"))
+		w.Write([]byte("{}"))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(srv.URL + "/")
+	return client, &calls
+}
+
+func TestWebhookRegistrar_Ensure(t *testing.T) {
+	hook := func(id int64, url string, events ...string) *github.Hook {
+		return &github.Hook{
+			ID:     github.Ptr(id),
+			Events: events,
+			Active: github.Ptr(true),
+			Config: &github.HookConfig{URL: github.Ptr(url), ContentType: github.Ptr("json")},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		existing  []*github.Hook
+		wantState WebhookState
+		wantCall  string
+	}{
+		{
+			name:      "missing",
+			existing:  []*github.Hook{hook(1, "https://elsewhere.example.com", "push")},
+			wantState: WebhookCreated,
+			wantCall:  "create",
+		},
+		{
+			name:      "wrong events",
+			existing:  []*github.Hook{hook(2, testWebhookURL, "issues")},
+			wantState: WebhookUpdated,
+			wantCall:  "edit 2",
+		},
+		{
+			name:      "push only",
+			existing:  []*github.Hook{hook(4, testWebhookURL, "push")},
+			wantState: WebhookUpdated,
+			wantCall:  "edit 4",
+		},
+		{
+			name:      "correct",
+			existing:  []*github.Hook{hook(3, testWebhookURL, "release", "pull_request", "push", "create")},
+			wantState: WebhookVerified,
+			wantCall:  "edit 3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, calls := newHooksServer(t, tt.existing)
+			registrar := NewWebhookRegistrar(client, "owner", "repo", testWebhookURL, "secret")
+
+			if err := registrar.Ensure(context.Background()); err != nil {
+				t.Fatalf("Ensure failed: %v", err)
+			}
+
+			status := registrar.Status()
+			if status.State != tt.wantState {
+				t.Errorf("State = %q, want %q", status.State, tt.wantState)
+			}
+			if status.CheckedAt.IsZero() {
+				t.Error("Expected CheckedAt to be set")
+			}
+			if len(*calls) != 1 || (*calls)[0] != tt.wantCall {
+				t.Errorf("calls = %v, want [%s]", *calls, tt.wantCall)
+			}
+		})
+	}
+}
+
+func TestWebhookRegistrar_Ensure_Failure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message": "Not Found"}`))
+	}))
+	defer srv.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(srv.URL + "/")
+	registrar := NewWebhookRegistrar(client, "owner", "repo", testWebhookURL, "secret")
+
+	if err := registrar.Ensure(context.Background()); err == nil {
+		t.Fatal("Expected Ensure to fail")
+	}
+	if status := registrar.Status(); status.State != WebhookFailed || status.Err == nil {
+		t.Errorf("Status = %+v, want a failed state with an error", status)
+	}
+}
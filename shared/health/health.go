@@ -0,0 +1,80 @@
+package health
+
+import (
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/dfryer1193/mjolnir/utils/httpx"
+	"github.com/go-chi/chi/v5"
+)
+
+// defaultDrainPeriod is how long a stopping server keeps serving after it reports not ready
+const defaultDrainPeriod = 5 * time.Second
+
+type ShutdownConfig struct {
+	// DrainPeriod is how long requests are still served once readiness has dropped
+	// Load balancers take a few probes to notice, and meanwhile keep sending traffic that would otherwise be refused.
+	DrainPeriod time.Duration
+}
+
+func NewShutdownConfig() *ShutdownConfig {
+	drainPeriod := defaultDrainPeriod
+	if d, err := time.ParseDuration(os.Getenv("SHUTDOWN_DRAIN_PERIOD")); err == nil && d >= 0 {
+		drainPeriod = d
+	}
+
+	return &ShutdownConfig{
+		DrainPeriod: drainPeriod,
+	}
+}
+
+// Checker tracks whether the server is ready to receive traffic
+// The server is live as soon as it is serving requests, but only ready once marked so.
+type Checker struct {
+	ready atomic.Bool
+}
+
+// New creates a Checker that is not ready yet
+func New() *Checker {
+	return &Checker{}
+}
+
+// MarkReady reports the server as ready for traffic
+func (c *Checker) MarkReady() {
+	c.ready.Store(true)
+}
+
+// MarkNotReady takes the server out of rotation, e.g. while shutting down
+func (c *Checker) MarkNotReady() {
+	c.ready.Store(false)
+}
+
+// Ready reports whether the server is ready for traffic
+func (c *Checker) Ready() bool {
+	return c.ready.Load()
+}
+
+func (c *Checker) RegisterRoutes(r chi.Router) {
+	r.Get("/healthz", c.HandleLiveness)
+	r.Get("/readyz", c.HandleReadiness)
+}
+
+type statusResponse struct {
+	Status string `json:"status"`
+}
+
+// HandleLiveness reports that the process is up and serving requests
+func (c *Checker) HandleLiveness(w http.ResponseWriter, r *http.Request) {
+	httpx.RespondJSON(w, r, http.StatusOK, statusResponse{Status: "ok"})
+}
+
+// HandleReadiness reports whether load balancers should send traffic here
+func (c *Checker) HandleReadiness(w http.ResponseWriter, r *http.Request) {
+	if !c.Ready() {
+		httpx.RespondJSON(w, r, http.StatusServiceUnavailable, statusResponse{Status: "starting"})
+		return
+	}
+	httpx.RespondJSON(w, r, http.StatusOK, statusResponse{Status: "ready"})
+}
@@ -0,0 +1,38 @@
+package health
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestChecker_Readiness(t *testing.T) {
+	checker := New()
+	r := chi.NewRouter()
+	checker.RegisterRoutes(r)
+
+	get := func(path string) int {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code
+	}
+
+	if code := get("/healthz"); code != http.StatusOK {
+		t.Errorf("/healthz = %d, want %d", code, http.StatusOK)
+	}
+	if code := get("/readyz"); code != http.StatusServiceUnavailable {
+		t.Errorf("/readyz before ready = %d, want %d", code, http.StatusServiceUnavailable)
+	}
+
+	checker.MarkReady()
+	if code := get("/readyz"); code != http.StatusOK {
+		t.Errorf("/readyz after ready = %d, want %d", code, http.StatusOK)
+	}
+
+	checker.MarkNotReady()
+	if code := get("/readyz"); code != http.StatusServiceUnavailable {
+		t.Errorf("/readyz after not ready = %d, want %d", code, http.StatusServiceUnavailable)
+	}
+}
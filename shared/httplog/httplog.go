@@ -0,0 +1,124 @@
+package httplog
+
+import (
+	"context"
+	"crypto/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog/log"
+)
+
+// RequestIDHeader carries the request ID in both directions
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds client supplied request IDs so they cannot bloat the logs
+const maxRequestIDLength = 128
+
+type ctxKey struct{}
+
+var requestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "goblog_http_requests_total",
+	Help: "HTTP requests served, by status class such as 2xx.",
+}, []string{"status"})
+
+// Middleware logs every request once it completes, with its method, route, status, size and latency
+// Each request gets an ID, taken from X-Request-ID when the client sent a usable one, which is echoed
+// in the response and attached to the request's context and logger. Handlers that log through
+// zerolog.Ctx(r.Context()) have the ID included automatically.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		reqID := r.Header.Get(RequestIDHeader)
+		if !validRequestID(reqID) {
+			reqID = rand.Text()
+		}
+		w.Header().Set(RequestIDHeader, reqID)
+
+		logger := log.With().Str("request_id", reqID).Logger()
+		ctx := logger.WithContext(context.WithValue(r.Context(), ctxKey{}, reqID))
+
+		rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rw, r.WithContext(ctx))
+
+		requestsTotal.WithLabelValues(strconv.Itoa(rw.status/100) + "xx").Inc()
+
+		event := logger.Info()
+		if rw.status >= http.StatusInternalServerError {
+			event = logger.Error()
+		}
+
+		event.
+			Str("method", r.Method).
+			Str("path", r.URL.Path).
+			Str("route", routePattern(r)).
+			Str("remote_addr", r.RemoteAddr).
+			Int("status", rw.status).
+			Int64("bytes", rw.bytes).
+			Dur("latency", time.Since(start)).
+			Msg("Request completed")
+	})
+}
+
+// RequestID returns the ID of the request ctx belongs to, or "" outside of a request
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// validRequestID accepts IDs of printable ASCII that are short enough to log safely
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// routePattern returns the chi route that matched, so requests can be grouped regardless of their parameters
+func routePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		return rctx.RoutePattern()
+	}
+	return ""
+}
+
+// responseWriter records the status code and number of bytes written
+type responseWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+func (rw *responseWriter) WriteHeader(status int) {
+	if rw.wroteHeader {
+		return
+	}
+	rw.status = status
+	rw.wroteHeader = true
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController, e.g. for flushing
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
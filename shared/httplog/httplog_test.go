@@ -0,0 +1,142 @@
+package httplog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	original := log.Logger
+	log.Logger = zerolog.New(&buf)
+	t.Cleanup(func() { log.Logger = original })
+	return &buf
+}
+
+func TestMiddleware_LogsRequest(t *testing.T) {
+	buf := captureLogs(t)
+
+	var handlerID string
+	r := chi.NewRouter()
+	r.Use(Middleware)
+	r.Get("/posts/{id}", func(w http.ResponseWriter, r *http.Request) {
+		handlerID = RequestID(r.Context())
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("hello"))
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/posts/001", nil))
+
+	respID := rec.Header().Get(RequestIDHeader)
+	if respID == "" {
+		t.Fatal("response has no request ID")
+	}
+	if handlerID != respID {
+		t.Errorf("handler request ID = %q, want %q", handlerID, respID)
+	}
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("failed to decode log entry %q: %v", buf.String(), err)
+	}
+
+	want := map[string]any{
+		"request_id": respID,
+		"method":     "GET",
+		"path":       "/posts/001",
+		"route":      "/posts/{id}",
+		"status":     float64(http.StatusCreated),
+		"bytes":      float64(5),
+	}
+	for k, v := range want {
+		if entry[k] != v {
+			t.Errorf("log %s = %v, want %v", k, entry[k], v)
+		}
+	}
+	if _, ok := entry["latency"]; !ok {
+		t.Error("log entry has no latency")
+	}
+}
+
+func TestMiddleware_PropagatesRequestID(t *testing.T) {
+	buf := captureLogs(t)
+
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		zerolog.Ctx(r.Context()).Info().Msg("inside handler")
+	}))
+
+	tests := []struct {
+		name     string
+		header   string
+		wantSame bool
+	}{
+		{name: "client ID is kept", header: "abc-123", wantSame: true},
+		{name: "missing ID is generated", header: "", wantSame: false},
+		{name: "ID with spaces is replaced", header: "abc 123", wantSame: false},
+		{name: "overlong ID is replaced", header: strings.Repeat("a", maxRequestIDLength+1), wantSame: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(RequestIDHeader, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			got := rec.Header().Get(RequestIDHeader)
+			if got == "" {
+				t.Fatal("response has no request ID")
+			}
+			if (got == tt.header) != tt.wantSame {
+				t.Errorf("request ID = %q, header was %q", got, tt.header)
+			}
+
+			// The handler's own log line carries the ID through the request logger
+			var entry map[string]any
+			line, _, _ := bytes.Cut(buf.Bytes(), []byte("\n"))
+			if err := json.Unmarshal(line, &entry); err != nil {
+				t.Fatalf("failed to decode log entry %q: %v", line, err)
+			}
+			if entry["request_id"] != got {
+				t.Errorf("handler log request_id = %v, want %q", entry["request_id"], got)
+			}
+		})
+	}
+}
+
+func TestMiddleware_CountsRequests(t *testing.T) {
+	captureLogs(t)
+
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+
+	count := func() float64 {
+		var m dto.Metric
+		if err := requestsTotal.WithLabelValues("5xx").Write(&m); err != nil {
+			t.Fatal(err)
+		}
+		return m.GetCounter().GetValue()
+	}
+
+	before := count()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if got := count() - before; got != 1 {
+		t.Errorf("5xx requests counted = %v, want 1", got)
+	}
+}
@@ -0,0 +1,179 @@
+package mail
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"maps"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultSMTPPort = 587
+
+// ErrNotConfigured is returned when email is sent without an SMTP server to send it through
+var ErrNotConfigured = errors.New("smtp is not configured")
+
+// Message is a plain text email
+type Message struct {
+	To      []string
+	Subject string
+	Body    string
+	// Headers are added to the message, such as List-Unsubscribe
+	Headers map[string]string
+}
+
+// Sender delivers email
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+type Config struct {
+	// Host is the SMTP server; email is disabled when it is empty
+	Host string
+	Port int
+	// Username and Password authenticate with PLAIN auth, which is only sent over TLS; no auth is used when Username is empty
+	Username string
+	Password string
+	// From is the sender address
+	From string
+}
+
+func NewConfig() *Config {
+	port := defaultSMTPPort
+	if n, err := strconv.Atoi(os.Getenv("SMTP_PORT")); err == nil && n > 0 {
+		port = n
+	}
+
+	return &Config{
+		Host:     os.Getenv("SMTP_HOST"),
+		Port:     port,
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     cmp.Or(os.Getenv("SMTP_FROM"), os.Getenv("SMTP_USERNAME")),
+	}
+}
+
+// Enabled reports whether an SMTP server is configured
+func (c *Config) Enabled() bool {
+	return c.Host != ""
+}
+
+// SMTPSender sends email through an SMTP server, upgrading the connection with STARTTLS when the server offers it
+type SMTPSender struct {
+	cfg *Config
+}
+
+func NewSMTPSender(cfg *Config) (*SMTPSender, error) {
+	if !cfg.Enabled() {
+		return nil, ErrNotConfigured
+	}
+	if _, err := mail.ParseAddress(cfg.From); err != nil {
+		return nil, fmt.Errorf("invalid SMTP_FROM %q: %w", cfg.From, err)
+	}
+
+	return &SMTPSender{cfg: cfg}, nil
+}
+
+// Send delivers msg to each of its recipients
+func (s *SMTPSender) Send(ctx context.Context, msg *Message) error {
+	if len(msg.To) == 0 {
+		return fmt.Errorf("email has no recipients")
+	}
+	for _, to := range msg.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			return fmt.Errorf("invalid recipient %q: %w", to, err)
+		}
+	}
+
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		return fmt.Errorf("failed to start smtp session: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: s.cfg.Host}); err != nil {
+			return fmt.Errorf("failed to start tls: %w", err)
+		}
+	}
+	// smtp.PlainAuth refuses to send the password over an unencrypted connection to another host
+	if s.cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)); err != nil {
+			return fmt.Errorf("failed to authenticate: %w", err)
+		}
+	}
+
+	if err := client.Mail(s.cfg.From); err != nil {
+		return fmt.Errorf("failed to set sender: %w", err)
+	}
+	for _, to := range msg.To {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("failed to add recipient %s: %w", to, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to start message: %w", err)
+	}
+	if _, err := w.Write(formatMessage(s.cfg.From, msg, time.Now())); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+
+	return client.Quit()
+}
+
+// formatMessage encodes msg as a UTF-8 plain text email
+// The subject and extra headers are folded onto one line so they can't add headers.
+func formatMessage(from string, msg *Message, now time.Time) []byte {
+	subject := strings.Join(strings.Fields(msg.Subject), " ")
+	domain := from[strings.LastIndex(from, "@")+1:]
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(msg.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%s@%s>\r\n", rand.Text(), strings.TrimSuffix(domain, ">"))
+	for _, name := range slices.Sorted(maps.Keys(msg.Headers)) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", textproto.CanonicalMIMEHeaderKey(name), strings.Join(strings.Fields(msg.Headers[name]), " "))
+	}
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n")
+	buf.WriteString("\r\n")
+
+	qp := quotedprintable.NewWriter(&buf)
+	qp.Write([]byte(strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n")))
+	qp.Close()
+
+	return buf.Bytes()
+}
@@ -0,0 +1,128 @@
+package mail
+
+import (
+	"context"
+	"io"
+	"mime/quotedprintable"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatMessage(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	msg := formatMessage("blog@example.com", &Message{
+		To:      []string{"owner@example.com"},
+		Subject: "Digest\r\nBcc: someone@example.com",
+		Body:    "Two failures\n  café = ok\n",
+		Headers: map[string]string{"list-unsubscribe": "<https://blog.example/unsubscribe>\r\nBcc: someone@example.com"},
+	}, now)
+
+	headers, body, ok := strings.Cut(string(msg), "\r\n\r\n")
+	if !ok {
+		t.Fatalf("Expected headers and a body, got %q", msg)
+	}
+	for _, want := range []string{
+		"From: blog@example.com\r\n",
+		"To: owner@example.com\r\n",
+		"Subject: Digest Bcc: someone@example.com\r\n",
+		"Date: Fri, 02 Jan 2026 03:04:05 +0000\r\n",
+		"Content-Type: text/plain; charset=utf-8\r\n",
+		"List-Unsubscribe: <https://blog.example/unsubscribe> Bcc: someone@example.com\r\n",
+	} {
+		if !strings.Contains(headers+"\r\n", want) {
+			t.Errorf("Expected headers to contain %q, got:\n%s", want, headers)
+		}
+	}
+
+	decoded, err := io.ReadAll(quotedprintable.NewReader(strings.NewReader(body)))
+	if err != nil {
+		t.Fatalf("Failed to decode body: %v", err)
+	}
+	if got := string(decoded); got != "Two failures\r\n  café = ok\r\n" {
+		t.Errorf("body = %q", got)
+	}
+}
+
+func TestSMTPSender_Send(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	received := make(chan []string, 1)
+	go serveSMTP(t, listener, received)
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	cfg := &Config{Host: host, From: "blog@example.com"}
+	cfg.Port, _ = net.LookupPort("tcp", port)
+	sender, err := NewSMTPSender(cfg)
+	if err != nil {
+		t.Fatalf("NewSMTPSender() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := sender.Send(ctx, &Message{To: []string{"owner@example.com"}, Subject: "Digest", Body: "Hello"}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	commands := <-received
+	joined := strings.Join(commands, "\n")
+	for _, want := range []string{"MAIL FROM:<blog@example.com>", "RCPT TO:<owner@example.com>", "Subject: Digest"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("Expected the server to receive %q, got:\n%s", want, joined)
+		}
+	}
+}
+
+func TestNewSMTPSender_Config(t *testing.T) {
+	if _, err := NewSMTPSender(&Config{}); err != ErrNotConfigured {
+		t.Errorf("NewSMTPSender() without a host error = %v, want ErrNotConfigured", err)
+	}
+	if _, err := NewSMTPSender(&Config{Host: "localhost", From: "not an address"}); err == nil {
+		t.Error("Expected an invalid sender address to be refused")
+	}
+}
+
+// serveSMTP answers one SMTP session without extensions, and sends every line it received
+func serveSMTP(t *testing.T, listener net.Listener, received chan<- []string) {
+	conn, err := listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	text := textproto.NewConn(conn)
+	var lines []string
+	text.PrintfLine("220 localhost ESMTP")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			received <- lines
+			return
+		}
+		lines = append(lines, line)
+		switch command := strings.ToUpper(strings.Fields(line + " ")[0]); command {
+		case "EHLO", "HELO":
+			text.PrintfLine("250 localhost")
+		case "DATA":
+			text.PrintfLine("354 go ahead")
+			data, err := text.ReadDotLines()
+			if err != nil {
+				t.Errorf("Failed to read message: %v", err)
+			}
+			lines = append(lines, data...)
+			text.PrintfLine("250 queued")
+		case "QUIT":
+			text.PrintfLine("221 bye")
+			received <- lines
+			return
+		default:
+			text.PrintfLine("250 ok")
+		}
+	}
+}
@@ -0,0 +1,71 @@
+// Package metrics turns the Prometheus registry into plain values for clients that do not scrape it
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Snapshot returns the current value of every counter and gauge whose name starts with prefix
+// Metrics without labels map to a number. Labelled metrics map to an object keyed by their labels,
+// e.g. {"status=2xx": 10}. Histograms and summaries are left out.
+func Snapshot(gatherer prometheus.Gatherer, prefix string) (map[string]any, error) {
+	families, err := gatherer.Gather()
+	if err != nil {
+		return nil, fmt.Errorf("failed to gather metrics: %w", err)
+	}
+
+	snapshot := make(map[string]any)
+	for _, family := range families {
+		if !strings.HasPrefix(family.GetName(), prefix) {
+			continue
+		}
+
+		values := make(map[string]float64)
+		for _, m := range family.GetMetric() {
+			value, ok := metricValue(family.GetType(), m)
+			if !ok {
+				continue
+			}
+			values[labelKey(m.GetLabel())] = value
+		}
+
+		if len(values) == 0 {
+			continue
+		}
+		if value, ok := values[""]; ok && len(values) == 1 {
+			snapshot[family.GetName()] = value
+			continue
+		}
+		snapshot[family.GetName()] = values
+	}
+
+	return snapshot, nil
+}
+
+func metricValue(kind dto.MetricType, m *dto.Metric) (float64, bool) {
+	switch kind {
+	case dto.MetricType_COUNTER:
+		return m.GetCounter().GetValue(), true
+	case dto.MetricType_GAUGE:
+		return m.GetGauge().GetValue(), true
+	case dto.MetricType_UNTYPED:
+		return m.GetUntyped().GetValue(), true
+	default:
+		return 0, false
+	}
+}
+
+// labelKey joins a metric's labels as name=value pairs, sorted by name
+func labelKey(labels []*dto.LabelPair) string {
+	pairs := make([]string, 0, len(labels))
+	for _, label := range labels {
+		pairs = append(pairs, label.GetName()+"="+label.GetValue())
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestSnapshot(t *testing.T) {
+	registry := prometheus.NewRegistry()
+
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "app_requests_total", Help: "h"}, []string{"status"})
+	depth := prometheus.NewGauge(prometheus.GaugeOpts{Name: "app_queue_depth", Help: "h"})
+	latency := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "app_latency_seconds", Help: "h"})
+	other := prometheus.NewGauge(prometheus.GaugeOpts{Name: "other_gauge", Help: "h"})
+	registry.MustRegister(requests, depth, latency, other)
+
+	requests.WithLabelValues("2xx").Add(3)
+	requests.WithLabelValues("5xx").Inc()
+	depth.Set(2)
+	latency.Observe(1)
+
+	snapshot, err := Snapshot(registry, "app_")
+	if err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+
+	if got := snapshot["app_queue_depth"]; got != 2.0 {
+		t.Errorf("app_queue_depth = %v, want 2", got)
+	}
+
+	byStatus, ok := snapshot["app_requests_total"].(map[string]float64)
+	if !ok {
+		t.Fatalf("app_requests_total = %#v, want values by label", snapshot["app_requests_total"])
+	}
+	if byStatus["status=2xx"] != 3 || byStatus["status=5xx"] != 1 {
+		t.Errorf("app_requests_total = %v", byStatus)
+	}
+
+	for _, name := range []string{"app_latency_seconds", "other_gauge"} {
+		if _, ok := snapshot[name]; ok {
+			t.Errorf("%s should not be in the snapshot", name)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+const (
+	defaultTelegramAPIURL = "https://api.telegram.org"
+
+	// Longest messages the services accept; longer text is cut short
+	discordMaxLength  = 2000
+	telegramMaxLength = 4096
+)
+
+// ErrNotConfigured is returned when a channel is missing the settings it needs to send
+var ErrNotConfigured = errors.New("channel is not configured")
+
+type MatrixConfig struct {
+	// HomeserverURL is the base URL of the homeserver, such as https://matrix.example.org
+	HomeserverURL string
+	// AccessToken authenticates the account messages are sent as, which must have joined the room
+	AccessToken string
+	// RoomID is the room messages are sent to, such as !abc123:example.org
+	RoomID string
+}
+
+// MatrixChannel sends notifications as text messages to a Matrix room
+type MatrixChannel struct {
+	cfg    MatrixConfig
+	client *http.Client
+}
+
+func NewMatrixChannel(cfg MatrixConfig, client *http.Client) (*MatrixChannel, error) {
+	if cfg.HomeserverURL == "" || cfg.AccessToken == "" || cfg.RoomID == "" {
+		return nil, fmt.Errorf("%w: matrix needs a homeserver URL, access token and room ID", ErrNotConfigured)
+	}
+	return &MatrixChannel{cfg: cfg, client: client}, nil
+}
+
+func (c *MatrixChannel) Send(ctx context.Context, text string) error {
+	// The transaction ID makes a retried request idempotent, so each message gets its own
+	endpoint := c.cfg.HomeserverURL + "/_matrix/client/v3/rooms/" + url.PathEscape(c.cfg.RoomID) +
+		"/send/m.room.message/" + rand.Text()
+	body := map[string]string{"msgtype": "m.notice", "body": text}
+	return sendJSON(ctx, c.client, http.MethodPut, endpoint, "Bearer "+c.cfg.AccessToken, body)
+}
+
+type DiscordConfig struct {
+	// WebhookURL is the channel webhook messages are posted to
+	WebhookURL string
+}
+
+// DiscordChannel posts notifications to a Discord channel through a webhook
+type DiscordChannel struct {
+	cfg    DiscordConfig
+	client *http.Client
+}
+
+func NewDiscordChannel(cfg DiscordConfig, client *http.Client) (*DiscordChannel, error) {
+	if cfg.WebhookURL == "" {
+		return nil, fmt.Errorf("%w: discord needs a webhook URL", ErrNotConfigured)
+	}
+	return &DiscordChannel{cfg: cfg, client: client}, nil
+}
+
+func (c *DiscordChannel) Send(ctx context.Context, text string) error {
+	body := map[string]any{
+		"content": truncate(text, discordMaxLength),
+		// Post titles and errors are not trusted to mention people
+		"allowed_mentions": map[string][]string{"parse": {}},
+	}
+	return sendJSON(ctx, c.client, http.MethodPost, c.cfg.WebhookURL, "", body)
+}
+
+type TelegramConfig struct {
+	// APIURL is the Bot API's base URL
+	APIURL string
+	// BotToken authenticates the bot messages are sent as
+	BotToken string
+	// ChatID is the chat, group or channel messages are sent to, which the bot must be a member of
+	ChatID string
+}
+
+// TelegramChannel sends notifications to a Telegram chat as a bot
+type TelegramChannel struct {
+	cfg    TelegramConfig
+	client *http.Client
+}
+
+func NewTelegramChannel(cfg TelegramConfig, client *http.Client) (*TelegramChannel, error) {
+	if cfg.BotToken == "" || cfg.ChatID == "" {
+		return nil, fmt.Errorf("%w: telegram needs a bot token and chat ID", ErrNotConfigured)
+	}
+	if cfg.APIURL == "" {
+		cfg.APIURL = defaultTelegramAPIURL
+	}
+	return &TelegramChannel{cfg: cfg, client: client}, nil
+}
+
+func (c *TelegramChannel) Send(ctx context.Context, text string) error {
+	endpoint := c.cfg.APIURL + "/bot" + c.cfg.BotToken + "/sendMessage"
+	body := map[string]any{
+		"chat_id":                  c.cfg.ChatID,
+		"text":                     truncate(text, telegramMaxLength),
+		"disable_web_page_preview": true,
+	}
+	err := sendJSON(ctx, c.client, http.MethodPost, endpoint, "", body)
+	// The bot token is part of the URL, which the client includes in its errors
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return fmt.Errorf("failed to send telegram message: %w", urlErr.Err)
+	}
+	return err
+}
+
+// sendJSON sends body as JSON, failing unless the response is a 2xx
+func sendJSON(ctx context.Context, client *http.Client, method string, endpoint string, authorization string, body any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response %s", resp.Status)
+	}
+	return nil
+}
+
+// truncate cuts text down to at most limit runes, marking that it was cut with an ellipsis
+func truncate(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit-1]) + "…"
+}
@@ -0,0 +1,123 @@
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// recordedRequest is a request received by a test server
+type recordedRequest struct {
+	method string
+	path   string
+	auth   string
+	body   map[string]any
+}
+
+func newRecordingServer(t *testing.T, status int) (*httptest.Server, *[]recordedRequest) {
+	t.Helper()
+	var requests []recordedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := recordedRequest{method: r.Method, path: r.URL.Path, auth: r.Header.Get("Authorization")}
+		if err := json.NewDecoder(r.Body).Decode(&req.body); err != nil {
+			t.Errorf("invalid JSON body: %v", err)
+		}
+		requests = append(requests, req)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestMatrixChannel(t *testing.T) {
+	server, requests := newRecordingServer(t, http.StatusOK)
+	channel, err := NewMatrixChannel(MatrixConfig{HomeserverURL: server.URL, AccessToken: "secret", RoomID: "!room:example.org"}, server.Client())
+	if err != nil {
+		t.Fatalf("NewMatrixChannel() error = %v", err)
+	}
+
+	for range 2 {
+		if err := channel.Send(t.Context(), "Published"); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+	}
+
+	req := (*requests)[0]
+	if req.method != http.MethodPut || !strings.HasPrefix(req.path, "/_matrix/client/v3/rooms/!room:example.org/send/m.room.message/") {
+		t.Errorf("request = %s %s, want a PUT of a room message", req.method, req.path)
+	}
+	if req.auth != "Bearer secret" || req.body["body"] != "Published" || req.body["msgtype"] != "m.notice" {
+		t.Errorf("request = %+v, want an authenticated notice", req)
+	}
+	if (*requests)[1].path == req.path {
+		t.Error("Expected each message to use its own transaction ID")
+	}
+}
+
+func TestDiscordChannel(t *testing.T) {
+	server, requests := newRecordingServer(t, http.StatusNoContent)
+	channel, err := NewDiscordChannel(DiscordConfig{WebhookURL: server.URL + "/api/webhooks/1/token"}, server.Client())
+	if err != nil {
+		t.Fatalf("NewDiscordChannel() error = %v", err)
+	}
+
+	if err := channel.Send(t.Context(), strings.Repeat("a", 3000)); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	req := (*requests)[0]
+	content, _ := req.body["content"].(string)
+	if req.method != http.MethodPost || req.path != "/api/webhooks/1/token" || len([]rune(content)) != discordMaxLength {
+		t.Errorf("request = %s %s with %d runes, want the message posted to the webhook cut to %d", req.method, req.path, len([]rune(content)), discordMaxLength)
+	}
+	if _, ok := req.body["allowed_mentions"]; !ok {
+		t.Error("Expected mentions to be disabled")
+	}
+}
+
+func TestTelegramChannel(t *testing.T) {
+	server, requests := newRecordingServer(t, http.StatusOK)
+	channel, err := NewTelegramChannel(TelegramConfig{APIURL: server.URL, BotToken: "123:abc", ChatID: "-100"}, server.Client())
+	if err != nil {
+		t.Fatalf("NewTelegramChannel() error = %v", err)
+	}
+
+	if err := channel.Send(t.Context(), "Failed"); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	req := (*requests)[0]
+	if req.path != "/bot123:abc/sendMessage" || req.body["chat_id"] != "-100" || req.body["text"] != "Failed" {
+		t.Errorf("request = %+v, want the message sent to the chat", req)
+	}
+
+	server.Close()
+	err = channel.Send(t.Context(), "Failed")
+	if err == nil || strings.Contains(err.Error(), "123:abc") {
+		t.Errorf("Send() to a closed server error = %v, want an error without the bot token", err)
+	}
+}
+
+func TestChannel_ErrorStatus(t *testing.T) {
+	server, _ := newRecordingServer(t, http.StatusForbidden)
+	channel, _ := NewDiscordChannel(DiscordConfig{WebhookURL: server.URL}, server.Client())
+
+	if err := channel.Send(t.Context(), "hello"); err == nil {
+		t.Error("Expected a 403 to fail")
+	}
+}
+
+func TestNewChannel_NotConfigured(t *testing.T) {
+	if _, err := NewMatrixChannel(MatrixConfig{HomeserverURL: "https://matrix.example"}, nil); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("NewMatrixChannel() error = %v, want ErrNotConfigured", err)
+	}
+	if _, err := NewDiscordChannel(DiscordConfig{}, nil); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("NewDiscordChannel() error = %v, want ErrNotConfigured", err)
+	}
+	if _, err := NewTelegramChannel(TelegramConfig{BotToken: "123:abc"}, nil); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("NewTelegramChannel() error = %v, want ErrNotConfigured", err)
+	}
+}
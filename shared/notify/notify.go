@@ -0,0 +1,144 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dfryer1193/goblog/shared/env"
+)
+
+const defaultTimeout = 10 * time.Second
+
+// Event is a kind of operational event operators can be notified of
+type Event string
+
+const (
+	// EventFailure is a file that failed to process
+	EventFailure Event = "failure"
+	// EventPublish is a post going live
+	EventPublish Event = "publish"
+)
+
+// Names of the channels events can be routed to
+const (
+	ChannelMatrix   = "matrix"
+	ChannelDiscord  = "discord"
+	ChannelTelegram = "telegram"
+)
+
+// Channel delivers a plain text notification somewhere operators will see it
+type Channel interface {
+	Send(ctx context.Context, text string) error
+}
+
+// Notifier sends operational events to the channels configured for them
+type Notifier interface {
+	// Notify sends text to every channel configured for event, returning the errors of those that failed
+	Notify(ctx context.Context, event Event, text string) error
+	// Wants reports whether any channel is configured for event
+	Wants(event Event) bool
+}
+
+type Config struct {
+	// Routes names the channels each event is sent to
+	Routes map[Event][]string
+
+	Matrix   MatrixConfig
+	Discord  DiscordConfig
+	Telegram TelegramConfig
+}
+
+func NewConfig() *Config {
+	return &Config{
+		Routes: map[Event][]string{
+			EventFailure: env.SplitList(os.Getenv("NOTIFY_FAILURES")),
+			EventPublish: env.SplitList(os.Getenv("NOTIFY_PUBLISHES")),
+		},
+		Matrix: MatrixConfig{
+			HomeserverURL: strings.TrimSuffix(os.Getenv("MATRIX_HOMESERVER_URL"), "/"),
+			AccessToken:   os.Getenv("MATRIX_ACCESS_TOKEN"),
+			RoomID:        os.Getenv("MATRIX_ROOM_ID"),
+		},
+		Discord: DiscordConfig{
+			WebhookURL: os.Getenv("DISCORD_WEBHOOK_URL"),
+		},
+		Telegram: TelegramConfig{
+			APIURL:   defaultTelegramAPIURL,
+			BotToken: os.Getenv("TELEGRAM_BOT_TOKEN"),
+			ChatID:   os.Getenv("TELEGRAM_CHAT_ID"),
+		},
+	}
+}
+
+// Router is a Notifier sending each event to the channels its route names
+type Router struct {
+	routes map[Event][]namedChannel
+}
+
+type namedChannel struct {
+	name string
+	Channel
+}
+
+// NewRouter builds the channels the routes in cfg name, sending through client, or a default client when it is nil
+// It fails when a route names an unknown channel or one that isn't configured.
+func NewRouter(cfg *Config, client *http.Client) (*Router, error) {
+	if client == nil {
+		client = &http.Client{Timeout: defaultTimeout}
+	}
+
+	r := &Router{routes: make(map[Event][]namedChannel)}
+	channels := make(map[string]Channel)
+	for event, names := range cfg.Routes {
+		for _, name := range names {
+			channel, ok := channels[name]
+			if !ok {
+				var err error
+				if channel, err = newChannel(cfg, name, client); err != nil {
+					return nil, fmt.Errorf("%s notifications: %w", event, err)
+				}
+				channels[name] = channel
+			}
+			r.routes[event] = append(r.routes[event], namedChannel{name: name, Channel: channel})
+		}
+	}
+
+	return r, nil
+}
+
+func newChannel(cfg *Config, name string, client *http.Client) (Channel, error) {
+	switch name {
+	case ChannelMatrix:
+		return NewMatrixChannel(cfg.Matrix, client)
+	case ChannelDiscord:
+		return NewDiscordChannel(cfg.Discord, client)
+	case ChannelTelegram:
+		return NewTelegramChannel(cfg.Telegram, client)
+	default:
+		return nil, fmt.Errorf("unknown channel %q", name)
+	}
+}
+
+func (r *Router) Notify(ctx context.Context, event Event, text string) error {
+	var errs []error
+	for _, channel := range r.routes[event] {
+		if err := channel.Send(ctx, text); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", channel.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *Router) Wants(event Event) bool {
+	return len(r.routes[event]) > 0
+}
+
+// Enabled reports whether any event is routed to a channel
+func (r *Router) Enabled() bool {
+	return len(r.routes) > 0
+}
@@ -0,0 +1,76 @@
+package notify
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestRouter(t *testing.T) {
+	matrix, matrixRequests := newRecordingServer(t, http.StatusOK)
+	discord, discordRequests := newRecordingServer(t, http.StatusInternalServerError)
+
+	cfg := &Config{
+		Routes: map[Event][]string{
+			EventFailure: {ChannelMatrix},
+			EventPublish: {ChannelDiscord, ChannelMatrix},
+		},
+		Matrix:  MatrixConfig{HomeserverURL: matrix.URL, AccessToken: "secret", RoomID: "!room:example.org"},
+		Discord: DiscordConfig{WebhookURL: discord.URL},
+	}
+	router, err := NewRouter(cfg, http.DefaultClient)
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+
+	if err := router.Notify(t.Context(), EventFailure, "Failed"); err != nil {
+		t.Fatalf("Notify(failure) error = %v", err)
+	}
+	if len(*matrixRequests) != 1 || len(*discordRequests) != 0 {
+		t.Errorf("failure sent to %d matrix and %d discord, want matrix only", len(*matrixRequests), len(*discordRequests))
+	}
+
+	// A failing channel doesn't stop the others
+	if err := router.Notify(t.Context(), EventPublish, "Published"); err == nil {
+		t.Error("Expected the discord failure to be returned")
+	}
+	if len(*matrixRequests) != 2 || len(*discordRequests) != 1 {
+		t.Errorf("publish sent to %d matrix and %d discord, want both", len(*matrixRequests)-1, len(*discordRequests))
+	}
+
+	if !router.Wants(EventPublish) || !router.Enabled() {
+		t.Error("Expected publishes to be wanted")
+	}
+}
+
+func TestNewRouter_Invalid(t *testing.T) {
+	if _, err := NewRouter(&Config{Routes: map[Event][]string{EventFailure: {"pager"}}}, nil); err == nil {
+		t.Error("Expected an unknown channel to be refused")
+	}
+	_, err := NewRouter(&Config{Routes: map[Event][]string{EventPublish: {ChannelTelegram}}}, nil)
+	if !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("NewRouter() with an unconfigured channel error = %v, want ErrNotConfigured", err)
+	}
+
+	router, err := NewRouter(&Config{}, nil)
+	if err != nil || router.Enabled() || router.Wants(EventFailure) {
+		t.Errorf("NewRouter() without routes = %v, %v, want a router sending nothing", router, err)
+	}
+}
+
+func TestNewConfig(t *testing.T) {
+	t.Setenv("NOTIFY_FAILURES", "matrix, telegram")
+	t.Setenv("NOTIFY_PUBLISHES", "")
+	t.Setenv("MATRIX_HOMESERVER_URL", "https://matrix.example/")
+
+	cfg := NewConfig()
+	if got := cfg.Routes[EventFailure]; len(got) != 2 || got[0] != ChannelMatrix || got[1] != ChannelTelegram {
+		t.Errorf("failure routes = %v", got)
+	}
+	if len(cfg.Routes[EventPublish]) != 0 {
+		t.Errorf("publish routes = %v, want none", cfg.Routes[EventPublish])
+	}
+	if cfg.Matrix.HomeserverURL != "https://matrix.example" || cfg.Telegram.APIURL != defaultTelegramAPIURL {
+		t.Errorf("cfg = %+v", cfg)
+	}
+}
@@ -0,0 +1,67 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Job is a unit of periodic background work
+// The context is cancelled when the scheduler is closed
+type Job func(ctx context.Context) error
+
+// Scheduler runs jobs on fixed intervals until it is closed
+type Scheduler struct {
+	// Scheduler lifecycle context - cancelled when Close() is called
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     *sync.WaitGroup
+}
+
+// New creates a new Scheduler with no jobs
+func New() *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		ctx:    ctx,
+		cancel: cancel,
+		wg:     &sync.WaitGroup{},
+	}
+}
+
+// Every runs job every interval, starting one interval from now
+// A failing job is logged and retried on the next tick
+func (s *Scheduler) Every(name string, interval time.Duration, job Job) {
+	s.wg.Go(func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-ticker.C:
+				s.run(name, job)
+			}
+		}
+	})
+}
+
+func (s *Scheduler) run(name string, job Job) {
+	start := time.Now()
+	if err := job(s.ctx); err != nil {
+		log.Error().Err(err).Str("job", name).Msg("Scheduled job failed")
+		return
+	}
+
+	log.Debug().Str("job", name).Dur("duration", time.Since(start)).Msg("Scheduled job completed")
+}
+
+// Close stops scheduling new runs and waits for running jobs to finish
+func (s *Scheduler) Close() error {
+	s.cancel()
+	s.wg.Wait()
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestScheduler_Every(t *testing.T) {
+	s := New()
+
+	var runs atomic.Int32
+	s.Every("counter", 5*time.Millisecond, func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	})
+
+	time.Sleep(50 * time.Millisecond)
+	s.Close()
+
+	if runs.Load() < 2 {
+		t.Errorf("job ran %d times, want at least 2", runs.Load())
+	}
+}
+
+func TestScheduler_FailingJobKeepsRunning(t *testing.T) {
+	s := New()
+
+	var runs atomic.Int32
+	s.Every("failing", 5*time.Millisecond, func(ctx context.Context) error {
+		runs.Add(1)
+		return errors.New("boom")
+	})
+
+	time.Sleep(50 * time.Millisecond)
+	s.Close()
+
+	if runs.Load() < 2 {
+		t.Errorf("failing job ran %d times, want at least 2", runs.Load())
+	}
+}
+
+func TestScheduler_CloseCancelsContext(t *testing.T) {
+	s := New()
+
+	started := make(chan struct{})
+	var cancelled atomic.Bool
+	s.Every("blocking", time.Millisecond, func(ctx context.Context) error {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-ctx.Done()
+		cancelled.Store(true)
+		return ctx.Err()
+	})
+
+	<-started
+	s.Close()
+
+	if !cancelled.Load() {
+		t.Error("job context was not cancelled on Close")
+	}
+}
@@ -0,0 +1,75 @@
+package http
+
+import (
+	"cmp"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/dfryer1193/goblog/shared/env"
+	"github.com/google/go-github/v75/github"
+)
+
+// defaultEvents are the event types the handler acts on
+const defaultEvents = "push,create,release,pull_request"
+
+// FilterConfig limits which signed deliveries the webhook handler acts on
+// The secret proves a delivery came from GitHub, not which repository sent it, so a secret shared
+// between repositories would otherwise let any of them publish to the blog.
+type FilterConfig struct {
+	// Repositories are the owner/name of repositories whose events are accepted
+	Repositories []string
+	// Branches are the branches whose pushes and pull requests are accepted, or empty for all
+	Branches []string
+	// Events are the accepted event types, as named in the X-GitHub-Event header
+	Events []string
+}
+
+// NewFilterConfig accepts events from repository unless WEBHOOK_REPOSITORIES names others
+func NewFilterConfig(repository string) *FilterConfig {
+	return &FilterConfig{
+		Repositories: env.SplitList(cmp.Or(os.Getenv("WEBHOOK_REPOSITORIES"), repository)),
+		Branches:     env.SplitList(os.Getenv("WEBHOOK_BRANCHES")),
+		Events:       env.SplitList(cmp.Or(os.Getenv("WEBHOOK_EVENTS"), defaultEvents)),
+	}
+}
+
+// allowsEvent reports whether deliveries of an event type are acted on
+func (c *FilterConfig) allowsEvent(eventType string) bool {
+	return slices.Contains(c.Events, eventType)
+}
+
+// allowsRepository reports whether events from a repository are acted on; GitHub names are case insensitive
+func (c *FilterConfig) allowsRepository(fullName string) bool {
+	return slices.ContainsFunc(c.Repositories, func(r string) bool {
+		return strings.EqualFold(r, fullName)
+	})
+}
+
+// allowsBranch reports whether events for a branch are acted on
+func (c *FilterConfig) allowsBranch(branch string) bool {
+	return len(c.Branches) == 0 || slices.Contains(c.Branches, branch)
+}
+
+// eventSource returns the repository an event came from and the branch it concerns
+// branch is empty for events about tags and releases, which are not filtered by branch.
+func eventSource(event any) (repository string, branch string) {
+	switch evt := event.(type) {
+	case *github.PushEvent:
+		branch, _ = strings.CutPrefix(evt.GetRef(), "refs/heads/")
+		if branch == evt.GetRef() {
+			branch = ""
+		}
+		return evt.GetRepo().GetFullName(), branch
+	case *github.CreateEvent:
+		if evt.GetRefType() == "branch" {
+			branch = evt.GetRef()
+		}
+		return evt.GetRepo().GetFullName(), branch
+	case *github.ReleaseEvent:
+		return evt.GetRepo().GetFullName(), ""
+	case *github.PullRequestEvent:
+		return evt.GetRepo().GetFullName(), evt.GetPullRequest().GetBase().GetRef()
+	}
+	return "", ""
+}
@@ -0,0 +1,70 @@
+package http
+
+import (
+	"testing"
+
+	"github.com/dfryer1193/goblog/shared/env"
+	"github.com/google/go-github/v75/github"
+)
+
+func TestFilterConfig(t *testing.T) {
+	filter := &FilterConfig{
+		Repositories: []string{"owner/blog"},
+		Branches:     []string{"main"},
+		Events:       env.SplitList(defaultEvents),
+	}
+
+	repo := &github.Repository{FullName: github.Ptr("Owner/Blog")}
+	tests := []struct {
+		name  string
+		event any
+		want  bool
+	}{
+		{
+			name:  "push to an accepted branch",
+			event: &github.PushEvent{Ref: github.Ptr("refs/heads/main"), Repo: &github.PushEventRepository{FullName: github.Ptr("owner/blog")}},
+			want:  true,
+		},
+		{
+			name:  "push to another branch",
+			event: &github.PushEvent{Ref: github.Ptr("refs/heads/draft"), Repo: &github.PushEventRepository{FullName: github.Ptr("owner/blog")}},
+			want:  false,
+		},
+		{
+			name:  "push of a tag",
+			event: &github.PushEvent{Ref: github.Ptr("refs/tags/v1"), Repo: &github.PushEventRepository{FullName: github.Ptr("owner/blog")}},
+			want:  true,
+		},
+		{
+			name:  "push from another repository",
+			event: &github.PushEvent{Ref: github.Ptr("refs/heads/main"), Repo: &github.PushEventRepository{FullName: github.Ptr("someone/fork")}},
+			want:  false,
+		},
+		{
+			name:  "release",
+			event: &github.ReleaseEvent{Repo: repo},
+			want:  true,
+		},
+		{
+			name: "pull request into another branch",
+			event: &github.PullRequestEvent{Repo: repo, PullRequest: &github.PullRequest{
+				Base: &github.PullRequestBranch{Ref: github.Ptr("draft")},
+			}},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repository, branch := eventSource(tt.event)
+			got := filter.allowsRepository(repository) && (branch == "" || filter.allowsBranch(branch))
+			if got != tt.want {
+				t.Errorf("accepted = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if filter.allowsEvent("issues") || !filter.allowsEvent("push") {
+		t.Error("Expected only the configured event types to be accepted")
+	}
+}
@@ -0,0 +1,111 @@
+package http
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/dfryer1193/mjolnir/utils/httpx"
+	"github.com/google/go-github/v75/github"
+	"github.com/rs/zerolog/log"
+)
+
+// pingResponse reports what a ping found wrong with the hook, which GitHub shows as the response of the delivery
+type pingResponse struct {
+	OK       bool     `json:"ok"`
+	Problems []string `json:"problems,omitempty"`
+}
+
+// handlePing checks the configuration of the hook that sent a ping, answering 422 with what to fix when it won't work
+func (h *WebhookHandler) handlePing(w http.ResponseWriter, r *http.Request, payload []byte) {
+	event, err := github.ParseWebHook("ping", payload)
+	if err != nil {
+		h.postService.RecordWebhookFailure(github.DeliveryID(r), "ping", "Invalid event")
+		http.Error(w, "Invalid event", http.StatusBadRequest)
+		return
+	}
+
+	problems := h.verifyHook(event.(*github.PingEvent))
+	h.postService.RecordWebhookPing(problems)
+
+	status := http.StatusOK
+	if len(problems) > 0 {
+		log.Warn().Strs("problems", problems).Msg("Webhook ping found problems with the hook's configuration")
+		status = http.StatusUnprocessableEntity
+	}
+	httpx.RespondJSON(w, r, status, pingResponse{OK: len(problems) == 0, Problems: problems})
+}
+
+// verifyHook returns the problems with a pinging hook's configuration, as instructions for fixing them
+func (h *WebhookHandler) verifyHook(evt *github.PingEvent) []string {
+	var problems []string
+	hook := evt.GetHook()
+
+	// Form encoded payloads can be validated too, but every other tool setting up the hook uses JSON
+	if contentType := hook.GetConfig().GetContentType(); contentType != "json" {
+		problems = append(problems, fmt.Sprintf("The hook's content type is %s; set it to application/json", cmp.Or(contentType, "form")))
+	}
+
+	if !slices.Contains(hook.Events, "*") {
+		for _, event := range h.filter.Events {
+			if !slices.Contains(hook.Events, event) {
+				problems = append(problems, fmt.Sprintf("The hook is not subscribed to %s events; select them in the hook's settings", event))
+			}
+		}
+	}
+
+	if repository := evt.GetRepo().GetFullName(); repository != "" && !h.filter.allowsRepository(repository) {
+		problems = append(problems, fmt.Sprintf("Deliveries from %s are refused; add it to WEBHOOK_REPOSITORIES or move the hook to an accepted repository", repository))
+	}
+	return problems
+}
+
+// handleInstallation logs changes to the GitHub App's installation, recording those that cut the server off
+// from an accepted repository as failures, since deliveries and API calls for it stop without any other sign
+func (h *WebhookHandler) handleInstallation(w http.ResponseWriter, r *http.Request, eventType string, payload []byte) {
+	deliveryID := github.DeliveryID(r)
+	event, err := github.ParseWebHook(eventType, payload)
+	if err != nil {
+		h.postService.RecordWebhookFailure(deliveryID, eventType, "Invalid event")
+		http.Error(w, "Invalid event", http.StatusBadRequest)
+		return
+	}
+
+	var problems []string
+	switch evt := event.(type) {
+	case *github.InstallationEvent:
+		switch evt.GetAction() {
+		case "deleted":
+			problems = append(problems, "The GitHub App was uninstalled")
+		case "suspend":
+			problems = append(problems, "The GitHub App's installation was suspended")
+		}
+	case *github.InstallationRepositoriesEvent:
+		for _, repo := range evt.RepositoriesRemoved {
+			if h.filter.allowsRepository(repo.GetFullName()) {
+				problems = append(problems, fmt.Sprintf("The GitHub App no longer has access to %s", repo.GetFullName()))
+			}
+		}
+	}
+
+	for _, problem := range problems {
+		log.Warn().Str("deliveryID", deliveryID).Int64("installation", installationID(event)).Msg(problem)
+		h.postService.RecordWebhookFailure(deliveryID, eventType, problem)
+	}
+	if len(problems) == 0 {
+		log.Info().Str("event", eventType).Int64("installation", installationID(event)).Msg("GitHub App installation changed")
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// installationID returns the ID of the installation an installation event is about
+func installationID(event any) int64 {
+	switch evt := event.(type) {
+	case *github.InstallationEvent:
+		return evt.GetInstallation().GetID()
+	case *github.InstallationRepositoriesEvent:
+		return evt.GetInstallation().GetID()
+	}
+	return 0
+}
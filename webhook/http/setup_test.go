@@ -0,0 +1,32 @@
+package http
+
+import (
+	"testing"
+
+	"github.com/google/go-github/v75/github"
+)
+
+func TestVerifyHook(t *testing.T) {
+	h := &WebhookHandler{filter: &FilterConfig{Repositories: []string{"owner/blog"}, Events: []string{"push", "release"}}}
+
+	sound := &github.PingEvent{
+		Hook: &github.Hook{Config: &github.HookConfig{ContentType: github.Ptr("json")}, Events: []string{"push", "release", "issues"}},
+		Repo: &github.Repository{FullName: github.Ptr("owner/blog")},
+	}
+	if problems := h.verifyHook(sound); len(problems) != 0 {
+		t.Errorf("verifyHook() = %v, want no problems", problems)
+	}
+
+	wildcard := &github.PingEvent{Hook: &github.Hook{Config: &github.HookConfig{ContentType: github.Ptr("json")}, Events: []string{"*"}}}
+	if problems := h.verifyHook(wildcard); len(problems) != 0 {
+		t.Errorf("verifyHook() = %v, want every event to be covered by *", problems)
+	}
+
+	broken := &github.PingEvent{
+		Hook: &github.Hook{Config: &github.HookConfig{ContentType: github.Ptr("form")}, Events: []string{"push"}},
+		Repo: &github.Repository{FullName: github.Ptr("someone/fork")},
+	}
+	if problems := h.verifyHook(broken); len(problems) != 3 {
+		t.Errorf("verifyHook() = %v, want the content type, the missing release events and the repository reported", problems)
+	}
+}
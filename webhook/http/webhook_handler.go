@@ -1,24 +1,40 @@
 package http
 
 import (
+	"fmt"
 	"net/http"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/dfryer1193/goblog/blog/application"
+	"github.com/dfryer1193/mjolnir/utils/httpx"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/go-github/v75/github"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
 )
 
 const (
-	repoName = "dfryer1193/blog"
+	// WebhookPath is where GitHub delivers push, tag, release and pull request events
+	WebhookPath = "/webhook/git"
+
+	// shutdownRetryAfter is how long senders are asked to wait before redelivering during a shutdown
+	shutdownRetryAfter = 30 * time.Second
 )
 
+// syncJobAccepted is returned when a push has been queued for processing
+type syncJobAccepted struct {
+	JobID int64 `json:"job_id"`
+}
+
 type WebhookHandler struct {
 	webhookSecret []byte
 	postService   *application.PostService
+	filter        *FilterConfig
 }
 
-func NewWebhookHandler(postService *application.PostService) *WebhookHandler {
+func NewWebhookHandler(postService *application.PostService, filter *FilterConfig) *WebhookHandler {
 	secret := os.Getenv("WEBHOOK_SECRET")
 	if secret == "" {
 		panic("WEBHOOK_SECRET is not set")
@@ -27,39 +43,118 @@ func NewWebhookHandler(postService *application.PostService) *WebhookHandler {
 	return &WebhookHandler{
 		webhookSecret: []byte(secret),
 		postService:   postService,
+		filter:        filter,
 	}
 }
 
 func (h *WebhookHandler) RegisterRoutes(r chi.Router) {
-	r.Post("/webhook/git", h.HandleGitWebhook)
+	r.Post(WebhookPath, h.HandleGitWebhook)
 }
 
 func (h *WebhookHandler) HandleGitWebhook(w http.ResponseWriter, r *http.Request) {
+	// Work started now would be cancelled when the service closes, so leave the delivery for the next process
+	if h.postService.ShuttingDown() {
+		w.Header().Set("Retry-After", strconv.Itoa(int(shutdownRetryAfter.Seconds())))
+		http.Error(w, "Shutting down", http.StatusServiceUnavailable)
+		return
+	}
+
+	eventType := github.WebHookType(r)
+	deliveryID := github.DeliveryID(r)
 	payload, err := github.ValidatePayload(r, h.webhookSecret)
 	if err != nil {
+		// Almost always a secret that differs from WEBHOOK_SECRET
+		log.Warn().Err(err).Str("deliveryID", deliveryID).Msg("Invalid webhook payload or signature")
+		h.postService.RecordWebhookFailure(deliveryID, eventType, "Invalid payload or signature")
 		http.Error(w, "Invalid payload", http.StatusBadRequest)
 		return
 	}
+	h.postService.RecordWebhookDelivery(eventType)
+
+	// Pings and app installation changes are about the setup rather than content, so the filter doesn't apply
+	switch eventType {
+	case "ping":
+		h.handlePing(w, r, payload)
+		return
+	case "installation", "installation_repositories":
+		h.handleInstallation(w, r, eventType, payload)
+		return
+	}
+
+	if !h.filter.allowsEvent(eventType) {
+		h.reject(w, r, "Event type not accepted", log.Warn().Str("event", eventType))
+		return
+	}
 
-	event, err := github.ParseWebHook(github.WebHookType(r), payload)
+	event, err := github.ParseWebHook(eventType, payload)
 	if err != nil {
+		h.postService.RecordWebhookFailure(deliveryID, eventType, "Invalid event")
 		http.Error(w, "Invalid event", http.StatusBadRequest)
 		return
 	}
 
+	repository, branch := eventSource(event)
+	if !h.filter.allowsRepository(repository) {
+		h.reject(w, r, "Repository not accepted", log.Warn().Str("event", eventType).Str("repository", repository))
+		return
+	}
+	if branch != "" && !h.filter.allowsBranch(branch) {
+		h.reject(w, r, "Branch not accepted", log.Warn().Str("event", eventType).Str("repository", repository).Str("branch", branch))
+		return
+	}
+
+	var handle func() (int64, error)
 	switch evt := event.(type) {
 	case *github.PushEvent:
-		// PostService uses its own lifecycle context, not the request context
-		// This allows workers to continue after the HTTP response is sent
-		err = h.postService.HandlePushEvent(evt)
+		handle = func() (int64, error) { return h.postService.HandlePushEvent(evt) }
+	case *github.CreateEvent:
+		handle = func() (int64, error) { return h.postService.HandleCreateEvent(evt) }
+	case *github.ReleaseEvent:
+		handle = func() (int64, error) { return h.postService.HandleReleaseEvent(evt) }
+	case *github.PullRequestEvent:
+		handle = func() (int64, error) { return h.postService.HandlePullRequestEvent(evt) }
+	default:
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	// GitHub redelivers with the original delivery ID, which is only handled once
+	claimed, err := h.postService.ClaimDelivery(r.Context(), deliveryID)
+	if err != nil {
+		h.postService.RecordWebhookFailure(deliveryID, eventType, "Failed to claim delivery: "+err.Error())
+		http.Error(w, "Error handling event", http.StatusInternalServerError)
+		return
 	}
+	if !claimed {
+		log.Info().Str("deliveryID", deliveryID).Msg("Skipping duplicate webhook delivery")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	// PostService uses its own lifecycle context, not the request context
+	// This allows workers to continue after the HTTP response is sent
+	jobID, err := handle()
 	if err != nil {
+		h.postService.ReleaseDelivery(r.Context(), deliveryID)
+		h.postService.RecordWebhookFailure(deliveryID, eventType, err.Error())
 		http.Error(w, "Error handling event", http.StatusInternalServerError)
 		return
 	}
 
 	// Respond immediately - post processing happens asynchronously
-	w.WriteHeader(http.StatusNoContent)
-}
+	if jobID == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
 
+	w.Header().Set("Location", fmt.Sprintf("/api/sync/jobs/%d", jobID))
+	httpx.RespondJSON(w, r, http.StatusAccepted, syncJobAccepted{JobID: jobID})
+}
 
+// reject refuses a validly signed delivery the filter doesn't accept, logging it with the given fields
+func (h *WebhookHandler) reject(w http.ResponseWriter, r *http.Request, reason string, entry *zerolog.Event) {
+	deliveryID := github.DeliveryID(r)
+	entry.Str("deliveryID", deliveryID).Str("reason", reason).Msg("Rejected webhook delivery")
+	h.postService.RecordWebhookFailure(deliveryID, github.WebHookType(r), reason)
+	http.Error(w, reason, http.StatusForbidden)
+}
@@ -0,0 +1,28 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dfryer1193/goblog/blog/application"
+)
+
+func TestHandleGitWebhook_ShuttingDown(t *testing.T) {
+	postService := &application.PostService{}
+	postService.BeginShutdown()
+	h := &WebhookHandler{webhookSecret: []byte("secret"), postService: postService, filter: &FilterConfig{}}
+
+	req := httptest.NewRequest(http.MethodPost, WebhookPath, strings.NewReader(`{}`))
+	req.Header.Set("X-GitHub-Event", "push")
+	rec := httptest.NewRecorder()
+	h.HandleGitWebhook(rec, req)
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "30" {
+		t.Errorf("Retry-After = %q, want %q", got, "30")
+	}
+}